@@ -18,7 +18,7 @@ import (
 	"github.com/sipeed/picoclaw/pkg/providers"
 )
 
-func agentCmd(message, sessionKey, model string, debug bool) error {
+func agentCmd(message, sessionKey, model string, debug, dryRun bool) error {
 	if sessionKey == "" {
 		sessionKey = "cli:default"
 	}
@@ -49,7 +49,12 @@ func agentCmd(message, sessionKey, model string, debug bool) error {
 
 	msgBus := bus.NewMessageBus()
 	defer msgBus.Close()
-	agentLoop := agent.NewAgentLoop(cfg, msgBus, provider)
+	var opts []agent.AgentLoopOption
+	if dryRun {
+		opts = append(opts, agent.WithDryRun())
+		fmt.Println("🧪 Dry run: requests are printed, no provider is called")
+	}
+	agentLoop := agent.NewAgentLoop(cfg, msgBus, provider, opts...)
 	defer agentLoop.Close()
 
 	// Print agent startup info (only for interactive mode)
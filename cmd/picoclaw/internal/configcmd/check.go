@@ -0,0 +1,48 @@
+package configcmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sipeed/picoclaw/cmd/picoclaw/internal"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func newCheckCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Report every problem in config.json",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return configCheckCmd(internal.GetConfigPath(), cmd.OutOrStdout())
+		},
+	}
+
+	return cmd
+}
+
+// configCheckCmd validates the config at path and lists all problems on w.
+// It returns an error when the file is missing, unreadable or has problems.
+func configCheckCmd(path string, w io.Writer) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("cannot read config: %w", err)
+	}
+	cfg, err := config.ReadConfig(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	problems, _ := cfg.Validate()
+	if len(problems) == 0 {
+		fmt.Fprintf(w, "%s: OK\n", path)
+		return nil
+	}
+	fmt.Fprintf(w, "%s: %d problem(s)\n", path, len(problems))
+	for _, p := range problems {
+		fmt.Fprintf(w, "  - %s\n", p)
+	}
+	return fmt.Errorf("config has %d problem(s)", len(problems))
+}
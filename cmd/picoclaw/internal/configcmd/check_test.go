@@ -0,0 +1,35 @@
+package configcmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCheckCmd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	var out bytes.Buffer
+	assert.Error(t, configCheckCmd(path, &out), "a missing file is an error")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{}`), 0o600))
+	out.Reset()
+	require.NoError(t, configCheckCmd(path, &out))
+	assert.Contains(t, out.String(), "OK")
+
+	data := `{
+		"channels": {"telegram": {"enabled": true}, "discord": {"enabled": true}},
+		"heartbeat": {"enabled": true, "interval": 1}
+	}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	out.Reset()
+	assert.Error(t, configCheckCmd(path, &out))
+	assert.Contains(t, out.String(), "3 problem(s)")
+	assert.Contains(t, out.String(), "channels.telegram is enabled but token is empty")
+	assert.Contains(t, out.String(), "channels.discord is enabled but token is empty")
+	assert.Contains(t, out.String(), "heartbeat.interval must be at least 5")
+}
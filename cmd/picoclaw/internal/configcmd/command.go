@@ -0,0 +1,20 @@
+// Package configcmd implements the "picoclaw config" command. It is not named
+// config to avoid clashing with pkg/config.
+package configcmd
+
+import "github.com/spf13/cobra"
+
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newCheckCommand())
+
+	return cmd
+}
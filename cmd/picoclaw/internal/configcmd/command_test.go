@@ -0,0 +1,25 @@
+package configcmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfigCommand(t *testing.T) {
+	cmd := NewConfigCommand()
+
+	require.NotNil(t, cmd)
+
+	assert.Equal(t, "config", cmd.Use)
+	assert.Equal(t, "Inspect the configuration", cmd.Short)
+
+	assert.Nil(t, cmd.Run)
+	assert.NotNil(t, cmd.RunE)
+
+	require.True(t, cmd.HasSubCommands())
+	subcommands := cmd.Commands()
+	require.Len(t, subcommands, 1)
+	assert.Equal(t, "check", subcommands[0].Name())
+}
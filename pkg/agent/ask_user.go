@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// pendingQuestionTTL bounds how long an ask_user question waits for an
+// answer. Later messages are treated as new requests.
+const pendingQuestionTTL = 24 * time.Hour
+
+// answerPendingQuestion consumes the session's pending ask_user question,
+// if any, and returns the user message rewritten as the answer to it. The
+// message is returned unchanged when nothing is pending or the question
+// has expired.
+func (al *AgentLoop) answerPendingQuestion(opts processOptions) string {
+	if al.state == nil || isEphemeralSession(opts.SessionKey) {
+		return opts.UserMessage
+	}
+	q, ok := al.state.GetPendingQuestion(opts.SessionKey)
+	if !ok {
+		return opts.UserMessage
+	}
+	if err := al.state.SetPendingQuestion(opts.SessionKey, nil); err != nil {
+		logger.WarnCF("agent", "Failed to clear pending question", map[string]any{
+			"session_key": opts.SessionKey,
+			"error":       err.Error(),
+		})
+	}
+	if time.Since(q.AskedAt) > pendingQuestionTTL {
+		return opts.UserMessage
+	}
+
+	logger.InfoCF("agent", "Resuming after clarifying question", map[string]any{
+		"session_key": opts.SessionKey,
+	})
+	return fmt.Sprintf(
+		"[System: answer] You asked the user: %q\nTheir answer: %s\n\n"+
+			"Continue the task using this answer.",
+		q.Question, opts.UserMessage,
+	)
+}
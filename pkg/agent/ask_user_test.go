@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/state"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// askingProvider asks a clarifying question on the first turn and answers
+// on the next.
+type askingProvider struct {
+	calls    int
+	lastUser string
+}
+
+func (p *askingProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.calls++
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			p.lastUser = messages[i].Content
+			break
+		}
+	}
+	if p.calls == 1 {
+		return &providers.LLMResponse{
+			ToolCalls: []providers.ToolCall{{
+				ID:        "call_1",
+				Name:      "ask_user",
+				Arguments: map[string]any{"question": "Which environment, staging or production?"},
+			}},
+		}, nil
+	}
+	return &providers.LLMResponse{Content: "Deployed to staging"}, nil
+}
+
+func (p *askingProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
+func newAskUserTestLoop(t *testing.T, provider providers.LLMProvider) *AgentLoop {
+	t.Helper()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	al.RegisterTool(tools.NewAskUserTool(al.state))
+	return al
+}
+
+func TestProcessMessage_AskUserPausesAndResumes(t *testing.T) {
+	provider := &askingProvider{}
+	al := newAskUserTestLoop(t, provider)
+	helper := testHelper{al: al}
+
+	msg := bus.InboundMessage{
+		Channel:  "telegram",
+		SenderID: "user1",
+		ChatID:   "chat1",
+		Content:  "deploy the app",
+	}
+
+	response := helper.executeAndGetResponse(t, context.Background(), msg)
+	if response != "Which environment, staging or production?" {
+		t.Fatalf("expected the question as the reply, got %q", response)
+	}
+	if provider.calls != 1 {
+		t.Fatalf("expected the turn to end after ask_user, got %d LLM calls", provider.calls)
+	}
+
+	msg.Content = "staging"
+	response = helper.executeAndGetResponse(t, context.Background(), msg)
+	if response != "Deployed to staging" {
+		t.Fatalf("expected resumed turn to finish, got %q", response)
+	}
+	if !strings.Contains(provider.lastUser, "Which environment") || !strings.Contains(provider.lastUser, "staging") {
+		t.Errorf("expected the answer to carry the question, got %q", provider.lastUser)
+	}
+
+	// The question is consumed by the answer.
+	msg.Content = "and check the logs"
+	helper.executeAndGetResponse(t, context.Background(), msg)
+	if provider.lastUser != "and check the logs" {
+		t.Errorf("expected a plain message after the answer, got %q", provider.lastUser)
+	}
+}
+
+func TestAnswerPendingQuestion_Expired(t *testing.T) {
+	al := newAskUserTestLoop(t, &simpleMockProvider{response: "ok"})
+	opts := processOptions{SessionKey: "agent:main:telegram:direct:1", UserMessage: "staging"}
+
+	err := al.state.SetPendingQuestion(opts.SessionKey, &state.PendingQuestion{
+		Question: "Which environment?",
+		AskedAt:  time.Now().Add(-pendingQuestionTTL - time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := al.answerPendingQuestion(opts); got != "staging" {
+		t.Errorf("expected expired question to be ignored, got %q", got)
+	}
+	if _, ok := al.state.GetPendingQuestion(opts.SessionKey); ok {
+		t.Error("expected expired question to be cleared")
+	}
+}
@@ -0,0 +1,254 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+func TestForceCompression_DropRatio(t *testing.T) {
+	tests := []struct {
+		ratio       float64
+		wantDropped int
+	}{
+		{0, 5}, // unset: default 0.5
+		{0.25, 2},
+		{0.75, 7},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("ratio_%g", tt.ratio), func(t *testing.T) {
+			cfg := &config.Config{
+				Agents: config.AgentsConfig{
+					Defaults: config.AgentDefaults{
+						Workspace: t.TempDir(),
+						Model:     "test-model",
+						MaxTokens: 4096,
+					},
+				},
+				Session: config.SessionConfig{CompressionDropRatio: tt.ratio},
+			}
+			al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "ok"})
+			agent := al.registry.GetDefaultAgent()
+			sessionKey := "agent:main:telegram:direct:1"
+
+			// System prompt, a 10-message conversation and the triggering message.
+			history := []providers.Message{{Role: "system", Content: "system"}}
+			for i := range 10 {
+				role := "user"
+				if i%2 == 1 {
+					role = "assistant"
+				}
+				history = append(history, providers.Message{Role: role, Content: fmt.Sprintf("msg %d", i)})
+			}
+			history = append(history, providers.Message{Role: "user", Content: "trigger"})
+			agent.Sessions.SetHistory(sessionKey, history)
+
+			al.forceCompression(agent, sessionKey)
+
+			got := agent.Sessions.GetHistory(sessionKey)
+			if want := len(history) - tt.wantDropped; len(got) != want {
+				t.Fatalf("history len = %d, want %d", len(got), want)
+			}
+			if !strings.Contains(got[0].Content, fmt.Sprintf("dropped %d oldest messages", tt.wantDropped)) {
+				t.Errorf("system note = %q", got[0].Content)
+			}
+			if got[1].Content != fmt.Sprintf("msg %d", tt.wantDropped) {
+				t.Errorf("first kept message = %q, want msg %d", got[1].Content, tt.wantDropped)
+			}
+			if got[len(got)-1].Content != "trigger" {
+				t.Errorf("last message = %q, want trigger", got[len(got)-1].Content)
+			}
+		})
+	}
+}
+
+// geminiToolCall builds a stored tool call the way runLLMIteration saves a
+// Gemini 3 call, with its thought signature.
+func geminiToolCall(id, signature string) providers.ToolCall {
+	return providers.ToolCall{
+		ID:   id,
+		Type: "function",
+		Name: "web_search",
+		Function: &providers.FunctionCall{
+			Name:             "web_search",
+			Arguments:        `{"query":"q"}`,
+			ThoughtSignature: signature,
+		},
+		ExtraContent:     &providers.ExtraContent{Google: &providers.GoogleExtra{ThoughtSignature: signature}},
+		ThoughtSignature: signature,
+	}
+}
+
+// checkGeminiHistory reports what Gemini 3 would reject in a history: a
+// conversation not starting with a user message, a function call without
+// its thought signature or results, or a result without its call.
+func checkGeminiHistory(history []providers.Message) error {
+	var pending map[string]bool
+	for i, m := range history {
+		if m.Role == "system" {
+			continue
+		}
+		if pending == nil && m.Role != "user" {
+			return fmt.Errorf("message %d: conversation starts with %s, want user", i, m.Role)
+		}
+		if m.Role == "tool" {
+			if !pending[m.ToolCallID] {
+				return fmt.Errorf("message %d: tool result %q has no matching call", i, m.ToolCallID)
+			}
+			delete(pending, m.ToolCallID)
+			continue
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("message %d: calls %v have no results", i, pending)
+		}
+		pending = map[string]bool{}
+		for _, tc := range m.ToolCalls {
+			if tc.Function == nil || tc.Function.ThoughtSignature == "" {
+				return fmt.Errorf("message %d: call %q lost its thought signature", i, tc.ID)
+			}
+			pending[tc.ID] = true
+		}
+	}
+	return nil
+}
+
+// geminiTurnHistory is a finished turn followed by a tool-call turn still in
+// progress, as stored in the session when the context window overflows.
+func geminiTurnHistory() []providers.Message {
+	return []providers.Message{
+		{Role: "user", Content: "hello"},
+		{Role: "assistant", Content: "hi"},
+		{Role: "user", Content: "research this"},
+		{Role: "assistant", ToolCalls: []providers.ToolCall{geminiToolCall("c1", "sig-1")}},
+		{Role: "tool", ToolCallID: "c1", Content: "result 1"},
+		{Role: "assistant", ToolCalls: []providers.ToolCall{geminiToolCall("c2", "sig-2")}},
+		{Role: "tool", ToolCallID: "c2", Content: "result 2"},
+		{Role: "assistant", ToolCalls: []providers.ToolCall{geminiToolCall("c3", "sig-3")}},
+		{Role: "tool", ToolCallID: "c3", Content: "result 3"},
+		{Role: "assistant", ToolCalls: []providers.ToolCall{geminiToolCall("c4", "sig-4")}},
+		{Role: "tool", ToolCallID: "c4", Content: "result 4"},
+	}
+}
+
+func TestForceCompression_KeepsGeminiToolCallRounds(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "test-model",
+				MaxTokens: 4096,
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "ok"})
+	agent := al.registry.GetDefaultAgent()
+	sessionKey := "agent:main:telegram:direct:1"
+	agent.Sessions.SetHistory(sessionKey, geminiTurnHistory())
+
+	// Half of the 9-message middle puts the cut inside the turn in progress:
+	// its user message stays and only the whole c1 round is dropped.
+	al.forceCompression(agent, sessionKey)
+
+	got := agent.Sessions.GetHistory(sessionKey)
+	if err := checkGeminiHistory(got); err != nil {
+		t.Fatalf("compressed history is invalid for Gemini: %v\n%+v", err, got)
+	}
+	if got[1].Content != "research this" {
+		t.Errorf("message 1 = %+v, want the user message that started the turn", got[1])
+	}
+	if len(got[2].ToolCalls) != 1 || got[2].ToolCalls[0].ID != "c2" {
+		t.Errorf("message 2 = %+v, want the c2 round to be kept whole", got[2])
+	}
+	if !strings.Contains(got[0].Content, "dropped 3 oldest messages") {
+		t.Errorf("system note = %q", got[0].Content)
+	}
+
+	messages := agent.ContextBuilder.BuildMessages(got, "", "", nil, "telegram", "1", "user1", "")
+	if err := checkGeminiHistory(messages); err != nil {
+		t.Errorf("messages sent after compression are invalid for Gemini: %v", err)
+	}
+}
+
+func TestSummarizeSession_KeepsWholeToolCallTurn(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "test-model",
+				MaxTokens: 4096,
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "summary"})
+	agent := al.registry.GetDefaultAgent()
+	sessionKey := "agent:main:telegram:direct:1"
+	history := append(geminiTurnHistory(), providers.Message{Role: "assistant", Content: "done"})
+	agent.Sessions.SetHistory(sessionKey, history)
+
+	if _, err := al.summarizeSessionWithContext(context.Background(), agent, sessionKey); err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+
+	// The last 4 messages start mid-turn, so the whole turn is kept.
+	got := agent.Sessions.GetHistory(sessionKey)
+	if len(got) != len(history)-2 || got[0].Content != "research this" {
+		t.Fatalf("kept history = %+v, want the turn from %q on", got, "research this")
+	}
+	if err := checkGeminiHistory(got); err != nil {
+		t.Errorf("kept history is invalid for Gemini: %v", err)
+	}
+}
+
+// geminiStrictProvider overflows the context window once, then fails any
+// request Gemini 3 would reject.
+type geminiStrictProvider struct{ calls int }
+
+func (p *geminiStrictProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	defs []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.calls++
+	if p.calls == 1 {
+		return nil, fmt.Errorf("context_length_exceeded")
+	}
+	if err := checkGeminiHistory(messages); err != nil {
+		return nil, fmt.Errorf("INVALID_ARGUMENT: %w", err)
+	}
+	return &providers.LLMResponse{Content: "recovered"}, nil
+}
+
+func (p *geminiStrictProvider) GetDefaultModel() string { return "gemini-3-pro" }
+
+func TestAgentLoop_GeminiHistorySurvivesCompression(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &geminiStrictProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	sessionKey := "test-session-gemini"
+	history := append(geminiTurnHistory(), providers.Message{Role: "assistant", Content: "done"})
+	al.registry.GetDefaultAgent().Sessions.SetHistory(sessionKey, history)
+
+	response, err := al.ProcessDirectWithChannel(context.Background(), "and now?", sessionKey, "test", "test-chat")
+	if err != nil {
+		t.Fatalf("ProcessDirectWithChannel: %v", err)
+	}
+	if response != "recovered" || provider.calls != 2 {
+		t.Errorf("response = %q after %d calls, want recovered after 2", response, provider.calls)
+	}
+}
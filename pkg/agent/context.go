@@ -26,6 +26,10 @@ type ContextBuilder struct {
 	toolDiscoveryBM25  bool
 	toolDiscoveryRegex bool
 
+	// maxHistoryMessages caps how many history messages BuildMessages sends;
+	// 0 sends the whole history.
+	maxHistoryMessages int
+
 	// Cache for system prompt to avoid rebuilding on every call.
 	// This fixes issue #607: repeated reprocessing of the entire context.
 	// The cache auto-invalidates when workspace source files change (mtime check).
@@ -51,6 +55,13 @@ func (cb *ContextBuilder) WithToolDiscovery(useBM25, useRegex bool) *ContextBuil
 	return cb
 }
 
+// WithMaxHistoryMessages limits the history sent per request to the most
+// recent max messages. The session keeps the full history.
+func (cb *ContextBuilder) WithMaxHistoryMessages(max int) *ContextBuilder {
+	cb.maxHistoryMessages = max
+	return cb
+}
+
 func getGlobalConfigDir() string {
 	if home := os.Getenv(config.EnvHome); home != "" {
 		return home
@@ -564,7 +575,7 @@ func (cb *ContextBuilder) BuildMessages(
 			"preview": preview,
 		})
 
-	history = sanitizeHistoryForProvider(history)
+	history = limitHistory(sanitizeHistoryForProvider(history), cb.maxHistoryMessages)
 
 	// Single system message containing all context — compatible with all providers.
 	// SystemParts enables cache-aware adapters to set per-block cache_control;
@@ -593,6 +604,45 @@ func (cb *ContextBuilder) BuildMessages(
 	return messages
 }
 
+// limitHistory returns the last max messages of history, moved forward to
+// start at a user message so no tool result or tool-call turn is cut off from
+// its counterpart. When the window holds no user message (one long tool-call
+// turn), it starts at the last user message instead, so the request that
+// turn answers is always kept. max <= 0 returns history unchanged.
+func limitHistory(history []providers.Message, max int) []providers.Message {
+	if max <= 0 || len(history) <= max {
+		return history
+	}
+	cut := len(history) - max
+	start := -1
+	for i := cut; i < len(history); i++ {
+		if history[i].Role == "user" {
+			start = i
+			break
+		}
+	}
+	for i := cut - 1; start < 0 && i >= 0; i-- {
+		if history[i].Role == "user" {
+			start = i
+		}
+	}
+	if start < 0 {
+		start = cut
+	}
+	logger.DebugCF("agent", "Limited history sent to provider", map[string]any{
+		"total": len(history),
+		"sent":  len(history) - start,
+	})
+	return sanitizeHistoryForProvider(history[start:])
+}
+
+// isTurnBoundary reports whether history can be cut just before msg: it is a
+// user message or a plain assistant reply. Cutting anywhere else separates
+// tool results from the assistant message that requested them.
+func isTurnBoundary(msg providers.Message) bool {
+	return msg.Role == "user" || (msg.Role == "assistant" && len(msg.ToolCalls) == 0)
+}
+
 func sanitizeHistoryForProvider(history []providers.Message) []providers.Message {
 	if len(history) == 0 {
 		return history
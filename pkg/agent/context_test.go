@@ -281,3 +281,49 @@ func TestSanitizeHistoryForProvider_PartialToolResultsInMiddle(t *testing.T) {
 	}
 	assertRoles(t, result, "user", "assistant", "tool", "assistant", "user", "user", "assistant", "tool", "assistant")
 }
+
+func TestLimitHistory(t *testing.T) {
+	history := []providers.Message{
+		msg("user", "first"),
+		msg("assistant", "one"),
+		msg("user", "second"),
+		assistantWithTools("A"),
+		toolResult("A"),
+		msg("assistant", "two"),
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		if got := limitHistory(history, 0); len(got) != len(history) {
+			t.Fatalf("expected full history, got %v", roles(got))
+		}
+	})
+
+	t.Run("starts at a user message", func(t *testing.T) {
+		got := limitHistory(history, 5)
+		assertRoles(t, got, "user", "assistant", "tool", "assistant")
+		if got[0].Content != "second" {
+			t.Errorf("expected window to start at the second user message, got %q", got[0].Content)
+		}
+	})
+
+	t.Run("keeps the last user message of a long tool turn", func(t *testing.T) {
+		got := limitHistory(history, 2)
+		assertRoles(t, got, "user", "assistant", "tool", "assistant")
+	})
+}
+
+func TestBuildMessages_MaxHistoryMessages(t *testing.T) {
+	cb := NewContextBuilder(t.TempDir()).WithMaxHistoryMessages(2)
+	history := []providers.Message{
+		msg("user", "old question"),
+		msg("assistant", "old answer"),
+		msg("user", "recent question"),
+		msg("assistant", "recent answer"),
+	}
+
+	messages := cb.BuildMessages(history, "", "new question", nil, "cli", "direct", "", "")
+	assertRoles(t, messages, "system", "user", "assistant", "user")
+	if messages[1].Content != "recent question" || messages[3].Content != "new question" {
+		t.Errorf("unexpected window: %+v", messages[1:])
+	}
+}
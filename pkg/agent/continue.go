@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/state"
+)
+
+// iterationLimitResponse replaces the default empty response when a turn
+// stopped at max_tool_iterations and was checkpointed for /continue.
+const iterationLimitResponse = "I reached the limit of %d steps before finishing this task. " +
+	"Send /continue to pick up where I stopped."
+
+// checkpointTask records the turn's task so /continue can resume it. The
+// work done so far is already in the session history. It reports whether a
+// checkpoint was stored.
+func (al *AgentLoop) checkpointTask(opts processOptions, iterations int) bool {
+	if al.state == nil || opts.NoHistory || isEphemeralSession(opts.SessionKey) {
+		return false
+	}
+	task := opts.ResumedTask
+	if task == "" {
+		task = opts.UserMessage
+	}
+	err := al.state.SetPendingTask(opts.SessionKey, &state.PendingTask{
+		Task:       task,
+		Iterations: iterations,
+		StoppedAt:  time.Now(),
+	})
+	if err != nil {
+		logger.WarnCF("agent", "Failed to checkpoint task at iteration limit", map[string]any{
+			"session_key": opts.SessionKey,
+			"error":       err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// continueTask resumes the session's checkpointed task, if any, by running a
+// new turn that restates the original request. resumed is false when there
+// is nothing to continue.
+func (al *AgentLoop) continueTask(
+	ctx context.Context,
+	agent *AgentInstance,
+	opts processOptions,
+) (reply string, resumed bool, err error) {
+	if al.state == nil {
+		return "", false, nil
+	}
+	task, ok := al.state.GetPendingTask(opts.SessionKey)
+	if !ok {
+		return "", false, nil
+	}
+	if err := al.state.SetPendingTask(opts.SessionKey, nil); err != nil {
+		return "", true, err
+	}
+
+	logger.InfoCF("agent", "Continuing task stopped at iteration limit", map[string]any{
+		"agent_id":    agent.ID,
+		"session_key": opts.SessionKey,
+		"iterations":  task.Iterations,
+	})
+
+	opts.UserMessage = fmt.Sprintf(
+		"[System: continue] You stopped after reaching the step limit while working on this request:\n%s\n\n"+
+			"Continue from where you left off. Do not repeat steps already completed above.",
+		task.Task,
+	)
+	opts.ResumedTask = task.Task
+	reply, err = al.runAgentLoop(ctx, agent, opts)
+	return reply, true, err
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// endlessToolProvider keeps requesting tool calls until finish is set.
+type endlessToolProvider struct {
+	finish   bool
+	lastUser string
+	calls    int
+}
+
+func (p *endlessToolProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.calls++
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			p.lastUser = messages[i].Content
+			break
+		}
+	}
+	if p.finish {
+		return &providers.LLMResponse{Content: "Migration finished"}, nil
+	}
+	return &providers.LLMResponse{
+		ToolCalls: []providers.ToolCall{{Name: "mock_custom", Arguments: map[string]any{}}},
+	}, nil
+}
+
+func (p *endlessToolProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
+func TestProcessMessage_ContinueAfterIterationLimit(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 2,
+			},
+		},
+	}
+	provider := &endlessToolProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	al.RegisterTool(&mockCustomTool{})
+	helper := testHelper{al: al}
+
+	msg := bus.InboundMessage{
+		Channel:  "telegram",
+		SenderID: "user1",
+		ChatID:   "chat1",
+		Content:  "migrate the database",
+	}
+
+	response := helper.executeAndGetResponse(t, context.Background(), msg)
+	if !strings.Contains(response, "/continue") {
+		t.Fatalf("expected /continue hint at the iteration limit, got %q", response)
+	}
+
+	provider.finish = true
+	msg.Content = "/continue"
+	response = helper.executeAndGetResponse(t, context.Background(), msg)
+	if response != "Migration finished" {
+		t.Fatalf("expected resumed turn to finish, got %q", response)
+	}
+	if !strings.Contains(provider.lastUser, "migrate the database") {
+		t.Errorf("expected the original request to be restated, got %q", provider.lastUser)
+	}
+
+	response = helper.executeAndGetResponse(t, context.Background(), msg)
+	if response != "Nothing to continue." {
+		t.Errorf("expected nothing left to continue, got %q", response)
+	}
+}
+
+func TestProcessMessage_AttemptBudgetSpansIterations(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+				LLMAttemptBudget:  3,
+			},
+		},
+	}
+	provider := &endlessToolProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	al.RegisterTool(&mockCustomTool{})
+
+	_, err := al.processMessage(context.Background(), bus.InboundMessage{
+		Channel:  "telegram",
+		SenderID: "user1",
+		ChatID:   "chat1",
+		Content:  "migrate the database",
+	})
+	if !errors.Is(err, providers.ErrAttemptBudgetExhausted) {
+		t.Fatalf("processMessage() error = %v, want ErrAttemptBudgetExhausted", err)
+	}
+	if provider.calls != 3 {
+		t.Errorf("LLM calls = %d, want 3 (the budget, not one per iteration)", provider.calls)
+	}
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// recordCost adds the price of one LLM response to the spend of the session
+// and the user, using the pricing of model in model_list. Like tool quotas,
+// only turns with a user identity are metered; cron, heartbeat and CLI runs
+// are not.
+func (al *AgentLoop) recordCost(model string, opts processOptions, usage *providers.UsageInfo) {
+	if usage == nil || opts.UserID == "" || al.state == nil {
+		return
+	}
+	mc, err := al.GetConfig().GetModelConfig(model)
+	if err != nil || mc.Pricing == nil {
+		return
+	}
+	cost := mc.Pricing.Cost(usage.PromptTokens, usage.CompletionTokens)
+	if cost <= 0 {
+		return
+	}
+	if err := al.state.AddCost(opts.SessionKey, opts.UserID, cost); err != nil {
+		// Failing to persist the spend should not block the user.
+		logger.WarnCF("agent", "Failed to record LLM cost", map[string]any{
+			"session_key": opts.SessionKey,
+			"user":        opts.UserID,
+			"error":       err.Error(),
+		})
+	}
+}
+
+// withCostRecorder returns a child context that bills LLM calls made on
+// behalf of the turn described by opts — subagents, summaries, summarize_url
+// and tool result truncation — to its session and user. The recorder reports
+// an error once the turn's spending limit is reached.
+func (al *AgentLoop) withCostRecorder(ctx context.Context, opts processOptions) context.Context {
+	return tools.WithToolUsageRecorder(ctx, func(model string, usage *providers.UsageInfo) error {
+		al.recordCost(model, opts, usage)
+		if msg := al.checkCostBudget(opts); msg != "" {
+			return errors.New(msg)
+		}
+		return nil
+	})
+}
+
+// recordUsage bills resp to the turn that ctx belongs to, if any. Summaries
+// are kept even when this exhausts the budget; the next turn is refused.
+func recordUsage(ctx context.Context, model string, resp *providers.LLMResponse) {
+	if record := tools.ToolUsageRecorder(ctx); record != nil && resp != nil {
+		_ = record(model, resp.Usage)
+	}
+}
+
+// checkCostBudget enforces session.max_cost and user.daily_cost_limit. It
+// returns "" when the next LLM call may proceed, or the message to reply with
+// instead of calling the model.
+func (al *AgentLoop) checkCostBudget(opts processOptions) string {
+	cfg := al.GetConfig()
+	if cfg == nil || opts.UserID == "" || al.state == nil {
+		return ""
+	}
+
+	if limit := cfg.Session.MaxCost; limit > 0 {
+		if spent := al.state.GetSessionCost(opts.SessionKey); spent >= limit {
+			logger.WarnCF("agent", "Session cost limit reached", map[string]any{
+				"session_key": opts.SessionKey,
+				"spent":       spent,
+				"limit":       limit,
+			})
+			return fmt.Sprintf(
+				"This conversation has reached its spending limit ($%.2f). Use /clear to start a new one.",
+				limit,
+			)
+		}
+	}
+
+	if limit := cfg.User.DailyCostLimit; limit > 0 {
+		if spent := al.state.GetUserDailyCost(opts.UserID); spent >= limit {
+			logger.WarnCF("agent", "Daily user cost limit reached", map[string]any{
+				"user":  opts.UserID,
+				"spent": spent,
+				"limit": limit,
+			})
+			return fmt.Sprintf("You have reached today's spending limit ($%.2f). Please try again tomorrow.", limit)
+		}
+	}
+
+	return ""
+}
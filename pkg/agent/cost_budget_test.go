@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+func TestCostBudget_SessionLimit(t *testing.T) {
+	al, cfg, _, _, cleanup := newTestAgentLoop(t)
+	defer cleanup()
+
+	cfg.ModelList = []config.ModelConfig{{
+		ModelName: "test-model",
+		Model:     "openai/test-model",
+		Pricing:   &config.ModelPricing{InputPerMillion: 2, OutputPerMillion: 10},
+	}}
+	cfg.Session.MaxCost = 0.01
+
+	opts := processOptions{SessionKey: "session-1", UserID: "telegram:123"}
+	if msg := al.checkCostBudget(opts); msg != "" {
+		t.Fatalf("checkCostBudget() before any spend = %q", msg)
+	}
+
+	// 1000 prompt + 500 completion tokens = $0.002 + $0.005.
+	usage := &providers.UsageInfo{PromptTokens: 1000, CompletionTokens: 500}
+	al.recordCost("test-model", opts, usage)
+	if got := al.state.GetSessionCost("session-1"); got < 0.00699 || got > 0.00701 {
+		t.Fatalf("session cost = %v, want 0.007", got)
+	}
+	if msg := al.checkCostBudget(opts); msg != "" {
+		t.Fatalf("checkCostBudget() under the limit = %q", msg)
+	}
+
+	al.recordCost("test-model", opts, usage)
+	msg := al.checkCostBudget(opts)
+	if !strings.Contains(msg, "spending limit") {
+		t.Fatalf("checkCostBudget() over the limit = %q, want a spending limit message", msg)
+	}
+
+	// Other sessions have their own budget.
+	if msg := al.checkCostBudget(processOptions{SessionKey: "session-2", UserID: "telegram:123"}); msg != "" {
+		t.Errorf("checkCostBudget() for another session = %q", msg)
+	}
+}
+
+func TestCostBudget_UserDailyLimit(t *testing.T) {
+	al, cfg, _, _, cleanup := newTestAgentLoop(t)
+	defer cleanup()
+
+	cfg.User.DailyCostLimit = 1
+
+	if err := al.state.AddCost("session-1", "telegram:123", 0.6); err != nil {
+		t.Fatalf("AddCost failed: %v", err)
+	}
+	if err := al.state.AddCost("session-2", "telegram:123", 0.6); err != nil {
+		t.Fatalf("AddCost failed: %v", err)
+	}
+
+	msg := al.checkCostBudget(processOptions{SessionKey: "session-3", UserID: "telegram:123"})
+	if !strings.Contains(msg, "today's spending limit") {
+		t.Errorf("checkCostBudget() = %q, want the daily limit message", msg)
+	}
+	if msg := al.checkCostBudget(processOptions{SessionKey: "session-3", UserID: "discord:456"}); msg != "" {
+		t.Errorf("checkCostBudget() for another user = %q", msg)
+	}
+	// Runs without a user identity (cron, heartbeat) are not metered.
+	if msg := al.checkCostBudget(processOptions{SessionKey: "session-3"}); msg != "" {
+		t.Errorf("checkCostBudget() without a user = %q", msg)
+	}
+}
+
+func TestRecordCost_SkipsUnpricedModels(t *testing.T) {
+	al, cfg, _, _, cleanup := newTestAgentLoop(t)
+	defer cleanup()
+
+	cfg.ModelList = []config.ModelConfig{{ModelName: "test-model", Model: "openai/test-model"}}
+
+	opts := processOptions{SessionKey: "session-1", UserID: "telegram:123"}
+	al.recordCost("test-model", opts, &providers.UsageInfo{PromptTokens: 1000, CompletionTokens: 1000})
+	al.recordCost("unknown-model", opts, &providers.UsageInfo{PromptTokens: 1000, CompletionTokens: 1000})
+	al.recordCost("test-model", opts, nil)
+
+	if got := al.state.GetSessionCost("session-1"); got != 0 {
+		t.Errorf("session cost = %v, want 0", got)
+	}
+}
+
+func TestCostRecorder_BillsTurnAndReportsLimit(t *testing.T) {
+	al, cfg, _, _, cleanup := newTestAgentLoop(t)
+	defer cleanup()
+
+	cfg.ModelList = []config.ModelConfig{{
+		ModelName: "test-model",
+		Model:     "openai/test-model",
+		Pricing:   &config.ModelPricing{InputPerMillion: 2, OutputPerMillion: 10},
+	}}
+	cfg.Session.MaxCost = 0.01
+
+	opts := processOptions{SessionKey: "session-1", UserID: "telegram:123"}
+	record := tools.ToolUsageRecorder(al.withCostRecorder(context.Background(), opts))
+	if record == nil {
+		t.Fatal("withCostRecorder() did not set a recorder")
+	}
+
+	usage := &providers.UsageInfo{PromptTokens: 1000, CompletionTokens: 500}
+	if err := record("test-model", usage); err != nil {
+		t.Fatalf("record() under the limit = %v", err)
+	}
+	if got := al.state.GetSessionCost("session-1"); got < 0.00699 || got > 0.00701 {
+		t.Fatalf("session cost = %v, want 0.007", got)
+	}
+	if err := record("test-model", usage); err == nil || !strings.Contains(err.Error(), "spending limit") {
+		t.Errorf("record() over the limit = %v, want the spending limit message", err)
+	}
+}
+
+func TestSummaryChatFunc_BillsTheServingCandidate(t *testing.T) {
+	al, cfg, _, _, cleanup := newTestAgentLoop(t)
+	defer cleanup()
+
+	cfg.ModelList = []config.ModelConfig{
+		{ModelName: "primary", Model: "openai/primary", Pricing: &config.ModelPricing{InputPerMillion: 1}},
+		{ModelName: "backup", Model: "openai/backup", Pricing: &config.ModelPricing{InputPerMillion: 10}},
+	}
+	agent := &AgentInstance{
+		Provider: &rateLimitedModelProvider{limited: "primary"},
+		Model:    "primary",
+		Candidates: []providers.FallbackCandidate{
+			{Provider: "openai", Model: "primary"},
+			{Provider: "openai", Model: "backup"},
+		},
+	}
+	chat := al.summaryChatFunc(agent, nil, providers.NewFallbackChain(providers.NewCooldownTracker(0)))
+
+	opts := processOptions{SessionKey: "session-1", UserID: "telegram:123"}
+	ctx := al.withCostRecorder(context.Background(), opts)
+	if _, err := chat(ctx, []providers.Message{{Role: "user", Content: "page"}}, nil); err != nil {
+		t.Fatalf("chat() error = %v", err)
+	}
+	// 1000 prompt tokens at the backup's $10 per million.
+	if got := al.state.GetSessionCost("session-1"); got < 0.00999 || got > 0.01001 {
+		t.Errorf("session cost = %v, want 0.01 (priced as backup)", got)
+	}
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// WithDryRun makes the loop build every LLM request as usual, messages and
+// tool definitions included, but log it and answer with the formatted request
+// instead of calling the provider. Dry-run turns are not saved to the session.
+func WithDryRun() AgentLoopOption {
+	return func(al *AgentLoop) {
+		al.dryRun = true
+	}
+}
+
+// dryRunResponse logs the request runLLMIteration would send and returns a
+// canned response describing it, with no tool calls so the turn ends.
+func dryRunResponse(
+	agent *AgentInstance,
+	model string,
+	messages []providers.Message,
+	toolDefs []providers.ToolDefinition,
+) *providers.LLMResponse {
+	messagesJSON := formatMessagesForLog(messages)
+	toolsJSON := formatToolsForLog(toolDefs)
+	logger.InfoCF("agent", "Dry run: LLM request not sent",
+		map[string]any{
+			"agent_id":      agent.ID,
+			"model":         model,
+			"messages_json": messagesJSON,
+			"tools_json":    toolsJSON,
+		})
+
+	return &providers.LLMResponse{
+		Content: fmt.Sprintf(
+			"[dry run] %d messages and %d tools for %s; no provider was called.\n\nMessages: %s\n\nTools: %s",
+			len(messages), len(toolDefs), model, messagesJSON, toolsJSON,
+		),
+		FinishReason: "stop",
+	}
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestDryRun_BuildsRequestWithoutCallingProvider(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &countingMockProvider{response: "real answer"}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider, WithDryRun())
+	al.RegisterTool(&mockCustomTool{})
+
+	const sessionKey = "cli:dry-run"
+	response, err := al.ProcessDirectWithChannel(context.Background(), "plan my trip", sessionKey, "cli", "direct")
+	if err != nil {
+		t.Fatalf("ProcessDirectWithChannel: %v", err)
+	}
+
+	if provider.calls != 0 {
+		t.Errorf("provider calls = %d, want 0 in a dry run", provider.calls)
+	}
+	for _, want := range []string{"[dry run]", "Role: user", "plan my trip", "Name: mock_custom"} {
+		if !strings.Contains(response, want) {
+			t.Errorf("response does not contain %q:\n%s", want, response)
+		}
+	}
+	if history := al.registry.GetDefaultAgent().Sessions.GetHistory(sessionKey); len(history) != 0 {
+		t.Errorf("dry run saved %d messages to the session, want none", len(history))
+	}
+}
@@ -0,0 +1,21 @@
+package agent
+
+// heartbeatSessionKey is the reserved session used by ProcessHeartbeat.
+const heartbeatSessionKey = "heartbeat"
+
+// ephemeralSessionKeys lists reserved session keys for stateless runs.
+// Nothing is read from or written to the session store for these keys.
+var ephemeralSessionKeys = map[string]struct{}{
+	heartbeatSessionKey: {},
+}
+
+// isEphemeralSession reports whether sessionKey is transient: an empty key
+// or a reserved key such as the heartbeat session. Ephemeral sessions never
+// accumulate history, and are skipped by summarization and compression.
+func isEphemeralSession(sessionKey string) bool {
+	if sessionKey == "" {
+		return true
+	}
+	_, ok := ephemeralSessionKeys[sessionKey]
+	return ok
+}
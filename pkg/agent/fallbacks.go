@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/fileutil"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/routing"
+)
+
+// candidateNames formats candidates as "provider/model" in chain order.
+func candidateNames(candidates []providers.FallbackCandidate) []string {
+	names := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		names = append(names, c.Provider+"/"+c.Model)
+	}
+	return names
+}
+
+// agentCandidates returns the fallback candidates of agent. /switch fallbacks
+// replaces them while other sessions are running, so reads go through al.mu.
+func (al *AgentLoop) agentCandidates(agent *AgentInstance) []providers.FallbackCandidate {
+	al.mu.RLock()
+	defer al.mu.RUnlock()
+	return agent.Candidates
+}
+
+// setAgentFallbacks replaces the fallback chain of agent with models and
+// re-resolves its candidates. /switch fallbacks has already checked that each
+// model is in model_list. The change is saved to the config file when one is
+// set; the result reports whether that worked. Otherwise it only lasts until
+// the agents are rebuilt.
+func (al *AgentLoop) setAgentFallbacks(agent *AgentInstance, models []string) bool {
+	cfg := al.GetConfig()
+	candidates := providers.ResolveCandidatesWithLookup(
+		providers.ModelConfig{Primary: agent.Model, Fallbacks: models},
+		cfg.Agents.Defaults.Provider,
+		modelListLookup(cfg),
+	)
+	applyCandidateSettings(cfg, candidates)
+
+	al.mu.Lock()
+	agent.Fallbacks = models
+	agent.Candidates = candidates
+	al.mu.Unlock()
+
+	if al.configPath == "" {
+		return false
+	}
+	if err := saveAgentFallbacks(al.configPath, agent.ID, models); err != nil {
+		logger.WarnCF("agent", "Failed to save fallback chain; keeping it in memory",
+			map[string]any{
+				"agent_id": agent.ID,
+				"path":     al.configPath,
+				"error":    err.Error(),
+			})
+		return false
+	}
+	return true
+}
+
+// saveAgentFallbacks writes models as the fallback chain of agentID to the
+// config file at path. Agents listed in agents.list get their own chain;
+// the implicit default agent updates agents.defaults.model_fallbacks.
+//
+// Only that field of the raw JSON is edited. Loading the file as a Config
+// would write env overrides, resolved file:// keys and expanded api_keys
+// entries back to disk.
+func saveAgentFallbacks(path, agentID string, models []string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	var root map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&root); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	if root == nil {
+		root = map[string]any{}
+	}
+
+	agents := jsonObject(root, "agents")
+	if agents == nil {
+		return fmt.Errorf("agents is not an object")
+	}
+	fallbacks := make([]any, len(models))
+	for i, m := range models {
+		fallbacks[i] = m
+	}
+
+	id := routing.NormalizeAgentID(agentID)
+	listed := false
+	list, _ := agents["list"].([]any)
+	for _, item := range list {
+		ac, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if acID, _ := ac["id"].(string); routing.NormalizeAgentID(acID) != id {
+			continue
+		}
+		switch model := ac["model"].(type) {
+		case map[string]any:
+			model["fallbacks"] = fallbacks
+		case string:
+			ac["model"] = map[string]any{"primary": model, "fallbacks": fallbacks}
+		default:
+			ac["model"] = map[string]any{"fallbacks": fallbacks}
+		}
+		listed = true
+		break
+	}
+	if !listed {
+		defaults := jsonObject(agents, "defaults")
+		if defaults == nil {
+			return fmt.Errorf("agents.defaults is not an object")
+		}
+		defaults["model_fallbacks"] = fallbacks
+	}
+
+	out, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(path, out, 0o600)
+}
+
+// jsonObject returns the object stored under key in parent, creating it when
+// the key is missing. It returns nil when the key holds something else.
+func jsonObject(parent map[string]any, key string) map[string]any {
+	v, ok := parent[key]
+	if !ok || v == nil {
+		obj := map[string]any{}
+		parent[key] = obj
+		return obj
+	}
+	obj, _ := v.(map[string]any)
+	return obj
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveAgentFallbacks_EditsOnlyTheField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	original := `{
+  "agents": {
+    "defaults": {"model_name": "gpt-4o", "max_tokens": 8192},
+    "list": [
+      {"id": "main", "default": true},
+      {"id": "coder", "model": "claude-sonnet"}
+    ]
+  },
+  "model_list": [
+    {"model_name": "gpt-4o", "model": "openai/gpt-4o", "api_key": "file://openai.key", "api_keys": ["k2", "k3"]}
+  ]
+}`
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveAgentFallbacks(path, "coder", []string{"gpt-4o", "qwen"}); err != nil {
+		t.Fatalf("saveAgentFallbacks(coder) error = %v", err)
+	}
+	if err := saveAgentFallbacks(path, "implicit", []string{"qwen"}); err != nil {
+		t.Fatalf("saveAgentFallbacks(implicit) error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("saved config is not JSON: %v", err)
+	}
+	agents := got["agents"].(map[string]any)
+	coder := agents["list"].([]any)[1].(map[string]any)
+	if model, _ := json.Marshal(coder["model"]); string(model) != `{"fallbacks":["gpt-4o","qwen"],"primary":"claude-sonnet"}` {
+		t.Errorf("coder model = %s", model)
+	}
+	defaults := agents["defaults"].(map[string]any)
+	if fb, _ := json.Marshal(defaults["model_fallbacks"]); string(fb) != `["qwen"]` {
+		t.Errorf("defaults.model_fallbacks = %s", fb)
+	}
+
+	// Everything else is written back as it was: no resolved keys, no
+	// expanded api_keys entries, no filled-in defaults.
+	saved := string(data)
+	for _, want := range []string{`"file://openai.key"`, `"max_tokens": 8192`} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("saved config lost %s:\n%s", want, saved)
+		}
+	}
+	for _, unwanted := range []string{"__key_", "workspace", "channels"} {
+		if strings.Contains(saved, unwanted) {
+			t.Errorf("saved config gained %q:\n%s", unwanted, saved)
+		}
+	}
+	if n := len(got["model_list"].([]any)); n != 1 {
+		t.Errorf("model_list has %d entries, want 1", n)
+	}
+}
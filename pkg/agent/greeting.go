@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// maybeGreet sends the channel's configured greeting when a user sends their
+// first direct message. Contacts are tracked in state by canonical ID, so a
+// user is greeted at most once. Users who already have session history
+// (e.g. from before the greeting was configured) are recorded silently.
+func (al *AgentLoop) maybeGreet(ctx context.Context, msg bus.InboundMessage, agent *AgentInstance, sessionKey string) {
+	if al.channelManager == nil || al.state == nil || msg.Peer.Kind != "direct" {
+		return
+	}
+	greeting := al.channelManager.GetGreeting(msg.Channel)
+	if greeting == "" {
+		return
+	}
+
+	contactID := senderContactID(msg)
+
+	first, err := al.state.MarkContact(contactID)
+	if err != nil {
+		logger.WarnCF("agent", "Failed to persist known contact", map[string]any{
+			"contact": contactID,
+			"error":   err.Error(),
+		})
+	}
+	if !first || len(agent.Sessions.GetHistory(sessionKey)) > 0 {
+		return
+	}
+
+	logger.InfoCF("agent", "Greeting new contact", map[string]any{
+		"channel": msg.Channel,
+		"contact": contactID,
+	})
+	al.bus.PublishOutbound(ctx, bus.OutboundMessage{
+		Channel: msg.Channel,
+		ChatID:  msg.ChatID,
+		Content: greeting,
+	})
+}
+
+// senderContactID identifies the human behind an inbound message across
+// channels: the canonical identity when one is linked, else "channel:senderID".
+func senderContactID(msg bus.InboundMessage) string {
+	if msg.Sender.CanonicalID != "" {
+		return msg.Sender.CanonicalID
+	}
+	if msg.SenderID == "" {
+		return ""
+	}
+	return msg.Channel + ":" + msg.SenderID
+}
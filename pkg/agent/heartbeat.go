@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/heartbeat"
+)
+
+// runHeartbeat triggers a heartbeat of agent immediately for the given chat,
+// as the /heartbeat command does. tasks replaces the HEARTBEAT.md task list
+// in the agent's workspace when set. The reply is empty when the heartbeat
+// found nothing to do.
+func (al *AgentLoop) runHeartbeat(
+	ctx context.Context,
+	agent *AgentInstance,
+	tasks, channel, chatID string,
+) (string, error) {
+	if tasks == "" {
+		data, err := os.ReadFile(filepath.Join(agent.Workspace, "HEARTBEAT.md"))
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("read HEARTBEAT.md: %w", err)
+		}
+		tasks = strings.TrimSpace(string(data))
+	}
+	if tasks == "" {
+		return "", fmt.Errorf("HEARTBEAT.md is empty or missing; pass tasks as /heartbeat <tasks>")
+	}
+
+	response, err := al.processHeartbeat(ctx, agent, heartbeat.FormatPrompt(tasks, time.Now()), channel, chatID)
+	if err != nil {
+		return "", err
+	}
+	if response = strings.TrimSpace(response); response == "HEARTBEAT_OK" {
+		return "", nil
+	}
+	return response, nil
+}
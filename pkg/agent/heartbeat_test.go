@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestRunHeartbeat_ReadsAgentWorkspace(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &recordingProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+
+	// The routed agent's workspace, not the default one, holds the tasks.
+	agent := al.GetRegistry().GetDefaultAgent()
+	agent.Workspace = t.TempDir()
+	if err := os.WriteFile(filepath.Join(agent.Workspace, "HEARTBEAT.md"), []byte("- water the plants"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := al.runHeartbeat(context.Background(), agent, "", "telegram", "chat1"); err != nil {
+		t.Fatalf("runHeartbeat() error = %v", err)
+	}
+	var prompt string
+	for _, m := range provider.lastMessages {
+		if m.Role == "user" {
+			prompt = m.Content
+		}
+	}
+	if !strings.Contains(prompt, "water the plants") {
+		t.Errorf("heartbeat prompt = %q, want the agent's HEARTBEAT.md tasks", prompt)
+	}
+}
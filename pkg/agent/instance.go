@@ -7,7 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/media"
@@ -40,6 +42,11 @@ type AgentInstance struct {
 	Subagents                 *config.SubagentsConfig
 	SkillsFilter              []string
 	Candidates                []providers.FallbackCandidate
+	CiteSources               bool
+	Modes                     map[string]string
+	// AllowedChannels lists the channels the agent may send to with the
+	// message tool; nil means any. See CanSendTo.
+	AllowedChannels []string
 
 	// Router is non-nil when model routing is configured and the light model
 	// was successfully resolved. It scores each incoming message and decides
@@ -48,6 +55,33 @@ type AgentInstance struct {
 	// LightCandidates holds the resolved provider candidates for the light model.
 	// Pre-computed at agent creation to avoid repeated model_list lookups at runtime.
 	LightCandidates []providers.FallbackCandidate
+
+	// LoopThreshold is the number of identical tool calls within one turn
+	// that counts as a loop; 0 disables loop detection.
+	LoopThreshold int
+	// EscalationModel and EscalationCandidates replace the active model for
+	// the rest of a turn once a loop is detected. Both are empty when no
+	// escalation model is configured, in which case the agent is only nudged.
+	EscalationModel      string
+	EscalationCandidates []providers.FallbackCandidate
+	// ReuseRepeatedCalls answers an immediately repeated call to a pure tool
+	// with its previous result; see repeatCache.
+	ReuseRepeatedCalls bool
+
+	// SummaryProvider and SummaryModel serve session summarization when a
+	// summary_model is configured; both are empty otherwise and Provider and
+	// Model are used. See summaryTarget.
+	SummaryProvider providers.LLMProvider
+	SummaryModel    string
+	// SummaryModelName is the model_list name of summary_model, used to
+	// price its calls.
+	SummaryModelName string
+
+	// NoToolCalling is set when the model is configured with supports_tools
+	// false. Tool definitions are then withheld from its requests. It stays
+	// false under tool_protocol "prompt", where Provider is wrapped in a
+	// PromptToolProvider that offers the tools through the system prompt.
+	NoToolCalling bool
 }
 
 // NewAgentInstance creates an agent instance from config.
@@ -71,11 +105,19 @@ func NewAgentInstance(
 	allowWritePaths := compilePatterns(cfg.Tools.AllowWritePaths)
 
 	toolsRegistry := tools.NewToolRegistry()
+	toolsRegistry.SetCallTimeout(time.Duration(cfg.Tools.CallTimeout) * time.Second)
+	if agentCfg != nil {
+		toolsRegistry.Disable(agentCfg.DisabledTools...)
+	}
 
 	if cfg.Tools.IsToolEnabled("read_file") {
 		maxReadFileSize := cfg.Tools.ReadFile.MaxReadFileSize
 		toolsRegistry.Register(tools.NewReadFileTool(workspace, readRestrict, maxReadFileSize, allowReadPaths))
 	}
+	if cfg.Tools.IsToolEnabled("read_document") {
+		maxChars := cfg.Tools.ReadDocument.MaxChars
+		toolsRegistry.Register(tools.NewReadDocumentTool(workspace, readRestrict, maxChars, allowReadPaths))
+	}
 	if cfg.Tools.IsToolEnabled("write_file") {
 		toolsRegistry.Register(tools.NewWriteFileTool(workspace, restrict, allowWritePaths))
 	}
@@ -104,21 +146,30 @@ func NewAgentInstance(
 	contextBuilder := NewContextBuilder(workspace).WithToolDiscovery(
 		mcpDiscoveryActive && cfg.Tools.MCP.Discovery.UseBM25,
 		mcpDiscoveryActive && cfg.Tools.MCP.Discovery.UseRegex,
-	)
+	).WithMaxHistoryMessages(defaults.MaxContextMessages)
 
 	agentID := routing.DefaultAgentID
 	agentName := ""
 	var subagents *config.SubagentsConfig
 	var skillsFilter []string
+	citeSources := false
+	var modes map[string]string
+	var allowedChannels []string
 
 	if agentCfg != nil {
 		agentID = routing.NormalizeAgentID(agentCfg.ID)
 		agentName = agentCfg.Name
 		subagents = agentCfg.Subagents
 		skillsFilter = agentCfg.Skills
+		citeSources = agentCfg.CiteSources
+		modes = agentCfg.Modes
+		allowedChannels = agentCfg.AllowedChannels
 	}
 
 	maxIter := defaults.MaxToolIterations
+	if agentCfg != nil && agentCfg.MaxToolIterations != nil && *agentCfg.MaxToolIterations > 0 {
+		maxIter = *agentCfg.MaxToolIterations
+	}
 	if maxIter == 0 {
 		maxIter = 20
 	}
@@ -134,8 +185,14 @@ func NewAgentInstance(
 	}
 
 	var thinkingLevelStr string
+	noToolCalling := false
 	if mc, err := cfg.GetModelConfig(model); err == nil {
 		thinkingLevelStr = mc.ThinkingLevel
+		if mc.ToolProtocol == config.ToolProtocolPrompt {
+			provider = providers.NewPromptToolProvider(provider)
+		} else {
+			noToolCalling = !mc.ToolCallingSupported()
+		}
 	}
 	thinkingLevel := parseThinkingLevel(thinkingLevelStr)
 
@@ -154,47 +211,10 @@ func NewAgentInstance(
 		Primary:   model,
 		Fallbacks: fallbacks,
 	}
-	resolveFromModelList := func(raw string) (string, bool) {
-		ensureProtocol := func(model string) string {
-			model = strings.TrimSpace(model)
-			if model == "" {
-				return ""
-			}
-			if strings.Contains(model, "/") {
-				return model
-			}
-			return "openai/" + model
-		}
-
-		raw = strings.TrimSpace(raw)
-		if raw == "" {
-			return "", false
-		}
-
-		if cfg != nil {
-			if mc, err := cfg.GetModelConfig(raw); err == nil && mc != nil && strings.TrimSpace(mc.Model) != "" {
-				return ensureProtocol(mc.Model), true
-			}
-
-			for i := range cfg.ModelList {
-				fullModel := strings.TrimSpace(cfg.ModelList[i].Model)
-				if fullModel == "" {
-					continue
-				}
-				if fullModel == raw {
-					return ensureProtocol(fullModel), true
-				}
-				_, modelID := providers.ExtractProtocol(fullModel)
-				if modelID == raw {
-					return ensureProtocol(fullModel), true
-				}
-			}
-		}
-
-		return "", false
-	}
+	resolveFromModelList := modelListLookup(cfg)
 
 	candidates := providers.ResolveCandidatesWithLookup(modelCfg, defaults.Provider, resolveFromModelList)
+	applyCandidateSettings(cfg, candidates)
 
 	// Model routing setup: pre-resolve light model candidates at creation time
 	// to avoid repeated model_list lookups on every incoming message.
@@ -209,12 +229,41 @@ func NewAgentInstance(
 				Threshold:  rc.Threshold,
 			})
 			lightCandidates = resolved
+			applyCandidateSettings(cfg, lightCandidates)
 		} else {
 			log.Printf("routing: light_model %q not found in model_list — routing disabled for agent %q",
 				rc.LightModel, agentID)
 		}
 	}
 
+	// Loop detection: pre-resolve the escalation model the same way as the
+	// light model above.
+	var loopThreshold int
+	var escalationModel string
+	var escalationCandidates []providers.FallbackCandidate
+	if ld := defaults.LoopDetection; ld != nil && ld.Enabled {
+		loopThreshold = ld.Threshold
+		if loopThreshold <= 0 {
+			loopThreshold = defaultLoopThreshold
+		}
+		if ld.EscalationModel != "" {
+			escalationCfg := providers.ModelConfig{Primary: ld.EscalationModel}
+			resolved := providers.ResolveCandidatesWithLookup(escalationCfg, defaults.Provider, resolveFromModelList)
+			if len(resolved) > 0 {
+				escalationModel = ld.EscalationModel
+				escalationCandidates = resolved
+				applyCandidateSettings(cfg, escalationCandidates)
+			} else {
+				log.Printf("loop_detection: escalation_model %q not found in model_list — nudging only for agent %q",
+					ld.EscalationModel, agentID)
+			}
+		}
+	}
+
+	reuseRepeatedCalls := defaults.LoopDetection != nil && defaults.LoopDetection.ReuseRepeatedCalls
+
+	summaryProvider, summaryModel, summaryModelName := resolveSummaryModel(agentCfg, defaults, cfg, agentID)
+
 	return &AgentInstance{
 		ID:                        agentID,
 		Name:                      agentName,
@@ -235,9 +284,66 @@ func NewAgentInstance(
 		Subagents:                 subagents,
 		SkillsFilter:              skillsFilter,
 		Candidates:                candidates,
+		CiteSources:               citeSources,
+		Modes:                     modes,
+		AllowedChannels:           allowedChannels,
 		Router:                    router,
 		LightCandidates:           lightCandidates,
+		LoopThreshold:             loopThreshold,
+		EscalationModel:           escalationModel,
+		EscalationCandidates:      escalationCandidates,
+		ReuseRepeatedCalls:        reuseRepeatedCalls,
+		SummaryProvider:           summaryProvider,
+		SummaryModel:              summaryModel,
+		SummaryModelName:          summaryModelName,
+		NoToolCalling:             noToolCalling,
+	}
+}
+
+// resolveSummaryModel creates the provider for the agent's summary_model, or
+// the defaults' one, and returns it with the model ID to request and the
+// model_name it came from. It returns nil and "" when none is set or the
+// model cannot be resolved, so summarization stays on the primary model.
+func resolveSummaryModel(
+	agentCfg *config.AgentConfig,
+	defaults *config.AgentDefaults,
+	cfg *config.Config,
+	agentID string,
+) (provider providers.LLMProvider, modelID, name string) {
+	name = strings.TrimSpace(defaults.SummaryModel)
+	if agentCfg != nil && strings.TrimSpace(agentCfg.SummaryModel) != "" {
+		name = strings.TrimSpace(agentCfg.SummaryModel)
+	}
+	if name == "" {
+		return nil, "", ""
+	}
+	mc, err := cfg.GetModelConfig(name)
+	if err != nil {
+		log.Printf("summary_model: %v — summarizing with the primary model for agent %q", err, agentID)
+		return nil, "", ""
+	}
+	provider, modelID, err = providers.CreateProviderFromConfig(mc)
+	if err != nil {
+		log.Printf("summary_model: %q: %v — summarizing with the primary model for agent %q", name, err, agentID)
+		return nil, "", ""
 	}
+	return provider, modelID, name
+}
+
+// CanSendTo reports whether the agent may send messages to channel.
+func (a *AgentInstance) CanSendTo(channel string) bool {
+	if a.AllowedChannels == nil {
+		return true
+	}
+	return slices.Contains(a.AllowedChannels, channel)
+}
+
+// summaryTarget returns the provider and model summarization calls use.
+func (a *AgentInstance) summaryTarget() (providers.LLMProvider, string) {
+	if a.SummaryProvider != nil && a.SummaryModel != "" {
+		return a.SummaryProvider, a.SummaryModel
+	}
+	return a.Provider, a.Model
 }
 
 // resolveAgentWorkspace determines the workspace directory for an agent.
@@ -305,8 +411,12 @@ func mediaTempDirPattern() string {
 	return "^" + regexp.QuoteMeta(filepath.Clean(media.TempDir())) + "(?:" + sep + "|$)"
 }
 
-// Close releases resources held by the agent's session store.
+// Close releases resources held by the agent's session store and its
+// summary provider.
 func (a *AgentInstance) Close() error {
+	if sp, ok := a.SummaryProvider.(providers.StatefulProvider); ok {
+		sp.Close()
+	}
 	if a.Sessions != nil {
 		return a.Sessions.Close()
 	}
@@ -351,3 +461,100 @@ func expandHome(path string) string {
 	}
 	return path
 }
+
+// modelListLookup resolves a model name or alias through cfg.ModelList into
+// a protocol-qualified model for candidate resolution.
+func modelListLookup(cfg *config.Config) func(string) (string, bool) {
+	return func(raw string) (string, bool) {
+		ensureProtocol := func(model string) string {
+			model = strings.TrimSpace(model)
+			if model == "" {
+				return ""
+			}
+			if strings.Contains(model, "/") {
+				return model
+			}
+			return "openai/" + model
+		}
+
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			return "", false
+		}
+
+		if cfg != nil {
+			if mc, err := cfg.GetModelConfig(raw); err == nil && mc != nil && strings.TrimSpace(mc.Model) != "" {
+				return ensureProtocol(mc.Model), true
+			}
+
+			for i := range cfg.ModelList {
+				fullModel := strings.TrimSpace(cfg.ModelList[i].Model)
+				if fullModel == "" {
+					continue
+				}
+				if fullModel == raw {
+					return ensureProtocol(fullModel), true
+				}
+				_, modelID := providers.ExtractProtocol(fullModel)
+				if modelID == raw {
+					return ensureProtocol(fullModel), true
+				}
+			}
+		}
+
+		return "", false
+	}
+}
+
+// applyCandidateSettings copies each model_list entry's request_timeout and
+// cooldown_seconds onto the matching fallback candidate, so the fallback chain
+// gives up on a hung model after the same time its HTTP client would and
+// keeps a flaky model out for as long as configured.
+func applyCandidateSettings(cfg *config.Config, candidates []providers.FallbackCandidate) {
+	if cfg == nil {
+		return
+	}
+	for i := range candidates {
+		key := providers.ModelKey(candidates[i].Provider, candidates[i].Model)
+		for _, mc := range cfg.ModelList {
+			if mc.RequestTimeout <= 0 && mc.CooldownSeconds <= 0 {
+				continue
+			}
+			if modelConfigKey(mc) == key {
+				candidates[i].Timeout = time.Duration(mc.RequestTimeout) * time.Second
+				candidates[i].Cooldown = time.Duration(mc.CooldownSeconds) * time.Second
+				break
+			}
+		}
+	}
+}
+
+// candidateModelName returns the model_name of the first model_list entry
+// that resolves to provider/model, or "" when none does. It maps the
+// candidate the fallback chain answered with back to its pricing.
+func candidateModelName(cfg *config.Config, provider, model string) string {
+	if cfg == nil {
+		return ""
+	}
+	key := providers.ModelKey(provider, model)
+	for _, mc := range cfg.ModelList {
+		if modelConfigKey(mc) == key {
+			return mc.ModelName
+		}
+	}
+	return ""
+}
+
+// modelConfigKey returns the provider/model key mc resolves to, matching the
+// candidates built by ResolveCandidatesWithLookup.
+func modelConfigKey(mc config.ModelConfig) string {
+	model := strings.TrimSpace(mc.Model)
+	if model != "" && !strings.Contains(model, "/") {
+		model = "openai/" + model
+	}
+	ref := providers.ParseModelRef(model, "")
+	if ref == nil {
+		return ""
+	}
+	return providers.ModelKey(ref.Provider, ref.Model)
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/media"
+	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
 func TestNewAgentInstance_UsesDefaultsTemperatureAndMaxTokens(t *testing.T) {
@@ -43,6 +47,78 @@ func TestNewAgentInstance_UsesDefaultsTemperatureAndMaxTokens(t *testing.T) {
 	}
 }
 
+func TestNewAgentInstance_MaxToolIterationsOverride(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxToolIterations: 5,
+			},
+		},
+	}
+	provider := &mockProvider{}
+
+	inherited := NewAgentInstance(&config.AgentConfig{ID: "main"}, &cfg.Agents.Defaults, cfg, provider)
+	if inherited.MaxIterations != 5 {
+		t.Errorf("MaxIterations = %d, want default 5", inherited.MaxIterations)
+	}
+
+	iterations := 40
+	research := NewAgentInstance(
+		&config.AgentConfig{ID: "research", Workspace: t.TempDir(), MaxToolIterations: &iterations},
+		&cfg.Agents.Defaults, cfg, provider,
+	)
+	if research.MaxIterations != 40 {
+		t.Errorf("MaxIterations = %d, want override 40", research.MaxIterations)
+	}
+}
+
+func TestNewAgentInstance_SummaryModel(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxToolIterations: 5,
+			},
+		},
+		ModelList: []config.ModelConfig{
+			{ModelName: "cheap", Model: "openai/gpt-4o-mini", APIKey: "k1"},
+			{ModelName: "cheaper", Model: "openai/gpt-4.1-nano", APIKey: "k2"},
+		},
+	}
+	provider := &mockProvider{}
+
+	unset := NewAgentInstance(nil, &cfg.Agents.Defaults, cfg, provider)
+	if p, model := unset.summaryTarget(); p != provider || model != "test-model" {
+		t.Errorf("summaryTarget() = %T, %q, want the primary provider and model", p, model)
+	}
+
+	cfg.Agents.Defaults.SummaryModel = "cheap"
+	defaults := NewAgentInstance(nil, &cfg.Agents.Defaults, cfg, provider)
+	if p, model := defaults.summaryTarget(); p == provider || model != "gpt-4o-mini" {
+		t.Errorf("summaryTarget() = %T, %q, want a separate provider for gpt-4o-mini", p, model)
+	}
+
+	override := NewAgentInstance(
+		&config.AgentConfig{ID: "research", Workspace: t.TempDir(), SummaryModel: "cheaper"},
+		&cfg.Agents.Defaults, cfg, provider,
+	)
+	if _, model := override.summaryTarget(); model != "gpt-4.1-nano" {
+		t.Errorf("summaryTarget() model = %q, want the agent's gpt-4.1-nano", model)
+	}
+	if override.SummaryModelName != "cheaper" {
+		t.Errorf("SummaryModelName = %q, want cheaper for pricing", override.SummaryModelName)
+	}
+
+	cfg.Agents.Defaults.SummaryModel = "missing"
+	missing := NewAgentInstance(nil, &cfg.Agents.Defaults, cfg, provider)
+	if p, model := missing.summaryTarget(); p != provider || model != "test-model" {
+		t.Errorf("summaryTarget() = %T, %q, want the primary model for an unknown summary_model", p, model)
+	}
+}
+
 func TestNewAgentInstance_DefaultsTemperatureWhenZero(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "agent-instance-test-*")
 	if err != nil {
@@ -165,6 +241,34 @@ func TestNewAgentInstance_ResolveCandidatesFromModelListAlias(t *testing.T) {
 	}
 }
 
+func TestNewAgentInstance_CandidateTimeoutFromModelList(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:      t.TempDir(),
+				ModelName:      "primary",
+				ModelFallbacks: []string{"backup"},
+			},
+		},
+		ModelList: []config.ModelConfig{
+			{ModelName: "primary", Model: "openai/gpt-4o", RequestTimeout: 30},
+			{ModelName: "backup", Model: "groq/llama-3.3-70b"},
+		},
+	}
+
+	agent := NewAgentInstance(nil, &cfg.Agents.Defaults, cfg, &mockProvider{})
+
+	if len(agent.Candidates) != 2 {
+		t.Fatalf("len(Candidates) = %d, want 2", len(agent.Candidates))
+	}
+	if got := agent.Candidates[0].Timeout; got != 30*time.Second {
+		t.Errorf("primary timeout = %v, want 30s", got)
+	}
+	if got := agent.Candidates[1].Timeout; got != 0 {
+		t.Errorf("backup timeout = %v, want 0 (chain default)", got)
+	}
+}
+
 func TestNewAgentInstance_AllowsMediaTempDirForReadListAndExec(t *testing.T) {
 	workspace := t.TempDir()
 	mediaDir := media.TempDir()
@@ -246,3 +350,91 @@ func TestNewAgentInstance_AllowsMediaTempDirForReadListAndExec(t *testing.T) {
 		t.Fatalf("exec output missing media content: %s", execResult.ForLLM)
 	}
 }
+
+func TestAgentDisabledTools(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxToolIterations: 5,
+			},
+			List: []config.AgentConfig{
+				{ID: "main", Default: true, Workspace: t.TempDir()},
+				{ID: "kiosk", Workspace: t.TempDir(), DisabledTools: []string{"i2c", "spi"}},
+			},
+		},
+	}
+	cfg.Tools.I2C.Enabled = true
+	cfg.Tools.SPI.Enabled = true
+
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+
+	primary, ok := al.registry.GetAgent("main")
+	if !ok {
+		t.Fatal("main agent not found")
+	}
+	kiosk, ok := al.registry.GetAgent("kiosk")
+	if !ok {
+		t.Fatal("kiosk agent not found")
+	}
+	for _, name := range []string{"i2c", "spi"} {
+		if !slices.Contains(primary.Tools.List(), name) {
+			t.Errorf("main agent should have %s, got %v", name, primary.Tools.List())
+		}
+		if slices.Contains(kiosk.Tools.List(), name) {
+			t.Errorf("kiosk agent should not have %s, got %v", name, kiosk.Tools.List())
+		}
+	}
+}
+
+func TestMessageTool_AllowedChannels(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxToolIterations: 5,
+			},
+			List: []config.AgentConfig{
+				{ID: "main", Default: true, AllowedChannels: []string{"slack"}},
+				{ID: "readonly", Workspace: t.TempDir(), AllowedChannels: []string{}},
+			},
+		},
+	}
+	cfg.Tools.Message.Enabled = true
+	msgBus := bus.NewMessageBus()
+	al := NewAgentLoop(cfg, msgBus, &mockProvider{})
+
+	send := func(agentID, channel string) *tools.ToolResult {
+		agent, ok := al.GetRegistry().GetAgent(agentID)
+		if !ok {
+			t.Fatalf("agent %q not registered", agentID)
+		}
+		tool, ok := agent.Tools.Get("message")
+		if !ok {
+			t.Fatalf("agent %q has no message tool", agentID)
+		}
+		return tool.Execute(context.Background(), map[string]any{
+			"content": "hi", "channel": channel, "chat_id": "c1",
+		})
+	}
+
+	if res := send("main", "telegram"); !res.IsError || !strings.Contains(res.ForLLM, "not allowed") {
+		t.Errorf("send to telegram = %+v, want a not-allowed error", res)
+	}
+	if res := send("readonly", "slack"); !res.IsError {
+		t.Errorf("read-only agent sent a message: %+v", res)
+	}
+	if res := send("main", "slack"); res.IsError {
+		t.Fatalf("send to slack failed: %s", res.ForLLM)
+	}
+	select {
+	case msg := <-msgBus.OutboundChan():
+		if msg.Channel != "slack" {
+			t.Errorf("outbound channel = %q, want slack", msg.Channel)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("allowed send was not published")
+	}
+}
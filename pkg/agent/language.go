@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// languageNames maps common ISO 639-1 codes to the language name used in the
+// reply-language directive. Unknown values are passed through verbatim so
+// users can also write full names such as "Brazilian Portuguese".
+var languageNames = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"hi": "Hindi",
+	"id": "Indonesian",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"th": "Thai",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"vi": "Vietnamese",
+	"zh": "Chinese",
+}
+
+// languageName resolves a language code or name to a display name.
+func languageName(language string) string {
+	language = strings.TrimSpace(language)
+	if name, ok := languageNames[strings.ToLower(language)]; ok {
+		return name
+	}
+	return language
+}
+
+// applyReplyLanguage appends a reply-language directive to the system message
+// so the model answers in the pinned language regardless of input language.
+// It is a no-op when language is empty (auto) or there is no system message.
+func applyReplyLanguage(messages []providers.Message, language string) []providers.Message {
+	if language == "" || len(messages) == 0 || messages[0].Role != "system" {
+		return messages
+	}
+
+	directive := fmt.Sprintf(
+		"## Reply Language\nAlways respond in %s, regardless of the language the user writes in.",
+		languageName(language),
+	)
+	return appendSystemDirective(messages, directive)
+}
+
+// appendSystemDirective appends a section to the system message, keeping the
+// structured SystemParts in sync. The caller ensures messages[0] is the
+// system message.
+func appendSystemDirective(messages []providers.Message, directive string) []providers.Message {
+	messages[0].Content += "\n\n---\n\n" + directive
+	if len(messages[0].SystemParts) > 0 {
+		parts := make([]providers.ContentBlock, len(messages[0].SystemParts), len(messages[0].SystemParts)+1)
+		copy(parts, messages[0].SystemParts)
+		messages[0].SystemParts = append(parts, providers.ContentBlock{Type: "text", Text: directive})
+	}
+	return messages
+}
+
+// sessionReplyLanguage returns the pinned reply language for a session, or ""
+// when the session follows the user's language.
+func (al *AgentLoop) sessionReplyLanguage(sessionKey string) string {
+	if al.state == nil || sessionKey == "" {
+		return ""
+	}
+	return al.state.GetSessionLanguage(sessionKey)
+}
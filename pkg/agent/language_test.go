@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+func TestApplyReplyLanguage(t *testing.T) {
+	base := func() []providers.Message {
+		return []providers.Message{
+			{
+				Role:        "system",
+				Content:     "system prompt",
+				SystemParts: []providers.ContentBlock{{Type: "text", Text: "system prompt"}},
+			},
+			{Role: "user", Content: "hello"},
+		}
+	}
+
+	msgs := applyReplyLanguage(base(), "")
+	if msgs[0].Content != "system prompt" {
+		t.Fatalf("auto language should not modify system prompt, got %q", msgs[0].Content)
+	}
+
+	msgs = applyReplyLanguage(base(), "ja")
+	if !strings.Contains(msgs[0].Content, "Always respond in Japanese") {
+		t.Fatalf("system prompt missing directive: %q", msgs[0].Content)
+	}
+	if len(msgs[0].SystemParts) != 2 {
+		t.Fatalf("SystemParts len = %d, want 2", len(msgs[0].SystemParts))
+	}
+
+	msgs = applyReplyLanguage(base(), "Klingon")
+	if !strings.Contains(msgs[0].Content, "Always respond in Klingon") {
+		t.Fatalf("unknown language should pass through verbatim: %q", msgs[0].Content)
+	}
+}
@@ -7,27 +7,28 @@
 package agent
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
-	"unicode/utf8"
 
 	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/channels"
 	"github.com/sipeed/picoclaw/pkg/commands"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/identity"
 	"github.com/sipeed/picoclaw/pkg/logger"
 	"github.com/sipeed/picoclaw/pkg/media"
 	"github.com/sipeed/picoclaw/pkg/providers"
 	"github.com/sipeed/picoclaw/pkg/routing"
+	"github.com/sipeed/picoclaw/pkg/semaphore"
 	"github.com/sipeed/picoclaw/pkg/skills"
 	"github.com/sipeed/picoclaw/pkg/state"
 	"github.com/sipeed/picoclaw/pkg/tools"
@@ -42,15 +43,25 @@ type AgentLoop struct {
 	state          *state.Manager
 	running        atomic.Bool
 	summarizing    sync.Map
+	sessionErrors  sync.Map // session key -> *sessionErrorLog, for /diag errors
+	summaryBreaker *summaryBreaker
 	fallback       *providers.FallbackChain
+	llmLimiter     *semaphore.Semaphore // global_llm_concurrency; nil = unlimited
 	channelManager *channels.Manager
 	mediaStore     media.MediaStore
 	transcriber    voice.Transcriber
 	cmdRegistry    *commands.Registry
 	mcp            mcpRuntime
+	tokenEstimator TokenEstimator
+	dryRun         bool   // WithDryRun: build requests but never call providers
+	configPath     string // config file that runtime edits are saved to; "" keeps them in memory
+	modelTestMu    sync.Mutex
+	lastModelTest  time.Time // start of the last /models test run, for its cooldown
 	mu             sync.RWMutex
 	// Track active requests for safe provider cleanup
 	activeRequests sync.WaitGroup
+	// FlushSessions runs still writing; Close waits for them
+	flushes sync.WaitGroup
 }
 
 // processOptions configures how a message is processed
@@ -60,12 +71,18 @@ type processOptions struct {
 	ChatID            string   // Target chat ID for tool execution
 	SenderID          string   // Current sender ID for dynamic context
 	SenderDisplayName string   // Current sender display name for dynamic context
+	UserID            string   // Cross-channel user identity for per-user limits (empty for system callers)
 	UserMessage       string   // User message content (may include prefix)
+	ResumedTask       string   // Original request when this turn resumes a checkpointed task
 	Media             []string // media:// refs from inbound message
 	DefaultResponse   string   // Response when LLM returns empty
 	EnableSummary     bool     // Whether to trigger summarization
 	SendResponse      bool     // Whether to send response via bus
+	SendInterim       bool     // Whether to send content that accompanies tool calls before running them
 	NoHistory         bool     // If true, don't load session history (for heartbeat)
+	DryRun            bool     // Log LLM requests and answer with them instead of calling the provider
+	// Model is the model set on the matched binding; nil uses the agent's model.
+	Model *config.AgentModelConfig
 }
 
 const (
@@ -82,16 +99,10 @@ func NewAgentLoop(
 	cfg *config.Config,
 	msgBus *bus.MessageBus,
 	provider providers.LLMProvider,
+	opts ...AgentLoopOption,
 ) *AgentLoop {
 	registry := NewAgentRegistry(cfg, provider)
 
-	// Register shared tools to all agents
-	registerSharedTools(cfg, msgBus, registry, provider)
-
-	// Set up shared fallback chain
-	cooldown := providers.NewCooldownTracker()
-	fallbackChain := providers.NewFallbackChain(cooldown)
-
 	// Create state manager using default agent's workspace for channel recording
 	defaultAgent := registry.GetDefaultAgent()
 	var stateManager *state.Manager
@@ -99,27 +110,60 @@ func NewAgentLoop(
 		stateManager = state.NewManager(defaultAgent.Workspace)
 	}
 
+	// One limiter for the whole process bounds all in-flight LLM calls.
+	llmLimiter := semaphore.New(cfg.Agents.Defaults.GlobalLLMConcurrency)
+
+	// Set up shared fallback chain
+	fallbackChain := newFallbackChain(cfg)
+
 	al := &AgentLoop{
-		bus:         msgBus,
-		cfg:         cfg,
-		registry:    registry,
-		state:       stateManager,
-		summarizing: sync.Map{},
-		fallback:    fallbackChain,
-		cmdRegistry: commands.NewRegistry(commands.BuiltinDefinitions()),
+		bus:            msgBus,
+		cfg:            cfg,
+		registry:       registry,
+		state:          stateManager,
+		summarizing:    sync.Map{},
+		summaryBreaker: newSummaryBreaker(summaryBreakerThreshold, summaryBreakerCooldown),
+		fallback:       fallbackChain,
+		llmLimiter:     llmLimiter,
+		cmdRegistry:    commands.NewRegistry(commands.BuiltinDefinitions()),
+		tokenEstimator: defaultTokenEstimator(provider),
+	}
+
+	// Register shared tools to all agents
+	al.registerSharedTools(cfg, msgBus, registry, provider, stateManager, llmLimiter, fallbackChain)
+
+	for _, opt := range opts {
+		opt(al)
 	}
 
 	return al
 }
 
+// newFallbackChain builds the shared fallback chain with the attempt cap,
+// total time budget and base cooldown from the agent defaults.
+func newFallbackChain(cfg *config.Config) *providers.FallbackChain {
+	cooldown := time.Duration(cfg.Agents.Defaults.FallbackCooldownSeconds) * time.Second
+	fc := providers.NewFallbackChain(providers.NewCooldownTracker(cooldown))
+	fc.SetLimits(
+		cfg.Agents.Defaults.FallbackMaxAttempts,
+		time.Duration(cfg.Agents.Defaults.FallbackBudgetSeconds)*time.Second,
+	)
+	return fc
+}
+
 // registerSharedTools registers tools that are shared across all agents (web, message, spawn).
-func registerSharedTools(
+func (al *AgentLoop) registerSharedTools(
 	cfg *config.Config,
 	msgBus *bus.MessageBus,
 	registry *AgentRegistry,
 	provider providers.LLMProvider,
+	stateManager *state.Manager,
+	llmLimiter *semaphore.Semaphore,
+	fallbackChain *providers.FallbackChain,
 ) {
 	allowReadPaths := buildAllowReadPatterns(cfg)
+	// One limiter for all agents so max_subagents bounds total concurrency.
+	subagentLimiter := semaphore.New(cfg.Agents.Defaults.MaxSubagents)
 
 	for _, agentID := range registry.ListAgentIDs() {
 		agent, ok := registry.GetAgent(agentID)
@@ -152,6 +196,8 @@ func registerSharedTools(
 				GLMSearchEngine:      cfg.Tools.Web.GLMSearch.SearchEngine,
 				GLMSearchMaxResults:  cfg.Tools.Web.GLMSearch.MaxResults,
 				GLMSearchEnabled:     cfg.Tools.Web.GLMSearch.Enabled,
+				MaxTotalResults:      cfg.Tools.Web.MaxTotalResults,
+				EngineOrder:          cfg.Tools.Web.EngineOrder,
 				Proxy:                cfg.Tools.Web.Proxy,
 			})
 			if err != nil {
@@ -160,7 +206,8 @@ func registerSharedTools(
 				agent.Tools.Register(searchTool)
 			}
 		}
-		if cfg.Tools.IsToolEnabled("web_fetch") {
+		if cfg.Tools.IsToolEnabled("web_fetch") || cfg.Tools.IsToolEnabled("summarize_url") ||
+			cfg.Tools.IsToolEnabled("fetch_feed") {
 			fetchTool, err := tools.NewWebFetchToolWithProxy(
 				50000,
 				cfg.Tools.Web.Proxy,
@@ -170,11 +217,24 @@ func registerSharedTools(
 			if err != nil {
 				logger.ErrorCF("agent", "Failed to create web fetch tool", map[string]any{"error": err.Error()})
 			} else {
-				agent.Tools.Register(fetchTool)
+				if cfg.Tools.IsToolEnabled("web_fetch") {
+					agent.Tools.Register(fetchTool)
+				}
+				// summarize_url reuses the fetcher so proxy, SSRF and size guards apply
+				if cfg.Tools.IsToolEnabled("summarize_url") {
+					agent.Tools.Register(tools.NewSummarizeURLTool(
+						fetchTool, al.summaryChatFunc(agent, llmLimiter, fallbackChain)))
+				}
+				if cfg.Tools.IsToolEnabled("fetch_feed") {
+					agent.Tools.Register(tools.NewFetchFeedTool(fetchTool))
+				}
 			}
 		}
 
-		// Hardware tools (I2C, SPI) - Linux only, returns error on other platforms
+		// Hardware tools (GPIO, I2C, SPI) - Linux only, returns error on other platforms
+		if cfg.Tools.IsToolEnabled("gpio") {
+			agent.Tools.Register(tools.NewGPIOTool())
+		}
 		if cfg.Tools.IsToolEnabled("i2c") {
 			agent.Tools.Register(tools.NewI2CTool())
 		}
@@ -185,13 +245,19 @@ func registerSharedTools(
 		// Message tool
 		if cfg.Tools.IsToolEnabled("message") {
 			messageTool := tools.NewMessageTool()
-			messageTool.SetSendCallback(func(channel, chatID, content string) error {
+			messageTool.SetSendCallback(func(channel, chatID, content string, opts tools.SendOptions) error {
+				if !agent.CanSendTo(channel) {
+					return fmt.Errorf("agent %q is not allowed to send to channel %q", agent.ID, channel)
+				}
 				pubCtx, pubCancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer pubCancel()
 				return msgBus.PublishOutbound(pubCtx, bus.OutboundMessage{
-					Channel: channel,
-					ChatID:  chatID,
-					Content: content,
+					Channel:        channel,
+					ChatID:         chatID,
+					Content:        content,
+					DisablePreview: opts.DisablePreview,
+					Choices:        opts.Choices,
+					AgentReply:     true,
 				})
 			})
 			agent.Tools.Register(messageTool)
@@ -209,6 +275,19 @@ func registerSharedTools(
 			agent.Tools.Register(sendFileTool)
 		}
 
+		// Attachment listing (inbound media via MediaStore — store injected later by SetMediaStore)
+		if cfg.Tools.IsToolEnabled("list_attachments") {
+			agent.Tools.Register(tools.NewListAttachmentsTool(nil))
+		}
+
+		// Per-session scratchpad variables, persisted in the workspace state
+		if stateManager != nil && cfg.Tools.IsToolEnabled("scratchpad") {
+			agent.Tools.Register(tools.NewSetVarTool(stateManager))
+			agent.Tools.Register(tools.NewGetVarTool(stateManager))
+			agent.Tools.Register(tools.NewListVarsTool(stateManager))
+			agent.Tools.Register(tools.NewDeleteVarTool(stateManager))
+		}
+
 		// Skill discovery and installation tools
 		skills_enabled := cfg.Tools.IsToolEnabled("skills")
 		find_skills_enable := cfg.Tools.IsToolEnabled("find_skills")
@@ -237,8 +316,14 @@ func registerSharedTools(
 		spawnEnabled := cfg.Tools.IsToolEnabled("spawn")
 		spawnStatusEnabled := cfg.Tools.IsToolEnabled("spawn_status")
 		if (spawnEnabled || spawnStatusEnabled) && cfg.Tools.IsToolEnabled("subagent") {
-			subagentManager := tools.NewSubagentManager(provider, agent.Model, agent.Workspace)
+			// agent.Provider, not provider: it carries the model's tool
+			// protocol (see NewAgentInstance), which subagents must share.
+			subagentManager := tools.NewSubagentManager(agent.Provider, agent.Model, agent.Workspace)
 			subagentManager.SetLLMOptions(agent.MaxTokens, agent.Temperature)
+			subagentManager.SetLimiter(subagentLimiter)
+			subagentManager.SetLLMLimiter(llmLimiter)
+			subagentManager.SetMaxResultChars(cfg.Agents.Defaults.SubagentMaxResultChars)
+			subagentManager.SetNoToolCalling(agent.NoToolCalling)
 			// Clone the parent's tool registry so subagents can use all
 			// tools registered so far (file, web, etc.) but NOT spawn/
 			// spawn_status which are added below — preventing recursive
@@ -258,6 +343,14 @@ func registerSharedTools(
 		} else if (spawnEnabled || spawnStatusEnabled) && !cfg.Tools.IsToolEnabled("subagent") {
 			logger.WarnCF("agent", "spawn/spawn_status tools require subagent to be enabled", nil)
 		}
+
+		// Clarifying questions pause the turn; registered after the subagent
+		// clone because subagents have no user to ask.
+		if stateManager != nil && cfg.Tools.IsToolEnabled("ask_user") {
+			agent.Tools.Register(tools.NewAskUserTool(stateManager))
+		}
+
+		warnToolCallingUnsupported(agent)
 	}
 }
 
@@ -314,6 +407,8 @@ func (al *AgentLoop) Run(ctx context.Context) error {
 						Channel: msg.Channel,
 						ChatID:  msg.ChatID,
 						Content: response,
+						// Errors and command output are not agent replies.
+						AgentReply: err == nil && !commands.HasCommandPrefix(msg.Content),
 					})
 					logger.InfoCF("agent", "Published outbound response",
 						map[string]any{
@@ -354,9 +449,29 @@ func (al *AgentLoop) Close() {
 		}
 	}
 
+	// Never close the session stores under a flush that is still writing.
+	al.flushes.Wait()
 	al.GetRegistry().Close()
 }
 
+// FlushSessions writes every agent's sessions to disk. It stops waiting when
+// ctx is done so shutdown can report a slow disk and move on; the flush keeps
+// running in the background and Close waits for it to finish.
+func (al *AgentLoop) FlushSessions(ctx context.Context) error {
+	done := make(chan error, 1)
+	al.flushes.Add(1)
+	go func() {
+		defer al.flushes.Done()
+		done <- al.GetRegistry().FlushSessions()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("flush sessions: %w", ctx.Err())
+	}
+}
+
 func (al *AgentLoop) RegisterTool(tool tools.Tool) {
 	registry := al.GetRegistry()
 	for _, agentID := range registry.ListAgentIDs() {
@@ -368,6 +483,39 @@ func (al *AgentLoop) RegisterTool(tool tools.Tool) {
 
 func (al *AgentLoop) SetChannelManager(cm *channels.Manager) {
 	al.channelManager = cm
+	al.restoreGroupTriggerModes()
+}
+
+// restoreGroupTriggerModes re-applies persisted per-chat group trigger
+// overrides (modes and prefixes) to the channel manager.
+func (al *AgentLoop) restoreGroupTriggerModes() {
+	if al.state == nil || al.channelManager == nil {
+		return
+	}
+	for chatKey, mode := range al.state.GetGroupTriggerModes() {
+		channelName, chatID, ok := strings.Cut(chatKey, ":")
+		if !ok {
+			continue
+		}
+		if err := al.channelManager.SetGroupTriggerMode(channelName, chatID, mode); err != nil {
+			logger.DebugCF("agent", "Skipped restoring group trigger mode", map[string]any{
+				"chat":  chatKey,
+				"error": err.Error(),
+			})
+		}
+	}
+	for chatKey, prefixes := range al.state.GetGroupTriggerPrefixes() {
+		channelName, chatID, ok := strings.Cut(chatKey, ":")
+		if !ok {
+			continue
+		}
+		if err := al.channelManager.SetGroupTriggerPrefixes(channelName, chatID, prefixes); err != nil {
+			logger.DebugCF("agent", "Skipped restoring group prefixes", map[string]any{
+				"chat":  chatKey,
+				"error": err.Error(),
+			})
+		}
+	}
 }
 
 // ReloadProviderAndConfig atomically swaps the provider and config with proper synchronization.
@@ -423,8 +571,17 @@ func (al *AgentLoop) ReloadProviderAndConfig(
 		return fmt.Errorf("context canceled after registry creation: %w", err)
 	}
 
+	// Keep the current limiter (and the calls waiting on it) unless the
+	// configured concurrency changed.
+	llmLimiter := al.getLLMLimiter()
+	if llmLimiter.Limit() != max(cfg.Agents.Defaults.GlobalLLMConcurrency, 0) {
+		llmLimiter = semaphore.New(cfg.Agents.Defaults.GlobalLLMConcurrency)
+	}
+
+	fallbackChain := newFallbackChain(cfg)
+
 	// Ensure shared tools are re-registered on the new registry
-	registerSharedTools(cfg, al.bus, registry, provider)
+	al.registerSharedTools(cfg, al.bus, registry, provider, al.state, llmLimiter, fallbackChain)
 
 	// Atomically swap the config and registry under write lock
 	// This ensures readers see a consistent pair
@@ -436,7 +593,8 @@ func (al *AgentLoop) ReloadProviderAndConfig(
 	al.registry = registry
 
 	// Also update fallback chain with new config
-	al.fallback = providers.NewFallbackChain(providers.NewCooldownTracker())
+	al.fallback = fallbackChain
+	al.llmLimiter = llmLimiter
 
 	al.mu.Unlock()
 
@@ -466,6 +624,13 @@ func (al *AgentLoop) ReloadProviderAndConfig(
 	return nil
 }
 
+// getLLMLimiter returns the process-wide LLM concurrency limiter (thread-safe).
+func (al *AgentLoop) getLLMLimiter() *semaphore.Semaphore {
+	al.mu.RLock()
+	defer al.mu.RUnlock()
+	return al.llmLimiter
+}
+
 // GetRegistry returns the current registry (thread-safe)
 func (al *AgentLoop) GetRegistry() *AgentRegistry {
 	al.mu.RLock()
@@ -484,13 +649,34 @@ func (al *AgentLoop) GetConfig() *config.Config {
 func (al *AgentLoop) SetMediaStore(s media.MediaStore) {
 	al.mediaStore = s
 
-	// Propagate store to send_file tools in all agents.
+	// Propagate store to media-aware tools in all agents.
 	registry := al.GetRegistry()
 	registry.ForEachTool("send_file", func(t tools.Tool) {
 		if sf, ok := t.(*tools.SendFileTool); ok {
 			sf.SetMediaStore(s)
 		}
 	})
+	registry.ForEachTool("list_attachments", func(t tools.Tool) {
+		if la, ok := t.(*tools.ListAttachmentsTool); ok {
+			la.SetMediaStore(s)
+		}
+	})
+	registry.ForEachTool("read_file", func(t tools.Tool) {
+		if rf, ok := t.(*tools.ReadFileTool); ok {
+			rf.SetMediaStore(s)
+		}
+	})
+	registry.ForEachTool("read_document", func(t tools.Tool) {
+		if rd, ok := t.(*tools.ReadDocumentTool); ok {
+			rd.SetMediaStore(s)
+		}
+	})
+}
+
+// SetConfigPath sets the config file that runtime edits, such as a new
+// fallback chain from /switch fallbacks, are saved to.
+func (al *AgentLoop) SetConfigPath(path string) {
+	al.configPath = path
 }
 
 // SetTranscriber injects a voice transcriber for agent-level audio transcription.
@@ -595,36 +781,6 @@ func (al *AgentLoop) sendTranscriptionFeedback(
 	}
 }
 
-// inferMediaType determines the media type ("image", "audio", "video", "file")
-// from a filename and MIME content type.
-func inferMediaType(filename, contentType string) string {
-	ct := strings.ToLower(contentType)
-	fn := strings.ToLower(filename)
-
-	if strings.HasPrefix(ct, "image/") {
-		return "image"
-	}
-	if strings.HasPrefix(ct, "audio/") || ct == "application/ogg" {
-		return "audio"
-	}
-	if strings.HasPrefix(ct, "video/") {
-		return "video"
-	}
-
-	// Fallback: infer from extension
-	ext := filepath.Ext(fn)
-	switch ext {
-	case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp", ".svg":
-		return "image"
-	case ".mp3", ".wav", ".ogg", ".m4a", ".flac", ".aac", ".wma", ".opus":
-		return "audio"
-	case ".mp4", ".avi", ".mov", ".webm", ".mkv":
-		return "video"
-	}
-
-	return "file"
-}
-
 // RecordLastChannel records the last active channel for this workspace.
 // This uses the atomic state save mechanism to prevent data loss on crash.
 func (al *AgentLoop) RecordLastChannel(channel string) error {
@@ -634,6 +790,19 @@ func (al *AgentLoop) RecordLastChannel(channel string) error {
 	return al.state.SetLastChannel(channel)
 }
 
+// lastActiveTarget returns the channel and chat ID of the last external chat
+// recorded in state, or empty strings if there is none.
+func (al *AgentLoop) lastActiveTarget() (channel, chatID string) {
+	if al.state == nil {
+		return "", ""
+	}
+	channel, chatID = al.state.GetLastTarget()
+	if constants.IsInternalChannel(channel) {
+		return "", ""
+	}
+	return channel, chatID
+}
+
 // RecordLastChatID records the last active chat ID for this workspace.
 // This uses the atomic state save mechanism to prevent data loss on crash.
 func (al *AgentLoop) RecordLastChatID(chatID string) error {
@@ -670,7 +839,9 @@ func (al *AgentLoop) ProcessDirectWithChannel(
 }
 
 // ProcessHeartbeat processes a heartbeat request without session history.
-// Each heartbeat is independent and doesn't accumulate context.
+// Each heartbeat is independent and doesn't accumulate context. Without a
+// channel and chat ID it targets the last active chat recorded in state, so
+// heartbeats keep reaching the user after a restart.
 func (al *AgentLoop) ProcessHeartbeat(
 	ctx context.Context,
 	content, channel, chatID string,
@@ -679,8 +850,24 @@ func (al *AgentLoop) ProcessHeartbeat(
 	if agent == nil {
 		return "", fmt.Errorf("no default agent for heartbeat")
 	}
+	return al.processHeartbeat(ctx, agent, content, channel, chatID)
+}
+
+// processHeartbeat runs a heartbeat turn of agent, delivered to channel and
+// chatID or, when they are empty, to the last active chat.
+func (al *AgentLoop) processHeartbeat(
+	ctx context.Context,
+	agent *AgentInstance,
+	content, channel, chatID string,
+) (string, error) {
+	if channel == "" || chatID == "" {
+		channel, chatID = al.lastActiveTarget()
+	}
+	if channel == "" || chatID == "" {
+		channel, chatID = "cli", "direct"
+	}
 	return al.runAgentLoop(ctx, agent, processOptions{
-		SessionKey:      "heartbeat",
+		SessionKey:      heartbeatSessionKey,
 		Channel:         channel,
 		ChatID:          chatID,
 		UserMessage:     content,
@@ -756,19 +943,27 @@ func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage)
 		ChatID:            msg.ChatID,
 		SenderID:          msg.SenderID,
 		SenderDisplayName: msg.Sender.DisplayName,
-		UserMessage:       msg.Content,
+		UserID:            senderContactID(msg),
+		UserMessage:       withReplyContext(msg.Content, msg.ReplyTo),
 		Media:             msg.Media,
+		Model:             route.Model,
 		DefaultResponse:   defaultResponse,
 		EnableSummary:     true,
 		SendResponse:      false,
+		SendInterim:       al.GetConfig().Agents.Defaults.SendInterimContent,
+		DryRun:            al.dryRun,
 	}
 
+	al.maybeGreet(ctx, msg, agent, sessionKey)
+
 	// context-dependent commands check their own Runtime fields and report
 	// "unavailable" when the required capability is nil.
 	if response, handled := al.handleCommand(ctx, msg, agent, &opts); handled {
 		return response, nil
 	}
 
+	opts.UserMessage = al.answerPendingQuestion(opts)
+
 	return al.runAgentLoop(ctx, agent, opts)
 }
 
@@ -846,6 +1041,17 @@ func (al *AgentLoop) processSystemMessage(
 		return "", nil
 	}
 
+	// Silent background tasks keep their result out of the chat entirely.
+	if msg.Metadata[tools.SpawnNotifyMetadataKey] == tools.SpawnNotifySilent {
+		logger.InfoCF("agent", "Background task completed (silent)",
+			map[string]any{
+				"sender_id":   msg.SenderID,
+				"content_len": len(content),
+				"channel":     originChannel,
+			})
+		return "", nil
+	}
+
 	// Use default agent for system messages
 	agent := al.GetRegistry().GetDefaultAgent()
 	if agent == nil {
@@ -886,10 +1092,19 @@ func (al *AgentLoop) runAgentLoop(
 		}
 	}
 
+	// LLM calls made for this turn outside the chat itself (subagents,
+	// summaries) are billed to it too.
+	ctx = al.withCostRecorder(ctx, opts)
+
+	// Ephemeral sessions (e.g. heartbeat) never touch the session store, and
+	// dry runs read history but never write it.
+	persist := !isEphemeralSession(opts.SessionKey)
+	save := persist && !opts.DryRun
+
 	// 1. Build messages (skip history for heartbeat)
 	var history []providers.Message
 	var summary string
-	if !opts.NoHistory {
+	if !opts.NoHistory && persist {
 		history = agent.Sessions.GetHistory(opts.SessionKey)
 		summary = agent.Sessions.GetSummary(opts.SessionKey)
 	}
@@ -903,6 +1118,9 @@ func (al *AgentLoop) runAgentLoop(
 		opts.SenderID,
 		opts.SenderDisplayName,
 	)
+	messages = applyReplyLanguage(messages, al.sessionReplyLanguage(opts.SessionKey))
+	messages = applySessionMode(messages, agent, al.sessionMode(opts.SessionKey))
+	messages = applyPinnedNotes(messages, al.sessionPins(opts.SessionKey))
 
 	// Resolve media:// refs: images→base64 data URLs, non-images→local paths in content
 	cfg := al.GetConfig()
@@ -910,37 +1128,57 @@ func (al *AgentLoop) runAgentLoop(
 	messages = resolveMediaRefs(messages, al.mediaStore, maxMediaSize)
 
 	// 2. Save user message to session
-	agent.Sessions.AddMessage(opts.SessionKey, "user", opts.UserMessage)
+	if save {
+		agent.Sessions.AddMessage(opts.SessionKey, "user", opts.UserMessage)
+	}
 
-	// 3. Run LLM iteration loop
-	finalContent, iteration, err := al.runLLMIteration(ctx, agent, messages, opts)
+	// 3. Run LLM iteration loop. One attempt budget covers every LLM call
+	// made for this message: each tool iteration, fallback candidates and
+	// the timeout and context-window retries.
+	attempts := providers.NewAttemptBudget(cfg.Agents.Defaults.LLMAttemptBudget)
+	finalContent, iteration, delivered, err := al.runLLMIteration(ctx, agent, messages, opts, attempts)
 	if err != nil {
+		al.recordSessionError(opts.SessionKey, "", err)
 		return "", err
 	}
 
 	// If last tool had ForUser content and we already sent it, we might not need to send final response
 	// This is controlled by the tool's Silent flag and ForUser content
 
-	// 4. Handle empty response
+	// 4. Handle empty response. A turn that ran out of iterations is
+	// checkpointed so the user can resume it with /continue.
 	if finalContent == "" {
-		finalContent = opts.DefaultResponse
+		if iteration >= agent.MaxIterations && al.checkpointTask(opts, iteration) {
+			finalContent = fmt.Sprintf(iterationLimitResponse, iteration)
+		} else {
+			finalContent = opts.DefaultResponse
+		}
 	}
 
 	// 5. Save final assistant message to session
-	agent.Sessions.AddMessage(opts.SessionKey, "assistant", finalContent)
-	agent.Sessions.Save(opts.SessionKey)
+	if save {
+		agent.Sessions.AddMessage(opts.SessionKey, "assistant", finalContent)
+		agent.Sessions.Save(opts.SessionKey)
+	}
 
 	// 6. Optional: summarization
-	if opts.EnableSummary {
-		al.maybeSummarize(agent, opts.SessionKey, opts.Channel, opts.ChatID)
+	if opts.EnableSummary && !opts.DryRun {
+		al.maybeSummarize(ctx, agent, opts.SessionKey, opts.Channel, opts.ChatID)
 	}
 
-	// 7. Optional: send response via bus
+	// 7. Optional: send response via bus. A final answer identical to the
+	// interim content already sent is not repeated.
+	if delivered {
+		logger.DebugCF("agent", "Final response already sent as interim content",
+			map[string]any{"agent_id": agent.ID, "session_key": opts.SessionKey})
+		return "", nil
+	}
 	if opts.SendResponse {
 		al.bus.PublishOutbound(ctx, bus.OutboundMessage{
-			Channel: opts.Channel,
-			ChatID:  opts.ChatID,
-			Content: finalContent,
+			Channel:    opts.Channel,
+			ChatID:     opts.ChatID,
+			Content:    finalContent,
+			AgentReply: true,
 		})
 	}
 
@@ -1013,21 +1251,32 @@ func (al *AgentLoop) handleReasoning(
 	}
 }
 
-// runLLMIteration executes the LLM call loop with tool handling.
+// runLLMIteration executes the LLM call loop with tool handling. The
+// returned bool reports that the final content was already delivered to the
+// user as interim content and must not be sent again.
 func (al *AgentLoop) runLLMIteration(
 	ctx context.Context,
 	agent *AgentInstance,
 	messages []providers.Message,
 	opts processOptions,
-) (string, int, error) {
+	attempts *providers.AttemptBudget,
+) (string, int, bool, error) {
 	iteration := 0
 	var finalContent string
+	var lastInterim string
+	var sources *sourceTracker
+	if agent.CiteSources {
+		sources = newSourceTracker()
+	}
 
 	// Determine effective model tier for this conversation turn.
 	// selectCandidates evaluates routing once and the decision is sticky for
 	// all tool-follow-up iterations within the same turn so that a multi-step
 	// tool chain doesn't switch models mid-way through.
-	activeCandidates, activeModel := al.selectCandidates(agent, opts.UserMessage, messages)
+	activeCandidates, activeModel := al.selectCandidates(agent, opts.Model, opts.UserMessage, messages)
+	loops := newLoopDetector(agent.LoopThreshold)
+	repeats := newRepeatCache(agent)
+	truncation := al.GetConfig().Tools.ResultTruncation
 
 	for iteration < agent.MaxIterations {
 		iteration++
@@ -1039,19 +1288,30 @@ func (al *AgentLoop) runLLMIteration(
 				"max":       agent.MaxIterations,
 			})
 
+		if declined := al.checkCostBudget(opts); declined != "" {
+			finalContent = declined
+			break
+		}
+
 		// Build tool definitions
 		providerToolDefs := agent.Tools.ToProviderDefs()
+		if agent.NoToolCalling {
+			providerToolDefs = nil
+		}
 
-		// Determine whether the provider's native web search should replace
-		// the client-side web_search tool for this request. Only enable when web
-		// search is actually enabled and registered (so users who disabled web
-		// access do not get provider-side search or billing).
+		// Determine whether the provider's native web search should be used
+		// for this request and whether the client-side web_search tool stays
+		// visible next to it (search_mode "both"). Only enable when web search
+		// is actually enabled and registered (so users who disabled web access
+		// do not get provider-side search or billing).
 		_, hasWebSearch := agent.Tools.Get("web_search")
-		useNativeSearch := al.cfg.Tools.Web.PreferNative &&
+		searchMode := al.cfg.Tools.Web.EffectiveSearchMode()
+		useNativeSearch := searchMode != config.WebSearchModeLocal &&
 			isNativeSearchProvider(agent.Provider) &&
 			hasWebSearch
+		keepClientSearch := useNativeSearch && searchMode == config.WebSearchModeBoth
 
-		if useNativeSearch {
+		if useNativeSearch && !keepClientSearch {
 			providerToolDefs = filterClientWebSearch(providerToolDefs)
 		}
 
@@ -1079,6 +1339,7 @@ func (al *AgentLoop) runLLMIteration(
 
 		// Call LLM with fallback chain if multiple candidates are configured.
 		var response *providers.LLMResponse
+		var servedModel string
 		var err error
 
 		llmOpts := map[string]any{
@@ -1089,6 +1350,9 @@ func (al *AgentLoop) runLLMIteration(
 		if useNativeSearch {
 			llmOpts["native_search"] = true
 		}
+		if keepClientSearch {
+			llmOpts["keep_client_search"] = true
+		}
 		// parseThinkingLevel guarantees ThinkingOff for empty/unknown values,
 		// so checking != ThinkingOff is sufficient.
 		if agent.ThinkingLevel != ThinkingOff {
@@ -1100,20 +1364,31 @@ func (al *AgentLoop) runLLMIteration(
 			}
 		}
 
-		callLLM := func() (*providers.LLMResponse, error) {
+		llmLimiter := al.getLLMLimiter()
+		// Providers that can stream forward reasoning while it is generated.
+		reasoningChannelID := al.targetReasoningChannelID(opts.Channel)
+		streamer, canStream := agent.Provider.(providers.StreamingProvider)
+		reasoningStreamed := false
+		// callLLM returns the model_name that served the response, for pricing.
+		callLLM := func() (*providers.LLMResponse, string, error) {
+			if opts.DryRun {
+				return dryRunResponse(agent, activeModel, messages, providerToolDefs), activeModel, nil
+			}
 			al.activeRequests.Add(1)
 			defer al.activeRequests.Done()
+			reasoningStreamed = false
 
 			if len(activeCandidates) > 1 && al.fallback != nil {
 				fbResult, fbErr := al.fallback.Execute(
-					ctx,
+					providers.WithAttemptBudget(ctx, attempts),
 					activeCandidates,
 					func(ctx context.Context, provider, model string) (*providers.LLMResponse, error) {
-						return agent.Provider.Chat(ctx, messages, providerToolDefs, model, llmOpts)
+						return providers.LimitedChat(
+							ctx, llmLimiter, agent.Provider, messages, providerToolDefs, model, llmOpts)
 					},
 				)
 				if fbErr != nil {
-					return nil, fbErr
+					return nil, "", fbErr
 				}
 				if fbResult.Provider != "" && len(fbResult.Attempts) > 0 {
 					logger.InfoCF(
@@ -1123,15 +1398,28 @@ func (al *AgentLoop) runLLMIteration(
 						map[string]any{"agent_id": agent.ID, "iteration": iteration},
 					)
 				}
-				return fbResult.Response, nil
+				return fbResult.Response, candidateModelName(al.GetConfig(), fbResult.Provider, fbResult.Model), nil
+			}
+			if !attempts.Take() {
+				return nil, "", providers.ErrAttemptBudgetExhausted
+			}
+			var resp *providers.LLMResponse
+			var err error
+			if canStream && reasoningChannelID != "" {
+				reasoningStreamed = true
+				resp, err = al.chatStreamingReasoning(ctx, llmLimiter, streamer,
+					messages, providerToolDefs, activeModel, llmOpts, opts.Channel, reasoningChannelID)
+			} else {
+				resp, err = providers.LimitedChat(
+					ctx, llmLimiter, agent.Provider, messages, providerToolDefs, activeModel, llmOpts)
 			}
-			return agent.Provider.Chat(ctx, messages, providerToolDefs, activeModel, llmOpts)
+			return resp, activeModel, err
 		}
 
 		// Retry loop for context/token errors
 		maxRetries := 2
 		for retry := 0; retry <= maxRetries; retry++ {
-			response, err = callLLM()
+			response, servedModel, err = callLLM()
 			if err == nil {
 				break
 			}
@@ -1156,6 +1444,29 @@ func (al *AgentLoop) runLLMIteration(
 				strings.Contains(errMsg, "prompt is too long") ||
 				strings.Contains(errMsg, "request too large"))
 
+			// A model that cannot call tools fails every request carrying them;
+			// answer without tools rather than not at all.
+			if len(providerToolDefs) > 0 && isToolsUnsupportedError(errMsg) && retry < maxRetries {
+				logger.WarnCF("agent", "Model rejected tool definitions, retrying without tools. "+
+					"Set supports_tools to false on its model_list entry to skip them up front",
+					map[string]any{
+						"agent_id": agent.ID,
+						"model":    activeModel,
+						"error":    err.Error(),
+					})
+				providerToolDefs = nil
+				continue
+			}
+
+			if (isTimeoutError || isContextError) && retry < maxRetries && attempts.Exhausted() {
+				logger.WarnCF("agent", "LLM attempt budget exhausted, not retrying", map[string]any{
+					"error":         err.Error(),
+					"attempts_used": attempts.Used(),
+					"budget":        attempts.Limit(),
+				})
+				break
+			}
+
 			if isTimeoutError && retry < maxRetries {
 				backoff := time.Duration(retry+1) * 5 * time.Second
 				logger.WarnCF("agent", "Timeout error, retrying after backoff", map[string]any{
@@ -1167,6 +1478,28 @@ func (al *AgentLoop) runLLMIteration(
 				continue
 			}
 
+			if isContextError && isEphemeralSession(opts.SessionKey) {
+				// No stored history to compress; the request itself is too large.
+				logger.WarnCF("agent", "Context window error in ephemeral session, not compressing",
+					map[string]any{
+						"session_key": opts.SessionKey,
+						"error":       err.Error(),
+					})
+				break
+			}
+
+			if isContextError && !al.GetConfig().Agents.Defaults.CompressOnOverflow() {
+				// The user asked to see overflows rather than have history
+				// dropped behind their back.
+				logger.WarnCF("agent", "Context window error, compression disabled by auto_compress_on_overflow",
+					map[string]any{
+						"session_key": opts.SessionKey,
+						"error":       err.Error(),
+					})
+				err = fmt.Errorf("%w (auto_compress_on_overflow is off, history was not compressed)", err)
+				break
+			}
+
 			if isContextError && retry < maxRetries {
 				logger.WarnCF(
 					"agent",
@@ -1192,6 +1525,9 @@ func (al *AgentLoop) runLLMIteration(
 					newHistory, newSummary, "",
 					nil, opts.Channel, opts.ChatID, opts.SenderID, opts.SenderDisplayName,
 				)
+				messages = applyReplyLanguage(messages, al.sessionReplyLanguage(opts.SessionKey))
+				messages = applySessionMode(messages, agent, al.sessionMode(opts.SessionKey))
+				messages = applyPinnedNotes(messages, al.sessionPins(opts.SessionKey))
 				continue
 			}
 			break
@@ -1200,20 +1536,20 @@ func (al *AgentLoop) runLLMIteration(
 		if err != nil {
 			logger.ErrorCF("agent", "LLM call failed",
 				map[string]any{
-					"agent_id":  agent.ID,
-					"iteration": iteration,
-					"model":     activeModel,
-					"error":     err.Error(),
+					"agent_id":      agent.ID,
+					"iteration":     iteration,
+					"model":         activeModel,
+					"attempts_used": attempts.Used(),
+					"error":         err.Error(),
 				})
-			return "", iteration, fmt.Errorf("LLM call failed after retries: %w", err)
+			return "", iteration, false, fmt.Errorf("LLM call failed after retries: %w", err)
 		}
 
-		go al.handleReasoning(
-			ctx,
-			response.Reasoning,
-			opts.Channel,
-			al.targetReasoningChannelID(opts.Channel),
-		)
+		al.recordCost(servedModel, opts, response.Usage)
+
+		if !reasoningStreamed {
+			go al.handleReasoning(ctx, response.Reasoning, opts.Channel, reasoningChannelID)
+		}
 
 		logger.DebugCF("agent", "LLM response",
 			map[string]any{
@@ -1222,8 +1558,10 @@ func (al *AgentLoop) runLLMIteration(
 				"content_chars":  len(response.Content),
 				"tool_calls":     len(response.ToolCalls),
 				"reasoning":      response.Reasoning,
-				"target_channel": al.targetReasoningChannelID(opts.Channel),
+				"target_channel": reasoningChannelID,
 				"channel":        opts.Channel,
+				"request_id":     response.RequestID,
+				"attempts_used":  attempts.Used(),
 			})
 		// Check if no tool calls - then check reasoning content if any
 		if len(response.ToolCalls) == 0 {
@@ -1236,14 +1574,12 @@ func (al *AgentLoop) runLLMIteration(
 					"agent_id":      agent.ID,
 					"iteration":     iteration,
 					"content_chars": len(finalContent),
+					"request_id":    response.RequestID,
 				})
 			break
 		}
 
-		normalizedToolCalls := make([]providers.ToolCall, 0, len(response.ToolCalls))
-		for _, tc := range response.ToolCalls {
-			normalizedToolCalls = append(normalizedToolCalls, providers.NormalizeToolCall(tc))
-		}
+		normalizedToolCalls := providers.NormalizeToolCalls(response.ToolCalls)
 
 		// Log tool calls
 		toolNames := make([]string, 0, len(normalizedToolCalls))
@@ -1252,10 +1588,11 @@ func (al *AgentLoop) runLLMIteration(
 		}
 		logger.InfoCF("agent", "LLM requested tool calls",
 			map[string]any{
-				"agent_id":  agent.ID,
-				"tools":     toolNames,
-				"count":     len(normalizedToolCalls),
-				"iteration": iteration,
+				"agent_id":   agent.ID,
+				"tools":      toolNames,
+				"count":      len(normalizedToolCalls),
+				"iteration":  iteration,
+				"request_id": response.RequestID,
 			})
 
 		// Build assistant message with tool calls
@@ -1289,24 +1626,59 @@ func (al *AgentLoop) runLLMIteration(
 		messages = append(messages, assistantMsg)
 
 		// Save assistant message with tool calls to session
-		agent.Sessions.AddFullMessage(opts.SessionKey, assistantMsg)
+		if !isEphemeralSession(opts.SessionKey) {
+			agent.Sessions.AddFullMessage(opts.SessionKey, assistantMsg)
+		}
+
+		// Let the user see "I'll look that up..." while the tools run.
+		if interim := strings.TrimSpace(response.Content); interim != "" && al.shouldSendInterim(opts) {
+			al.bus.PublishOutbound(ctx, bus.OutboundMessage{
+				Channel: opts.Channel,
+				ChatID:  opts.ChatID,
+				Content: interim,
+			})
+			lastInterim = interim
+		}
 
-		// Execute tool calls in parallel
+		// Execute tool calls, in parallel where the tools allow it
 		type indexedAgentResult struct {
 			result *tools.ToolResult
 			tc     providers.ToolCall
+			cached bool
 		}
 
 		agentResults := make([]indexedAgentResult, len(normalizedToolCalls))
-		var wg sync.WaitGroup
+		pending := make([]int, 0, len(normalizedToolCalls))
 
 		for i, tc := range normalizedToolCalls {
 			agentResults[i].tc = tc
 
-			wg.Add(1)
-			go func(idx int, tc providers.ToolCall) {
-				defer wg.Done()
+			// Don't re-run a pure call identical to the previous iteration's.
+			if !repeats.cacheable(agent, tc.Name) {
+				pending = append(pending, i)
+				continue
+			}
+			if cached := repeats.lookup(tc, iteration); cached != nil {
+				logger.InfoCF("agent", "Repeated tool call, returning previous result",
+					map[string]any{
+						"agent_id":  agent.ID,
+						"tool":      tc.Name,
+						"iteration": iteration,
+					})
+				agentResults[i].result = cached
+				agentResults[i].cached = true
+				continue
+			}
+			pending = append(pending, i)
+		}
 
+		tools.RunCalls(len(pending),
+			func(j int) bool {
+				return agent.Tools.IsConcurrencySafe(normalizedToolCalls[pending[j]].Name)
+			},
+			func(j int) {
+				idx := pending[j]
+				tc := normalizedToolCalls[idx]
 				argsJSON, _ := json.Marshal(tc.Arguments)
 				argsPreview := utils.Truncate(string(argsJSON), 200)
 				logger.InfoCF("agent", fmt.Sprintf("Tool call: %s(%s)", tc.Name, argsPreview),
@@ -1356,11 +1728,24 @@ func (al *AgentLoop) runLLMIteration(
 						SenderID: fmt.Sprintf("async:%s", tc.Name),
 						ChatID:   fmt.Sprintf("%s:%s", opts.Channel, opts.ChatID),
 						Content:  content,
+						Metadata: result.Metadata,
 					})
 				}
 
+				if missing := al.checkToolAvailable(agent, tc.Name); missing != nil {
+					agentResults[idx].result = missing
+					return
+				}
+
+				if quotaResult := al.checkToolQuota(opts.UserID, tc.Name); quotaResult != nil {
+					agentResults[idx].result = quotaResult
+					return
+				}
+
+				toolCtx := tools.WithToolSessionKey(tools.WithToolMedia(ctx, opts.Media), opts.SessionKey)
+				toolCtx = tools.WithToolAgentID(toolCtx, agent.ID)
 				toolResult := agent.Tools.ExecuteWithContext(
-					ctx,
+					toolCtx,
 					tc.Name,
 					tc.Arguments,
 					opts.Channel,
@@ -1368,14 +1753,23 @@ func (al *AgentLoop) runLLMIteration(
 					asyncCallback,
 				)
 				agentResults[idx].result = toolResult
-			}(i, tc)
-		}
-		wg.Wait()
+			})
 
 		// Process results in original order (send to user, save to session)
+		endTurn := false
 		for _, r := range agentResults {
+			if sources != nil {
+				sources.record(r.tc, r.result)
+			}
+
+			// A tool that ends the turn supplies the final reply itself.
+			if r.result.EndTurn {
+				endTurn = true
+				finalContent = r.result.ForUser
+			}
+
 			// Send ForUser content to user immediately if not Silent
-			if !r.result.Silent && r.result.ForUser != "" && opts.SendResponse {
+			if !r.result.Silent && !r.result.EndTurn && r.result.ForUser != "" && opts.SendResponse {
 				al.bus.PublishOutbound(ctx, bus.OutboundMessage{
 					Channel: opts.Channel,
 					ChatID:  opts.ChatID,
@@ -1397,7 +1791,7 @@ func (al *AgentLoop) runLLMIteration(
 						if _, meta, err := al.mediaStore.ResolveWithMeta(ref); err == nil {
 							part.Filename = meta.Filename
 							part.ContentType = meta.ContentType
-							part.Type = inferMediaType(meta.Filename, meta.ContentType)
+							part.Type = utils.InferMediaType(meta.Filename, meta.ContentType)
 						}
 					}
 					parts = append(parts, part)
@@ -1409,11 +1803,24 @@ func (al *AgentLoop) runLLMIteration(
 				})
 			}
 
+			if r.result.IsError {
+				toolErr := r.result.Err
+				if toolErr == nil {
+					toolErr = errors.New(r.result.ForLLM)
+				}
+				al.recordSessionError(opts.SessionKey, "tool "+r.tc.Name, toolErr)
+			}
+
 			// Determine content for LLM based on tool result
 			contentForLLM := r.result.ForLLM
 			if contentForLLM == "" && r.result.Err != nil {
 				contentForLLM = r.result.Err.Error()
 			}
+			contentForLLM = al.limitToolResult(ctx, agent, truncation, r.tc.Name, contentForLLM)
+
+			if repeats.cacheable(agent, r.tc.Name) {
+				repeats.record(r.tc, iteration, contentForLLM, r.cached)
+			}
 
 			toolResultMsg := providers.Message{
 				Role:       "tool",
@@ -1423,7 +1830,45 @@ func (al *AgentLoop) runLLMIteration(
 			messages = append(messages, toolResultMsg)
 
 			// Save tool result message to session
-			agent.Sessions.AddFullMessage(opts.SessionKey, toolResultMsg)
+			if !isEphemeralSession(opts.SessionKey) {
+				agent.Sessions.AddFullMessage(opts.SessionKey, toolResultMsg)
+			}
+		}
+
+		if endTurn {
+			logger.InfoCF("agent", "Tool ended the turn",
+				map[string]any{
+					"agent_id":  agent.ID,
+					"iteration": iteration,
+				})
+			break
+		}
+
+		// Break out of tool-call loops: switch to the escalation model once,
+		// and nudge the agent if it keeps repeating itself after that.
+		if repeated := loops.observe(normalizedToolCalls); repeated != "" {
+			if len(agent.EscalationCandidates) > 0 && activeModel != agent.EscalationModel {
+				logger.WarnCF("agent", "Tool loop detected, escalating model",
+					map[string]any{
+						"agent_id":   agent.ID,
+						"tool":       repeated,
+						"iteration":  iteration,
+						"from_model": activeModel,
+						"to_model":   agent.EscalationModel,
+					})
+				activeCandidates, activeModel = agent.EscalationCandidates, agent.EscalationModel
+			} else {
+				logger.WarnCF("agent", "Tool loop detected, nudging agent",
+					map[string]any{
+						"agent_id":  agent.ID,
+						"tool":      repeated,
+						"iteration": iteration,
+					})
+				messages = append(messages, providers.Message{
+					Role:    "user",
+					Content: fmt.Sprintf(loopNudgeMessage, repeated, agent.LoopThreshold),
+				})
+			}
 		}
 
 		// Tick down TTL of discovered tools after processing tool results.
@@ -1438,27 +1883,47 @@ func (al *AgentLoop) runLLMIteration(
 		})
 	}
 
-	return finalContent, iteration, nil
+	delivered := lastInterim != "" && strings.TrimSpace(finalContent) == lastInterim
+	if sources != nil && !delivered {
+		finalContent = sources.annotate(finalContent)
+	}
+	return finalContent, iteration, delivered, nil
+}
+
+// shouldSendInterim reports whether content accompanying tool calls should be
+// sent to the user before the tools execute.
+func (al *AgentLoop) shouldSendInterim(opts processOptions) bool {
+	return opts.SendInterim &&
+		opts.Channel != "" &&
+		opts.ChatID != "" &&
+		!constants.IsInternalChannel(opts.Channel)
 }
 
 // selectCandidates returns the model candidates and resolved model name to use
 // for a conversation turn. When model routing is configured and the incoming
 // message scores below the complexity threshold, it returns the light model
-// candidates instead of the primary ones.
+// candidates instead of the primary ones. The primary candidates come from
+// the matched binding's model when it sets one, else from the agent.
 //
 // The returned (candidates, model) pair is used for all LLM calls within one
 // turn — tool follow-up iterations use the same tier as the initial call so
 // that a multi-step tool chain doesn't switch models mid-way.
 func (al *AgentLoop) selectCandidates(
 	agent *AgentInstance,
+	override *config.AgentModelConfig,
 	userMsg string,
 	history []providers.Message,
 ) (candidates []providers.FallbackCandidate, model string) {
+	primary, primaryModel := al.agentCandidates(agent), agent.Model
+	if resolved := al.resolveModelOverride(agent, override); len(resolved) > 0 {
+		primary, primaryModel = resolved, override.Primary
+	}
+
 	if agent.Router == nil || len(agent.LightCandidates) == 0 {
-		return agent.Candidates, agent.Model
+		return primary, primaryModel
 	}
 
-	_, usedLight, score := agent.Router.SelectModel(userMsg, history, agent.Model)
+	_, usedLight, score := agent.Router.SelectModel(userMsg, history, primaryModel)
 	if !usedLight {
 		logger.DebugCF("agent", "Model routing: primary model selected",
 			map[string]any{
@@ -1466,7 +1931,7 @@ func (al *AgentLoop) selectCandidates(
 				"score":     score,
 				"threshold": agent.Router.Threshold(),
 			})
-		return agent.Candidates, agent.Model
+		return primary, primaryModel
 	}
 
 	logger.InfoCF("agent", "Model routing: light model selected",
@@ -1479,50 +1944,103 @@ func (al *AgentLoop) selectCandidates(
 	return agent.LightCandidates, agent.Router.LightModel()
 }
 
+// resolveModelOverride resolves a binding-level model into fallback
+// candidates. It returns nil when there is no override or it cannot be
+// resolved, in which case the agent's own model is used.
+func (al *AgentLoop) resolveModelOverride(
+	agent *AgentInstance,
+	override *config.AgentModelConfig,
+) []providers.FallbackCandidate {
+	if override == nil || strings.TrimSpace(override.Primary) == "" {
+		return nil
+	}
+	cfg := al.GetConfig()
+	candidates := providers.ResolveCandidatesWithLookup(
+		providers.ModelConfig{Primary: override.Primary, Fallbacks: override.Fallbacks},
+		cfg.Agents.Defaults.Provider,
+		modelListLookup(cfg),
+	)
+	if len(candidates) == 0 {
+		logger.WarnCF("agent", "Binding model could not be resolved; using the agent's model",
+			map[string]any{"agent_id": agent.ID, "model": override.Primary})
+		return nil
+	}
+	applyCandidateSettings(cfg, candidates)
+	return candidates
+}
+
 // maybeSummarize triggers summarization if the session history exceeds thresholds.
-func (al *AgentLoop) maybeSummarize(agent *AgentInstance, sessionKey, channel, chatID string) {
+func (al *AgentLoop) maybeSummarize(ctx context.Context, agent *AgentInstance, sessionKey, channel, chatID string) {
+	if isEphemeralSession(sessionKey) {
+		return
+	}
 	newHistory := agent.Sessions.GetHistory(sessionKey)
 	tokenEstimate := al.estimateTokens(newHistory)
 	threshold := agent.ContextWindow * agent.SummarizeTokenPercent / 100
 
 	if len(newHistory) > agent.SummarizeMessageThreshold || tokenEstimate > threshold {
 		summarizeKey := agent.ID + ":" + sessionKey
-		if _, loading := al.summarizing.LoadOrStore(summarizeKey, true); !loading {
+		if al.isSummarizing(summarizeKey) {
+			return
+		}
+		// While the breaker is open, keep serving the un-summarized history;
+		// forceCompression still guards the context window.
+		if !al.summaryBreaker.Allow() {
+			logger.DebugCF("agent", "Summarization paused by breaker", map[string]any{
+				"session_key": sessionKey,
+			})
+			return
+		}
+		if entry := al.tryStartSummarizing(summarizeKey); entry != nil {
 			go func() {
-				defer al.summarizing.Delete(summarizeKey)
+				defer al.finishSummarizing(summarizeKey, entry)
 				logger.Debug("Memory threshold reached. Optimizing conversation history...")
-				al.summarizeSession(agent, sessionKey)
+				// The summary outlives the turn but is still billed to it.
+				al.summarizeSession(context.WithoutCancel(ctx), agent, sessionKey)
 			}()
 		}
 	}
 }
 
 // forceCompression aggressively reduces context when the limit is hit.
-// It drops the oldest 50% of messages (keeping system prompt and last user message).
+// It drops the oldest share of the conversation set by
+// session.compression_drop_ratio (half by default), keeping the system prompt
+// and the last user message.
 func (al *AgentLoop) forceCompression(agent *AgentInstance, sessionKey string) {
+	if isEphemeralSession(sessionKey) {
+		return
+	}
 	history := agent.Sessions.GetHistory(sessionKey)
 	if len(history) <= 4 {
 		return
 	}
 
 	// Keep system prompt (usually [0]) and the very last message (user's trigger)
-	// We want to drop the oldest half of the *conversation*
+	// We want to drop the oldest part of the *conversation*, as set by
+	// session.compression_drop_ratio (half by default)
 	// Assuming [0] is system, [1:] is conversation
 	conversation := history[1 : len(history)-1]
 	if len(conversation) == 0 {
 		return
 	}
 
-	// Helper to find the mid-point of the conversation
-	mid := len(conversation) / 2
+	ratio := al.GetConfig().Session.EffectiveCompressionDropRatio()
+	mid := max(int(float64(len(conversation))*ratio), 1)
 
 	// New history structure:
 	// 1. System Prompt (with compression note appended)
-	// 2. Second half of conversation
+	// 2. Remaining part of conversation
 	// 3. Last message
 
-	droppedCount := mid
-	keptConversation := conversation[mid:]
+	keptConversation := compressionKeep(conversation, mid)
+	droppedCount := len(conversation) - len(keptConversation)
+	if droppedCount == 0 {
+		logger.WarnCF("agent", "Forced compression found nothing it could drop", map[string]any{
+			"session_key": sessionKey,
+			"count":       len(history),
+		})
+		return
+	}
 
 	newHistory := make([]providers.Message, 0, 1+len(keptConversation)+1)
 
@@ -1550,6 +2068,43 @@ func (al *AgentLoop) forceCompression(agent *AgentInstance, sessionKey string) {
 	})
 }
 
+// compressionKeep returns what forceCompression keeps of conversation after
+// dropping about its first mid messages. The cut is moved forward to a turn
+// boundary so an assistant's tool calls stay with their results; Gemini 3
+// also rejects a turn whose function calls lost their thought signatures.
+// When mid falls inside the turn still in progress, the user message that
+// started it is kept and only its oldest whole tool-call rounds are dropped.
+func compressionKeep(conversation []providers.Message, mid int) []providers.Message {
+	for i := mid; i < len(conversation); i++ {
+		if isTurnBoundary(conversation[i]) {
+			return conversation[i:]
+		}
+	}
+
+	turn := -1
+	for i := mid - 1; i >= 0; i-- {
+		if conversation[i].Role == "user" {
+			turn = i
+			break
+		}
+	}
+	for i := mid; i < len(conversation); i++ {
+		// A round starts at an assistant tool-call message after the
+		// previous round's results.
+		if conversation[i].Role == "assistant" && conversation[i-1].Role == "tool" {
+			kept := make([]providers.Message, 0, 1+len(conversation)-i)
+			if turn >= 0 {
+				kept = append(kept, conversation[turn])
+			}
+			return append(kept, conversation[i:]...)
+		}
+	}
+	if turn > 0 {
+		return conversation[turn:]
+	}
+	return conversation
+}
+
 // GetStartupInfo returns information about loaded tools and skills for logging.
 func (al *AgentLoop) GetStartupInfo() map[string]any {
 	info := make(map[string]any)
@@ -1579,6 +2134,33 @@ func (al *AgentLoop) GetStartupInfo() map[string]any {
 	return info
 }
 
+// agentInfo describes the agent with the given ID, or the default agent when
+// id is empty, for /agents info.
+func (al *AgentLoop) agentInfo(id string) (commands.AgentInfo, bool) {
+	registry := al.GetRegistry()
+	defaultAgent := registry.GetDefaultAgent()
+	agent := defaultAgent
+	if id != "" {
+		var ok bool
+		if agent, ok = registry.GetAgent(id); !ok {
+			return commands.AgentInfo{}, false
+		}
+	}
+	if agent == nil {
+		return commands.AgentInfo{}, false
+	}
+	return commands.AgentInfo{
+		ID:            agent.ID,
+		Name:          agent.Name,
+		Default:       agent == defaultAgent,
+		Model:         agent.Model,
+		MaxTokens:     agent.MaxTokens,
+		Temperature:   agent.Temperature,
+		MaxIterations: agent.MaxIterations,
+		Tools:         agent.Tools.List(),
+	}, true
+}
+
 // formatMessagesForLog formats messages for logging
 func formatMessagesForLog(messages []providers.Message) string {
 	if len(messages) == 0 {
@@ -1639,19 +2221,45 @@ func formatToolsForLog(toolDefs []providers.ToolDefinition) string {
 }
 
 // summarizeSession summarizes the conversation history for a session.
-func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+func (al *AgentLoop) summarizeSession(ctx context.Context, agent *AgentInstance, sessionKey string) {
+	ctx, cancel := context.WithTimeout(ctx, summarizeTimeout)
 	defer cancel()
+	al.summarizeSessionWithContext(ctx, agent, sessionKey)
+}
+
+// summarizeSessionWithContext summarizes all but the last few messages of
+// the session into its summary and truncates the history. It returns the new
+// summary, or "" when there was nothing to summarize, and the LLM error if
+// any call failed (a truncated fallback summary may still have been saved).
+func (al *AgentLoop) summarizeSessionWithContext(
+	ctx context.Context,
+	agent *AgentInstance,
+	sessionKey string,
+) (string, error) {
+	if isEphemeralSession(sessionKey) {
+		return "", nil
+	}
+	// Returning before any LLM call must not leave a half-open probe pending.
+	defer al.summaryBreaker.Release()
 
 	history := agent.Sessions.GetHistory(sessionKey)
 	summary := agent.Sessions.GetSummary(sessionKey)
 
-	// Keep last 4 messages for continuity
+	// Keep last 4 messages for continuity, moved back to a turn boundary so
+	// no tool-call round is split from its results.
 	if len(history) <= 4 {
-		return
+		return "", nil
+	}
+	keepFrom := len(history) - 4
+	for keepFrom > 0 && !isTurnBoundary(history[keepFrom]) {
+		keepFrom--
+	}
+	if keepFrom == 0 {
+		return "", nil
 	}
+	keepCount := len(history) - keepFrom
 
-	toSummarize := history[:len(history)-4]
+	toSummarize := history[:keepFrom]
 
 	// Oversized Message Guard
 	maxMessageTokens := agent.ContextWindow / 2
@@ -1662,7 +2270,7 @@ func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
 		if m.Role != "user" && m.Role != "assistant" {
 			continue
 		}
-		msgTokens := len(m.Content) / 2
+		msgTokens := al.estimateTokens([]providers.Message{m})
 		if msgTokens > maxMessageTokens {
 			omitted = true
 			continue
@@ -1671,7 +2279,7 @@ func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
 	}
 
 	if len(validMessages) == 0 {
-		return
+		return "", nil
 	}
 
 	const (
@@ -1681,6 +2289,17 @@ func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
 		fallbackMaxContentLength = 200
 	)
 
+	// Any LLM failure counts against the summarization breaker, even when a
+	// truncated fallback summary could still be produced.
+	var llmErr error
+	defer func() {
+		if llmErr != nil {
+			al.summaryBreaker.RecordFailure(llmErr)
+		} else {
+			al.summaryBreaker.RecordSuccess()
+		}
+	}()
+
 	// Multi-Part Summarization
 	var finalSummary string
 	if len(validMessages) > maxSummarizationMessages {
@@ -1691,8 +2310,12 @@ func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
 		part1 := validMessages[:mid]
 		part2 := validMessages[mid:]
 
-		s1, _ := al.summarizeBatch(ctx, agent, part1, "")
-		s2, _ := al.summarizeBatch(ctx, agent, part2, "")
+		s1, err1 := al.summarizeBatch(ctx, agent, part1, "")
+		s2, err2 := al.summarizeBatch(ctx, agent, part2, "")
+		if llmErr = cmp.Or(err1, err2); llmErr != nil {
+			// Provider is failing; don't spend another call on the merge.
+			finalSummary = s1 + " " + s2
+		}
 
 		mergePrompt := fmt.Sprintf(
 			"Merge these two conversation summaries into one cohesive summary:\n\n1: %s\n\n2: %s",
@@ -1700,14 +2323,17 @@ func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
 			s2,
 		)
 
-		resp, err := al.retryLLMCall(ctx, agent, mergePrompt, llmMaxRetries)
-		if err == nil && resp.Content != "" {
-			finalSummary = resp.Content
-		} else {
-			finalSummary = s1 + " " + s2
+		if llmErr == nil {
+			resp, err := al.retryLLMCall(ctx, agent, mergePrompt, llmMaxRetries)
+			if err == nil && resp.Content != "" {
+				finalSummary = resp.Content
+			} else {
+				finalSummary = s1 + " " + s2
+				llmErr = summaryCallError(err)
+			}
 		}
 	} else {
-		finalSummary, _ = al.summarizeBatch(ctx, agent, validMessages, summary)
+		finalSummary, llmErr = al.summarizeBatch(ctx, agent, validMessages, summary)
 	}
 
 	if omitted && finalSummary != "" {
@@ -1716,9 +2342,10 @@ func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
 
 	if finalSummary != "" {
 		agent.Sessions.SetSummary(sessionKey, finalSummary)
-		agent.Sessions.TruncateHistory(sessionKey, 4)
+		agent.Sessions.TruncateHistory(sessionKey, keepCount)
 		agent.Sessions.Save(sessionKey)
 	}
+	return finalSummary, llmErr
 }
 
 // findNearestUserMessage finds the nearest user message to the given index.
@@ -1746,7 +2373,48 @@ func (al *AgentLoop) findNearestUserMessage(messages []providers.Message, mid in
 	return originalMid
 }
 
-// retryLLMCall calls the LLM with retry logic.
+// summaryChatFunc returns the function summarization calls use: session
+// summaries and summarize_url. It sends to the agent's summary_model when one
+// is set. Otherwise it uses the agent's model and, like a chat turn, falls
+// back through its fallback chain. Responses are billed to the turn ctx
+// belongs to; see withCostRecorder.
+func (al *AgentLoop) summaryChatFunc(
+	agent *AgentInstance,
+	limiter *semaphore.Semaphore,
+	fallbackChain *providers.FallbackChain,
+) tools.LLMCallFunc {
+	return func(
+		ctx context.Context,
+		messages []providers.Message,
+		options map[string]any,
+	) (*providers.LLMResponse, error) {
+		provider, model := agent.summaryTarget()
+		candidates := al.agentCandidates(agent)
+		if provider != agent.Provider || len(candidates) <= 1 || fallbackChain == nil {
+			resp, err := providers.LimitedChat(ctx, limiter, provider, messages, nil, model, options)
+			if err == nil {
+				priced := agent.Model
+				if provider != agent.Provider {
+					priced = agent.SummaryModelName
+				}
+				recordUsage(ctx, priced, resp)
+			}
+			return resp, err
+		}
+		result, err := fallbackChain.Execute(ctx, candidates,
+			func(ctx context.Context, _, model string) (*providers.LLMResponse, error) {
+				return providers.LimitedChat(ctx, limiter, agent.Provider, messages, nil, model, options)
+			})
+		if err != nil {
+			return nil, err
+		}
+		recordUsage(ctx, candidateModelName(al.GetConfig(), result.Provider, result.Model), result.Response)
+		return result.Response, nil
+	}
+}
+
+// retryLLMCall calls the agent's summary model (see summaryTarget) with retry
+// logic. It serves summarization, not the chat turn.
 func (al *AgentLoop) retryLLMCall(
 	ctx context.Context,
 	agent *AgentInstance,
@@ -1759,16 +2427,15 @@ func (al *AgentLoop) retryLLMCall(
 
 	var resp *providers.LLMResponse
 	var err error
+	chat := al.summaryChatFunc(agent, al.getLLMLimiter(), al.fallback)
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		al.activeRequests.Add(1)
 		resp, err = func() (*providers.LLMResponse, error) {
 			defer al.activeRequests.Done()
-			return agent.Provider.Chat(
+			return chat(
 				ctx,
 				[]providers.Message{{Role: "user", Content: prompt}},
-				nil,
-				agent.Model,
 				map[string]any{
 					"max_tokens":       agent.MaxTokens,
 					"temperature":      llmTemperature,
@@ -1821,6 +2488,7 @@ func (al *AgentLoop) summarizeBatch(
 	if err == nil && response.Content != "" {
 		return strings.TrimSpace(response.Content), nil
 	}
+	err = summaryCallError(err)
 
 	var fallback strings.Builder
 	fallback.WriteString("Conversation summary: ")
@@ -1850,19 +2518,25 @@ func (al *AgentLoop) summarizeBatch(
 		}
 		fallback.WriteString(fmt.Sprintf("%s: %s", m.Role, content))
 	}
-	return fallback.String(), nil
+	return fallback.String(), err
+}
+
+// summaryCallError normalizes a failed summarization LLM call; an empty
+// response without an error still counts as a failure.
+func summaryCallError(err error) error {
+	if err != nil {
+		return err
+	}
+	return errors.New("empty summarization response")
 }
 
-// estimateTokens estimates the number of tokens in a message list.
-// Uses a safe heuristic of 2.5 characters per token to account for CJK and other
-// overheads better than the previous 3 chars/token.
+// estimateTokens estimates the number of tokens in a message list with the
+// loop's TokenEstimator.
 func (al *AgentLoop) estimateTokens(messages []providers.Message) int {
-	totalChars := 0
-	for _, m := range messages {
-		totalChars += utf8.RuneCountInString(m.Content)
+	if al.tokenEstimator == nil {
+		return charTokenEstimator{}.EstimateMessages(messages)
 	}
-	// 2.5 chars per token = totalChars * 2 / 5
-	return totalChars * 2 / 5
+	return al.tokenEstimator.EstimateMessages(messages)
 }
 
 func (al *AgentLoop) handleCommand(
@@ -1888,6 +2562,7 @@ func (al *AgentLoop) handleCommand(
 		ChatID:   msg.ChatID,
 		SenderID: msg.SenderID,
 		Text:     msg.Content,
+		Admin:    isAdminSender(al.GetConfig(), msg),
 		Reply: func(text string) error {
 			commandReply = text
 			return nil
@@ -1908,13 +2583,41 @@ func (al *AgentLoop) handleCommand(
 	}
 }
 
+// isAdminSender reports whether the sender of msg may run admin-only
+// commands: anyone on an internal channel such as the CLI, or a sender listed
+// in admins. Only canonical "platform:id" entries count, so a bare ID cannot
+// match a user on another platform.
+func isAdminSender(cfg *config.Config, msg bus.InboundMessage) bool {
+	if constants.IsInternalChannel(msg.Channel) {
+		return true
+	}
+	if cfg == nil {
+		return false
+	}
+	sender := msg.Sender
+	if sender.Platform == "" {
+		sender.Platform = msg.Channel
+		sender.PlatformID = msg.SenderID
+	}
+	for _, admin := range cfg.Admins {
+		if _, _, ok := identity.ParseCanonicalID(admin); ok && identity.MatchAllowed(sender, admin) {
+			return true
+		}
+	}
+	return false
+}
+
 func (al *AgentLoop) buildCommandsRuntime(agent *AgentInstance, opts *processOptions) *commands.Runtime {
 	registry := al.GetRegistry()
 	cfg := al.GetConfig()
 	rt := &commands.Runtime{
-		Config:          cfg,
-		ListAgentIDs:    registry.ListAgentIDs,
-		ListDefinitions: al.cmdRegistry.Definitions,
+		Config:           cfg,
+		ListAgentIDs:     registry.ListAgentIDs,
+		GetAgentInfo:     al.agentInfo,
+		ListDefinitions:  al.cmdRegistry.Definitions,
+		ListSummarizing:  al.listSummarizing,
+		ClearSummarizing: al.clearSummarizing,
+		TestModels:       al.testModels,
 		GetEnabledChannels: func() []string {
 			if al.channelManager == nil {
 				return nil
@@ -1940,6 +2643,12 @@ func (al *AgentLoop) buildCommandsRuntime(agent *AgentInstance, opts *processOpt
 			agent.Model = value
 			return oldModel, nil
 		}
+		rt.GetFallbacks = func() []string {
+			return candidateNames(al.agentCandidates(agent))
+		}
+		rt.SetFallbacks = func(models []string) bool {
+			return al.setAgentFallbacks(agent, models)
+		}
 
 		rt.ClearHistory = func() error {
 			if opts == nil {
@@ -1952,6 +2661,130 @@ func (al *AgentLoop) buildCommandsRuntime(agent *AgentInstance, opts *processOpt
 			agent.Sessions.SetHistory(opts.SessionKey, make([]providers.Message, 0))
 			agent.Sessions.SetSummary(opts.SessionKey, "")
 			agent.Sessions.Save(opts.SessionKey)
+			al.clearSessionErrors(opts.SessionKey)
+			if al.state != nil {
+				// A checkpointed task can't be resumed without its history.
+				if err := al.state.SetPendingTask(opts.SessionKey, nil); err != nil {
+					logger.WarnCF("agent", "Failed to clear pending task", map[string]any{
+						"session_key": opts.SessionKey,
+						"error":       err.Error(),
+					})
+				}
+				if err := al.state.SetPendingQuestion(opts.SessionKey, nil); err != nil {
+					logger.WarnCF("agent", "Failed to clear pending question", map[string]any{
+						"session_key": opts.SessionKey,
+						"error":       err.Error(),
+					})
+				}
+				if err := al.state.ClearSessionVars(opts.SessionKey); err != nil {
+					logger.WarnCF("agent", "Failed to clear session variables", map[string]any{
+						"session_key": opts.SessionKey,
+						"error":       err.Error(),
+					})
+				}
+				if err := al.state.ResetSessionCost(opts.SessionKey); err != nil {
+					logger.WarnCF("agent", "Failed to reset session cost", map[string]any{
+						"session_key": opts.SessionKey,
+						"error":       err.Error(),
+					})
+				}
+			}
+			return nil
+		}
+
+		if agent.Sessions != nil && opts != nil {
+			rt.GetSummary = func() string {
+				return agent.Sessions.GetSummary(opts.SessionKey)
+			}
+			rt.RefreshSummary = func(ctx context.Context) (string, error) {
+				return al.refreshSummary(al.withCostRecorder(ctx, *opts), agent, opts.SessionKey)
+			}
+		}
+
+		if al.state != nil && opts != nil {
+			rt.ContinueTask = func(ctx context.Context) (string, bool, error) {
+				return al.continueTask(ctx, agent, *opts)
+			}
+			rt.ListModes = func() []string {
+				return modeNames(agent)
+			}
+			rt.GetMode = func() string {
+				return al.state.GetSessionMode(opts.SessionKey)
+			}
+			rt.SetMode = func(name string) error {
+				return al.state.SetSessionMode(opts.SessionKey, name)
+			}
+			rt.GetPins = func() []string {
+				return al.state.GetSessionPins(opts.SessionKey)
+			}
+			rt.SetPins = func(pins []string) error {
+				return al.state.SetSessionPins(opts.SessionKey, pins)
+			}
+		}
+	}
+	if opts != nil {
+		rt.GetRecentErrors = func() []string {
+			return al.recentSessionErrors(opts.SessionKey)
+		}
+		rt.RunHeartbeat = func(ctx context.Context, tasks string) (string, error) {
+			target := agent
+			if target == nil {
+				target = registry.GetDefaultAgent()
+			}
+			if target == nil {
+				return "", fmt.Errorf("no agent for heartbeat")
+			}
+			return al.runHeartbeat(ctx, target, tasks, opts.Channel, opts.ChatID)
+		}
+	}
+	if al.channelManager != nil && opts != nil {
+		rt.SetPresence = func(ctx context.Context, status string) error {
+			return al.channelManager.SetPresence(ctx, opts.Channel, status)
+		}
+	}
+	if al.state != nil && opts != nil {
+		rt.GetReplyLanguage = func() string {
+			return al.state.GetSessionLanguage(opts.SessionKey)
+		}
+		rt.SetReplyLanguage = func(language string) error {
+			return al.state.SetSessionLanguage(opts.SessionKey, language)
+		}
+	}
+	if al.channelManager != nil && opts != nil {
+		rt.GetGroupTrigger = func() string {
+			return al.channelManager.GetGroupTriggerMode(opts.Channel, opts.ChatID)
+		}
+		rt.SetGroupTrigger = func(mode string) error {
+			if err := al.channelManager.SetGroupTriggerMode(opts.Channel, opts.ChatID, mode); err != nil {
+				return err
+			}
+			if al.state != nil {
+				chatKey := opts.Channel + ":" + opts.ChatID
+				if err := al.state.SetGroupTriggerMode(chatKey, mode); err != nil {
+					logger.WarnCF("agent", "Failed to persist group trigger mode", map[string]any{
+						"chat":  chatKey,
+						"error": err.Error(),
+					})
+				}
+			}
+			return nil
+		}
+		rt.GetGroupPrefixes = func() []string {
+			return al.channelManager.GetGroupTriggerPrefixes(opts.Channel, opts.ChatID)
+		}
+		rt.SetGroupPrefixes = func(prefixes []string) error {
+			if err := al.channelManager.SetGroupTriggerPrefixes(opts.Channel, opts.ChatID, prefixes); err != nil {
+				return err
+			}
+			if al.state != nil {
+				chatKey := opts.Channel + ":" + opts.ChatID
+				if err := al.state.SetGroupTriggerPrefixes(chatKey, prefixes); err != nil {
+					logger.WarnCF("agent", "Failed to persist group prefixes", map[string]any{
+						"chat":  chatKey,
+						"error": err.Error(),
+					})
+				}
+			}
 			return nil
 		}
 	}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// defaultLoopThreshold is used when loop detection is enabled without an
+// explicit threshold.
+const defaultLoopThreshold = 3
+
+// loopNudgeMessage is injected into the conversation when the agent keeps
+// repeating a tool call and there is no (further) model to escalate to.
+const loopNudgeMessage = "[System: you have called %s with the same arguments %d times without making progress. " +
+	"Do not repeat it again. Try a different approach, or explain to the user what is blocking you.]"
+
+// loopDetector counts identical tool calls (same name and arguments) within
+// one turn so a stuck agent can be escalated or nudged instead of burning
+// iterations until max_tool_iterations.
+type loopDetector struct {
+	threshold int
+	seen      map[string]int
+}
+
+// newLoopDetector returns a detector, or nil when threshold disables it.
+func newLoopDetector(threshold int) *loopDetector {
+	if threshold <= 0 {
+		return nil
+	}
+	return &loopDetector{threshold: threshold, seen: make(map[string]int)}
+}
+
+// observe records one iteration's tool calls and returns the name of a tool
+// whose identical call has now been seen threshold times, or "". The count
+// for that call is reset so the detector fires again only after another
+// threshold repetitions.
+func (d *loopDetector) observe(calls []providers.ToolCall) string {
+	if d == nil {
+		return ""
+	}
+	repeated := ""
+	for _, tc := range calls {
+		sig := toolCallSignature(tc)
+		d.seen[sig]++
+		if d.seen[sig] >= d.threshold && repeated == "" {
+			repeated = tc.Name
+			d.seen[sig] = 0
+		}
+	}
+	return repeated
+}
+
+// toolCallSignature identifies a tool call by name and arguments.
+// json.Marshal sorts map keys, so equal arguments yield equal signatures.
+func toolCallSignature(tc providers.ToolCall) string {
+	args, _ := json.Marshal(tc.Arguments)
+	return tc.Name + "\x00" + string(args)
+}
+
+// repeatedCallNote wraps the cached result returned for a tool call that is
+// identical to the one made in the previous iteration.
+const repeatedCallNote = "[You already called %s with these exact arguments in the previous step, " +
+	"so it was not run again. Here is the prior result:]\n\n%s"
+
+type priorToolCall struct {
+	signature string
+	iteration int
+	result    string
+}
+
+// repeatCache remembers the last call of each pure tool within one turn. A
+// call identical to the one made in the immediately preceding iteration is
+// answered from the cache instead of running the tool again, which saves
+// expensive lookups when a model repeats itself. Tools with side effects or
+// changing results (exec, status tools) are never cached. A nil cache, used
+// unless loop_detection.reuse_repeated_calls is set, caches nothing.
+type repeatCache map[string]priorToolCall
+
+// newRepeatCache returns the cache for one turn of agent, or nil when the
+// agent does not reuse repeated calls.
+func newRepeatCache(agent *AgentInstance) repeatCache {
+	if !agent.ReuseRepeatedCalls {
+		return nil
+	}
+	return repeatCache{}
+}
+
+// cacheable reports whether calls to the tool named name may be cached.
+func (c repeatCache) cacheable(agent *AgentInstance, name string) bool {
+	if c == nil || agent.Tools == nil {
+		return false
+	}
+	tool, ok := agent.Tools.Get(name)
+	return ok && tools.IsPure(tool)
+}
+
+// lookup returns the cached result for tc, or nil when the tool must run.
+func (c repeatCache) lookup(tc providers.ToolCall, iteration int) *tools.ToolResult {
+	prior, ok := c[tc.Name]
+	if !ok || prior.iteration != iteration-1 || prior.signature != toolCallSignature(tc) {
+		return nil
+	}
+	return tools.SilentResult(fmt.Sprintf(repeatedCallNote, tc.Name, prior.result))
+}
+
+// record stores the result of a tool call made in iteration. For a call
+// answered by lookup, only the iteration is advanced so the original result
+// keeps being served while the model repeats itself.
+func (c repeatCache) record(tc providers.ToolCall, iteration int, result string, cached bool) {
+	if c == nil {
+		return
+	}
+	if cached {
+		prior := c[tc.Name]
+		prior.iteration = iteration
+		c[tc.Name] = prior
+		return
+	}
+	c[tc.Name] = priorToolCall{
+		signature: toolCallSignature(tc),
+		iteration: iteration,
+		result:    result,
+	}
+}
@@ -0,0 +1,262 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+func TestLoopDetector_Observe(t *testing.T) {
+	call := func(name, path string) []providers.ToolCall {
+		return []providers.ToolCall{{Name: name, Arguments: map[string]any{"path": path}}}
+	}
+
+	d := newLoopDetector(3)
+	if got := d.observe(call("read_file", "a.txt")); got != "" {
+		t.Fatalf("first call reported as loop: %q", got)
+	}
+	if got := d.observe(call("read_file", "b.txt")); got != "" {
+		t.Fatalf("different arguments reported as loop: %q", got)
+	}
+	if got := d.observe(call("read_file", "a.txt")); got != "" {
+		t.Fatalf("second identical call reported as loop: %q", got)
+	}
+	if got := d.observe(call("read_file", "a.txt")); got != "read_file" {
+		t.Fatalf("expected loop on third identical call, got %q", got)
+	}
+	if got := d.observe(call("read_file", "a.txt")); got != "" {
+		t.Errorf("expected count to reset after reporting, got %q", got)
+	}
+
+	disabled := newLoopDetector(0)
+	if disabled != nil {
+		t.Fatal("expected nil detector for threshold 0")
+	}
+	if got := disabled.observe(call("read_file", "a.txt")); got != "" {
+		t.Errorf("nil detector reported a loop: %q", got)
+	}
+}
+
+// loopingProvider repeats the same tool call until it is either called with
+// the escalation model or nudged, then answers.
+type loopingProvider struct {
+	models []string
+}
+
+func (p *loopingProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.models = append(p.models, model)
+	last := messages[len(messages)-1]
+	if model == "strong" {
+		return &providers.LLMResponse{Content: "escalated answer"}, nil
+	}
+	if last.Role == "user" && strings.HasPrefix(last.Content, "[System: you have called mock_custom") {
+		return &providers.LLMResponse{Content: "nudged answer"}, nil
+	}
+	return &providers.LLMResponse{
+		ToolCalls: []providers.ToolCall{{Name: "mock_custom", Arguments: map[string]any{"q": "same"}}},
+	}, nil
+}
+
+func (p *loopingProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
+func TestProcessMessage_LoopDetection(t *testing.T) {
+	newLoop := func(t *testing.T, ld *config.LoopDetectionConfig, provider providers.LLMProvider) *AgentLoop {
+		t.Helper()
+		cfg := &config.Config{
+			Agents: config.AgentsConfig{
+				Defaults: config.AgentDefaults{
+					Workspace:         t.TempDir(),
+					Model:             "test-model",
+					MaxTokens:         4096,
+					MaxToolIterations: 10,
+					LoopDetection:     ld,
+				},
+			},
+			ModelList: []config.ModelConfig{
+				{ModelName: "strong", Model: "openai/strong-model"},
+			},
+		}
+		al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+		al.RegisterTool(&mockCustomTool{})
+		return al
+	}
+	msg := bus.InboundMessage{
+		Channel:  "telegram",
+		SenderID: "user1",
+		ChatID:   "chat1",
+		Content:  "do the thing",
+	}
+
+	t.Run("escalates to the configured model", func(t *testing.T) {
+		provider := &loopingProvider{}
+		al := newLoop(t, &config.LoopDetectionConfig{Enabled: true, Threshold: 2, EscalationModel: "strong"}, provider)
+		response := testHelper{al: al}.executeAndGetResponse(t, context.Background(), msg)
+
+		if response != "escalated answer" {
+			t.Fatalf("expected escalated answer, got %q", response)
+		}
+		if len(provider.models) != 3 {
+			t.Errorf("expected escalation after 2 repeated calls, got models %v", provider.models)
+		}
+	})
+
+	t.Run("nudges without an escalation model", func(t *testing.T) {
+		provider := &loopingProvider{}
+		al := newLoop(t, &config.LoopDetectionConfig{Enabled: true}, provider)
+		response := testHelper{al: al}.executeAndGetResponse(t, context.Background(), msg)
+
+		if response != "nudged answer" {
+			t.Fatalf("expected nudged answer, got %q", response)
+		}
+		if len(provider.models) != defaultLoopThreshold+1 {
+			t.Errorf("expected nudge after %d repeated calls, got %d LLM calls",
+				defaultLoopThreshold, len(provider.models))
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		provider := &loopingProvider{}
+		al := newLoop(t, nil, provider)
+		testHelper{al: al}.executeAndGetResponse(t, context.Background(), msg)
+
+		if len(provider.models) != 10 {
+			t.Errorf("expected the loop to run until max_tool_iterations, got %d LLM calls", len(provider.models))
+		}
+	})
+}
+
+// countingTool counts its executions. pure makes it a tools.PureTool.
+type countingTool struct {
+	runs int
+	pure bool
+}
+
+func (c *countingTool) Name() string               { return "lookup_invoice" }
+func (c *countingTool) Description() string        { return "Looks up an invoice" }
+func (c *countingTool) Parameters() map[string]any { return map[string]any{"type": "object"} }
+func (c *countingTool) Pure() bool                 { return c.pure }
+
+func (c *countingTool) Execute(ctx context.Context, args map[string]any) *tools.ToolResult {
+	c.runs++
+	return tools.SilentResult("invoice 42: unpaid")
+}
+
+// repeatingCallProvider makes the same tool call `repeat` times in a row and
+// then answers, recording the last tool result it saw.
+type repeatingCallProvider struct {
+	repeat     int
+	calls      int
+	lastResult string
+}
+
+func (p *repeatingCallProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	if last := messages[len(messages)-1]; last.Role == "tool" {
+		p.lastResult = last.Content
+	}
+	p.calls++
+	if p.calls > p.repeat {
+		return &providers.LLMResponse{Content: "done"}, nil
+	}
+	return &providers.LLMResponse{
+		ToolCalls: []providers.ToolCall{{
+			ID:        "call_1",
+			Name:      "lookup_invoice",
+			Arguments: map[string]any{"customer": "acme"},
+		}},
+	}, nil
+}
+
+func (p *repeatingCallProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
+func TestProcessMessage_RepeatedToolCallReturnsPriorResult(t *testing.T) {
+	run := func(t *testing.T, reuse, pure bool) (*countingTool, *repeatingCallProvider) {
+		t.Helper()
+		cfg := &config.Config{
+			Agents: config.AgentsConfig{
+				Defaults: config.AgentDefaults{
+					Workspace:         t.TempDir(),
+					Model:             "test-model",
+					MaxTokens:         4096,
+					MaxToolIterations: 10,
+					LoopDetection:     &config.LoopDetectionConfig{ReuseRepeatedCalls: reuse},
+				},
+			},
+		}
+		provider := &repeatingCallProvider{repeat: 3}
+		al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+		tool := &countingTool{pure: pure}
+		al.RegisterTool(tool)
+
+		response := testHelper{al: al}.executeAndGetResponse(t, context.Background(), bus.InboundMessage{
+			Channel:  "telegram",
+			SenderID: "user1",
+			ChatID:   "chat1",
+			Content:  "check invoice 42",
+		})
+		if response != "done" {
+			t.Fatalf("expected final answer, got %q", response)
+		}
+		return tool, provider
+	}
+
+	t.Run("pure tool is reused", func(t *testing.T) {
+		tool, provider := run(t, true, true)
+		if tool.runs != 1 {
+			t.Errorf("expected the tool to run once, ran %d times", tool.runs)
+		}
+		if !strings.Contains(provider.lastResult, "already called lookup_invoice") ||
+			!strings.Contains(provider.lastResult, "invoice 42: unpaid") {
+			t.Errorf("expected repeated call to return the prior result with a note, got %q", provider.lastResult)
+		}
+	})
+
+	t.Run("impure tool always runs", func(t *testing.T) {
+		if tool, _ := run(t, true, false); tool.runs != 3 {
+			t.Errorf("expected the tool to run 3 times, ran %d times", tool.runs)
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		if tool, _ := run(t, false, true); tool.runs != 3 {
+			t.Errorf("expected the tool to run 3 times, ran %d times", tool.runs)
+		}
+	})
+}
+
+func TestRepeatCache_OnlyPreviousIteration(t *testing.T) {
+	tc := providers.ToolCall{Name: "exec", Arguments: map[string]any{"cmd": "ls"}}
+	c := repeatCache{}
+	c.record(tc, 1, "a.txt", false)
+
+	if c.lookup(tc, 3) != nil {
+		t.Error("expected no cached result for a call two iterations later")
+	}
+	other := providers.ToolCall{Name: "exec", Arguments: map[string]any{"cmd": "pwd"}}
+	if c.lookup(other, 2) != nil {
+		t.Error("expected no cached result for different arguments")
+	}
+	if got := c.lookup(tc, 2); got == nil || !strings.Contains(got.ForLLM, "a.txt") {
+		t.Fatalf("expected cached result, got %+v", got)
+	}
+}
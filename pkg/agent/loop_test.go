@@ -163,6 +163,31 @@ func TestRecordLastChatID(t *testing.T) {
 	}
 }
 
+func TestLastActiveTarget_RestoredAfterRestart(t *testing.T) {
+	al, cfg, msgBus, provider, cleanup := newTestAgentLoop(t)
+	defer cleanup()
+
+	if channel, chatID := al.lastActiveTarget(); channel != "" || chatID != "" {
+		t.Errorf("lastActiveTarget() before any chat = %q, %q", channel, chatID)
+	}
+	if err := al.RecordLastChannel("telegram:123456"); err != nil {
+		t.Fatalf("RecordLastChannel failed: %v", err)
+	}
+
+	al2 := NewAgentLoop(cfg, msgBus, provider)
+	channel, chatID := al2.lastActiveTarget()
+	if channel != "telegram" || chatID != "123456" {
+		t.Errorf("lastActiveTarget() after restart = %q, %q, want telegram, 123456", channel, chatID)
+	}
+
+	if err := al2.RecordLastChannel("cli:direct"); err != nil {
+		t.Fatalf("RecordLastChannel failed: %v", err)
+	}
+	if channel, chatID := al2.lastActiveTarget(); channel != "" || chatID != "" {
+		t.Errorf("lastActiveTarget() for internal channel = %q, %q", channel, chatID)
+	}
+}
+
 func TestNewAgentLoop_StateInitialized(t *testing.T) {
 	// Create temp workspace
 	tmpDir, err := os.MkdirTemp("", "agent-test-*")
@@ -514,6 +539,51 @@ func TestProcessMessage_UsesRouteSessionKey(t *testing.T) {
 	}
 }
 
+func TestProcessHeartbeat_DoesNotPersistHistory(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "agent-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         tmpDir,
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "HEARTBEAT_OK"})
+
+	for i := 0; i < 2; i++ {
+		if _, err := al.ProcessHeartbeat(context.Background(), "check tasks", "telegram", "chat1"); err != nil {
+			t.Fatalf("ProcessHeartbeat failed: %v", err)
+		}
+	}
+
+	defaultAgent := al.registry.GetDefaultAgent()
+	if history := defaultAgent.Sessions.GetHistory(heartbeatSessionKey); len(history) != 0 {
+		t.Fatalf("heartbeat session accumulated %d messages, want 0", len(history))
+	}
+}
+
+func TestIsEphemeralSession(t *testing.T) {
+	for key, want := range map[string]bool{
+		"":                        true,
+		heartbeatSessionKey:       true,
+		"agent:main:main":         false,
+		"agent:main:telegram:123": false,
+	} {
+		if got := isEphemeralSession(key); got != want {
+			t.Errorf("isEphemeralSession(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
 func TestProcessMessage_CommandOutcomes(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "agent-test-*")
 	if err != nil {
@@ -845,6 +915,51 @@ func TestAgentLoop_ContextExhaustionRetry(t *testing.T) {
 	}
 }
 
+func TestAgentLoop_ContextErrorWithoutAutoCompress(t *testing.T) {
+	autoCompress := false
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:              t.TempDir(),
+				Model:                  "test-model",
+				MaxTokens:              4096,
+				MaxToolIterations:      10,
+				AutoCompressOnOverflow: &autoCompress,
+			},
+		},
+	}
+	provider := &failFirstMockProvider{
+		failures:    1,
+		failError:   fmt.Errorf("context_length_exceeded: 200000 tokens"),
+		successResp: "should not be reached",
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+
+	sessionKey := "test-session-no-compress"
+	history := []providers.Message{
+		{Role: "user", Content: "Old message 1"},
+		{Role: "assistant", Content: "Old response 1"},
+		{Role: "user", Content: "Old message 2"},
+		{Role: "assistant", Content: "Old response 2"},
+		{Role: "user", Content: "Old message 3"},
+		{Role: "assistant", Content: "Old response 3"},
+	}
+	agent := al.registry.GetDefaultAgent()
+	agent.Sessions.SetHistory(sessionKey, history)
+
+	_, err := al.ProcessDirectWithChannel(context.Background(), "Trigger message", sessionKey, "test", "test-chat")
+	if err == nil || !strings.Contains(err.Error(), "context_length_exceeded") {
+		t.Fatalf("err = %v, want the provider's context error", err)
+	}
+	if provider.currentCall != 1 {
+		t.Errorf("provider calls = %d, want 1 (no compression retry)", provider.currentCall)
+	}
+	// Only the new user message was added; nothing was dropped.
+	if got := agent.Sessions.GetHistory(sessionKey); len(got) != len(history)+1 {
+		t.Errorf("history len = %d, want %d (uncompressed)", len(got), len(history)+1)
+	}
+}
+
 // TestProcessDirectWithChannel_TriggersMCPInitialization verifies that
 // ProcessDirectWithChannel triggers MCP initialization when MCP is enabled.
 // Note: Manager is only initialized when at least one MCP server is configured
@@ -1507,3 +1622,287 @@ func TestFilterClientWebSearch_EmptyInput(t *testing.T) {
 		t.Fatalf("len(result) = %d, want 0", len(result))
 	}
 }
+
+// toolThenAnswerProvider asks for one tool call (with accompanying content)
+// and then answers with finalContent.
+type toolThenAnswerProvider struct {
+	interim      string
+	finalContent string
+	calls        int
+}
+
+func (p *toolThenAnswerProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.calls++
+	if p.calls == 1 {
+		return &providers.LLMResponse{
+			Content: p.interim,
+			ToolCalls: []providers.ToolCall{{
+				ID:        "call_1",
+				Name:      "mock_custom",
+				Arguments: map[string]any{},
+			}},
+		}, nil
+	}
+	return &providers.LLMResponse{Content: p.finalContent}, nil
+}
+
+func (p *toolThenAnswerProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
+func drainOutbound(msgBus *bus.MessageBus) []string {
+	var contents []string
+	for {
+		select {
+		case msg := <-msgBus.OutboundChan():
+			contents = append(contents, msg.Content)
+		default:
+			return contents
+		}
+	}
+}
+
+func TestProcessMessage_SendInterimContent(t *testing.T) {
+	newLoop := func(t *testing.T, enabled bool, provider providers.LLMProvider) (*AgentLoop, *bus.MessageBus) {
+		t.Helper()
+		cfg := &config.Config{
+			Agents: config.AgentsConfig{
+				Defaults: config.AgentDefaults{
+					Workspace:          t.TempDir(),
+					Model:              "test-model",
+					MaxTokens:          4096,
+					MaxToolIterations:  10,
+					SendInterimContent: enabled,
+				},
+			},
+		}
+		msgBus := bus.NewMessageBus()
+		al := NewAgentLoop(cfg, msgBus, provider)
+		al.RegisterTool(&mockCustomTool{})
+		return al, msgBus
+	}
+	msg := bus.InboundMessage{
+		Channel:    "telegram",
+		SenderID:   "user1",
+		ChatID:     "chat1",
+		Content:    "look it up",
+		SessionKey: "test-session",
+	}
+
+	t.Run("sends interim content before tools", func(t *testing.T) {
+		al, msgBus := newLoop(t, true, &toolThenAnswerProvider{interim: "Let me check...", finalContent: "Found it"})
+		response := testHelper{al: al}.executeAndGetResponse(t, context.Background(), msg)
+
+		if response != "Found it" {
+			t.Errorf("expected final response 'Found it', got %q", response)
+		}
+		if got := drainOutbound(msgBus); len(got) != 1 || got[0] != "Let me check..." {
+			t.Errorf("expected interim content to be published once, got %v", got)
+		}
+	})
+
+	t.Run("does not repeat identical final content", func(t *testing.T) {
+		al, msgBus := newLoop(t, true, &toolThenAnswerProvider{interim: "Done.", finalContent: "Done."})
+		response := testHelper{al: al}.executeAndGetResponse(t, context.Background(), msg)
+
+		if response != "" {
+			t.Errorf("expected empty response after interim delivery, got %q", response)
+		}
+		if got := drainOutbound(msgBus); len(got) != 1 {
+			t.Errorf("expected exactly one outbound message, got %v", got)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		al, msgBus := newLoop(t, false, &toolThenAnswerProvider{interim: "Let me check...", finalContent: "Found it"})
+		testHelper{al: al}.executeAndGetResponse(t, context.Background(), msg)
+
+		if got := drainOutbound(msgBus); len(got) != 0 {
+			t.Errorf("expected no interim messages, got %v", got)
+		}
+	})
+}
+
+func TestAgentInfo(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "ok"})
+	al.RegisterTool(&mockCustomTool{})
+
+	info, ok := al.agentInfo("")
+	if !ok || !info.Default || info.ID != "main" {
+		t.Fatalf("agentInfo(\"\") = %+v, %v; want the default agent", info, ok)
+	}
+	if info.Model != "test-model" || info.MaxTokens != 4096 || info.MaxIterations != 10 {
+		t.Errorf("unexpected settings: %+v", info)
+	}
+	if !slices.Contains(info.Tools, "mock_custom") {
+		t.Errorf("tools = %v, want mock_custom listed", info.Tools)
+	}
+
+	if _, ok := al.agentInfo("ghost"); ok {
+		t.Error("expected unknown agent to be reported")
+	}
+}
+
+func TestSelectCandidates_BindingModel(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				ModelName:         "strong",
+				MaxToolIterations: 5,
+			},
+		},
+		ModelList: []config.ModelConfig{
+			{ModelName: "strong", Model: "openai/gpt-4o"},
+			{ModelName: "cheap", Model: "openai/gpt-4o-mini", RequestTimeout: 20},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+	agent := al.registry.GetDefaultAgent()
+
+	_, model := al.selectCandidates(agent, nil, "hi", nil)
+	if model != agent.Model {
+		t.Errorf("model without override = %q, want agent model %q", model, agent.Model)
+	}
+
+	candidates, model := al.selectCandidates(agent, &config.AgentModelConfig{Primary: "cheap"}, "hi", nil)
+	if model != "cheap" {
+		t.Errorf("model = %q, want binding model %q", model, "cheap")
+	}
+	if len(candidates) != 1 || candidates[0].Model != "gpt-4o-mini" || candidates[0].Timeout != 20*time.Second {
+		t.Errorf("candidates = %+v, want gpt-4o-mini with its timeout", candidates)
+	}
+
+	_, model = al.selectCandidates(agent, &config.AgentModelConfig{Primary: "  "}, "hi", nil)
+	if model != agent.Model {
+		t.Errorf("blank override: model = %q, want agent model %q", model, agent.Model)
+	}
+}
+
+// hangingTool blocks until released, ignoring its context.
+type hangingTool struct{ release chan struct{} }
+
+func (h *hangingTool) Name() string               { return "hang" }
+func (h *hangingTool) Description() string        { return "never returns in time" }
+func (h *hangingTool) Parameters() map[string]any { return map[string]any{"type": "object"} }
+func (h *hangingTool) Execute(context.Context, map[string]any) *tools.ToolResult {
+	<-h.release
+	return tools.NewToolResult("too late")
+}
+
+// hangThenAnswerProvider calls the hang tool, then answers with whatever the
+// tool call returned.
+type hangThenAnswerProvider struct{ calls int }
+
+func (p *hangThenAnswerProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	defs []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.calls++
+	if p.calls == 1 {
+		return &providers.LLMResponse{
+			ToolCalls: []providers.ToolCall{{ID: "call_1", Name: "hang", Arguments: map[string]any{}}},
+		}, nil
+	}
+	last := messages[len(messages)-1]
+	return &providers.LLMResponse{Content: last.Content}, nil
+}
+
+func (p *hangThenAnswerProvider) GetDefaultModel() string { return "mock-model" }
+
+func TestRunLLMIteration_ToolCallTimeout(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &hangThenAnswerProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	tool := &hangingTool{release: make(chan struct{})}
+	defer close(tool.release)
+	al.RegisterTool(tool)
+	al.registry.GetDefaultAgent().Tools.SetCallTimeout(50 * time.Millisecond)
+
+	// The tool ignores its context, so only the call timeout can free the
+	// loop; run it in a goroutine to fail instead of hanging if it doesn't.
+	type outcome struct {
+		response string
+		err      error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		response, err := al.processMessage(context.Background(), bus.InboundMessage{
+			Channel:  "telegram",
+			SenderID: "user1",
+			ChatID:   "chat1",
+			Content:  "run it",
+		})
+		done <- outcome{response, err}
+	}()
+
+	select {
+	case out := <-done:
+		if out.err != nil {
+			t.Fatalf("processMessage failed: %v", out.err)
+		}
+		if !strings.Contains(out.response, `tool "hang" timed out after 50ms`) {
+			t.Errorf("response = %q, want the timeout error passed back to the model", out.response)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent loop blocked on a hung tool")
+	}
+}
+
+func TestIsAdminSender(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Admins = []string{"telegram:123", "456"}
+
+	for _, tc := range []struct {
+		name string
+		msg  bus.InboundMessage
+		want bool
+	}{
+		{"cli", bus.InboundMessage{Channel: "cli", SenderID: "user"}, true},
+		{"listed", bus.InboundMessage{Channel: "telegram", SenderID: "123"}, true},
+		{
+			"listed canonical",
+			bus.InboundMessage{
+				Channel:  "telegram",
+				SenderID: "telegram:123",
+				Sender:   bus.SenderInfo{Platform: "telegram", PlatformID: "123", CanonicalID: "telegram:123"},
+			},
+			true,
+		},
+		{"same id on another platform", bus.InboundMessage{Channel: "discord", SenderID: "123"}, false},
+		{"bare id entries are ignored", bus.InboundMessage{Channel: "telegram", SenderID: "456"}, false},
+		{"unlisted", bus.InboundMessage{Channel: "telegram", SenderID: "789"}, false},
+	} {
+		if got := isAdminSender(cfg, tc.msg); got != tc.want {
+			t.Errorf("%s: isAdminSender() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// applySessionMode appends the instruction of the session's active mode to
+// the system message. It is a no-op when no mode is active, the mode is no
+// longer configured for the agent, or there is no system message.
+func applySessionMode(messages []providers.Message, agent *AgentInstance, mode string) []providers.Message {
+	if mode == "" || len(messages) == 0 || messages[0].Role != "system" {
+		return messages
+	}
+	instruction := strings.TrimSpace(agent.Modes[mode])
+	if instruction == "" {
+		return messages
+	}
+	return appendSystemDirective(messages, fmt.Sprintf("## Mode: %s\n%s", mode, instruction))
+}
+
+// sessionMode returns the active prompt mode for a session, or "" when the
+// session uses the agent's default behavior.
+func (al *AgentLoop) sessionMode(sessionKey string) string {
+	if al.state == nil || sessionKey == "" {
+		return ""
+	}
+	return al.state.GetSessionMode(sessionKey)
+}
+
+// modeNames returns the agent's configured mode names in sorted order.
+func modeNames(agent *AgentInstance) []string {
+	names := make([]string, 0, len(agent.Modes))
+	for name := range agent.Modes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
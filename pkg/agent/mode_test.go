@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+func TestApplySessionMode(t *testing.T) {
+	base := func() []providers.Message {
+		return []providers.Message{
+			{
+				Role:        "system",
+				Content:     "system prompt",
+				SystemParts: []providers.ContentBlock{{Type: "text", Text: "system prompt"}},
+			},
+			{Role: "user", Content: "hello"},
+		}
+	}
+	agent := &AgentInstance{Modes: map[string]string{"concise": "Answer in at most two sentences."}}
+
+	msgs := applySessionMode(base(), agent, "")
+	if msgs[0].Content != "system prompt" {
+		t.Fatalf("no mode should not modify system prompt, got %q", msgs[0].Content)
+	}
+
+	msgs = applySessionMode(base(), agent, "concise")
+	if !strings.Contains(msgs[0].Content, "## Mode: concise\nAnswer in at most two sentences.") {
+		t.Fatalf("system prompt missing mode instruction: %q", msgs[0].Content)
+	}
+	if len(msgs[0].SystemParts) != 2 {
+		t.Fatalf("SystemParts len = %d, want 2", len(msgs[0].SystemParts))
+	}
+
+	msgs = applySessionMode(base(), agent, "removed")
+	if msgs[0].Content != "system prompt" {
+		t.Fatalf("unconfigured mode should be ignored, got %q", msgs[0].Content)
+	}
+
+	if got := modeNames(&AgentInstance{Modes: map[string]string{"verbose": "v", "concise": "c"}}); strings.Join(got, ",") != "concise,verbose" {
+		t.Errorf("modeNames = %v, want sorted names", got)
+	}
+}
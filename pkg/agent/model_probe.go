@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/commands"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+const (
+	// modelProbeTimeout bounds each model's test request.
+	modelProbeTimeout = 30 * time.Second
+	// modelTestCooldown is the minimum time between two /models test runs,
+	// since each run sends a paid request to every configured model.
+	modelTestCooldown = time.Minute
+)
+
+// modelProbeTarget is one model to test and how to reach it.
+type modelProbeTarget struct {
+	name     string
+	provider providers.LLMProvider
+	model    string
+	err      error // set when the provider could not be created
+	owned    bool  // provider was created for the probe and must be closed
+}
+
+// testModels sends a tiny request to every model_list entry and to every
+// agent model that is not a model_list alias, concurrently, and reports
+// each model's latency or error in configuration order. Runs less than
+// modelTestCooldown apart are refused.
+func (al *AgentLoop) testModels(ctx context.Context) ([]commands.ModelProbe, error) {
+	al.modelTestMu.Lock()
+	if since := time.Since(al.lastModelTest); !al.lastModelTest.IsZero() && since < modelTestCooldown {
+		al.modelTestMu.Unlock()
+		return nil, fmt.Errorf("models were tested %s ago; try again in %s",
+			since.Round(time.Second), (modelTestCooldown - since).Round(time.Second))
+	}
+	al.lastModelTest = time.Now()
+	al.modelTestMu.Unlock()
+
+	targets := al.modelProbeTargets()
+	probes := make([]commands.ModelProbe, len(targets))
+	limiter := al.getLLMLimiter()
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		probes[i].Name = target.name
+		if target.err != nil {
+			probes[i].Err = target.err
+			continue
+		}
+		wg.Add(1)
+		go func(i int, target modelProbeTarget) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, modelProbeTimeout)
+			defer cancel()
+
+			start := time.Now()
+			_, err := providers.LimitedChat(probeCtx, limiter, target.provider,
+				[]providers.Message{{Role: "user", Content: "Reply with OK."}},
+				nil, target.model, map[string]any{"max_tokens": 16})
+			probes[i].Latency = time.Since(start)
+			probes[i].Err = err
+		}(i, target)
+	}
+	wg.Wait()
+
+	for _, target := range targets {
+		if sp, ok := target.provider.(providers.StatefulProvider); ok && target.owned {
+			sp.Close()
+		}
+	}
+	return probes, nil
+}
+
+// modelProbeTargets lists the models /models test should probe. Entries
+// sharing a model_name (load balancing) are numbered so each endpoint is
+// reported separately.
+func (al *AgentLoop) modelProbeTargets() []modelProbeTarget {
+	cfg := al.GetConfig()
+	var targets []modelProbeTarget
+
+	counts := make(map[string]int, len(cfg.ModelList))
+	for _, mc := range cfg.ModelList {
+		counts[mc.ModelName]++
+	}
+	seen := make(map[string]int, len(cfg.ModelList))
+	for i := range cfg.ModelList {
+		mc := cfg.ModelList[i]
+		seen[mc.ModelName]++
+		name := mc.ModelName
+		if counts[name] > 1 {
+			name = fmt.Sprintf("%s #%d", name, seen[name])
+		}
+		provider, modelID, err := providers.CreateProviderFromConfig(&mc)
+		targets = append(targets, modelProbeTarget{
+			name:     name,
+			provider: provider,
+			model:    modelID,
+			err:      err,
+			owned:    err == nil,
+		})
+	}
+
+	registry := al.GetRegistry()
+	for _, id := range registry.ListAgentIDs() {
+		agent, ok := registry.GetAgent(id)
+		if !ok || agent.Provider == nil || agent.Model == "" || counts[agent.Model] > 0 {
+			continue
+		}
+		targets = append(targets, modelProbeTarget{
+			name:     fmt.Sprintf("%s (agent %s)", agent.Model, id),
+			provider: agent.Provider,
+			model:    agent.Model,
+		})
+	}
+	return targets
+}
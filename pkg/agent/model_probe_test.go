@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestTestModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"OK"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "local-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+		ModelList: []config.ModelConfig{
+			{ModelName: "working", Model: "openai/test-model", APIBase: server.URL, APIKey: "key"},
+			{ModelName: "broken", Model: "bogus/test-model"},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+
+	probes, err := al.testModels(context.Background())
+	if err != nil {
+		t.Fatalf("testModels() error = %v", err)
+	}
+	if len(probes) != 3 {
+		t.Fatalf("expected 3 probes, got %+v", probes)
+	}
+	if probes[0].Name != "working" || probes[0].Err != nil {
+		t.Errorf("expected working model to pass, got %+v", probes[0])
+	}
+	if probes[1].Name != "broken" || probes[1].Err == nil {
+		t.Errorf("expected broken model to fail, got %+v", probes[1])
+	}
+	if !strings.HasPrefix(probes[2].Name, "local-model (agent ") || probes[2].Err != nil {
+		t.Errorf("expected agent model probed through its provider, got %+v", probes[2])
+	}
+}
+
+func TestModelProbeTargets_NumbersLoadBalancedEntries(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "gpt",
+			},
+		},
+		ModelList: []config.ModelConfig{
+			{ModelName: "gpt", Model: "openai/gpt-5.4", APIKey: "a"},
+			{ModelName: "gpt", Model: "openai/gpt-5.4", APIKey: "b"},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+
+	targets := al.modelProbeTargets()
+	if len(targets) != 2 {
+		t.Fatalf("expected only the model_list entries, got %d targets", len(targets))
+	}
+	if targets[0].name != "gpt #1" || targets[1].name != "gpt #2" {
+		t.Errorf("unexpected names %q, %q", targets[0].name, targets[1].name)
+	}
+}
+
+func TestTestModels_Cooldown(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "local-model",
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+
+	if _, err := al.testModels(context.Background()); err != nil {
+		t.Fatalf("first testModels() error = %v", err)
+	}
+	if _, err := al.testModels(context.Background()); err == nil || !strings.Contains(err.Error(), "try again") {
+		t.Fatalf("second testModels() error = %v, want the cooldown error", err)
+	}
+
+	al.lastModelTest = time.Now().Add(-modelTestCooldown)
+	if _, err := al.testModels(context.Background()); err != nil {
+		t.Errorf("testModels() after the cooldown error = %v", err)
+	}
+}
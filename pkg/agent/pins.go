@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// applyPinnedNotes appends the session's pinned notes to the system prompt.
+// They are added on every request, so they survive summarization and history
+// limits.
+func applyPinnedNotes(messages []providers.Message, pins []string) []providers.Message {
+	if len(pins) == 0 || len(messages) == 0 || messages[0].Role != "system" {
+		return messages
+	}
+	var sb strings.Builder
+	sb.WriteString("## Pinned Notes\n")
+	sb.WriteString("The user pinned these notes. They always apply, even when earlier messages are no longer shown.")
+	for i, pin := range pins {
+		fmt.Fprintf(&sb, "\n%d. %s", i+1, pin)
+	}
+	return appendSystemDirective(messages, sb.String())
+}
+
+// sessionPins returns the notes pinned in a session.
+func (al *AgentLoop) sessionPins(sessionKey string) []string {
+	if al.state == nil || sessionKey == "" {
+		return nil
+	}
+	return al.state.GetSessionPins(sessionKey)
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+func TestApplyPinnedNotes(t *testing.T) {
+	base := func() []providers.Message {
+		return []providers.Message{
+			{
+				Role:        "system",
+				Content:     "system prompt",
+				SystemParts: []providers.ContentBlock{{Type: "text", Text: "system prompt"}},
+			},
+			{Role: "user", Content: "hello"},
+		}
+	}
+
+	msgs := applyPinnedNotes(base(), nil)
+	if msgs[0].Content != "system prompt" {
+		t.Fatalf("no pins should not modify system prompt, got %q", msgs[0].Content)
+	}
+
+	msgs = applyPinnedNotes(base(), []string{"My name is Ana", "Budget is 500 EUR"})
+	if !strings.Contains(msgs[0].Content, "## Pinned Notes") ||
+		!strings.Contains(msgs[0].Content, "\n1. My name is Ana\n2. Budget is 500 EUR") {
+		t.Fatalf("system prompt missing pinned notes: %q", msgs[0].Content)
+	}
+	if len(msgs[0].SystemParts) != 2 {
+		t.Fatalf("SystemParts len = %d, want 2", len(msgs[0].SystemParts))
+	}
+}
+
+func TestProcessMessage_PinnedNotesInPrompt(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &recordingProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	helper := testHelper{al: al}
+
+	msg := bus.InboundMessage{
+		Channel:  "telegram",
+		SenderID: "user1",
+		ChatID:   "chat1",
+		Content:  "/pin My name is Ana",
+	}
+	response := helper.executeAndGetResponse(t, context.Background(), msg)
+	if response != "Pinned note 1: My name is Ana" {
+		t.Fatalf("unexpected /pin response %q", response)
+	}
+
+	msg.Content = "what is my name?"
+	helper.executeAndGetResponse(t, context.Background(), msg)
+	if len(provider.lastMessages) == 0 || !strings.Contains(provider.lastMessages[0].Content, "1. My name is Ana") {
+		t.Fatal("expected pinned note in the system prompt")
+	}
+}
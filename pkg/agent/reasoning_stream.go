@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/semaphore"
+)
+
+// Streamed reasoning is forwarded to the reasoning channel in chunks: whatever
+// has arrived is sent once it reaches reasoningFlushChars or has waited
+// reasoningFlushInterval, so long reasoning shows up while it is generated.
+const (
+	reasoningFlushInterval = 500 * time.Millisecond
+	reasoningFlushChars    = 200
+)
+
+var errStreamEnded = errors.New("stream ended without a response")
+
+// chatStreamingReasoning runs one LLM call through a streaming provider and
+// publishes the reasoning to the reasoning channel as it arrives. The last
+// chunk is sent when the stream completes, so the published chunks add up
+// to the full reasoning. The limiter slot is held until the stream ends.
+func (al *AgentLoop) chatStreamingReasoning(
+	ctx context.Context,
+	limiter *semaphore.Semaphore,
+	provider providers.StreamingProvider,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	options map[string]any,
+	channelName, channelID string,
+) (*providers.LLMResponse, error) {
+	if err := limiter.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer limiter.Release()
+
+	deltas, err := provider.ChatStream(ctx, messages, tools, model, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var reasoning, pending strings.Builder
+	flush := func() {
+		if pending.Len() == 0 {
+			return
+		}
+		al.handleReasoning(ctx, pending.String(), channelName, channelID)
+		pending.Reset()
+	}
+
+	ticker := time.NewTicker(reasoningFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case delta, ok := <-deltas:
+			if !ok {
+				flush()
+				return nil, errStreamEnded
+			}
+			if delta.Err != nil {
+				flush()
+				return nil, delta.Err
+			}
+			reasoning.WriteString(delta.Reasoning)
+			pending.WriteString(delta.Reasoning)
+			if delta.Response != nil {
+				flush()
+				if delta.Response.Reasoning == "" {
+					delta.Response.Reasoning = reasoning.String()
+				}
+				return delta.Response, nil
+			}
+			if utf8.RuneCountInString(pending.String()) >= reasoningFlushChars {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// streamingProvider replays scripted deltas, waiting pause before each one.
+type streamingProvider struct {
+	mockProvider
+	deltas []providers.StreamDelta
+	pause  time.Duration
+}
+
+func (p *streamingProvider) ChatStream(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	options map[string]any,
+) (<-chan providers.StreamDelta, error) {
+	ch := make(chan providers.StreamDelta)
+	go func() {
+		defer close(ch)
+		for _, d := range p.deltas {
+			time.Sleep(p.pause)
+			select {
+			case ch <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func newStreamingTestLoop(t *testing.T) (*AgentLoop, *bus.MessageBus) {
+	t.Helper()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxToolIterations: 5,
+			},
+		},
+	}
+	msgBus := bus.NewMessageBus()
+	return NewAgentLoop(cfg, msgBus, &mockProvider{}), msgBus
+}
+
+func TestChatStreamingReasoning_FlushesBySize(t *testing.T) {
+	al, msgBus := newStreamingTestLoop(t)
+	first := strings.Repeat("a", reasoningFlushChars)
+	provider := &streamingProvider{deltas: []providers.StreamDelta{
+		{Reasoning: first[:100]},
+		{Reasoning: first[100:]},
+		{Reasoning: "tail"},
+		{Content: "answer", Response: &providers.LLMResponse{Content: "answer"}},
+	}}
+
+	resp, err := al.chatStreamingReasoning(context.Background(), nil, provider,
+		nil, nil, "test-model", nil, "slack", "reasoning-chan")
+	if err != nil {
+		t.Fatalf("chatStreamingReasoning() error = %v", err)
+	}
+	if resp.Content != "answer" || resp.Reasoning != first+"tail" {
+		t.Errorf("response = %+v, want content and the accumulated reasoning", resp)
+	}
+
+	got := drainOutbound(msgBus)
+	if len(got) != 2 || got[0] != first || got[1] != "tail" {
+		t.Errorf("published chunks = %q, want a %d-char chunk then the final tail", got, reasoningFlushChars)
+	}
+}
+
+func TestChatStreamingReasoning_FlushesByTime(t *testing.T) {
+	al, msgBus := newStreamingTestLoop(t)
+	provider := &streamingProvider{
+		pause: reasoningFlushInterval + 200*time.Millisecond,
+		deltas: []providers.StreamDelta{
+			{Reasoning: "thinking..."},
+			{Reasoning: " done", Response: &providers.LLMResponse{Content: "ok", Reasoning: "thinking... done"}},
+		},
+	}
+
+	resp, err := al.chatStreamingReasoning(context.Background(), nil, provider,
+		nil, nil, "test-model", nil, "slack", "reasoning-chan")
+	if err != nil {
+		t.Fatalf("chatStreamingReasoning() error = %v", err)
+	}
+	if resp.Reasoning != "thinking... done" {
+		t.Errorf("Reasoning = %q", resp.Reasoning)
+	}
+
+	got := drainOutbound(msgBus)
+	if len(got) != 2 || got[0] != "thinking..." || got[1] != " done" {
+		t.Errorf("published chunks = %q, want a timed partial flush then the final chunk", got)
+	}
+}
+
+func TestChatStreamingReasoning_StreamEndsEarly(t *testing.T) {
+	al, _ := newStreamingTestLoop(t)
+	provider := &streamingProvider{deltas: []providers.StreamDelta{{Reasoning: "partial"}}}
+
+	_, err := al.chatStreamingReasoning(context.Background(), nil, provider,
+		nil, nil, "test-model", nil, "slack", "reasoning-chan")
+	if err == nil {
+		t.Fatal("expected an error when the stream closes without a response")
+	}
+}
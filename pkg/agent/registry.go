@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/sipeed/picoclaw/pkg/config"
@@ -126,6 +128,23 @@ func (r *AgentRegistry) Close() {
 	}
 }
 
+// FlushSessions persists the sessions of every agent and returns the
+// failures joined.
+func (r *AgentRegistry) FlushSessions() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var errs []error
+	for _, agent := range r.agents {
+		if agent.Sessions == nil {
+			continue
+		}
+		if err := agent.Sessions.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("agent %s: %w", agent.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // GetDefaultAgent returns the default agent instance.
 func (r *AgentRegistry) GetDefaultAgent() *AgentInstance {
 	r.mu.RLock()
@@ -2,10 +2,15 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/session"
 )
 
 type mockRegistryProvider struct{}
@@ -203,3 +208,73 @@ func TestAgentInstance_FallbackExplicitEmpty(t *testing.T) {
 		t.Errorf("expected 0 fallbacks (explicit empty), got %d: %v", len(agent.Fallbacks), agent.Fallbacks)
 	}
 }
+
+// blockingFlushStore is a session store whose Flush blocks until release is
+// closed. closedMidFlight reports whether Close was called during a Flush.
+type blockingFlushStore struct {
+	session.SessionStore
+	release         chan struct{}
+	inFlush         atomic.Bool
+	closedMidFlight atomic.Bool
+}
+
+func (s *blockingFlushStore) Flush() error {
+	s.inFlush.Store(true)
+	<-s.release
+	s.inFlush.Store(false)
+	return nil
+}
+
+func (s *blockingFlushStore) Close() error {
+	if s.inFlush.Load() {
+		s.closedMidFlight.Store(true)
+	}
+	return s.SessionStore.Close()
+}
+
+func TestFlushSessions(t *testing.T) {
+	cfg := testCfg(nil)
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+	agent := al.registry.GetDefaultAgent()
+
+	agent.Sessions.Close()
+	dir := t.TempDir()
+	agent.Sessions = session.NewSessionManager(dir)
+	agent.Sessions.AddMessage("telegram:1", "user", "half-finished turn")
+	if err := al.FlushSessions(context.Background()); err != nil {
+		t.Fatalf("FlushSessions() error = %v", err)
+	}
+	if got := session.NewSessionManager(dir).GetHistory("telegram:1"); len(got) != 1 {
+		t.Errorf("flushed history = %+v, want the pending message", got)
+	}
+
+	stuck := &blockingFlushStore{SessionStore: agent.Sessions, release: make(chan struct{})}
+	agent.Sessions = stuck
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := al.FlushSessions(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FlushSessions() error = %v, want deadline exceeded", err)
+	}
+
+	// Close waits for the abandoned flush instead of closing the store under it.
+	closed := make(chan struct{})
+	go func() {
+		al.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close() returned while a flush was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(stuck.release)
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close() did not return after the flush finished")
+	}
+	if stuck.closedMidFlight.Load() {
+		t.Error("session store was closed during a flush")
+	}
+}
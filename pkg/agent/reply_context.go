@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// maxReplyQuoteLen caps how much of a quoted message is shown to the model.
+const maxReplyQuoteLen = 1000
+
+// withReplyContext prefixes the user message with the message it replies to,
+// so the model knows what "this" or "that" refers to. Replies without quoted
+// text are left as they are.
+func withReplyContext(content string, reply *bus.ReplyContext) string {
+	if reply == nil || reply.Text == "" {
+		return content
+	}
+	author := reply.Author
+	if author == "" {
+		author = "unknown"
+	}
+	return fmt.Sprintf("[quoted message from %s]: %s\n\n%s",
+		author, utils.Truncate(reply.Text, maxReplyQuoteLen), content)
+}
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+func TestWithReplyContext(t *testing.T) {
+	if got := withReplyContext("hi", nil); got != "hi" {
+		t.Errorf("no reply: got %q", got)
+	}
+	if got := withReplyContext("hi", &bus.ReplyContext{MessageID: "42"}); got != "hi" {
+		t.Errorf("reply without text: got %q", got)
+	}
+
+	got := withReplyContext("why?", &bus.ReplyContext{Author: "alice", Text: "the build is broken"})
+	if want := "[quoted message from alice]: the build is broken\n\nwhy?"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = withReplyContext("ok", &bus.ReplyContext{Text: strings.Repeat("x", maxReplyQuoteLen*2)})
+	if !strings.HasPrefix(got, "[quoted message from unknown]: ") {
+		t.Errorf("missing author should read unknown: %q", got[:40])
+	}
+	if len(got) > maxReplyQuoteLen+100 {
+		t.Errorf("quote was not truncated: %d chars", len(got))
+	}
+}
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+const (
+	// sessionErrorLimit is how many recent errors /diag errors keeps per session.
+	sessionErrorLimit = 5
+	// sessionErrorMaxLen bounds the length of one recorded error message.
+	sessionErrorMaxLen = 300
+	// sessionErrorTTL is how long an error stays visible to /diag errors.
+	// Sessions whose newest error is older are dropped on the next record.
+	sessionErrorTTL = 24 * time.Hour
+)
+
+// secretPatterns match credentials that may appear in provider or tool error
+// messages. The first group of each pattern is kept and the rest replaced.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]{8,}`),
+	regexp.MustCompile(`(?i)(\b(?:api[_-]?key|access[_-]?token|token|secret|password|key)` +
+		`["']?\s*[=:]\s*["']?)[^\s&"',}]+`),
+	regexp.MustCompile(`(sk-)[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`(bot\d+:)[A-Za-z0-9_-]{20,}`),
+}
+
+// redactSecrets masks credentials in s so error text can be shown to users.
+func redactSecrets(s string) string {
+	for _, re := range secretPatterns {
+		s = re.ReplaceAllString(s, "${1}[REDACTED]")
+	}
+	return s
+}
+
+// sessionError is one failure recorded for a session.
+type sessionError struct {
+	at      time.Time
+	message string
+}
+
+// sessionErrorLog is a bounded ring of a session's most recent errors.
+type sessionErrorLog struct {
+	mu      sync.Mutex
+	entries []sessionError
+}
+
+// recordSessionError remembers a failure for /diag errors. source names what
+// failed (e.g. "tool exec"); the message is redacted and truncated.
+func (al *AgentLoop) recordSessionError(sessionKey, source string, err error) {
+	if sessionKey == "" || err == nil {
+		return
+	}
+	msg := err.Error()
+	if source != "" {
+		msg = source + ": " + msg
+	}
+	entry := sessionError{
+		at:      time.Now(),
+		message: utils.Truncate(redactSecrets(msg), sessionErrorMaxLen),
+	}
+
+	al.pruneSessionErrors(entry.at)
+
+	v, _ := al.sessionErrors.LoadOrStore(sessionKey, &sessionErrorLog{})
+	log := v.(*sessionErrorLog)
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.entries = append(log.entries, entry)
+	if len(log.entries) > sessionErrorLimit {
+		log.entries = log.entries[len(log.entries)-sessionErrorLimit:]
+	}
+}
+
+// pruneSessionErrors forgets sessions whose newest error expired before now,
+// so sessions that are never cleared don't accumulate for the process life.
+func (al *AgentLoop) pruneSessionErrors(now time.Time) {
+	al.sessionErrors.Range(func(key, v any) bool {
+		log := v.(*sessionErrorLog)
+		log.mu.Lock()
+		stale := len(log.entries) == 0 || now.Sub(log.entries[len(log.entries)-1].at) > sessionErrorTTL
+		log.mu.Unlock()
+		if stale {
+			al.sessionErrors.CompareAndDelete(key, v)
+		}
+		return true
+	})
+}
+
+// clearSessionErrors forgets the recorded errors of a session, e.g. on /clear.
+func (al *AgentLoop) clearSessionErrors(sessionKey string) {
+	al.sessionErrors.Delete(sessionKey)
+}
+
+// recentSessionErrors lists a session's recorded errors, oldest first.
+func (al *AgentLoop) recentSessionErrors(sessionKey string) []string {
+	v, ok := al.sessionErrors.Load(sessionKey)
+	if !ok {
+		return nil
+	}
+	log := v.(*sessionErrorLog)
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	now := time.Now()
+	out := make([]string, 0, len(log.entries))
+	for _, e := range log.entries {
+		if now.Sub(e.at) > sessionErrorTTL {
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s %s", e.at.Format("2006-01-02 15:04:05"), e.message))
+	}
+	return out
+}
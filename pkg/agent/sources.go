@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// maxCitedSources bounds the sources list appended to an answer.
+const maxCitedSources = 5
+
+// sourceTracker collects the URLs web tools returned during one turn so the
+// final answer can be annotated with the ones it relied on.
+type sourceTracker struct {
+	fetched []string // pages the agent read (web_fetch, summarize_url, fetch_feed)
+	found   []string // search hits (web_search)
+	seen    map[string]bool
+}
+
+func newSourceTracker() *sourceTracker {
+	return &sourceTracker{seen: make(map[string]bool)}
+}
+
+// record captures source URLs from a successful web tool call.
+func (s *sourceTracker) record(tc providers.ToolCall, result *tools.ToolResult) {
+	if result == nil || result.IsError {
+		return
+	}
+	switch tc.Name {
+	case "web_fetch", "summarize_url", "fetch_feed":
+		if u, _ := tc.Arguments["url"].(string); u != "" {
+			s.add(&s.fetched, u)
+		}
+	case "web_search":
+		for _, line := range strings.Split(result.ForLLM, "\n") {
+			if u, ok := strings.CutPrefix(strings.TrimSpace(line), "URL: "); ok {
+				s.add(&s.found, u)
+			}
+		}
+	}
+}
+
+func (s *sourceTracker) add(list *[]string, u string) {
+	u = strings.TrimSpace(u)
+	if u == "" || s.seen[u] {
+		return
+	}
+	s.seen[u] = true
+	*list = append(*list, u)
+}
+
+// annotate appends a compact sources list to answer. Fetched pages are always
+// listed; search hits only when the answer mentions their URL or site.
+func (s *sourceTracker) annotate(answer string) string {
+	if strings.TrimSpace(answer) == "" {
+		return answer
+	}
+
+	sources := append([]string(nil), s.fetched...)
+	lower := strings.ToLower(answer)
+	for _, u := range s.found {
+		if answerReferences(lower, u) {
+			sources = append(sources, u)
+		}
+	}
+	if len(sources) == 0 {
+		return answer
+	}
+	if len(sources) > maxCitedSources {
+		sources = sources[:maxCitedSources]
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(answer, "\n"))
+	b.WriteString("\n\nSources:")
+	for _, u := range sources {
+		b.WriteString("\n- ")
+		b.WriteString(u)
+	}
+	return b.String()
+}
+
+// answerReferences reports whether the lowercased answer mentions u or its host.
+func answerReferences(lowerAnswer, u string) bool {
+	if strings.Contains(lowerAnswer, strings.ToLower(u)) {
+		return true
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	return host != "" && strings.Contains(lowerAnswer, host)
+}
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+func TestSourceTracker_Annotate(t *testing.T) {
+	s := newSourceTracker()
+	s.record(providers.ToolCall{Name: "web_search"}, &tools.ToolResult{
+		ForLLM: "Results for: go (via Brave)\n\n[1] Go\n    URL: https://www.go.dev/doc\n" +
+			"\n[2] Other\n    URL: https://example.org/page\n",
+	})
+	s.record(providers.ToolCall{
+		Name:      "web_fetch",
+		Arguments: map[string]any{"url": "https://blog.example.com/post"},
+	}, &tools.ToolResult{ForLLM: "{}"})
+	s.record(providers.ToolCall{
+		Name:      "web_fetch",
+		Arguments: map[string]any{"url": "https://failed.example.com/"},
+	}, tools.ErrorResult("fetch failed"))
+
+	got := s.annotate("According to go.dev, generics landed in 1.18.")
+	want := "According to go.dev, generics landed in 1.18.\n\nSources:\n" +
+		"- https://blog.example.com/post\n- https://www.go.dev/doc"
+	if got != want {
+		t.Errorf("annotate() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestSourceTracker_NoSources(t *testing.T) {
+	s := newSourceTracker()
+	s.record(providers.ToolCall{Name: "exec"}, &tools.ToolResult{ForLLM: "URL: https://example.com/"})
+	s.record(providers.ToolCall{Name: "web_search"}, &tools.ToolResult{
+		ForLLM: "Results for: x (via Brave)\n\n[1] X\n    URL: https://unrelated.example.net/\n",
+	})
+
+	answer := "Nothing to cite here."
+	if got := s.annotate(answer); got != answer {
+		t.Errorf("expected answer unchanged, got %q", got)
+	}
+	if got := s.annotate(""); got != "" {
+		t.Errorf("expected empty answer unchanged, got %q", got)
+	}
+	if !strings.Contains(s.annotate("see unrelated.example.net"), "Sources:") {
+		t.Error("expected a referenced search hit to be cited")
+	}
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// summarizeTimeout bounds one summarization, background or on demand.
+const summarizeTimeout = 120 * time.Second
+
+// summarizingStaleAfter is how long an in-flight summarization may hold its
+// session's slot before it is considered stuck. summarizeSession runs under a
+// 120s context, so anything older has lost its goroutine.
+const summarizingStaleAfter = 5 * time.Minute
+
+// errSummarizing is returned by refreshSummary while another summarization
+// of the same session is running.
+var errSummarizing = errors.New("a summarization of this session is already in progress")
+
+// summarizingEntry marks one in-flight background summarization. The pointer
+// identity lets the owning goroutine release only its own entry, even if a
+// stale entry was reclaimed and replaced in the meantime.
+type summarizingEntry struct {
+	started time.Time
+}
+
+// tryStartSummarizing claims the summarization slot for key. It returns nil
+// if another summarization is already running; a stale entry is reclaimed.
+func (al *AgentLoop) tryStartSummarizing(key string) *summarizingEntry {
+	entry := &summarizingEntry{started: time.Now()}
+	for {
+		existing, loaded := al.summarizing.LoadOrStore(key, entry)
+		if !loaded {
+			return entry
+		}
+		old, ok := existing.(*summarizingEntry)
+		if ok && time.Since(old.started) < summarizingStaleAfter {
+			return nil
+		}
+		if al.summarizing.CompareAndDelete(key, existing) {
+			logger.WarnCF("agent", "Reclaimed stale summarization slot", map[string]any{
+				"key": key,
+			})
+		}
+	}
+}
+
+// finishSummarizing releases the slot claimed by tryStartSummarizing.
+func (al *AgentLoop) finishSummarizing(key string, entry *summarizingEntry) {
+	al.summarizing.CompareAndDelete(key, entry)
+}
+
+// isSummarizing reports whether a non-stale summarization holds key.
+func (al *AgentLoop) isSummarizing(key string) bool {
+	v, ok := al.summarizing.Load(key)
+	if !ok {
+		return false
+	}
+	entry, ok := v.(*summarizingEntry)
+	return ok && time.Since(entry.started) < summarizingStaleAfter
+}
+
+// listSummarizing describes the in-flight summarizations, oldest first.
+func (al *AgentLoop) listSummarizing() []string {
+	type item struct {
+		key     string
+		started time.Time
+	}
+	var items []item
+	al.summarizing.Range(func(k, v any) bool {
+		key, _ := k.(string)
+		if entry, ok := v.(*summarizingEntry); ok {
+			items = append(items, item{key: key, started: entry.started})
+		}
+		return true
+	})
+	sort.Slice(items, func(i, j int) bool { return items[i].started.Before(items[j].started) })
+
+	lines := make([]string, 0, len(items))
+	for _, it := range items {
+		age := time.Since(it.started).Round(time.Second)
+		line := fmt.Sprintf("%s (running %s)", it.key, age)
+		if age >= summarizingStaleAfter {
+			line += " [stale]"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// clearSummarizing drops every in-flight marker and returns how many were
+// removed. Goroutines still running finish normally; their release is a no-op.
+func (al *AgentLoop) clearSummarizing() int {
+	n := 0
+	al.summarizing.Range(func(k, _ any) bool {
+		al.summarizing.Delete(k)
+		n++
+		return true
+	})
+	return n
+}
+
+// refreshSummary summarizes the session now and waits for the result, for
+// /summary refresh. It shares the slot used by background summarization so
+// the two never rewrite the same session concurrently.
+func (al *AgentLoop) refreshSummary(ctx context.Context, agent *AgentInstance, sessionKey string) (string, error) {
+	key := agent.ID + ":" + sessionKey
+	entry := al.tryStartSummarizing(key)
+	if entry == nil {
+		return "", errSummarizing
+	}
+	defer al.finishSummarizing(key, entry)
+
+	ctx, cancel := context.WithTimeout(ctx, summarizeTimeout)
+	defer cancel()
+	return al.summarizeSessionWithContext(ctx, agent, sessionKey)
+}
@@ -0,0 +1,231 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+func TestSummarizing_ClaimAndRelease(t *testing.T) {
+	al := &AgentLoop{}
+
+	entry := al.tryStartSummarizing("main:s1")
+	if entry == nil {
+		t.Fatal("expected to claim a free slot")
+	}
+	if al.tryStartSummarizing("main:s1") != nil {
+		t.Fatal("expected second claim to be rejected while in flight")
+	}
+	if !al.isSummarizing("main:s1") {
+		t.Fatal("expected key to be reported as summarizing")
+	}
+
+	al.finishSummarizing("main:s1", entry)
+	if al.isSummarizing("main:s1") {
+		t.Fatal("expected slot to be released")
+	}
+}
+
+func TestSummarizing_ReclaimsStaleEntry(t *testing.T) {
+	al := &AgentLoop{}
+	stale := &summarizingEntry{started: time.Now().Add(-2 * summarizingStaleAfter)}
+	al.summarizing.Store("main:s1", stale)
+
+	if al.isSummarizing("main:s1") {
+		t.Fatal("stale entry should not block summarization")
+	}
+	if !strings.HasSuffix(al.listSummarizing()[0], "[stale]") {
+		t.Fatalf("expected stale marker, got %v", al.listSummarizing())
+	}
+
+	fresh := al.tryStartSummarizing("main:s1")
+	if fresh == nil {
+		t.Fatal("expected stale slot to be reclaimed")
+	}
+
+	// The old goroutine finishing late must not release the new claim.
+	al.finishSummarizing("main:s1", stale)
+	if !al.isSummarizing("main:s1") {
+		t.Fatal("late release of stale entry removed the fresh claim")
+	}
+}
+
+func TestSummarizing_Clear(t *testing.T) {
+	al := &AgentLoop{}
+	al.tryStartSummarizing("main:s1")
+	al.tryStartSummarizing("main:s2")
+
+	if got := len(al.listSummarizing()); got != 2 {
+		t.Fatalf("listed %d entries, want 2", got)
+	}
+	if n := al.clearSummarizing(); n != 2 {
+		t.Fatalf("cleared %d, want 2", n)
+	}
+	if got := al.listSummarizing(); len(got) != 0 {
+		t.Fatalf("expected empty list after clear, got %v", got)
+	}
+}
+
+func TestRefreshSummary(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "talked about cats"})
+	agent := al.registry.GetDefaultAgent()
+	const sessionKey = "agent:main:telegram:direct:user1"
+
+	if summary, err := al.refreshSummary(context.Background(), agent, sessionKey); err != nil || summary != "" {
+		t.Fatalf("expected nothing to summarize for an empty session, got %q, %v", summary, err)
+	}
+
+	for i := 0; i < 4; i++ {
+		agent.Sessions.AddMessage(sessionKey, "user", "question")
+		agent.Sessions.AddMessage(sessionKey, "assistant", "answer")
+	}
+
+	entry := al.tryStartSummarizing(agent.ID + ":" + sessionKey)
+	if _, err := al.refreshSummary(context.Background(), agent, sessionKey); err != errSummarizing {
+		t.Fatalf("expected errSummarizing while a summarization runs, got %v", err)
+	}
+	al.finishSummarizing(agent.ID+":"+sessionKey, entry)
+
+	summary, err := al.refreshSummary(context.Background(), agent, sessionKey)
+	if err != nil {
+		t.Fatalf("refreshSummary() error = %v", err)
+	}
+	if summary != "talked about cats" || agent.Sessions.GetSummary(sessionKey) != summary {
+		t.Errorf("expected the new summary to be returned and saved, got %q", summary)
+	}
+	if got := len(agent.Sessions.GetHistory(sessionKey)); got != 4 {
+		t.Errorf("expected history truncated to 4 messages, got %d", got)
+	}
+}
+
+// modelRecordingProvider records the model of every call.
+type modelRecordingProvider struct {
+	models []string
+}
+
+func (p *modelRecordingProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.models = append(p.models, model)
+	return &providers.LLMResponse{Content: "summary"}, nil
+}
+
+func (p *modelRecordingProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
+func TestSummarizeSession_UsesSummaryModel(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "premium-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	chat := &modelRecordingProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), chat)
+	agent := al.registry.GetDefaultAgent()
+	cheap := &modelRecordingProvider{}
+	agent.SummaryProvider, agent.SummaryModel = cheap, "cheap-model"
+
+	// Enough messages to take the multi-part path, so the merge call is
+	// covered as well.
+	const sessionKey = "agent:main:telegram:direct:user1"
+	for i := 0; i < 8; i++ {
+		agent.Sessions.AddMessage(sessionKey, "user", "question")
+		agent.Sessions.AddMessage(sessionKey, "assistant", "answer")
+	}
+
+	if _, err := al.summarizeSessionWithContext(context.Background(), agent, sessionKey); err != nil {
+		t.Fatalf("summarizeSessionWithContext() error = %v", err)
+	}
+	if len(chat.models) != 0 {
+		t.Errorf("chat model was called for summarization: %v", chat.models)
+	}
+	if len(cheap.models) != 3 {
+		t.Fatalf("summary model calls = %v, want two batches and a merge", cheap.models)
+	}
+	for _, model := range cheap.models {
+		if model != "cheap-model" {
+			t.Errorf("summary call used model %q, want cheap-model", model)
+		}
+	}
+}
+
+// rateLimitedModelProvider fails every call to one model with a rate limit.
+type rateLimitedModelProvider struct {
+	modelRecordingProvider
+	limited string
+}
+
+func (p *rateLimitedModelProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.models = append(p.models, model)
+	if model == p.limited {
+		return nil, errors.New("status 429: rate limit exceeded")
+	}
+	return &providers.LLMResponse{
+		Content: "summary from " + model,
+		Usage:   &providers.UsageInfo{PromptTokens: 1000},
+	}, nil
+}
+
+func TestSummaryChatFunc_FallsBackWithoutSummaryModel(t *testing.T) {
+	provider := &rateLimitedModelProvider{limited: "primary"}
+	agent := &AgentInstance{
+		Provider: provider,
+		Model:    "primary",
+		Candidates: []providers.FallbackCandidate{
+			{Provider: "openai", Model: "primary"},
+			{Provider: "openai", Model: "backup"},
+		},
+	}
+	chat := (&AgentLoop{}).summaryChatFunc(agent, nil, providers.NewFallbackChain(providers.NewCooldownTracker(0)))
+
+	resp, err := chat(context.Background(), []providers.Message{{Role: "user", Content: "page"}}, nil)
+	if err != nil {
+		t.Fatalf("chat() error = %v", err)
+	}
+	if resp.Content != "summary from backup" {
+		t.Errorf("content = %q, want the backup model's summary", resp.Content)
+	}
+
+	// A summary_model is used on its own.
+	cheap := &modelRecordingProvider{}
+	agent.SummaryProvider, agent.SummaryModel = cheap, "cheap-model"
+	provider.models = nil
+	if _, err := chat(context.Background(), []providers.Message{{Role: "user", Content: "page"}}, nil); err != nil {
+		t.Fatalf("chat() with summary model error = %v", err)
+	}
+	if len(provider.models) != 0 || len(cheap.models) != 1 || cheap.models[0] != "cheap-model" {
+		t.Errorf("calls: chat model %v, summary model %v", provider.models, cheap.models)
+	}
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	summaryBreakerThreshold = 3
+	summaryBreakerCooldown  = 5 * time.Minute
+)
+
+// summaryBreaker is a circuit breaker shared by all background
+// summarization calls. After threshold consecutive failures it opens and
+// rejects summarization for the cooldown period, so a provider outage does
+// not spawn a doomed long-running call for every session that crosses the
+// threshold. Once the cooldown elapses a single probe is let through
+// (half-open); its outcome closes or re-opens the breaker.
+type summaryBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+	nowFunc   func() time.Time
+}
+
+func newSummaryBreaker(threshold int, cooldown time.Duration) *summaryBreaker {
+	return &summaryBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		nowFunc:   time.Now,
+	}
+}
+
+// Allow reports whether a summarization attempt may start now.
+func (b *summaryBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if b.probing || b.nowFunc().Before(b.openUntil) {
+		return false
+	}
+
+	b.probing = true
+	logger.InfoCF("agent", "Summarization breaker half-open, probing provider", map[string]any{
+		"failures": b.failures,
+	})
+	return true
+}
+
+// RecordSuccess closes the breaker and resets the failure count.
+func (b *summaryBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.openUntil.IsZero() {
+		logger.InfoCF("agent", "Summarization breaker closed", map[string]any{
+			"failures": b.failures,
+		})
+	}
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.probing = false
+}
+
+// Release clears a pending half-open probe that ended without an LLM
+// outcome. It is a no-op after RecordSuccess or RecordFailure.
+func (b *summaryBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// RecordFailure counts a failed summarization and opens the breaker once
+// the threshold is reached. A failed probe re-opens it immediately.
+func (b *summaryBreaker) RecordFailure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if !b.probing && b.failures < b.threshold {
+		return
+	}
+
+	b.probing = false
+	b.openUntil = b.nowFunc().Add(b.cooldown)
+	fields := map[string]any{
+		"failures":         b.failures,
+		"cooldown_seconds": b.cooldown.Seconds(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	logger.WarnCF("agent", "Summarization breaker open, pausing summarization", fields)
+}
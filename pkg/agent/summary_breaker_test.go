@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSummaryBreaker_OpensAfterThreshold(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newSummaryBreaker(3, time.Minute)
+	b.nowFunc = func() time.Time { return now }
+
+	errDown := errors.New("provider down")
+	for i := 0; i < 2; i++ {
+		if !b.Allow() {
+			t.Fatalf("Allow() = false before threshold (failure %d)", i)
+		}
+		b.RecordFailure(errDown)
+	}
+	if !b.Allow() {
+		t.Fatal("Allow() = false before threshold reached")
+	}
+	b.RecordFailure(errDown)
+
+	if b.Allow() {
+		t.Fatal("Allow() = true while breaker is open")
+	}
+
+	// Cooldown elapsed: exactly one probe is allowed
+	now = now.Add(time.Minute)
+	if !b.Allow() {
+		t.Fatal("Allow() = false after cooldown, want half-open probe")
+	}
+	if b.Allow() {
+		t.Fatal("Allow() = true for a second concurrent probe")
+	}
+
+	// Failed probe re-opens immediately
+	b.RecordFailure(errDown)
+	if b.Allow() {
+		t.Fatal("Allow() = true after failed probe")
+	}
+
+	// Successful probe closes the breaker
+	now = now.Add(time.Minute)
+	if !b.Allow() {
+		t.Fatal("Allow() = false after second cooldown")
+	}
+	b.RecordSuccess()
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("Allow() = false after breaker closed")
+	}
+}
+
+func TestSummaryBreaker_SuccessResetsFailures(t *testing.T) {
+	b := newSummaryBreaker(2, time.Minute)
+
+	b.RecordFailure(errors.New("timeout"))
+	b.RecordSuccess()
+	b.RecordFailure(errors.New("timeout"))
+
+	if !b.Allow() {
+		t.Fatal("Allow() = false, want failures reset by success")
+	}
+}
+
+func TestSummaryBreaker_ReleaseFreesProbe(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newSummaryBreaker(1, time.Minute)
+	b.nowFunc = func() time.Time { return now }
+
+	b.RecordFailure(errors.New("timeout"))
+	now = now.Add(time.Minute)
+	if !b.Allow() {
+		t.Fatal("Allow() = false after cooldown")
+	}
+
+	// Probe ended without calling the LLM
+	b.Release()
+	if !b.Allow() {
+		t.Fatal("Allow() = false after probe was released")
+	}
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// TokenEstimator estimates how many tokens messages take up in a model's
+// context. It drives the token threshold for summarization and the
+// oversized message guard.
+//
+// A provider that implements TokenEstimator is used as the estimator by
+// default; WithTokenEstimator overrides it.
+type TokenEstimator interface {
+	EstimateMessages(messages []providers.Message) int
+}
+
+// AgentLoopOption configures optional AgentLoop dependencies.
+type AgentLoopOption func(*AgentLoop)
+
+// WithTokenEstimator replaces the default character-based token estimate,
+// e.g. with the tokenizer of a local model.
+func WithTokenEstimator(e TokenEstimator) AgentLoopOption {
+	return func(al *AgentLoop) {
+		if e != nil {
+			al.tokenEstimator = e
+		}
+	}
+}
+
+// charTokenEstimator estimates 2.5 characters per token, a safe heuristic
+// that accounts for CJK and other overheads better than 3 chars/token.
+type charTokenEstimator struct{}
+
+func (charTokenEstimator) EstimateMessages(messages []providers.Message) int {
+	totalChars := 0
+	for _, m := range messages {
+		totalChars += utf8.RuneCountInString(m.Content)
+	}
+	// 2.5 chars per token = totalChars * 2 / 5
+	return totalChars * 2 / 5
+}
+
+// defaultTokenEstimator returns the provider's own estimator when it has
+// one, otherwise the character heuristic.
+func defaultTokenEstimator(provider providers.LLMProvider) TokenEstimator {
+	if e, ok := provider.(TokenEstimator); ok {
+		return e
+	}
+	return charTokenEstimator{}
+}
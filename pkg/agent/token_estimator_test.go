@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// fakeTokenEstimator counts a fixed number of tokens per message.
+type fakeTokenEstimator struct {
+	perMessage int
+}
+
+func (f fakeTokenEstimator) EstimateMessages(messages []providers.Message) int {
+	return len(messages) * f.perMessage
+}
+
+func newEstimatorTestLoop(t *testing.T, estimator TokenEstimator) (*AgentLoop, *AgentInstance) {
+	t.Helper()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "test-model",
+				MaxTokens: 4096, // token threshold 3072, oversized message guard 2048
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "summary"},
+		WithTokenEstimator(estimator))
+	return al, al.registry.GetDefaultAgent()
+}
+
+func addExchanges(agent *AgentInstance, sessionKey string, n int) {
+	for range n {
+		agent.Sessions.AddMessage(sessionKey, "user", "question")
+		agent.Sessions.AddMessage(sessionKey, "assistant", "answer")
+	}
+}
+
+func TestMaybeSummarize_UsesTokenEstimator(t *testing.T) {
+	const sessionKey = "agent:main:telegram:direct:1"
+
+	// 6 short messages are far below both thresholds for the heuristic, but
+	// the injected estimator puts them at 3600 tokens.
+	al, agent := newEstimatorTestLoop(t, fakeTokenEstimator{perMessage: 600})
+	addExchanges(agent, sessionKey, 3)
+	al.maybeSummarize(context.Background(), agent, sessionKey, "telegram", "1")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for agent.Sessions.GetSummary(sessionKey) == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("expected summarization to be triggered by the estimator")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	al, agent = newEstimatorTestLoop(t, fakeTokenEstimator{perMessage: 1})
+	addExchanges(agent, sessionKey, 3)
+	al.maybeSummarize(context.Background(), agent, sessionKey, "telegram", "1")
+	if al.isSummarizing(agent.ID + ":" + sessionKey) {
+		t.Fatal("expected no summarization below the estimated threshold")
+	}
+}
+
+func TestSummarizeSession_OversizedGuardUsesTokenEstimator(t *testing.T) {
+	const sessionKey = "agent:main:telegram:direct:1"
+
+	al, agent := newEstimatorTestLoop(t, fakeTokenEstimator{perMessage: 5000})
+	addExchanges(agent, sessionKey, 4)
+	summary, err := al.summarizeSessionWithContext(context.Background(), agent, sessionKey)
+	if err != nil || summary != "" {
+		t.Fatalf("expected every message to be skipped as oversized, got %q, %v", summary, err)
+	}
+
+	al, agent = newEstimatorTestLoop(t, fakeTokenEstimator{perMessage: 10})
+	addExchanges(agent, sessionKey, 4)
+	summary, err = al.summarizeSessionWithContext(context.Background(), agent, sessionKey)
+	if err != nil || summary != "summary" {
+		t.Fatalf("expected messages to be summarized, got %q, %v", summary, err)
+	}
+}
+
+func TestDefaultTokenEstimator(t *testing.T) {
+	if _, ok := defaultTokenEstimator(&simpleMockProvider{}).(charTokenEstimator); !ok {
+		t.Error("expected the character heuristic for providers without a tokenizer")
+	}
+	provider := &estimatingProvider{}
+	if defaultTokenEstimator(provider) != TokenEstimator(provider) {
+		t.Error("expected a provider implementing TokenEstimator to be used")
+	}
+
+	msgs := []providers.Message{{Role: "user", Content: "0123456789"}}
+	if got := (charTokenEstimator{}).EstimateMessages(msgs); got != 4 {
+		t.Errorf("EstimateMessages() = %d, want 4", got)
+	}
+}
+
+// estimatingProvider is a provider that supplies its own tokenizer.
+type estimatingProvider struct {
+	simpleMockProvider
+}
+
+func (p *estimatingProvider) EstimateMessages(messages []providers.Message) int {
+	return len(messages)
+}
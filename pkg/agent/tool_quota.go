@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// checkToolQuota enforces tools.daily_quotas for userID. It returns nil when
+// the call may proceed, or an error result to hand back to the LLM instead of
+// executing the tool. Calls without a user identity (cron, heartbeat, CLI)
+// are not metered.
+func (al *AgentLoop) checkToolQuota(userID, toolName string) *tools.ToolResult {
+	cfg := al.GetConfig()
+	if cfg == nil || userID == "" || al.state == nil {
+		return nil
+	}
+	limit := cfg.Tools.DailyQuotas[toolName]
+	if limit <= 0 {
+		return nil
+	}
+
+	allowed, err := al.state.ConsumeToolQuota(userID, toolName, limit)
+	if err != nil {
+		// Failing to persist the counter should not block the user.
+		logger.WarnCF("agent", "Failed to record tool quota usage", map[string]any{
+			"tool":  toolName,
+			"user":  userID,
+			"error": err.Error(),
+		})
+	}
+	if allowed {
+		return nil
+	}
+
+	logger.WarnCF("agent", "Daily tool quota reached", map[string]any{
+		"tool":  toolName,
+		"user":  userID,
+		"limit": limit,
+	})
+	return tools.ErrorResult(fmt.Sprintf(
+		"Daily quota for %s reached (%d per day). Tell the user they can try again tomorrow.",
+		toolName, limit,
+	))
+}
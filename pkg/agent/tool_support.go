@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// toolsUnsupportedMarkers are provider error fragments meaning the model
+// rejected a request because it cannot call tools.
+var toolsUnsupportedMarkers = []string{
+	"does not support tools",            // Ollama
+	"does not support function calling", // OpenAI-compatible servers
+	"tools are not supported",
+	"tool use is not supported",
+	"enable-auto-tool-choice", // vLLM started without a tool parser
+}
+
+// isToolsUnsupportedError reports whether a lower-cased provider error says
+// the model cannot take tool definitions.
+func isToolsUnsupportedError(errMsg string) bool {
+	for _, marker := range toolsUnsupportedMarkers {
+		if strings.Contains(errMsg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// warnToolCallingUnsupported logs when an agent has tools registered but its
+// model is configured without tool calling, so neither it nor its subagents
+// can ever use them.
+func warnToolCallingUnsupported(agent *AgentInstance) {
+	if !agent.NoToolCalling || agent.Tools.Count() == 0 {
+		return
+	}
+	logger.WarnCF("agent",
+		"Model does not support tool calling; registered tools will not be offered to it or its subagents",
+		map[string]any{
+			"agent_id": agent.ID,
+			"model":    agent.Model,
+			"tools":    agent.Tools.Count(),
+		})
+}
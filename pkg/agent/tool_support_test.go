@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+func TestIsToolsUnsupportedError(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{"registry.ollama.ai/library/gemma:2b does not support tools", true},
+		{`"auto" tool choice requires --enable-auto-tool-choice and --tool-call-parser to be set`, true},
+		{"this model does not support function calling", true},
+		{"context_length_exceeded", false},
+		{"connection refused", false},
+	}
+	for _, tt := range tests {
+		if got := isToolsUnsupportedError(tt.msg); got != tt.want {
+			t.Errorf("isToolsUnsupportedError(%q) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+// toolRejectingProvider fails every request that carries tool definitions,
+// like a local model without function calling.
+type toolRejectingProvider struct {
+	toolCounts []int
+}
+
+func (p *toolRejectingProvider) Chat(
+	ctx context.Context, msgs []providers.Message, tools []providers.ToolDefinition,
+	model string, opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.toolCounts = append(p.toolCounts, len(tools))
+	if len(tools) > 0 {
+		return nil, errors.New("registry.ollama.ai/library/tiny does not support tools")
+	}
+	return &providers.LLMResponse{Content: "plain answer"}, nil
+}
+
+func (p *toolRejectingProvider) GetDefaultModel() string { return "tiny" }
+
+func newToolSupportTestConfig(t *testing.T) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Agents.Defaults.Model = "tiny"
+	cfg.Agents.Defaults.MaxTokens = 4096
+	cfg.Agents.Defaults.MaxToolIterations = 5
+	return cfg
+}
+
+func TestAgentLoop_RetriesWithoutToolsWhenModelRejectsThem(t *testing.T) {
+	provider := &toolRejectingProvider{}
+	al := NewAgentLoop(newToolSupportTestConfig(t), bus.NewMessageBus(), provider)
+
+	resp, err := al.ProcessDirectWithChannel(context.Background(), "hello", "s1", "cli", "direct")
+	if err != nil {
+		t.Fatalf("ProcessDirectWithChannel() error: %v", err)
+	}
+	if resp != "plain answer" {
+		t.Errorf("response = %q, want %q", resp, "plain answer")
+	}
+	if len(provider.toolCounts) != 2 || provider.toolCounts[0] == 0 || provider.toolCounts[1] != 0 {
+		t.Errorf("tool counts per call = %v, want tools then none", provider.toolCounts)
+	}
+}
+
+func TestAgentLoop_SupportsToolsFalseWithholdsTools(t *testing.T) {
+	cfg := newToolSupportTestConfig(t)
+	supportsTools := false
+	cfg.ModelList = append(cfg.ModelList, config.ModelConfig{
+		ModelName:     "tiny",
+		Model:         "ollama/tiny",
+		SupportsTools: &supportsTools,
+	})
+
+	provider := &toolRejectingProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	if !al.registry.GetDefaultAgent().NoToolCalling {
+		t.Fatal("expected NoToolCalling for a model with supports_tools false")
+	}
+
+	if _, err := al.ProcessDirectWithChannel(context.Background(), "hello", "s1", "cli", "direct"); err != nil {
+		t.Fatalf("ProcessDirectWithChannel() error: %v", err)
+	}
+	if len(provider.toolCounts) != 1 || provider.toolCounts[0] != 0 {
+		t.Errorf("tool counts per call = %v, want a single call without tools", provider.toolCounts)
+	}
+}
+
+// promptProtocolProvider answers the first call with a prompt-protocol tool
+// call and every later one with a plain answer.
+type promptProtocolProvider struct {
+	mu         sync.Mutex
+	toolCounts []int
+	requests   [][]providers.Message
+}
+
+func (p *promptProtocolProvider) Chat(
+	ctx context.Context, msgs []providers.Message, tools []providers.ToolDefinition,
+	model string, opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.toolCounts = append(p.toolCounts, len(tools))
+	p.requests = append(p.requests, msgs)
+	if len(p.requests) == 1 {
+		return &providers.LLMResponse{Content: "Action: list_dir\nArgs: {\"path\": \".\"}"}, nil
+	}
+	return &providers.LLMResponse{Content: "done"}, nil
+}
+
+func (p *promptProtocolProvider) GetDefaultModel() string { return "tiny" }
+
+func TestSpawn_SubagentUsesPromptToolProtocol(t *testing.T) {
+	cfg := newToolSupportTestConfig(t)
+	cfg.ModelList = append(cfg.ModelList, config.ModelConfig{
+		ModelName:    "tiny",
+		Model:        "ollama/tiny",
+		ToolProtocol: config.ToolProtocolPrompt,
+	})
+
+	provider := &promptProtocolProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	agent := al.registry.GetDefaultAgent()
+	if _, ok := agent.Tools.Get("spawn"); !ok {
+		t.Fatal("spawn tool not registered")
+	}
+
+	done := make(chan *tools.ToolResult, 1)
+	agent.Tools.ExecuteWithContext(context.Background(), "spawn", map[string]any{"task": "list files"},
+		"cli", "direct", func(_ context.Context, result *tools.ToolResult) { done <- result })
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subagent did not finish")
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if len(provider.requests) != 2 {
+		t.Fatalf("subagent made %d LLM calls, want 2 (tool call, then answer)", len(provider.requests))
+	}
+	for i, n := range provider.toolCounts {
+		if n != 0 {
+			t.Errorf("call %d sent %d native tool definitions, want none", i, n)
+		}
+	}
+	last := provider.requests[1]
+	if got := last[len(last)-1].Content; !strings.HasPrefix(got, "Observation (list_dir)") {
+		t.Errorf("second call ends with %q, want the list_dir observation", got)
+	}
+}
+
+func TestSpawn_SubagentWithholdsToolsWhenModelCannotCallThem(t *testing.T) {
+	cfg := newToolSupportTestConfig(t)
+	supportsTools := false
+	cfg.ModelList = append(cfg.ModelList, config.ModelConfig{
+		ModelName:     "tiny",
+		Model:         "ollama/tiny",
+		SupportsTools: &supportsTools,
+	})
+
+	provider := &toolRejectingProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	agent := al.registry.GetDefaultAgent()
+
+	done := make(chan *tools.ToolResult, 1)
+	agent.Tools.ExecuteWithContext(context.Background(), "spawn", map[string]any{"task": "answer"},
+		"cli", "direct", func(_ context.Context, result *tools.ToolResult) { done <- result })
+	var result *tools.ToolResult
+	select {
+	case result = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subagent did not finish")
+	}
+
+	if result.IsError || !strings.Contains(result.ForLLM, "plain answer") {
+		t.Errorf("subagent result = %+v, want the plain answer", result)
+	}
+	if len(provider.toolCounts) != 1 || provider.toolCounts[0] != 0 {
+		t.Errorf("tool counts per call = %v, want a single call without tools", provider.toolCounts)
+	}
+}
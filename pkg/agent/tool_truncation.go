@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// Tool result truncation strategies (tools.result_truncation.strategy).
+const (
+	truncateHead      = "head"
+	truncateTail      = "tail"
+	truncateHeadTail  = "head_tail"
+	truncateSummarize = "summarize"
+)
+
+// summarizeInputMaxChars caps how much of an oversized tool result is handed
+// to the LLM for summarization.
+const summarizeInputMaxChars = 64000
+
+// limitToolResult applies the configured truncation policy to a tool result
+// before it becomes a tool message.
+func (al *AgentLoop) limitToolResult(
+	ctx context.Context,
+	agent *AgentInstance,
+	cfg config.ToolResultTruncationConfig,
+	toolName, content string,
+) string {
+	rule := cfg.RuleFor(toolName)
+	size := len([]rune(content))
+	if rule.MaxChars <= 0 || size <= rule.MaxChars {
+		return content
+	}
+
+	strategy := rule.Strategy
+	switch strategy {
+	case truncateHead, truncateTail, truncateHeadTail:
+	case truncateSummarize:
+		summary, err := al.summarizeToolResult(ctx, agent, toolName, content, rule.MaxChars)
+		if err == nil {
+			return summary
+		}
+		logger.WarnCF("agent", "Failed to summarize tool result, truncating instead", map[string]any{
+			"tool":  toolName,
+			"error": err.Error(),
+		})
+		strategy = truncateHeadTail
+	case "":
+		strategy = truncateHeadTail
+	default:
+		logger.WarnCF("agent", "Unknown tool result truncation strategy, using head_tail", map[string]any{
+			"tool":     toolName,
+			"strategy": strategy,
+		})
+		strategy = truncateHeadTail
+	}
+
+	logger.DebugCF("agent", "Truncated tool result", map[string]any{
+		"tool":      toolName,
+		"strategy":  strategy,
+		"chars":     size,
+		"max_chars": rule.MaxChars,
+	})
+	return truncateText(content, strategy, rule.MaxChars)
+}
+
+// truncateText shortens content to maxChars runes, keeping the start, the
+// end or both, and marks what was dropped.
+func truncateText(content, strategy string, maxChars int) string {
+	runes := []rune(content)
+	if maxChars <= 0 || len(runes) <= maxChars {
+		return content
+	}
+	switch strategy {
+	case truncateHead:
+		return string(runes[:maxChars]) +
+			fmt.Sprintf("\n\n[Truncated: showing first %d of %d characters]", maxChars, len(runes))
+	case truncateTail:
+		return fmt.Sprintf("[Truncated: showing last %d of %d characters]\n\n", maxChars, len(runes)) +
+			string(runes[len(runes)-maxChars:])
+	default:
+		head := maxChars / 2
+		tail := maxChars - head
+		return string(runes[:head]) +
+			fmt.Sprintf("\n\n[Truncated: %d characters omitted]\n\n", len(runes)-maxChars) +
+			string(runes[len(runes)-tail:])
+	}
+}
+
+// summarizeToolResult asks the LLM to condense an oversized tool result to
+// roughly maxChars characters.
+func (al *AgentLoop) summarizeToolResult(
+	ctx context.Context,
+	agent *AgentInstance,
+	toolName, content string,
+	maxChars int,
+) (string, error) {
+	size := len([]rune(content))
+	prompt := fmt.Sprintf(
+		"The output below from the %q tool is too long to use directly. Summarize it in at most %d characters, "+
+			"keeping every detail needed to continue the task: names, numbers, paths, errors and exact values. "+
+			"Reply with the summary only.\n\nOUTPUT:\n%s",
+		toolName, maxChars, truncateText(content, truncateHeadTail, summarizeInputMaxChars),
+	)
+	resp, err := al.retryLLMCall(ctx, agent, prompt, 1)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil || strings.TrimSpace(resp.Content) == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	summary := truncateText(strings.TrimSpace(resp.Content), truncateHeadTail, maxChars)
+	return fmt.Sprintf("[Summarized: the original output was %d characters]\n\n%s", size, summary), nil
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestTruncateText(t *testing.T) {
+	content := "abcdefghijklmnopqrstuvwxyz"
+
+	if got := truncateText(content, truncateHead, 30); got != content {
+		t.Errorf("short content changed: %q", got)
+	}
+	got := truncateText(content, truncateHead, 4)
+	if !strings.HasPrefix(got, "abcd\n\n[Truncated: showing first 4 of 26") {
+		t.Errorf("head = %q", got)
+	}
+	got = truncateText(content, truncateTail, 4)
+	if !strings.HasSuffix(got, "]\n\nwxyz") || !strings.Contains(got, "showing last 4 of 26") {
+		t.Errorf("tail = %q", got)
+	}
+	got = truncateText(content, truncateHeadTail, 6)
+	if !strings.HasPrefix(got, "abc\n\n") || !strings.HasSuffix(got, "\n\nxyz") ||
+		!strings.Contains(got, "20 characters omitted") {
+		t.Errorf("head_tail = %q", got)
+	}
+}
+
+func TestLimitToolResult(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "test-model",
+				MaxTokens: 4096,
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "short summary"})
+	agent := al.registry.GetDefaultAgent()
+	ctx := context.Background()
+	content := strings.Repeat("x", 100)
+
+	truncation := config.ToolResultTruncationConfig{
+		Strategy: truncateHead,
+		MaxChars: 50,
+		PerTool: map[string]config.ToolResultTruncationRule{
+			"read_file":     {MaxChars: -1},
+			"web_fetch":     {Strategy: truncateSummarize},
+			"exec":          {Strategy: truncateTail, MaxChars: 10},
+			"unknown_style": {Strategy: "middle"},
+		},
+	}
+
+	if got := al.limitToolResult(ctx, agent, config.ToolResultTruncationConfig{}, "exec", content); got != content {
+		t.Error("expected no truncation when max_chars is unset")
+	}
+	got := al.limitToolResult(ctx, agent, truncation, "list_dir", content)
+	if !strings.Contains(got, "showing first 50 of 100") {
+		t.Errorf("global head = %q", got)
+	}
+	if got := al.limitToolResult(ctx, agent, truncation, "read_file", content); got != content {
+		t.Error("expected per-tool override to disable truncation")
+	}
+	got = al.limitToolResult(ctx, agent, truncation, "exec", content)
+	if !strings.Contains(got, "showing last 10 of 100") {
+		t.Errorf("per-tool tail = %q", got)
+	}
+	got = al.limitToolResult(ctx, agent, truncation, "unknown_style", content)
+	if !strings.Contains(got, "characters omitted") {
+		t.Errorf("unknown strategy should fall back to head_tail, got %q", got)
+	}
+	got = al.limitToolResult(ctx, agent, truncation, "web_fetch", content)
+	if !strings.Contains(got, "Summarized") || !strings.HasSuffix(got, "short summary") {
+		t.Errorf("summarize = %q", got)
+	}
+}
@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// defaultUnavailableToolHint follows the "not available" error when
+// agents.defaults.unavailable_tool_message is unset.
+const defaultUnavailableToolHint = "Use one of the available tools, or answer without it."
+
+// checkToolAvailable returns an error result when the model calls a tool the
+// agent does not have, e.g. one disabled for this agent that an older prompt
+// or session history still mentions. The result names the tools that can be
+// called so the model can recover on the next iteration.
+func (al *AgentLoop) checkToolAvailable(agent *AgentInstance, toolName string) *tools.ToolResult {
+	if _, ok := agent.Tools.Get(toolName); ok {
+		return nil
+	}
+
+	defs := agent.Tools.ToProviderDefs()
+	available := make([]string, 0, len(defs))
+	for _, def := range defs {
+		available = append(available, def.Function.Name)
+	}
+	logger.WarnCF("agent", "Model called an unavailable tool", map[string]any{
+		"agent_id": agent.ID,
+		"tool":     toolName,
+	})
+
+	hint := defaultUnavailableToolHint
+	if cfg := al.GetConfig(); cfg != nil && cfg.Agents.Defaults.UnavailableToolMessage != "" {
+		hint = cfg.Agents.Defaults.UnavailableToolMessage
+	}
+	list := "none"
+	if len(available) > 0 {
+		list = strings.Join(available, ", ")
+	}
+	return tools.ErrorResult(fmt.Sprintf("Tool %q is not available. Available tools: %s. %s", toolName, list, hint))
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// staleToolProvider calls a tool the agent does not have, then answers once
+// it has seen the error.
+type staleToolProvider struct {
+	calls      int
+	toolResult string
+}
+
+func (p *staleToolProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	opts map[string]any,
+) (*providers.LLMResponse, error) {
+	p.calls++
+	if p.calls == 1 {
+		return &providers.LLMResponse{
+			ToolCalls: []providers.ToolCall{{
+				ID:        "call_1",
+				Name:      "legacy_search",
+				Arguments: map[string]any{"query": "weather"},
+			}},
+		}, nil
+	}
+	for _, m := range messages {
+		if m.Role == "tool" && m.ToolCallID == "call_1" {
+			p.toolResult = m.Content
+		}
+	}
+	return &providers.LLMResponse{Content: "It is sunny."}, nil
+}
+
+func (p *staleToolProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
+func TestRunLLMIteration_UnavailableToolRecovers(t *testing.T) {
+	provider := &staleToolProvider{}
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:              t.TempDir(),
+				Model:                  "test-model",
+				MaxTokens:              4096,
+				MaxToolIterations:      10,
+				UnavailableToolMessage: "Try web_search instead.",
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	helper := testHelper{al: al}
+
+	response := helper.executeAndGetResponse(t, context.Background(), bus.InboundMessage{
+		Channel:  "telegram",
+		SenderID: "user1",
+		ChatID:   "chat1",
+		Content:  "what's the weather?",
+	})
+
+	if response != "It is sunny." {
+		t.Fatalf("response = %q, want the model's recovered answer", response)
+	}
+	if provider.calls != 2 {
+		t.Errorf("LLM calls = %d, want 2", provider.calls)
+	}
+	for _, want := range []string{`Tool "legacy_search" is not available`, "Available tools:", "Try web_search instead."} {
+		if !strings.Contains(provider.toolResult, want) {
+			t.Errorf("tool result = %q, want it to contain %q", provider.toolResult, want)
+		}
+	}
+}
@@ -15,6 +15,22 @@ type SenderInfo struct {
 	DisplayName string `json:"display_name,omitempty"` // display name
 }
 
+// Metadata keys channels use to describe the message an inbound message
+// replies to. BaseChannel.HandleMessage lifts them into InboundMessage.ReplyTo.
+const (
+	MetadataReplyToMessageID = "reply_to_message_id"
+	MetadataReplyToAuthor    = "reply_to_author"
+	MetadataReplyToText      = "reply_to_text"
+)
+
+// ReplyContext describes the message an inbound message replies to or quotes.
+// Text and Author are empty when the platform only reports the message ID.
+type ReplyContext struct {
+	MessageID string `json:"message_id,omitempty"`
+	Author    string `json:"author,omitempty"`
+	Text      string `json:"text,omitempty"`
+}
+
 type InboundMessage struct {
 	Channel    string            `json:"channel"`
 	SenderID   string            `json:"sender_id"`
@@ -26,14 +42,18 @@ type InboundMessage struct {
 	MessageID  string            `json:"message_id,omitempty"`  // platform message ID
 	MediaScope string            `json:"media_scope,omitempty"` // media lifecycle scope
 	SessionKey string            `json:"session_key"`
+	ReplyTo    *ReplyContext     `json:"reply_to,omitempty"` // message being replied to
 	Metadata   map[string]string `json:"metadata,omitempty"`
 }
 
 type OutboundMessage struct {
-	Channel          string `json:"channel"`
-	ChatID           string `json:"chat_id"`
-	Content          string `json:"content"`
-	ReplyToMessageID string `json:"reply_to_message_id,omitempty"`
+	Channel          string   `json:"channel"`
+	ChatID           string   `json:"chat_id"`
+	Content          string   `json:"content"`
+	ReplyToMessageID string   `json:"reply_to_message_id,omitempty"`
+	DisablePreview   bool     `json:"disable_preview,omitempty"` // suppress link unfurling where supported
+	Choices          []string `json:"choices,omitempty"`         // options offered as buttons, or numbered text where unsupported
+	AgentReply       bool     `json:"agent_reply,omitempty"`     // a reply written by the agent; gets the channel footer
 }
 
 // MediaPart describes a single media attachment to send.
@@ -6,12 +6,15 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/commands"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/identity"
 	"github.com/sipeed/picoclaw/pkg/logger"
@@ -33,6 +36,9 @@ func init() {
 	uniqueIDPrefix = hex.EncodeToString(b[:])
 }
 
+// DefaultEmptyTriggerReply answers a group trigger that carries no message.
+const DefaultEmptyTriggerReply = "Yes? What can I help with?"
+
 // audioAnnotationRe matches audio/voice annotations injected by channels (e.g. [voice], [audio: file.ogg]).
 var audioAnnotationRe = regexp.MustCompile(`\[(voice|audio)(?::[^\]]*)?\]`)
 
@@ -70,6 +76,39 @@ func WithGroupTrigger(gt config.GroupTriggerConfig) BaseChannelOption {
 	return func(c *BaseChannel) { c.groupTrigger = gt }
 }
 
+// WithGreeting sets the message sent to a user on their first direct message.
+func WithGreeting(text string) BaseChannelOption {
+	return func(c *BaseChannel) { c.greeting = strings.TrimSpace(text) }
+}
+
+// WithFooter sets a line appended to every agent reply sent on the channel,
+// e.g. a disclaimer or branding.
+func WithFooter(text string) BaseChannelOption {
+	return func(c *BaseChannel) { c.footer = strings.TrimSpace(text) }
+}
+
+// WithMediaTypes limits the inbound media types ("image", "audio", "video",
+// "file") the channel accepts. A non-empty allow list admits only the listed
+// types; deny always wins. Rejected media is dropped before it is downloaded,
+// and rejectedNote, if set, is sent to the user in reply.
+func WithMediaTypes(allow, deny []string, rejectedNote string) BaseChannelOption {
+	return func(c *BaseChannel) {
+		c.mediaTypesAllow = normalizeMediaTypes(allow)
+		c.mediaTypesDeny = normalizeMediaTypes(deny)
+		c.mediaRejectedNote = strings.TrimSpace(rejectedNote)
+	}
+}
+
+func normalizeMediaTypes(types []string) []string {
+	var out []string
+	for _, t := range types {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
 // WithReasoningChannelID sets the reasoning channel ID where thoughts should be sent.
 func WithReasoningChannelID(id string) BaseChannelOption {
 	return func(c *BaseChannel) { c.reasoningChannelID = id }
@@ -91,9 +130,20 @@ type BaseChannel struct {
 	maxMessageLength    int
 	groupTrigger        config.GroupTriggerConfig
 	mediaStore          media.MediaStore
+	dedupStore          *DedupStore
 	placeholderRecorder PlaceholderRecorder
 	owner               Channel // the concrete channel that embeds this BaseChannel
 	reasoningChannelID  string
+	greeting            string
+	footer              string
+	mediaTypesAllow     []string
+	mediaTypesDeny      []string
+	mediaRejectedNote   string
+	groupTriggerModes   sync.Map // chatID → runtime group trigger mode override
+	groupPrefixes       sync.Map // chatID → per-chat trigger prefixes ([]string)
+	coalesceWindow      time.Duration
+	coalesceMu          sync.Mutex
+	pendingInbound      map[string]*pendingInbound // coalesceKey → message being coalesced
 }
 
 func NewBaseChannel(
@@ -129,11 +179,14 @@ func (c *BaseChannel) MaxMessageLength() int {
 //
 // Logic:
 //   - If isMentioned → always respond
+//   - If a runtime override is set for chatID → apply it (mention/prefix/all)
+//   - If per-chat prefixes are set for chatID → they replace the channel
+//     prefixes and enable prefix matching for that chat
 //   - If mention_only configured and not mentioned → ignore
 //   - If prefixes configured → respond if content starts with any prefix (strip it)
 //   - If prefixes configured but no match and not mentioned → ignore
 //   - Otherwise (no group_trigger configured) → respond to all (permissive default)
-func (c *BaseChannel) ShouldRespondInGroup(isMentioned bool, content string) (bool, string) {
+func (c *BaseChannel) ShouldRespondInGroup(chatID string, isMentioned bool, content string) (bool, string) {
 	gt := c.groupTrigger
 
 	// Mentioned → always respond
@@ -141,6 +194,27 @@ func (c *BaseChannel) ShouldRespondInGroup(isMentioned bool, content string) (bo
 		return true, strings.TrimSpace(content)
 	}
 
+	chatPrefixes := c.GroupTriggerPrefixes(chatID)
+	prefixes := gt.Prefixes
+	if len(chatPrefixes) > 0 {
+		prefixes = chatPrefixes
+	}
+
+	switch c.GroupTriggerMode(chatID) {
+	case GroupTriggerAll:
+		return true, strings.TrimSpace(content)
+	case GroupTriggerMention:
+		return false, content
+	case GroupTriggerPrefix:
+		// Prefix-only, even when the channel is configured as mention_only.
+		return matchGroupPrefix(prefixes, content)
+	}
+
+	// Per-chat prefixes imply prefix matching for that chat
+	if len(chatPrefixes) > 0 {
+		return matchGroupPrefix(chatPrefixes, content)
+	}
+
 	// mention_only → require mention
 	if gt.MentionOnly {
 		return false, content
@@ -148,19 +222,82 @@ func (c *BaseChannel) ShouldRespondInGroup(isMentioned bool, content string) (bo
 
 	// Prefix matching
 	if len(gt.Prefixes) > 0 {
-		for _, prefix := range gt.Prefixes {
-			if prefix != "" && strings.HasPrefix(content, prefix) {
-				return true, strings.TrimSpace(strings.TrimPrefix(content, prefix))
-			}
-		}
 		// Prefixes configured but none matched and not mentioned → ignore
-		return false, content
+		return matchGroupPrefix(gt.Prefixes, content)
 	}
 
 	// No group_trigger configured → permissive (respond to all)
 	return true, strings.TrimSpace(content)
 }
 
+// requiresGroupTrigger reports whether messages in the group chat need a
+// mention or prefix to be answered, mirroring ShouldRespondInGroup.
+func (c *BaseChannel) requiresGroupTrigger(chatID string) bool {
+	switch c.GroupTriggerMode(chatID) {
+	case GroupTriggerAll:
+		return false
+	case GroupTriggerMention, GroupTriggerPrefix:
+		return true
+	}
+	return len(c.GroupTriggerPrefixes(chatID)) > 0 || c.groupTrigger.MentionOnly || len(c.groupTrigger.Prefixes) > 0
+}
+
+// EmptyTriggerReply returns the prompt sent for a group trigger without a
+// message.
+func (c *BaseChannel) EmptyTriggerReply() string {
+	if reply := strings.TrimSpace(c.groupTrigger.EmptyReply); reply != "" {
+		return reply
+	}
+	return DefaultEmptyTriggerReply
+}
+
+// matchGroupPrefix reports whether content starts with any of prefixes and
+// returns the content with the matched prefix stripped.
+func matchGroupPrefix(prefixes []string, content string) (bool, string) {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(content, prefix) {
+			return true, strings.TrimSpace(strings.TrimPrefix(content, prefix))
+		}
+	}
+	return false, content
+}
+
+// SetGroupTriggerMode implements GroupTriggerOverrider.
+func (c *BaseChannel) SetGroupTriggerMode(chatID, mode string) {
+	if mode == "" {
+		c.groupTriggerModes.Delete(chatID)
+		return
+	}
+	c.groupTriggerModes.Store(chatID, mode)
+}
+
+// GroupTriggerMode implements GroupTriggerOverrider.
+func (c *BaseChannel) GroupTriggerMode(chatID string) string {
+	if v, ok := c.groupTriggerModes.Load(chatID); ok {
+		return v.(string)
+	}
+	return ""
+}
+
+// SetGroupTriggerPrefixes implements GroupTriggerOverrider.
+func (c *BaseChannel) SetGroupTriggerPrefixes(chatID string, prefixes []string) {
+	if len(prefixes) == 0 {
+		c.groupPrefixes.Delete(chatID)
+		return
+	}
+	stored := make([]string, len(prefixes))
+	copy(stored, prefixes)
+	c.groupPrefixes.Store(chatID, stored)
+}
+
+// GroupTriggerPrefixes implements GroupTriggerOverrider.
+func (c *BaseChannel) GroupTriggerPrefixes(chatID string) []string {
+	if v, ok := c.groupPrefixes.Load(chatID); ok {
+		return v.([]string)
+	}
+	return nil
+}
+
 func (c *BaseChannel) Name() string {
 	return c.name
 }
@@ -169,6 +306,16 @@ func (c *BaseChannel) ReasoningChannelID() string {
 	return c.reasoningChannelID
 }
 
+// Greeting returns the configured first-contact greeting, or "" if none.
+func (c *BaseChannel) Greeting() string {
+	return c.greeting
+}
+
+// Footer returns the line appended to agent replies, or "" if none.
+func (c *BaseChannel) Footer() string {
+	return c.footer
+}
+
 func (c *BaseChannel) IsRunning() bool {
 	return c.running.Load()
 }
@@ -229,6 +376,35 @@ func (c *BaseChannel) IsAllowedSender(sender bus.SenderInfo) bool {
 	return false
 }
 
+// MetadataMediaRejected is the inbound metadata key under which a channel
+// lists, comma-separated, the media types it dropped because of its media type
+// filter (see AllowsMediaType).
+const MetadataMediaRejected = "media_rejected"
+
+// AllowsMediaType reports whether inbound media of the given type ("image",
+// "audio", "video" or "file") may be downloaded and passed to the agent.
+func (c *BaseChannel) AllowsMediaType(mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	if slices.Contains(c.mediaTypesDeny, mediaType) {
+		return false
+	}
+	return len(c.mediaTypesAllow) == 0 || slices.Contains(c.mediaTypesAllow, mediaType)
+}
+
+// replyContextFromMetadata builds the reply context from the reply metadata
+// keys set by the channel. It returns nil when the message is not a reply.
+func replyContextFromMetadata(metadata map[string]string) *bus.ReplyContext {
+	reply := bus.ReplyContext{
+		MessageID: metadata[bus.MetadataReplyToMessageID],
+		Author:    metadata[bus.MetadataReplyToAuthor],
+		Text:      metadata[bus.MetadataReplyToText],
+	}
+	if reply == (bus.ReplyContext{}) {
+		return nil
+	}
+	return &reply
+}
+
 func (c *BaseChannel) HandleMessage(
 	ctx context.Context,
 	peer bus.Peer,
@@ -258,6 +434,28 @@ func (c *BaseChannel) HandleMessage(
 		resolvedSenderID = sender.CanonicalID
 	}
 
+	// Media dropped by the media type filter is answered with the configured
+	// note. A message that carried nothing else is not sent to the agent.
+	if metadata[MetadataMediaRejected] != "" {
+		if c.mediaRejectedNote != "" {
+			c.reply(ctx, chatID, messageID, c.mediaRejectedNote)
+		}
+		if strings.TrimSpace(content) == "" && len(media) == 0 {
+			return
+		}
+	}
+
+	// A group message left empty once the trigger was stripped (the user
+	// only pinged the bot) is answered with a short prompt instead of being
+	// sent to the agent. In chats that need no trigger there is nothing to
+	// answer, so it is dropped.
+	if peer.Kind != "direct" && strings.TrimSpace(content) == "" && len(media) == 0 {
+		if c.requiresGroupTrigger(chatID) {
+			c.replyToEmptyTrigger(ctx, chatID, messageID)
+		}
+		return
+	}
+
 	scope := BuildMediaScope(c.name, chatID, messageID)
 
 	msg := bus.InboundMessage{
@@ -270,9 +468,29 @@ func (c *BaseChannel) HandleMessage(
 		Peer:       peer,
 		MessageID:  messageID,
 		MediaScope: scope,
+		ReplyTo:    replyContextFromMetadata(metadata),
 		Metadata:   metadata,
 	}
 
+	if c.coalesceWindow > 0 {
+		// Commands are not joined with chat text; anything still waiting
+		// from this sender goes first so the order is kept.
+		if commands.HasCommandPrefix(content) {
+			c.flushCoalesced(ctx, coalesceKey(msg))
+			c.dispatch(ctx, msg)
+			return
+		}
+		c.coalesce(ctx, msg)
+		return
+	}
+	c.dispatch(ctx, msg)
+}
+
+// dispatch triggers the typing indicator, reaction and placeholder for an
+// inbound message and publishes it to the bus.
+func (c *BaseChannel) dispatch(ctx context.Context, msg bus.InboundMessage) {
+	chatID, messageID, content := msg.ChatID, msg.MessageID, msg.Content
+
 	// Auto-trigger typing indicator, message reaction, and placeholder before publishing.
 	// Each capability is independent — all three may fire for the same message.
 	if c.owner != nil && c.placeholderRecorder != nil {
@@ -310,8 +528,35 @@ func (c *BaseChannel) HandleMessage(
 	}
 }
 
+func (c *BaseChannel) replyToEmptyTrigger(ctx context.Context, chatID, messageID string) {
+	c.reply(ctx, chatID, messageID, c.EmptyTriggerReply())
+}
+
+// reply publishes a short channel-generated reply to a message without
+// involving the agent.
+func (c *BaseChannel) reply(ctx context.Context, chatID, messageID, content string) {
+	err := c.bus.PublishOutbound(ctx, bus.OutboundMessage{
+		Channel:          c.name,
+		ChatID:           chatID,
+		Content:          content,
+		ReplyToMessageID: messageID,
+	})
+	if err != nil {
+		logger.ErrorCF("channels", "Failed to publish channel reply", map[string]any{
+			"channel": c.name,
+			"chat_id": chatID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// SetRunning records whether the channel is running. Stopping it also
+// publishes any messages still held back by coalescing.
 func (c *BaseChannel) SetRunning(running bool) {
 	c.running.Store(running)
+	if !running {
+		c.flushAllCoalesced()
+	}
 }
 
 // SetMediaStore injects a MediaStore into the channel.
@@ -320,6 +565,16 @@ func (c *BaseChannel) SetMediaStore(s media.MediaStore) { c.mediaStore = s }
 // GetMediaStore returns the injected MediaStore (may be nil).
 func (c *BaseChannel) GetMediaStore() media.MediaStore { return c.mediaStore }
 
+// SetDedupStore injects the persistent DedupStore into the channel.
+func (c *BaseChannel) SetDedupStore(s *DedupStore) { c.dedupStore = s }
+
+// SeenBefore reports whether messageID was already handled by this channel
+// before, possibly in an earlier process. It records the ID as a side effect
+// and is always false when no DedupStore is configured.
+func (c *BaseChannel) SeenBefore(messageID string) bool {
+	return c.dedupStore.SeenBefore(c.name, messageID)
+}
+
 // SetPlaceholderRecorder injects a PlaceholderRecorder into the channel.
 func (c *BaseChannel) SetPlaceholderRecorder(r PlaceholderRecorder) {
 	c.placeholderRecorder = r
@@ -1,6 +1,7 @@
 package channels
 
 import (
+	"context"
 	"testing"
 
 	"github.com/sipeed/picoclaw/pkg/bus"
@@ -60,6 +61,7 @@ func TestShouldRespondInGroup(t *testing.T) {
 	tests := []struct {
 		name        string
 		gt          config.GroupTriggerConfig
+		mode        string
 		isMentioned bool
 		content     string
 		wantRespond bool
@@ -161,12 +163,58 @@ func TestShouldRespondInGroup(t *testing.T) {
 			wantRespond: true,
 			wantContent: "hello",
 		},
+		{
+			name:        "override all - mention_only channel responds to everything",
+			gt:          config.GroupTriggerConfig{MentionOnly: true},
+			mode:        GroupTriggerAll,
+			isMentioned: false,
+			content:     " hello ",
+			wantRespond: true,
+			wantContent: "hello",
+		},
+		{
+			name:        "override mention - permissive channel requires mention",
+			gt:          config.GroupTriggerConfig{},
+			mode:        GroupTriggerMention,
+			isMentioned: false,
+			content:     "hello",
+			wantRespond: false,
+			wantContent: "hello",
+		},
+		{
+			name:        "override mention - mentioned",
+			gt:          config.GroupTriggerConfig{},
+			mode:        GroupTriggerMention,
+			isMentioned: true,
+			content:     "hello",
+			wantRespond: true,
+			wantContent: "hello",
+		},
+		{
+			name:        "override prefix - mention_only channel accepts prefix",
+			gt:          config.GroupTriggerConfig{MentionOnly: true, Prefixes: []string{"/ask"}},
+			mode:        GroupTriggerPrefix,
+			isMentioned: false,
+			content:     "/ask hello",
+			wantRespond: true,
+			wantContent: "hello",
+		},
+		{
+			name:        "override prefix - no prefixes configured",
+			gt:          config.GroupTriggerConfig{},
+			mode:        GroupTriggerPrefix,
+			isMentioned: false,
+			content:     "hello",
+			wantRespond: false,
+			wantContent: "hello",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ch := NewBaseChannel("test", nil, nil, nil, WithGroupTrigger(tt.gt))
-			gotRespond, gotContent := ch.ShouldRespondInGroup(tt.isMentioned, tt.content)
+			ch.SetGroupTriggerMode("chat1", tt.mode)
+			gotRespond, gotContent := ch.ShouldRespondInGroup("chat1", tt.isMentioned, tt.content)
 			if gotRespond != tt.wantRespond {
 				t.Errorf("ShouldRespondInGroup() respond = %v, want %v", gotRespond, tt.wantRespond)
 			}
@@ -177,6 +225,104 @@ func TestShouldRespondInGroup(t *testing.T) {
 	}
 }
 
+func TestGroupTriggerMode_PerChat(t *testing.T) {
+	ch := NewBaseChannel("test", nil, nil, nil,
+		WithGroupTrigger(config.GroupTriggerConfig{MentionOnly: true}))
+
+	ch.SetGroupTriggerMode("group-a", GroupTriggerAll)
+
+	if respond, _ := ch.ShouldRespondInGroup("group-a", false, "hi"); !respond {
+		t.Error("group-a should respond to all messages")
+	}
+	if respond, _ := ch.ShouldRespondInGroup("group-b", false, "hi"); respond {
+		t.Error("group-b should keep mention_only behavior")
+	}
+
+	ch.SetGroupTriggerMode("group-a", "")
+	if got := ch.GroupTriggerMode("group-a"); got != "" {
+		t.Errorf("GroupTriggerMode() = %q, want cleared", got)
+	}
+	if respond, _ := ch.ShouldRespondInGroup("group-a", false, "hi"); respond {
+		t.Error("group-a should revert to mention_only after clearing")
+	}
+}
+
+func TestGroupTriggerPrefixes_PerChat(t *testing.T) {
+	ch := NewBaseChannel("test", nil, nil, nil,
+		WithGroupTrigger(config.GroupTriggerConfig{Prefixes: []string{"/ask"}}))
+
+	ch.SetGroupTriggerPrefixes("group-a", []string{"!bot"})
+
+	if respond, content := ch.ShouldRespondInGroup("group-a", false, "!bot hello"); !respond || content != "hello" {
+		t.Errorf("group-a with chat prefix: respond=%v content=%q", respond, content)
+	}
+	if respond, _ := ch.ShouldRespondInGroup("group-a", false, "/ask hello"); respond {
+		t.Error("group-a chat prefixes should replace channel prefixes")
+	}
+	if respond, content := ch.ShouldRespondInGroup("group-b", false, "/ask hello"); !respond || content != "hello" {
+		t.Errorf("group-b should keep channel prefixes: respond=%v content=%q", respond, content)
+	}
+
+	// Per-chat prefixes enable prefix matching even on mention_only channels
+	mentionOnly := NewBaseChannel("test", nil, nil, nil,
+		WithGroupTrigger(config.GroupTriggerConfig{MentionOnly: true}))
+	mentionOnly.SetGroupTriggerPrefixes("group-a", []string{"@assistant"})
+	if respond, _ := mentionOnly.ShouldRespondInGroup("group-a", false, "@assistant hi"); !respond {
+		t.Error("chat prefix should trigger on mention_only channel")
+	}
+
+	ch.SetGroupTriggerPrefixes("group-a", nil)
+	if got := ch.GroupTriggerPrefixes("group-a"); got != nil {
+		t.Errorf("GroupTriggerPrefixes() = %v, want cleared", got)
+	}
+}
+
+func TestHandleMessage_EmptyGroupTrigger(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := NewBaseChannel("test", nil, msgBus, nil,
+		WithGroupTrigger(config.GroupTriggerConfig{MentionOnly: true, EmptyReply: "Hm?"}))
+	group := bus.Peer{Kind: "group", ID: "group-a"}
+
+	ch.HandleMessage(context.Background(), group, "m1", "user1", "group-a", "  ", nil, nil)
+	select {
+	case out := <-msgBus.OutboundChan():
+		if out.Content != "Hm?" || out.ChatID != "group-a" || out.ReplyToMessageID != "m1" {
+			t.Errorf("empty trigger reply = %+v", out)
+		}
+	default:
+		t.Fatal("expected a reply to the empty trigger")
+	}
+	select {
+	case in := <-msgBus.InboundChan():
+		t.Fatalf("empty trigger should not reach the agent, got %+v", in)
+	default:
+	}
+
+	// A message with media is forwarded even without text.
+	ch.HandleMessage(context.Background(), group, "m2", "user1", "group-a", "", []string{"media://1"}, nil)
+	select {
+	case <-msgBus.InboundChan():
+	default:
+		t.Fatal("expected media message to be forwarded")
+	}
+
+	// Chats that need no trigger drop empty messages silently.
+	ch.SetGroupTriggerMode("group-b", GroupTriggerAll)
+	ch.HandleMessage(context.Background(), bus.Peer{Kind: "group", ID: "group-b"}, "m3", "user1", "group-b", "", nil, nil)
+	select {
+	case out := <-msgBus.OutboundChan():
+		t.Fatalf("unexpected reply in group without trigger: %+v", out)
+	case in := <-msgBus.InboundChan():
+		t.Fatalf("empty message should not reach the agent, got %+v", in)
+	default:
+	}
+
+	if got := NewBaseChannel("test", nil, nil, nil).EmptyTriggerReply(); got != DefaultEmptyTriggerReply {
+		t.Errorf("EmptyTriggerReply() = %q, want default", got)
+	}
+}
+
 func TestIsAllowedSender(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -263,3 +409,72 @@ func TestIsAllowedSender(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleMessage_ReplyContext(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := NewBaseChannel("test", nil, msgBus, nil)
+	direct := bus.Peer{Kind: "direct", ID: "user1"}
+
+	metadata := map[string]string{
+		bus.MetadataReplyToMessageID: "m0",
+		bus.MetadataReplyToAuthor:    "alice",
+		bus.MetadataReplyToText:      "the build is broken",
+	}
+	ch.HandleMessage(context.Background(), direct, "m1", "user1", "chat1", "why?", nil, metadata)
+	in := <-msgBus.InboundChan()
+	want := bus.ReplyContext{MessageID: "m0", Author: "alice", Text: "the build is broken"}
+	if in.ReplyTo == nil || *in.ReplyTo != want {
+		t.Errorf("ReplyTo = %+v, want %+v", in.ReplyTo, want)
+	}
+
+	ch.HandleMessage(context.Background(), direct, "m2", "user1", "chat1", "hello", nil, nil)
+	if in := <-msgBus.InboundChan(); in.ReplyTo != nil {
+		t.Errorf("ReplyTo = %+v, want nil for a message that is not a reply", in.ReplyTo)
+	}
+}
+
+func TestAllowsMediaType(t *testing.T) {
+	open := NewBaseChannel("test", nil, nil, nil)
+	if !open.AllowsMediaType("video") {
+		t.Error("a channel without filters should accept every media type")
+	}
+
+	ch := NewBaseChannel("test", nil, nil, nil,
+		WithMediaTypes([]string{" Image ", "audio", "file"}, []string{"file"}, ""))
+	for mediaType, want := range map[string]bool{"image": true, "audio": true, "video": false, "file": false} {
+		if got := ch.AllowsMediaType(mediaType); got != want {
+			t.Errorf("AllowsMediaType(%q) = %v, want %v", mediaType, got, want)
+		}
+	}
+}
+
+func TestHandleMessage_RejectedMedia(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := NewBaseChannel("test", nil, msgBus, nil,
+		WithMediaTypes(nil, []string{"image"}, "Images are not accepted here."))
+	direct := bus.Peer{Kind: "direct", ID: "user1"}
+	rejected := map[string]string{MetadataMediaRejected: "image"}
+
+	ch.HandleMessage(context.Background(), direct, "m1", "user1", "chat1", "", nil, rejected)
+	select {
+	case out := <-msgBus.OutboundChan():
+		if out.Content != "Images are not accepted here." || out.ReplyToMessageID != "m1" {
+			t.Errorf("rejection note = %+v", out)
+		}
+	default:
+		t.Fatal("expected the rejection note")
+	}
+	select {
+	case in := <-msgBus.InboundChan():
+		t.Fatalf("a message with only rejected media should not reach the agent, got %+v", in)
+	default:
+	}
+
+	ch.HandleMessage(context.Background(), direct, "m2", "user1", "chat1", "look at this", nil, rejected)
+	<-msgBus.OutboundChan()
+	if in := <-msgBus.InboundChan(); in.Content != "look at this" {
+		t.Errorf("caption should still reach the agent, got %q", in.Content)
+	}
+}
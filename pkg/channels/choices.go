@@ -0,0 +1,74 @@
+package channels
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+// FormatChoicesText appends choices to content as a numbered list, the
+// fallback for channels that cannot render buttons.
+func FormatChoicesText(content string, choices []string) string {
+	if len(choices) == 0 {
+		return content
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(content, "\n"))
+	if b.Len() > 0 {
+		b.WriteString("\n\n")
+	}
+	for i, choice := range choices {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%d. %s", i+1, choice)
+	}
+	b.WriteString("\n\nReply with the number or text of your choice.")
+	return b.String()
+}
+
+// outboundChunks prepares msg for ch: choices become numbered text unless ch
+// is a ChoiceSender, agent replies get the channel footer, and content over
+// the channel's length limit is split.
+// Only the last chunk keeps the choices so buttons appear once, at the end.
+func outboundChunks(ch Channel, msg bus.OutboundMessage) []bus.OutboundMessage {
+	if len(msg.Choices) > 0 {
+		if _, ok := ch.(ChoiceSender); !ok {
+			msg.Content = FormatChoicesText(msg.Content, msg.Choices)
+			msg.Choices = nil
+		}
+	}
+
+	// The footer is added before splitting so it counts toward the limit.
+	if fp, ok := ch.(interface{ Footer() string }); ok && msg.AgentReply {
+		msg.Content = appendFooter(msg.Content, fp.Footer())
+	}
+
+	maxLen := 0
+	if mlp, ok := ch.(MessageLengthProvider); ok {
+		maxLen = mlp.MaxMessageLength()
+	}
+	if maxLen <= 0 || len([]rune(msg.Content)) <= maxLen {
+		return []bus.OutboundMessage{msg}
+	}
+
+	parts := SplitMessageSmart(msg.Content, maxLen)
+	chunks := make([]bus.OutboundMessage, len(parts))
+	for i, part := range parts {
+		chunks[i] = msg
+		chunks[i].Content = part
+		if i < len(parts)-1 {
+			chunks[i].Choices = nil
+		}
+	}
+	return chunks
+}
+
+// appendFooter adds footer below content, separated by a blank line.
+func appendFooter(content, footer string) string {
+	if footer == "" || strings.TrimSpace(content) == "" {
+		return content
+	}
+	return strings.TrimRight(content, " \t\n") + "\n\n" + footer
+}
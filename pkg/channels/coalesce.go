@@ -0,0 +1,149 @@
+package channels
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+// coalesceMaxMessages caps how many messages are joined into one, so a
+// sender who never pauses still gets an answer.
+const coalesceMaxMessages = 10
+
+// WithCoalesceWindow joins messages a sender posts in quick succession into a
+// single inbound message. A message is held until the sender has been quiet in
+// that chat for window; each new message restarts the wait. Zero, the default,
+// publishes every message as soon as it arrives.
+func WithCoalesceWindow(window time.Duration) BaseChannelOption {
+	return func(c *BaseChannel) { c.coalesceWindow = window }
+}
+
+// pendingInbound is an inbound message held back by coalescing. Each new
+// message bumps gen and arms a fresh timer; a timer that fires for an older
+// generation does nothing, since its callback may already have been waiting
+// on coalesceMu when the batch grew.
+type pendingInbound struct {
+	msg   bus.InboundMessage
+	ctx   context.Context
+	count int
+	gen   int
+	timer *time.Timer
+}
+
+// coalesceKey identifies the sender and chat whose messages are joined.
+func coalesceKey(msg bus.InboundMessage) string {
+	return msg.ChatID + "\x00" + msg.SenderID
+}
+
+// coalesce holds msg back, joining it to a waiting message from the same
+// sender. The joined message is dispatched once the sender has been quiet for
+// the coalescing window or coalesceMaxMessages have been joined.
+func (c *BaseChannel) coalesce(ctx context.Context, msg bus.InboundMessage) {
+	key := coalesceKey(msg)
+
+	c.coalesceMu.Lock()
+	if c.pendingInbound == nil {
+		c.pendingInbound = make(map[string]*pendingInbound)
+	}
+	p, ok := c.pendingInbound[key]
+	if !ok {
+		// The dispatch happens after the handler that received the message
+		// has returned, so it must not inherit a request-scoped cancel.
+		p = &pendingInbound{msg: msg, ctx: context.WithoutCancel(ctx), count: 1}
+		c.pendingInbound[key] = p
+		c.armCoalesceTimer(key, p)
+		c.coalesceMu.Unlock()
+		return
+	}
+
+	p.msg = joinInbound(p.msg, msg)
+	p.count++
+	if p.count < coalesceMaxMessages {
+		p.timer.Stop()
+		c.armCoalesceTimer(key, p)
+		c.coalesceMu.Unlock()
+		return
+	}
+	p.timer.Stop()
+	delete(c.pendingInbound, key)
+	c.coalesceMu.Unlock()
+
+	c.dispatch(ctx, p.msg)
+}
+
+// armCoalesceTimer starts a new quiet-period timer for p's current
+// generation. The caller holds coalesceMu.
+func (c *BaseChannel) armCoalesceTimer(key string, p *pendingInbound) {
+	p.gen++
+	gen := p.gen
+	p.timer = time.AfterFunc(c.coalesceWindow, func() {
+		c.coalesceMu.Lock()
+		current := c.pendingInbound[key] == p && p.gen == gen
+		if current {
+			delete(c.pendingInbound, key)
+		}
+		c.coalesceMu.Unlock()
+
+		if current {
+			c.dispatch(p.ctx, p.msg)
+		}
+	})
+}
+
+// flushAllCoalesced dispatches every waiting message, so nothing a sender
+// posted is lost when the channel stops.
+func (c *BaseChannel) flushAllCoalesced() {
+	c.coalesceMu.Lock()
+	pending := c.pendingInbound
+	c.pendingInbound = nil
+	for _, p := range pending {
+		p.timer.Stop()
+	}
+	c.coalesceMu.Unlock()
+
+	for _, p := range pending {
+		c.dispatch(p.ctx, p.msg)
+	}
+}
+
+// flushCoalesced dispatches the message waiting under key, if any.
+func (c *BaseChannel) flushCoalesced(ctx context.Context, key string) {
+	c.coalesceMu.Lock()
+	p, ok := c.pendingInbound[key]
+	if ok {
+		p.timer.Stop()
+		delete(c.pendingInbound, key)
+	}
+	c.coalesceMu.Unlock()
+
+	if ok {
+		c.dispatch(ctx, p.msg)
+	}
+}
+
+// joinInbound appends next to msg. The joined message takes the ID of the
+// latest message, so replies and reactions target what the user sent last,
+// and keeps the first reply context.
+func joinInbound(msg, next bus.InboundMessage) bus.InboundMessage {
+	switch {
+	case strings.TrimSpace(msg.Content) == "":
+		msg.Content = next.Content
+	case strings.TrimSpace(next.Content) != "":
+		msg.Content += "\n" + next.Content
+	}
+	msg.Media = append(msg.Media, next.Media...)
+	msg.MessageID = next.MessageID
+	if msg.ReplyTo == nil {
+		msg.ReplyTo = next.ReplyTo
+	}
+	if len(next.Metadata) > 0 {
+		merged := make(map[string]string, len(msg.Metadata)+len(next.Metadata))
+		maps.Copy(merged, msg.Metadata)
+		maps.Copy(merged, next.Metadata)
+		msg.Metadata = merged
+	}
+	return msg
+}
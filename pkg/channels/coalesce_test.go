@@ -0,0 +1,148 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+func receiveInbound(t *testing.T, msgBus *bus.MessageBus) bus.InboundMessage {
+	t.Helper()
+	select {
+	case in := <-msgBus.InboundChan():
+		return in
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an inbound message")
+		return bus.InboundMessage{}
+	}
+}
+
+func expectNoInbound(t *testing.T, msgBus *bus.MessageBus) {
+	t.Helper()
+	select {
+	case in := <-msgBus.InboundChan():
+		t.Fatalf("unexpected inbound message %+v", in)
+	default:
+	}
+}
+
+func TestCoalesce_JoinsRapidMessages(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := NewBaseChannel("test", nil, msgBus, nil, WithCoalesceWindow(50*time.Millisecond))
+	direct := bus.Peer{Kind: "direct", ID: "user1"}
+	ctx := context.Background()
+
+	ch.HandleMessage(ctx, direct, "m1", "user1", "chat1", "hi", nil, nil)
+	ch.HandleMessage(ctx, direct, "m2", "user1", "chat1", "can you", nil, nil)
+	ch.HandleMessage(ctx, direct, "m3", "user1", "chat1", "check the logs?", []string{"media://a"}, nil)
+	// Another sender in the same chat is not joined.
+	ch.HandleMessage(ctx, direct, "m4", "user2", "chat1", "unrelated", nil, nil)
+	expectNoInbound(t, msgBus)
+
+	got := map[string]bus.InboundMessage{}
+	for range 2 {
+		in := receiveInbound(t, msgBus)
+		got[in.SenderID] = in
+	}
+	joined := got["user1"]
+	if joined.Content != "hi\ncan you\ncheck the logs?" {
+		t.Errorf("joined content = %q", joined.Content)
+	}
+	if joined.MessageID != "m3" {
+		t.Errorf("joined MessageID = %q, want the latest (m3)", joined.MessageID)
+	}
+	if len(joined.Media) != 1 || joined.Media[0] != "media://a" {
+		t.Errorf("joined media = %v", joined.Media)
+	}
+	if got["user2"].Content != "unrelated" {
+		t.Errorf("user2 content = %q", got["user2"].Content)
+	}
+}
+
+func TestCoalesce_CommandFlushesPending(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := NewBaseChannel("test", nil, msgBus, nil, WithCoalesceWindow(time.Hour))
+	direct := bus.Peer{Kind: "direct", ID: "user1"}
+	ctx := context.Background()
+
+	ch.HandleMessage(ctx, direct, "m1", "user1", "chat1", "hello", nil, nil)
+	ch.HandleMessage(ctx, direct, "m2", "user1", "chat1", "/help", nil, nil)
+
+	if in := receiveInbound(t, msgBus); in.Content != "hello" {
+		t.Errorf("first = %q, want the pending message", in.Content)
+	}
+	if in := receiveInbound(t, msgBus); in.Content != "/help" {
+		t.Errorf("second = %q, want the command", in.Content)
+	}
+}
+
+func TestCoalesce_MaxMessages(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := NewBaseChannel("test", nil, msgBus, nil, WithCoalesceWindow(time.Hour))
+	direct := bus.Peer{Kind: "direct", ID: "user1"}
+
+	for range coalesceMaxMessages {
+		ch.HandleMessage(context.Background(), direct, "m", "user1", "chat1", "x", nil, nil)
+	}
+	in := receiveInbound(t, msgBus)
+	if want := coalesceMaxMessages*2 - 1; len(in.Content) != want {
+		t.Errorf("len(content) = %d, want %d", len(in.Content), want)
+	}
+}
+
+func TestCoalesce_DisabledByDefault(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := NewBaseChannel("test", nil, msgBus, nil)
+	direct := bus.Peer{Kind: "direct", ID: "user1"}
+
+	ch.HandleMessage(context.Background(), direct, "m1", "user1", "chat1", "one", nil, nil)
+	select {
+	case in := <-msgBus.InboundChan():
+		if in.Content != "one" {
+			t.Errorf("content = %q", in.Content)
+		}
+	default:
+		t.Fatal("message should be published immediately")
+	}
+}
+
+func TestCoalesce_StaleTimerDoesNotFlush(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	ch := NewBaseChannel("test", nil, msgBus, nil, WithCoalesceWindow(time.Hour))
+	direct := bus.Peer{Kind: "direct", ID: "user1"}
+
+	ch.HandleMessage(context.Background(), direct, "m1", "user1", "chat1", "one", nil, nil)
+	key := coalesceKey(bus.InboundMessage{ChatID: "chat1", SenderID: "user1"})
+
+	// Arm a short timer and hold the lock until it has fired and is waiting
+	// on it, then grow the batch the way coalesce does.
+	ch.coalesceMu.Lock()
+	p := ch.pendingInbound[key]
+	p.timer.Stop()
+	ch.coalesceWindow = 10 * time.Millisecond
+	ch.armCoalesceTimer(key, p)
+	time.Sleep(50 * time.Millisecond)
+	p.msg = joinInbound(p.msg, bus.InboundMessage{Content: "two", MessageID: "m2"})
+	p.count++
+	p.timer.Stop()
+	ch.coalesceWindow = time.Hour
+	ch.armCoalesceTimer(key, p)
+	ch.coalesceMu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+	expectNoInbound(t, msgBus)
+
+	// Stopping the channel publishes the batch that is still waiting.
+	ch.SetRunning(false)
+	if in := receiveInbound(t, msgBus); in.Content != "one\ntwo" {
+		t.Errorf("content = %q, want the joined batch", in.Content)
+	}
+	expectNoInbound(t, msgBus)
+}
@@ -0,0 +1,169 @@
+package channels
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/fileutil"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	// DefaultDedupTTL is how long a persisted message ID suppresses a
+	// redelivery. Platforms redeliver within minutes, so an hour covers a
+	// restart or deploy with room to spare.
+	DefaultDedupTTL = time.Hour
+	// dedupStoreMaxEntries bounds the file; the oldest entries go first.
+	dedupStoreMaxEntries = 4096
+	// dedupFlushDelay is how long new entries wait before they are written
+	// out together. A crash within it only weakens dedup after the restart.
+	dedupFlushDelay = 2 * time.Second
+)
+
+// DedupStore remembers recently handled inbound message IDs on disk, so a
+// message the platform redelivers across a restart is not processed twice.
+// Entries are keyed by channel and message ID and expire after a TTL. New
+// entries are written out in batches, at most dedupFlushDelay after they are
+// recorded, and on Close.
+type DedupStore struct {
+	mu         sync.Mutex
+	path       string
+	ttl        time.Duration
+	seen       map[string]time.Time
+	now        func() time.Time
+	dirty      bool
+	flushTimer *time.Timer
+	closed     bool
+
+	writeMu sync.Mutex // serializes file writes, which happen outside mu
+}
+
+// NewDedupStore loads the store at path, dropping expired entries. A missing
+// or unreadable file starts an empty store. ttl <= 0 uses DefaultDedupTTL.
+func NewDedupStore(path string, ttl time.Duration) *DedupStore {
+	if ttl <= 0 {
+		ttl = DefaultDedupTTL
+	}
+	s := &DedupStore{
+		path: path,
+		ttl:  ttl,
+		seen: make(map[string]time.Time),
+		now:  time.Now,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.WarnCF("channels", "Failed to read dedup store", map[string]any{
+				"path":  path,
+				"error": err.Error(),
+			})
+		}
+		return s
+	}
+	if err := json.Unmarshal(data, &s.seen); err != nil {
+		logger.WarnCF("channels", "Ignoring corrupt dedup store", map[string]any{
+			"path":  path,
+			"error": err.Error(),
+		})
+		s.seen = make(map[string]time.Time)
+	}
+	s.expireLocked()
+	return s
+}
+
+// SeenBefore records channel/messageID and reports whether it was already
+// recorded within the TTL. Empty IDs are never duplicates. A nil store
+// always returns false.
+func (s *DedupStore) SeenBefore(channel, messageID string) bool {
+	if s == nil || messageID == "" {
+		return false
+	}
+	key := channel + ":" + messageID
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if at, ok := s.seen[key]; ok && now.Sub(at) < s.ttl {
+		return true
+	}
+	s.seen[key] = now
+	if len(s.seen) > dedupStoreMaxEntries {
+		s.expireLocked()
+	}
+	s.dirty = true
+	if s.flushTimer == nil && !s.closed {
+		s.flushTimer = time.AfterFunc(dedupFlushDelay, s.Flush)
+	}
+	return false
+}
+
+// Flush writes pending entries to disk now. It is a no-op when nothing
+// changed since the last write.
+func (s *DedupStore) Flush() {
+	if s == nil {
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.mu.Lock()
+	if s.flushTimer != nil {
+		s.flushTimer.Stop()
+		s.flushTimer = nil
+	}
+	if !s.dirty {
+		s.mu.Unlock()
+		return
+	}
+	s.expireLocked()
+	data, err := json.Marshal(s.seen)
+	s.dirty = false
+	s.mu.Unlock()
+
+	if err == nil {
+		err = fileutil.WriteFileAtomic(s.path, data, 0o600)
+	}
+	if err != nil {
+		logger.WarnCF("channels", "Failed to save dedup store", map[string]any{
+			"path":  s.path,
+			"error": err.Error(),
+		})
+	}
+}
+
+// Close writes pending entries and stops scheduling further writes; entries
+// recorded afterwards are kept in memory only.
+func (s *DedupStore) Close() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.Flush()
+}
+
+// expireLocked drops expired entries and, past dedupStoreMaxEntries, the
+// oldest ones. Callers must hold s.mu.
+func (s *DedupStore) expireLocked() {
+	now := s.now()
+	for key, at := range s.seen {
+		if now.Sub(at) >= s.ttl {
+			delete(s.seen, key)
+		}
+	}
+	for len(s.seen) > dedupStoreMaxEntries {
+		var oldestKey string
+		var oldest time.Time
+		for key, at := range s.seen {
+			if oldestKey == "" || at.Before(oldest) {
+				oldestKey, oldest = key, at
+			}
+		}
+		delete(s.seen, oldestKey)
+	}
+}
@@ -0,0 +1,79 @@
+package channels
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDedupStore_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "channel_dedup.json")
+
+	s := NewDedupStore(path, time.Hour)
+	if s.SeenBefore("onebot", "42") {
+		t.Fatal("first delivery reported as seen")
+	}
+	if !s.SeenBefore("onebot", "42") {
+		t.Fatal("second delivery not reported as seen")
+	}
+	if s.SeenBefore("wecom", "42") {
+		t.Error("same ID on another channel reported as seen")
+	}
+	s.Close()
+
+	restarted := NewDedupStore(path, time.Hour)
+	if !restarted.SeenBefore("onebot", "42") {
+		t.Error("redelivery after restart not reported as seen")
+	}
+}
+
+func TestDedupStore_ExpiresEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "channel_dedup.json")
+	now := time.Now()
+
+	s := NewDedupStore(path, time.Minute)
+	s.now = func() time.Time { return now }
+	s.SeenBefore("onebot", "1")
+
+	s.now = func() time.Time { return now.Add(2 * time.Minute) }
+	if s.SeenBefore("onebot", "1") {
+		t.Error("expired entry reported as seen")
+	}
+}
+
+func TestDedupStore_NilAndCorrupt(t *testing.T) {
+	var nilStore *DedupStore
+	if nilStore.SeenBefore("onebot", "1") {
+		t.Error("nil store reported a duplicate")
+	}
+
+	path := filepath.Join(t.TempDir(), "channel_dedup.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewDedupStore(path, 0)
+	if s.SeenBefore("onebot", "1") || s.SeenBefore("onebot", "") || s.SeenBefore("onebot", "") {
+		t.Error("corrupt file or empty IDs produced a duplicate")
+	}
+}
+
+func TestDedupStore_BatchesWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "channel_dedup.json")
+
+	s := NewDedupStore(path, time.Hour)
+	for _, id := range []string{"1", "2", "3"} {
+		s.SeenBefore("onebot", id)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no write before the flush, stat err = %v", err)
+	}
+
+	s.Flush()
+	restarted := NewDedupStore(path, time.Hour)
+	for _, id := range []string{"1", "2", "3"} {
+		if !restarted.SeenBefore("onebot", id) {
+			t.Errorf("entry %s not persisted by Flush", id)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/open-dingtalk/dingtalk-stream-sdk-go/chatbot"
 	"github.com/open-dingtalk/dingtalk-stream-sdk-go/client"
@@ -47,6 +48,9 @@ func NewDingTalkChannel(cfg config.DingTalkConfig, messageBus *bus.MessageBus) (
 		channels.WithMaxMessageLength(20000),
 		channels.WithGroupTrigger(cfg.GroupTrigger),
 		channels.WithReasoningChannelID(cfg.ReasoningChannelID),
+		channels.WithGreeting(cfg.Greeting),
+		channels.WithFooter(cfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(cfg.CoalesceWindowMS)*time.Millisecond),
 	)
 
 	return &DingTalkChannel{
@@ -175,7 +179,7 @@ func (c *DingTalkChannel) onChatBotMessageReceived(
 	} else {
 		peer = bus.Peer{Kind: "group", ID: data.ConversationId}
 		// In group chats, apply unified group trigger filtering
-		respond, cleaned := c.ShouldRespondInGroup(false, content)
+		respond, cleaned := c.ShouldRespondInGroup(chatID, false, content)
 		if !respond {
 			return nil, nil
 		}
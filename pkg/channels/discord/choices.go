@@ -0,0 +1,245 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/channels"
+	"github.com/sipeed/picoclaw/pkg/identity"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	// choiceIDPrefix marks buttons created by SendChoices so foreign
+	// component interactions are ignored.
+	choiceIDPrefix = "picoclaw_choice:"
+
+	// Discord limits: 5 buttons per row, 5 rows per message, 80-char labels.
+	maxButtonsPerRow = 5
+	maxChoiceButtons = 25
+	maxButtonLabel   = 80
+
+	// maxPendingChoices bounds how many unanswered choice sets are kept so
+	// a press can be mapped back to the full option text.
+	maxPendingChoices = 256
+)
+
+// SendChoices implements channels.ChoiceSender. Choices are rendered as
+// buttons; any beyond Discord's limit are listed as numbered text instead.
+func (c *DiscordChannel) SendChoices(ctx context.Context, msg bus.OutboundMessage) error {
+	if !c.IsRunning() {
+		return channels.ErrNotRunning
+	}
+	if msg.ChatID == "" {
+		return fmt.Errorf("channel ID is empty")
+	}
+
+	content, components := choiceMessage(msg.Content, c.rememberChoices(msg.Choices), msg.Choices)
+	disablePreview := msg.DisablePreview || c.config.DisableLinkPreview
+	return c.sendChunk(ctx, msg.ChatID, content, msg.ReplyToMessageID, disablePreview, components)
+}
+
+// EditMessageWithChoices implements channels.ChoiceSender.
+func (c *DiscordChannel) EditMessageWithChoices(
+	ctx context.Context,
+	chatID, messageID, content string,
+	choices []string,
+) error {
+	content, components := choiceMessage(content, c.rememberChoices(choices), choices)
+	edit := discordgo.NewMessageEdit(chatID, messageID).SetContent(content)
+	edit.Components = &components
+	if c.config.DisableLinkPreview {
+		edit.Flags = discordgo.MessageFlagsSuppressEmbeds
+	}
+	_, err := c.session.ChannelMessageEditComplex(edit)
+	return err
+}
+
+// choiceMessage builds the button rows for choices, tagging each button with
+// key and its index. When there are too many options for buttons, all of them
+// fall back to numbered text.
+func choiceMessage(content, key string, choices []string) (string, []discordgo.MessageComponent) {
+	if len(choices) > maxChoiceButtons {
+		return channels.FormatChoicesText(content, choices), nil
+	}
+
+	var rows []discordgo.MessageComponent
+	var row discordgo.ActionsRow
+	for i, choice := range choices {
+		row.Components = append(row.Components, discordgo.Button{
+			Label:    buttonLabel(choice),
+			Style:    discordgo.PrimaryButton,
+			CustomID: choiceIDPrefix + key + ":" + strconv.Itoa(i),
+		})
+		if len(row.Components) == maxButtonsPerRow {
+			rows = append(rows, row)
+			row = discordgo.ActionsRow{}
+		}
+	}
+	if len(row.Components) > 0 {
+		rows = append(rows, row)
+	}
+	return content, rows
+}
+
+// buttonLabel cuts choice to Discord's label limit. Unlike utils.Truncate it
+// ignores the global no-truncation flag, since an over-long label makes
+// Discord reject the whole message.
+func buttonLabel(choice string) string {
+	runes := []rune(choice)
+	if len(runes) <= maxButtonLabel {
+		return choice
+	}
+	return string(runes[:maxButtonLabel-1]) + "…"
+}
+
+// rememberChoices stores choices under a fresh key so a button press can be
+// resolved to the full option text rather than its possibly cut label.
+func (c *DiscordChannel) rememberChoices(choices []string) string {
+	c.choicesMu.Lock()
+	defer c.choicesMu.Unlock()
+
+	c.choiceSeq++
+	key := strconv.FormatInt(time.Now().UnixNano(), 36) + strconv.FormatUint(c.choiceSeq, 36)
+	if len(c.choiceOrder) >= maxPendingChoices {
+		delete(c.choices, c.choiceOrder[0])
+		c.choiceOrder = c.choiceOrder[1:]
+	}
+	c.choices[key] = append([]string(nil), choices...)
+	c.choiceOrder = append(c.choiceOrder, key)
+	return key
+}
+
+// takeChoice returns the full text of the option a button with customID
+// stands for and forgets the set it belongs to. ok is false when the set is
+// unknown, e.g. after a restart.
+func (c *DiscordChannel) takeChoice(customID string) (choice string, ok bool) {
+	key, idx, found := strings.Cut(strings.TrimPrefix(customID, choiceIDPrefix), ":")
+	if !found {
+		return "", false
+	}
+	i, err := strconv.Atoi(idx)
+	if err != nil {
+		return "", false
+	}
+
+	c.choicesMu.Lock()
+	defer c.choicesMu.Unlock()
+	choices, exists := c.choices[key]
+	if !exists || i < 0 || i >= len(choices) {
+		return "", false
+	}
+	delete(c.choices, key)
+	for j, k := range c.choiceOrder {
+		if k == key {
+			c.choiceOrder = append(c.choiceOrder[:j], c.choiceOrder[j+1:]...)
+			break
+		}
+	}
+	return choices[i], true
+}
+
+// handleInteraction turns a press on a choice button into an inbound message
+// whose content is the chosen option, and removes the buttons so the choice
+// cannot be made twice.
+func (c *DiscordChannel) handleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i == nil || i.Interaction == nil || i.Type != discordgo.InteractionMessageComponent || i.Message == nil {
+		return
+	}
+	data := i.MessageComponentData()
+	if !strings.HasPrefix(data.CustomID, choiceIDPrefix) {
+		return
+	}
+
+	user := i.User
+	if i.Member != nil && i.Member.User != nil {
+		user = i.Member.User
+	}
+	if user == nil {
+		return
+	}
+
+	sender := bus.SenderInfo{
+		Platform:    "discord",
+		PlatformID:  user.ID,
+		CanonicalID: identity.BuildCanonicalID("discord", user.ID),
+		Username:    user.Username,
+		DisplayName: user.Username,
+	}
+	if !c.IsAllowedSender(sender) {
+		logger.DebugCF("discord", "Choice rejected by allowlist", map[string]any{
+			"user_id": user.ID,
+		})
+		return
+	}
+
+	choice, ok := c.takeChoice(data.CustomID)
+	if !ok {
+		choice = choiceLabel(i.Message.Components, data.CustomID)
+	}
+	if choice == "" {
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("%s\n\n> %s", i.Message.Content, choice),
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		logger.WarnCF("discord", "Failed to acknowledge choice", map[string]any{
+			"error": err.Error(),
+		})
+	}
+
+	peer := bus.Peer{Kind: "channel", ID: i.ChannelID}
+	if i.GuildID == "" {
+		peer = bus.Peer{Kind: "direct", ID: user.ID}
+	}
+	metadata := map[string]string{
+		"user_id":    user.ID,
+		"username":   user.Username,
+		"guild_id":   i.GuildID,
+		"channel_id": i.ChannelID,
+		"is_dm":      fmt.Sprintf("%t", i.GuildID == ""),
+		"choice":     "true",
+	}
+
+	c.HandleMessage(c.ctx, peer, i.ID, user.ID, i.ChannelID, choice, nil, metadata, sender)
+}
+
+// choiceLabel finds the label of the button with customID.
+func choiceLabel(components []discordgo.MessageComponent, customID string) string {
+	for _, component := range components {
+		var row *discordgo.ActionsRow
+		switch v := component.(type) {
+		case *discordgo.ActionsRow:
+			row = v
+		case discordgo.ActionsRow:
+			row = &v
+		default:
+			continue
+		}
+		for _, inner := range row.Components {
+			switch b := inner.(type) {
+			case *discordgo.Button:
+				if b.CustomID == customID {
+					return b.Label
+				}
+			case discordgo.Button:
+				if b.CustomID == customID {
+					return b.Label
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+func TestChoiceMessage_ButtonRows(t *testing.T) {
+	choices := make([]string, 7)
+	for i := range choices {
+		choices[i] = fmt.Sprintf("Option %d", i+1)
+	}
+
+	content, rows := choiceMessage("Pick one", "k", choices)
+	if content != "Pick one" {
+		t.Errorf("content = %q, want unchanged", content)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	first := rows[0].(discordgo.ActionsRow)
+	if len(first.Components) != maxButtonsPerRow {
+		t.Errorf("expected %d buttons in first row, got %d", maxButtonsPerRow, len(first.Components))
+	}
+	if label := choiceLabel(rows, choiceIDPrefix+"k:6"); label != "Option 7" {
+		t.Errorf("choiceLabel = %q, want Option 7", label)
+	}
+	if label := choiceLabel(rows, "other:1"); label != "" {
+		t.Errorf("expected no label for a foreign custom ID, got %q", label)
+	}
+}
+
+func TestChoiceMessage_TooManyFallsBackToText(t *testing.T) {
+	choices := make([]string, maxChoiceButtons+1)
+	for i := range choices {
+		choices[i] = fmt.Sprintf("Option %d", i+1)
+	}
+
+	content, rows := choiceMessage("Pick one", "k", choices)
+	if rows != nil {
+		t.Errorf("expected no buttons, got %d rows", len(rows))
+	}
+	if !strings.Contains(content, "26. Option 26") {
+		t.Errorf("expected numbered options in content, got %q", content)
+	}
+}
+
+func TestChoiceMessage_LongChoiceKeepsFullText(t *testing.T) {
+	utils.SetDisableTruncation(true)
+	defer utils.SetDisableTruncation(false)
+
+	long := strings.Repeat("a", maxButtonLabel+20)
+	c := &DiscordChannel{choices: make(map[string][]string)}
+	key := c.rememberChoices([]string{"short", long})
+	_, rows := choiceMessage("Pick one", key, []string{"short", long})
+
+	button := rows[0].(discordgo.ActionsRow).Components[1].(discordgo.Button)
+	if n := len([]rune(button.Label)); n > maxButtonLabel {
+		t.Errorf("label has %d runes, want at most %d", n, maxButtonLabel)
+	}
+
+	choice, ok := c.takeChoice(button.CustomID)
+	if !ok || choice != long {
+		t.Errorf("takeChoice = %q, %v; want the full choice", choice, ok)
+	}
+	if _, ok := c.takeChoice(button.CustomID); ok {
+		t.Error("expected the choice set to be forgotten after a press")
+	}
+}
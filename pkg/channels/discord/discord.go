@@ -10,6 +10,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/gorilla/websocket"
@@ -42,6 +43,13 @@ type DiscordChannel struct {
 	typingMu   sync.Mutex
 	typingStop map[string]chan struct{} // chatID → stop signal
 	botUserID  string                   // stored for mention checking
+	presenceMu sync.Mutex
+	presence   string // custom status set via SetPresence, restored on reconnect
+
+	choicesMu   sync.Mutex
+	choices     map[string][]string // choice set key → full option texts
+	choiceOrder []string            // keys oldest first, for eviction
+	choiceSeq   uint64
 }
 
 func NewDiscordChannel(cfg config.DiscordConfig, bus *bus.MessageBus) (*DiscordChannel, error) {
@@ -65,6 +73,10 @@ func NewDiscordChannel(cfg config.DiscordConfig, bus *bus.MessageBus) (*DiscordC
 		channels.WithMaxMessageLength(2000),
 		channels.WithGroupTrigger(cfg.GroupTrigger),
 		channels.WithReasoningChannelID(cfg.ReasoningChannelID),
+		channels.WithGreeting(cfg.Greeting),
+		channels.WithFooter(cfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(cfg.CoalesceWindowMS)*time.Millisecond),
+		channels.WithMediaTypes(cfg.MediaTypesAllow, cfg.MediaTypesDeny, cfg.MediaRejectedNote),
 	)
 
 	return &DiscordChannel{
@@ -73,6 +85,7 @@ func NewDiscordChannel(cfg config.DiscordConfig, bus *bus.MessageBus) (*DiscordC
 		config:      cfg,
 		ctx:         context.Background(),
 		typingStop:  make(map[string]chan struct{}),
+		choices:     make(map[string][]string),
 	}, nil
 }
 
@@ -89,6 +102,8 @@ func (c *DiscordChannel) Start(ctx context.Context) error {
 	c.botUserID = botUser.ID
 
 	c.session.AddHandler(c.handleMessage)
+	c.session.AddHandler(c.handleInteraction)
+	c.session.AddHandler(c.handleReady)
 
 	if err := c.session.Open(); err != nil {
 		return fmt.Errorf("failed to open discord session: %w", err)
@@ -104,6 +119,39 @@ func (c *DiscordChannel) Start(ctx context.Context) error {
 	return nil
 }
 
+// maxCustomStatusLen is Discord's limit for a custom status.
+const maxCustomStatusLen = 128
+
+// SetPresence implements channels.PresenceSetter by setting the bot's custom
+// status. An empty status clears it.
+func (c *DiscordChannel) SetPresence(ctx context.Context, status string) error {
+	if utf8.RuneCountInString(status) > maxCustomStatusLen {
+		return fmt.Errorf("status is longer than %d characters", maxCustomStatusLen)
+	}
+	c.presenceMu.Lock()
+	defer c.presenceMu.Unlock()
+	if err := c.session.UpdateCustomStatus(status); err != nil {
+		return fmt.Errorf("discord update status: %w", err)
+	}
+	c.presence = status
+	return nil
+}
+
+// handleReady restores the custom status after the gateway reconnects, since
+// a new session starts without one.
+func (c *DiscordChannel) handleReady(s *discordgo.Session, _ *discordgo.Ready) {
+	c.presenceMu.Lock()
+	defer c.presenceMu.Unlock()
+	if c.presence == "" {
+		return
+	}
+	if err := s.UpdateCustomStatus(c.presence); err != nil {
+		logger.WarnCF("discord", "Failed to restore custom status", map[string]any{
+			"error": err.Error(),
+		})
+	}
+}
+
 func (c *DiscordChannel) Stop(ctx context.Context) error {
 	logger.InfoC("discord", "Stopping Discord bot")
 	c.SetRunning(false)
@@ -142,7 +190,8 @@ func (c *DiscordChannel) Send(ctx context.Context, msg bus.OutboundMessage) erro
 		return nil
 	}
 
-	return c.sendChunk(ctx, channelID, msg.Content, msg.ReplyToMessageID)
+	disablePreview := msg.DisablePreview || c.config.DisableLinkPreview
+	return c.sendChunk(ctx, channelID, msg.Content, msg.ReplyToMessageID, disablePreview, nil)
 }
 
 // SendMedia implements the channels.MediaSender interface.
@@ -242,7 +291,27 @@ func (c *DiscordChannel) SendMedia(ctx context.Context, msg bus.OutboundMediaMes
 
 // EditMessage implements channels.MessageEditor.
 func (c *DiscordChannel) EditMessage(ctx context.Context, chatID string, messageID string, content string) error {
-	_, err := c.session.ChannelMessageEdit(chatID, messageID, content)
+	return c.editMessage(chatID, messageID, content, c.config.DisableLinkPreview)
+}
+
+// EditMessageWithoutPreview implements channels.LinkPreviewEditor.
+func (c *DiscordChannel) EditMessageWithoutPreview(
+	ctx context.Context,
+	chatID string,
+	messageID string,
+	content string,
+) error {
+	return c.editMessage(chatID, messageID, content, true)
+}
+
+func (c *DiscordChannel) editMessage(chatID, messageID, content string, disablePreview bool) error {
+	if !disablePreview {
+		_, err := c.session.ChannelMessageEdit(chatID, messageID, content)
+		return err
+	}
+	edit := discordgo.NewMessageEdit(chatID, messageID).SetContent(content)
+	edit.Flags = discordgo.MessageFlagsSuppressEmbeds
+	_, err := c.session.ChannelMessageEditComplex(edit)
 	return err
 }
 
@@ -267,7 +336,12 @@ func (c *DiscordChannel) SendPlaceholder(ctx context.Context, chatID string) (st
 	return msg.ID, nil
 }
 
-func (c *DiscordChannel) sendChunk(ctx context.Context, channelID, content, replyToID string) error {
+func (c *DiscordChannel) sendChunk(
+	ctx context.Context,
+	channelID, content, replyToID string,
+	disablePreview bool,
+	components []discordgo.MessageComponent,
+) error {
 	// Use the passed ctx for timeout control
 	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
 	defer cancel()
@@ -276,15 +350,20 @@ func (c *DiscordChannel) sendChunk(ctx context.Context, channelID, content, repl
 	go func() {
 		var err error
 
-		// If we have an ID, we send the message as "Reply"
-		if replyToID != "" {
-			_, err = c.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
-				Content: content,
-				Reference: &discordgo.MessageReference{
+		// Replies, embed suppression and buttons all need the complex send API
+		if replyToID != "" || disablePreview || len(components) > 0 {
+			send := &discordgo.MessageSend{Content: content, Components: components}
+			// If we have an ID, we send the message as "Reply"
+			if replyToID != "" {
+				send.Reference = &discordgo.MessageReference{
 					MessageID: replyToID,
 					ChannelID: channelID,
-				},
-			})
+				}
+			}
+			if disablePreview {
+				send.Flags = discordgo.MessageFlagsSuppressEmbeds
+			}
+			_, err = c.session.ChannelMessageSendComplex(channelID, send)
 		} else {
 			// Otherwise, we send a normal message
 			_, err = c.session.ChannelMessageSend(channelID, content)
@@ -355,7 +434,7 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 			}
 		}
 		content = c.stripBotMention(content)
-		respond, cleaned := c.ShouldRespondInGroup(isMentioned, content)
+		respond, cleaned := c.ShouldRespondInGroup(m.ChannelID, isMentioned, content)
 		if !respond {
 			logger.DebugCF("discord", "Group message ignored by group trigger", map[string]any{
 				"user_id": m.Author.ID,
@@ -372,17 +451,14 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 	// double-expanding links that appear in the referenced message.
 	content = c.resolveDiscordRefs(s, content, m.GuildID)
 
-	// Prepend referenced (quoted) message content if this is a reply
+	// Capture the referenced (quoted) message if this is a reply
+	var replyAuthor, replyText string
 	if m.MessageReference != nil && m.ReferencedMessage != nil {
-		refContent := m.ReferencedMessage.Content
-		if refContent != "" {
-			refAuthor := "unknown"
-			if m.ReferencedMessage.Author != nil {
-				refAuthor = m.ReferencedMessage.Author.Username
-			}
-			refContent = c.resolveDiscordRefs(s, refContent, m.GuildID)
-			content = fmt.Sprintf("[quoted message from %s]: %s\n\n%s",
-				refAuthor, refContent, content)
+		if m.ReferencedMessage.Author != nil {
+			replyAuthor = m.ReferencedMessage.Author.Username
+		}
+		if m.ReferencedMessage.Content != "" {
+			replyText = c.resolveDiscordRefs(s, m.ReferencedMessage.Content, m.GuildID)
 		}
 	}
 
@@ -406,7 +482,12 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 		return localPath // fallback
 	}
 
+	var rejected []string
 	for _, attachment := range m.Attachments {
+		if mediaType := utils.InferMediaType(attachment.Filename, attachment.ContentType); !c.AllowsMediaType(mediaType) {
+			rejected = append(rejected, mediaType)
+			continue
+		}
 		isAudio := utils.IsAudioFile(attachment.Filename, attachment.ContentType)
 
 		if isAudio {
@@ -428,11 +509,11 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 		}
 	}
 
-	if content == "" && len(mediaPaths) == 0 {
+	if content == "" && len(mediaPaths) == 0 && len(rejected) == 0 {
 		return
 	}
 
-	if content == "" {
+	if content == "" && len(mediaPaths) > 0 {
 		content = "[media only]"
 	}
 
@@ -459,6 +540,14 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 		"channel_id":   m.ChannelID,
 		"is_dm":        fmt.Sprintf("%t", m.GuildID == ""),
 	}
+	if len(rejected) > 0 {
+		metadata[channels.MetadataMediaRejected] = strings.Join(rejected, ",")
+	}
+	if m.MessageReference != nil {
+		metadata[bus.MetadataReplyToMessageID] = m.MessageReference.MessageID
+		metadata[bus.MetadataReplyToAuthor] = replyAuthor
+		metadata[bus.MetadataReplyToText] = replyText
+	}
 
 	c.HandleMessage(c.ctx, peer, m.ID, senderID, m.ChannelID, content, mediaPaths, metadata, sender)
 }
@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	lark "github.com/larksuite/oapi-sdk-go/v3"
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
@@ -51,6 +52,10 @@ func NewFeishuChannel(cfg config.FeishuConfig, bus *bus.MessageBus) (*FeishuChan
 	base := channels.NewBaseChannel("feishu", cfg, bus, cfg.AllowFrom,
 		channels.WithGroupTrigger(cfg.GroupTrigger),
 		channels.WithReasoningChannelID(cfg.ReasoningChannelID),
+		channels.WithGreeting(cfg.Greeting),
+		channels.WithFooter(cfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(cfg.CoalesceWindowMS)*time.Millisecond),
+		channels.WithMediaTypes(cfg.MediaTypesAllow, cfg.MediaTypesDeny, cfg.MediaRejectedNote),
 	)
 
 	tc := newTokenCache()
@@ -387,16 +392,22 @@ func (c *FeishuChannel) handleMessageReceive(ctx context.Context, event *larkim.
 	// Extract content based on message type
 	content := extractContent(messageType, rawContent)
 
+	// Media of a type the channel refuses is dropped before download.
+	var rejected string
+	if mediaType := feishuMediaType(messageType); mediaType != "" && !c.AllowsMediaType(mediaType) {
+		rejected = mediaType
+	}
+
 	// Handle media messages (download and store)
 	var mediaRefs []string
-	if store := c.GetMediaStore(); store != nil && messageID != "" {
+	if store := c.GetMediaStore(); store != nil && messageID != "" && rejected == "" {
 		mediaRefs = c.downloadInboundMedia(ctx, chatID, messageID, messageType, rawContent, store)
 	}
 
 	// Append media tags to content (like Telegram does)
 	content = appendMediaTags(content, messageType, mediaRefs)
 
-	if content == "" {
+	if content == "" && rejected == "" {
 		content = "[empty message]"
 	}
 
@@ -414,6 +425,9 @@ func (c *FeishuChannel) handleMessageReceive(ctx context.Context, event *larkim.
 	if sender != nil && sender.TenantKey != nil {
 		metadata["tenant_key"] = *sender.TenantKey
 	}
+	if rejected != "" {
+		metadata[channels.MetadataMediaRejected] = rejected
+	}
 
 	var peer bus.Peer
 	if chatType == "p2p" {
@@ -430,7 +444,7 @@ func (c *FeishuChannel) handleMessageReceive(ctx context.Context, event *larkim.
 		}
 
 		// In group chats, apply unified group trigger filtering
-		respond, cleaned := c.ShouldRespondInGroup(isMentioned, content)
+		respond, cleaned := c.ShouldRespondInGroup(chatID, isMentioned, content)
 		if !respond {
 			return nil
 		}
@@ -684,6 +698,22 @@ func (c *FeishuChannel) downloadResource(
 	return ref
 }
 
+// feishuMediaType maps a Feishu message type to an inbound media type, or ""
+// for messages that carry no media.
+func feishuMediaType(messageType string) string {
+	switch messageType {
+	case larkim.MsgTypeImage:
+		return "image"
+	case larkim.MsgTypeAudio:
+		return "audio"
+	case larkim.MsgTypeMedia:
+		return "video"
+	case larkim.MsgTypeFile:
+		return "file"
+	}
+	return ""
+}
+
 // appendMediaTags appends media type tags to content (like Telegram's "[image: photo]").
 func appendMediaTags(content, messageType string, mediaRefs []string) string {
 	if len(mediaRefs) == 0 {
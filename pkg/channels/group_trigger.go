@@ -0,0 +1,38 @@
+package channels
+
+// Group trigger modes that override a channel's GroupTriggerConfig for a
+// single chat at runtime (see GroupTriggerOverrider).
+const (
+	// GroupTriggerMention responds only when the bot is mentioned.
+	GroupTriggerMention = "mention"
+	// GroupTriggerPrefix responds when the message starts with a trigger prefix
+	// (or the bot is mentioned).
+	GroupTriggerPrefix = "prefix"
+	// GroupTriggerAll responds to every message in the chat.
+	GroupTriggerAll = "all"
+)
+
+// IsValidGroupTriggerMode reports whether mode is a recognized override mode.
+func IsValidGroupTriggerMode(mode string) bool {
+	switch mode {
+	case GroupTriggerMention, GroupTriggerPrefix, GroupTriggerAll:
+		return true
+	}
+	return false
+}
+
+// GroupTriggerOverrider is implemented by channels that support overriding the
+// group trigger behavior per chat at runtime. BaseChannel implements it, so
+// every channel that embeds BaseChannel supports it.
+type GroupTriggerOverrider interface {
+	// SetGroupTriggerMode sets the override for chatID. An empty mode clears
+	// the override and restores the channel's configured behavior.
+	SetGroupTriggerMode(chatID, mode string)
+	// GroupTriggerMode returns the override for chatID, or "" if none is set.
+	GroupTriggerMode(chatID string) string
+	// SetGroupTriggerPrefixes sets trigger prefixes for chatID that replace the
+	// channel-wide prefixes. An empty slice clears the override.
+	SetGroupTriggerPrefixes(chatID string, prefixes []string)
+	// GroupTriggerPrefixes returns the prefix override for chatID, or nil.
+	GroupTriggerPrefixes(chatID string) []string
+}
@@ -3,6 +3,7 @@ package channels
 import (
 	"context"
 
+	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/commands"
 )
 
@@ -19,6 +20,23 @@ type MessageEditor interface {
 	EditMessage(ctx context.Context, chatID string, messageID string, content string) error
 }
 
+// LinkPreviewEditor — MessageEditor extension for channels that can suppress
+// link previews on an edit. Manager.preSend uses it when the outbound message
+// sets DisablePreview and a placeholder is being replaced.
+type LinkPreviewEditor interface {
+	EditMessageWithoutPreview(ctx context.Context, chatID string, messageID string, content string) error
+}
+
+// ChoiceSender — channels that render OutboundMessage.Choices as native
+// buttons. A press must come back as an inbound message whose content is the
+// chosen option. Manager routes messages with choices to SendChoices (and
+// placeholder edits to EditMessageWithChoices); other channels get the
+// choices appended as numbered text.
+type ChoiceSender interface {
+	SendChoices(ctx context.Context, msg bus.OutboundMessage) error
+	EditMessageWithChoices(ctx context.Context, chatID, messageID, content string, choices []string) error
+}
+
 // ReactionCapable — channels that can add a reaction (e.g. 👀) to an inbound message.
 // ReactToMessage adds a reaction and returns an undo function to remove it.
 // The undo function MUST be idempotent and safe to call multiple times.
@@ -26,6 +44,19 @@ type ReactionCapable interface {
 	ReactToMessage(ctx context.Context, chatID, messageID string) (undo func(), err error)
 }
 
+// MessageRecaller — channels that can retract (recall/delete) a message the
+// bot sent earlier. An empty messageID recalls the bot's latest message in
+// the chat. Channels must refuse to recall messages the bot did not send.
+type MessageRecaller interface {
+	RecallMessage(ctx context.Context, chatID, messageID string) error
+}
+
+// PresenceSetter — channels that can show a short status line for the bot,
+// such as a Discord custom status. An empty status clears it.
+type PresenceSetter interface {
+	SetPresence(ctx context.Context, status string) error
+}
+
 // PlaceholderCapable — channels that can send a placeholder message
 // (e.g. "Thinking... 💭") that will later be edited to the actual response.
 // The channel MUST also implement MessageEditor for the placeholder to be useful.
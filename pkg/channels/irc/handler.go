@@ -79,7 +79,7 @@ func (c *IRCChannel) onPrivmsg(conn *ircevent.Connection, e ircmsg.Message) {
 		if isMentioned {
 			content = stripBotMention(content, currentNick)
 		}
-		respond, cleaned := c.ShouldRespondInGroup(isMentioned, content)
+		respond, cleaned := c.ShouldRespondInGroup(chatID, isMentioned, content)
 		if !respond {
 			return
 		}
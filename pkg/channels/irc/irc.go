@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ergochat/irc-go/ircevent"
 	"github.com/ergochat/irc-go/ircmsg"
@@ -37,6 +38,9 @@ func NewIRCChannel(cfg config.IRCConfig, messageBus *bus.MessageBus) (*IRCChanne
 		channels.WithMaxMessageLength(400),
 		channels.WithGroupTrigger(cfg.GroupTrigger),
 		channels.WithReasoningChannelID(cfg.ReasoningChannelID),
+		channels.WithGreeting(cfg.Greeting),
+		channels.WithFooter(cfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(cfg.CoalesceWindowMS)*time.Millisecond),
 	)
 
 	return &IRCChannel{
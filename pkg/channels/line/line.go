@@ -70,6 +70,10 @@ func NewLINEChannel(cfg config.LINEConfig, messageBus *bus.MessageBus) (*LINECha
 		channels.WithMaxMessageLength(5000),
 		channels.WithGroupTrigger(cfg.GroupTrigger),
 		channels.WithReasoningChannelID(cfg.ReasoningChannelID),
+		channels.WithGreeting(cfg.Greeting),
+		channels.WithFooter(cfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(cfg.CoalesceWindowMS)*time.Millisecond),
+		channels.WithMediaTypes(cfg.MediaTypesAllow, cfg.MediaTypesDeny, cfg.MediaRejectedNote),
 	)
 
 	return &LINEChannel{
@@ -311,6 +315,16 @@ func (c *LINEChannel) processEvent(event lineEvent) {
 		return localPath // fallback
 	}
 
+	// Media of a type the channel refuses is dropped before download.
+	var rejected string
+	allowMedia := func(mediaType string) bool {
+		if c.AllowsMediaType(mediaType) {
+			return true
+		}
+		rejected = mediaType
+		return false
+	}
+
 	switch msg.Type {
 	case "text":
 		content = msg.Text
@@ -319,24 +333,36 @@ func (c *LINEChannel) processEvent(event lineEvent) {
 			content = c.stripBotMention(content, msg)
 		}
 	case "image":
+		if !allowMedia("image") {
+			break
+		}
 		localPath := c.downloadContent(msg.ID, "image.jpg")
 		if localPath != "" {
 			mediaPaths = append(mediaPaths, storeMedia(localPath, "image.jpg"))
 			content = "[image]"
 		}
 	case "audio":
+		if !allowMedia("audio") {
+			break
+		}
 		localPath := c.downloadContent(msg.ID, "audio.m4a")
 		if localPath != "" {
 			mediaPaths = append(mediaPaths, storeMedia(localPath, "audio.m4a"))
 			content = "[audio]"
 		}
 	case "video":
+		if !allowMedia("video") {
+			break
+		}
 		localPath := c.downloadContent(msg.ID, "video.mp4")
 		if localPath != "" {
 			mediaPaths = append(mediaPaths, storeMedia(localPath, "video.mp4"))
 			content = "[video]"
 		}
 	case "file":
+		if !allowMedia("file") {
+			break
+		}
 		content = "[file]"
 	case "sticker":
 		content = "[sticker]"
@@ -344,14 +370,14 @@ func (c *LINEChannel) processEvent(event lineEvent) {
 		content = fmt.Sprintf("[%s]", msg.Type)
 	}
 
-	if strings.TrimSpace(content) == "" {
+	if strings.TrimSpace(content) == "" && rejected == "" {
 		return
 	}
 
 	// In group chats, apply unified group trigger filtering
 	if isGroup {
 		isMentioned := c.isBotMentioned(msg)
-		respond, cleaned := c.ShouldRespondInGroup(isMentioned, content)
+		respond, cleaned := c.ShouldRespondInGroup(chatID, isMentioned, content)
 		if !respond {
 			logger.DebugCF("line", "Ignoring group message by group trigger", map[string]any{
 				"chat_id": chatID,
@@ -365,6 +391,9 @@ func (c *LINEChannel) processEvent(event lineEvent) {
 		"platform":    "line",
 		"source_type": event.Source.Type,
 	}
+	if rejected != "" {
+		metadata[channels.MetadataMediaRejected] = rejected
+	}
 
 	var peer bus.Peer
 	if isGroup {
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -82,6 +83,7 @@ type Manager struct {
 	bus           *bus.MessageBus
 	config        *config.Config
 	mediaStore    media.MediaStore
+	dedupStore    *DedupStore
 	dispatchTask  *asyncTask
 	mux           *http.ServeMux
 	httpServer    *http.Server
@@ -165,7 +167,16 @@ func (m *Manager) preSend(ctx context.Context, name string, msg bus.OutboundMess
 	if v, loaded := m.placeholders.LoadAndDelete(key); loaded {
 		if entry, ok := v.(placeholderEntry); ok && entry.id != "" {
 			if editor, ok := ch.(MessageEditor); ok {
-				if err := editor.EditMessage(ctx, msg.ChatID, entry.id, msg.Content); err == nil {
+				edit := editor.EditMessage
+				if pe, ok := ch.(LinkPreviewEditor); ok && msg.DisablePreview {
+					edit = pe.EditMessageWithoutPreview
+				}
+				if cs, ok := ch.(ChoiceSender); ok && len(msg.Choices) > 0 {
+					edit = func(ctx context.Context, chatID, messageID, content string) error {
+						return cs.EditMessageWithChoices(ctx, chatID, messageID, content, msg.Choices)
+					}
+				}
+				if err := edit(ctx, msg.ChatID, entry.id, msg.Content); err == nil {
 					return true // edited successfully, skip Send
 				}
 				// edit failed → fall through to normal Send
@@ -184,6 +195,9 @@ func NewManager(cfg *config.Config, messageBus *bus.MessageBus, store media.Medi
 		config:     cfg,
 		mediaStore: store,
 	}
+	if cfg.Channels.PersistDedup {
+		m.dedupStore = NewDedupStore(filepath.Join(cfg.WorkspacePath(), "state", "channel_dedup.json"), 0)
+	}
 
 	if err := m.initChannels(); err != nil {
 		return nil, err
@@ -217,6 +231,12 @@ func (m *Manager) initChannel(name, displayName string) {
 				setter.SetMediaStore(m.mediaStore)
 			}
 		}
+		// Inject the persistent DedupStore if enabled and supported
+		if m.dedupStore != nil {
+			if setter, ok := ch.(interface{ SetDedupStore(s *DedupStore) }); ok {
+				setter.SetDedupStore(m.dedupStore)
+			}
+		}
 		// Inject PlaceholderRecorder if channel supports it
 		if setter, ok := ch.(interface{ SetPlaceholderRecorder(r PlaceholderRecorder) }); ok {
 			setter.SetPlaceholderRecorder(m)
@@ -467,6 +487,9 @@ func (m *Manager) StopAll(ctx context.Context) error {
 		}
 	}
 
+	// Channels may record message IDs until they stop; write them out last.
+	m.dedupStore.Close()
+
 	logger.InfoC("channels", "All channels stopped")
 	return nil
 }
@@ -500,19 +523,8 @@ func (m *Manager) runWorker(ctx context.Context, name string, w *channelWorker)
 			if !ok {
 				return
 			}
-			maxLen := 0
-			if mlp, ok := w.ch.(MessageLengthProvider); ok {
-				maxLen = mlp.MaxMessageLength()
-			}
-			if maxLen > 0 && len([]rune(msg.Content)) > maxLen {
-				chunks := SplitMessage(msg.Content, maxLen)
-				for _, chunk := range chunks {
-					chunkMsg := msg
-					chunkMsg.Content = chunk
-					m.sendWithRetry(ctx, name, w, chunkMsg)
-				}
-			} else {
-				m.sendWithRetry(ctx, name, w, msg)
+			for _, chunk := range outboundChunks(w.ch, msg) {
+				m.sendWithRetry(ctx, name, w, chunk)
 			}
 		case <-ctx.Done():
 			return
@@ -537,9 +549,14 @@ func (m *Manager) sendWithRetry(ctx context.Context, name string, w *channelWork
 		return // placeholder was edited successfully, skip Send
 	}
 
+	send := w.ch.Send
+	if cs, ok := w.ch.(ChoiceSender); ok && len(msg.Choices) > 0 {
+		send = cs.SendChoices
+	}
+
 	var lastErr error
 	for attempt := 0; attempt <= maxRetries; attempt++ {
-		lastErr = w.ch.Send(ctx, msg)
+		lastErr = send(ctx, msg)
 		if lastErr == nil {
 			return
 		}
@@ -688,6 +705,30 @@ func (m *Manager) runMediaWorker(ctx context.Context, name string, w *channelWor
 	}
 }
 
+// dedupMediaParts drops parts whose ref already appeared earlier in the same
+// message, so a file a tool returned twice is only uploaded once. The first
+// occurrence, with its caption, is kept. Parts without a ref are left alone.
+func dedupMediaParts(parts []bus.MediaPart) []bus.MediaPart {
+	if len(parts) < 2 {
+		return parts
+	}
+	seen := make(map[string]bool, len(parts))
+	deduped := make([]bus.MediaPart, 0, len(parts))
+	for _, part := range parts {
+		if part.Ref != "" {
+			if seen[part.Ref] {
+				continue
+			}
+			seen[part.Ref] = true
+		}
+		deduped = append(deduped, part)
+	}
+	if dropped := len(parts) - len(deduped); dropped > 0 {
+		logger.DebugCF("channels", "Dropped duplicate media parts", map[string]any{"dropped": dropped})
+	}
+	return deduped
+}
+
 // sendMediaWithRetry sends a media message through the channel with rate limiting and
 // retry logic. If the channel does not implement MediaSender, it silently skips.
 func (m *Manager) sendMediaWithRetry(ctx context.Context, name string, w *channelWorker, msg bus.OutboundMediaMessage) {
@@ -699,6 +740,8 @@ func (m *Manager) sendMediaWithRetry(ctx context.Context, name string, w *channe
 		return
 	}
 
+	msg.Parts = dedupMediaParts(msg.Parts)
+
 	// Rate limit: wait for token
 	if err := w.limiter.Wait(ctx); err != nil {
 		return
@@ -814,6 +857,128 @@ func (m *Manager) GetStatus() map[string]any {
 	return status
 }
 
+// SetGroupTriggerMode overrides the group trigger mode for a single chat on
+// the named channel. An empty mode clears the override.
+func (m *Manager) SetGroupTriggerMode(channelName, chatID, mode string) error {
+	if mode != "" && !IsValidGroupTriggerMode(mode) {
+		return fmt.Errorf("invalid group trigger mode %q", mode)
+	}
+
+	m.mu.RLock()
+	ch, exists := m.channels[channelName]
+	m.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("channel %s not found", channelName)
+	}
+	gto, ok := ch.(GroupTriggerOverrider)
+	if !ok {
+		return fmt.Errorf("channel %s does not support group trigger overrides", channelName)
+	}
+	gto.SetGroupTriggerMode(chatID, mode)
+	return nil
+}
+
+// GetGroupTriggerMode returns the group trigger override for a chat on the
+// named channel, or "" when none is set.
+func (m *Manager) GetGroupTriggerMode(channelName, chatID string) string {
+	m.mu.RLock()
+	ch, exists := m.channels[channelName]
+	m.mu.RUnlock()
+
+	if !exists {
+		return ""
+	}
+	if gto, ok := ch.(GroupTriggerOverrider); ok {
+		return gto.GroupTriggerMode(chatID)
+	}
+	return ""
+}
+
+// SetGroupTriggerPrefixes overrides the trigger prefixes for a single chat on
+// the named channel. An empty slice clears the override.
+func (m *Manager) SetGroupTriggerPrefixes(channelName, chatID string, prefixes []string) error {
+	m.mu.RLock()
+	ch, exists := m.channels[channelName]
+	m.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("channel %s not found", channelName)
+	}
+	gto, ok := ch.(GroupTriggerOverrider)
+	if !ok {
+		return fmt.Errorf("channel %s does not support group trigger overrides", channelName)
+	}
+	gto.SetGroupTriggerPrefixes(chatID, prefixes)
+	return nil
+}
+
+// GetGroupTriggerPrefixes returns the prefix override for a chat on the named
+// channel, or nil when none is set.
+func (m *Manager) GetGroupTriggerPrefixes(channelName, chatID string) []string {
+	m.mu.RLock()
+	ch, exists := m.channels[channelName]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil
+	}
+	if gto, ok := ch.(GroupTriggerOverrider); ok {
+		return gto.GroupTriggerPrefixes(chatID)
+	}
+	return nil
+}
+
+// RecallMessage retracts a message the bot sent to a chat on the named
+// channel. An empty messageID recalls the bot's latest message there.
+func (m *Manager) RecallMessage(ctx context.Context, channelName, chatID, messageID string) error {
+	m.mu.RLock()
+	ch, exists := m.channels[channelName]
+	m.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("channel %s not found", channelName)
+	}
+	recaller, ok := ch.(MessageRecaller)
+	if !ok {
+		return fmt.Errorf("channel %s does not support recalling messages", channelName)
+	}
+	return recaller.RecallMessage(ctx, chatID, messageID)
+}
+
+// SetPresence updates the bot's status line on the named channel. An empty
+// status clears it.
+func (m *Manager) SetPresence(ctx context.Context, channelName, status string) error {
+	m.mu.RLock()
+	ch, exists := m.channels[channelName]
+	m.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("channel %s not found", channelName)
+	}
+	setter, ok := ch.(PresenceSetter)
+	if !ok {
+		return fmt.Errorf("channel %s does not support a bot status", channelName)
+	}
+	return setter.SetPresence(ctx, status)
+}
+
+// GetGreeting returns the first-contact greeting configured for a channel,
+// or "" if none is configured.
+func (m *Manager) GetGreeting(channelName string) string {
+	m.mu.RLock()
+	ch, exists := m.channels[channelName]
+	m.mu.RUnlock()
+
+	if !exists {
+		return ""
+	}
+	if g, ok := ch.(interface{ Greeting() string }); ok {
+		return g.Greeting()
+	}
+	return ""
+}
+
 func (m *Manager) GetEnabledChannels() []string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -861,18 +1026,8 @@ func (m *Manager) SendMessage(ctx context.Context, msg bus.OutboundMessage) erro
 		return fmt.Errorf("channel %s has no active worker", msg.Channel)
 	}
 
-	maxLen := 0
-	if mlp, ok := w.ch.(MessageLengthProvider); ok {
-		maxLen = mlp.MaxMessageLength()
-	}
-	if maxLen > 0 && len([]rune(msg.Content)) > maxLen {
-		for _, chunk := range SplitMessage(msg.Content, maxLen) {
-			chunkMsg := msg
-			chunkMsg.Content = chunk
-			m.sendWithRetry(ctx, msg.Channel, w, chunkMsg)
-		}
-	} else {
-		m.sendWithRetry(ctx, msg.Channel, w, msg)
+	for _, chunk := range outboundChunks(w.ch, msg) {
+		m.sendWithRetry(ctx, msg.Channel, w, chunk)
 	}
 	return nil
 }
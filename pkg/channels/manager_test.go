@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -487,6 +489,44 @@ func TestPreSend_PlaceholderEditSuccess(t *testing.T) {
 	}
 }
 
+// mockPreviewEditor is a MessageEditor that also implements LinkPreviewEditor.
+type mockPreviewEditor struct {
+	mockMessageEditor
+	previewlessEdits int
+}
+
+func (m *mockPreviewEditor) EditMessageWithoutPreview(ctx context.Context, chatID, messageID, content string) error {
+	m.previewlessEdits++
+	return m.editFn(ctx, chatID, messageID, content)
+}
+
+func TestPreSend_DisablePreviewUsesLinkPreviewEditor(t *testing.T) {
+	m := newTestManager()
+	ch := &mockPreviewEditor{
+		mockMessageEditor: mockMessageEditor{
+			editFn: func(_ context.Context, _, _, _ string) error { return nil },
+		},
+	}
+
+	m.RecordPlaceholder("test", "123", "456")
+	msg := bus.OutboundMessage{Channel: "test", ChatID: "123", Content: "https://example.com"}
+	if !m.preSend(context.Background(), "test", msg, ch) {
+		t.Fatal("expected placeholder to be edited")
+	}
+	if ch.previewlessEdits != 0 {
+		t.Fatalf("expected plain edit without DisablePreview, got %d previewless edits", ch.previewlessEdits)
+	}
+
+	m.RecordPlaceholder("test", "123", "789")
+	msg.DisablePreview = true
+	if !m.preSend(context.Background(), "test", msg, ch) {
+		t.Fatal("expected placeholder to be edited")
+	}
+	if ch.previewlessEdits != 1 {
+		t.Fatalf("expected 1 previewless edit, got %d", ch.previewlessEdits)
+	}
+}
+
 func TestPreSend_PlaceholderEditFails_FallsThrough(t *testing.T) {
 	m := newTestManager()
 
@@ -1190,3 +1230,265 @@ func TestManager_SendPlaceholder(t *testing.T) {
 		t.Error("expected SendPlaceholder to fail for unknown channel")
 	}
 }
+
+type mockChoiceSender struct {
+	mockChannel
+	choiceMessages []bus.OutboundMessage
+	editedChoices  []string
+}
+
+func (m *mockChoiceSender) SendChoices(ctx context.Context, msg bus.OutboundMessage) error {
+	m.choiceMessages = append(m.choiceMessages, msg)
+	return nil
+}
+
+func (m *mockChoiceSender) EditMessageWithChoices(
+	ctx context.Context,
+	chatID, messageID, content string,
+	choices []string,
+) error {
+	m.editedChoices = choices
+	return nil
+}
+
+func TestSendMessage_ChoicesFallBackToNumberedText(t *testing.T) {
+	m := newTestManager()
+
+	var received []bus.OutboundMessage
+	ch := &mockChannel{
+		sendFn: func(_ context.Context, msg bus.OutboundMessage) error {
+			received = append(received, msg)
+			return nil
+		},
+	}
+	m.channels["test"] = ch
+	m.workers["test"] = &channelWorker{ch: ch, limiter: rate.NewLimiter(rate.Inf, 1)}
+
+	err := m.SendMessage(context.Background(), bus.OutboundMessage{
+		Channel: "test",
+		ChatID:  "123",
+		Content: "Deploy now?",
+		Choices: []string{"Yes", "No"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 message sent, got %d", len(received))
+	}
+	want := "Deploy now?\n\n1. Yes\n2. No\n\nReply with the number or text of your choice."
+	if received[0].Content != want {
+		t.Errorf("content = %q, want %q", received[0].Content, want)
+	}
+	if len(received[0].Choices) != 0 {
+		t.Errorf("expected choices to be consumed by the text fallback, got %v", received[0].Choices)
+	}
+}
+
+func TestSendMessage_ChoicesUseChoiceSender(t *testing.T) {
+	m := newTestManager()
+
+	ch := &mockChoiceSender{}
+	ch.sendFn = func(_ context.Context, _ bus.OutboundMessage) error { return nil }
+	m.channels["test"] = ch
+	m.workers["test"] = &channelWorker{ch: ch, limiter: rate.NewLimiter(rate.Inf, 1)}
+
+	err := m.SendMessage(context.Background(), bus.OutboundMessage{
+		Channel: "test",
+		ChatID:  "123",
+		Content: "Deploy now?",
+		Choices: []string{"Yes", "No"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(ch.sentMessages) != 0 {
+		t.Errorf("expected plain Send not to be used, got %d messages", len(ch.sentMessages))
+	}
+	if len(ch.choiceMessages) != 1 || ch.choiceMessages[0].Content != "Deploy now?" {
+		t.Fatalf("expected one SendChoices call with original content, got %+v", ch.choiceMessages)
+	}
+}
+
+func TestPreSend_ChoicesEditPlaceholderWithButtons(t *testing.T) {
+	m := newTestManager()
+	ch := &mockChoiceSender{}
+	m.RecordPlaceholder("test", "123", "ph-1")
+
+	msg := bus.OutboundMessage{Channel: "test", ChatID: "123", Content: "Pick one", Choices: []string{"A", "B"}}
+	if !m.preSend(context.Background(), "test", msg, ch) {
+		t.Fatal("expected placeholder to be edited")
+	}
+	if len(ch.editedChoices) != 2 {
+		t.Errorf("expected choices to be passed to the edit, got %v", ch.editedChoices)
+	}
+	if ch.editedMessages != 0 {
+		t.Errorf("expected plain EditMessage not to be used, got %d", ch.editedMessages)
+	}
+}
+
+func TestOutboundChunks_ChoicesOnLastChunkOnly(t *testing.T) {
+	ch := &mockChoiceSenderWithLength{maxLen: 10}
+	msg := bus.OutboundMessage{Content: strings.Repeat("word ", 10), Choices: []string{"A"}}
+
+	chunks := outboundChunks(ch, msg)
+	if len(chunks) < 2 {
+		t.Fatalf("expected content to be split, got %d chunks", len(chunks))
+	}
+	for i, chunk := range chunks[:len(chunks)-1] {
+		if len(chunk.Choices) != 0 {
+			t.Errorf("chunk %d should not carry choices", i)
+		}
+	}
+	if last := chunks[len(chunks)-1]; len(last.Choices) != 1 {
+		t.Errorf("expected last chunk to carry choices, got %v", last.Choices)
+	}
+}
+
+func TestOutboundChunks_FooterOnAgentReplies(t *testing.T) {
+	ch := &mockChannel{BaseChannel: *NewBaseChannel("test", nil, nil, nil,
+		WithFooter("AI-generated, may be inaccurate"), WithMaxMessageLength(40))}
+
+	chunks := outboundChunks(ch, bus.OutboundMessage{Content: "Hello\n", AgentReply: true})
+	if len(chunks) != 1 || chunks[0].Content != "Hello\n\nAI-generated, may be inaccurate" {
+		t.Fatalf("chunks = %+v, want footer appended", chunks)
+	}
+
+	status := outboundChunks(ch, bus.OutboundMessage{Content: "Compressing history..."})
+	if status[0].Content != "Compressing history..." {
+		t.Errorf("status message got a footer: %q", status[0].Content)
+	}
+
+	// The footer counts toward the length limit and ends the last chunk.
+	chunks = outboundChunks(ch, bus.OutboundMessage{Content: strings.Repeat("word ", 5), AgentReply: true})
+	if len(chunks) < 2 {
+		t.Fatalf("expected the footer to force a split, got %+v", chunks)
+	}
+	for _, chunk := range chunks {
+		if n := len([]rune(chunk.Content)); n > 40 {
+			t.Errorf("chunk of %d runes exceeds the limit: %q", n, chunk.Content)
+		}
+	}
+	if last := chunks[len(chunks)-1].Content; !strings.HasSuffix(last, "may be inaccurate") {
+		t.Errorf("last chunk = %q, want it to end with the footer", last)
+	}
+}
+
+type mockChoiceSenderWithLength struct {
+	mockChoiceSender
+	maxLen int
+}
+
+func (m *mockChoiceSenderWithLength) MaxMessageLength() int {
+	return m.maxLen
+}
+
+// mockRecaller is a channel that supports MessageRecaller.
+type mockRecaller struct {
+	mockChannel
+	recalled []string
+}
+
+func (m *mockRecaller) RecallMessage(_ context.Context, chatID, messageID string) error {
+	m.recalled = append(m.recalled, chatID+"/"+messageID)
+	return nil
+}
+
+func TestRecallMessage(t *testing.T) {
+	m := newTestManager()
+	recaller := &mockRecaller{}
+	m.channels["onebot"] = recaller
+	m.channels["plain"] = &mockChannel{}
+
+	if err := m.RecallMessage(context.Background(), "onebot", "group:1", "42"); err != nil {
+		t.Fatalf("RecallMessage() error = %v", err)
+	}
+	if len(recaller.recalled) != 1 || recaller.recalled[0] != "group:1/42" {
+		t.Errorf("recalled = %v, want [group:1/42]", recaller.recalled)
+	}
+
+	if err := m.RecallMessage(context.Background(), "plain", "group:1", "42"); err == nil {
+		t.Error("expected an error for a channel without recall support")
+	}
+	if err := m.RecallMessage(context.Background(), "missing", "group:1", "42"); err == nil {
+		t.Error("expected an error for an unknown channel")
+	}
+}
+
+// mockPresenceSetter is a channel that supports PresenceSetter.
+type mockPresenceSetter struct {
+	mockChannel
+	status string
+}
+
+func (m *mockPresenceSetter) SetPresence(_ context.Context, status string) error {
+	m.status = status
+	return nil
+}
+
+func TestSetPresence(t *testing.T) {
+	m := newTestManager()
+	setter := &mockPresenceSetter{}
+	m.channels["discord"] = setter
+	m.channels["plain"] = &mockChannel{}
+
+	if err := m.SetPresence(context.Background(), "discord", "Answering questions"); err != nil {
+		t.Fatalf("SetPresence() error = %v", err)
+	}
+	if setter.status != "Answering questions" {
+		t.Errorf("status = %q", setter.status)
+	}
+	if err := m.SetPresence(context.Background(), "plain", "x"); err == nil {
+		t.Error("expected an error for a channel without presence support")
+	}
+	if err := m.SetPresence(context.Background(), "missing", "x"); err == nil {
+		t.Error("expected an error for an unknown channel")
+	}
+}
+
+type mockMediaChannel struct {
+	mockChannel
+	sentMedia []bus.OutboundMediaMessage
+}
+
+func (m *mockMediaChannel) SendMedia(ctx context.Context, msg bus.OutboundMediaMessage) error {
+	m.sentMedia = append(m.sentMedia, msg)
+	return nil
+}
+
+func TestSendMediaWithRetry_DedupsPartsByRef(t *testing.T) {
+	m := newTestManager()
+	ch := &mockMediaChannel{}
+	w := &channelWorker{
+		ch:      ch,
+		limiter: rate.NewLimiter(rate.Inf, 1),
+	}
+
+	m.sendMediaWithRetry(context.Background(), "test", w, bus.OutboundMediaMessage{
+		Channel: "test",
+		ChatID:  "1",
+		Parts: []bus.MediaPart{
+			{Type: "image", Ref: "media://a", Caption: "first"},
+			{Type: "image", Ref: "media://b"},
+			{Type: "image", Ref: "media://a", Caption: "second"},
+			{Type: "file", Caption: "no ref"},
+			{Type: "file", Caption: "no ref"},
+			{Type: "image", Ref: "media://a"},
+		},
+	})
+
+	if len(ch.sentMedia) != 1 {
+		t.Fatalf("expected 1 SendMedia call, got %d", len(ch.sentMedia))
+	}
+	want := []bus.MediaPart{
+		{Type: "image", Ref: "media://a", Caption: "first"},
+		{Type: "image", Ref: "media://b"},
+		{Type: "file", Caption: "no ref"},
+		{Type: "file", Caption: "no ref"},
+	}
+	if got := ch.sentMedia[0].Parts; !reflect.DeepEqual(got, want) {
+		t.Errorf("parts = %+v, want %+v", got, want)
+	}
+}
@@ -218,6 +218,10 @@ func NewMatrixChannel(cfg config.MatrixConfig, messageBus *bus.MessageBus) (*Mat
 		channels.WithMaxMessageLength(65536),
 		channels.WithGroupTrigger(cfg.GroupTrigger),
 		channels.WithReasoningChannelID(cfg.ReasoningChannelID),
+		channels.WithGreeting(cfg.Greeting),
+		channels.WithFooter(cfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(cfg.CoalesceWindowMS)*time.Millisecond),
+		channels.WithMediaTypes(cfg.MediaTypesAllow, cfg.MediaTypesDeny, cfg.MediaRejectedNote),
 	)
 
 	return &MatrixChannel{
@@ -561,12 +565,29 @@ func (c *MatrixChannel) handleMessageEvent(ctx context.Context, evt *event.Event
 	roomID := evt.RoomID.String()
 	scope := channels.BuildMediaScope("matrix", roomID, evt.ID.String())
 
-	content, mediaPaths, ok := c.extractInboundContent(ctx, msgEvt, scope)
-	if !ok {
-		return
+	// Media of a type the channel refuses is dropped before download; only
+	// its caption is kept.
+	var rejected string
+	switch msgEvt.MsgType {
+	case event.MsgImage, event.MsgAudio, event.MsgVideo, event.MsgFile:
+		if kind := matrixMediaKind(msgEvt.MsgType); !c.AllowsMediaType(kind) {
+			rejected = kind
+		}
+	}
+
+	var content string
+	var mediaPaths []string
+	if rejected != "" {
+		content = msgEvt.GetCaption()
+	} else {
+		var ok bool
+		content, mediaPaths, ok = c.extractInboundContent(ctx, msgEvt, scope)
+		if !ok {
+			return
+		}
 	}
 	content = strings.TrimSpace(content)
-	if content == "" && len(mediaPaths) == 0 {
+	if content == "" && len(mediaPaths) == 0 && rejected == "" {
 		return
 	}
 
@@ -592,7 +613,7 @@ func (c *MatrixChannel) handleMessageEvent(ctx context.Context, evt *event.Event
 		if isMentioned {
 			content = c.stripSelfMention(content)
 		}
-		respond, cleaned := c.ShouldRespondInGroup(isMentioned, content)
+		respond, cleaned := c.ShouldRespondInGroup(roomID, isMentioned, content)
 		if !respond {
 			logger.DebugCF("matrix", "Ignoring group message by trigger rules", map[string]any{
 				"room_id":      roomID,
@@ -608,7 +629,7 @@ func (c *MatrixChannel) handleMessageEvent(ctx context.Context, evt *event.Event
 	}
 
 	content = strings.TrimSpace(content)
-	if content == "" {
+	if content == "" && rejected == "" {
 		return
 	}
 
@@ -626,7 +647,10 @@ func (c *MatrixChannel) handleMessageEvent(ctx context.Context, evt *event.Event
 		"sender_raw": senderID,
 	}
 	if replyTo := msgEvt.GetRelatesTo().GetReplyTo(); replyTo != "" {
-		metadata["reply_to_msg_id"] = replyTo.String()
+		metadata[bus.MetadataReplyToMessageID] = replyTo.String()
+	}
+	if rejected != "" {
+		metadata[channels.MetadataMediaRejected] = rejected
 	}
 
 	c.HandleMessage(
@@ -0,0 +1,170 @@
+package onebot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	// maxForwardDepth bounds how deeply nested forwarded messages are expanded.
+	maxForwardDepth = 3
+	// maxForwardTextLen bounds the text taken from one forwarded message, so a
+	// long chat log does not flood the agent's context.
+	maxForwardTextLen = 8000
+	// forwardFetchTimeout bounds one get_forward_msg call.
+	forwardFetchTimeout = 10 * time.Second
+)
+
+// forwardNode is one message inside a forwarded (merged) message. go-cqhttp
+// puts the segments in content, NapCat and LLOneBot in message.
+type forwardNode struct {
+	Sender struct {
+		Nickname string `json:"nickname"`
+		Card     string `json:"card"`
+	} `json:"sender"`
+	Message json.RawMessage `json:"message"`
+	Content json.RawMessage `json:"content"`
+}
+
+// isForwardSegment reports whether a raw message contains a forward segment.
+// Expanding one calls get_forward_msg, whose response is read by the listen
+// loop, so such messages must be handled off that loop.
+func isForwardSegment(raw json.RawMessage) bool {
+	var segments []struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(raw, &segments) != nil {
+		return false
+	}
+	for _, seg := range segments {
+		if seg.Type == "forward" {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardText renders a forward segment as text, one line per message
+// prefixed with the sender's nickname. depth is the nesting level of this
+// forward, starting at 1. It falls back to a placeholder when the content
+// cannot be fetched or the nesting is too deep.
+func (c *OneBotChannel) forwardText(data map[string]any, depth int) string {
+	const placeholder = "[forward message]"
+	if depth > maxForwardDepth {
+		return placeholder
+	}
+
+	var nodes []forwardNode
+	if inline, ok := data["content"]; ok {
+		// Some implementations embed the nodes in the segment itself.
+		b, _ := json.Marshal(inline)
+		if json.Unmarshal(b, &nodes) != nil {
+			nodes = nil
+		}
+	}
+	if nodes == nil {
+		id := fmt.Sprintf("%v", data["id"])
+		if data["id"] == nil || id == "" {
+			return placeholder
+		}
+		fetched, err := c.fetchForward(id)
+		if err != nil {
+			logger.WarnCF("onebot", "Failed to fetch forwarded message", map[string]any{
+				"id":    id,
+				"error": err.Error(),
+			})
+			return placeholder
+		}
+		nodes = fetched
+	}
+
+	var b strings.Builder
+	b.WriteString("[forwarded messages]\n")
+	for _, node := range nodes {
+		segments := node.Message
+		if len(segments) == 0 {
+			segments = node.Content
+		}
+		text := strings.TrimSpace(c.forwardNodeText(segments, depth))
+		if text == "" {
+			continue
+		}
+		name := node.Sender.Card
+		if name == "" {
+			name = node.Sender.Nickname
+		}
+		if name == "" {
+			name = "unknown"
+		}
+		// Indent continuation lines so nested forwards stay readable.
+		fmt.Fprintf(&b, "%s: %s\n", name, strings.ReplaceAll(text, "\n", "\n  "))
+	}
+	b.WriteString("[end of forwarded messages]")
+	return truncate(b.String(), maxForwardTextLen)
+}
+
+// forwardNodeText renders the segments of one forwarded message as text.
+// Media is shown as a placeholder and not downloaded.
+func (c *OneBotChannel) forwardNodeText(raw json.RawMessage, depth int) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var segments []map[string]any
+	if json.Unmarshal(raw, &segments) != nil {
+		return ""
+	}
+
+	var parts []string
+	for _, seg := range segments {
+		segType, _ := seg["type"].(string)
+		data, _ := seg["data"].(map[string]any)
+		switch segType {
+		case "text":
+			if t, ok := data["text"].(string); ok {
+				parts = append(parts, t)
+			}
+		case "at":
+			parts = append(parts, fmt.Sprintf("@%v", data["qq"]))
+		case "image", "video", "file":
+			parts = append(parts, "["+segType+"]")
+		case "record":
+			parts = append(parts, "[voice]")
+		case "face":
+			parts = append(parts, fmt.Sprintf("[face:%v]", data["id"]))
+		case "forward":
+			parts = append(parts, "\n"+c.forwardText(data, depth+1)+"\n")
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// fetchForward loads the messages of a forwarded message with get_forward_msg.
+func (c *OneBotChannel) fetchForward(id string) ([]forwardNode, error) {
+	// OneBot v11 names the parameter id; go-cqhttp expects message_id.
+	resp, err := c.sendAPIRequest("get_forward_msg", map[string]any{
+		"id":         id,
+		"message_id": id,
+	}, forwardFetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		RetCode json.RawMessage `json:"retcode"`
+		Data    struct {
+			Messages []forwardNode `json:"messages"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("invalid get_forward_msg response: %w", err)
+	}
+	if code, _ := parseJSONInt64(result.RetCode); code != 0 {
+		return nil, fmt.Errorf("get_forward_msg failed with retcode %d", code)
+	}
+	return result.Data.Messages, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -37,8 +39,71 @@ type OneBotChannel struct {
 	pending       map[string]chan json.RawMessage
 	pendingMu     sync.Mutex
 	lastMessageID sync.Map
+	outQueue      []queuedOutbound
+	outDraining   bool // a goroutine is writing outQueue; guarded by outMu
+	outMu         sync.Mutex
+
+	// Messages arriving in a chat while a forward there is being expanded
+	// wait in inbound, oldest first, so the chat keeps its order. A chat is
+	// present while its worker goroutine runs.
+	inbound   map[string][]*oneBotRawEvent
+	inboundMu sync.Mutex
+
+	// Messages sent by the bot, so RecallMessage only retracts its own.
+	// sentEchoes maps the echo of a send request to its chat until the
+	// response arrives; sentMessages holds recent message IDs per chat,
+	// oldest first.
+	sentEchoes   map[string]sentEcho
+	sentMessages map[string][]string
+	sentMu       sync.Mutex
+
+	// Connection health, reported via IsRunning and the health endpoint.
+	lastActivity      atomic.Int64 // unix nanos of the last frame or pong received
+	lastConnected     atomic.Int64 // unix nanos of the last successful connect
+	reconnectAttempts atomic.Int64
+	reconnect         reconnectBackoff
+
+	// dial and after replace connect and time.After in reconnectLoop; nil
+	// uses the real ones. Tests set them to drive the loop.
+	dial  func() error
+	after func(time.Duration) <-chan time.Time
+
+	pingInterval    time.Duration
+	readTimeout     time.Duration
+	reactionEmojiID int
 }
 
+// sentEcho identifies the chat a send request went to.
+type sentEcho struct {
+	chatID string
+	sentAt time.Time
+}
+
+// queuedOutbound is an outbound frame buffered while the WebSocket is down.
+type queuedOutbound struct {
+	chatID   string
+	data     []byte
+	queuedAt time.Time
+}
+
+const (
+	// defaultReadTimeout is the read deadline extended on every frame and
+	// pong. A connection with no activity for this long is considered dead.
+	defaultReadTimeout = 60 * time.Second
+	// defaultPingInterval is how often the pinger sends a WebSocket ping.
+	defaultPingInterval = 30 * time.Second
+	// defaultReactionEmojiID is the QQ emoji added to messages being handled.
+	defaultReactionEmojiID = 289
+
+	// outboundQueueSize bounds the number of frames buffered across a reconnect.
+	outboundQueueSize = 128
+	// outboundQueueTTL drops buffered frames that are too stale to be useful.
+	outboundQueueTTL = 5 * time.Minute
+
+	// sentMessagesPerChat bounds the bot message IDs remembered per chat.
+	sentMessagesPerChat = 50
+)
+
 type oneBotRawEvent struct {
 	PostType      string          `json:"post_type"`
 	MessageType   string          `json:"message_type"`
@@ -100,16 +165,46 @@ func NewOneBotChannel(cfg config.OneBotConfig, messageBus *bus.MessageBus) (*One
 	base := channels.NewBaseChannel("onebot", cfg, messageBus, cfg.AllowFrom,
 		channels.WithGroupTrigger(cfg.GroupTrigger),
 		channels.WithReasoningChannelID(cfg.ReasoningChannelID),
+		channels.WithGreeting(cfg.Greeting),
+		channels.WithFooter(cfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(cfg.CoalesceWindowMS)*time.Millisecond),
+		channels.WithMediaTypes(cfg.MediaTypesAllow, cfg.MediaTypesDeny, cfg.MediaRejectedNote),
 	)
 
+	pingInterval := time.Duration(cfg.PingInterval) * time.Second
+	if pingInterval <= 0 {
+		pingInterval = defaultPingInterval
+	}
+	readTimeout := time.Duration(cfg.ReadTimeout) * time.Second
+	if readTimeout <= 0 {
+		readTimeout = defaultReadTimeout
+	}
+	if readTimeout <= pingInterval {
+		return nil, fmt.Errorf(
+			"onebot read_timeout (%v) must be greater than ping_interval (%v)",
+			readTimeout, pingInterval,
+		)
+	}
+
+	reactionEmojiID := cfg.ReactionEmojiID
+	if reactionEmojiID <= 0 {
+		reactionEmojiID = defaultReactionEmojiID
+	}
+
 	const dedupSize = 1024
 	return &OneBotChannel{
-		BaseChannel: base,
-		config:      cfg,
-		dedup:       make(map[string]struct{}, dedupSize),
-		dedupRing:   make([]string, dedupSize),
-		dedupIdx:    0,
-		pending:     make(map[string]chan json.RawMessage),
+		BaseChannel:     base,
+		config:          cfg,
+		dedup:           make(map[string]struct{}, dedupSize),
+		dedupRing:       make([]string, dedupSize),
+		dedupIdx:        0,
+		pending:         make(map[string]chan json.RawMessage),
+		sentEchoes:      make(map[string]sentEcho),
+		sentMessages:    make(map[string][]string),
+		pingInterval:    pingInterval,
+		readTimeout:     readTimeout,
+		reactionEmojiID: reactionEmojiID,
+		reconnect:       newReconnectBackoff(time.Duration(cfg.ReconnectInterval) * time.Second),
 	}, nil
 }
 
@@ -130,7 +225,8 @@ func (c *OneBotChannel) setMsgEmojiLike(messageID string, emojiID int, set bool)
 }
 
 // ReactToMessage implements channels.ReactionCapable.
-// It adds an emoji reaction (ID 289) to group messages and returns an undo function.
+// It adds the configured emoji reaction (reaction_emoji_id, default 289) to group
+// messages and returns an undo function.
 // Private messages return a no-op since reactions are only meaningful in groups.
 func (c *OneBotChannel) ReactToMessage(ctx context.Context, chatID, messageID string) (func(), error) {
 	// Only react in group chats
@@ -138,13 +234,126 @@ func (c *OneBotChannel) ReactToMessage(ctx context.Context, chatID, messageID st
 		return func() {}, nil
 	}
 
-	c.setMsgEmojiLike(messageID, 289, true)
+	emojiID := c.reactionEmojiID
+	c.setMsgEmojiLike(messageID, emojiID, true)
 
 	return func() {
-		c.setMsgEmojiLike(messageID, 289, false)
+		c.setMsgEmojiLike(messageID, emojiID, false)
 	}, nil
 }
 
+// RecallMessage implements channels.MessageRecaller. It retracts a message
+// the bot sent to chatID using delete_msg. An empty messageID recalls the
+// bot's most recent message in the chat. Messages not sent by the bot are
+// refused.
+func (c *OneBotChannel) RecallMessage(ctx context.Context, chatID, messageID string) error {
+	if !c.BaseChannel.IsRunning() {
+		return channels.ErrNotRunning
+	}
+
+	c.sentMu.Lock()
+	ids := c.sentMessages[chatID]
+	if messageID == "" && len(ids) > 0 {
+		messageID = ids[len(ids)-1]
+	}
+	tracked := messageID != "" && slices.Contains(ids, messageID)
+	c.sentMu.Unlock()
+
+	if !tracked {
+		if messageID == "" {
+			return fmt.Errorf("no bot messages to recall in %s: %w", chatID, channels.ErrSendFailed)
+		}
+		return fmt.Errorf("message %s in %s was not sent by the bot: %w", messageID, chatID, channels.ErrSendFailed)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	var id any = messageID
+	if n, err := strconv.ParseInt(messageID, 10, 64); err == nil {
+		id = n
+	}
+	resp, err := c.sendAPIRequest("delete_msg", map[string]any{"message_id": id}, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("onebot recall: %w", err)
+	}
+
+	var result struct {
+		RetCode json.RawMessage `json:"retcode"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("onebot recall: invalid response: %w", err)
+	}
+	if code, _ := parseJSONInt64(result.RetCode); code != 0 {
+		return fmt.Errorf("onebot recall of message %s failed (retcode %d %s): %w",
+			messageID, code, result.Message, channels.ErrSendFailed)
+	}
+
+	c.forgetSent(chatID, messageID)
+	return nil
+}
+
+// trackSend remembers that the request with this echo sends a message to
+// chatID, so recordSent can pick up the message ID from the response.
+// Echoes whose response never arrived are dropped after outboundQueueTTL.
+func (c *OneBotChannel) trackSend(echo, chatID string) {
+	now := time.Now()
+	c.sentMu.Lock()
+	defer c.sentMu.Unlock()
+	for e, s := range c.sentEchoes {
+		if now.Sub(s.sentAt) > outboundQueueTTL {
+			delete(c.sentEchoes, e)
+		}
+	}
+	c.sentEchoes[echo] = sentEcho{chatID: chatID, sentAt: now}
+}
+
+// recordSent stores the message ID returned in the response to a tracked
+// send request.
+func (c *OneBotChannel) recordSent(raw *oneBotRawEvent) {
+	c.sentMu.Lock()
+	defer c.sentMu.Unlock()
+
+	sent, ok := c.sentEchoes[raw.Echo]
+	if !ok {
+		return
+	}
+	delete(c.sentEchoes, raw.Echo)
+
+	if code, _ := parseJSONInt64(raw.RetCode); code != 0 {
+		return
+	}
+	var data struct {
+		MessageID json.RawMessage `json:"message_id"`
+	}
+	if json.Unmarshal(raw.Data, &data) != nil || len(data.MessageID) == 0 {
+		return
+	}
+
+	ids := append(c.sentMessages[sent.chatID], parseJSONString(data.MessageID))
+	if len(ids) > sentMessagesPerChat {
+		ids = ids[len(ids)-sentMessagesPerChat:]
+	}
+	c.sentMessages[sent.chatID] = ids
+}
+
+// forgetSent drops messageID from the bot messages tracked for chatID.
+func (c *OneBotChannel) forgetSent(chatID, messageID string) {
+	c.sentMu.Lock()
+	defer c.sentMu.Unlock()
+
+	ids := slices.DeleteFunc(c.sentMessages[chatID], func(id string) bool { return id == messageID })
+	if len(ids) == 0 {
+		delete(c.sentMessages, chatID)
+		return
+	}
+	c.sentMessages[chatID] = ids
+}
+
 func (c *OneBotChannel) Start(ctx context.Context) error {
 	if c.config.WSUrl == "" {
 		return fmt.Errorf("OneBot ws_url not configured")
@@ -197,15 +406,20 @@ func (c *OneBotChannel) connect() error {
 	}
 
 	conn.SetPongHandler(func(appData string) error {
-		_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		c.markActivity()
+		_ = conn.SetReadDeadline(time.Now().Add(c.readTimeout))
 		return nil
 	})
-	_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	_ = conn.SetReadDeadline(time.Now().Add(c.readTimeout))
 
 	c.mu.Lock()
 	c.conn = conn
 	c.mu.Unlock()
 
+	now := time.Now().UnixNano()
+	c.lastConnected.Store(now)
+	c.lastActivity.Store(now)
+
 	go c.pinger(conn)
 
 	logger.InfoC("onebot", "WebSocket connected")
@@ -213,7 +427,7 @@ func (c *OneBotChannel) connect() error {
 }
 
 func (c *OneBotChannel) pinger(conn *websocket.Conn) {
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(c.pingInterval)
 	defer ticker.Stop()
 
 	for {
@@ -336,33 +550,6 @@ func (c *OneBotChannel) sendAPIRequest(action string, params any, timeout time.D
 	}
 }
 
-func (c *OneBotChannel) reconnectLoop() {
-	interval := max(time.Duration(c.config.ReconnectInterval)*time.Second, 5*time.Second)
-
-	for {
-		select {
-		case <-c.ctx.Done():
-			return
-		case <-time.After(interval):
-			c.mu.Lock()
-			conn := c.conn
-			c.mu.Unlock()
-
-			if conn == nil {
-				logger.InfoC("onebot", "Attempting to reconnect...")
-				if err := c.connect(); err != nil {
-					logger.ErrorCF("onebot", "Reconnect failed", map[string]any{
-						"error": err.Error(),
-					})
-				} else {
-					go c.listen()
-					c.fetchSelfID()
-				}
-			}
-		}
-	}
-}
-
 func (c *OneBotChannel) Stop(ctx context.Context) error {
 	logger.InfoC("onebot", "Stopping OneBot channel")
 	c.SetRunning(false)
@@ -388,11 +575,82 @@ func (c *OneBotChannel) Stop(ctx context.Context) error {
 	}
 	c.mu.Unlock()
 
+	c.outMu.Lock()
+	if len(c.outQueue) > 0 {
+		logger.WarnCF("onebot", "Dropping queued outbound messages on stop", map[string]any{
+			"count": len(c.outQueue),
+		})
+	}
+	c.outQueue = nil
+	c.outMu.Unlock()
+
 	return nil
 }
 
+// IsRunning reports whether the channel is started and the WebSocket is
+// connected and has seen traffic within the read timeout. Unlike the base
+// implementation it returns false while reconnectLoop is retrying.
+func (c *OneBotChannel) IsRunning() bool {
+	return c.BaseChannel.IsRunning() && c.isConnected()
+}
+
+func (c *OneBotChannel) isConnected() bool {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return false
+	}
+	return time.Since(time.Unix(0, c.lastActivity.Load())) < c.readTimeout
+}
+
+func (c *OneBotChannel) markActivity() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+// HealthPath returns the health check endpoint path.
+func (c *OneBotChannel) HealthPath() string {
+	return "/health/onebot"
+}
+
+// HealthHandler reports the actual WebSocket connection state, including the
+// last successful connect and the number of reconnect attempts.
+func (c *OneBotChannel) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	connected := c.isConnected()
+	status := map[string]any{
+		"status":             "ok",
+		"running":            c.BaseChannel.IsRunning(),
+		"connected":          connected,
+		"reconnect_attempts": c.reconnectAttempts.Load(),
+	}
+	if !connected {
+		status["status"] = "disconnected"
+	}
+	if ts := c.lastConnected.Load(); ts > 0 {
+		status["last_connected"] = time.Unix(0, ts).Format(time.RFC3339)
+	}
+	if ts := c.lastActivity.Load(); ts > 0 {
+		status["last_activity"] = time.Unix(0, ts).Format(time.RFC3339)
+	}
+	c.outMu.Lock()
+	status["queued_outbound"] = len(c.outQueue)
+	c.outMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !connected {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logger.DebugCF("onebot", "Failed to write health status", map[string]any{
+			"error": err.Error(),
+		})
+	}
+}
+
 func (c *OneBotChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
-	if !c.IsRunning() {
+	// Check the lifecycle flag rather than IsRunning: while disconnected,
+	// messages are queued for delivery after reconnect.
+	if !c.BaseChannel.IsRunning() {
 		return channels.ErrNotRunning
 	}
 
@@ -403,14 +661,6 @@ func (c *OneBotChannel) Send(ctx context.Context, msg bus.OutboundMessage) error
 	default:
 	}
 
-	c.mu.Lock()
-	conn := c.conn
-	c.mu.Unlock()
-
-	if conn == nil {
-		return fmt.Errorf("OneBot WebSocket not connected")
-	}
-
 	action, params, err := c.buildSendRequest(msg)
 	if err != nil {
 		return err
@@ -429,17 +679,9 @@ func (c *OneBotChannel) Send(ctx context.Context, msg bus.OutboundMessage) error
 		return fmt.Errorf("failed to marshal OneBot request: %w", err)
 	}
 
-	c.writeMu.Lock()
-	_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-	err = conn.WriteMessage(websocket.TextMessage, data)
-	_ = conn.SetWriteDeadline(time.Time{})
-	c.writeMu.Unlock()
-
-	if err != nil {
-		logger.ErrorCF("onebot", "Failed to send message", map[string]any{
-			"error": err.Error(),
-		})
-		return fmt.Errorf("onebot send: %w", channels.ErrTemporary)
+	c.trackSend(echo, msg.ChatID)
+	if err := c.writeOrQueue(msg.ChatID, data); err != nil {
+		return fmt.Errorf("onebot send: %w", err)
 	}
 
 	return nil
@@ -447,7 +689,7 @@ func (c *OneBotChannel) Send(ctx context.Context, msg bus.OutboundMessage) error
 
 // SendMedia implements the channels.MediaSender interface.
 func (c *OneBotChannel) SendMedia(ctx context.Context, msg bus.OutboundMediaMessage) error {
-	if !c.IsRunning() {
+	if !c.BaseChannel.IsRunning() {
 		return channels.ErrNotRunning
 	}
 
@@ -457,14 +699,6 @@ func (c *OneBotChannel) SendMedia(ctx context.Context, msg bus.OutboundMediaMess
 	default:
 	}
 
-	c.mu.Lock()
-	conn := c.conn
-	c.mu.Unlock()
-
-	if conn == nil {
-		return fmt.Errorf("OneBot WebSocket not connected")
-	}
-
 	store := c.GetMediaStore()
 	if store == nil {
 		return fmt.Errorf("no media store available: %w", channels.ErrSendFailed)
@@ -540,22 +774,146 @@ func (c *OneBotChannel) SendMedia(ctx context.Context, msg bus.OutboundMediaMess
 		return fmt.Errorf("failed to marshal OneBot request: %w", err)
 	}
 
+	c.trackSend(echo, chatID)
+	if err := c.writeOrQueue(chatID, data); err != nil {
+		return fmt.Errorf("onebot send media: %w", err)
+	}
+
+	return nil
+}
+
+// writeFrame writes a single text frame to conn under the write lock.
+func (c *OneBotChannel) writeFrame(conn *websocket.Conn, data []byte) error {
 	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 	_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-	err = conn.WriteMessage(websocket.TextMessage, data)
+	err := conn.WriteMessage(websocket.TextMessage, data)
 	_ = conn.SetWriteDeadline(time.Time{})
-	c.writeMu.Unlock()
+	return err
+}
 
-	if err != nil {
-		logger.ErrorCF("onebot", "Failed to send media message", map[string]any{
-			"error": err.Error(),
+// writeOrQueue appends an outbound frame to the queue and, when the WebSocket
+// is connected, drains it. Frames are written in order without holding outMu,
+// so a slow write does not block other senders. When disconnected or the
+// write fails, the frame stays queued and flushOutbound delivers it after
+// reconnect. Returning nil for a queued frame keeps the manager from retrying
+// it, so a message is never delivered twice. When the queue is full,
+// ErrTemporary is returned and the manager's backoff applies.
+func (c *OneBotChannel) writeOrQueue(chatID string, data []byte) error {
+	c.outMu.Lock()
+	c.pruneOutboundLocked(time.Now())
+	if len(c.outQueue) >= outboundQueueSize {
+		size := len(c.outQueue)
+		c.outMu.Unlock()
+		logger.ErrorCF("onebot", "Outbound queue full", map[string]any{
+			"chat_id": chatID,
+			"size":    size,
+		})
+		return channels.ErrTemporary
+	}
+	c.outQueue = append(c.outQueue, queuedOutbound{
+		chatID:   chatID,
+		data:     data,
+		queuedAt: time.Now(),
+	})
+	queued := len(c.outQueue)
+	c.outMu.Unlock()
+
+	c.mu.Lock()
+	connected := c.conn != nil
+	c.mu.Unlock()
+	if !connected {
+		logger.InfoCF("onebot", "OneBot disconnected; message queued until reconnect", map[string]any{
+			"chat_id": chatID,
+			"queued":  queued,
 		})
-		return fmt.Errorf("onebot send media: %w", channels.ErrTemporary)
+		return nil
 	}
 
+	c.drainOutbound()
 	return nil
 }
 
+// drainOutbound writes queued frames in order until the queue is empty, the
+// WebSocket is gone, or a write fails. Only one goroutine drains at a time;
+// others return immediately and leave their frames to it. It returns the
+// number of frames written.
+func (c *OneBotChannel) drainOutbound() int {
+	c.outMu.Lock()
+	if c.outDraining {
+		c.outMu.Unlock()
+		return 0
+	}
+	c.outDraining = true
+	c.outMu.Unlock()
+
+	sent := 0
+	for {
+		c.mu.Lock()
+		conn := c.conn
+		c.mu.Unlock()
+
+		c.outMu.Lock()
+		c.pruneOutboundLocked(time.Now())
+		if conn == nil || len(c.outQueue) == 0 {
+			c.outDraining = false
+			c.outMu.Unlock()
+			return sent
+		}
+		q := c.outQueue[0]
+		c.outQueue = c.outQueue[1:]
+		c.outMu.Unlock()
+
+		if err := c.writeFrame(conn, q.data); err != nil {
+			logger.WarnCF("onebot", "Failed to write message, queueing for reconnect", map[string]any{
+				"chat_id": q.chatID,
+				"error":   err.Error(),
+			})
+			c.outMu.Lock()
+			c.outQueue = append([]queuedOutbound{q}, c.outQueue...)
+			c.outDraining = false
+			c.outMu.Unlock()
+			return sent
+		}
+		sent++
+	}
+}
+
+// pruneOutboundLocked drops queued frames older than outboundQueueTTL.
+// Must be called with outMu held.
+func (c *OneBotChannel) pruneOutboundLocked(now time.Time) {
+	kept := c.outQueue[:0]
+	for _, q := range c.outQueue {
+		if now.Sub(q.queuedAt) > outboundQueueTTL {
+			logger.WarnCF("onebot", "Dropping expired queued message", map[string]any{
+				"chat_id": q.chatID,
+				"age":     now.Sub(q.queuedAt).String(),
+			})
+			continue
+		}
+		kept = append(kept, q)
+	}
+	c.outQueue = kept
+}
+
+// flushOutbound sends frames buffered during a disconnect in their original
+// order after reconnect. It stops at the first write failure and keeps the
+// remainder queued for the next reconnect.
+func (c *OneBotChannel) flushOutbound() {
+	sent := c.drainOutbound()
+
+	c.outMu.Lock()
+	remaining := len(c.outQueue)
+	c.outMu.Unlock()
+	if sent == 0 && remaining == 0 {
+		return
+	}
+	logger.InfoCF("onebot", "Flushed queued outbound messages", map[string]any{
+		"sent":      sent,
+		"remaining": remaining,
+	})
+}
+
 func (c *OneBotChannel) buildMessageSegments(chatID, content string) []oneBotMessageSegment {
 	var segments []oneBotMessageSegment
 
@@ -626,7 +984,8 @@ func (c *OneBotChannel) listen() {
 				return
 			}
 
-			_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+			c.markActivity()
+			_ = conn.SetReadDeadline(time.Now().Add(c.readTimeout))
 
 			var raw oneBotRawEvent
 			if err := json.Unmarshal(message, &raw); err != nil {
@@ -644,6 +1003,8 @@ func (c *OneBotChannel) listen() {
 			})
 
 			if raw.Echo != "" {
+				c.recordSent(&raw)
+
 				c.pendingMu.Lock()
 				ch, ok := c.pending[raw.Echo]
 				c.pendingMu.Unlock()
@@ -708,6 +1069,8 @@ type parseMessageResult struct {
 	IsBotMentioned bool
 	Media          []string
 	ReplyTo        string
+	Rejected       []string // media types dropped by the channel's media type filter
+	Forwarded      bool     // the message contains forwarded messages, expanded into Text
 }
 
 func (c *OneBotChannel) parseMessageSegments(
@@ -744,6 +1107,8 @@ func (c *OneBotChannel) parseMessageSegments(
 	selfIDStr := strconv.FormatInt(selfID, 10)
 	var mediaRefs []string
 	var replyTo string
+	var rejected []string
+	forwarded := false
 
 	// Helper to register a local file with the media store
 	storeFile := func(localPath, filename string) string {
@@ -780,6 +1145,10 @@ func (c *OneBotChannel) parseMessageSegments(
 			}
 
 		case "image", "video", "file":
+			if !c.AllowsMediaType(segType) {
+				rejected = append(rejected, segType)
+				continue
+			}
 			if data != nil {
 				url, _ := data["url"].(string)
 				if url != "" {
@@ -801,6 +1170,10 @@ func (c *OneBotChannel) parseMessageSegments(
 			}
 
 		case "record":
+			if !c.AllowsMediaType("audio") {
+				rejected = append(rejected, "audio")
+				continue
+			}
 			if data != nil {
 				url, _ := data["url"].(string)
 				if url != "" {
@@ -828,7 +1201,8 @@ func (c *OneBotChannel) parseMessageSegments(
 			}
 
 		case "forward":
-			textParts = append(textParts, "[forward message]")
+			forwarded = true
+			textParts = append(textParts, c.forwardText(data, 1))
 
 		default:
 		}
@@ -839,6 +1213,8 @@ func (c *OneBotChannel) parseMessageSegments(
 		IsBotMentioned: mentioned,
 		Media:          mediaRefs,
 		ReplyTo:        replyTo,
+		Rejected:       rejected,
+		Forwarded:      forwarded,
 	}
 }
 
@@ -859,7 +1235,7 @@ func (c *OneBotChannel) handleRawEvent(raw *oneBotRawEvent) {
 				return
 			}
 		}
-		c.handleMessage(raw)
+		c.dispatchMessage(raw)
 
 	case "message_sent":
 		logger.DebugCF("onebot", "Bot sent message event", map[string]any{
@@ -891,6 +1267,59 @@ func (c *OneBotChannel) handleRawEvent(raw *oneBotRawEvent) {
 	}
 }
 
+// dispatchMessage handles a message event in arrival order for its chat.
+// Expanding a forward waits for an API response, which the listen loop has
+// to read, so it runs on a worker goroutine; later messages in that chat
+// queue behind it instead of overtaking it.
+func (c *OneBotChannel) dispatchMessage(raw *oneBotRawEvent) {
+	chatID := rawChatID(raw)
+
+	c.inboundMu.Lock()
+	if queue, busy := c.inbound[chatID]; busy {
+		c.inbound[chatID] = append(queue, raw)
+		c.inboundMu.Unlock()
+		return
+	}
+	if !isForwardSegment(raw.Message) {
+		c.inboundMu.Unlock()
+		c.handleMessage(raw)
+		return
+	}
+	if c.inbound == nil {
+		c.inbound = make(map[string][]*oneBotRawEvent)
+	}
+	c.inbound[chatID] = nil
+	c.inboundMu.Unlock()
+
+	go c.drainInbound(chatID, raw)
+}
+
+// drainInbound handles raw and then every message queued for chatID, until
+// the queue is empty.
+func (c *OneBotChannel) drainInbound(chatID string, raw *oneBotRawEvent) {
+	for raw != nil {
+		c.handleMessage(raw)
+
+		c.inboundMu.Lock()
+		if queue := c.inbound[chatID]; len(queue) > 0 {
+			raw = queue[0]
+			c.inbound[chatID] = queue[1:]
+		} else {
+			delete(c.inbound, chatID)
+			raw = nil
+		}
+		c.inboundMu.Unlock()
+	}
+}
+
+// rawChatID returns the chat ID of a message event, as used by handleMessage.
+func rawChatID(raw *oneBotRawEvent) string {
+	if raw.MessageType == "group" {
+		return "group:" + parseJSONString(raw.GroupID)
+	}
+	return "private:" + parseJSONString(raw.UserID)
+}
+
 func (c *OneBotChannel) handleMetaEvent(raw *oneBotRawEvent) {
 	if raw.MetaEventType == "lifecycle" {
 		logger.InfoCF("onebot", "Lifecycle event", map[string]any{"sub_type": raw.SubType})
@@ -907,6 +1336,11 @@ func (c *OneBotChannel) handleNoticeEvent(raw *oneBotRawEvent) {
 		"user_id":     parseJSONString(raw.UserID),
 		"message_id":  parseJSONString(raw.MessageID),
 	}
+	if raw.NoticeType == "group_recall" {
+		// A bot message recalled by a group admin can no longer be recalled.
+		c.forgetSent("group:"+parseJSONString(raw.GroupID), parseJSONString(raw.MessageID))
+	}
+
 	switch raw.NoticeType {
 	case "group_recall", "group_increase", "group_decrease",
 		"friend_add", "group_admin", "group_ban":
@@ -960,7 +1394,12 @@ func (c *OneBotChannel) handleMessage(raw *oneBotRawEvent) {
 		}
 	}
 
-	if parsed.Text != "" && content != parsed.Text && (len(parsed.Media) > 0 || parsed.ReplyTo != "") {
+	if parsed.Text != "" && content != parsed.Text &&
+		(len(parsed.Media) > 0 || parsed.ReplyTo != "" || parsed.Forwarded) {
+		content = parsed.Text
+	}
+	// The raw CQ message still carries rejected media; keep only the text.
+	if len(parsed.Rejected) > 0 {
 		content = parsed.Text
 	}
 
@@ -981,7 +1420,7 @@ func (c *OneBotChannel) handleMessage(raw *oneBotRawEvent) {
 		return
 	}
 
-	if content == "" {
+	if content == "" && len(parsed.Rejected) == 0 {
 		logger.DebugCF("onebot", "Received empty message, ignoring", map[string]any{
 			"message_id": messageID,
 		})
@@ -996,7 +1435,10 @@ func (c *OneBotChannel) handleMessage(raw *oneBotRawEvent) {
 	metadata := map[string]string{}
 
 	if parsed.ReplyTo != "" {
-		metadata["reply_to_message_id"] = parsed.ReplyTo
+		metadata[bus.MetadataReplyToMessageID] = parsed.ReplyTo
+	}
+	if len(parsed.Rejected) > 0 {
+		metadata[channels.MetadataMediaRejected] = strings.Join(parsed.Rejected, ",")
 	}
 
 	switch raw.MessageType {
@@ -1021,7 +1463,7 @@ func (c *OneBotChannel) handleMessage(raw *oneBotRawEvent) {
 			metadata["sender_name"] = sender.Nickname
 		}
 
-		respond, strippedContent := c.ShouldRespondInGroup(isBotMentioned, content)
+		respond, strippedContent := c.ShouldRespondInGroup(chatID, isBotMentioned, content)
 		if !respond {
 			logger.DebugCF("onebot", "Group message ignored (no trigger)", map[string]any{
 				"sender":       senderID,
@@ -1080,9 +1522,8 @@ func (c *OneBotChannel) isDuplicate(messageID string) bool {
 	}
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	if _, exists := c.dedup[messageID]; exists {
+		c.mu.Unlock()
 		return true
 	}
 
@@ -1092,8 +1533,10 @@ func (c *OneBotChannel) isDuplicate(messageID string) bool {
 	c.dedupRing[c.dedupIdx] = messageID
 	c.dedup[messageID] = struct{}{}
 	c.dedupIdx = (c.dedupIdx + 1) % len(c.dedupRing)
+	c.mu.Unlock()
 
-	return false
+	// The persistent store catches redeliveries from before a restart.
+	return c.SeenBefore(messageID)
 }
 
 func truncate(s string, n int) string {
@@ -0,0 +1,538 @@
+package onebot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/channels"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// fakeOneBot is a minimal OneBot v11 WebSocket server. It answers
+// send_group_msg with sentMessageID, get_forward_msg with the nodes in
+// forwards, and forwards every delete_msg and set_msg_emoji_like request to
+// deletes and emojiLikes. When echoes is set, the echo of every request is
+// sent to it in the order received.
+type fakeOneBot struct {
+	sentMessageID int64
+	deletes       chan map[string]any
+	emojiLikes    chan map[string]any
+	forwards      map[string][]map[string]any
+	echoes        chan string
+}
+
+func (f *fakeOneBot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req struct {
+			Action string         `json:"action"`
+			Params map[string]any `json:"params"`
+			Echo   string         `json:"echo"`
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return
+		}
+		if f.echoes != nil {
+			f.echoes <- req.Echo
+		}
+
+		resp := map[string]any{"status": "ok", "retcode": 0, "echo": req.Echo}
+		switch req.Action {
+		case "get_login_info":
+			resp["data"] = map[string]any{"user_id": 10000, "nickname": "bot"}
+		case "send_group_msg", "send_private_msg":
+			resp["data"] = map[string]any{"message_id": f.sentMessageID}
+		case "get_forward_msg":
+			id, _ := req.Params["id"].(string)
+			resp["data"] = map[string]any{"messages": f.forwards[id]}
+		case "delete_msg":
+			f.deletes <- req.Params
+			resp["data"] = nil
+		case "set_msg_emoji_like":
+			f.emojiLikes <- req.Params
+			resp["data"] = nil
+		}
+		out, _ := json.Marshal(resp)
+		if err := conn.WriteMessage(websocket.TextMessage, out); err != nil {
+			return
+		}
+	}
+}
+
+func startTestChannel(t *testing.T, server *fakeOneBot) *OneBotChannel {
+	t.Helper()
+	return startTestChannelWithConfig(t, server, config.OneBotConfig{})
+}
+
+func startTestChannelWithConfig(t *testing.T, server *fakeOneBot, cfg config.OneBotConfig) *OneBotChannel {
+	t.Helper()
+
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	cfg.WSUrl = "ws" + strings.TrimPrefix(ts.URL, "http")
+	ch, err := NewOneBotChannel(cfg, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("NewOneBotChannel() error = %v", err)
+	}
+	if err := ch.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { ch.Stop(context.Background()) })
+	return ch
+}
+
+// waitForSent waits until the channel has recorded a bot message in chatID.
+func waitForSent(t *testing.T, ch *OneBotChannel, chatID string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		ch.sentMu.Lock()
+		n := len(ch.sentMessages[chatID])
+		ch.sentMu.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no sent message recorded for %s", chatID)
+}
+
+func TestRecallMessage_SendsDeleteMsg(t *testing.T) {
+	server := &fakeOneBot{sentMessageID: 4242, deletes: make(chan map[string]any, 1)}
+	ch := startTestChannel(t, server)
+	ctx := context.Background()
+
+	if err := ch.Send(ctx, bus.OutboundMessage{ChatID: "group:123", Content: "hello"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	waitForSent(t, ch, "group:123")
+
+	if err := ch.RecallMessage(ctx, "group:123", "4242"); err != nil {
+		t.Fatalf("RecallMessage() error = %v", err)
+	}
+
+	select {
+	case params := <-server.deletes:
+		if id, _ := params["message_id"].(float64); id != 4242 {
+			t.Errorf("delete_msg message_id = %v, want 4242", params["message_id"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("delete_msg was not sent")
+	}
+
+	// A recalled message is no longer tracked.
+	if err := ch.RecallMessage(ctx, "group:123", "4242"); !errors.Is(err, channels.ErrSendFailed) {
+		t.Errorf("second RecallMessage() error = %v, want ErrSendFailed", err)
+	}
+}
+
+func TestRecallMessage_LatestWhenIDEmpty(t *testing.T) {
+	server := &fakeOneBot{sentMessageID: 7, deletes: make(chan map[string]any, 1)}
+	ch := startTestChannel(t, server)
+	ctx := context.Background()
+
+	if err := ch.Send(ctx, bus.OutboundMessage{ChatID: "group:5", Content: "hi"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	waitForSent(t, ch, "group:5")
+
+	if err := ch.RecallMessage(ctx, "group:5", ""); err != nil {
+		t.Fatalf("RecallMessage() error = %v", err)
+	}
+	select {
+	case params := <-server.deletes:
+		if id, _ := params["message_id"].(float64); id != 7 {
+			t.Errorf("delete_msg message_id = %v, want 7", params["message_id"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("delete_msg was not sent")
+	}
+}
+
+func TestRecallMessage_RefusesForeignMessages(t *testing.T) {
+	server := &fakeOneBot{sentMessageID: 1, deletes: make(chan map[string]any, 1)}
+	ch := startTestChannel(t, server)
+
+	err := ch.RecallMessage(context.Background(), "group:123", "999")
+	if !errors.Is(err, channels.ErrSendFailed) {
+		t.Fatalf("RecallMessage() error = %v, want ErrSendFailed", err)
+	}
+	select {
+	case params := <-server.deletes:
+		t.Errorf("unexpected delete_msg %v", params)
+	default:
+	}
+}
+
+func TestGroupRecallNotice_ForgetsMessage(t *testing.T) {
+	ch, err := NewOneBotChannel(config.OneBotConfig{}, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("NewOneBotChannel() error = %v", err)
+	}
+	ch.trackSend("send_1", "group:9")
+	ch.recordSent(&oneBotRawEvent{Echo: "send_1", Data: json.RawMessage(`{"message_id":55}`)})
+	if got := ch.sentMessages["group:9"]; len(got) != 1 || got[0] != "55" {
+		t.Fatalf("sentMessages = %v, want [55]", got)
+	}
+
+	ch.handleNoticeEvent(&oneBotRawEvent{
+		NoticeType: "group_recall",
+		GroupID:    json.RawMessage(`9`),
+		MessageID:  json.RawMessage(`55`),
+	})
+	if got := ch.sentMessages["group:9"]; len(got) != 0 {
+		t.Errorf("sentMessages after recall notice = %v, want none", got)
+	}
+}
+
+func textNode(nickname, text string) map[string]any {
+	return map[string]any{
+		"sender":  map[string]any{"nickname": nickname},
+		"message": []map[string]any{{"type": "text", "data": map[string]any{"text": text}}},
+	}
+}
+
+func TestParseMessageSegments_Forward(t *testing.T) {
+	server := &fakeOneBot{forwards: map[string][]map[string]any{
+		"outer": {
+			textNode("Alice", "look at this"),
+			{
+				"sender":  map[string]any{"nickname": "Bob"},
+				"content": []map[string]any{{"type": "forward", "data": map[string]any{"id": "inner"}}},
+			},
+		},
+		"inner": {
+			textNode("Carol", "first"),
+			textNode("Dave", "second"),
+		},
+	}}
+	ch := startTestChannel(t, server)
+
+	raw := json.RawMessage(`[{"type":"text","data":{"text":"what happened? "}},` +
+		`{"type":"forward","data":{"id":"outer"}}]`)
+	got := ch.parseMessageSegments(raw, 0, nil, "")
+
+	if !got.Forwarded {
+		t.Error("Forwarded = false, want true")
+	}
+	for _, want := range []string{
+		"what happened? [forwarded messages]",
+		"Alice: look at this",
+		"Bob: [forwarded messages]",
+		"  Carol: first",
+		"  Dave: second",
+	} {
+		if !strings.Contains(got.Text, want) {
+			t.Errorf("text missing %q:\n%s", want, got.Text)
+		}
+	}
+}
+
+func TestDispatchMessage_KeepsChatOrderBehindForward(t *testing.T) {
+	server := &fakeOneBot{forwards: map[string][]map[string]any{
+		"fw": {textNode("Alice", "forwarded")},
+	}}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	mb := bus.NewMessageBus()
+	cfg := config.OneBotConfig{WSUrl: "ws" + strings.TrimPrefix(ts.URL, "http")}
+	ch, err := NewOneBotChannel(cfg, mb)
+	if err != nil {
+		t.Fatalf("NewOneBotChannel() error = %v", err)
+	}
+	if err := ch.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { ch.Stop(context.Background()) })
+
+	event := func(id int, message string) *oneBotRawEvent {
+		var raw oneBotRawEvent
+		data := `{"post_type":"message","message_type":"private","user_id":42,` +
+			`"message_id":` + strconv.Itoa(id) + `,"message":` + message + `}`
+		if err := json.Unmarshal([]byte(data), &raw); err != nil {
+			t.Fatalf("unmarshal event: %v", err)
+		}
+		return &raw
+	}
+	ch.handleRawEvent(event(1, `[{"type":"forward","data":{"id":"fw"}}]`))
+	ch.handleRawEvent(event(2, `[{"type":"text","data":{"text":"after"}}]`))
+
+	for _, want := range []string{"Alice: forwarded", "after"} {
+		select {
+		case msg := <-mb.InboundChan():
+			if !strings.Contains(msg.Content, want) {
+				t.Fatalf("received %q, want it to contain %q", msg.Content, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+	ch.inboundMu.Lock()
+	defer ch.inboundMu.Unlock()
+	if len(ch.inbound) != 0 {
+		t.Errorf("inbound queues left after drain: %v", ch.inbound)
+	}
+}
+
+func TestParseMessageSegments_ForwardDepthLimit(t *testing.T) {
+	// A forward that contains itself must stop at maxForwardDepth.
+	server := &fakeOneBot{forwards: map[string][]map[string]any{
+		"loop": {{
+			"sender":  map[string]any{"nickname": "Eve"},
+			"message": []map[string]any{{"type": "forward", "data": map[string]any{"id": "loop"}}},
+		}},
+	}}
+	ch := startTestChannel(t, server)
+
+	got := ch.parseMessageSegments(json.RawMessage(`[{"type":"forward","data":{"id":"loop"}}]`), 0, nil, "")
+	if n := strings.Count(got.Text, "[forwarded messages]"); n != maxForwardDepth {
+		t.Errorf("expanded %d levels, want %d:\n%s", n, maxForwardDepth, got.Text)
+	}
+	if !strings.Contains(got.Text, "[forward message]") {
+		t.Errorf("expected a placeholder past the depth limit:\n%s", got.Text)
+	}
+}
+
+func TestIsForwardSegment(t *testing.T) {
+	if !isForwardSegment(json.RawMessage(`[{"type":"text"},{"type":"forward","data":{"id":"1"}}]`)) {
+		t.Error("forward segment not detected")
+	}
+	if isForwardSegment(json.RawMessage(`[{"type":"text"}]`)) || isForwardSegment(json.RawMessage(`"plain"`)) {
+		t.Error("false positive")
+	}
+}
+
+func TestReactToMessage_ConfiguredEmoji(t *testing.T) {
+	server := &fakeOneBot{emojiLikes: make(chan map[string]any, 2)}
+	ch := startTestChannelWithConfig(t, server, config.OneBotConfig{ReactionEmojiID: 424})
+
+	undo, err := ch.ReactToMessage(context.Background(), "group:1", "77")
+	if err != nil {
+		t.Fatalf("ReactToMessage() error = %v", err)
+	}
+	undo()
+
+	// The set and undo requests are sent concurrently; both use the emoji.
+	for range 2 {
+		select {
+		case params := <-server.emojiLikes:
+			if id, _ := params["emoji_id"].(float64); id != 424 {
+				t.Errorf("emoji_id = %v, want 424", params["emoji_id"])
+			}
+			if params["message_id"] != "77" {
+				t.Errorf("message_id = %v, want 77", params["message_id"])
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("set_msg_emoji_like was not sent")
+		}
+	}
+}
+
+func TestReactToMessage_DefaultEmojiAndPrivateNoop(t *testing.T) {
+	server := &fakeOneBot{emojiLikes: make(chan map[string]any, 2)}
+	ch := startTestChannel(t, server)
+
+	if _, err := ch.ReactToMessage(context.Background(), "private:5", "1"); err != nil {
+		t.Fatalf("ReactToMessage() error = %v", err)
+	}
+	if _, err := ch.ReactToMessage(context.Background(), "group:5", "2"); err != nil {
+		t.Fatalf("ReactToMessage() error = %v", err)
+	}
+	select {
+	case params := <-server.emojiLikes:
+		if params["message_id"] != "2" {
+			t.Errorf("private chat should not get a reaction, got %v", params)
+		}
+		if id, _ := params["emoji_id"].(float64); id != defaultReactionEmojiID {
+			t.Errorf("emoji_id = %v, want %d", params["emoji_id"], defaultReactionEmojiID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("set_msg_emoji_like was not sent")
+	}
+}
+
+func TestIsDuplicateUsesPersistentStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "channel_dedup.json")
+	ch, err := NewOneBotChannel(config.OneBotConfig{}, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("NewOneBotChannel() error = %v", err)
+	}
+	store := channels.NewDedupStore(path, time.Hour)
+	ch.SetDedupStore(store)
+	if ch.isDuplicate("100") {
+		t.Fatal("first delivery reported as duplicate")
+	}
+	store.Close()
+
+	// A fresh channel stands in for the process after a restart.
+	restarted, _ := NewOneBotChannel(config.OneBotConfig{}, bus.NewMessageBus())
+	restarted.SetDedupStore(channels.NewDedupStore(path, time.Hour))
+	if !restarted.isDuplicate("100") {
+		t.Error("redelivery after restart not reported as duplicate")
+	}
+	if restarted.isDuplicate("0") || restarted.isDuplicate("0") {
+		t.Error("message_id 0 must never be a duplicate")
+	}
+}
+
+// newDisconnectedChannel returns a channel pointed at server that has not
+// connected yet.
+func newDisconnectedChannel(t *testing.T, server *fakeOneBot) *OneBotChannel {
+	t.Helper()
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	cfg := config.OneBotConfig{WSUrl: "ws" + strings.TrimPrefix(ts.URL, "http")}
+	ch, err := NewOneBotChannel(cfg, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("NewOneBotChannel() error = %v", err)
+	}
+	ch.ctx, ch.cancel = context.WithCancel(context.Background())
+	t.Cleanup(func() { ch.Stop(context.Background()) })
+	return ch
+}
+
+func queuedFrame(echo string) []byte {
+	data, _ := json.Marshal(oneBotAPIRequest{Action: "send_private_msg", Echo: echo})
+	return data
+}
+
+func TestWriteOrQueue_FlushesInOrderAfterReconnect(t *testing.T) {
+	server := &fakeOneBot{echoes: make(chan string, 16)}
+	ch := newDisconnectedChannel(t, server)
+
+	for _, echo := range []string{"q1", "q2", "q3"} {
+		if err := ch.writeOrQueue("private:1", queuedFrame(echo)); err != nil {
+			t.Fatalf("writeOrQueue(%s) while disconnected error = %v", echo, err)
+		}
+	}
+	if n := len(ch.outQueue); n != 3 {
+		t.Fatalf("queued = %d, want 3", n)
+	}
+
+	if err := ch.connect(); err != nil {
+		t.Fatalf("connect() error = %v", err)
+	}
+	ch.flushOutbound()
+	if err := ch.writeOrQueue("private:1", queuedFrame("live")); err != nil {
+		t.Fatalf("writeOrQueue after reconnect error = %v", err)
+	}
+
+	for _, want := range []string{"q1", "q2", "q3", "live"} {
+		select {
+		case got := <-server.echoes:
+			if got != want {
+				t.Fatalf("received %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+	ch.outMu.Lock()
+	defer ch.outMu.Unlock()
+	if len(ch.outQueue) != 0 || ch.outDraining {
+		t.Errorf("after flush: queued = %d, draining = %v", len(ch.outQueue), ch.outDraining)
+	}
+}
+
+func TestWriteOrQueue_PrunesExpiredAndBoundsSize(t *testing.T) {
+	ch := newDisconnectedChannel(t, &fakeOneBot{})
+
+	if err := ch.writeOrQueue("private:1", queuedFrame("stale")); err != nil {
+		t.Fatalf("writeOrQueue() error = %v", err)
+	}
+	ch.outQueue[0].queuedAt = time.Now().Add(-outboundQueueTTL - time.Second)
+	if err := ch.writeOrQueue("private:1", queuedFrame("fresh")); err != nil {
+		t.Fatalf("writeOrQueue() error = %v", err)
+	}
+	if len(ch.outQueue) != 1 || !strings.Contains(string(ch.outQueue[0].data), "fresh") {
+		t.Fatalf("queue after TTL prune = %d frames, want only the fresh one", len(ch.outQueue))
+	}
+
+	for len(ch.outQueue) < outboundQueueSize {
+		if err := ch.writeOrQueue("private:1", queuedFrame("fill")); err != nil {
+			t.Fatalf("writeOrQueue() below the limit error = %v", err)
+		}
+	}
+	err := ch.writeOrQueue("private:1", queuedFrame("overflow"))
+	if !errors.Is(err, channels.ErrTemporary) {
+		t.Errorf("writeOrQueue() on a full queue error = %v, want ErrTemporary", err)
+	}
+	if len(ch.outQueue) != outboundQueueSize {
+		t.Errorf("queued = %d, want %d", len(ch.outQueue), outboundQueueSize)
+	}
+}
+
+func healthStatus(t *testing.T, ch *OneBotChannel) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	ch.HealthHandler(rec, httptest.NewRequest(http.MethodGet, ch.HealthPath(), nil))
+	var status map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("health body %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, status
+}
+
+func TestHealthHandler_ReportsConnectionState(t *testing.T) {
+	ch := startTestChannelWithConfig(t, &fakeOneBot{}, config.OneBotConfig{ReconnectInterval: 5})
+
+	code, status := healthStatus(t, ch)
+	if code != http.StatusOK {
+		t.Errorf("connected: code = %d, want 200", code)
+	}
+	if status["status"] != "ok" || status["connected"] != true || status["running"] != true {
+		t.Errorf("connected: status = %v", status)
+	}
+	if _, ok := status["last_connected"]; !ok {
+		t.Errorf("connected: last_connected missing in %v", status)
+	}
+	if !ch.IsRunning() {
+		t.Error("IsRunning() = false while connected")
+	}
+
+	ch.mu.Lock()
+	ch.conn.Close()
+	ch.conn = nil
+	ch.mu.Unlock()
+	if err := ch.writeOrQueue("private:1", queuedFrame("q1")); err != nil {
+		t.Fatalf("writeOrQueue() error = %v", err)
+	}
+
+	code, status = healthStatus(t, ch)
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("disconnected: code = %d, want 503", code)
+	}
+	if status["status"] != "disconnected" || status["connected"] != false || status["running"] != true {
+		t.Errorf("disconnected: status = %v", status)
+	}
+	if status["queued_outbound"] != float64(1) {
+		t.Errorf("disconnected: queued_outbound = %v, want 1", status["queued_outbound"])
+	}
+	if ch.IsRunning() {
+		t.Error("IsRunning() = true while disconnected")
+	}
+}
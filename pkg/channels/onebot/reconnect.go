@@ -0,0 +1,116 @@
+package onebot
+
+import (
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	// reconnectMinInterval is the shortest base delay between reconnect attempts.
+	reconnectMinInterval = 5 * time.Second
+	// reconnectMaxDelay caps the backoff on an endpoint that keeps failing.
+	reconnectMaxDelay = 2 * time.Minute
+	// reconnectStableAfter is how long a connection must stay up before the
+	// backoff returns to the base interval.
+	reconnectStableAfter = 60 * time.Second
+)
+
+// reconnectBackoff holds the delay before the next reconnect attempt. It
+// doubles after every attempt up to max and drops back to base once a
+// connection has stayed up for stableAfter, so a flapping endpoint is not
+// hammered. It is only used by reconnectLoop.
+type reconnectBackoff struct {
+	base        time.Duration
+	max         time.Duration
+	stableAfter time.Duration
+	delay       time.Duration
+	connectedAt time.Time
+}
+
+func newReconnectBackoff(base time.Duration) reconnectBackoff {
+	base = max(base, reconnectMinInterval)
+	return reconnectBackoff{
+		base:        base,
+		max:         max(reconnectMaxDelay, base),
+		stableAfter: reconnectStableAfter,
+		delay:       base,
+	}
+}
+
+// next returns the delay to wait before the next attempt and doubles it for
+// the one after.
+func (b *reconnectBackoff) next() time.Duration {
+	d := b.delay
+	b.delay = min(b.delay*2, b.max)
+	return d
+}
+
+// connected records a successful connection at now.
+func (b *reconnectBackoff) connected(now time.Time) {
+	b.connectedAt = now
+}
+
+// observeUp resets the delay once the connection made at connectedAt has
+// been up for stableAfter.
+func (b *reconnectBackoff) observeUp(now time.Time) {
+	if !b.connectedAt.IsZero() && now.Sub(b.connectedAt) >= b.stableAfter {
+		b.delay = b.base
+		b.connectedAt = time.Time{}
+	}
+}
+
+// reconnectLoop watches the connection and reconnects when it drops. While
+// connected it checks every base interval; while disconnected it retries
+// with exponential backoff.
+func (c *OneBotChannel) reconnectLoop() {
+	dial, after := c.dial, c.after
+	if dial == nil {
+		dial = c.connect
+	}
+	if after == nil {
+		after = time.After
+	}
+
+	for {
+		c.mu.Lock()
+		connected := c.conn != nil
+		c.mu.Unlock()
+
+		wait := c.reconnect.base
+		if connected {
+			c.reconnect.observeUp(time.Now())
+		} else {
+			wait = c.reconnect.next()
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-after(wait):
+		}
+
+		c.mu.Lock()
+		conn := c.conn
+		c.mu.Unlock()
+		if conn != nil {
+			continue
+		}
+
+		attempt := c.reconnectAttempts.Add(1)
+		logger.InfoCF("onebot", "Attempting to reconnect...", map[string]any{
+			"attempt": attempt,
+		})
+		if err := dial(); err != nil {
+			logger.ErrorCF("onebot", "Reconnect failed", map[string]any{
+				"error":      err.Error(),
+				"next_retry": c.reconnect.delay.String(),
+			})
+			continue
+		}
+		c.reconnect.connected(time.Now())
+		go c.listen()
+		c.flushOutbound()
+		c.fetchSelfID()
+	}
+}
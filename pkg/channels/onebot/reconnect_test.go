@@ -0,0 +1,100 @@
+package onebot
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestReconnectBackoff(t *testing.T) {
+	b := newReconnectBackoff(0)
+	var got []time.Duration
+	for range 7 {
+		got = append(got, b.next())
+	}
+	want := []time.Duration{
+		5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second,
+		80 * time.Second, 2 * time.Minute, 2 * time.Minute,
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("delays = %v, want %v", got, want)
+		}
+	}
+
+	// A connection that drops quickly keeps the backoff.
+	now := time.Now()
+	b.connected(now)
+	b.observeUp(now.Add(30 * time.Second))
+	if d := b.next(); d != 2*time.Minute {
+		t.Errorf("delay after a short connection = %v, want 2m", d)
+	}
+
+	// One that stays up for a minute resets it.
+	b.connected(now)
+	b.observeUp(now.Add(reconnectStableAfter))
+	if d := b.next(); d != 5*time.Second {
+		t.Errorf("delay after a stable connection = %v, want 5s", d)
+	}
+}
+
+func TestReconnectLoop_BacksOffThenResets(t *testing.T) {
+	ts := httptest.NewServer(&fakeOneBot{})
+	t.Cleanup(ts.Close)
+
+	cfg := config.OneBotConfig{WSUrl: "ws" + strings.TrimPrefix(ts.URL, "http"), ReconnectInterval: 5}
+	ch, err := NewOneBotChannel(cfg, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("NewOneBotChannel() error = %v", err)
+	}
+	ch.ctx, ch.cancel = context.WithCancel(context.Background())
+	t.Cleanup(func() { ch.Stop(context.Background()) })
+	ch.reconnect.stableAfter = 0
+
+	failures := 3
+	ch.dial = func() error {
+		if failures > 0 {
+			failures--
+			return errors.New("connection refused")
+		}
+		return ch.connect()
+	}
+	var waits []time.Duration
+	ch.after = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		fire := make(chan time.Time, 1)
+		if len(waits) < 6 {
+			fire <- time.Now()
+		} else {
+			ch.cancel()
+		}
+		return fire
+	}
+
+	ch.reconnectLoop()
+
+	want := []time.Duration{
+		5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, // three failures, then success
+		5 * time.Second, 5 * time.Second, // connected: checked at the base interval
+	}
+	if len(waits) != len(want) {
+		t.Fatalf("waits = %v, want %v", waits, want)
+	}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Fatalf("waits = %v, want %v", waits, want)
+		}
+	}
+	if ch.reconnect.delay != 5*time.Second {
+		t.Errorf("delay after a stable connection = %v, want the 5s base", ch.reconnect.delay)
+	}
+	if got := ch.reconnectAttempts.Load(); got != 4 {
+		t.Errorf("reconnect attempts = %d, want 4", got)
+	}
+}
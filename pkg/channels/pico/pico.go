@@ -3,8 +3,11 @@ package pico
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -22,23 +25,34 @@ import (
 
 // picoConn represents a single WebSocket connection.
 type picoConn struct {
-	id        string
-	conn      *websocket.Conn
-	sessionID string
-	writeMu   sync.Mutex
-	closed    atomic.Bool
+	id           string
+	conn         *websocket.Conn
+	sessionID    string
+	writeTimeout time.Duration
+	writeMu      sync.Mutex
+	closed       atomic.Bool
 }
 
 // writeJSON sends a JSON message to the connection with write locking.
+// A client that stops reading fails the write after writeTimeout instead of
+// blocking the sender.
 func (pc *picoConn) writeJSON(v any) error {
 	if pc.closed.Load() {
 		return fmt.Errorf("connection closed")
 	}
 	pc.writeMu.Lock()
 	defer pc.writeMu.Unlock()
+	_ = pc.conn.SetWriteDeadline(time.Now().Add(pc.writeTimeout))
 	return pc.conn.WriteJSON(v)
 }
 
+// ping sends a ping control frame.
+func (pc *picoConn) ping() error {
+	pc.writeMu.Lock()
+	defer pc.writeMu.Unlock()
+	return pc.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pc.writeTimeout))
+}
+
 // close closes the connection.
 func (pc *picoConn) close() {
 	if pc.closed.CompareAndSwap(false, true) {
@@ -54,6 +68,8 @@ type PicoChannel struct {
 	upgrader    websocket.Upgrader
 	connections sync.Map // connID → *picoConn
 	connCount   atomic.Int32
+	rejected    atomic.Int64 // connections refused at max_connections
+	idleDropped atomic.Int64 // connections closed after missing pings
 	ctx         context.Context
 	cancel      context.CancelFunc
 }
@@ -66,29 +82,68 @@ func NewPicoChannel(cfg config.PicoConfig, messageBus *bus.MessageBus) (*PicoCha
 
 	base := channels.NewBaseChannel("pico", cfg, messageBus, cfg.AllowFrom)
 
-	allowOrigins := cfg.AllowOrigins
-	checkOrigin := func(r *http.Request) bool {
-		if len(allowOrigins) == 0 {
-			return true // allow all if not configured
-		}
-		origin := r.Header.Get("Origin")
-		for _, allowed := range allowOrigins {
-			if allowed == "*" || allowed == origin {
-				return true
-			}
-		}
-		return false
-	}
-
-	return &PicoChannel{
+	c := &PicoChannel{
 		BaseChannel: base,
 		config:      cfg,
-		upgrader: websocket.Upgrader{
-			CheckOrigin:     checkOrigin,
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
-		},
-	}, nil
+	}
+	c.upgrader = websocket.Upgrader{
+		CheckOrigin:     c.checkOrigin,
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+	return c, nil
+}
+
+// Default connection timings, used when the config leaves them unset.
+const (
+	defaultPingInterval = 30 * time.Second
+	defaultReadTimeout  = 60 * time.Second
+	defaultWriteTimeout = 10 * time.Second
+)
+
+// pingInterval returns the configured ping interval or its default.
+func (c *PicoChannel) pingInterval() time.Duration {
+	if c.config.PingInterval > 0 {
+		return time.Duration(c.config.PingInterval) * time.Second
+	}
+	return defaultPingInterval
+}
+
+// readTimeout returns how long a connection may stay silent (no messages and
+// no pong) before it is dropped. It is never shorter than two ping intervals,
+// so a healthy client always has a chance to answer a ping in time.
+func (c *PicoChannel) readTimeout() time.Duration {
+	timeout := defaultReadTimeout
+	if c.config.ReadTimeout > 0 {
+		timeout = time.Duration(c.config.ReadTimeout) * time.Second
+	}
+	return max(timeout, 2*c.pingInterval())
+}
+
+// writeTimeout returns the configured write deadline or its default.
+func (c *PicoChannel) writeTimeout() time.Duration {
+	if c.config.WriteTimeout > 0 {
+		return time.Duration(c.config.WriteTimeout) * time.Second
+	}
+	return defaultWriteTimeout
+}
+
+// Stats reports connection counters for monitoring.
+type Stats struct {
+	Connections    int   `json:"connections"`
+	MaxConnections int   `json:"max_connections"`
+	Rejected       int64 `json:"rejected_total"`
+	IdleDropped    int64 `json:"idle_dropped_total"`
+}
+
+// Stats returns the channel's current connection counters.
+func (c *PicoChannel) Stats() Stats {
+	return Stats{
+		Connections:    int(c.connCount.Load()),
+		MaxConnections: c.maxConnections(),
+		Rejected:       c.rejected.Load(),
+		IdleDropped:    c.idleDropped.Load(),
+	}
 }
 
 // Start implements Channel.
@@ -132,6 +187,8 @@ func (c *PicoChannel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch {
 	case path == "/ws" || path == "/ws/":
 		c.handleWebSocket(w, r)
+	case path == "/stats":
+		c.handleStats(w, r)
 	default:
 		http.NotFound(w, r)
 	}
@@ -235,19 +292,24 @@ func (c *PicoChannel) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Authenticate
-	if !c.authenticate(r) {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	if !c.checkOrigin(r) {
+		logger.WarnCF("pico", "Rejected WebSocket connection from disallowed origin", map[string]any{
+			"origin": r.Header.Get("Origin"),
+		})
+		http.Error(w, "origin not allowed", http.StatusForbidden)
 		return
 	}
 
-	// Check connection limit
-	maxConns := c.config.MaxConnections
-	if maxConns <= 0 {
-		maxConns = 100
+	// Tokens in URLs end up in proxy and access logs, so only accept them
+	// when explicitly enabled.
+	if !c.config.AllowTokenQuery && r.URL.Query().Has("token") {
+		http.Error(w, "token query parameter is disabled; use the Authorization header", http.StatusUnauthorized)
+		return
 	}
-	if int(c.connCount.Load()) >= maxConns {
-		http.Error(w, "too many connections", http.StatusServiceUnavailable)
+
+	// Authenticate
+	if !c.authenticate(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
@@ -257,8 +319,16 @@ func (c *PicoChannel) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		responseHeader = http.Header{"Sec-WebSocket-Protocol": {proto}}
 	}
 
+	// Reserve a connection slot up front so concurrent upgrades can't
+	// overshoot the limit; readLoop releases it on disconnect.
+	if !c.acquireConnSlot() {
+		c.rejectOverLimit(w, r, responseHeader)
+		return
+	}
+
 	conn, err := c.upgrader.Upgrade(w, r, responseHeader)
 	if err != nil {
+		c.connCount.Add(-1)
 		logger.ErrorCF("pico", "WebSocket upgrade failed", map[string]any{
 			"error": err.Error(),
 		})
@@ -272,22 +342,100 @@ func (c *PicoChannel) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	pc := &picoConn{
-		id:        uuid.New().String(),
-		conn:      conn,
-		sessionID: sessionID,
+		id:           uuid.New().String(),
+		conn:         conn,
+		sessionID:    sessionID,
+		writeTimeout: c.writeTimeout(),
 	}
 
 	c.connections.Store(pc.id, pc)
-	c.connCount.Add(1)
 
 	logger.InfoCF("pico", "WebSocket client connected", map[string]any{
-		"conn_id":    pc.id,
-		"session_id": sessionID,
+		"conn_id":     pc.id,
+		"session_id":  sessionID,
+		"connections": c.connCount.Load(),
 	})
 
 	go c.readLoop(pc)
 }
 
+// defaultMaxConnections is used when max_connections is not configured.
+const defaultMaxConnections = 100
+
+// maxConnections returns the configured connection limit or its default.
+func (c *PicoChannel) maxConnections() int {
+	if c.config.MaxConnections > 0 {
+		return c.config.MaxConnections
+	}
+	return defaultMaxConnections
+}
+
+// acquireConnSlot reserves one of the max_connections slots. It reports false
+// when the limit is reached.
+func (c *PicoChannel) acquireConnSlot() bool {
+	if int(c.connCount.Add(1)) > c.maxConnections() {
+		c.connCount.Add(-1)
+		return false
+	}
+	return true
+}
+
+// rejectOverLimit completes the handshake only to close it with
+// CloseTryAgainLater: browsers cannot see the HTTP status of a failed
+// upgrade, but they do receive the close code and reason.
+func (c *PicoChannel) rejectOverLimit(w http.ResponseWriter, r *http.Request, responseHeader http.Header) {
+	c.rejected.Add(1)
+	logger.WarnCF("pico", "Rejected WebSocket connection: too many connections", map[string]any{
+		"max_connections": c.maxConnections(),
+	})
+
+	conn, err := c.upgrader.Upgrade(w, r, responseHeader)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too many connections")
+	_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(c.writeTimeout()))
+}
+
+// handleStats serves the connection counters as JSON. It requires the same
+// token as the WebSocket endpoint.
+func (c *PicoChannel) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !c.authenticate(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c.Stats())
+}
+
+// checkOrigin reports whether a WebSocket handshake may proceed based on its
+// Origin header. Requests without an Origin come from non-browser clients and
+// are allowed (they still need the token). Browser origins must match
+// allow_origins exactly ("*" allows any); when allow_origins is empty only
+// same-origin pages may connect.
+func (c *PicoChannel) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if len(c.config.AllowOrigins) == 0 {
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	for _, allowed := range c.config.AllowOrigins {
+		allowed = strings.TrimSuffix(strings.TrimSpace(allowed), "/")
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
 // authenticate checks the request for a valid token:
 //  1. Authorization: Bearer <token> header
 //  2. Sec-WebSocket-Protocol "token.<value>" (for browsers that can't set headers)
@@ -340,28 +488,22 @@ func (c *PicoChannel) readLoop(pc *picoConn) {
 		c.connections.Delete(pc.id)
 		c.connCount.Add(-1)
 		logger.InfoCF("pico", "WebSocket client disconnected", map[string]any{
-			"conn_id":    pc.id,
-			"session_id": pc.sessionID,
+			"conn_id":     pc.id,
+			"session_id":  pc.sessionID,
+			"connections": c.connCount.Load(),
 		})
 	}()
 
-	readTimeout := time.Duration(c.config.ReadTimeout) * time.Second
-	if readTimeout <= 0 {
-		readTimeout = 60 * time.Second
-	}
-
+	// Every message or pong pushes the read deadline out; a client that
+	// misses pings for readTimeout fails the next read and is dropped.
+	readTimeout := c.readTimeout()
 	_ = pc.conn.SetReadDeadline(time.Now().Add(readTimeout))
 	pc.conn.SetPongHandler(func(appData string) error {
 		_ = pc.conn.SetReadDeadline(time.Now().Add(readTimeout))
 		return nil
 	})
 
-	// Start ping ticker
-	pingInterval := time.Duration(c.config.PingInterval) * time.Second
-	if pingInterval <= 0 {
-		pingInterval = 30 * time.Second
-	}
-	go c.pingLoop(pc, pingInterval)
+	go c.pingLoop(pc, c.pingInterval())
 
 	for {
 		select {
@@ -372,6 +514,15 @@ func (c *PicoChannel) readLoop(pc *picoConn) {
 
 		_, rawMsg, err := pc.conn.ReadMessage()
 		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				c.idleDropped.Add(1)
+				logger.InfoCF("pico", "Dropping idle WebSocket connection", map[string]any{
+					"conn_id":      pc.id,
+					"read_timeout": readTimeout.String(),
+				})
+				return
+			}
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
 				logger.DebugCF("pico", "WebSocket read error", map[string]any{
 					"conn_id": pc.id,
@@ -407,10 +558,7 @@ func (c *PicoChannel) pingLoop(pc *picoConn, interval time.Duration) {
 			if pc.closed.Load() {
 				return
 			}
-			pc.writeMu.Lock()
-			err := pc.conn.WriteMessage(websocket.PingMessage, nil)
-			pc.writeMu.Unlock()
-			if err != nil {
+			if err := pc.ping(); err != nil {
 				return
 			}
 		}
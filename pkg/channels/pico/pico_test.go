@@ -0,0 +1,169 @@
+package pico
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+const testToken = "secret"
+
+func startTestServer(t *testing.T, cfg config.PicoConfig) (*PicoChannel, string) {
+	t.Helper()
+	cfg.Token = testToken
+	ch, err := NewPicoChannel(cfg, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("NewPicoChannel() error = %v", err)
+	}
+	if err := ch.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	srv := httptest.NewServer(ch)
+	t.Cleanup(func() {
+		_ = ch.Stop(context.Background())
+		srv.Close()
+	})
+	return ch, "ws" + strings.TrimPrefix(srv.URL, "http") + "/pico/ws"
+}
+
+func dial(t *testing.T, wsURL string, header http.Header) (*websocket.Conn, int) {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		if resp == nil {
+			t.Fatalf("Dial() error = %v", err)
+		}
+		return nil, resp.StatusCode
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, resp.StatusCode
+}
+
+func authHeader(origin string) http.Header {
+	h := http.Header{"Authorization": {"Bearer " + testToken}}
+	if origin != "" {
+		h.Set("Origin", origin)
+	}
+	return h
+}
+
+func TestHandleWebSocket_Origins(t *testing.T) {
+	t.Run("allow list", func(t *testing.T) {
+		_, wsURL := startTestServer(t, config.PicoConfig{AllowOrigins: []string{"https://app.example.com/"}})
+
+		if _, status := dial(t, wsURL, authHeader("https://evil.example.com")); status != http.StatusForbidden {
+			t.Errorf("disallowed origin: status = %d, want %d", status, http.StatusForbidden)
+		}
+		if _, status := dial(t, wsURL, authHeader("https://app.example.com")); status != http.StatusSwitchingProtocols {
+			t.Errorf("allowed origin: status = %d, want %d", status, http.StatusSwitchingProtocols)
+		}
+	})
+
+	t.Run("same origin only when unset", func(t *testing.T) {
+		_, wsURL := startTestServer(t, config.PicoConfig{})
+		host := strings.TrimPrefix(strings.Split(wsURL, "/pico")[0], "ws://")
+
+		if _, status := dial(t, wsURL, authHeader("https://evil.example.com")); status != http.StatusForbidden {
+			t.Errorf("cross origin: status = %d, want %d", status, http.StatusForbidden)
+		}
+		if _, status := dial(t, wsURL, authHeader("http://"+host)); status != http.StatusSwitchingProtocols {
+			t.Errorf("same origin: status = %d, want %d", status, http.StatusSwitchingProtocols)
+		}
+		if _, status := dial(t, wsURL, authHeader("")); status != http.StatusSwitchingProtocols {
+			t.Errorf("no origin: status = %d, want %d", status, http.StatusSwitchingProtocols)
+		}
+	})
+}
+
+func TestHandleWebSocket_TokenQuery(t *testing.T) {
+	_, wsURL := startTestServer(t, config.PicoConfig{})
+	if _, status := dial(t, wsURL+"?token="+testToken, nil); status != http.StatusUnauthorized {
+		t.Errorf("token query disabled: status = %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	_, wsURL = startTestServer(t, config.PicoConfig{AllowTokenQuery: true})
+	if _, status := dial(t, wsURL+"?token="+testToken, nil); status != http.StatusSwitchingProtocols {
+		t.Errorf("token query enabled: status = %d, want %d", status, http.StatusSwitchingProtocols)
+	}
+}
+
+func TestHandleWebSocket_MaxConnections(t *testing.T) {
+	ch, wsURL := startTestServer(t, config.PicoConfig{MaxConnections: 1})
+
+	if _, status := dial(t, wsURL, authHeader("")); status != http.StatusSwitchingProtocols {
+		t.Fatalf("first connection: status = %d, want %d", status, http.StatusSwitchingProtocols)
+	}
+
+	// The over-limit connection is closed with a close code browsers can see.
+	conn, _ := dial(t, wsURL, authHeader(""))
+	if conn == nil {
+		t.Fatal("expected the over-limit handshake to complete before closing")
+	}
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+		t.Errorf("over limit: err = %v, want close code %d", err, websocket.CloseTryAgainLater)
+	}
+
+	stats := ch.Stats()
+	if stats.Connections != 1 || stats.MaxConnections != 1 || stats.Rejected != 1 {
+		t.Errorf("Stats() = %+v, want 1 connection, limit 1, 1 rejected", stats)
+	}
+}
+
+func TestHandleWebSocket_DropsIdleConnections(t *testing.T) {
+	ch, wsURL := startTestServer(t, config.PicoConfig{PingInterval: 1, ReadTimeout: 1})
+
+	// The client never reads, so it never answers the server's pings.
+	if _, status := dial(t, wsURL, authHeader("")); status != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want %d", status, http.StatusSwitchingProtocols)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for ch.connCount.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("idle connection was not dropped; Stats() = %+v", ch.Stats())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if got := ch.Stats().IdleDropped; got != 1 {
+		t.Errorf("IdleDropped = %d, want 1", got)
+	}
+}
+
+func TestServeHTTP_Stats(t *testing.T) {
+	_, wsURL := startTestServer(t, config.PicoConfig{})
+	statsURL := "http" + strings.TrimSuffix(strings.TrimPrefix(wsURL, "ws"), "/ws") + "/stats"
+
+	resp, err := http.Get(statsURL)
+	if err != nil {
+		t.Fatalf("GET stats: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unauthenticated stats: status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, statsURL, nil)
+	req.Header = authHeader("")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET stats: %v", err)
+	}
+	defer resp.Body.Close()
+	var stats Stats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	if stats.MaxConnections != defaultMaxConnections {
+		t.Errorf("MaxConnections = %d, want %d", stats.MaxConnections, defaultMaxConnections)
+	}
+}
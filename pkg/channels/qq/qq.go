@@ -63,6 +63,9 @@ func NewQQChannel(cfg config.QQConfig, messageBus *bus.MessageBus) (*QQChannel,
 		channels.WithMaxMessageLength(cfg.MaxMessageLength),
 		channels.WithGroupTrigger(cfg.GroupTrigger),
 		channels.WithReasoningChannelID(cfg.ReasoningChannelID),
+		channels.WithGreeting(cfg.Greeting),
+		channels.WithFooter(cfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(cfg.CoalesceWindowMS)*time.Millisecond),
 	)
 
 	return &QQChannel{
@@ -477,7 +480,7 @@ func (c *QQChannel) handleGroupATMessage() event.GroupATMessageEventHandler {
 		}
 
 		// GroupAT event means bot is always mentioned; apply group trigger filtering
-		respond, cleaned := c.ShouldRespondInGroup(true, content)
+		respond, cleaned := c.ShouldRespondInGroup(data.GroupID, true, content)
 		if !respond {
 			return nil
 		}
@@ -2,6 +2,7 @@ package channels
 
 import (
 	"strings"
+	"unicode/utf8"
 )
 
 // SplitMessage splits long messages into chunks, preserving code block integrity.
@@ -206,3 +207,115 @@ func findLastSpaceInRange(runes []rune, start, end, searchWindow int) int {
 	}
 	return start - 1
 }
+
+// SplitMessageSmart splits content into chunks of at most maxLen runes,
+// breaking only between lines. It prefers to break between paragraphs, and a
+// fenced code block cut across chunks is closed at the end of one chunk and
+// reopened, with its language tag, at the start of the next, so every chunk
+// renders as valid markdown on its own. Only lines that alone exceed the
+// limit are broken inside.
+func SplitMessageSmart(content string, maxLen int) []string {
+	if maxLen <= 0 || utf8.RuneCountInString(content) <= maxLen {
+		if content == "" {
+			return nil
+		}
+		return []string{content}
+	}
+
+	lines := strings.Split(content, "\n")
+	longestHeader := 0
+	for _, line := range lines {
+		if isFenceLine(line) {
+			longestHeader = max(longestHeader, utf8.RuneCountInString(strings.TrimSpace(line)))
+		}
+	}
+	// Room for a reopened fence header and a closing fence around each line.
+	lines = breakLongLines(lines, max(maxLen-longestHeader-len("\n\n```"), 1))
+
+	var chunks []string
+	inFence, header := false, ""
+	for i := 0; i < len(lines); {
+		// Blank lines between chunks are dropped outside code.
+		if !inFence && strings.TrimSpace(lines[i]) == "" {
+			i++
+			continue
+		}
+
+		var cur []string
+		size := 0
+		if inFence {
+			cur, size = []string{header}, utf8.RuneCountInString(header)
+		}
+		base := len(cur)
+
+		fence, hdr := inFence, header
+		paraEnd, paraSize, paraNext := 0, 0, 0
+		j := i
+		for ; j < len(lines); j++ {
+			line := lines[j]
+			add := utf8.RuneCountInString(line)
+			if len(cur) > 0 {
+				add++
+			}
+			nextFence, nextHdr := fence, hdr
+			if isFenceLine(line) {
+				if fence {
+					nextFence, nextHdr = false, ""
+				} else {
+					nextFence, nextHdr = true, strings.TrimSpace(line)
+				}
+			}
+			reserve := 0
+			if nextFence {
+				reserve = len("\n```")
+			}
+			if size+add+reserve > maxLen && len(cur) > base {
+				break
+			}
+			cur = append(cur, line)
+			size += add
+			fence, hdr = nextFence, nextHdr
+			if !fence && strings.TrimSpace(line) == "" {
+				paraEnd, paraSize, paraNext = len(cur), size, j+1
+			}
+		}
+
+		if j < len(lines) && paraEnd > base && paraSize >= maxLen/2 {
+			// Break at the last paragraph boundary instead of mid-paragraph.
+			cur, fence, hdr, j = cur[:paraEnd], false, "", paraNext
+		}
+		chunk := strings.TrimRight(strings.Join(cur, "\n"), " \t\r\n")
+		if fence && j < len(lines) {
+			chunk += "\n```"
+		}
+		if strings.TrimSpace(chunk) != "" {
+			chunks = append(chunks, chunk)
+		}
+		i, inFence, header = j, fence, hdr
+	}
+	return chunks
+}
+
+// isFenceLine reports whether line opens or closes a fenced code block.
+func isFenceLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+// breakLongLines splits lines longer than limit runes, preferring to break
+// after a space.
+func breakLongLines(lines []string, limit int) []string {
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		runes := []rune(line)
+		for len(runes) > limit {
+			cut := limit
+			if sp := findLastSpaceInRange(runes, 0, limit, limit/2); sp > 0 {
+				cut = sp + 1
+			}
+			out = append(out, strings.TrimRight(string(runes[:cut]), " "))
+			runes = runes[cut:]
+		}
+		out = append(out, string(runes))
+	}
+	return out
+}
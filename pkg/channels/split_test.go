@@ -1,8 +1,10 @@
 package channels
 
 import (
+	"fmt"
 	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestSplitMessage(t *testing.T) {
@@ -360,3 +362,80 @@ func TestSplitMessage_CodeBlockIntegrity(t *testing.T) {
 		t.Errorf("First chunk exceeded maxLen: length %d runes", len([]rune(chunks[0])))
 	}
 }
+
+// fenceBalanced reports whether every code fence opened in chunk is closed.
+func fenceBalanced(chunk string) bool {
+	open := false
+	for line := range strings.SplitSeq(chunk, "\n") {
+		if isFenceLine(line) {
+			open = !open
+		}
+	}
+	return !open
+}
+
+func TestSplitMessageSmart_LongCodeFence(t *testing.T) {
+	var code strings.Builder
+	for i := range 60 {
+		fmt.Fprintf(&code, "    result%d := compute(%d)\n", i, i)
+	}
+	content := "Here is the fix:\n\n```go\nfunc main() {\n" + code.String() + "}\n```\n\nRun it with `go run .`"
+	const maxLen = 300
+
+	chunks := SplitMessageSmart(content, maxLen)
+	if len(chunks) < 3 {
+		t.Fatalf("expected the code to span several chunks, got %d", len(chunks))
+	}
+
+	chunkLines := map[string]bool{}
+	for i, chunk := range chunks {
+		if n := utf8.RuneCountInString(chunk); n > maxLen {
+			t.Errorf("chunk %d has %d runes, over %d", i, n, maxLen)
+		}
+		if !fenceBalanced(chunk) {
+			t.Errorf("chunk %d leaves a code fence open:\n%s", i, chunk)
+		}
+		if i > 0 && strings.HasPrefix(chunk, "```") && !strings.HasPrefix(chunk, "```go\n") {
+			t.Errorf("chunk %d reopens the fence without its language: %q", i, chunk)
+		}
+		for line := range strings.SplitSeq(chunk, "\n") {
+			chunkLines[line] = true
+		}
+	}
+
+	// Chunks break only between lines, so no code line is cut.
+	for line := range strings.SplitSeq(content, "\n") {
+		if line != "" && !chunkLines[line] {
+			t.Errorf("line %q was lost or broken", line)
+		}
+	}
+}
+
+func TestSplitMessageSmart_PrefersParagraphs(t *testing.T) {
+	para := strings.TrimSpace(strings.Repeat("lorem ipsum dolor ", 5))
+	content := para + "\n\n" + para + "\n\n" + para
+	chunks := SplitMessageSmart(content, 200)
+
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if chunks[0] != para+"\n\n"+para || chunks[1] != para {
+		t.Errorf("expected a break between paragraphs, got %q", chunks)
+	}
+}
+
+func TestSplitMessageSmart_ShortAndLongLines(t *testing.T) {
+	if got := SplitMessageSmart("short", 100); len(got) != 1 || got[0] != "short" {
+		t.Errorf("short message = %q", got)
+	}
+	if got := SplitMessageSmart("", 100); got != nil {
+		t.Errorf("empty message = %q, want nil", got)
+	}
+
+	long := strings.Repeat("word ", 100)
+	for i, chunk := range SplitMessageSmart(long, 50) {
+		if n := utf8.RuneCountInString(chunk); n > 50 {
+			t.Errorf("chunk %d has %d runes", i, n)
+		}
+	}
+}
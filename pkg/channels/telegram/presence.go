@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/mymmrac/telego"
+
+	"github.com/sipeed/picoclaw/pkg/commands"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// maxShortDescriptionLen is Telegram's limit for a bot's short description.
+const maxShortDescriptionLen = 120
+
+// SetPresence implements channels.PresenceSetter. Telegram bots have no status
+// line; the closest is the short description shown on the bot's profile,
+// which status replaces. The command menu is synced as well, so /help and the
+// other commands appear even while startup registration is still retrying.
+func (c *TelegramChannel) SetPresence(ctx context.Context, status string) error {
+	if utf8.RuneCountInString(status) > maxShortDescriptionLen {
+		return fmt.Errorf("status is longer than %d characters", maxShortDescriptionLen)
+	}
+	err := c.bot.SetMyShortDescription(ctx, &telego.SetMyShortDescriptionParams{
+		ShortDescription: status,
+	})
+	if err != nil {
+		return fmt.Errorf("telegram set short description: %w", err)
+	}
+
+	if err := c.RegisterCommands(ctx, commands.BuiltinDefinitions()); err != nil {
+		logger.WarnCF("telegram", "Failed to sync bot commands", map[string]any{
+			"error": err.Error(),
+		})
+	}
+	return nil
+}
@@ -93,6 +93,10 @@ func NewTelegramChannel(cfg *config.Config, bus *bus.MessageBus) (*TelegramChann
 		channels.WithMaxMessageLength(4000),
 		channels.WithGroupTrigger(telegramCfg.GroupTrigger),
 		channels.WithReasoningChannelID(telegramCfg.ReasoningChannelID),
+		channels.WithGreeting(telegramCfg.Greeting),
+		channels.WithFooter(telegramCfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(telegramCfg.CoalesceWindowMS)*time.Millisecond),
+		channels.WithMediaTypes(telegramCfg.MediaTypesAllow, telegramCfg.MediaTypesDeny, telegramCfg.MediaRejectedNote),
 	)
 
 	return &TelegramChannel{
@@ -171,6 +175,7 @@ func (c *TelegramChannel) Send(ctx context.Context, msg bus.OutboundMessage) err
 	}
 
 	useMarkdownV2 := c.config.Channels.Telegram.UseMarkdownV2
+	disablePreview := msg.DisablePreview || c.config.Channels.Telegram.DisableLinkPreview
 
 	chatID, threadID, err := parseTelegramChatID(msg.ChatID)
 	if err != nil {
@@ -204,12 +209,13 @@ func (c *TelegramChannel) Send(ctx context.Context, msg bus.OutboundMessage) err
 
 			if smallerLen <= 0 {
 				if err := c.sendChunk(ctx, sendChunkParams{
-					chatID:        chatID,
-					threadID:      threadID,
-					content:       content,
-					replyToID:     replyToID,
-					mdFallback:    chunk,
-					useMarkdownV2: useMarkdownV2,
+					chatID:         chatID,
+					threadID:       threadID,
+					content:        content,
+					replyToID:      replyToID,
+					mdFallback:     chunk,
+					useMarkdownV2:  useMarkdownV2,
+					disablePreview: disablePreview,
 				}); err != nil {
 					return err
 				}
@@ -242,12 +248,13 @@ func (c *TelegramChannel) Send(ctx context.Context, msg bus.OutboundMessage) err
 		}
 
 		if err := c.sendChunk(ctx, sendChunkParams{
-			chatID:        chatID,
-			threadID:      threadID,
-			content:       content,
-			replyToID:     replyToID,
-			mdFallback:    chunk,
-			useMarkdownV2: useMarkdownV2,
+			chatID:         chatID,
+			threadID:       threadID,
+			content:        content,
+			replyToID:      replyToID,
+			mdFallback:     chunk,
+			useMarkdownV2:  useMarkdownV2,
+			disablePreview: disablePreview,
 		}); err != nil {
 			return err
 		}
@@ -259,12 +266,13 @@ func (c *TelegramChannel) Send(ctx context.Context, msg bus.OutboundMessage) err
 }
 
 type sendChunkParams struct {
-	chatID        int64
-	threadID      int
-	content       string
-	replyToID     string
-	mdFallback    string
-	useMarkdownV2 bool
+	chatID         int64
+	threadID       int
+	content        string
+	replyToID      string
+	mdFallback     string
+	useMarkdownV2  bool
+	disablePreview bool
 }
 
 // sendChunk sends a single HTML/MarkdownV2 message, falling back to the original
@@ -281,6 +289,10 @@ func (c *TelegramChannel) sendChunk(
 		tgMsg.WithParseMode(telego.ModeHTML)
 	}
 
+	if params.disablePreview {
+		tgMsg.LinkPreviewOptions = &telego.LinkPreviewOptions{IsDisabled: true}
+	}
+
 	if params.replyToID != "" {
 		if mid, parseErr := strconv.Atoi(params.replyToID); parseErr == nil {
 			tgMsg.ReplyParameters = &telego.ReplyParameters{
@@ -339,6 +351,24 @@ func (c *TelegramChannel) StartTyping(ctx context.Context, chatID string) (func(
 
 // EditMessage implements channels.MessageEditor.
 func (c *TelegramChannel) EditMessage(ctx context.Context, chatID string, messageID string, content string) error {
+	return c.editMessage(ctx, chatID, messageID, content, c.config.Channels.Telegram.DisableLinkPreview)
+}
+
+// EditMessageWithoutPreview implements channels.LinkPreviewEditor.
+func (c *TelegramChannel) EditMessageWithoutPreview(
+	ctx context.Context,
+	chatID string,
+	messageID string,
+	content string,
+) error {
+	return c.editMessage(ctx, chatID, messageID, content, true)
+}
+
+func (c *TelegramChannel) editMessage(
+	ctx context.Context,
+	chatID, messageID, content string,
+	disablePreview bool,
+) error {
 	useMarkdownV2 := c.config.Channels.Telegram.UseMarkdownV2
 	cid, _, err := parseTelegramChatID(chatID)
 	if err != nil {
@@ -355,10 +385,15 @@ func (c *TelegramChannel) EditMessage(ctx context.Context, chatID string, messag
 	} else {
 		editMsg.WithParseMode(telego.ModeHTML)
 	}
+	if disablePreview {
+		editMsg.LinkPreviewOptions = &telego.LinkPreviewOptions{IsDisabled: true}
+	}
 	_, err = c.bot.EditMessageText(ctx, editMsg)
 	if err != nil {
 		logParseFailed(err, useMarkdownV2)
-		_, err = c.bot.EditMessageText(ctx, tu.EditMessageText(tu.ID(cid), mid, content))
+		editMsg.Text = content
+		editMsg.ParseMode = ""
+		_, err = c.bot.EditMessageText(ctx, editMsg)
 	}
 
 	return err
@@ -553,7 +588,16 @@ func (c *TelegramChannel) handleMessage(ctx context.Context, message *telego.Mes
 		content += message.Caption
 	}
 
-	if len(message.Photo) > 0 {
+	var rejected []string
+	allowMedia := func(mediaType string) bool {
+		if c.AllowsMediaType(mediaType) {
+			return true
+		}
+		rejected = append(rejected, mediaType)
+		return false
+	}
+
+	if len(message.Photo) > 0 && allowMedia("image") {
 		photo := message.Photo[len(message.Photo)-1]
 		photoPath := c.downloadPhoto(ctx, photo.FileID)
 		if photoPath != "" {
@@ -565,7 +609,7 @@ func (c *TelegramChannel) handleMessage(ctx context.Context, message *telego.Mes
 		}
 	}
 
-	if message.Voice != nil {
+	if message.Voice != nil && allowMedia("audio") {
 		voicePath := c.downloadFile(ctx, message.Voice.FileID, ".ogg")
 		if voicePath != "" {
 			mediaPaths = append(mediaPaths, storeMedia(voicePath, "voice.ogg"))
@@ -577,7 +621,7 @@ func (c *TelegramChannel) handleMessage(ctx context.Context, message *telego.Mes
 		}
 	}
 
-	if message.Audio != nil {
+	if message.Audio != nil && allowMedia("audio") {
 		audioPath := c.downloadFile(ctx, message.Audio.FileID, ".mp3")
 		if audioPath != "" {
 			mediaPaths = append(mediaPaths, storeMedia(audioPath, "audio.mp3"))
@@ -588,7 +632,7 @@ func (c *TelegramChannel) handleMessage(ctx context.Context, message *telego.Mes
 		}
 	}
 
-	if message.Document != nil {
+	if message.Document != nil && allowMedia(utils.InferMediaType(message.Document.FileName, message.Document.MimeType)) {
 		docPath := c.downloadFile(ctx, message.Document.FileID, "")
 		if docPath != "" {
 			mediaPaths = append(mediaPaths, storeMedia(docPath, "document"))
@@ -599,33 +643,33 @@ func (c *TelegramChannel) handleMessage(ctx context.Context, message *telego.Mes
 		}
 	}
 
-	if content == "" {
+	if content == "" && len(rejected) == 0 {
 		content = "[empty message]"
 	}
 
+	// For forum topics, embed the thread ID as "chatID/threadID" so replies
+	// route to the correct topic and each topic gets its own session.
+	// Only forum groups (IsForum) are handled; regular group reply threads
+	// must share one session per group.
+	compositeChatID := fmt.Sprintf("%d", chatID)
+	threadID := message.MessageThreadID
+	if message.Chat.IsForum && threadID != 0 {
+		compositeChatID = fmt.Sprintf("%d/%d", chatID, threadID)
+	}
+
 	// In group chats, apply unified group trigger filtering
 	if message.Chat.Type != "private" {
 		isMentioned := c.isBotMentioned(message)
 		if isMentioned {
 			content = c.stripBotMention(content)
 		}
-		respond, cleaned := c.ShouldRespondInGroup(isMentioned, content)
+		respond, cleaned := c.ShouldRespondInGroup(compositeChatID, isMentioned, content)
 		if !respond {
 			return nil
 		}
 		content = cleaned
 	}
 
-	// For forum topics, embed the thread ID as "chatID/threadID" so replies
-	// route to the correct topic and each topic gets its own session.
-	// Only forum groups (IsForum) are handled; regular group reply threads
-	// must share one session per group.
-	compositeChatID := fmt.Sprintf("%d", chatID)
-	threadID := message.MessageThreadID
-	if message.Chat.IsForum && threadID != 0 {
-		compositeChatID = fmt.Sprintf("%d/%d", chatID, threadID)
-	}
-
 	logger.DebugCF("telegram", "Received message", map[string]any{
 		"sender_id": sender.CanonicalID,
 		"chat_id":   compositeChatID,
@@ -655,6 +699,10 @@ func (c *TelegramChannel) handleMessage(ctx context.Context, message *telego.Mes
 		metadata["parent_peer_kind"] = "topic"
 		metadata["parent_peer_id"] = fmt.Sprintf("%d", threadID)
 	}
+	addReplyMetadata(metadata, message)
+	if len(rejected) > 0 {
+		metadata[channels.MetadataMediaRejected] = strings.Join(rejected, ",")
+	}
 
 	c.HandleMessage(c.ctx,
 		peer,
@@ -837,3 +885,29 @@ func (c *TelegramChannel) stripBotMention(content string) string {
 	content = re.ReplaceAllString(content, "")
 	return strings.TrimSpace(content)
 }
+
+// addReplyMetadata records the message being replied to. A quoted excerpt is
+// preferred over the full text. Replies to the service message that opens a
+// forum topic are implicit and ignored.
+func addReplyMetadata(metadata map[string]string, message *telego.Message) {
+	reply := message.ReplyToMessage
+	if reply == nil || reply.ForumTopicCreated != nil {
+		return
+	}
+	metadata[bus.MetadataReplyToMessageID] = fmt.Sprintf("%d", reply.MessageID)
+	if reply.From != nil {
+		author := reply.From.Username
+		if author == "" {
+			author = reply.From.FirstName
+		}
+		metadata[bus.MetadataReplyToAuthor] = author
+	}
+	text := reply.Text
+	if text == "" {
+		text = reply.Caption
+	}
+	if message.Quote != nil && message.Quote.Text != "" {
+		text = message.Quote.Text
+	}
+	metadata[bus.MetadataReplyToText] = text
+}
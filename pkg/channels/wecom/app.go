@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -33,6 +34,7 @@ type WeComAppChannel struct {
 	*channels.BaseChannel
 	config        config.WeComAppConfig
 	client        *http.Client
+	apiBase       string
 	accessToken   string
 	tokenExpiry   time.Time
 	tokenMu       sync.RWMutex
@@ -127,6 +129,10 @@ func NewWeComAppChannel(cfg config.WeComAppConfig, messageBus *bus.MessageBus) (
 		channels.WithMaxMessageLength(2048),
 		channels.WithGroupTrigger(cfg.GroupTrigger),
 		channels.WithReasoningChannelID(cfg.ReasoningChannelID),
+		channels.WithGreeting(cfg.Greeting),
+		channels.WithFooter(cfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(cfg.CoalesceWindowMS)*time.Millisecond),
+		channels.WithMediaTypes(cfg.MediaTypesAllow, cfg.MediaTypesDeny, cfg.MediaRejectedNote),
 	)
 
 	// Client timeout must be >= the configured ReplyTimeout so the
@@ -141,6 +147,7 @@ func NewWeComAppChannel(cfg config.WeComAppConfig, messageBus *bus.MessageBus) (
 		BaseChannel:   base,
 		config:        cfg,
 		client:        &http.Client{Timeout: clientTimeout},
+		apiBase:       wecomAPIBase,
 		ctx:           ctx,
 		cancel:        cancel,
 		processedMsgs: NewMessageDeduplicator(wecomMaxProcessedMessages),
@@ -207,9 +214,22 @@ func (c *WeComAppChannel) Send(ctx context.Context, msg bus.OutboundMessage) err
 		"preview": utils.Truncate(msg.Content, 100),
 	})
 
+	if c.config.PreferMarkdown || looksLikeMarkdown(msg.Content) {
+		return c.sendMarkdownMessage(ctx, accessToken, msg.ChatID, msg.Content)
+	}
 	return c.sendTextMessage(ctx, accessToken, msg.ChatID, msg.Content)
 }
 
+// markdownPattern matches the markdown that WeCom renders and plain text
+// would show as noise: headings, bullet or numbered lists, and code fences.
+var markdownPattern = regexp.MustCompile("(?m)^(#{1,6} |\\s*[-*+] |\\s*\\d+\\. |\\s*```)")
+
+// looksLikeMarkdown reports whether content should be sent as a markdown
+// message rather than plain text.
+func looksLikeMarkdown(content string) bool {
+	return markdownPattern.MatchString(content)
+}
+
 // SendMedia implements the channels.MediaSender interface.
 func (c *WeComAppChannel) SendMedia(ctx context.Context, msg bus.OutboundMediaMessage) error {
 	if !c.IsRunning() {
@@ -286,7 +306,7 @@ func (c *WeComAppChannel) SendMedia(ctx context.Context, msg bus.OutboundMediaMe
 // uploadMedia uploads a local file to WeCom temporary media storage.
 func (c *WeComAppChannel) uploadMedia(ctx context.Context, accessToken, mediaType, localPath string) (string, error) {
 	apiURL := fmt.Sprintf("%s/cgi-bin/media/upload?access_token=%s&type=%s",
-		wecomAPIBase, url.QueryEscape(accessToken), url.QueryEscape(mediaType))
+		c.apiBase, url.QueryEscape(accessToken), url.QueryEscape(mediaType))
 
 	file, err := os.Open(localPath)
 	if err != nil {
@@ -352,7 +372,7 @@ func (c *WeComAppChannel) uploadMedia(ctx context.Context, accessToken, mediaTyp
 
 // sendWeComMessage marshals payload and POSTs it to the WeCom message API.
 func (c *WeComAppChannel) sendWeComMessage(ctx context.Context, accessToken string, payload any) error {
-	apiURL := fmt.Sprintf("%s/cgi-bin/message/send?access_token=%s", wecomAPIBase, accessToken)
+	apiURL := fmt.Sprintf("%s/cgi-bin/message/send?access_token=%s", c.apiBase, accessToken)
 
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
@@ -624,7 +644,7 @@ func (c *WeComAppChannel) processMessage(ctx context.Context, msg WeComXMLMessag
 	// Message deduplication: Use msg_id to prevent duplicate processing
 	// As per WeCom documentation, use msg_id for deduplication
 	msgID := fmt.Sprintf("%d", msg.MsgId)
-	if !c.processedMsgs.MarkMessageProcessed(msgID) {
+	if !c.processedMsgs.MarkMessageProcessed(msgID) || c.SeenBefore(msgID) {
 		logger.DebugCF("wecom_app", "Skipping duplicate message", map[string]any{
 			"msg_id": msgID,
 		})
@@ -649,6 +669,13 @@ func (c *WeComAppChannel) processMessage(ctx context.Context, msg WeComXMLMessag
 	}
 
 	content := msg.Content
+	mediaRefs, rejected := c.downloadInboundMedia(ctx, msg, chatID, messageID)
+	if rejected != "" {
+		metadata[channels.MetadataMediaRejected] = rejected
+	}
+	if len(mediaRefs) > 0 && content == "" {
+		content = "[" + msg.MsgType + "]"
+	}
 
 	logger.DebugCF("wecom_app", "Received message", map[string]any{
 		"sender_id": senderID,
@@ -664,7 +691,7 @@ func (c *WeComAppChannel) processMessage(ctx context.Context, msg WeComXMLMessag
 	}
 
 	// Handle the message through the base channel
-	c.HandleMessage(ctx, peer, messageID, senderID, chatID, content, nil, metadata, appSender)
+	c.HandleMessage(ctx, peer, messageID, senderID, chatID, content, mediaRefs, metadata, appSender)
 }
 
 // tokenRefreshLoop periodically refreshes the access token
@@ -689,7 +716,7 @@ func (c *WeComAppChannel) tokenRefreshLoop() {
 // refreshAccessToken gets a new access token from WeCom API
 func (c *WeComAppChannel) refreshAccessToken() error {
 	apiURL := fmt.Sprintf("%s/cgi-bin/gettoken?corpid=%s&corpsecret=%s",
-		wecomAPIBase, url.QueryEscape(c.config.CorpID), url.QueryEscape(c.config.CorpSecret))
+		c.apiBase, url.QueryEscape(c.config.CorpID), url.QueryEscape(c.config.CorpSecret))
 
 	resp, err := http.Get(apiURL)
 	if err != nil {
@@ -743,6 +770,17 @@ func (c *WeComAppChannel) sendTextMessage(ctx context.Context, accessToken, user
 	return c.sendWeComMessage(ctx, accessToken, msg)
 }
 
+// sendMarkdownMessage sends a markdown message to a user.
+func (c *WeComAppChannel) sendMarkdownMessage(ctx context.Context, accessToken, userID, content string) error {
+	msg := WeComMarkdownMessage{
+		ToUser:  userID,
+		MsgType: "markdown",
+		AgentID: c.config.AgentID,
+	}
+	msg.Markdown.Content = content
+	return c.sendWeComMessage(ctx, accessToken, msg)
+}
+
 // handleHealth handles health check requests
 func (c *WeComAppChannel) handleHealth(w http.ResponseWriter, r *http.Request) {
 	status := map[string]any{
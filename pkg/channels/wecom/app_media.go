@@ -0,0 +1,187 @@
+package wecom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/channels"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/media"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// wecomMaxMediaBytes bounds one downloaded inbound media file.
+const wecomMaxMediaBytes = 20 << 20
+
+// errWeComTokenExpired is returned when WeCom rejects the access token.
+var errWeComTokenExpired = errors.New("wecom access token expired")
+
+// isWeComTokenError reports whether an errcode means the access token is
+// missing, invalid or expired.
+func isWeComTokenError(code int) bool {
+	return code == 40014 || code == 41001 || code == 42001
+}
+
+// downloadInboundMedia downloads the image or voice attached to msg and
+// registers it with the media store. It returns no refs when the message
+// carries no media, no store is set, or the download fails. rejected names
+// the media type when the channel's media type filter refused it.
+func (c *WeComAppChannel) downloadInboundMedia(
+	ctx context.Context,
+	msg WeComXMLMessage,
+	chatID, messageID string,
+) (refs []string, rejected string) {
+	if msg.MediaId == "" {
+		return nil, ""
+	}
+	var mediaType, ext string
+	switch msg.MsgType {
+	case "image":
+		mediaType, ext = "image", ".jpg"
+	case "voice":
+		mediaType, ext = "audio", ".amr"
+		if msg.Format != "" {
+			ext = "." + strings.ToLower(msg.Format)
+		}
+	default:
+		return nil, ""
+	}
+	if !c.AllowsMediaType(mediaType) {
+		return nil, mediaType
+	}
+	store := c.GetMediaStore()
+	if store == nil {
+		return nil, ""
+	}
+
+	localPath, filename, err := c.downloadMedia(ctx, msg.MediaId, messageID, ext)
+	if err != nil {
+		logger.ErrorCF("wecom_app", "Failed to download media", map[string]any{
+			"media_id": msg.MediaId,
+			"error":    err.Error(),
+		})
+		return nil, ""
+	}
+
+	scope := channels.BuildMediaScope("wecom_app", chatID, messageID)
+	ref, err := store.Store(localPath, media.MediaMeta{
+		Filename: filename,
+		Source:   "wecom_app",
+	}, scope)
+	if err != nil {
+		logger.ErrorCF("wecom_app", "Failed to store downloaded media", map[string]any{
+			"media_id": msg.MediaId,
+			"error":    err.Error(),
+		})
+		os.Remove(localPath)
+		return nil, ""
+	}
+	return []string{ref}, ""
+}
+
+// downloadMedia fetches a temporary media file by media_id. When WeCom
+// rejects the access token, the token is refreshed and the download retried
+// once. It returns the local path and the file's name.
+func (c *WeComAppChannel) downloadMedia(
+	ctx context.Context,
+	mediaID, messageID, fallbackExt string,
+) (string, string, error) {
+	token := c.getAccessToken()
+	if token == "" {
+		if err := c.refreshAccessToken(); err != nil {
+			return "", "", err
+		}
+		token = c.getAccessToken()
+	}
+
+	localPath, filename, err := c.fetchMedia(ctx, token, mediaID, messageID, fallbackExt)
+	if errors.Is(err, errWeComTokenExpired) {
+		if refreshErr := c.refreshAccessToken(); refreshErr != nil {
+			return "", "", refreshErr
+		}
+		localPath, filename, err = c.fetchMedia(ctx, c.getAccessToken(), mediaID, messageID, fallbackExt)
+	}
+	return localPath, filename, err
+}
+
+// fetchMedia performs one /cgi-bin/media/get request and writes the body to
+// the shared media directory.
+func (c *WeComAppChannel) fetchMedia(
+	ctx context.Context,
+	accessToken, mediaID, messageID, fallbackExt string,
+) (string, string, error) {
+	apiURL := fmt.Sprintf("%s/cgi-bin/media/get?access_token=%s&media_id=%s",
+		c.apiBase, url.QueryEscape(accessToken), url.QueryEscape(mediaID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", "", channels.ClassifyNetError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("wecom media download failed: HTTP %d", resp.StatusCode)
+	}
+
+	// Errors come back as a JSON body instead of the file.
+	contentType := resp.Header.Get("Content-Type")
+	if strings.Contains(contentType, "json") || strings.HasPrefix(contentType, "text/plain") {
+		var result struct {
+			ErrCode int    `json:"errcode"`
+			ErrMsg  string `json:"errmsg"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&result); err != nil {
+			return "", "", fmt.Errorf("failed to parse media response: %w", err)
+		}
+		if isWeComTokenError(result.ErrCode) {
+			return "", "", errWeComTokenExpired
+		}
+		return "", "", fmt.Errorf("media API error: %s (code: %d)", result.ErrMsg, result.ErrCode)
+	}
+
+	filename := mediaID + fallbackExt
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := filepath.Base(params["filename"]); name != "" && name != "." && name != "/" {
+			filename = name
+		}
+	}
+
+	mediaDir := media.TempDir()
+	if err := os.MkdirAll(mediaDir, 0o700); err != nil {
+		return "", "", fmt.Errorf("failed to create media directory: %w", err)
+	}
+	ext := filepath.Ext(filename)
+	if ext == "" {
+		ext = fallbackExt
+	}
+	localPath := filepath.Join(mediaDir, utils.SanitizeFilename(messageID+"-"+mediaID+ext))
+
+	out, err := os.Create(localPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create local file: %w", err)
+	}
+	written, err := io.Copy(out, io.LimitReader(resp.Body, wecomMaxMediaBytes+1))
+	out.Close()
+	if err != nil {
+		os.Remove(localPath)
+		return "", "", fmt.Errorf("failed to write media: %w", err)
+	}
+	if written > wecomMaxMediaBytes {
+		os.Remove(localPath)
+		return "", "", fmt.Errorf("media too large (max %d bytes)", wecomMaxMediaBytes)
+	}
+	return localPath, filename, nil
+}
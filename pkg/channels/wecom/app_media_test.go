@@ -0,0 +1,163 @@
+package wecom
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/media"
+)
+
+func TestWeComAppDownloadsInboundImage(t *testing.T) {
+	imageData := []byte("\xff\xd8\xff fake jpeg")
+	var tokenRequests, mediaRequests int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/gettoken":
+			tokenRequests++
+			json.NewEncoder(w).Encode(WeComAccessTokenResponse{AccessToken: "fresh_token", ExpiresIn: 7200})
+		case "/cgi-bin/media/get":
+			mediaRequests++
+			if r.URL.Query().Get("media_id") != "media_123" {
+				t.Errorf("media_id = %q, want media_123", r.URL.Query().Get("media_id"))
+			}
+			if r.URL.Query().Get("access_token") != "fresh_token" {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]any{"errcode": 42001, "errmsg": "access_token expired"})
+				return
+			}
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("Content-Disposition", `attachment; filename="photo.jpg"`)
+			w.Write(imageData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	msgBus := bus.NewMessageBus()
+	ch, err := NewWeComAppChannel(config.WeComAppConfig{
+		CorpID:     "test_corp_id",
+		CorpSecret: "test_secret",
+		AgentID:    1000002,
+	}, msgBus)
+	if err != nil {
+		t.Fatalf("NewWeComAppChannel() error = %v", err)
+	}
+	ch.apiBase = ts.URL
+	store := media.NewFileMediaStore()
+	ch.SetMediaStore(store)
+
+	// A token WeCom has already revoked forces a refresh and one retry.
+	ch.tokenMu.Lock()
+	ch.accessToken = "stale_token"
+	ch.tokenExpiry = time.Now().Add(time.Hour)
+	ch.tokenMu.Unlock()
+
+	ch.processMessage(context.Background(), WeComXMLMessage{
+		FromUserName: "user123",
+		MsgType:      "image",
+		MediaId:      "media_123",
+		MsgId:        98765,
+		AgentID:      1000002,
+	})
+
+	var msg bus.InboundMessage
+	select {
+	case msg = <-msgBus.InboundChan():
+	case <-time.After(2 * time.Second):
+		t.Fatal("no inbound message published")
+	}
+
+	if tokenRequests != 1 || mediaRequests != 2 {
+		t.Errorf("token requests = %d, media requests = %d, want 1 and 2", tokenRequests, mediaRequests)
+	}
+	if len(msg.Media) != 1 {
+		t.Fatalf("Media = %v, want one ref", msg.Media)
+	}
+	if msg.Content != "[image]" {
+		t.Errorf("Content = %q, want [image]", msg.Content)
+	}
+	path, meta, err := store.ResolveWithMeta(msg.Media[0])
+	if err != nil {
+		t.Fatalf("ResolveWithMeta() error = %v", err)
+	}
+	defer os.Remove(path)
+	if meta.Filename != "photo.jpg" {
+		t.Errorf("Filename = %q, want photo.jpg", meta.Filename)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(imageData) {
+		t.Errorf("stored content = %q, want %q", got, imageData)
+	}
+}
+
+func TestWeComAppSkipsMediaWithoutStore(t *testing.T) {
+	ch, _ := NewWeComAppChannel(config.WeComAppConfig{
+		CorpID:     "test_corp_id",
+		CorpSecret: "test_secret",
+		AgentID:    1000002,
+	}, bus.NewMessageBus())
+
+	refs, _ := ch.downloadInboundMedia(context.Background(), WeComXMLMessage{
+		MsgType: "voice",
+		MediaId: "media_123",
+	}, "user123", "1")
+	if refs != nil {
+		t.Errorf("refs = %v, want nil without a media store", refs)
+	}
+}
+
+func TestWeComAppRejectsDisallowedMediaType(t *testing.T) {
+	var mediaRequests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaRequests++
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	msgBus := bus.NewMessageBus()
+	ch, err := NewWeComAppChannel(config.WeComAppConfig{
+		CorpID:            "test_corp_id",
+		CorpSecret:        "test_secret",
+		AgentID:           1000002,
+		MediaTypesDeny:    config.FlexibleStringSlice{"image"},
+		MediaRejectedNote: "No images, please.",
+	}, msgBus)
+	if err != nil {
+		t.Fatalf("NewWeComAppChannel() error = %v", err)
+	}
+	ch.apiBase = ts.URL
+	ch.SetMediaStore(media.NewFileMediaStore())
+
+	ch.processMessage(context.Background(), WeComXMLMessage{
+		FromUserName: "user123",
+		MsgType:      "image",
+		MediaId:      "media_123",
+		MsgId:        98766,
+		AgentID:      1000002,
+	})
+
+	select {
+	case out := <-msgBus.OutboundChan():
+		if out.Content != "No images, please." || out.ChatID != "user123" {
+			t.Errorf("reply = %+v, want the rejection note to user123", out)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no rejection note sent")
+	}
+	select {
+	case msg := <-msgBus.InboundChan():
+		t.Errorf("image-only message reached the agent: %+v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if mediaRequests != 0 {
+		t.Errorf("media requests = %d, want none for a refused type", mediaRequests)
+	}
+}
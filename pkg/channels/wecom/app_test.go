@@ -3,89 +3,20 @@ package wecom
 import (
 	"bytes"
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/sha1"
 	"encoding/base64"
-	"encoding/binary"
 	"encoding/json"
 	"encoding/xml"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/channels/wecom/wecomtest"
 	"github.com/sipeed/picoclaw/pkg/config"
 )
 
-// generateTestAESKeyApp generates a valid test AES key for WeCom App
-func generateTestAESKeyApp() string {
-	// AES key needs to be 32 bytes (256 bits) for AES-256
-	key := make([]byte, 32)
-	for i := range key {
-		key[i] = byte(i + 1)
-	}
-	// Return base64 encoded key without padding
-	return base64.StdEncoding.EncodeToString(key)[:43]
-}
-
-// encryptTestMessageApp encrypts a message for testing WeCom App
-func encryptTestMessageApp(message, aesKey string) (string, error) {
-	// Decode AES key
-	key, err := base64.StdEncoding.DecodeString(aesKey + "=")
-	if err != nil {
-		return "", err
-	}
-
-	// Prepare message: random(16) + msg_len(4) + msg + corp_id
-	random := make([]byte, 0, 16)
-	for i := range 16 {
-		random = append(random, byte(i+1))
-	}
-
-	msgBytes := []byte(message)
-	corpID := []byte("test_corp_id")
-
-	msgLen := uint32(len(msgBytes))
-	lenBytes := make([]byte, 4)
-	binary.BigEndian.PutUint32(lenBytes, msgLen)
-
-	plainText := append(random, lenBytes...)
-	plainText = append(plainText, msgBytes...)
-	plainText = append(plainText, corpID...)
-
-	// PKCS7 padding
-	blockSize := aes.BlockSize
-	padding := blockSize - len(plainText)%blockSize
-	padText := bytes.Repeat([]byte{byte(padding)}, padding)
-	plainText = append(plainText, padText...)
-
-	// Encrypt
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	mode := cipher.NewCBCEncrypter(block, key[:aes.BlockSize])
-	cipherText := make([]byte, len(plainText))
-	mode.CryptBlocks(cipherText, plainText)
-
-	return base64.StdEncoding.EncodeToString(cipherText), nil
-}
-
-// generateSignatureApp generates a signature for testing WeCom App
-func generateSignatureApp(token, timestamp, nonce, msgEncrypt string) string {
-	params := []string{token, timestamp, nonce, msgEncrypt}
-	sort.Strings(params)
-	str := strings.Join(params, "")
-	hash := sha1.Sum([]byte(str))
-	return fmt.Sprintf("%x", hash)
-}
-
 func TestNewWeComAppChannel(t *testing.T) {
 	msgBus := bus.NewMessageBus()
 
@@ -192,7 +123,7 @@ func TestWeComAppVerifySignature(t *testing.T) {
 		timestamp := "1234567890"
 		nonce := "test_nonce"
 		msgEncrypt := "test_message"
-		expectedSig := generateSignatureApp("test_token", timestamp, nonce, msgEncrypt)
+		expectedSig := wecomtest.Sign("test_token", timestamp, nonce, msgEncrypt)
 
 		if !verifySignature(ch.config.Token, expectedSig, timestamp, nonce, msgEncrypt) {
 			t.Error("valid signature should pass verification")
@@ -250,7 +181,7 @@ func TestWeComAppDecryptMessage(t *testing.T) {
 	})
 
 	t.Run("decrypt with AES key", func(t *testing.T) {
-		aesKey := generateTestAESKeyApp()
+		aesKey := wecomtest.AESKey()
 		cfg := config.WeComAppConfig{
 			CorpID:         "test_corp_id",
 			CorpSecret:     "test_secret",
@@ -260,7 +191,7 @@ func TestWeComAppDecryptMessage(t *testing.T) {
 		ch, _ := NewWeComAppChannel(cfg, msgBus)
 
 		originalMsg := "<xml><Content>Hello</Content></xml>"
-		encrypted, err := encryptTestMessageApp(originalMsg, aesKey)
+		encrypted, err := wecomtest.Encrypt(originalMsg, aesKey, "test_corp_id")
 		if err != nil {
 			t.Fatalf("failed to encrypt test message: %v", err)
 		}
@@ -305,7 +236,7 @@ func TestWeComAppDecryptMessage(t *testing.T) {
 	})
 
 	t.Run("ciphertext too short", func(t *testing.T) {
-		aesKey := generateTestAESKeyApp()
+		aesKey := wecomtest.AESKey()
 		cfg := config.WeComAppConfig{
 			CorpID:         "test_corp_id",
 			CorpSecret:     "test_secret",
@@ -325,7 +256,7 @@ func TestWeComAppDecryptMessage(t *testing.T) {
 
 func TestWeComAppHandleVerification(t *testing.T) {
 	msgBus := bus.NewMessageBus()
-	aesKey := generateTestAESKeyApp()
+	aesKey := wecomtest.AESKey()
 	cfg := config.WeComAppConfig{
 		CorpID:         "test_corp_id",
 		CorpSecret:     "test_secret",
@@ -337,16 +268,11 @@ func TestWeComAppHandleVerification(t *testing.T) {
 
 	t.Run("valid verification request", func(t *testing.T) {
 		echostr := "test_echostr_123"
-		encryptedEchostr, _ := encryptTestMessageApp(echostr, aesKey)
-		timestamp := "1234567890"
-		nonce := "test_nonce"
-		signature := generateSignatureApp("test_token", timestamp, nonce, encryptedEchostr)
-
-		req := httptest.NewRequest(
-			http.MethodGet,
-			"/webhook/wecom-app?msg_signature="+signature+"&timestamp="+timestamp+"&nonce="+nonce+"&echostr="+encryptedEchostr,
-			nil,
-		)
+		callback := wecomtest.Callback{Token: "test_token", EncodingAESKey: aesKey, ReceiveID: "test_corp_id"}
+		req, err := callback.VerifyRequest("/webhook/wecom-app", echostr)
+		if err != nil {
+			t.Fatalf("VerifyRequest() error = %v", err)
+		}
 		w := httptest.NewRecorder()
 
 		ch.handleVerification(context.Background(), w, req)
@@ -372,7 +298,7 @@ func TestWeComAppHandleVerification(t *testing.T) {
 
 	t.Run("invalid signature", func(t *testing.T) {
 		echostr := "test_echostr"
-		encryptedEchostr, _ := encryptTestMessageApp(echostr, aesKey)
+		encryptedEchostr, _ := wecomtest.Encrypt(echostr, aesKey, "test_corp_id")
 		timestamp := "1234567890"
 		nonce := "test_nonce"
 
@@ -393,7 +319,7 @@ func TestWeComAppHandleVerification(t *testing.T) {
 
 func TestWeComAppHandleMessageCallback(t *testing.T) {
 	msgBus := bus.NewMessageBus()
-	aesKey := generateTestAESKeyApp()
+	aesKey := wecomtest.AESKey()
 	cfg := config.WeComAppConfig{
 		CorpID:         "test_corp_id",
 		CorpSecret:     "test_secret",
@@ -416,27 +342,11 @@ func TestWeComAppHandleMessageCallback(t *testing.T) {
 		}
 		xmlData, _ := xml.Marshal(xmlMsg)
 
-		// Encrypt message
-		encrypted, _ := encryptTestMessageApp(string(xmlData), aesKey)
-
-		// Create encrypted XML wrapper
-		encryptedWrapper := struct {
-			XMLName xml.Name `xml:"xml"`
-			Encrypt string   `xml:"Encrypt"`
-		}{
-			Encrypt: encrypted,
+		callback := wecomtest.Callback{Token: "test_token", EncodingAESKey: aesKey, ReceiveID: "test_corp_id"}
+		req, err := callback.MessageRequest("/webhook/wecom-app", string(xmlData))
+		if err != nil {
+			t.Fatalf("MessageRequest() error = %v", err)
 		}
-		wrapperData, _ := xml.Marshal(encryptedWrapper)
-
-		timestamp := "1234567890"
-		nonce := "test_nonce"
-		signature := generateSignatureApp("test_token", timestamp, nonce, encrypted)
-
-		req := httptest.NewRequest(
-			http.MethodPost,
-			"/webhook/wecom-app?msg_signature="+signature+"&timestamp="+timestamp+"&nonce="+nonce,
-			bytes.NewReader(wrapperData),
-		)
 		w := httptest.NewRecorder()
 
 		ch.handleMessageCallback(context.Background(), w, req)
@@ -463,7 +373,7 @@ func TestWeComAppHandleMessageCallback(t *testing.T) {
 	t.Run("invalid XML", func(t *testing.T) {
 		timestamp := "1234567890"
 		nonce := "test_nonce"
-		signature := generateSignatureApp("test_token", timestamp, nonce, "")
+		signature := wecomtest.Sign("test_token", timestamp, nonce, "")
 
 		req := httptest.NewRequest(
 			http.MethodPost,
@@ -607,7 +517,7 @@ func TestWeComAppHandleWebhook(t *testing.T) {
 		encoded := base64.StdEncoding.EncodeToString([]byte(echostr))
 		timestamp := "1234567890"
 		nonce := "test_nonce"
-		signature := generateSignatureApp("test_token", timestamp, nonce, encoded)
+		signature := wecomtest.Sign("test_token", timestamp, nonce, encoded)
 
 		req := httptest.NewRequest(
 			http.MethodGet,
@@ -634,7 +544,7 @@ func TestWeComAppHandleWebhook(t *testing.T) {
 
 		timestamp := "1234567890"
 		nonce := "test_nonce"
-		signature := generateSignatureApp("test_token", timestamp, nonce, encryptedWrapper.Encrypt)
+		signature := wecomtest.Sign("test_token", timestamp, nonce, encryptedWrapper.Encrypt)
 
 		req := httptest.NewRequest(
 			http.MethodPost,
@@ -1067,3 +977,73 @@ func TestWeComAppXMLMessageEvent(t *testing.T) {
 		t.Errorf("EventKey = %q, want %q", msg.EventKey, "event_key_123")
 	}
 }
+
+func TestLooksLikeMarkdown(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"Hello there", false},
+		{"Costs 3 - 2 = 1, see #42", false},
+		{"# Summary\nAll good", true},
+		{"Steps:\n- build\n- test", true},
+		{"Steps:\n1. build\n2. test", true},
+		{"Run:\n```bash\nmake\n```", true},
+		{"#hashtag only", false},
+	}
+	for _, tt := range tests {
+		if got := looksLikeMarkdown(tt.content); got != tt.want {
+			t.Errorf("looksLikeMarkdown(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestWeComAppSendChoosesFormat(t *testing.T) {
+	var bodies []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer ts.Close()
+
+	newChannel := func(preferMarkdown bool) *WeComAppChannel {
+		ch, _ := NewWeComAppChannel(config.WeComAppConfig{
+			CorpID:         "test_corp_id",
+			CorpSecret:     "test_secret",
+			AgentID:        1000002,
+			PreferMarkdown: preferMarkdown,
+		}, bus.NewMessageBus())
+		ch.apiBase = ts.URL
+		ch.accessToken = "token"
+		ch.tokenExpiry = time.Now().Add(time.Hour)
+		ch.SetRunning(true)
+		return ch
+	}
+
+	ctx := context.Background()
+	if err := newChannel(false).Send(ctx, bus.OutboundMessage{ChatID: "user1", Content: "plain reply"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := newChannel(false).Send(ctx, bus.OutboundMessage{ChatID: "user1", Content: "## Plan\n- a\n- b"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := newChannel(true).Send(ctx, bus.OutboundMessage{ChatID: "user1", Content: "plain reply"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	want := []string{"text", "markdown", "markdown"}
+	if len(bodies) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(bodies), len(want))
+	}
+	for i, body := range bodies {
+		if body["msgtype"] != want[i] {
+			t.Errorf("request %d msgtype = %v, want %s", i, body["msgtype"], want[i])
+		}
+	}
+	md, _ := bodies[1]["markdown"].(map[string]any)
+	if md["content"] != "## Plan\n- a\n- b" || bodies[1]["touser"] != "user1" || bodies[1]["agentid"] != float64(1000002) {
+		t.Errorf("markdown payload = %v", bodies[1])
+	}
+}
@@ -3,88 +3,19 @@ package wecom
 import (
 	"bytes"
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/sha1"
 	"encoding/base64"
-	"encoding/binary"
 	"encoding/json"
 	"encoding/xml"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"sort"
 	"strings"
 	"testing"
 
 	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/channels/wecom/wecomtest"
 	"github.com/sipeed/picoclaw/pkg/config"
 )
 
-// generateTestAESKey generates a valid test AES key
-func generateTestAESKey() string {
-	// AES key needs to be 32 bytes (256 bits) for AES-256
-	key := make([]byte, 32)
-	for i := range key {
-		key[i] = byte(i)
-	}
-	// Return base64 encoded key without padding
-	return base64.StdEncoding.EncodeToString(key)[:43]
-}
-
-// encryptTestMessage encrypts a message for testing (AIBOT JSON format)
-func encryptTestMessage(message, aesKey string) (string, error) {
-	// Decode AES key
-	key, err := base64.StdEncoding.DecodeString(aesKey + "=")
-	if err != nil {
-		return "", err
-	}
-
-	// Prepare message: random(16) + msg_len(4) + msg + receiveid
-	random := make([]byte, 0, 16)
-	for i := range 16 {
-		random = append(random, byte(i))
-	}
-
-	msgBytes := []byte(message)
-	receiveID := []byte("test_aibot_id")
-
-	msgLen := uint32(len(msgBytes))
-	lenBytes := make([]byte, 4)
-	binary.BigEndian.PutUint32(lenBytes, msgLen)
-
-	plainText := append(random, lenBytes...)
-	plainText = append(plainText, msgBytes...)
-	plainText = append(plainText, receiveID...)
-
-	// PKCS7 padding
-	blockSize := aes.BlockSize
-	padding := blockSize - len(plainText)%blockSize
-	padText := bytes.Repeat([]byte{byte(padding)}, padding)
-	plainText = append(plainText, padText...)
-
-	// Encrypt
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	mode := cipher.NewCBCEncrypter(block, key[:aes.BlockSize])
-	cipherText := make([]byte, len(plainText))
-	mode.CryptBlocks(cipherText, plainText)
-
-	return base64.StdEncoding.EncodeToString(cipherText), nil
-}
-
-// generateSignature generates a signature for testing
-func generateSignature(token, timestamp, nonce, msgEncrypt string) string {
-	params := []string{token, timestamp, nonce, msgEncrypt}
-	sort.Strings(params)
-	str := strings.Join(params, "")
-	hash := sha1.Sum([]byte(str))
-	return fmt.Sprintf("%x", hash)
-}
-
 func TestNewWeComBotChannel(t *testing.T) {
 	msgBus := bus.NewMessageBus()
 
@@ -172,7 +103,7 @@ func TestWeComBotVerifySignature(t *testing.T) {
 		timestamp := "1234567890"
 		nonce := "test_nonce"
 		msgEncrypt := "test_message"
-		expectedSig := generateSignature("test_token", timestamp, nonce, msgEncrypt)
+		expectedSig := wecomtest.Sign("test_token", timestamp, nonce, msgEncrypt)
 
 		if !verifySignature(ch.config.Token, expectedSig, timestamp, nonce, msgEncrypt) {
 			t.Error("valid signature should pass verification")
@@ -229,7 +160,7 @@ func TestWeComBotDecryptMessage(t *testing.T) {
 	})
 
 	t.Run("decrypt with AES key", func(t *testing.T) {
-		aesKey := generateTestAESKey()
+		aesKey := wecomtest.AESKey()
 		cfg := config.WeComConfig{
 			Token:          "test_token",
 			WebhookURL:     "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=test",
@@ -238,7 +169,7 @@ func TestWeComBotDecryptMessage(t *testing.T) {
 		ch, _ := NewWeComBotChannel(cfg, msgBus)
 
 		originalMsg := "<xml><Content>Hello</Content></xml>"
-		encrypted, err := encryptTestMessage(originalMsg, aesKey)
+		encrypted, err := wecomtest.Encrypt(originalMsg, aesKey, "test_aibot_id")
 		if err != nil {
 			t.Fatalf("failed to encrypt test message: %v", err)
 		}
@@ -337,7 +268,7 @@ func TestWeComBotPKCS7Unpad(t *testing.T) {
 
 func TestWeComBotHandleVerification(t *testing.T) {
 	msgBus := bus.NewMessageBus()
-	aesKey := generateTestAESKey()
+	aesKey := wecomtest.AESKey()
 	cfg := config.WeComConfig{
 		Token:          "test_token",
 		EncodingAESKey: aesKey,
@@ -347,16 +278,11 @@ func TestWeComBotHandleVerification(t *testing.T) {
 
 	t.Run("valid verification request", func(t *testing.T) {
 		echostr := "test_echostr_123"
-		encryptedEchostr, _ := encryptTestMessage(echostr, aesKey)
-		timestamp := "1234567890"
-		nonce := "test_nonce"
-		signature := generateSignature("test_token", timestamp, nonce, encryptedEchostr)
-
-		req := httptest.NewRequest(
-			http.MethodGet,
-			"/webhook/wecom?msg_signature="+signature+"&timestamp="+timestamp+"&nonce="+nonce+"&echostr="+encryptedEchostr,
-			nil,
-		)
+		callback := wecomtest.Callback{Token: "test_token", EncodingAESKey: aesKey, ReceiveID: "test_aibot_id"}
+		req, err := callback.VerifyRequest("/webhook/wecom", echostr)
+		if err != nil {
+			t.Fatalf("VerifyRequest() error = %v", err)
+		}
 		w := httptest.NewRecorder()
 
 		ch.handleVerification(context.Background(), w, req)
@@ -382,7 +308,7 @@ func TestWeComBotHandleVerification(t *testing.T) {
 
 	t.Run("invalid signature", func(t *testing.T) {
 		echostr := "test_echostr"
-		encryptedEchostr, _ := encryptTestMessage(echostr, aesKey)
+		encryptedEchostr, _ := wecomtest.Encrypt(echostr, aesKey, "test_aibot_id")
 		timestamp := "1234567890"
 		nonce := "test_nonce"
 
@@ -403,7 +329,7 @@ func TestWeComBotHandleVerification(t *testing.T) {
 
 func TestWeComBotHandleMessageCallback(t *testing.T) {
 	msgBus := bus.NewMessageBus()
-	aesKey := generateTestAESKey()
+	aesKey := wecomtest.AESKey()
 	cfg := config.WeComConfig{
 		Token:          "test_token",
 		EncodingAESKey: aesKey,
@@ -413,22 +339,11 @@ func TestWeComBotHandleMessageCallback(t *testing.T) {
 
 	runBotMessageCallback := func(t *testing.T, jsonMsg string) *httptest.ResponseRecorder {
 		t.Helper()
-		encrypted, _ := encryptTestMessage(jsonMsg, aesKey)
-		encryptedWrapper := struct {
-			XMLName xml.Name `xml:"xml"`
-			Encrypt string   `xml:"Encrypt"`
-		}{
-			Encrypt: encrypted,
+		callback := wecomtest.Callback{Token: "test_token", EncodingAESKey: aesKey, ReceiveID: "test_aibot_id"}
+		req, err := callback.MessageRequest("/webhook/wecom", jsonMsg)
+		if err != nil {
+			t.Fatalf("MessageRequest() error = %v", err)
 		}
-		wrapperData, _ := xml.Marshal(encryptedWrapper)
-		timestamp := "1234567890"
-		nonce := "test_nonce"
-		signature := generateSignature("test_token", timestamp, nonce, encrypted)
-		req := httptest.NewRequest(
-			http.MethodPost,
-			"/webhook/wecom?msg_signature="+signature+"&timestamp="+timestamp+"&nonce="+nonce,
-			bytes.NewReader(wrapperData),
-		)
 		w := httptest.NewRecorder()
 		ch.handleMessageCallback(context.Background(), w, req)
 		return w
@@ -485,7 +400,7 @@ func TestWeComBotHandleMessageCallback(t *testing.T) {
 	t.Run("invalid XML", func(t *testing.T) {
 		timestamp := "1234567890"
 		nonce := "test_nonce"
-		signature := generateSignature("test_token", timestamp, nonce, "")
+		signature := wecomtest.Sign("test_token", timestamp, nonce, "")
 
 		req := httptest.NewRequest(
 			http.MethodPost,
@@ -610,7 +525,7 @@ func TestWeComBotHandleWebhook(t *testing.T) {
 		encoded := base64.StdEncoding.EncodeToString([]byte(echostr))
 		timestamp := "1234567890"
 		nonce := "test_nonce"
-		signature := generateSignature("test_token", timestamp, nonce, encoded)
+		signature := wecomtest.Sign("test_token", timestamp, nonce, encoded)
 
 		req := httptest.NewRequest(
 			http.MethodGet,
@@ -637,7 +552,7 @@ func TestWeComBotHandleWebhook(t *testing.T) {
 
 		timestamp := "1234567890"
 		nonce := "test_nonce"
-		signature := generateSignature("test_token", timestamp, nonce, encryptedWrapper.Encrypt)
+		signature := wecomtest.Sign("test_token", timestamp, nonce, encryptedWrapper.Encrypt)
 
 		req := httptest.NewRequest(
 			http.MethodPost,
@@ -0,0 +1,50 @@
+package wecomtest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := AESKey()
+	encrypt, err := Encrypt("<xml>hi</xml>", key, "corp")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(key + "=")
+	data, _ := base64.StdEncoding.DecodeString(encrypt)
+	if len(data)%paddingBlockSize != 0 {
+		t.Fatalf("ciphertext length %d is not padded to %d", len(data), paddingBlockSize)
+	}
+	block, _ := aes.NewCipher(raw)
+	cipher.NewCBCDecrypter(block, raw[:aes.BlockSize]).CryptBlocks(data, data)
+	data = data[:len(data)-int(data[len(data)-1])]
+
+	n := binary.BigEndian.Uint32(data[16:20])
+	if msg := string(data[20 : 20+n]); msg != "<xml>hi</xml>" {
+		t.Errorf("message = %q", msg)
+	}
+	if id := string(data[20+n:]); id != "corp" {
+		t.Errorf("receive id = %q, want corp", id)
+	}
+}
+
+func TestEncryptRejectsBadKey(t *testing.T) {
+	if _, err := Encrypt("x", "short", "corp"); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
+
+func TestCallbackQueryIsSigned(t *testing.T) {
+	q := Callback{Token: "tok"}.Query("cipher")
+	if q.Get("timestamp") != DefaultTimestamp || q.Get("nonce") != DefaultNonce {
+		t.Errorf("defaults not applied: %v", q)
+	}
+	if q.Get("msg_signature") != Sign("tok", DefaultTimestamp, DefaultNonce, "cipher") {
+		t.Errorf("msg_signature = %q", q.Get("msg_signature"))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,6 +37,10 @@ func NewWhatsAppChannel(cfg config.WhatsAppConfig, bus *bus.MessageBus) (*WhatsA
 		cfg.AllowFrom,
 		channels.WithMaxMessageLength(65536),
 		channels.WithReasoningChannelID(cfg.ReasoningChannelID),
+		channels.WithGreeting(cfg.Greeting),
+		channels.WithFooter(cfg.Footer),
+		channels.WithCoalesceWindow(time.Duration(cfg.CoalesceWindowMS)*time.Millisecond),
+		channels.WithMediaTypes(cfg.MediaTypesAllow, cfg.MediaTypesDeny, cfg.MediaRejectedNote),
 	)
 
 	return &WhatsAppChannel{
@@ -204,17 +209,28 @@ func (c *WhatsAppChannel) handleIncomingMessage(msg map[string]any) {
 		content = ""
 	}
 
-	var mediaPaths []string
+	// The bridge has already downloaded the media, so the type filter can
+	// only go by file name here.
+	var mediaPaths, rejected []string
 	if mediaData, ok := msg["media"].([]any); ok {
 		mediaPaths = make([]string, 0, len(mediaData))
 		for _, m := range mediaData {
-			if path, ok := m.(string); ok {
-				mediaPaths = append(mediaPaths, path)
+			path, ok := m.(string)
+			if !ok {
+				continue
 			}
+			if mediaType := utils.InferMediaType(path, ""); !c.AllowsMediaType(mediaType) {
+				rejected = append(rejected, mediaType)
+				continue
+			}
+			mediaPaths = append(mediaPaths, path)
 		}
 	}
 
 	metadata := make(map[string]string)
+	if len(rejected) > 0 {
+		metadata[channels.MetadataMediaRejected] = strings.Join(rejected, ",")
+	}
 	var messageID string
 	if mid, ok := msg["id"].(string); ok {
 		messageID = mid
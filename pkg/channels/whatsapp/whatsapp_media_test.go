@@ -0,0 +1,39 @@
+package whatsapp
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestHandleIncomingMessage_DropsDeniedMediaTypes(t *testing.T) {
+	messageBus := bus.NewMessageBus()
+	ch, err := NewWhatsAppChannel(config.WhatsAppConfig{
+		BridgeURL:      "ws://localhost:3001",
+		MediaTypesDeny: config.FlexibleStringSlice{"image"},
+	}, messageBus)
+	if err != nil {
+		t.Fatalf("NewWhatsAppChannel() error = %v", err)
+	}
+	ch.ctx = context.Background()
+
+	ch.handleIncomingMessage(map[string]any{
+		"type":    "message",
+		"id":      "mid1",
+		"from":    "user1",
+		"chat":    "user1",
+		"content": "see attached",
+		"media":   []any{"/tmp/photo.jpg", "/tmp/report.pdf"},
+	})
+
+	inbound := <-messageBus.InboundChan()
+	if !slices.Equal(inbound.Media, []string{"/tmp/report.pdf"}) {
+		t.Errorf("Media = %v, want only the PDF", inbound.Media)
+	}
+	if inbound.Metadata["media_rejected"] != "image" {
+		t.Errorf("media_rejected = %q, want image", inbound.Metadata["media_rejected"])
+	}
+}
@@ -10,8 +10,20 @@ func BuiltinDefinitions() []Definition {
 		helpCommand(),
 		showCommand(),
 		listCommand(),
+		agentsCommand(),
+		modelsCommand(),
 		switchCommand(),
 		checkCommand(),
 		clearCommand(),
+		summaryCommand(),
+		langCommand(),
+		modeCommand(),
+		pinCommand(),
+		unpinCommand(),
+		groupCommand(),
+		diagCommand(),
+		presenceCommand(),
+		continueCommand(),
+		heartbeatCommand(),
 	}
 }
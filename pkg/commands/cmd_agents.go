@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AgentInfo describes one registered agent for /agents info.
+type AgentInfo struct {
+	ID            string
+	Name          string
+	Default       bool
+	Model         string
+	MaxTokens     int
+	Temperature   float64
+	MaxIterations int
+	Tools         []string
+}
+
+func agentsCommand() Definition {
+	return Definition{
+		Name:        "agents",
+		Description: "Inspect registered agents",
+		SubCommands: []SubCommand{
+			{
+				Name:        "list",
+				Description: "Registered agents",
+				Handler:     agentsHandler(),
+			},
+			{
+				Name:        "info",
+				Description: "Model and tools of an agent",
+				ArgsUsage:   "[<id>]",
+				Handler: func(_ context.Context, req Request, rt *Runtime) error {
+					if rt == nil || rt.GetAgentInfo == nil {
+						return req.Reply(unavailableMsg)
+					}
+					id := nthToken(req.Text, 2)
+					info, ok := rt.GetAgentInfo(id)
+					if !ok {
+						if id == "" {
+							return req.Reply("No agents registered")
+						}
+						return req.Reply(fmt.Sprintf("Unknown agent: %s", id))
+					}
+					return req.Reply(formatAgentInfo(info))
+				},
+			},
+		},
+	}
+}
+
+func formatAgentInfo(info AgentInfo) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Agent: %s", info.ID)
+	if info.Name != "" {
+		fmt.Fprintf(&sb, " (%s)", info.Name)
+	}
+	if info.Default {
+		sb.WriteString(" [default]")
+	}
+	fmt.Fprintf(&sb, "\nModel: %s", info.Model)
+	fmt.Fprintf(&sb, "\nMax tokens: %d", info.MaxTokens)
+	fmt.Fprintf(&sb, "\nTemperature: %g", info.Temperature)
+	fmt.Fprintf(&sb, "\nMax iterations: %d", info.MaxIterations)
+	if len(info.Tools) == 0 {
+		sb.WriteString("\nTools: none")
+	} else {
+		fmt.Fprintf(&sb, "\nTools (%d): %s", len(info.Tools), strings.Join(info.Tools, ", "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAgentsInfo(t *testing.T) {
+	agents := map[string]AgentInfo{
+		"main": {
+			ID: "main", Default: true, Model: "gpt-5.4", MaxTokens: 8192, Temperature: 0.7,
+			MaxIterations: 20, Tools: []string{"exec", "read_file"},
+		},
+		"research": {ID: "research", Name: "Research", Model: "claude-sonnet", MaxTokens: 4096, MaxIterations: 60},
+	}
+	var requested string
+	rt := &Runtime{
+		GetAgentInfo: func(id string) (AgentInfo, bool) {
+			requested = id
+			if id == "" {
+				id = "main"
+			}
+			info, ok := agents[id]
+			return info, ok
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	execute := func(text string) {
+		t.Helper()
+		res := ex.Execute(context.Background(), Request{
+			Text: text,
+			Reply: func(text string) error {
+				reply = text
+				return nil
+			},
+		})
+		if res.Outcome != OutcomeHandled {
+			t.Fatalf("%s: outcome=%v, want=%v", text, res.Outcome, OutcomeHandled)
+		}
+	}
+
+	execute("/agents info")
+	if requested != "" {
+		t.Fatalf("requested=%q, want default agent", requested)
+	}
+	want := "Agent: main [default]\nModel: gpt-5.4\nMax tokens: 8192\nTemperature: 0.7\n" +
+		"Max iterations: 20\nTools (2): exec, read_file"
+	if reply != want {
+		t.Fatalf("reply=%q, want %q", reply, want)
+	}
+
+	execute("/agents info research")
+	want = "Agent: research (Research)\nModel: claude-sonnet\nMax tokens: 4096\nTemperature: 0\n" +
+		"Max iterations: 60\nTools: none"
+	if reply != want {
+		t.Fatalf("reply=%q, want %q", reply, want)
+	}
+
+	execute("/agents info ghost")
+	if reply != "Unknown agent: ghost" {
+		t.Fatalf("reply=%q", reply)
+	}
+}
+
+func TestAgentsInfo_Unavailable(t *testing.T) {
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), &Runtime{})
+	var reply string
+	ex.Execute(context.Background(), Request{Text: "/agents info", Reply: func(text string) error {
+		reply = text
+		return nil
+	}})
+	if reply != unavailableMsg {
+		t.Fatalf("reply=%q", reply)
+	}
+}
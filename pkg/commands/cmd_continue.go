@@ -0,0 +1,24 @@
+package commands
+
+import "context"
+
+func continueCommand() Definition {
+	return Definition{
+		Name:        "continue",
+		Description: "Resume a task that stopped at the step limit",
+		Usage:       "/continue",
+		Handler: func(ctx context.Context, req Request, rt *Runtime) error {
+			if rt == nil || rt.ContinueTask == nil {
+				return req.Reply(unavailableMsg)
+			}
+			reply, resumed, err := rt.ContinueTask(ctx)
+			if err != nil {
+				return req.Reply("Failed to continue: " + err.Error())
+			}
+			if !resumed {
+				return req.Reply("Nothing to continue.")
+			}
+			return req.Reply(reply)
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestContinue_Outcomes(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context) (string, bool, error)
+		want string
+	}{
+		{
+			name: "unavailable",
+			want: unavailableMsg,
+		},
+		{
+			name: "nothing pending",
+			fn:   func(context.Context) (string, bool, error) { return "", false, nil },
+			want: "Nothing to continue.",
+		},
+		{
+			name: "resumed",
+			fn:   func(context.Context) (string, bool, error) { return "All done.", true, nil },
+			want: "All done.",
+		},
+		{
+			name: "error",
+			fn:   func(context.Context) (string, bool, error) { return "", true, errors.New("boom") },
+			want: "Failed to continue: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := NewExecutor(NewRegistry(BuiltinDefinitions()), &Runtime{ContinueTask: tt.fn})
+			var reply string
+			res := ex.Execute(context.Background(), Request{
+				Text: "/continue",
+				Reply: func(text string) error {
+					reply = text
+					return nil
+				},
+			})
+			if res.Outcome != OutcomeHandled {
+				t.Fatalf("outcome=%v, want=%v", res.Outcome, OutcomeHandled)
+			}
+			if reply != tt.want {
+				t.Errorf("reply=%q, want=%q", reply, tt.want)
+			}
+		})
+	}
+}
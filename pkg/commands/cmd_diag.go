@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func diagCommand() Definition {
+	return Definition{
+		Name:        "diag",
+		Description: "Inspect and reset internal agent state",
+		SubCommands: []SubCommand{
+			{
+				Name:        "summarizing",
+				Description: "List in-flight background summarizations",
+				AdminOnly:   true,
+				Handler: func(_ context.Context, req Request, rt *Runtime) error {
+					if rt == nil || rt.ListSummarizing == nil {
+						return req.Reply(unavailableMsg)
+					}
+					entries := rt.ListSummarizing()
+					if len(entries) == 0 {
+						return req.Reply("No summarizations in progress")
+					}
+					return req.Reply(fmt.Sprintf("Summarizations in progress:\n- %s", strings.Join(entries, "\n- ")))
+				},
+			},
+			{
+				Name:        "errors",
+				Description: "Show recent errors in this session",
+				Handler: func(_ context.Context, req Request, rt *Runtime) error {
+					if rt == nil || rt.GetRecentErrors == nil {
+						return req.Reply(unavailableMsg)
+					}
+					errs := rt.GetRecentErrors()
+					if len(errs) == 0 {
+						return req.Reply("No errors recorded for this session")
+					}
+					return req.Reply(fmt.Sprintf("Recent errors in this session:\n- %s", strings.Join(errs, "\n- ")))
+				},
+			},
+			{
+				Name:        "clear-summarizing",
+				Description: "Reset stuck summarization markers",
+				AdminOnly:   true,
+				Handler: func(_ context.Context, req Request, rt *Runtime) error {
+					if rt == nil || rt.ClearSummarizing == nil {
+						return req.Reply(unavailableMsg)
+					}
+					return req.Reply(fmt.Sprintf("Cleared %d summarization marker(s)", rt.ClearSummarizing()))
+				},
+			},
+		},
+	}
+}
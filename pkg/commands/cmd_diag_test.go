@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDiag_SummarizingListAndClear(t *testing.T) {
+	inflight := []string{"main:telegram:1 (running 3s)"}
+	rt := &Runtime{
+		ListSummarizing: func() []string { return inflight },
+		ClearSummarizing: func() int {
+			n := len(inflight)
+			inflight = nil
+			return n
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	execute := func(text string) {
+		t.Helper()
+		res := ex.Execute(context.Background(), Request{
+			Text:  text,
+			Admin: true,
+			Reply: func(text string) error {
+				reply = text
+				return nil
+			},
+		})
+		if res.Outcome != OutcomeHandled {
+			t.Fatalf("%s: outcome=%v, want=%v", text, res.Outcome, OutcomeHandled)
+		}
+	}
+
+	execute("/diag summarizing")
+	if reply != "Summarizations in progress:\n- main:telegram:1 (running 3s)" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/diag clear-summarizing")
+	if reply != "Cleared 1 summarization marker(s)" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/diag summarizing")
+	if reply != "No summarizations in progress" {
+		t.Fatalf("reply=%q", reply)
+	}
+}
+
+func TestDiag_Unavailable(t *testing.T) {
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), &Runtime{})
+
+	var reply string
+	ex.Execute(context.Background(), Request{
+		Text:  "/diag summarizing",
+		Admin: true,
+		Reply: func(text string) error { reply = text; return nil },
+	})
+	if reply != unavailableMsg {
+		t.Fatalf("reply=%q, want unavailable", reply)
+	}
+}
+
+func TestDiag_Errors(t *testing.T) {
+	var errs []string
+	rt := &Runtime{GetRecentErrors: func() []string { return errs }}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	execute := func() {
+		t.Helper()
+		res := ex.Execute(context.Background(), Request{
+			Text:  "/diag errors",
+			Reply: func(text string) error { reply = text; return nil },
+		})
+		if res.Outcome != OutcomeHandled {
+			t.Fatalf("outcome=%v, want=%v", res.Outcome, OutcomeHandled)
+		}
+	}
+
+	execute()
+	if reply != "No errors recorded for this session" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	errs = []string{"2026-01-02 10:00:00 tool exec: exit status 1", "2026-01-02 10:01:00 rate limited"}
+	execute()
+	want := "Recent errors in this session:\n- 2026-01-02 10:00:00 tool exec: exit status 1\n- 2026-01-02 10:01:00 rate limited"
+	if reply != want {
+		t.Fatalf("reply=%q, want %q", reply, want)
+	}
+}
+
+func TestDiag_SummarizingRequiresAdmin(t *testing.T) {
+	cleared := false
+	rt := &Runtime{
+		ListSummarizing: func() []string { return []string{"main:telegram:1 (running 3s)"} },
+		ClearSummarizing: func() int {
+			cleared = true
+			return 1
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	for _, text := range []string{"/diag summarizing", "/diag clear-summarizing"} {
+		var reply string
+		ex.Execute(context.Background(), Request{
+			Channel: "telegram",
+			Text:    text,
+			Reply:   func(text string) error { reply = text; return nil },
+		})
+		if reply != adminOnlyMsg {
+			t.Errorf("%s: reply=%q, want the admin-only message", text, reply)
+		}
+	}
+	if cleared {
+		t.Error("clear-summarizing ran for a non-admin")
+	}
+}
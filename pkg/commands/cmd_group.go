@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func groupCommand() Definition {
+	return Definition{
+		Name:        "group",
+		Description: "Configure group chat behavior",
+		AdminOnly:   true,
+		SubCommands: []SubCommand{
+			{
+				Name:        "trigger",
+				Description: "Set when the bot responds in this chat",
+				ArgsUsage:   "<mention|prefix|all|default>",
+				Handler: func(_ context.Context, req Request, rt *Runtime) error {
+					if rt == nil || rt.GetGroupTrigger == nil || rt.SetGroupTrigger == nil {
+						return req.Reply(unavailableMsg)
+					}
+					mode := strings.ToLower(nthToken(req.Text, 2))
+					switch mode {
+					case "":
+						current := rt.GetGroupTrigger()
+						if current == "" {
+							current = "default (channel config)"
+						}
+						return req.Reply(fmt.Sprintf("Group trigger: %s", current))
+					case "default":
+						if err := rt.SetGroupTrigger(""); err != nil {
+							return req.Reply(err.Error())
+						}
+						return req.Reply("Group trigger reset to channel default")
+					case "mention", "prefix", "all":
+						if err := rt.SetGroupTrigger(mode); err != nil {
+							return req.Reply(err.Error())
+						}
+						return req.Reply(fmt.Sprintf("Group trigger set to %s", mode))
+					default:
+						return req.Reply("Usage: /group trigger <mention|prefix|all|default>")
+					}
+				},
+			},
+			{
+				Name:        "prefix",
+				Description: "Set trigger prefixes for this chat",
+				ArgsUsage:   "<prefix...|default>",
+				Handler: func(_ context.Context, req Request, rt *Runtime) error {
+					if rt == nil || rt.GetGroupPrefixes == nil || rt.SetGroupPrefixes == nil {
+						return req.Reply(unavailableMsg)
+					}
+					// tokens: [/group, prefix, <prefix>...]
+					prefixes := strings.Fields(strings.TrimSpace(req.Text))[2:]
+					switch {
+					case len(prefixes) == 0:
+						current := rt.GetGroupPrefixes()
+						if len(current) == 0 {
+							return req.Reply("Group prefixes: default (channel config)")
+						}
+						return req.Reply(fmt.Sprintf("Group prefixes: %s", strings.Join(current, " ")))
+					case len(prefixes) == 1 && strings.EqualFold(prefixes[0], "default"):
+						if err := rt.SetGroupPrefixes(nil); err != nil {
+							return req.Reply(err.Error())
+						}
+						return req.Reply("Group prefixes reset to channel default")
+					default:
+						if err := rt.SetGroupPrefixes(prefixes); err != nil {
+							return req.Reply(err.Error())
+						}
+						return req.Reply(fmt.Sprintf("Group prefixes set to %s", strings.Join(prefixes, " ")))
+					}
+				},
+			},
+		},
+	}
+}
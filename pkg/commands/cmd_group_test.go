@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGroupTrigger_SetShowReset(t *testing.T) {
+	current := ""
+	rt := &Runtime{
+		GetGroupTrigger: func() string { return current },
+		SetGroupTrigger: func(mode string) error {
+			current = mode
+			return nil
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	execute := func(text string) {
+		t.Helper()
+		res := ex.Execute(context.Background(), Request{
+			Text:  text,
+			Admin: true,
+			Reply: func(text string) error {
+				reply = text
+				return nil
+			},
+		})
+		if res.Outcome != OutcomeHandled {
+			t.Fatalf("%s: outcome=%v, want=%v", text, res.Outcome, OutcomeHandled)
+		}
+	}
+
+	execute("/group trigger all")
+	if current != "all" || reply != "Group trigger set to all" {
+		t.Fatalf("mode=%q reply=%q", current, reply)
+	}
+
+	execute("/group trigger")
+	if reply != "Group trigger: all" {
+		t.Fatalf("reply=%q, want current mode", reply)
+	}
+
+	execute("/group trigger default")
+	if current != "" || reply != "Group trigger reset to channel default" {
+		t.Fatalf("mode=%q reply=%q", current, reply)
+	}
+
+	execute("/group trigger sometimes")
+	if current != "" || reply != "Usage: /group trigger <mention|prefix|all|default>" {
+		t.Fatalf("invalid mode should show usage, mode=%q reply=%q", current, reply)
+	}
+}
+
+func TestGroupPrefix_SetShowReset(t *testing.T) {
+	var current []string
+	rt := &Runtime{
+		GetGroupPrefixes: func() []string { return current },
+		SetGroupPrefixes: func(prefixes []string) error {
+			current = prefixes
+			return nil
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	execute := func(text string) {
+		t.Helper()
+		ex.Execute(context.Background(), Request{
+			Text:  text,
+			Admin: true,
+			Reply: func(text string) error {
+				reply = text
+				return nil
+			},
+		})
+	}
+
+	execute("/group prefix !bot @assistant")
+	if len(current) != 2 || current[0] != "!bot" || current[1] != "@assistant" {
+		t.Fatalf("prefixes=%v", current)
+	}
+	if reply != "Group prefixes set to !bot @assistant" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/group prefix")
+	if reply != "Group prefixes: !bot @assistant" {
+		t.Fatalf("reply=%q, want current prefixes", reply)
+	}
+
+	execute("/group prefix default")
+	if current != nil || reply != "Group prefixes reset to channel default" {
+		t.Fatalf("prefixes=%v reply=%q", current, reply)
+	}
+}
+
+func TestGroup_RequiresAdmin(t *testing.T) {
+	current := ""
+	rt := &Runtime{
+		GetGroupTrigger: func() string { return current },
+		SetGroupTrigger: func(mode string) error {
+			current = mode
+			return nil
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	res := ex.Execute(context.Background(), Request{
+		Channel: "telegram",
+		Text:    "/group trigger all",
+		Reply: func(text string) error {
+			reply = text
+			return nil
+		},
+	})
+	if res.Outcome != OutcomeHandled {
+		t.Fatalf("outcome=%v, want=%v", res.Outcome, OutcomeHandled)
+	}
+	if current != "" || reply != adminOnlyMsg {
+		t.Fatalf("non-admin: mode=%q reply=%q", current, reply)
+	}
+}
@@ -0,0 +1,25 @@
+package commands
+
+import "context"
+
+func heartbeatCommand() Definition {
+	return Definition{
+		Name:        "heartbeat",
+		Description: "Run a heartbeat check now in this chat",
+		Usage:       "/heartbeat [tasks]",
+		AdminOnly:   true,
+		Handler: func(ctx context.Context, req Request, rt *Runtime) error {
+			if rt == nil || rt.RunHeartbeat == nil {
+				return req.Reply(unavailableMsg)
+			}
+			reply, err := rt.RunHeartbeat(ctx, textAfterCommand(req.Text))
+			if err != nil {
+				return req.Reply("Heartbeat failed: " + err.Error())
+			}
+			if reply == "" {
+				return req.Reply("Heartbeat produced no output (HEARTBEAT_OK).")
+			}
+			return req.Reply("Heartbeat output:\n" + reply)
+		},
+	}
+}
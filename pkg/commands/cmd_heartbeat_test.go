@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHeartbeat_Outcomes(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		fn        func(ctx context.Context, tasks string) (string, error)
+		want      string
+		wantTasks string
+	}{
+		{
+			name: "unavailable",
+			text: "/heartbeat",
+			want: unavailableMsg,
+		},
+		{
+			name: "no output",
+			text: "/heartbeat",
+			fn:   func(context.Context, string) (string, error) { return "", nil },
+			want: "Heartbeat produced no output (HEARTBEAT_OK).",
+		},
+		{
+			name:      "custom tasks",
+			text:      "/heartbeat check the weather",
+			fn:        func(context.Context, string) (string, error) { return "It is sunny.", nil },
+			want:      "Heartbeat output:\nIt is sunny.",
+			wantTasks: "check the weather",
+		},
+		{
+			name: "error",
+			text: "/heartbeat",
+			fn:   func(context.Context, string) (string, error) { return "", errors.New("boom") },
+			want: "Heartbeat failed: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTasks string
+			rt := &Runtime{}
+			if tt.fn != nil {
+				rt.RunHeartbeat = func(ctx context.Context, tasks string) (string, error) {
+					gotTasks = tasks
+					return tt.fn(ctx, tasks)
+				}
+			}
+			ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+			var reply string
+			res := ex.Execute(context.Background(), Request{
+				Text:  tt.text,
+				Admin: true,
+				Reply: func(text string) error {
+					reply = text
+					return nil
+				},
+			})
+			if res.Outcome != OutcomeHandled {
+				t.Fatalf("outcome=%v, want=%v", res.Outcome, OutcomeHandled)
+			}
+			if reply != tt.want {
+				t.Errorf("reply=%q, want=%q", reply, tt.want)
+			}
+			if gotTasks != tt.wantTasks {
+				t.Errorf("tasks=%q, want=%q", gotTasks, tt.wantTasks)
+			}
+		})
+	}
+}
+
+func TestHeartbeat_RequiresAdmin(t *testing.T) {
+	ran := false
+	rt := &Runtime{
+		RunHeartbeat: func(context.Context, string) (string, error) {
+			ran = true
+			return "", nil
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	ex.Execute(context.Background(), Request{
+		Channel: "telegram",
+		Text:    "/heartbeat post my secrets",
+		Reply:   func(text string) error { reply = text; return nil },
+	})
+	if ran || reply != adminOnlyMsg {
+		t.Errorf("non-admin: ran=%v reply=%q", ran, reply)
+	}
+}
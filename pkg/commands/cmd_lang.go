@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func langCommand() Definition {
+	return Definition{
+		Name:        "lang",
+		Description: "Set the reply language for this session",
+		Usage:       "/lang [<code>|auto]",
+		Handler: func(_ context.Context, req Request, rt *Runtime) error {
+			if rt == nil || rt.GetReplyLanguage == nil || rt.SetReplyLanguage == nil {
+				return req.Reply(unavailableMsg)
+			}
+			value := nthToken(req.Text, 1)
+			if value == "" {
+				current := rt.GetReplyLanguage()
+				if current == "" {
+					return req.Reply("Reply language: auto (matching your language)")
+				}
+				return req.Reply(fmt.Sprintf("Reply language: %s", current))
+			}
+			if strings.EqualFold(value, "auto") {
+				if err := rt.SetReplyLanguage(""); err != nil {
+					return req.Reply("Failed to reset reply language: " + err.Error())
+				}
+				return req.Reply("Reply language reset to auto")
+			}
+			if err := rt.SetReplyLanguage(value); err != nil {
+				return req.Reply("Failed to set reply language: " + err.Error())
+			}
+			return req.Reply(fmt.Sprintf("Reply language set to %s", value))
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLang_SetAndAuto(t *testing.T) {
+	current := ""
+	rt := &Runtime{
+		GetReplyLanguage: func() string { return current },
+		SetReplyLanguage: func(language string) error {
+			current = language
+			return nil
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	execute := func(text string) {
+		t.Helper()
+		res := ex.Execute(context.Background(), Request{
+			Text: text,
+			Reply: func(text string) error {
+				reply = text
+				return nil
+			},
+		})
+		if res.Outcome != OutcomeHandled {
+			t.Fatalf("%s: outcome=%v, want=%v", text, res.Outcome, OutcomeHandled)
+		}
+	}
+
+	execute("/lang fr")
+	if current != "fr" {
+		t.Fatalf("language=%q, want=%q", current, "fr")
+	}
+	if reply != "Reply language set to fr" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/lang")
+	if reply != "Reply language: fr" {
+		t.Fatalf("reply=%q, want current language", reply)
+	}
+
+	execute("/lang AUTO")
+	if current != "" {
+		t.Fatalf("language=%q, want cleared", current)
+	}
+	if reply != "Reply language reset to auto" {
+		t.Fatalf("reply=%q", reply)
+	}
+}
+
+func TestLang_Unavailable(t *testing.T) {
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), &Runtime{})
+
+	var reply string
+	ex.Execute(context.Background(), Request{
+		Text: "/lang en",
+		Reply: func(text string) error {
+			reply = text
+			return nil
+		},
+	})
+	if reply != unavailableMsg {
+		t.Fatalf("reply=%q, want=%q", reply, unavailableMsg)
+	}
+}
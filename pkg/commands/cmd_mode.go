@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func modeCommand() Definition {
+	return Definition{
+		Name:        "mode",
+		Description: "Switch the prompt mode for this session",
+		Usage:       "/mode [<name>|off]",
+		Handler: func(_ context.Context, req Request, rt *Runtime) error {
+			if rt == nil || rt.ListModes == nil || rt.GetMode == nil || rt.SetMode == nil {
+				return req.Reply(unavailableMsg)
+			}
+			modes := rt.ListModes()
+			if len(modes) == 0 {
+				return req.Reply("No modes are configured for this agent.")
+			}
+			available := "Available: " + strings.Join(modes, ", ")
+
+			value := nthToken(req.Text, 1)
+			if value == "" {
+				current := rt.GetMode()
+				if current == "" {
+					current = "default"
+				}
+				return req.Reply(fmt.Sprintf("Mode: %s\n%s", current, available))
+			}
+			if strings.EqualFold(value, "off") || strings.EqualFold(value, "default") {
+				if err := rt.SetMode(""); err != nil {
+					return req.Reply("Failed to reset mode: " + err.Error())
+				}
+				return req.Reply("Mode reset to default")
+			}
+			if !slices.Contains(modes, value) {
+				return req.Reply(fmt.Sprintf("Unknown mode: %s\n%s", value, available))
+			}
+			if err := rt.SetMode(value); err != nil {
+				return req.Reply("Failed to set mode: " + err.Error())
+			}
+			return req.Reply(fmt.Sprintf("Mode set to %s", value))
+		},
+	}
+}
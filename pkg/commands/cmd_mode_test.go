@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMode_SetAndReset(t *testing.T) {
+	current := ""
+	rt := &Runtime{
+		ListModes: func() []string { return []string{"concise", "formal"} },
+		GetMode:   func() string { return current },
+		SetMode: func(name string) error {
+			current = name
+			return nil
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	execute := func(text string) {
+		t.Helper()
+		res := ex.Execute(context.Background(), Request{
+			Text: text,
+			Reply: func(text string) error {
+				reply = text
+				return nil
+			},
+		})
+		if res.Outcome != OutcomeHandled {
+			t.Fatalf("%s: outcome=%v, want=%v", text, res.Outcome, OutcomeHandled)
+		}
+	}
+
+	execute("/mode")
+	if reply != "Mode: default\nAvailable: concise, formal" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/mode concise")
+	if current != "concise" {
+		t.Fatalf("mode=%q, want=%q", current, "concise")
+	}
+	if reply != "Mode set to concise" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/mode pirate")
+	if current != "concise" {
+		t.Fatalf("mode=%q, unknown mode must not be applied", current)
+	}
+	if reply != "Unknown mode: pirate\nAvailable: concise, formal" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/mode off")
+	if current != "" {
+		t.Fatalf("mode=%q, want cleared", current)
+	}
+	if reply != "Mode reset to default" {
+		t.Fatalf("reply=%q", reply)
+	}
+}
+
+func TestMode_NoModesConfigured(t *testing.T) {
+	rt := &Runtime{
+		ListModes: func() []string { return nil },
+		GetMode:   func() string { return "" },
+		SetMode:   func(string) error { return nil },
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	ex.Execute(context.Background(), Request{
+		Text: "/mode concise",
+		Reply: func(text string) error {
+			reply = text
+			return nil
+		},
+	})
+	if reply != "No modes are configured for this agent." {
+		t.Fatalf("reply=%q", reply)
+	}
+}
+
+func TestMode_Unavailable(t *testing.T) {
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), &Runtime{})
+
+	var reply string
+	ex.Execute(context.Background(), Request{
+		Text: "/mode concise",
+		Reply: func(text string) error {
+			reply = text
+			return nil
+		},
+	})
+	if reply != unavailableMsg {
+		t.Fatalf("reply=%q, want=%q", reply, unavailableMsg)
+	}
+}
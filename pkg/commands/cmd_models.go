@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// ModelProbe is the outcome of sending a tiny test request to one model.
+type ModelProbe struct {
+	Name    string
+	Latency time.Duration
+	Err     error
+}
+
+func modelsCommand() Definition {
+	return Definition{
+		Name:        "models",
+		Description: "Inspect configured models",
+		SubCommands: []SubCommand{
+			{
+				Name:        "test",
+				Description: "Send a tiny request to every configured model",
+				AdminOnly:   true,
+				Handler: func(ctx context.Context, req Request, rt *Runtime) error {
+					if rt == nil || rt.TestModels == nil {
+						return req.Reply(unavailableMsg)
+					}
+					probes, err := rt.TestModels(ctx)
+					if err != nil {
+						return req.Reply(err.Error())
+					}
+					if len(probes) == 0 {
+						return req.Reply("No models configured")
+					}
+					return req.Reply(formatModelProbes(probes))
+				},
+			},
+		},
+	}
+}
+
+func formatModelProbes(probes []ModelProbe) string {
+	failed := 0
+	for _, p := range probes {
+		if p.Err != nil {
+			failed++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Model test: %d ok, %d failed\n", len(probes)-failed, failed)
+	for _, p := range probes {
+		latency := p.Latency.Round(time.Millisecond)
+		if p.Err != nil {
+			fmt.Fprintf(&sb, "- %s: error after %s: %s\n", p.Name, latency, utils.Truncate(p.Err.Error(), 200))
+		} else {
+			fmt.Fprintf(&sb, "- %s: ok (%s)\n", p.Name, latency)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModelsTest(t *testing.T) {
+	run := func(t *testing.T, rt *Runtime) string {
+		t.Helper()
+		ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+		var reply string
+		res := ex.Execute(context.Background(), Request{
+			Text:  "/models test",
+			Admin: true,
+			Reply: func(text string) error {
+				reply = text
+				return nil
+			},
+		})
+		if res.Outcome != OutcomeHandled {
+			t.Fatalf("outcome=%v, want=%v", res.Outcome, OutcomeHandled)
+		}
+		return reply
+	}
+
+	t.Run("unavailable", func(t *testing.T) {
+		if got := run(t, &Runtime{}); got != unavailableMsg {
+			t.Errorf("reply=%q, want=%q", got, unavailableMsg)
+		}
+	})
+
+	t.Run("no models", func(t *testing.T) {
+		rt := &Runtime{TestModels: func(context.Context) ([]ModelProbe, error) { return nil, nil }}
+		if got := run(t, rt); got != "No models configured" {
+			t.Errorf("reply=%q", got)
+		}
+	})
+
+	t.Run("reports each model", func(t *testing.T) {
+		rt := &Runtime{TestModels: func(context.Context) ([]ModelProbe, error) {
+			return []ModelProbe{
+				{Name: "gpt-5.4", Latency: 812 * time.Millisecond},
+				{Name: "glm-4.7", Latency: 1500 * time.Millisecond, Err: errors.New("401 unauthorized")},
+			}, nil
+		}}
+		got := run(t, rt)
+		for _, want := range []string{
+			"1 ok, 1 failed",
+			"- gpt-5.4: ok (812ms)",
+			"- glm-4.7: error after 1.5s: 401 unauthorized",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("reply missing %q:\n%s", want, got)
+			}
+		}
+	})
+	t.Run("cooldown", func(t *testing.T) {
+		rt := &Runtime{TestModels: func(context.Context) ([]ModelProbe, error) {
+			return nil, errors.New("models were tested 10s ago; try again in 50s")
+		}}
+		if got := run(t, rt); got != "models were tested 10s ago; try again in 50s" {
+			t.Errorf("reply=%q", got)
+		}
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		called := false
+		rt := &Runtime{TestModels: func(context.Context) ([]ModelProbe, error) {
+			called = true
+			return nil, nil
+		}}
+		ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+		var reply string
+		ex.Execute(context.Background(), Request{
+			Channel: "telegram",
+			Text:    "/models test",
+			Reply:   func(text string) error { reply = text; return nil },
+		})
+		if called || reply != adminOnlyMsg {
+			t.Errorf("called=%v reply=%q, want the admin-only message", called, reply)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxPins bounds how many notes a session may pin.
+	maxPins = 20
+	// maxPinLen bounds the length of one pinned note, in characters.
+	maxPinLen = 500
+)
+
+func pinCommand() Definition {
+	return Definition{
+		Name:        "pin",
+		Description: "Pin a note the agent always keeps in context",
+		Usage:       "/pin [<note>]",
+		Handler: func(_ context.Context, req Request, rt *Runtime) error {
+			if rt == nil || rt.GetPins == nil || rt.SetPins == nil {
+				return req.Reply(unavailableMsg)
+			}
+			pins := rt.GetPins()
+
+			note := textAfterCommand(req.Text)
+			if note == "" {
+				return req.Reply(formatPins(pins))
+			}
+			if utf8.RuneCountInString(note) > maxPinLen {
+				return req.Reply(fmt.Sprintf("Note is too long (max %d characters)", maxPinLen))
+			}
+			if len(pins) >= maxPins {
+				return req.Reply(fmt.Sprintf("Too many pinned notes (max %d). Remove one with /unpin first.", maxPins))
+			}
+			if err := rt.SetPins(append(pins, note)); err != nil {
+				return req.Reply("Failed to pin note: " + err.Error())
+			}
+			return req.Reply(fmt.Sprintf("Pinned note %d: %s", len(pins)+1, note))
+		},
+	}
+}
+
+func unpinCommand() Definition {
+	return Definition{
+		Name:        "unpin",
+		Description: "Remove a pinned note",
+		Usage:       "/unpin <number|all>",
+		Handler: func(_ context.Context, req Request, rt *Runtime) error {
+			if rt == nil || rt.GetPins == nil || rt.SetPins == nil {
+				return req.Reply(unavailableMsg)
+			}
+			pins := rt.GetPins()
+
+			value := nthToken(req.Text, 1)
+			if strings.EqualFold(value, "all") {
+				if err := rt.SetPins(nil); err != nil {
+					return req.Reply("Failed to remove pinned notes: " + err.Error())
+				}
+				return req.Reply("All pinned notes removed")
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return req.Reply("Usage: /unpin <number|all>")
+			}
+			if n < 1 || n > len(pins) {
+				return req.Reply(fmt.Sprintf("No pinned note %d", n))
+			}
+			removed := pins[n-1]
+			pins = append(pins[:n-1], pins[n:]...)
+			if err := rt.SetPins(pins); err != nil {
+				return req.Reply("Failed to remove pinned note: " + err.Error())
+			}
+			return req.Reply("Unpinned: " + removed)
+		},
+	}
+}
+
+func formatPins(pins []string) string {
+	if len(pins) == 0 {
+		return "No pinned notes. Add one with /pin <note>."
+	}
+	var sb strings.Builder
+	sb.WriteString("Pinned notes:")
+	for i, pin := range pins {
+		fmt.Fprintf(&sb, "\n%d. %s", i+1, pin)
+	}
+	return sb.String()
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPin_AddListRemove(t *testing.T) {
+	var pins []string
+	rt := &Runtime{
+		GetPins: func() []string { return slices.Clone(pins) },
+		SetPins: func(p []string) error {
+			pins = p
+			return nil
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	execute := func(text string) {
+		t.Helper()
+		res := ex.Execute(context.Background(), Request{
+			Text: text,
+			Reply: func(text string) error {
+				reply = text
+				return nil
+			},
+		})
+		if res.Outcome != OutcomeHandled {
+			t.Fatalf("%s: outcome=%v, want=%v", text, res.Outcome, OutcomeHandled)
+		}
+	}
+
+	execute("/pin")
+	if !strings.HasPrefix(reply, "No pinned notes") {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/pin My name is Ana")
+	execute("/pin   Always answer  in metric units ")
+	if reply != "Pinned note 2: Always answer  in metric units" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/pin")
+	if reply != "Pinned notes:\n1. My name is Ana\n2. Always answer  in metric units" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/unpin 3")
+	if reply != "No pinned note 3" || len(pins) != 2 {
+		t.Fatalf("reply=%q pins=%v", reply, pins)
+	}
+	execute("/unpin one")
+	if reply != "Usage: /unpin <number|all>" {
+		t.Fatalf("reply=%q", reply)
+	}
+
+	execute("/unpin 1")
+	if reply != "Unpinned: My name is Ana" || !slices.Equal(pins, []string{"Always answer  in metric units"}) {
+		t.Fatalf("reply=%q pins=%v", reply, pins)
+	}
+
+	execute("/unpin all")
+	if reply != "All pinned notes removed" || len(pins) != 0 {
+		t.Fatalf("reply=%q pins=%v", reply, pins)
+	}
+}
+
+func TestPin_Limits(t *testing.T) {
+	pins := make([]string, maxPins)
+	rt := &Runtime{
+		GetPins: func() []string { return slices.Clone(pins) },
+		SetPins: func(p []string) error {
+			pins = p
+			return nil
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	req := func(text string) Request {
+		return Request{Text: text, Reply: func(text string) error {
+			reply = text
+			return nil
+		}}
+	}
+
+	ex.Execute(context.Background(), req("/pin one more"))
+	if !strings.HasPrefix(reply, "Too many pinned notes") || len(pins) != maxPins {
+		t.Fatalf("reply=%q pins=%d", reply, len(pins))
+	}
+
+	pins = nil
+	ex.Execute(context.Background(), req("/pin "+strings.Repeat("x", maxPinLen+1)))
+	if !strings.HasPrefix(reply, "Note is too long") || len(pins) != 0 {
+		t.Fatalf("reply=%q pins=%v", reply, pins)
+	}
+}
+
+func TestPin_Unavailable(t *testing.T) {
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), &Runtime{})
+	var reply string
+	ex.Execute(context.Background(), Request{Text: "/pin note", Reply: func(text string) error {
+		reply = text
+		return nil
+	}})
+	if reply != unavailableMsg {
+		t.Fatalf("reply=%q", reply)
+	}
+}
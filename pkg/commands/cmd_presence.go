@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func presenceCommand() Definition {
+	return Definition{
+		Name:        "presence",
+		Description: "Set the bot's status on this channel",
+		Usage:       "/presence <status>|clear",
+		AdminOnly:   true,
+		Handler: func(ctx context.Context, req Request, rt *Runtime) error {
+			if rt == nil || rt.SetPresence == nil {
+				return req.Reply(unavailableMsg)
+			}
+			status := textAfterCommand(req.Text)
+			if status == "" {
+				return req.Reply("Usage: /presence <status>|clear")
+			}
+			if strings.EqualFold(status, "clear") {
+				status = ""
+			}
+			if err := rt.SetPresence(ctx, status); err != nil {
+				return req.Reply("Failed to set status: " + err.Error())
+			}
+			if status == "" {
+				return req.Reply("Status cleared")
+			}
+			return req.Reply(fmt.Sprintf("Status set to %q", status))
+		},
+	}
+}
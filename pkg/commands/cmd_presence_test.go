@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPresence(t *testing.T) {
+	var got []string
+	var failWith error
+	rt := &Runtime{
+		SetPresence: func(_ context.Context, status string) error {
+			if failWith != nil {
+				return failWith
+			}
+			got = append(got, status)
+			return nil
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	execute := func(text string) {
+		t.Helper()
+		res := ex.Execute(context.Background(), Request{
+			Text:  text,
+			Admin: true,
+			Reply: func(text string) error { reply = text; return nil },
+		})
+		if res.Outcome != OutcomeHandled {
+			t.Fatalf("%s: outcome=%v, want=%v", text, res.Outcome, OutcomeHandled)
+		}
+	}
+
+	execute("/presence Helping in #general")
+	if reply != `Status set to "Helping in #general"` {
+		t.Errorf("reply=%q", reply)
+	}
+	execute("/presence clear")
+	if reply != "Status cleared" {
+		t.Errorf("reply=%q", reply)
+	}
+	if len(got) != 2 || got[0] != "Helping in #general" || got[1] != "" {
+		t.Errorf("statuses=%q", got)
+	}
+
+	execute("/presence")
+	if reply != "Usage: /presence <status>|clear" {
+		t.Errorf("reply=%q", reply)
+	}
+
+	failWith = errors.New("channel cli does not support a bot status")
+	execute("/presence busy")
+	if reply != "Failed to set status: channel cli does not support a bot status" {
+		t.Errorf("reply=%q", reply)
+	}
+
+	failWith = nil
+	got = nil
+	ex.Execute(context.Background(), Request{
+		Channel: "discord",
+		Text:    "/presence busy",
+		Reply:   func(text string) error { reply = text; return nil },
+	})
+	if len(got) != 0 || reply != adminOnlyMsg {
+		t.Errorf("non-admin: statuses=%q reply=%q", got, reply)
+	}
+}
+
+func TestPresence_Unavailable(t *testing.T) {
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), &Runtime{})
+
+	var reply string
+	ex.Execute(context.Background(), Request{
+		Text:  "/presence busy",
+		Admin: true,
+		Reply: func(text string) error { reply = text; return nil },
+	})
+	if reply != unavailableMsg {
+		t.Fatalf("reply=%q, want unavailable", reply)
+	}
+}
@@ -3,6 +3,7 @@ package commands
 import (
 	"context"
 	"fmt"
+	"strings"
 )
 
 func showCommand() Definition {
@@ -21,6 +22,28 @@ func showCommand() Definition {
 					return req.Reply(fmt.Sprintf("Current Model: %s (Provider: %s)", name, provider))
 				},
 			},
+			{
+				Name:        "fallbacks",
+				Description: "Model fallback chain, in order",
+				Handler: func(_ context.Context, req Request, rt *Runtime) error {
+					if rt == nil || rt.GetFallbacks == nil {
+						return req.Reply(unavailableMsg)
+					}
+					chain := rt.GetFallbacks()
+					if len(chain) == 0 {
+						return req.Reply("No model candidates configured")
+					}
+					var sb strings.Builder
+					sb.WriteString("Fallback chain:")
+					for i, name := range chain {
+						sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, name))
+						if i == 0 {
+							sb.WriteString(" (primary)")
+						}
+					}
+					return req.Reply(sb.String())
+				},
+			},
 			{
 				Name:        "channel",
 				Description: "Current channel",
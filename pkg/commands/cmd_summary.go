@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"context"
+	"strings"
+)
+
+func summaryCommand() Definition {
+	return Definition{
+		Name:        "summary",
+		Description: "Show or refresh the conversation summary",
+		Usage:       "/summary [refresh]",
+		Handler: func(ctx context.Context, req Request, rt *Runtime) error {
+			if rt == nil || rt.GetSummary == nil {
+				return req.Reply(unavailableMsg)
+			}
+			switch arg := nthToken(req.Text, 1); {
+			case arg == "":
+				summary := rt.GetSummary()
+				if strings.TrimSpace(summary) == "" {
+					return req.Reply("No summary yet")
+				}
+				return req.Reply("Summary:\n" + summary)
+			case strings.EqualFold(arg, "refresh"):
+				if rt.RefreshSummary == nil {
+					return req.Reply(unavailableMsg)
+				}
+				summary, err := rt.RefreshSummary(ctx)
+				if err != nil {
+					reply := "Failed to refresh summary: " + err.Error()
+					if summary != "" {
+						reply += "\n\nSaved a partial summary instead:\n" + summary
+					}
+					return req.Reply(reply)
+				}
+				if summary == "" {
+					return req.Reply("Not enough history to summarize yet")
+				}
+				return req.Reply("Summary refreshed:\n" + summary)
+			default:
+				return req.Reply("Usage: /summary [refresh]")
+			}
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	get := func(s string) func() string { return func() string { return s } }
+	refresh := func(s string, err error) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) { return s, err }
+	}
+
+	tests := []struct {
+		name string
+		text string
+		rt   *Runtime
+		want string
+	}{
+		{
+			name: "unavailable",
+			text: "/summary",
+			rt:   &Runtime{},
+			want: unavailableMsg,
+		},
+		{
+			name: "no summary",
+			text: "/summary",
+			rt:   &Runtime{GetSummary: get("")},
+			want: "No summary yet",
+		},
+		{
+			name: "show",
+			text: "/summary",
+			rt:   &Runtime{GetSummary: get("talked about cats")},
+			want: "Summary:\ntalked about cats",
+		},
+		{
+			name: "refresh",
+			text: "/summary refresh",
+			rt:   &Runtime{GetSummary: get("old"), RefreshSummary: refresh("new", nil)},
+			want: "Summary refreshed:\nnew",
+		},
+		{
+			name: "refresh with too little history",
+			text: "/summary refresh",
+			rt:   &Runtime{GetSummary: get(""), RefreshSummary: refresh("", nil)},
+			want: "Not enough history to summarize yet",
+		},
+		{
+			name: "refresh error",
+			text: "/summary refresh",
+			rt:   &Runtime{GetSummary: get(""), RefreshSummary: refresh("", errors.New("timeout"))},
+			want: "Failed to refresh summary: timeout",
+		},
+		{
+			name: "refresh error with fallback",
+			text: "/summary refresh",
+			rt:   &Runtime{GetSummary: get(""), RefreshSummary: refresh("partial", errors.New("timeout"))},
+			want: "Failed to refresh summary: timeout\n\nSaved a partial summary instead:\npartial",
+		},
+		{
+			name: "unknown argument",
+			text: "/summary foo",
+			rt:   &Runtime{GetSummary: get("")},
+			want: "Usage: /summary [refresh]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := NewExecutor(NewRegistry(BuiltinDefinitions()), tt.rt)
+			var reply string
+			res := ex.Execute(context.Background(), Request{
+				Text: tt.text,
+				Reply: func(text string) error {
+					reply = text
+					return nil
+				},
+			})
+			if res.Outcome != OutcomeHandled {
+				t.Fatalf("outcome=%v, want=%v", res.Outcome, OutcomeHandled)
+			}
+			if reply != tt.want {
+				t.Errorf("reply=%q, want=%q", reply, tt.want)
+			}
+		})
+	}
+}
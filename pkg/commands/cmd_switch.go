@@ -3,6 +3,8 @@ package commands
 import (
 	"context"
 	"fmt"
+	"strings"
+	"unicode"
 )
 
 func switchCommand() Definition {
@@ -30,6 +32,38 @@ func switchCommand() Definition {
 					return req.Reply(fmt.Sprintf("Switched model from %s to %s", oldModel, value))
 				},
 			},
+			{
+				Name:        "fallbacks",
+				Description: "Replace the model fallback chain",
+				ArgsUsage:   "to <model,model,...>",
+				AdminOnly:   true,
+				Handler: func(_ context.Context, req Request, rt *Runtime) error {
+					if rt == nil || rt.SetFallbacks == nil {
+						return req.Reply(unavailableMsg)
+					}
+					// tokens: [/switch, fallbacks, to, <models>...]
+					tokens := strings.Fields(strings.TrimSpace(req.Text))
+					if len(tokens) < 4 || tokens[2] != "to" {
+						return req.Reply("Usage: /switch fallbacks to <model,model,...>")
+					}
+					models := parseModelList(strings.Join(tokens[3:], " "))
+					if len(models) == 0 {
+						return req.Reply("Usage: /switch fallbacks to <model,model,...>")
+					}
+					if rt.Config != nil {
+						for _, name := range models {
+							if _, err := rt.Config.GetModelConfig(name); err != nil {
+								return req.Reply(err.Error())
+							}
+						}
+					}
+					reply := fmt.Sprintf("Fallback chain set to %s", strings.Join(models, ", "))
+					if !rt.SetFallbacks(models) {
+						reply += " (this session only; not saved to config)"
+					}
+					return req.Reply(reply)
+				},
+			},
 			{
 				Name:        "channel",
 				Description: "Moved to /check channel",
@@ -40,3 +74,10 @@ func switchCommand() Definition {
 		},
 	}
 }
+
+// parseModelList splits a comma- or space-separated list of model names.
+func parseModelList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
@@ -3,7 +3,10 @@ package commands
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
 )
 
 func TestSwitchModel_Success(t *testing.T) {
@@ -277,3 +280,114 @@ func TestSwitch_NoSubCommand(t *testing.T) {
 		t.Fatal("expected usage reply for bare /switch")
 	}
 }
+
+func TestShowFallbacks_ListsChainInOrder(t *testing.T) {
+	rt := &Runtime{
+		GetFallbacks: func() []string {
+			return []string{"openai/gpt-4o", "anthropic/claude-sonnet-4", "ollama/qwen2.5"}
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	res := ex.Execute(context.Background(), Request{
+		Text: "/show fallbacks",
+		Reply: func(text string) error {
+			reply = text
+			return nil
+		},
+	})
+	if res.Outcome != OutcomeHandled {
+		t.Fatalf("outcome=%v, want=%v", res.Outcome, OutcomeHandled)
+	}
+	want := "Fallback chain:\n1. openai/gpt-4o (primary)\n2. anthropic/claude-sonnet-4\n3. ollama/qwen2.5"
+	if reply != want {
+		t.Fatalf("reply=%q, want=%q", reply, want)
+	}
+}
+
+func TestSwitchFallbacks_ReplacesChain(t *testing.T) {
+	var got []string
+	rt := &Runtime{
+		Config: &config.Config{ModelList: []config.ModelConfig{
+			{ModelName: "claude-sonnet", Model: "anthropic/claude-sonnet-4"},
+			{ModelName: "qwen", Model: "ollama/qwen2.5"},
+		}},
+		SetFallbacks: func(models []string) bool {
+			got = models
+			return false
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	ex.Execute(context.Background(), Request{
+		Text:  "/switch fallbacks to claude-sonnet, qwen",
+		Admin: true,
+		Reply: func(text string) error {
+			reply = text
+			return nil
+		},
+	})
+	if fmt.Sprint(got) != "[claude-sonnet qwen]" {
+		t.Fatalf("SetFallbacks got %v, want [claude-sonnet qwen]", got)
+	}
+	want := "Fallback chain set to claude-sonnet, qwen (this session only; not saved to config)"
+	if reply != want {
+		t.Fatalf("reply=%q, want=%q", reply, want)
+	}
+}
+
+func TestSwitchFallbacks_RejectsUnknownModel(t *testing.T) {
+	called := false
+	rt := &Runtime{
+		Config: &config.Config{ModelList: []config.ModelConfig{
+			{ModelName: "claude-sonnet", Model: "anthropic/claude-sonnet-4"},
+		}},
+		SetFallbacks: func(models []string) bool {
+			called = true
+			return true
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	ex.Execute(context.Background(), Request{
+		Text:  "/switch fallbacks to claude-sonnet,no-such-model",
+		Admin: true,
+		Reply: func(text string) error {
+			reply = text
+			return nil
+		},
+	})
+	if called {
+		t.Fatal("SetFallbacks called despite an unknown model")
+	}
+	if !strings.Contains(reply, "no-such-model") {
+		t.Fatalf("reply=%q, want an error naming the unknown model", reply)
+	}
+}
+
+func TestSwitchFallbacks_RequiresAdmin(t *testing.T) {
+	called := false
+	rt := &Runtime{
+		Config: &config.Config{ModelList: []config.ModelConfig{
+			{ModelName: "claude-sonnet", Model: "anthropic/claude-sonnet-4"},
+		}},
+		SetFallbacks: func(models []string) bool {
+			called = true
+			return true
+		},
+	}
+	ex := NewExecutor(NewRegistry(BuiltinDefinitions()), rt)
+
+	var reply string
+	ex.Execute(context.Background(), Request{
+		Channel: "telegram",
+		Text:    "/switch fallbacks to claude-sonnet",
+		Reply:   func(text string) error { reply = text; return nil },
+	})
+	if called || reply != adminOnlyMsg {
+		t.Errorf("non-admin: called=%v reply=%q", called, reply)
+	}
+}
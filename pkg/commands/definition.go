@@ -10,6 +10,7 @@ type SubCommand struct {
 	Name        string
 	Description string
 	ArgsUsage   string // optional, e.g. "<session-id>"
+	AdminOnly   bool   // only senders listed in the admins config may run it
 	Handler     Handler
 }
 
@@ -27,6 +28,7 @@ type Definition struct {
 	Aliases     []string
 	SubCommands []SubCommand // optional; when set, Executor routes to sub-command handlers
 	Handler     Handler      // for simple commands without sub-commands
+	AdminOnly   bool         // applies to the command and all of its sub-commands
 }
 
 // EffectiveUsage returns the usage string. When SubCommands are present,
@@ -56,6 +56,11 @@ func (e *Executor) executeDefinition(ctx context.Context, req Request, def Defin
 		req.Reply = func(string) error { return nil }
 	}
 
+	if def.AdminOnly && !req.Admin {
+		err := req.Reply(adminOnlyMsg)
+		return ExecuteResult{Outcome: OutcomeHandled, Command: def.Name, Err: err}
+	}
+
 	// Simple command — no sub-commands
 	if len(def.SubCommands) == 0 {
 		if def.Handler == nil {
@@ -78,6 +83,10 @@ func (e *Executor) executeDefinition(ctx context.Context, req Request, def Defin
 			if sc.Handler == nil {
 				return ExecuteResult{Outcome: OutcomePassthrough, Command: def.Name}
 			}
+			if sc.AdminOnly && !req.Admin {
+				err := req.Reply(adminOnlyMsg)
+				return ExecuteResult{Outcome: OutcomeHandled, Command: def.Name, Err: err}
+			}
 			err := sc.Handler(ctx, req, e.rt)
 			return ExecuteResult{Outcome: OutcomeHandled, Command: def.Name, Err: err}
 		}
@@ -258,3 +258,58 @@ func TestExecutor_SubCommand_NilHandler_ReturnsPassthrough(t *testing.T) {
 		t.Fatalf("outcome=%v, want=%v", res.Outcome, OutcomePassthrough)
 	}
 }
+
+func TestExecutor_AdminOnly(t *testing.T) {
+	var called []string
+	handler := func(name string) Handler {
+		return func(context.Context, Request, *Runtime) error {
+			called = append(called, name)
+			return nil
+		}
+	}
+	defs := []Definition{
+		{Name: "reload", AdminOnly: true, Handler: handler("reload")},
+		{
+			Name: "cfg",
+			SubCommands: []SubCommand{
+				{Name: "get", Handler: handler("get")},
+				{Name: "set", AdminOnly: true, Handler: handler("set")},
+			},
+		},
+	}
+	ex := NewExecutor(NewRegistry(defs), nil)
+
+	for _, tc := range []struct {
+		text      string
+		admin     bool
+		wantCall  bool
+		wantReply string
+	}{
+		{"/reload", false, false, adminOnlyMsg},
+		{"/reload", true, true, ""},
+		{"/cfg get", false, true, ""},
+		{"/cfg set x", false, false, adminOnlyMsg},
+		{"/cfg set x", true, true, ""},
+	} {
+		called = nil
+		var reply string
+		res := ex.Execute(context.Background(), Request{
+			Channel: "telegram",
+			Text:    tc.text,
+			Admin:   tc.admin,
+			Reply: func(text string) error {
+				reply = text
+				return nil
+			},
+		})
+		if res.Outcome != OutcomeHandled {
+			t.Errorf("%s (admin=%v): outcome=%v, want handled", tc.text, tc.admin, res.Outcome)
+		}
+		if got := len(called) > 0; got != tc.wantCall {
+			t.Errorf("%s (admin=%v): handler called=%v, want %v", tc.text, tc.admin, got, tc.wantCall)
+		}
+		if reply != tc.wantReply {
+			t.Errorf("%s (admin=%v): reply=%q, want %q", tc.text, tc.admin, reply, tc.wantReply)
+		}
+	}
+}
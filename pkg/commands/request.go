@@ -3,6 +3,7 @@ package commands
 import (
 	"context"
 	"strings"
+	"unicode"
 )
 
 type Handler func(ctx context.Context, req Request, rt *Runtime) error
@@ -12,10 +13,15 @@ type Request struct {
 	ChatID   string
 	SenderID string
 	Text     string
-	Reply    func(text string) error
+	// Admin reports whether the sender may run admin-only commands.
+	Admin bool
+	Reply func(text string) error
 }
 
-const unavailableMsg = "Command unavailable in current context."
+const (
+	unavailableMsg = "Command unavailable in current context."
+	adminOnlyMsg   = "This command is restricted to admins."
+)
 
 var commandPrefixes = []string{"/", "!"}
 
@@ -70,6 +76,17 @@ func nthToken(input string, n int) string {
 	return parts[n]
 }
 
+// textAfterCommand returns the input after its first token, trimmed, keeping
+// the argument's own spacing.
+func textAfterCommand(input string) string {
+	input = strings.TrimSpace(input)
+	i := strings.IndexFunc(input, unicode.IsSpace)
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSpace(input[i:])
+}
+
 func normalizeCommandName(name string) string {
 	return strings.ToLower(strings.TrimSpace(name))
 }
@@ -1,6 +1,10 @@
 package commands
 
-import "github.com/sipeed/picoclaw/pkg/config"
+import (
+	"context"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
 
 // Runtime provides runtime dependencies to command handlers. It is constructed
 // per-request by the agent loop so that per-request state (like session scope)
@@ -9,9 +13,32 @@ type Runtime struct {
 	Config             *config.Config
 	GetModelInfo       func() (name, provider string)
 	ListAgentIDs       func() []string
+	GetAgentInfo       func(id string) (AgentInfo, bool)
 	ListDefinitions    func() []Definition
 	GetEnabledChannels func() []string
 	SwitchModel        func(value string) (oldModel string, err error)
+	GetFallbacks       func() []string
+	SetFallbacks       func(models []string) (persisted bool)
 	SwitchChannel      func(value string) error
 	ClearHistory       func() error
+	GetSummary         func() string
+	RefreshSummary     func(ctx context.Context) (summary string, err error)
+	GetReplyLanguage   func() string
+	SetReplyLanguage   func(language string) error
+	ListModes          func() []string
+	GetMode            func() string
+	SetMode            func(name string) error
+	GetPins            func() []string
+	SetPins            func(pins []string) error
+	GetGroupTrigger    func() string
+	SetGroupTrigger    func(mode string) error
+	GetGroupPrefixes   func() []string
+	SetGroupPrefixes   func(prefixes []string) error
+	ListSummarizing    func() []string
+	ClearSummarizing   func() int
+	GetRecentErrors    func() []string
+	SetPresence        func(ctx context.Context, status string) error
+	ContinueTask       func(ctx context.Context) (reply string, resumed bool, err error)
+	TestModels         func(ctx context.Context) ([]ModelProbe, error)
+	RunHeartbeat       func(ctx context.Context, tasks string) (reply string, err error)
 }
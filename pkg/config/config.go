@@ -3,10 +3,13 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync/atomic"
+	"time"
+	"unicode"
 
 	"github.com/caarlos0/env/v11"
 
@@ -77,9 +80,13 @@ func (f *FlexibleStringSlice) UnmarshalText(text []byte) error {
 }
 
 type Config struct {
-	Agents    AgentsConfig    `json:"agents"`
-	Bindings  []AgentBinding  `json:"bindings,omitempty"`
-	Session   SessionConfig   `json:"session,omitempty"`
+	Agents   AgentsConfig   `json:"agents"`
+	Bindings []AgentBinding `json:"bindings,omitempty"`
+	Session  SessionConfig  `json:"session,omitempty"`
+	User     UserConfig     `json:"user,omitempty"`
+	// Admins lists the canonical "platform:id" identities allowed to run
+	// admin-only commands such as /group.
+	Admins    []string        `json:"admins,omitempty"`
 	Channels  ChannelsConfig  `json:"channels"`
 	Providers ProvidersConfig `json:"providers,omitempty"`
 	ModelList []ModelConfig   `json:"model_list"` // New model-centric provider configuration
@@ -88,6 +95,11 @@ type Config struct {
 	Heartbeat HeartbeatConfig `json:"heartbeat"`
 	Devices   DevicesConfig   `json:"devices"`
 	Voice     VoiceConfig     `json:"voice"`
+	// ModelAliases maps extra names to a model_name, e.g. {"fast": "gpt-4o-mini"}.
+	ModelAliases map[string]string `json:"model_aliases,omitempty"`
+	// NormalizeModelNames lets a model name match a model_name that differs
+	// only in case, dashes, underscores or spaces ("GPT4o" finds "gpt-4o").
+	NormalizeModelNames bool `json:"normalize_model_names,omitempty" env:"PICOCLAW_NORMALIZE_MODEL_NAMES"`
 	// BuildInfo contains build-time version information
 	BuildInfo BuildInfo `json:"build_info,omitempty"`
 }
@@ -107,6 +119,7 @@ func (c Config) MarshalJSON() ([]byte, error) {
 	aux := &struct {
 		Providers *ProvidersConfig `json:"providers,omitempty"`
 		Session   *SessionConfig   `json:"session,omitempty"`
+		User      *UserConfig      `json:"user,omitempty"`
 		*Alias
 	}{
 		Alias: (*Alias)(&c),
@@ -118,18 +131,31 @@ func (c Config) MarshalJSON() ([]byte, error) {
 	}
 
 	// Only include session if not empty
-	if c.Session.DMScope != "" || len(c.Session.IdentityLinks) > 0 {
+	if c.Session.DMScope != "" || len(c.Session.IdentityLinks) > 0 || c.Session.MaxCost > 0 {
 		aux.Session = &c.Session
 	}
 
+	// Only include user if a limit is set
+	if c.User.DailyCostLimit > 0 {
+		aux.User = &c.User
+	}
+
 	return json.Marshal(aux)
 }
 
 type AgentsConfig struct {
 	Defaults AgentDefaults `json:"defaults"`
 	List     []AgentConfig `json:"list,omitempty"`
+	// MaxAgents and MaxBindings cap the size of agents.list and bindings,
+	// catching runaway generated configs on small devices. 0 means no limit.
+	MaxAgents   int `json:"max_agents,omitempty"   env:"PICOCLAW_AGENTS_MAX_AGENTS"`
+	MaxBindings int `json:"max_bindings,omitempty" env:"PICOCLAW_AGENTS_MAX_BINDINGS"`
 }
 
+// FootprintWarnThreshold is the number of enabled channels times agents above
+// which LoadConfig warns about the worker and memory footprint.
+const FootprintWarnThreshold = 64
+
 // AgentModelConfig supports both string and structured model config.
 // String format: "gpt-4" (just primary, no fallbacks)
 // Object format: {"primary": "gpt-4", "fallbacks": ["claude-haiku"]}
@@ -177,6 +203,26 @@ type AgentConfig struct {
 	Model     *AgentModelConfig `json:"model,omitempty"`
 	Skills    []string          `json:"skills,omitempty"`
 	Subagents *SubagentsConfig  `json:"subagents,omitempty"`
+	// CiteSources appends the web pages an answer relied on as a sources list.
+	CiteSources bool `json:"cite_sources,omitempty"`
+	// Modes maps a mode name to an instruction added to the system prompt
+	// while that mode is active in a session (see /mode).
+	Modes map[string]string `json:"modes,omitempty"`
+	// MaxToolIterations overrides agents.defaults.max_tool_iterations for
+	// this agent. Nil inherits the default; set values must be at least 1.
+	MaxToolIterations *int `json:"max_tool_iterations,omitempty"`
+	// DisabledTools lists tool names this agent never gets, even when the
+	// tool is enabled globally under tools.
+	DisabledTools []string `json:"disabled_tools,omitempty"`
+	// DefaultTarget is the "channel:chat_id" that this agent's cron jobs
+	// without a chat of their own post to, e.g. "telegram:123456789".
+	DefaultTarget string `json:"default_target,omitempty"`
+	// SummaryModel overrides agents.defaults.summary_model for this agent.
+	SummaryModel string `json:"summary_model,omitempty"`
+	// AllowedChannels limits the channels this agent's message tool may send
+	// to. Nil allows every channel; an empty list allows none. There is no
+	// omitempty, so an empty list survives SaveConfig.
+	AllowedChannels []string `json:"allowed_channels"`
 }
 
 type SubagentsConfig struct {
@@ -200,11 +246,43 @@ type BindingMatch struct {
 type AgentBinding struct {
 	AgentID string       `json:"agent_id"`
 	Match   BindingMatch `json:"match"`
+	// Model overrides the agent's model for messages matched by this binding,
+	// so one persona can run on different models per channel or chat.
+	Model *AgentModelConfig `json:"model,omitempty"`
 }
 
 type SessionConfig struct {
 	DMScope       string              `json:"dm_scope,omitempty"`
 	IdentityLinks map[string][]string `json:"identity_links,omitempty"`
+	// CompressionDropRatio is the share of the oldest conversation messages
+	// dropped by emergency compression when the context window overflows.
+	CompressionDropRatio float64 `json:"compression_drop_ratio,omitempty"`
+	// MaxCost caps the estimated spend of one session, in USD, priced with
+	// the pricing of the models in model_list. 0 means no limit.
+	MaxCost float64 `json:"max_cost,omitempty"`
+}
+
+// UserConfig holds limits applied per user across all their sessions.
+type UserConfig struct {
+	// DailyCostLimit caps the estimated spend of one user per local day, in
+	// USD. 0 means no limit.
+	DailyCostLimit float64 `json:"daily_cost_limit,omitempty"`
+}
+
+// Bounds and default of SessionConfig.CompressionDropRatio.
+const (
+	DefaultCompressionDropRatio = 0.5
+	MinCompressionDropRatio     = 0.1
+	MaxCompressionDropRatio     = 0.9
+)
+
+// EffectiveCompressionDropRatio returns the configured drop ratio, or the
+// default when unset, clamped to the valid range.
+func (c SessionConfig) EffectiveCompressionDropRatio() float64 {
+	if c.CompressionDropRatio == 0 {
+		return DefaultCompressionDropRatio
+	}
+	return min(max(c.CompressionDropRatio, MinCompressionDropRatio), MaxCompressionDropRatio)
 }
 
 // RoutingConfig controls the intelligent model routing feature.
@@ -219,23 +297,48 @@ type RoutingConfig struct {
 	Threshold  float64 `json:"threshold"`   // complexity score in [0,1]; score >= threshold → primary model
 }
 
+// LoopDetectionConfig controls how the agent reacts when it keeps repeating
+// the same tool call within one turn.
+type LoopDetectionConfig struct {
+	Enabled         bool   `json:"enabled"`
+	Threshold       int    `json:"threshold"`        // identical tool calls (same name and arguments) before reacting; default 3
+	EscalationModel string `json:"escalation_model"` // model_name from model_list to switch to; empty = nudge only
+	// ReuseRepeatedCalls answers a call to a pure tool (web_search, web_fetch,
+	// ...) that exactly repeats the previous step's call with the prior
+	// result instead of running it again. It works even when Enabled is false.
+	ReuseRepeatedCalls bool `json:"reuse_repeated_calls,omitempty"`
+}
+
 type AgentDefaults struct {
-	Workspace                 string         `json:"workspace"                       env:"PICOCLAW_AGENTS_DEFAULTS_WORKSPACE"`
-	RestrictToWorkspace       bool           `json:"restrict_to_workspace"           env:"PICOCLAW_AGENTS_DEFAULTS_RESTRICT_TO_WORKSPACE"`
-	AllowReadOutsideWorkspace bool           `json:"allow_read_outside_workspace"    env:"PICOCLAW_AGENTS_DEFAULTS_ALLOW_READ_OUTSIDE_WORKSPACE"`
-	Provider                  string         `json:"provider"                        env:"PICOCLAW_AGENTS_DEFAULTS_PROVIDER"`
-	ModelName                 string         `json:"model_name"                      env:"PICOCLAW_AGENTS_DEFAULTS_MODEL_NAME"`
-	Model                     string         `json:"model,omitempty"                 env:"PICOCLAW_AGENTS_DEFAULTS_MODEL"` // Deprecated: use model_name instead
-	ModelFallbacks            []string       `json:"model_fallbacks,omitempty"`
-	ImageModel                string         `json:"image_model,omitempty"           env:"PICOCLAW_AGENTS_DEFAULTS_IMAGE_MODEL"`
-	ImageModelFallbacks       []string       `json:"image_model_fallbacks,omitempty"`
-	MaxTokens                 int            `json:"max_tokens"                      env:"PICOCLAW_AGENTS_DEFAULTS_MAX_TOKENS"`
-	Temperature               *float64       `json:"temperature,omitempty"           env:"PICOCLAW_AGENTS_DEFAULTS_TEMPERATURE"`
-	MaxToolIterations         int            `json:"max_tool_iterations"             env:"PICOCLAW_AGENTS_DEFAULTS_MAX_TOOL_ITERATIONS"`
-	SummarizeMessageThreshold int            `json:"summarize_message_threshold"     env:"PICOCLAW_AGENTS_DEFAULTS_SUMMARIZE_MESSAGE_THRESHOLD"`
-	SummarizeTokenPercent     int            `json:"summarize_token_percent"         env:"PICOCLAW_AGENTS_DEFAULTS_SUMMARIZE_TOKEN_PERCENT"`
-	MaxMediaSize              int            `json:"max_media_size,omitempty"        env:"PICOCLAW_AGENTS_DEFAULTS_MAX_MEDIA_SIZE"`
-	Routing                   *RoutingConfig `json:"routing,omitempty"`
+	Workspace                 string               `json:"workspace"                           env:"PICOCLAW_AGENTS_DEFAULTS_WORKSPACE"`
+	RestrictToWorkspace       bool                 `json:"restrict_to_workspace"               env:"PICOCLAW_AGENTS_DEFAULTS_RESTRICT_TO_WORKSPACE"`
+	AllowReadOutsideWorkspace bool                 `json:"allow_read_outside_workspace"        env:"PICOCLAW_AGENTS_DEFAULTS_ALLOW_READ_OUTSIDE_WORKSPACE"`
+	Provider                  string               `json:"provider"                            env:"PICOCLAW_AGENTS_DEFAULTS_PROVIDER"`
+	ModelName                 string               `json:"model_name"                          env:"PICOCLAW_AGENTS_DEFAULTS_MODEL_NAME"`
+	Model                     string               `json:"model,omitempty"                     env:"PICOCLAW_AGENTS_DEFAULTS_MODEL"` // Deprecated: use model_name instead
+	ModelFallbacks            []string             `json:"model_fallbacks,omitempty"`
+	FallbackMaxAttempts       int                  `json:"fallback_max_attempts,omitempty"     env:"PICOCLAW_AGENTS_DEFAULTS_FALLBACK_MAX_ATTEMPTS"`
+	FallbackBudgetSeconds     int                  `json:"fallback_budget_seconds,omitempty"   env:"PICOCLAW_AGENTS_DEFAULTS_FALLBACK_BUDGET_SECONDS"`
+	FallbackCooldownSeconds   int                  `json:"fallback_cooldown_seconds,omitempty" env:"PICOCLAW_AGENTS_DEFAULTS_FALLBACK_COOLDOWN_SECONDS"`
+	LLMAttemptBudget          int                  `json:"llm_attempt_budget,omitempty"        env:"PICOCLAW_AGENTS_DEFAULTS_LLM_ATTEMPT_BUDGET"`
+	ImageModel                string               `json:"image_model,omitempty"               env:"PICOCLAW_AGENTS_DEFAULTS_IMAGE_MODEL"`
+	ImageModelFallbacks       []string             `json:"image_model_fallbacks,omitempty"`
+	MaxTokens                 int                  `json:"max_tokens"                          env:"PICOCLAW_AGENTS_DEFAULTS_MAX_TOKENS"`
+	Temperature               *float64             `json:"temperature,omitempty"               env:"PICOCLAW_AGENTS_DEFAULTS_TEMPERATURE"`
+	MaxToolIterations         int                  `json:"max_tool_iterations"                 env:"PICOCLAW_AGENTS_DEFAULTS_MAX_TOOL_ITERATIONS"`
+	SummarizeMessageThreshold int                  `json:"summarize_message_threshold"         env:"PICOCLAW_AGENTS_DEFAULTS_SUMMARIZE_MESSAGE_THRESHOLD"`
+	SummarizeTokenPercent     int                  `json:"summarize_token_percent"             env:"PICOCLAW_AGENTS_DEFAULTS_SUMMARIZE_TOKEN_PERCENT"`
+	MaxContextMessages        int                  `json:"max_context_messages,omitempty"      env:"PICOCLAW_AGENTS_DEFAULTS_MAX_CONTEXT_MESSAGES"`
+	MaxMediaSize              int                  `json:"max_media_size,omitempty"            env:"PICOCLAW_AGENTS_DEFAULTS_MAX_MEDIA_SIZE"`
+	SendInterimContent        bool                 `json:"send_interim_content,omitempty"      env:"PICOCLAW_AGENTS_DEFAULTS_SEND_INTERIM_CONTENT"`
+	MaxSubagents              int                  `json:"max_subagents,omitempty"             env:"PICOCLAW_AGENTS_DEFAULTS_MAX_SUBAGENTS"`
+	GlobalLLMConcurrency      int                  `json:"global_llm_concurrency,omitempty"    env:"PICOCLAW_AGENTS_DEFAULTS_GLOBAL_LLM_CONCURRENCY"`
+	SubagentMaxResultChars    int                  `json:"subagent_max_result_chars,omitempty" env:"PICOCLAW_AGENTS_DEFAULTS_SUBAGENT_MAX_RESULT_CHARS"`
+	UnavailableToolMessage    string               `json:"unavailable_tool_message,omitempty"  env:"PICOCLAW_AGENTS_DEFAULTS_UNAVAILABLE_TOOL_MESSAGE"`
+	SummaryModel              string               `json:"summary_model,omitempty"             env:"PICOCLAW_AGENTS_DEFAULTS_SUMMARY_MODEL"`
+	AutoCompressOnOverflow    *bool                `json:"auto_compress_on_overflow,omitempty" env:"PICOCLAW_AGENTS_DEFAULTS_AUTO_COMPRESS_ON_OVERFLOW"`
+	Routing                   *RoutingConfig       `json:"routing,omitempty"`
+	LoopDetection             *LoopDetectionConfig `json:"loop_detection,omitempty"`
 }
 
 const DefaultMaxMediaSize = 20 * 1024 * 1024 // 20 MB
@@ -247,6 +350,12 @@ func (d *AgentDefaults) GetMaxMediaSize() int {
 	return DefaultMaxMediaSize
 }
 
+// CompressOnOverflow reports whether a context-window error compresses the
+// session history and retries. Unset means true; false surfaces the error.
+func (d *AgentDefaults) CompressOnOverflow() bool {
+	return d.AutoCompressOnOverflow == nil || *d.AutoCompressOnOverflow
+}
+
 // GetModelName returns the effective model name for the agent defaults.
 // It prefers the new "model_name" field but falls back to "model" for backward compatibility.
 func (d *AgentDefaults) GetModelName() string {
@@ -273,12 +382,81 @@ type ChannelsConfig struct {
 	WeComAIBot WeComAIBotConfig `json:"wecom_aibot"`
 	Pico       PicoConfig       `json:"pico"`
 	IRC        IRCConfig        `json:"irc"`
+	// PersistDedup keeps recently handled inbound message IDs on disk, so
+	// messages redelivered across a restart are not answered twice.
+	PersistDedup bool `json:"persist_dedup,omitempty" env:"PICOCLAW_CHANNELS_PERSIST_DEDUP"`
+}
+
+// channelNames lists the config names of every channel, in the order of
+// ChannelsConfig's fields.
+var channelNames = []string{
+	"whatsapp", "telegram", "feishu", "discord", "maixcam", "qq", "dingtalk", "slack",
+	"matrix", "line", "onebot", "wecom", "wecom_app", "wecom_aibot", "pico", "irc",
+}
+
+// EnabledNames returns the names of the enabled channels.
+func (c ChannelsConfig) EnabledNames() []string {
+	var names []string
+	for _, name := range channelNames {
+		if c.IsEnabled(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// footprint estimates how many channel/agent pairs the gateway may serve. An
+// empty agents.list still runs the implicit main agent.
+func (c *Config) footprint() int {
+	return len(c.Channels.EnabledNames()) * max(len(c.Agents.List), 1)
+}
+
+// IsEnabled reports whether the channel registered under name is enabled.
+func (c ChannelsConfig) IsEnabled(name string) bool {
+	switch name {
+	case "whatsapp", "whatsapp_native":
+		return c.WhatsApp.Enabled
+	case "telegram":
+		return c.Telegram.Enabled
+	case "feishu":
+		return c.Feishu.Enabled
+	case "discord":
+		return c.Discord.Enabled
+	case "maixcam":
+		return c.MaixCam.Enabled
+	case "qq":
+		return c.QQ.Enabled
+	case "dingtalk":
+		return c.DingTalk.Enabled
+	case "slack":
+		return c.Slack.Enabled
+	case "matrix":
+		return c.Matrix.Enabled
+	case "line":
+		return c.LINE.Enabled
+	case "onebot":
+		return c.OneBot.Enabled
+	case "wecom":
+		return c.WeCom.Enabled
+	case "wecom_app":
+		return c.WeComApp.Enabled
+	case "wecom_aibot":
+		return c.WeComAIBot.Enabled
+	case "pico":
+		return c.Pico.Enabled
+	case "irc":
+		return c.IRC.Enabled
+	}
+	return false
 }
 
 // GroupTriggerConfig controls when the bot responds in group chats.
 type GroupTriggerConfig struct {
 	MentionOnly bool     `json:"mention_only,omitempty"`
 	Prefixes    []string `json:"prefixes,omitempty"`
+	// EmptyReply is sent when a trigger carries no message, e.g. a bare
+	// mention. Empty uses a short default prompt.
+	EmptyReply string `json:"empty_reply,omitempty"`
 }
 
 // TypingConfig controls typing indicator behavior (Phase 10).
@@ -299,6 +477,12 @@ type WhatsAppConfig struct {
 	SessionStorePath   string              `json:"session_store_path"   env:"PICOCLAW_CHANNELS_WHATSAPP_SESSION_STORE_PATH"`
 	AllowFrom          FlexibleStringSlice `json:"allow_from"           env:"PICOCLAW_CHANNELS_WHATSAPP_ALLOW_FROM"`
 	ReasoningChannelID string              `json:"reasoning_channel_id" env:"PICOCLAW_CHANNELS_WHATSAPP_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"   env:"PICOCLAW_CHANNELS_WHATSAPP_GREETING"`
+	Footer             string              `json:"footer,omitempty"     env:"PICOCLAW_CHANNELS_WHATSAPP_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_WHATSAPP_COALESCE_WINDOW_MS"`
+	MediaTypesAllow    FlexibleStringSlice `json:"media_types_allow,omitempty" env:"PICOCLAW_CHANNELS_WHATSAPP_MEDIA_TYPES_ALLOW"`
+	MediaTypesDeny     FlexibleStringSlice `json:"media_types_deny,omitempty" env:"PICOCLAW_CHANNELS_WHATSAPP_MEDIA_TYPES_DENY"`
+	MediaRejectedNote  string              `json:"media_rejected_note,omitempty" env:"PICOCLAW_CHANNELS_WHATSAPP_MEDIA_REJECTED_NOTE"`
 }
 
 type TelegramConfig struct {
@@ -311,6 +495,13 @@ type TelegramConfig struct {
 	Typing             TypingConfig        `json:"typing,omitempty"`
 	Placeholder        PlaceholderConfig   `json:"placeholder,omitempty"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_TELEGRAM_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_TELEGRAM_GREETING"`
+	Footer             string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_TELEGRAM_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_TELEGRAM_COALESCE_WINDOW_MS"`
+	MediaTypesAllow    FlexibleStringSlice `json:"media_types_allow,omitempty" env:"PICOCLAW_CHANNELS_TELEGRAM_MEDIA_TYPES_ALLOW"`
+	MediaTypesDeny     FlexibleStringSlice `json:"media_types_deny,omitempty" env:"PICOCLAW_CHANNELS_TELEGRAM_MEDIA_TYPES_DENY"`
+	MediaRejectedNote  string              `json:"media_rejected_note,omitempty" env:"PICOCLAW_CHANNELS_TELEGRAM_MEDIA_REJECTED_NOTE"`
+	DisableLinkPreview bool                `json:"disable_link_preview,omitempty" env:"PICOCLAW_CHANNELS_TELEGRAM_DISABLE_LINK_PREVIEW"`
 	UseMarkdownV2      bool                `json:"use_markdown_v2"         env:"PICOCLAW_CHANNELS_TELEGRAM_USE_MARKDOWN_V2"`
 }
 
@@ -324,6 +515,12 @@ type FeishuConfig struct {
 	GroupTrigger        GroupTriggerConfig  `json:"group_trigger,omitempty"`
 	Placeholder         PlaceholderConfig   `json:"placeholder,omitempty"`
 	ReasoningChannelID  string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_FEISHU_REASONING_CHANNEL_ID"`
+	Greeting            string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_FEISHU_GREETING"`
+	Footer              string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_FEISHU_FOOTER"`
+	CoalesceWindowMS    int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_FEISHU_COALESCE_WINDOW_MS"`
+	MediaTypesAllow     FlexibleStringSlice `json:"media_types_allow,omitempty" env:"PICOCLAW_CHANNELS_FEISHU_MEDIA_TYPES_ALLOW"`
+	MediaTypesDeny      FlexibleStringSlice `json:"media_types_deny,omitempty" env:"PICOCLAW_CHANNELS_FEISHU_MEDIA_TYPES_DENY"`
+	MediaRejectedNote   string              `json:"media_rejected_note,omitempty" env:"PICOCLAW_CHANNELS_FEISHU_MEDIA_REJECTED_NOTE"`
 	RandomReactionEmoji FlexibleStringSlice `json:"random_reaction_emoji"   env:"PICOCLAW_CHANNELS_FEISHU_RANDOM_REACTION_EMOJI"`
 	IsLark              bool                `json:"is_lark"                 env:"PICOCLAW_CHANNELS_FEISHU_IS_LARK"`
 }
@@ -338,6 +535,13 @@ type DiscordConfig struct {
 	Typing             TypingConfig        `json:"typing,omitempty"`
 	Placeholder        PlaceholderConfig   `json:"placeholder,omitempty"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_DISCORD_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_DISCORD_GREETING"`
+	Footer             string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_DISCORD_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_DISCORD_COALESCE_WINDOW_MS"`
+	MediaTypesAllow    FlexibleStringSlice `json:"media_types_allow,omitempty" env:"PICOCLAW_CHANNELS_DISCORD_MEDIA_TYPES_ALLOW"`
+	MediaTypesDeny     FlexibleStringSlice `json:"media_types_deny,omitempty" env:"PICOCLAW_CHANNELS_DISCORD_MEDIA_TYPES_DENY"`
+	MediaRejectedNote  string              `json:"media_rejected_note,omitempty" env:"PICOCLAW_CHANNELS_DISCORD_MEDIA_REJECTED_NOTE"`
+	DisableLinkPreview bool                `json:"disable_link_preview,omitempty" env:"PICOCLAW_CHANNELS_DISCORD_DISABLE_LINK_PREVIEW"`
 }
 
 type MaixCamConfig struct {
@@ -346,6 +550,9 @@ type MaixCamConfig struct {
 	Port               int                 `json:"port"                 env:"PICOCLAW_CHANNELS_MAIXCAM_PORT"`
 	AllowFrom          FlexibleStringSlice `json:"allow_from"           env:"PICOCLAW_CHANNELS_MAIXCAM_ALLOW_FROM"`
 	ReasoningChannelID string              `json:"reasoning_channel_id" env:"PICOCLAW_CHANNELS_MAIXCAM_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"   env:"PICOCLAW_CHANNELS_MAIXCAM_GREETING"`
+	Footer             string              `json:"footer,omitempty"     env:"PICOCLAW_CHANNELS_MAIXCAM_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_MAIXCAM_COALESCE_WINDOW_MS"`
 }
 
 type QQConfig struct {
@@ -357,6 +564,9 @@ type QQConfig struct {
 	MaxMessageLength   int                 `json:"max_message_length"      env:"PICOCLAW_CHANNELS_QQ_MAX_MESSAGE_LENGTH"`
 	SendMarkdown       bool                `json:"send_markdown"           env:"PICOCLAW_CHANNELS_QQ_SEND_MARKDOWN"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_QQ_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_QQ_GREETING"`
+	Footer             string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_QQ_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_QQ_COALESCE_WINDOW_MS"`
 }
 
 type DingTalkConfig struct {
@@ -366,6 +576,9 @@ type DingTalkConfig struct {
 	AllowFrom          FlexibleStringSlice `json:"allow_from"              env:"PICOCLAW_CHANNELS_DINGTALK_ALLOW_FROM"`
 	GroupTrigger       GroupTriggerConfig  `json:"group_trigger,omitempty"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_DINGTALK_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_DINGTALK_GREETING"`
+	Footer             string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_DINGTALK_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_DINGTALK_COALESCE_WINDOW_MS"`
 }
 
 type SlackConfig struct {
@@ -377,6 +590,13 @@ type SlackConfig struct {
 	Typing             TypingConfig        `json:"typing,omitempty"`
 	Placeholder        PlaceholderConfig   `json:"placeholder,omitempty"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_SLACK_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_SLACK_GREETING"`
+	Footer             string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_SLACK_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_SLACK_COALESCE_WINDOW_MS"`
+	MediaTypesAllow    FlexibleStringSlice `json:"media_types_allow,omitempty" env:"PICOCLAW_CHANNELS_SLACK_MEDIA_TYPES_ALLOW"`
+	MediaTypesDeny     FlexibleStringSlice `json:"media_types_deny,omitempty" env:"PICOCLAW_CHANNELS_SLACK_MEDIA_TYPES_DENY"`
+	MediaRejectedNote  string              `json:"media_rejected_note,omitempty" env:"PICOCLAW_CHANNELS_SLACK_MEDIA_REJECTED_NOTE"`
+	DisableLinkPreview bool                `json:"disable_link_preview,omitempty" env:"PICOCLAW_CHANNELS_SLACK_DISABLE_LINK_PREVIEW"`
 }
 
 type MatrixConfig struct {
@@ -391,6 +611,12 @@ type MatrixConfig struct {
 	GroupTrigger       GroupTriggerConfig  `json:"group_trigger,omitempty"`
 	Placeholder        PlaceholderConfig   `json:"placeholder,omitempty"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"     env:"PICOCLAW_CHANNELS_MATRIX_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"       env:"PICOCLAW_CHANNELS_MATRIX_GREETING"`
+	Footer             string              `json:"footer,omitempty"         env:"PICOCLAW_CHANNELS_MATRIX_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_MATRIX_COALESCE_WINDOW_MS"`
+	MediaTypesAllow    FlexibleStringSlice `json:"media_types_allow,omitempty" env:"PICOCLAW_CHANNELS_MATRIX_MEDIA_TYPES_ALLOW"`
+	MediaTypesDeny     FlexibleStringSlice `json:"media_types_deny,omitempty" env:"PICOCLAW_CHANNELS_MATRIX_MEDIA_TYPES_DENY"`
+	MediaRejectedNote  string              `json:"media_rejected_note,omitempty" env:"PICOCLAW_CHANNELS_MATRIX_MEDIA_REJECTED_NOTE"`
 }
 
 type LINEConfig struct {
@@ -405,6 +631,12 @@ type LINEConfig struct {
 	Typing             TypingConfig        `json:"typing,omitempty"`
 	Placeholder        PlaceholderConfig   `json:"placeholder,omitempty"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_LINE_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_LINE_GREETING"`
+	Footer             string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_LINE_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_LINE_COALESCE_WINDOW_MS"`
+	MediaTypesAllow    FlexibleStringSlice `json:"media_types_allow,omitempty" env:"PICOCLAW_CHANNELS_LINE_MEDIA_TYPES_ALLOW"`
+	MediaTypesDeny     FlexibleStringSlice `json:"media_types_deny,omitempty" env:"PICOCLAW_CHANNELS_LINE_MEDIA_TYPES_DENY"`
+	MediaRejectedNote  string              `json:"media_rejected_note,omitempty" env:"PICOCLAW_CHANNELS_LINE_MEDIA_REJECTED_NOTE"`
 }
 
 type OneBotConfig struct {
@@ -412,12 +644,21 @@ type OneBotConfig struct {
 	WSUrl              string              `json:"ws_url"                  env:"PICOCLAW_CHANNELS_ONEBOT_WS_URL"`
 	AccessToken        string              `json:"access_token"            env:"PICOCLAW_CHANNELS_ONEBOT_ACCESS_TOKEN"`
 	ReconnectInterval  int                 `json:"reconnect_interval"      env:"PICOCLAW_CHANNELS_ONEBOT_RECONNECT_INTERVAL"`
+	PingInterval       int                 `json:"ping_interval,omitempty" env:"PICOCLAW_CHANNELS_ONEBOT_PING_INTERVAL"`
+	ReadTimeout        int                 `json:"read_timeout,omitempty"  env:"PICOCLAW_CHANNELS_ONEBOT_READ_TIMEOUT"`
+	ReactionEmojiID    int                 `json:"reaction_emoji_id,omitempty" env:"PICOCLAW_CHANNELS_ONEBOT_REACTION_EMOJI_ID"`
 	GroupTriggerPrefix []string            `json:"group_trigger_prefix"    env:"PICOCLAW_CHANNELS_ONEBOT_GROUP_TRIGGER_PREFIX"`
 	AllowFrom          FlexibleStringSlice `json:"allow_from"              env:"PICOCLAW_CHANNELS_ONEBOT_ALLOW_FROM"`
 	GroupTrigger       GroupTriggerConfig  `json:"group_trigger,omitempty"`
 	Typing             TypingConfig        `json:"typing,omitempty"`
 	Placeholder        PlaceholderConfig   `json:"placeholder,omitempty"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_ONEBOT_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_ONEBOT_GREETING"`
+	Footer             string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_ONEBOT_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_ONEBOT_COALESCE_WINDOW_MS"`
+	MediaTypesAllow    FlexibleStringSlice `json:"media_types_allow,omitempty" env:"PICOCLAW_CHANNELS_ONEBOT_MEDIA_TYPES_ALLOW"`
+	MediaTypesDeny     FlexibleStringSlice `json:"media_types_deny,omitempty" env:"PICOCLAW_CHANNELS_ONEBOT_MEDIA_TYPES_DENY"`
+	MediaRejectedNote  string              `json:"media_rejected_note,omitempty" env:"PICOCLAW_CHANNELS_ONEBOT_MEDIA_REJECTED_NOTE"`
 }
 
 type WeComConfig struct {
@@ -432,6 +673,9 @@ type WeComConfig struct {
 	ReplyTimeout       int                 `json:"reply_timeout"           env:"PICOCLAW_CHANNELS_WECOM_REPLY_TIMEOUT"`
 	GroupTrigger       GroupTriggerConfig  `json:"group_trigger,omitempty"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_WECOM_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_WECOM_GREETING"`
+	Footer             string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_WECOM_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_WECOM_COALESCE_WINDOW_MS"`
 }
 
 type WeComAppConfig struct {
@@ -448,6 +692,13 @@ type WeComAppConfig struct {
 	ReplyTimeout       int                 `json:"reply_timeout"           env:"PICOCLAW_CHANNELS_WECOM_APP_REPLY_TIMEOUT"`
 	GroupTrigger       GroupTriggerConfig  `json:"group_trigger,omitempty"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_WECOM_APP_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_WECOM_APP_GREETING"`
+	Footer             string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_WECOM_APP_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_WECOM_APP_COALESCE_WINDOW_MS"`
+	MediaTypesAllow    FlexibleStringSlice `json:"media_types_allow,omitempty" env:"PICOCLAW_CHANNELS_WECOM_APP_MEDIA_TYPES_ALLOW"`
+	MediaTypesDeny     FlexibleStringSlice `json:"media_types_deny,omitempty" env:"PICOCLAW_CHANNELS_WECOM_APP_MEDIA_TYPES_DENY"`
+	MediaRejectedNote  string              `json:"media_rejected_note,omitempty" env:"PICOCLAW_CHANNELS_WECOM_APP_MEDIA_REJECTED_NOTE"`
+	PreferMarkdown     bool                `json:"prefer_markdown,omitempty" env:"PICOCLAW_CHANNELS_WECOM_APP_PREFER_MARKDOWN"`
 }
 
 type WeComAIBotConfig struct {
@@ -460,6 +711,9 @@ type WeComAIBotConfig struct {
 	MaxSteps           int                 `json:"max_steps"            env:"PICOCLAW_CHANNELS_WECOM_AIBOT_MAX_STEPS"`       // Maximum streaming steps
 	WelcomeMessage     string              `json:"welcome_message"      env:"PICOCLAW_CHANNELS_WECOM_AIBOT_WELCOME_MESSAGE"` // Sent on enter_chat event; empty = no welcome
 	ReasoningChannelID string              `json:"reasoning_channel_id" env:"PICOCLAW_CHANNELS_WECOM_AIBOT_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"   env:"PICOCLAW_CHANNELS_WECOM_AIBOT_GREETING"`
+	Footer             string              `json:"footer,omitempty"     env:"PICOCLAW_CHANNELS_WECOM_AIBOT_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_WECOM_AIBOT_COALESCE_WINDOW_MS"`
 }
 
 type PicoConfig struct {
@@ -492,11 +746,61 @@ type IRCConfig struct {
 	GroupTrigger       GroupTriggerConfig  `json:"group_trigger,omitempty"`
 	Typing             TypingConfig        `json:"typing,omitempty"`
 	ReasoningChannelID string              `json:"reasoning_channel_id"    env:"PICOCLAW_CHANNELS_IRC_REASONING_CHANNEL_ID"`
+	Greeting           string              `json:"greeting,omitempty"      env:"PICOCLAW_CHANNELS_IRC_GREETING"`
+	Footer             string              `json:"footer,omitempty"        env:"PICOCLAW_CHANNELS_IRC_FOOTER"`
+	CoalesceWindowMS   int                 `json:"coalesce_window_ms,omitempty" env:"PICOCLAW_CHANNELS_IRC_COALESCE_WINDOW_MS"`
 }
 
 type HeartbeatConfig struct {
-	Enabled  bool `json:"enabled"  env:"PICOCLAW_HEARTBEAT_ENABLED"`
-	Interval int  `json:"interval" env:"PICOCLAW_HEARTBEAT_INTERVAL"` // minutes, min 5
+	Enabled    bool             `json:"enabled"               env:"PICOCLAW_HEARTBEAT_ENABLED"`
+	Interval   int              `json:"interval"              env:"PICOCLAW_HEARTBEAT_INTERVAL"` // minutes, min 5
+	QuietHours QuietHoursConfig `json:"quiet_hours,omitempty"`
+	// DisableJitter fires heartbeats at exactly Interval instead of spreading
+	// them by ±HeartbeatJitter so restarted instances don't fire together.
+	DisableJitter bool `json:"disable_jitter,omitempty" env:"PICOCLAW_HEARTBEAT_DISABLE_JITTER"`
+}
+
+// Heartbeat interval bounds, in minutes, and the jitter fraction.
+const (
+	MinHeartbeatInterval     = 5
+	DefaultHeartbeatInterval = 30
+	HeartbeatJitter          = 0.1
+)
+
+// BaseInterval returns Interval as a duration, with 0 meaning
+// DefaultHeartbeatInterval and values below MinHeartbeatInterval clamped.
+func (h HeartbeatConfig) BaseInterval() time.Duration {
+	minutes := h.Interval
+	if minutes == 0 {
+		minutes = DefaultHeartbeatInterval
+	}
+	return time.Duration(max(minutes, MinHeartbeatInterval)) * time.Minute
+}
+
+// EffectiveInterval returns the clamped interval with up to ±HeartbeatJitter
+// applied, drawn anew on each call. With DisableJitter it equals
+// BaseInterval.
+func (h HeartbeatConfig) EffectiveInterval() time.Duration {
+	return h.jitteredInterval(rand.Float64())
+}
+
+// jitteredInterval scales BaseInterval by a factor in [1-HeartbeatJitter,
+// 1+HeartbeatJitter), where r is a uniform random value in [0, 1).
+func (h HeartbeatConfig) jitteredInterval(r float64) time.Duration {
+	base := h.BaseInterval()
+	if h.DisableJitter {
+		return base
+	}
+	return time.Duration(float64(base) * (1 + HeartbeatJitter*(2*r-1)))
+}
+
+// QuietHoursConfig suppresses heartbeats and other proactive messages during
+// a daily window. Replies to user messages are not affected.
+type QuietHoursConfig struct {
+	Enabled  bool   `json:"enabled,omitempty"`
+	Start    string `json:"start,omitempty"`    // HH:MM, e.g. "22:00"
+	End      string `json:"end,omitempty"`      // HH:MM, e.g. "07:00"
+	Timezone string `json:"timezone,omitempty"` // IANA name, empty = local time
 }
 
 type DevicesConfig struct {
@@ -615,12 +919,60 @@ type ModelConfig struct {
 	Workspace   string `json:"workspace,omitempty"`    // Workspace path for CLI-based providers
 
 	// Optional optimizations
-	RPM            int    `json:"rpm,omitempty"`              // Requests per minute limit
-	MaxTokensField string `json:"max_tokens_field,omitempty"` // Field name for max tokens (e.g., "max_completion_tokens")
-	RequestTimeout int    `json:"request_timeout,omitempty"`
-	ThinkingLevel  string `json:"thinking_level,omitempty"` // Extended thinking: off|low|medium|high|xhigh|adaptive
+	RPM             int    `json:"rpm,omitempty"`              // Requests per minute limit
+	MaxTokensField  string `json:"max_tokens_field,omitempty"` // Field name for max tokens (e.g., "max_completion_tokens")
+	RequestTimeout  int    `json:"request_timeout,omitempty"`
+	CooldownSeconds int    `json:"cooldown_seconds,omitempty"` // Base fallback cooldown; 0 uses fallback_cooldown_seconds
+	ThinkingLevel   string `json:"thinking_level,omitempty"`   // Extended thinking: off|low|medium|high|xhigh|adaptive
+
+	// SupportsTools set to false marks a model without native function
+	// calling. Tool definitions are then withheld from its requests.
+	SupportsTools *bool `json:"supports_tools,omitempty"`
+
+	// ToolProtocol selects how tools are offered to the model: "native"
+	// (default) uses the provider's function calling, "prompt" describes the
+	// tools in the system prompt and parses Action/Args blocks from replies.
+	ToolProtocol string `json:"tool_protocol,omitempty"`
+
+	// Pricing sets the model's price so that session.max_cost and
+	// user.daily_cost_limit can be enforced. Models without it cost nothing.
+	Pricing *ModelPricing `json:"pricing,omitempty"`
+
+	// ExtraBody holds provider-specific fields merged into each request body
+	// (e.g. "reasoning_effort"). It cannot override ReservedExtraBodyFields.
+	ExtraBody map[string]any `json:"extra_body,omitempty"`
+}
+
+// ToolCallingSupported reports whether the model accepts tool definitions.
+// Models are assumed to support tool calling unless supports_tools is false.
+func (c *ModelConfig) ToolCallingSupported() bool {
+	return c.SupportsTools == nil || *c.SupportsTools
+}
+
+// ModelPricing is the price of a model in USD per million tokens.
+type ModelPricing struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// Cost returns the price in USD of a request with the given token counts.
+func (p *ModelPricing) Cost(promptTokens, completionTokens int) float64 {
+	if p == nil {
+		return 0
+	}
+	return (float64(promptTokens)*p.InputPerMillion + float64(completionTokens)*p.OutputPerMillion) / 1e6
 }
 
+// Tool protocols accepted by ModelConfig.ToolProtocol.
+const (
+	ToolProtocolNative = "native"
+	ToolProtocolPrompt = "prompt"
+)
+
+// ReservedExtraBodyFields are request fields that extra_body may not set,
+// because the agent builds them from the conversation itself.
+var ReservedExtraBodyFields = []string{"model", "messages", "tools", "tool_choice", "stream"}
+
 // Validate checks if the ModelConfig has all required fields.
 func (c *ModelConfig) Validate() error {
 	if c.ModelName == "" {
@@ -629,6 +981,22 @@ func (c *ModelConfig) Validate() error {
 	if c.Model == "" {
 		return fmt.Errorf("model is required")
 	}
+	if c.CooldownSeconds < 0 {
+		return fmt.Errorf("cooldown_seconds must not be negative")
+	}
+	if c.Pricing != nil && (c.Pricing.InputPerMillion < 0 || c.Pricing.OutputPerMillion < 0) {
+		return fmt.Errorf("pricing must not be negative")
+	}
+	switch c.ToolProtocol {
+	case "", ToolProtocolNative, ToolProtocolPrompt:
+	default:
+		return fmt.Errorf("tool_protocol must be %q or %q", ToolProtocolNative, ToolProtocolPrompt)
+	}
+	for _, field := range ReservedExtraBodyFields {
+		if _, ok := c.ExtraBody[field]; ok {
+			return fmt.Errorf("extra_body must not set reserved field %q", field)
+		}
+	}
 	return nil
 }
 
@@ -707,12 +1075,44 @@ type WebToolsConfig struct {
 	// and the provider's built-in search is used instead. Falls back to client-side
 	// search when the provider does not support native search.
 	PreferNative bool `json:"prefer_native" env:"PICOCLAW_TOOLS_WEB_PREFER_NATIVE"`
+	// SearchMode selects which search surface the model sees: "native"
+	// (provider built-in only), "local" (client-side web_search only) or
+	// "both". Empty derives the mode from PreferNative.
+	SearchMode string `json:"search_mode,omitempty" env:"PICOCLAW_TOOLS_WEB_SEARCH_MODE"`
 	// Proxy is an optional proxy URL for web tools (http/https/socks5/socks5h).
 	// For authenticated proxies, prefer HTTP_PROXY/HTTPS_PROXY env vars instead of embedding credentials in config.
 	Proxy                string              `json:"proxy,omitempty"                  env:"PICOCLAW_TOOLS_WEB_PROXY"`
 	FetchLimitBytes      int64               `json:"fetch_limit_bytes,omitempty"      env:"PICOCLAW_TOOLS_WEB_FETCH_LIMIT_BYTES"`
 	Format               string              `json:"format,omitempty"                 env:"PICOCLAW_TOOLS_WEB_FORMAT"`
 	PrivateHostWhitelist FlexibleStringSlice `json:"private_host_whitelist,omitempty" env:"PICOCLAW_TOOLS_WEB_PRIVATE_HOST_WHITELIST"`
+	MaxTotalResults      int                 `json:"max_total_results,omitempty"      env:"PICOCLAW_TOOLS_WEB_MAX_TOTAL_RESULTS"`
+	// EngineOrder lists search engines to try first, e.g. ["searxng", "brave"].
+	// Enabled engines not listed follow in the built-in order.
+	EngineOrder FlexibleStringSlice `json:"engine_order,omitempty" env:"PICOCLAW_TOOLS_WEB_ENGINE_ORDER"`
+}
+
+// WebSearchEngines names the engines tools.web.engine_order may list, in the
+// built-in order web_search tries them.
+var WebSearchEngines = []string{"perplexity", "brave", "searxng", "tavily", "duckduckgo", "glm_search"}
+
+// Web search modes for WebToolsConfig.SearchMode.
+const (
+	WebSearchModeNative = "native"
+	WebSearchModeLocal  = "local"
+	WebSearchModeBoth   = "both"
+)
+
+// EffectiveSearchMode returns the configured search mode, falling back to
+// PreferNative when SearchMode is unset or unrecognized.
+func (c *WebToolsConfig) EffectiveSearchMode() string {
+	switch mode := strings.ToLower(strings.TrimSpace(c.SearchMode)); mode {
+	case WebSearchModeNative, WebSearchModeLocal, WebSearchModeBoth:
+		return mode
+	}
+	if c.PreferNative {
+		return WebSearchModeNative
+	}
+	return WebSearchModeLocal
 }
 
 type CronToolsConfig struct {
@@ -749,30 +1149,75 @@ type ReadFileToolConfig struct {
 	MaxReadFileSize int  `json:"max_read_file_size"`
 }
 
+type ReadDocumentToolConfig struct {
+	Enabled  bool `json:"enabled"`
+	MaxChars int  `json:"max_chars,omitempty"` // extracted text cap, default 50000
+}
+
 type ToolsConfig struct {
-	AllowReadPaths  []string           `json:"allow_read_paths"  env:"PICOCLAW_TOOLS_ALLOW_READ_PATHS"`
-	AllowWritePaths []string           `json:"allow_write_paths" env:"PICOCLAW_TOOLS_ALLOW_WRITE_PATHS"`
-	Web             WebToolsConfig     `json:"web"`
-	Cron            CronToolsConfig    `json:"cron"`
-	Exec            ExecConfig         `json:"exec"`
-	Skills          SkillsToolsConfig  `json:"skills"`
-	MediaCleanup    MediaCleanupConfig `json:"media_cleanup"`
-	MCP             MCPConfig          `json:"mcp"`
-	AppendFile      ToolConfig         `json:"append_file"                                              envPrefix:"PICOCLAW_TOOLS_APPEND_FILE_"`
-	EditFile        ToolConfig         `json:"edit_file"                                                envPrefix:"PICOCLAW_TOOLS_EDIT_FILE_"`
-	FindSkills      ToolConfig         `json:"find_skills"                                              envPrefix:"PICOCLAW_TOOLS_FIND_SKILLS_"`
-	I2C             ToolConfig         `json:"i2c"                                                      envPrefix:"PICOCLAW_TOOLS_I2C_"`
-	InstallSkill    ToolConfig         `json:"install_skill"                                            envPrefix:"PICOCLAW_TOOLS_INSTALL_SKILL_"`
-	ListDir         ToolConfig         `json:"list_dir"                                                 envPrefix:"PICOCLAW_TOOLS_LIST_DIR_"`
-	Message         ToolConfig         `json:"message"                                                  envPrefix:"PICOCLAW_TOOLS_MESSAGE_"`
-	ReadFile        ReadFileToolConfig `json:"read_file"                                                envPrefix:"PICOCLAW_TOOLS_READ_FILE_"`
-	SendFile        ToolConfig         `json:"send_file"                                                envPrefix:"PICOCLAW_TOOLS_SEND_FILE_"`
-	Spawn           ToolConfig         `json:"spawn"                                                    envPrefix:"PICOCLAW_TOOLS_SPAWN_"`
-	SpawnStatus     ToolConfig         `json:"spawn_status"                                             envPrefix:"PICOCLAW_TOOLS_SPAWN_STATUS_"`
-	SPI             ToolConfig         `json:"spi"                                                      envPrefix:"PICOCLAW_TOOLS_SPI_"`
-	Subagent        ToolConfig         `json:"subagent"                                                 envPrefix:"PICOCLAW_TOOLS_SUBAGENT_"`
-	WebFetch        ToolConfig         `json:"web_fetch"                                                envPrefix:"PICOCLAW_TOOLS_WEB_FETCH_"`
-	WriteFile       ToolConfig         `json:"write_file"                                               envPrefix:"PICOCLAW_TOOLS_WRITE_FILE_"`
+	AllowReadPaths   []string                   `json:"allow_read_paths"  env:"PICOCLAW_TOOLS_ALLOW_READ_PATHS"`
+	AllowWritePaths  []string                   `json:"allow_write_paths" env:"PICOCLAW_TOOLS_ALLOW_WRITE_PATHS"`
+	Web              WebToolsConfig             `json:"web"`
+	Cron             CronToolsConfig            `json:"cron"`
+	Exec             ExecConfig                 `json:"exec"`
+	Skills           SkillsToolsConfig          `json:"skills"`
+	MediaCleanup     MediaCleanupConfig         `json:"media_cleanup"`
+	MCP              MCPConfig                  `json:"mcp"`
+	DailyQuotas      map[string]int             `json:"daily_quotas,omitempty"`                                    // per-user daily call caps by tool name
+	CallTimeout      int                        `json:"call_timeout,omitempty"  env:"PICOCLAW_TOOLS_CALL_TIMEOUT"` // seconds per call; 0 = 120s, <0 = none
+	ResultTruncation ToolResultTruncationConfig `json:"result_truncation"`
+	AppendFile       ToolConfig                 `json:"append_file"                                              envPrefix:"PICOCLAW_TOOLS_APPEND_FILE_"`
+	AskUser          ToolConfig                 `json:"ask_user"                                                 envPrefix:"PICOCLAW_TOOLS_ASK_USER_"`
+	EditFile         ToolConfig                 `json:"edit_file"                                                envPrefix:"PICOCLAW_TOOLS_EDIT_FILE_"`
+	FetchFeed        ToolConfig                 `json:"fetch_feed"                                               envPrefix:"PICOCLAW_TOOLS_FETCH_FEED_"`
+	FindSkills       ToolConfig                 `json:"find_skills"                                              envPrefix:"PICOCLAW_TOOLS_FIND_SKILLS_"`
+	GPIO             ToolConfig                 `json:"gpio"                                                     envPrefix:"PICOCLAW_TOOLS_GPIO_"`
+	I2C              ToolConfig                 `json:"i2c"                                                      envPrefix:"PICOCLAW_TOOLS_I2C_"`
+	InstallSkill     ToolConfig                 `json:"install_skill"                                            envPrefix:"PICOCLAW_TOOLS_INSTALL_SKILL_"`
+	ListAttachments  ToolConfig                 `json:"list_attachments"                                         envPrefix:"PICOCLAW_TOOLS_LIST_ATTACHMENTS_"`
+	ListDir          ToolConfig                 `json:"list_dir"                                                 envPrefix:"PICOCLAW_TOOLS_LIST_DIR_"`
+	Message          ToolConfig                 `json:"message"                                                  envPrefix:"PICOCLAW_TOOLS_MESSAGE_"`
+	ReadDocument     ReadDocumentToolConfig     `json:"read_document"                                            envPrefix:"PICOCLAW_TOOLS_READ_DOCUMENT_"`
+	ReadFile         ReadFileToolConfig         `json:"read_file"                                                envPrefix:"PICOCLAW_TOOLS_READ_FILE_"`
+	Scratchpad       ToolConfig                 `json:"scratchpad"                                               envPrefix:"PICOCLAW_TOOLS_SCRATCHPAD_"`
+	SendFile         ToolConfig                 `json:"send_file"                                                envPrefix:"PICOCLAW_TOOLS_SEND_FILE_"`
+	Spawn            ToolConfig                 `json:"spawn"                                                    envPrefix:"PICOCLAW_TOOLS_SPAWN_"`
+	SpawnStatus      ToolConfig                 `json:"spawn_status"                                             envPrefix:"PICOCLAW_TOOLS_SPAWN_STATUS_"`
+	SPI              ToolConfig                 `json:"spi"                                                      envPrefix:"PICOCLAW_TOOLS_SPI_"`
+	Subagent         ToolConfig                 `json:"subagent"                                                 envPrefix:"PICOCLAW_TOOLS_SUBAGENT_"`
+	SummarizeURL     ToolConfig                 `json:"summarize_url"                                            envPrefix:"PICOCLAW_TOOLS_SUMMARIZE_URL_"`
+	WebFetch         ToolConfig                 `json:"web_fetch"                                                envPrefix:"PICOCLAW_TOOLS_WEB_FETCH_"`
+	WriteFile        ToolConfig                 `json:"write_file"                                               envPrefix:"PICOCLAW_TOOLS_WRITE_FILE_"`
+}
+
+// ToolResultTruncationConfig controls how tool results are shortened before
+// they are sent to the LLM. A MaxChars of 0 disables truncation.
+type ToolResultTruncationConfig struct {
+	Strategy string                              `json:"strategy,omitempty"  env:"PICOCLAW_TOOLS_RESULT_TRUNCATION_STRATEGY"`
+	MaxChars int                                 `json:"max_chars,omitempty" env:"PICOCLAW_TOOLS_RESULT_TRUNCATION_MAX_CHARS"`
+	PerTool  map[string]ToolResultTruncationRule `json:"per_tool,omitempty"`
+}
+
+// ToolResultTruncationRule overrides the truncation policy for one tool.
+// Empty fields inherit the global setting; a negative MaxChars disables
+// truncation for the tool.
+type ToolResultTruncationRule struct {
+	Strategy string `json:"strategy,omitempty"`
+	MaxChars int    `json:"max_chars,omitempty"`
+}
+
+// RuleFor returns the effective truncation policy for the named tool.
+func (c ToolResultTruncationConfig) RuleFor(tool string) ToolResultTruncationRule {
+	rule := ToolResultTruncationRule{Strategy: c.Strategy, MaxChars: c.MaxChars}
+	if override, ok := c.PerTool[tool]; ok {
+		if override.Strategy != "" {
+			rule.Strategy = override.Strategy
+		}
+		if override.MaxChars != 0 {
+			rule.MaxChars = override.MaxChars
+		}
+	}
+	return rule
 }
 
 type SearchCacheConfig struct {
@@ -830,14 +1275,66 @@ type MCPConfig struct {
 }
 
 func LoadConfig(path string) (*Config, error) {
+	cfg, found, err := readConfig(path)
+	if err != nil || !found {
+		return cfg, err
+	}
+
+	// Validate model_list for uniqueness and required fields
+	if err := cfg.ValidateModelList(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.ValidateAgents(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.ValidateChannels(); err != nil {
+		return nil, err
+	}
+
+	if n := cfg.footprint(); n > FootprintWarnThreshold {
+		fmt.Fprintf(os.Stderr,
+			"picoclaw: warning: %d enabled channels x %d agents = %d exceeds %d; "+
+				"expect a large worker and memory footprint\n",
+			len(cfg.Channels.EnabledNames()), max(len(cfg.Agents.List), 1), n, FootprintWarnThreshold)
+	}
+
+	if n := cfg.Heartbeat.Interval; n != 0 && n < MinHeartbeatInterval {
+		cfg.Heartbeat.Interval = MinHeartbeatInterval
+		fmt.Fprintf(os.Stderr, "picoclaw: warning: heartbeat.interval %d is below the %d-minute minimum; using %d\n",
+			n, MinHeartbeatInterval, MinHeartbeatInterval)
+	}
+
+	if r := cfg.Session.CompressionDropRatio; r < MinCompressionDropRatio || r > MaxCompressionDropRatio {
+		cfg.Session.CompressionDropRatio = min(max(r, MinCompressionDropRatio), MaxCompressionDropRatio)
+		fmt.Fprintf(os.Stderr,
+			"picoclaw: warning: session.compression_drop_ratio %g is outside %g-%g; using %g\n",
+			r, MinCompressionDropRatio, MaxCompressionDropRatio, cfg.Session.CompressionDropRatio)
+	}
+
+	return cfg, nil
+}
+
+// ReadConfig loads path like LoadConfig, including env overrides and
+// migrations, but skips validation so Validate can report every problem. A
+// missing file yields the default config.
+func ReadConfig(path string) (*Config, error) {
+	cfg, _, err := readConfig(path)
+	return cfg, err
+}
+
+// readConfig reads, decodes and migrates the config at path. found is false
+// when the file does not exist and the defaults were returned.
+func readConfig(path string) (*Config, bool, error) {
 	cfg := DefaultConfig()
 
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return cfg, nil
+			return cfg, false, nil
 		}
-		return nil, err
+		return nil, false, err
 	}
 
 	// Pre-scan the JSON to check how many model_list entries the user provided.
@@ -848,14 +1345,14 @@ func LoadConfig(path string) (*Config, error) {
 	// entries; when count is 0 we keep DefaultConfig's built-in list as fallback.
 	var tmp Config
 	if err := json.Unmarshal(data, &tmp); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if len(tmp.ModelList) > 0 {
 		cfg.ModelList = nil
 	}
 
 	if err := json.Unmarshal(data, cfg); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	if passphrase := credential.PassphraseProvider(); passphrase != "" {
@@ -869,11 +1366,11 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	if err := env.Parse(cfg); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	if err := resolveAPIKeys(cfg.ModelList, filepath.Dir(path)); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	// Expand multi-key configs into separate entries for key-level failover
@@ -887,12 +1384,7 @@ func LoadConfig(path string) (*Config, error) {
 		cfg.ModelList = ConvertProvidersToModelList(cfg)
 	}
 
-	// Validate model_list for uniqueness and required fields
-	if err := cfg.ValidateModelList(); err != nil {
-		return nil, err
-	}
-
-	return cfg, nil
+	return cfg, true, nil
 }
 
 // encryptPlaintextAPIKeys returns a copy of models with plaintext api_key values
@@ -1048,10 +1540,15 @@ func expandHome(path string) string {
 
 // GetModelConfig returns the ModelConfig for the given model name.
 // If multiple configs exist with the same model_name, it uses round-robin
-// selection for load balancing. Returns an error if the model is not found.
+// selection for load balancing. Returns an error if the model is not found,
+// suggesting the closest configured model_name when there is one.
 func (c *Config) GetModelConfig(modelName string) (*ModelConfig, error) {
 	matches := c.findMatches(modelName)
 	if len(matches) == 0 {
+		if suggestion := c.suggestModelName(modelName); suggestion != "" {
+			return nil, fmt.Errorf("model %q not found in model_list or providers; did you mean %q?",
+				modelName, suggestion)
+		}
 		return nil, fmt.Errorf("model %q not found in model_list or providers", modelName)
 	}
 	if len(matches) == 1 {
@@ -1063,17 +1560,124 @@ func (c *Config) GetModelConfig(modelName string) (*ModelConfig, error) {
 	return &matches[idx], nil
 }
 
-// findMatches finds all ModelConfig entries with the given model_name.
+// findMatches finds all ModelConfig entries with the given model_name. An
+// exact match wins; otherwise model_aliases is consulted, then, when
+// normalize_model_names is set, names equal after normalization.
 func (c *Config) findMatches(modelName string) []ModelConfig {
+	if matches := c.lookupModelName(modelName); len(matches) > 0 {
+		return matches
+	}
+	if target, ok := c.resolveModelAlias(modelName); ok {
+		return c.lookupModelName(target)
+	}
+	return nil
+}
+
+func (c *Config) lookupModelName(modelName string) []ModelConfig {
 	var matches []ModelConfig
 	for i := range c.ModelList {
 		if c.ModelList[i].ModelName == modelName {
 			matches = append(matches, c.ModelList[i])
 		}
 	}
+	if len(matches) > 0 || !c.NormalizeModelNames {
+		return matches
+	}
+
+	// Normalized names must point at a single model_name; "gpt4o" matching
+	// both "gpt-4o" and "GPT_4o" is ambiguous and treated as a miss.
+	want := normalizeModelName(modelName)
+	resolved := ""
+	for i := range c.ModelList {
+		name := c.ModelList[i].ModelName
+		if normalizeModelName(name) != want {
+			continue
+		}
+		if resolved != "" && resolved != name {
+			return nil
+		}
+		resolved = name
+		matches = append(matches, c.ModelList[i])
+	}
 	return matches
 }
 
+func (c *Config) resolveModelAlias(modelName string) (string, bool) {
+	if target, ok := c.ModelAliases[modelName]; ok {
+		return target, true
+	}
+	if !c.NormalizeModelNames {
+		return "", false
+	}
+	want := normalizeModelName(modelName)
+	for alias, target := range c.ModelAliases {
+		if normalizeModelName(alias) == want {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// normalizeModelName lowercases name and drops the separators users most
+// often get wrong.
+func normalizeModelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', ' ':
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, strings.TrimSpace(name))
+}
+
+// suggestModelName returns the configured model_name or alias closest to
+// modelName by edit distance, or "" when none is close enough to be a typo.
+func (c *Config) suggestModelName(modelName string) string {
+	want := strings.ToLower(modelName)
+	best, bestDist := "", -1
+	consider := func(name string) {
+		if name == "" {
+			return
+		}
+		dist := editDistance(want, strings.ToLower(name))
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = name, dist
+		}
+	}
+	for i := range c.ModelList {
+		consider(c.ModelList[i].ModelName)
+	}
+	for alias := range c.ModelAliases {
+		consider(alias)
+	}
+	if bestDist < 0 || bestDist > max(2, len([]rune(modelName))/3) {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
 // HasProvidersConfig checks if any provider in the old providers config has configuration.
 func (c *Config) HasProvidersConfig() bool {
 	return !c.Providers.IsEmpty()
@@ -1091,6 +1695,92 @@ func (c *Config) ValidateModelList() error {
 	return nil
 }
 
+// ValidateAgents checks per-agent settings in agents.list.
+func (c *Config) ValidateAgents() error {
+	if c.Agents.MaxAgents < 0 || c.Agents.MaxBindings < 0 {
+		return fmt.Errorf("agents.max_agents and agents.max_bindings must not be negative")
+	}
+	if limit, n := c.Agents.MaxAgents, len(c.Agents.List); limit > 0 && n > limit {
+		return fmt.Errorf("agents.list has %d agents, more than agents.max_agents (%d)", n, limit)
+	}
+	if limit, n := c.Agents.MaxBindings, len(c.Bindings); limit > 0 && n > limit {
+		return fmt.Errorf("bindings has %d entries, more than agents.max_bindings (%d)", n, limit)
+	}
+	for i, a := range c.Agents.List {
+		if a.MaxToolIterations != nil && *a.MaxToolIterations < 1 {
+			return fmt.Errorf("agents.list[%d] (%s): max_tool_iterations must be at least 1, got %d",
+				i, a.ID, *a.MaxToolIterations)
+		}
+		if a.DefaultTarget != "" {
+			channel, _, ok := ParseTarget(a.DefaultTarget)
+			if !ok {
+				return fmt.Errorf("agents.list[%d] (%s): default_target must be \"channel:chat_id\", got %q",
+					i, a.ID, a.DefaultTarget)
+			}
+			if !c.Channels.IsEnabled(channel) {
+				return fmt.Errorf("agents.list[%d] (%s): default_target channel %q is not enabled",
+					i, a.ID, channel)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseTarget splits a "channel:chat_id" target. The chat ID may itself
+// contain colons.
+func ParseTarget(target string) (channel, chatID string, ok bool) {
+	channel, chatID, ok = strings.Cut(strings.TrimSpace(target), ":")
+	if !ok || channel == "" || chatID == "" {
+		return "", "", false
+	}
+	return channel, chatID, true
+}
+
+// AgentDefaultTarget returns the default_target of the agent agentID. An
+// empty agentID means the default agent: the one marked default, or else the
+// first listed. ok is false when the agent is not listed or has no target.
+func (c *Config) AgentDefaultTarget(agentID string) (channel, chatID string, ok bool) {
+	if len(c.Agents.List) == 0 {
+		return "", "", false
+	}
+	agentID = strings.TrimSpace(agentID)
+	if agentID != "" {
+		for _, a := range c.Agents.List {
+			if strings.EqualFold(strings.TrimSpace(a.ID), agentID) {
+				return ParseTarget(a.DefaultTarget)
+			}
+		}
+		return "", "", false
+	}
+	agent := c.Agents.List[0]
+	for _, a := range c.Agents.List {
+		if a.Default && strings.TrimSpace(a.ID) != "" {
+			agent = a
+			break
+		}
+	}
+	return ParseTarget(agent.DefaultTarget)
+}
+
+// ValidateChannels checks channel settings that have no usable fallback.
+func (c *Config) ValidateChannels() error {
+	if id := c.Channels.OneBot.ReactionEmojiID; id <= 0 {
+		return fmt.Errorf("channels.onebot.reaction_emoji_id must be a positive integer, got %d", id)
+	}
+	// Unset values fall back to the channel defaults of 30s and 60s.
+	ping, read := c.Channels.OneBot.PingInterval, c.Channels.OneBot.ReadTimeout
+	if ping <= 0 {
+		ping = 30
+	}
+	if read <= 0 {
+		read = 60
+	}
+	if read <= ping {
+		return fmt.Errorf("channels.onebot.read_timeout (%ds) must be greater than ping_interval (%ds)", read, ping)
+	}
+	return nil
+}
+
 func MergeAPIKeys(apiKey string, apiKeys []string) []string {
 	seen := make(map[string]struct{})
 	var all []string
@@ -1149,47 +1839,21 @@ func ExpandMultiKeyModels(models []ModelConfig) []ModelConfig {
 			suffix := fmt.Sprintf("__key_%d", i)
 			expandedName := originalName + suffix
 
-			// Create a copy for the additional key
-			additionalEntry := ModelConfig{
-				ModelName:      expandedName,
-				Model:          m.Model,
-				APIBase:        m.APIBase,
-				APIKey:         keys[i],
-				Proxy:          m.Proxy,
-				AuthMethod:     m.AuthMethod,
-				ConnectMode:    m.ConnectMode,
-				Workspace:      m.Workspace,
-				RPM:            m.RPM,
-				MaxTokensField: m.MaxTokensField,
-				RequestTimeout: m.RequestTimeout,
-				ThinkingLevel:  m.ThinkingLevel,
-			}
+			// Copy the whole entry so every optional field carries over
+			additionalEntry := m
+			additionalEntry.ModelName = expandedName
+			additionalEntry.APIKey = keys[i]
+			additionalEntry.APIKeys = nil
+			additionalEntry.Fallbacks = nil
 			expanded = append(expanded, additionalEntry)
 			fallbackNames = append(fallbackNames, expandedName)
 		}
 
-		// Create the primary entry with first key and fallbacks
-		primaryEntry := ModelConfig{
-			ModelName:      originalName,
-			Model:          m.Model,
-			APIBase:        m.APIBase,
-			APIKey:         keys[0],
-			Proxy:          m.Proxy,
-			AuthMethod:     m.AuthMethod,
-			ConnectMode:    m.ConnectMode,
-			Workspace:      m.Workspace,
-			RPM:            m.RPM,
-			MaxTokensField: m.MaxTokensField,
-			RequestTimeout: m.RequestTimeout,
-			ThinkingLevel:  m.ThinkingLevel,
-		}
-
-		// Prepend new fallbacks to existing ones
-		if len(fallbackNames) > 0 {
-			primaryEntry.Fallbacks = append(fallbackNames, m.Fallbacks...)
-		} else if len(m.Fallbacks) > 0 {
-			primaryEntry.Fallbacks = m.Fallbacks
-		}
+		// The primary entry keeps the first key and falls back to the others
+		primaryEntry := m
+		primaryEntry.APIKey = keys[0]
+		primaryEntry.APIKeys = nil
+		primaryEntry.Fallbacks = append(fallbackNames, m.Fallbacks...)
 
 		expanded = append(expanded, primaryEntry)
 	}
@@ -1211,20 +1875,32 @@ func (t *ToolsConfig) IsToolEnabled(name string) bool {
 		return t.MediaCleanup.Enabled
 	case "append_file":
 		return t.AppendFile.Enabled
+	case "ask_user":
+		return t.AskUser.Enabled
 	case "edit_file":
 		return t.EditFile.Enabled
+	case "fetch_feed":
+		return t.FetchFeed.Enabled
 	case "find_skills":
 		return t.FindSkills.Enabled
+	case "gpio":
+		return t.GPIO.Enabled
 	case "i2c":
 		return t.I2C.Enabled
 	case "install_skill":
 		return t.InstallSkill.Enabled
+	case "list_attachments":
+		return t.ListAttachments.Enabled
 	case "list_dir":
 		return t.ListDir.Enabled
 	case "message":
 		return t.Message.Enabled
+	case "read_document":
+		return t.ReadDocument.Enabled
 	case "read_file":
 		return t.ReadFile.Enabled
+	case "scratchpad":
+		return t.Scratchpad.Enabled
 	case "spawn":
 		return t.Spawn.Enabled
 	case "spawn_status":
@@ -1233,6 +1909,8 @@ func (t *ToolsConfig) IsToolEnabled(name string) bool {
 		return t.SPI.Enabled
 	case "subagent":
 		return t.Subagent.Enabled
+	case "summarize_url":
+		return t.SummarizeURL.Enabled
 	case "web_fetch":
 		return t.WebFetch.Enabled
 	case "send_file":
@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sipeed/picoclaw/pkg/credential"
 )
@@ -77,6 +78,160 @@ func TestAgentModelConfig_MarshalObject(t *testing.T) {
 	}
 }
 
+func TestAgentConfig_MaxToolIterationsRoundTrip(t *testing.T) {
+	iterations := 50
+	data, err := json.Marshal(AgentConfig{ID: "research", MaxToolIterations: &iterations})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var got AgentConfig
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.MaxToolIterations == nil || *got.MaxToolIterations != 50 {
+		t.Errorf("MaxToolIterations = %v, want 50", got.MaxToolIterations)
+	}
+
+	// An unset override is omitted and decodes as nil (inherit the default).
+	data, err = json.Marshal(AgentConfig{ID: "main"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if strings.Contains(string(data), "max_tool_iterations") {
+		t.Errorf("unset override should be omitted, got %s", data)
+	}
+	got = AgentConfig{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.MaxToolIterations != nil {
+		t.Errorf("MaxToolIterations = %d, want nil", *got.MaxToolIterations)
+	}
+}
+
+func TestLoadConfig_AgentMaxToolIterations(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	data := `{"agents":{"list":[{"id":"qa","max_tool_iterations":2},{"id":"research","max_tool_iterations":15}]}}`
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if got := cfg.Agents.List[0].MaxToolIterations; got == nil || *got != 2 {
+		t.Errorf("qa max_tool_iterations = %v, want 2", got)
+	}
+
+	data = `{"agents":{"list":[{"id":"qa","max_tool_iterations":0}]}}`
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), "max_tool_iterations") {
+		t.Errorf("LoadConfig() error = %v, want max_tool_iterations validation error", err)
+	}
+}
+
+func TestLoadConfig_AgentDefaultTarget(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	for _, tc := range []struct {
+		data    string
+		wantErr string
+	}{
+		{
+			`{"channels":{"telegram":{"enabled":true}},` +
+				`"agents":{"list":[{"id":"main","default_target":"telegram:-100:42"}]}}`,
+			"",
+		},
+		{`{"agents":{"list":[{"id":"main","default_target":"telegram"}]}}`, "channel:chat_id"},
+		{`{"agents":{"list":[{"id":"main","default_target":"telegram:123"}]}}`, "not enabled"},
+	} {
+		if err := os.WriteFile(configPath, []byte(tc.data), 0o600); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		cfg, err := LoadConfig(configPath)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tc.data, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: LoadConfig() error: %v", tc.data, err)
+		}
+		channel, chatID, ok := cfg.AgentDefaultTarget("main")
+		if !ok || channel != "telegram" || chatID != "-100:42" {
+			t.Errorf("AgentDefaultTarget() = %q, %q, %v, want telegram, -100:42", channel, chatID, ok)
+		}
+	}
+}
+
+func TestConfig_AgentDefaultTarget(t *testing.T) {
+	cfg := &Config{}
+	if _, _, ok := cfg.AgentDefaultTarget(""); ok {
+		t.Error("AgentDefaultTarget() ok with no agents")
+	}
+	cfg.Agents.List = []AgentConfig{
+		{ID: "first", DefaultTarget: "slack:C1"},
+		{ID: "main", Default: true, DefaultTarget: "discord:42"},
+		{ID: "quiet"},
+	}
+	for _, tc := range []struct {
+		agentID, channel, chatID string
+		ok                       bool
+	}{
+		{"", "discord", "42", true},
+		{"first", "slack", "C1", true},
+		{"Main", "discord", "42", true},
+		{"quiet", "", "", false},
+		{"unknown", "", "", false},
+	} {
+		channel, chatID, ok := cfg.AgentDefaultTarget(tc.agentID)
+		if channel != tc.channel || chatID != tc.chatID || ok != tc.ok {
+			t.Errorf("AgentDefaultTarget(%q) = %q, %q, %v, want %q, %q, %v",
+				tc.agentID, channel, chatID, ok, tc.channel, tc.chatID, tc.ok)
+		}
+	}
+}
+
+func TestValidateAgents_Limits(t *testing.T) {
+	cfg := &Config{}
+	cfg.Agents.List = []AgentConfig{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	cfg.Bindings = []AgentBinding{{AgentID: "a"}, {AgentID: "b"}}
+	if err := cfg.ValidateAgents(); err != nil {
+		t.Fatalf("ValidateAgents() without limits error: %v", err)
+	}
+
+	cfg.Agents.MaxAgents = 2
+	if err := cfg.ValidateAgents(); err == nil || !strings.Contains(err.Error(), "max_agents") {
+		t.Errorf("ValidateAgents() error = %v, want max_agents error", err)
+	}
+
+	cfg.Agents.MaxAgents = 3
+	cfg.Agents.MaxBindings = 1
+	if err := cfg.ValidateAgents(); err == nil || !strings.Contains(err.Error(), "max_bindings") {
+		t.Errorf("ValidateAgents() error = %v, want max_bindings error", err)
+	}
+
+	cfg.Agents.MaxBindings = -1
+	if err := cfg.ValidateAgents(); err == nil {
+		t.Error("ValidateAgents() accepted a negative limit")
+	}
+}
+
+func TestConfig_Footprint(t *testing.T) {
+	cfg := &Config{}
+	cfg.Channels.Telegram.Enabled = true
+	cfg.Channels.Discord.Enabled = true
+	if got := cfg.footprint(); got != 2 {
+		t.Errorf("footprint() = %d, want 2 for the implicit main agent", got)
+	}
+	cfg.Agents.List = make([]AgentConfig, 5)
+	if got := cfg.footprint(); got != 10 {
+		t.Errorf("footprint() = %d, want 10", got)
+	}
+}
+
 func TestProvidersConfig_IsEmpty(t *testing.T) {
 	var empty ProvidersConfig
 	if !empty.IsEmpty() {
@@ -273,6 +428,20 @@ func TestDefaultConfig_Temperature(t *testing.T) {
 	}
 }
 
+func TestAgentDefaults_CompressOnOverflow(t *testing.T) {
+	cfg := DefaultConfig()
+	if !cfg.Agents.Defaults.CompressOnOverflow() {
+		t.Error("CompressOnOverflow() should default to true")
+	}
+
+	if err := json.Unmarshal([]byte(`{"agents":{"defaults":{"auto_compress_on_overflow":false}}}`), cfg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if cfg.Agents.Defaults.CompressOnOverflow() {
+		t.Error("CompressOnOverflow() should be false when auto_compress_on_overflow is false")
+	}
+}
+
 // TestDefaultConfig_Gateway verifies gateway defaults
 func TestDefaultConfig_Gateway(t *testing.T) {
 	cfg := DefaultConfig()
@@ -380,6 +549,34 @@ func TestSaveConfig_IncludesEmptyLegacyModelField(t *testing.T) {
 	}
 }
 
+func TestSaveConfig_KeepsEmptyAllowedChannels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := DefaultConfig()
+	cfg.Agents.List = []AgentConfig{
+		{ID: "main", Default: true},
+		{ID: "ops", AllowedChannels: []string{"slack"}},
+		{ID: "observer", AllowedChannels: []string{}},
+	}
+	if err := SaveConfig(path, cfg); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if got := loaded.Agents.List[0].AllowedChannels; got != nil {
+		t.Errorf("main allowed_channels = %#v, want nil (allow all)", got)
+	}
+	if got := loaded.Agents.List[1].AllowedChannels; len(got) != 1 || got[0] != "slack" {
+		t.Errorf("ops allowed_channels = %#v, want [slack]", got)
+	}
+	if got := loaded.Agents.List[2].AllowedChannels; got == nil || len(got) != 0 {
+		t.Errorf("observer allowed_channels = %#v, want an empty list (allow none)", got)
+	}
+}
+
 // TestConfig_Complete verifies all config fields are set
 func TestConfig_Complete(t *testing.T) {
 	cfg := DefaultConfig()
@@ -456,6 +653,54 @@ func TestLoadConfig_WebPreferNativeCanBeDisabled(t *testing.T) {
 	}
 }
 
+func TestWebToolsConfig_EffectiveSearchMode(t *testing.T) {
+	tests := []struct {
+		name         string
+		searchMode   string
+		preferNative bool
+		want         string
+	}{
+		{"unset prefers native", "", true, WebSearchModeNative},
+		{"unset prefers local", "", false, WebSearchModeLocal},
+		{"explicit both", "both", false, WebSearchModeBoth},
+		{"explicit local overrides prefer_native", "local", true, WebSearchModeLocal},
+		{"case insensitive", " Native ", false, WebSearchModeNative},
+		{"unknown falls back", "bogus", false, WebSearchModeLocal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := WebToolsConfig{SearchMode: tt.searchMode, PreferNative: tt.preferNative}
+			if got := c.EffectiveSearchMode(); got != tt.want {
+				t.Errorf("EffectiveSearchMode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolResultTruncationConfig_RuleFor(t *testing.T) {
+	c := ToolResultTruncationConfig{
+		Strategy: "head_tail",
+		MaxChars: 8000,
+		PerTool: map[string]ToolResultTruncationRule{
+			"exec":      {Strategy: "tail"},
+			"read_file": {MaxChars: -1},
+		},
+	}
+	tests := []struct {
+		tool string
+		want ToolResultTruncationRule
+	}{
+		{"web_fetch", ToolResultTruncationRule{Strategy: "head_tail", MaxChars: 8000}},
+		{"exec", ToolResultTruncationRule{Strategy: "tail", MaxChars: 8000}},
+		{"read_file", ToolResultTruncationRule{Strategy: "head_tail", MaxChars: -1}},
+	}
+	for _, tt := range tests {
+		if got := c.RuleFor(tt.tool); got != tt.want {
+			t.Errorf("RuleFor(%q) = %+v, want %+v", tt.tool, got, tt.want)
+		}
+	}
+}
+
 func TestDefaultConfig_ExecAllowRemoteEnabled(t *testing.T) {
 	cfg := DefaultConfig()
 	if !cfg.Tools.Exec.AllowRemote {
@@ -518,6 +763,38 @@ func TestLoadConfig_CronAllowCommandDefaultsTrueWhenUnset(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_CompressionDropRatio(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want float64
+	}{
+		{"unset uses default", `{}`, DefaultCompressionDropRatio},
+		{"in range", `{"session":{"compression_drop_ratio":0.3}}`, 0.3},
+		{"too high is clamped", `{"session":{"compression_drop_ratio":1.5}}`, MaxCompressionDropRatio},
+		{"too low is clamped", `{"session":{"compression_drop_ratio":0.01}}`, MinCompressionDropRatio},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(tt.json), 0o600); err != nil {
+				t.Fatalf("WriteFile() error: %v", err)
+			}
+			cfg, err := LoadConfig(configPath)
+			if err != nil {
+				t.Fatalf("LoadConfig() error: %v", err)
+			}
+			if got := cfg.Session.CompressionDropRatio; got != tt.want {
+				t.Errorf("CompressionDropRatio = %g, want %g", got, tt.want)
+			}
+		})
+	}
+
+	if got := (SessionConfig{}).EffectiveCompressionDropRatio(); got != DefaultCompressionDropRatio {
+		t.Errorf("EffectiveCompressionDropRatio() of zero config = %g, want default", got)
+	}
+}
+
 func TestLoadConfig_OpenAIWebSearchCanBeDisabled(t *testing.T) {
 	dir := t.TempDir()
 	configPath := filepath.Join(dir, "config.json")
@@ -1057,3 +1334,104 @@ func TestLoadConfig_UsesPassphraseProvider(t *testing.T) {
 		t.Errorf("api_key = %q, want %q", cfg.ModelList[0].APIKey, plainKey)
 	}
 }
+
+func TestLoadConfig_OneBotReactionEmojiID(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if got := cfg.Channels.OneBot.ReactionEmojiID; got != 289 {
+		t.Errorf("default reaction_emoji_id = %d, want 289", got)
+	}
+
+	for _, tc := range []struct {
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{`{"channels":{"onebot":{"reaction_emoji_id":124}}}`, 124, false},
+		{`{"channels":{"onebot":{"enabled":true}}}`, 289, false},
+		{`{"channels":{"onebot":{"reaction_emoji_id":0}}}`, 0, true},
+		{`{"channels":{"onebot":{"reaction_emoji_id":-5}}}`, 0, true},
+	} {
+		if err := os.WriteFile(configPath, []byte(tc.data), 0o600); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		cfg, err := LoadConfig(configPath)
+		if tc.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "reaction_emoji_id") {
+				t.Errorf("%s: error = %v, want reaction_emoji_id validation error", tc.data, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: LoadConfig() error: %v", tc.data, err)
+		}
+		if got := cfg.Channels.OneBot.ReactionEmojiID; got != tc.want {
+			t.Errorf("%s: reaction_emoji_id = %d, want %d", tc.data, got, tc.want)
+		}
+	}
+}
+
+func TestLoadConfig_OneBotReadTimeoutMustExceedPingInterval(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	for _, tc := range []struct {
+		data    string
+		wantErr bool
+	}{
+		{`{"channels":{"onebot":{"ping_interval":20,"read_timeout":45}}}`, false},
+		{`{"channels":{"onebot":{"ping_interval":30,"read_timeout":30}}}`, true},
+		{`{"channels":{"onebot":{"ping_interval":60,"read_timeout":30}}}`, true},
+		// An unset read_timeout means the 60s default.
+		{`{"channels":{"onebot":{"ping_interval":90,"read_timeout":0}}}`, true},
+	} {
+		if err := os.WriteFile(configPath, []byte(tc.data), 0o600); err != nil {
+			t.Fatalf("WriteFile() error: %v", err)
+		}
+		_, err := LoadConfig(configPath)
+		if tc.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "read_timeout") {
+				t.Errorf("%s: error = %v, want read_timeout validation error", tc.data, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: LoadConfig() error: %v", tc.data, err)
+		}
+	}
+}
+
+func TestHeartbeatConfig_EffectiveInterval(t *testing.T) {
+	clamped := HeartbeatConfig{Interval: 2, DisableJitter: true}
+	if got := clamped.EffectiveInterval(); got != 5*time.Minute {
+		t.Errorf("EffectiveInterval() for 2 minutes = %v, want 5m", got)
+	}
+	if got := (HeartbeatConfig{DisableJitter: true}).EffectiveInterval(); got != 30*time.Minute {
+		t.Errorf("EffectiveInterval() for unset interval = %v, want 30m", got)
+	}
+
+	h := HeartbeatConfig{Interval: 60}
+	if got := h.jitteredInterval(0); got != 54*time.Minute {
+		t.Errorf("jitteredInterval(0) = %v, want 54m", got)
+	}
+	for range 1000 {
+		if got := h.EffectiveInterval(); got < 54*time.Minute || got >= 66*time.Minute {
+			t.Fatalf("EffectiveInterval() = %v, want within ±10%% of 60m", got)
+		}
+	}
+}
+
+func TestLoadConfig_ClampsHeartbeatInterval(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"heartbeat":{"enabled":true,"interval":2}}`), 0o600); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if cfg.Heartbeat.Interval != MinHeartbeatInterval {
+		t.Errorf("heartbeat.interval = %d, want %d", cfg.Heartbeat.Interval, MinHeartbeatInterval)
+	}
+}
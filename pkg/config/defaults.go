@@ -39,7 +39,8 @@ func DefaultConfig() *Config {
 		},
 		Bindings: []AgentBinding{},
 		Session: SessionConfig{
-			DMScope: "per-channel-peer",
+			DMScope:              "per-channel-peer",
+			CompressionDropRatio: DefaultCompressionDropRatio,
 		},
 		Channels: ChannelsConfig{
 			WhatsApp: WhatsAppConfig{
@@ -130,6 +131,9 @@ func DefaultConfig() *Config {
 				WSUrl:              "ws://127.0.0.1:3001",
 				AccessToken:        "",
 				ReconnectInterval:  5,
+				PingInterval:       30,
+				ReadTimeout:        60,
+				ReactionEmojiID:    289,
 				GroupTriggerPrefix: []string{},
 				AllowFrom:          FlexibleStringSlice{},
 			},
@@ -498,31 +502,53 @@ func DefaultConfig() *Config {
 				},
 				Servers: map[string]MCPServerConfig{},
 			},
+			ResultTruncation: ToolResultTruncationConfig{
+				Strategy: "head_tail",
+			},
 			AppendFile: ToolConfig{
 				Enabled: true,
 			},
+			AskUser: ToolConfig{
+				Enabled: true,
+			},
 			EditFile: ToolConfig{
 				Enabled: true,
 			},
+			FetchFeed: ToolConfig{
+				Enabled: true,
+			},
 			FindSkills: ToolConfig{
 				Enabled: true,
 			},
+			GPIO: ToolConfig{
+				Enabled: false, // Hardware tool - Linux only
+			},
 			I2C: ToolConfig{
 				Enabled: false, // Hardware tool - Linux only
 			},
 			InstallSkill: ToolConfig{
 				Enabled: true,
 			},
+			ListAttachments: ToolConfig{
+				Enabled: true,
+			},
 			ListDir: ToolConfig{
 				Enabled: true,
 			},
 			Message: ToolConfig{
 				Enabled: true,
 			},
+			ReadDocument: ReadDocumentToolConfig{
+				Enabled:  true,
+				MaxChars: 50000,
+			},
 			ReadFile: ReadFileToolConfig{
 				Enabled:         true,
 				MaxReadFileSize: 64 * 1024, // 64KB
 			},
+			Scratchpad: ToolConfig{
+				Enabled: true,
+			},
 			Spawn: ToolConfig{
 				Enabled: true,
 			},
@@ -535,6 +561,9 @@ func DefaultConfig() *Config {
 			Subagent: ToolConfig{
 				Enabled: true,
 			},
+			SummarizeURL: ToolConfig{
+				Enabled: true,
+			},
 			WebFetch: ToolConfig{
 				Enabled: true,
 			},
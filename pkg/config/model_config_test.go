@@ -307,6 +307,60 @@ func TestModelConfig_Validate(t *testing.T) {
 			config:  ModelConfig{},
 			wantErr: true,
 		},
+		{
+			name: "negative cooldown_seconds",
+			config: ModelConfig{
+				ModelName:       "test",
+				Model:           "openai/gpt-4o",
+				CooldownSeconds: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "extra_body with provider fields",
+			config: ModelConfig{
+				ModelName: "test",
+				Model:     "openai/o3",
+				ExtraBody: map[string]any{"reasoning_effort": "high"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "extra_body overriding messages",
+			config: ModelConfig{
+				ModelName: "test",
+				Model:     "openai/gpt-4o",
+				ExtraBody: map[string]any{"messages": []any{}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "prompt tool protocol",
+			config: ModelConfig{
+				ModelName:    "test",
+				Model:        "ollama/qwen2.5:0.5b",
+				ToolProtocol: ToolProtocolPrompt,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown tool protocol",
+			config: ModelConfig{
+				ModelName:    "test",
+				Model:        "ollama/qwen2.5:0.5b",
+				ToolProtocol: "xml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative pricing",
+			config: ModelConfig{
+				ModelName: "test",
+				Model:     "openai/gpt-4o",
+				Pricing:   &ModelPricing{InputPerMillion: -1},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -319,6 +373,17 @@ func TestModelConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestModelPricing_Cost(t *testing.T) {
+	p := &ModelPricing{InputPerMillion: 3, OutputPerMillion: 15}
+	if got := p.Cost(2_000_000, 100_000); got != 7.5 {
+		t.Errorf("Cost() = %v, want 7.5", got)
+	}
+	var unpriced *ModelPricing
+	if got := unpriced.Cost(1000, 1000); got != 0 {
+		t.Errorf("nil Cost() = %v, want 0", got)
+	}
+}
+
 func TestConfig_ValidateModelList(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -428,3 +493,130 @@ func TestModelConfig_RequestTimeoutDefaultZeroValue(t *testing.T) {
 		t.Fatalf("RequestTimeout = %d, want 0", cfg.RequestTimeout)
 	}
 }
+
+func TestGetModelConfig_NormalizeModelNames(t *testing.T) {
+	cfg := &Config{
+		ModelList: []ModelConfig{
+			{ModelName: "gpt-4o", Model: "openai/gpt-4o", APIKey: "key1"},
+			{ModelName: "claude_sonnet", Model: "anthropic/claude-sonnet-4", APIKey: "key2"},
+		},
+	}
+
+	if _, err := cfg.GetModelConfig("GPT4o"); err == nil {
+		t.Fatal("GetModelConfig() matched a differently spelled name without normalize_model_names")
+	}
+
+	cfg.NormalizeModelNames = true
+	for name, want := range map[string]string{
+		"GPT4o":         "openai/gpt-4o",
+		"gpt_4o":        "openai/gpt-4o",
+		"Claude Sonnet": "anthropic/claude-sonnet-4",
+	} {
+		result, err := cfg.GetModelConfig(name)
+		if err != nil {
+			t.Errorf("GetModelConfig(%q) error = %v", name, err)
+			continue
+		}
+		if result.Model != want {
+			t.Errorf("GetModelConfig(%q).Model = %q, want %q", name, result.Model, want)
+		}
+	}
+}
+
+func TestGetModelConfig_NormalizedNameAmbiguous(t *testing.T) {
+	cfg := &Config{
+		NormalizeModelNames: true,
+		ModelList: []ModelConfig{
+			{ModelName: "gpt-4o", Model: "openai/gpt-4o", APIKey: "key1"},
+			{ModelName: "GPT_4o", Model: "azure/gpt-4o", APIKey: "key2"},
+		},
+	}
+
+	if _, err := cfg.GetModelConfig("gpt4o"); err == nil {
+		t.Fatal("GetModelConfig() expected error when a normalized name matches two model_names")
+	}
+	// Exact names still resolve.
+	result, err := cfg.GetModelConfig("GPT_4o")
+	if err != nil || result.Model != "azure/gpt-4o" {
+		t.Errorf("GetModelConfig(exact) = %+v, %v", result, err)
+	}
+}
+
+func TestGetModelConfig_Aliases(t *testing.T) {
+	cfg := &Config{
+		ModelList: []ModelConfig{
+			{ModelName: "gpt-4o-mini", Model: "openai/gpt-4o-mini", APIKey: "key1"},
+			{ModelName: "fast", Model: "groq/llama-3.1-8b", APIKey: "key2"},
+		},
+		ModelAliases: map[string]string{
+			"cheap": "gpt-4o-mini",
+			"fast":  "gpt-4o-mini",
+		},
+	}
+
+	result, err := cfg.GetModelConfig("cheap")
+	if err != nil || result.Model != "openai/gpt-4o-mini" {
+		t.Fatalf("GetModelConfig(alias) = %+v, %v", result, err)
+	}
+	// A real model_name takes precedence over an alias of the same name.
+	result, err = cfg.GetModelConfig("fast")
+	if err != nil || result.Model != "groq/llama-3.1-8b" {
+		t.Errorf("GetModelConfig(shadowed alias) = %+v, %v", result, err)
+	}
+
+	if _, err := cfg.GetModelConfig("CHEAP"); err == nil {
+		t.Error("aliases should be case-sensitive without normalize_model_names")
+	}
+	cfg.NormalizeModelNames = true
+	if _, err := cfg.GetModelConfig("CHEAP"); err != nil {
+		t.Errorf("GetModelConfig(normalized alias) error = %v", err)
+	}
+}
+
+func TestGetModelConfig_SuggestsClosestName(t *testing.T) {
+	cfg := &Config{
+		ModelList: []ModelConfig{
+			{ModelName: "gpt-4o", Model: "openai/gpt-4o", APIKey: "key1"},
+			{ModelName: "claude-sonnet", Model: "anthropic/claude-sonnet-4", APIKey: "key2"},
+		},
+		ModelAliases: map[string]string{"sonnet": "claude-sonnet"},
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"gpt4o", `did you mean "gpt-4o"?`},
+		{"claude-sonet", `did you mean "claude-sonnet"?`},
+		{"Sonet", `did you mean "sonnet"?`},
+	}
+	for _, tt := range tests {
+		_, err := cfg.GetModelConfig(tt.name)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("GetModelConfig(%q) error = %v, want it to contain %s", tt.name, err, tt.want)
+		}
+	}
+
+	_, err := cfg.GetModelConfig("llama-3.1-70b")
+	if err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("expected no suggestion for an unrelated name, got %v", err)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"gpt4o", "gpt-4o", 1},
+		{"kitten", "sitting", 3},
+		{"模型", "模形", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -236,6 +237,54 @@ func TestExpandMultiKeyModels_PreservesOtherFields(t *testing.T) {
 	}
 }
 
+func TestExpandMultiKeyModels_KeepsEveryOptionalField(t *testing.T) {
+	supportsTools := false
+	m := ModelConfig{
+		ModelName:       "gpt-4",
+		Model:           "openai/gpt-4o",
+		APIBase:         "https://api.example.com",
+		APIKey:          "key1",
+		APIKeys:         []string{"key2", "key3"},
+		Proxy:           "http://proxy:8080",
+		Fallbacks:       []string{"backup"},
+		AuthMethod:      "token",
+		ConnectMode:     "grpc",
+		Workspace:       "/srv/ws",
+		RPM:             60,
+		MaxTokensField:  "max_completion_tokens",
+		RequestTimeout:  30,
+		CooldownSeconds: 10,
+		ThinkingLevel:   "high",
+		SupportsTools:   &supportsTools,
+		ToolProtocol:    "prompt",
+		Pricing:         &ModelPricing{InputPerMillion: 2, OutputPerMillion: 8},
+		ExtraBody:       map[string]any{"reasoning_effort": "low"},
+	}
+	// Fail loudly when a new field is added here without a value above, so
+	// the check below keeps covering every field.
+	v := reflect.ValueOf(m)
+	for i := range v.NumField() {
+		if v.Field(i).IsZero() {
+			t.Fatalf("test model leaves %s unset", v.Type().Field(i).Name)
+		}
+	}
+
+	// Only the name, key and fallbacks may differ from the original entry.
+	overridden := map[string]bool{"ModelName": true, "APIKey": true, "APIKeys": true, "Fallbacks": true}
+	for _, e := range ExpandMultiKeyModels([]ModelConfig{m}) {
+		ev := reflect.ValueOf(e)
+		for i := range ev.NumField() {
+			name := ev.Type().Field(i).Name
+			if overridden[name] {
+				continue
+			}
+			if !reflect.DeepEqual(ev.Field(i).Interface(), v.Field(i).Interface()) {
+				t.Errorf("%s: %s = %v, want %v", e.ModelName, name, ev.Field(i).Interface(), v.Field(i).Interface())
+			}
+		}
+	}
+}
+
 func TestMergeAPIKeys(t *testing.T) {
 	tests := []struct {
 		name     string
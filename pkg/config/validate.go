@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxTemperature is the upper bound Validate accepts for temperature.
+const maxTemperature = 2.0
+
+// Validate checks the whole config and returns every problem found, one
+// message per problem, instead of stopping at the first like LoadConfig. The
+// error is non-nil exactly when problems is non-empty.
+func (c *Config) Validate() ([]string, error) {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	apiBases := make(map[string]string)
+	for i := range c.ModelList {
+		m := &c.ModelList[i]
+		if err := m.Validate(); err != nil {
+			add("model_list[%d]: %v", i, err)
+			continue
+		}
+		if base, seen := apiBases[m.ModelName]; seen && base != m.APIBase {
+			add("model_list[%d]: model_name %q is repeated with a different api_base (%q vs %q)",
+				i, m.ModelName, m.APIBase, base)
+			continue
+		}
+		apiBases[m.ModelName] = m.APIBase
+	}
+
+	aliases := make([]string, 0, len(c.ModelAliases))
+	for alias := range c.ModelAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		if target := c.ModelAliases[alias]; len(c.lookupModelName(target)) == 0 {
+			add("model_aliases.%s points to %q, which is not a model_name in model_list", alias, target)
+		}
+	}
+
+	for i, admin := range c.Admins {
+		if platform, id, ok := strings.Cut(strings.TrimSpace(admin), ":"); !ok || platform == "" || id == "" {
+			add("admins[%d] must be a \"platform:id\" identity, got %q", i, admin)
+		}
+	}
+
+	if err := c.ValidateAgents(); err != nil {
+		add("%v", err)
+	}
+	if err := c.ValidateChannels(); err != nil {
+		add("%v", err)
+	}
+	for _, name := range c.Channels.EnabledNames() {
+		if missing := c.Channels.missingCredential(name); missing != "" {
+			add("channels.%s is enabled but %s is empty", name, missing)
+		}
+	}
+
+	for i, name := range c.Tools.Web.EngineOrder {
+		if n := strings.ToLower(strings.TrimSpace(name)); n != "" && !slices.Contains(WebSearchEngines, n) {
+			add("tools.web.engine_order[%d]: unknown search engine %q (want one of %s)",
+				i, name, strings.Join(WebSearchEngines, ", "))
+		}
+	}
+
+	if n := c.Heartbeat.Interval; c.Heartbeat.Enabled && n != 0 && n < MinHeartbeatInterval {
+		add("heartbeat.interval must be at least %d minutes, got %d", MinHeartbeatInterval, n)
+	}
+	if t := c.Agents.Defaults.Temperature; t != nil && (*t < 0 || *t > maxTemperature) {
+		add("agents.defaults.temperature must be between 0 and %g, got %g", maxTemperature, *t)
+	}
+
+	if len(problems) > 0 {
+		return problems, fmt.Errorf("config has %d problem(s): %s", len(problems), strings.Join(problems, "; "))
+	}
+	return nil, nil
+}
+
+// missingCredential returns the config key an enabled channel needs to start
+// but lacks, or "" when it has what it needs. It mirrors the checks the
+// channel manager makes before creating each channel.
+func (c ChannelsConfig) missingCredential(name string) string {
+	switch name {
+	case "whatsapp":
+		if !c.WhatsApp.UseNative && c.WhatsApp.BridgeURL == "" {
+			return "bridge_url"
+		}
+	case "telegram":
+		if c.Telegram.Token == "" {
+			return "token"
+		}
+	case "discord":
+		if c.Discord.Token == "" {
+			return "token"
+		}
+	case "dingtalk":
+		if c.DingTalk.ClientID == "" {
+			return "client_id"
+		}
+	case "slack":
+		if c.Slack.BotToken == "" {
+			return "bot_token"
+		}
+	case "matrix":
+		switch {
+		case c.Matrix.Homeserver == "":
+			return "homeserver"
+		case c.Matrix.UserID == "":
+			return "user_id"
+		case c.Matrix.AccessToken == "":
+			return "access_token"
+		}
+	case "line":
+		if c.LINE.ChannelAccessToken == "" {
+			return "channel_access_token"
+		}
+	case "onebot":
+		if c.OneBot.WSUrl == "" {
+			return "ws_url"
+		}
+	case "wecom":
+		if c.WeCom.Token == "" {
+			return "token"
+		}
+	case "wecom_app":
+		if c.WeComApp.CorpID == "" {
+			return "corp_id"
+		}
+	case "wecom_aibot":
+		if c.WeComAIBot.Token == "" {
+			return "token"
+		}
+	case "pico":
+		if c.Pico.Token == "" {
+			return "token"
+		}
+	case "irc":
+		if c.IRC.Server == "" {
+			return "server"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ModelList = []ModelConfig{
+		{ModelName: "gpt", Model: "openai/gpt-4o", APIBase: "https://api.openai.com/v1"},
+		{ModelName: "gpt", Model: "openai/gpt-4o", APIBase: "https://proxy.example.com/v1"},
+	}
+	cfg.Channels.Telegram.Enabled = true
+	cfg.Channels.Telegram.Token = ""
+	cfg.Heartbeat.Enabled = true
+	cfg.Heartbeat.Interval = 2
+
+	problems, err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil, want problems")
+	}
+	if len(problems) != 3 {
+		t.Fatalf("Validate() = %q, want 3 problems", problems)
+	}
+	for i, want := range []string{
+		`model_name "gpt" is repeated with a different api_base`,
+		"channels.telegram is enabled but token is empty",
+		"heartbeat.interval must be at least 5",
+	} {
+		if !strings.Contains(problems[i], want) {
+			t.Errorf("problems[%d] = %q, want it to contain %q", i, problems[i], want)
+		}
+	}
+}
+
+func TestValidate_Admins(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Admins = []string{"telegram:123", "123", "@alice"}
+	problems, _ := cfg.Validate()
+	if len(problems) != 2 || !strings.Contains(problems[0], "admins[1]") || !strings.Contains(problems[1], "admins[2]") {
+		t.Errorf("Validate() = %q, want problems for admins[1] and admins[2]", problems)
+	}
+}
+
+func TestValidate_EngineOrder(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Tools.Web.EngineOrder = FlexibleStringSlice{"SearXNG", "bing", "duckduckgo"}
+	problems, _ := cfg.Validate()
+	if len(problems) != 1 || !strings.Contains(problems[0], `engine_order[1]: unknown search engine "bing"`) {
+		t.Errorf("Validate() = %q, want one problem for bing", problems)
+	}
+}
+
+func TestValidate_SameAPIBaseIsLoadBalancing(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ModelList = []ModelConfig{
+		{ModelName: "gpt", Model: "openai/gpt-4o", APIBase: "https://api.openai.com/v1", APIKey: "k1"},
+		{ModelName: "gpt", Model: "openai/gpt-4o", APIBase: "https://api.openai.com/v1", APIKey: "k2"},
+	}
+	if problems, err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %q, %v, want no problems", problems, err)
+	}
+}
+
+func TestValidate_Temperature(t *testing.T) {
+	cfg := DefaultConfig()
+	temp := 3.5
+	cfg.Agents.Defaults.Temperature = &temp
+	problems, _ := cfg.Validate()
+	if len(problems) != 1 || !strings.Contains(problems[0], "temperature") {
+		t.Errorf("Validate() = %q, want one temperature problem", problems)
+	}
+}
+
+func TestValidate_ModelAliases(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ModelList = []ModelConfig{
+		{ModelName: "gpt-4o", Model: "openai/gpt-4o", APIKey: "k1"},
+	}
+	cfg.ModelAliases = map[string]string{"main": "gpt-4o", "fast": "gpt-4o-mini"}
+
+	problems, _ := cfg.Validate()
+	if len(problems) != 1 || !strings.Contains(problems[0], `model_aliases.fast points to "gpt-4o-mini"`) {
+		t.Errorf("Validate() = %q, want one problem for the fast alias", problems)
+	}
+}
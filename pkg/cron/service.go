@@ -30,6 +30,8 @@ type CronPayload struct {
 	Deliver bool   `json:"deliver"`
 	Channel string `json:"channel,omitempty"`
 	To      string `json:"to,omitempty"`
+	// Agent is the ID of the agent that scheduled the job, if any.
+	Agent string `json:"agent,omitempty"`
 }
 
 type CronJobState struct {
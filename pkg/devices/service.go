@@ -19,6 +19,7 @@ type Service struct {
 	state   *state.Manager
 	sources []events.EventSource
 	enabled bool
+	quiet   func(time.Time) bool
 	ctx     context.Context
 	cancel  context.CancelFunc
 	mu      sync.RWMutex
@@ -50,6 +51,14 @@ func (s *Service) SetBus(msgBus *bus.MessageBus) {
 	s.bus = msgBus
 }
 
+// SetQuietHours sets a check that suppresses notifications while it
+// reports true for the current time.
+func (s *Service) SetQuietHours(active func(time.Time) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quiet = active
+}
+
 func (s *Service) Start(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -108,12 +117,20 @@ func (s *Service) handleEvents(kind events.Kind, eventCh <-chan *events.DeviceEv
 func (s *Service) sendNotification(ev *events.DeviceEvent) {
 	s.mu.RLock()
 	msgBus := s.bus
+	quiet := s.quiet
 	s.mu.RUnlock()
 
 	if msgBus == nil {
 		return
 	}
 
+	if quiet != nil && quiet(time.Now()) {
+		logger.InfoCF("devices", "Device notification suppressed (quiet hours)", map[string]any{
+			"event": ev.FormatMessage(),
+		})
+		return
+	}
+
 	lastChannel := s.state.GetLastChannel()
 	if lastChannel == "" {
 		logger.DebugCF("devices", "No last channel, skipping notification", map[string]any{
@@ -45,6 +45,7 @@ const (
 	serviceShutdownTimeout  = 30 * time.Second
 	providerReloadTimeout   = 30 * time.Second
 	gracefulShutdownTimeout = 15 * time.Second
+	sessionFlushTimeout     = 5 * time.Second
 )
 
 type services struct {
@@ -97,6 +98,7 @@ func Run(debug bool, configPath string, allowEmptyStartup bool) error {
 
 	msgBus := bus.NewMessageBus()
 	agentLoop := agent.NewAgentLoop(cfg, msgBus, provider)
+	agentLoop.SetConfigPath(configPath)
 
 	fmt.Println("\n📦 Agent Status:")
 	startupInfo := agentLoop.GetStartupInfo()
@@ -200,6 +202,9 @@ func setupAndStartServices(
 	)
 	runningServices.HeartbeatService.SetBus(msgBus)
 	runningServices.HeartbeatService.SetHandler(createHeartbeatHandler(agentLoop))
+	quietHours := loadQuietHours(cfg)
+	runningServices.HeartbeatService.SetQuietHours(quietHours)
+	runningServices.HeartbeatService.SetJitter(!cfg.Heartbeat.DisableJitter)
 	if err = runningServices.HeartbeatService.Start(); err != nil {
 		return nil, fmt.Errorf("error starting heartbeat service: %w", err)
 	}
@@ -253,6 +258,7 @@ func setupAndStartServices(
 		MonitorUSB: cfg.Devices.MonitorUSB,
 	}, stateManager)
 	runningServices.DeviceService.SetBus(msgBus)
+	runningServices.DeviceService.SetQuietHours(quietHours.Active)
 	if err = runningServices.DeviceService.Start(context.Background()); err != nil {
 		logger.ErrorCF("device", "Error starting device service", map[string]any{"error": err.Error()})
 	} else if cfg.Devices.Enabled {
@@ -298,6 +304,16 @@ func shutdownGateway(
 	stopAndCleanupServices(runningServices, gracefulShutdownTimeout)
 
 	agentLoop.Stop()
+
+	// Persist in-flight conversations before the session stores close.
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), sessionFlushTimeout)
+	if err := agentLoop.FlushSessions(flushCtx); err != nil {
+		logger.Errorf("Failed to flush sessions: %v", err)
+	}
+	flushCancel()
+
+	// Close waits for a flush that outlived the timeout before closing the
+	// session stores.
 	agentLoop.Close()
 
 	logger.Info("✓ Gateway stopped")
@@ -397,6 +413,9 @@ func restartServices(
 	)
 	runningServices.HeartbeatService.SetBus(msgBus)
 	runningServices.HeartbeatService.SetHandler(createHeartbeatHandler(al))
+	quietHours := loadQuietHours(cfg)
+	runningServices.HeartbeatService.SetQuietHours(quietHours)
+	runningServices.HeartbeatService.SetJitter(!cfg.Heartbeat.DisableJitter)
 	if err = runningServices.HeartbeatService.Start(); err != nil {
 		return fmt.Errorf("error restarting heartbeat service: %w", err)
 	}
@@ -444,6 +463,7 @@ func restartServices(
 		MonitorUSB: cfg.Devices.MonitorUSB,
 	}, stateManager)
 	runningServices.DeviceService.SetBus(msgBus)
+	runningServices.DeviceService.SetQuietHours(quietHours.Active)
 	if err := runningServices.DeviceService.Start(context.Background()); err != nil {
 		logger.WarnCF("device", "Failed to restart device service", map[string]any{"error": err.Error()})
 	} else if cfg.Devices.Enabled {
@@ -576,12 +596,23 @@ func setupCronTool(
 	return cronService, nil
 }
 
+// loadQuietHours builds the quiet-hours window from config. It returns nil
+// when quiet hours are disabled or misconfigured.
+func loadQuietHours(cfg *config.Config) *heartbeat.QuietHours {
+	qh := cfg.Heartbeat.QuietHours
+	if !qh.Enabled {
+		return nil
+	}
+	quiet, err := heartbeat.NewQuietHours(qh.Start, qh.End, qh.Timezone)
+	if err != nil {
+		logger.WarnCF("heartbeat", "Quiet hours disabled", map[string]any{"error": err.Error()})
+		return nil
+	}
+	return quiet
+}
+
 func createHeartbeatHandler(agentLoop *agent.AgentLoop) func(prompt, channel, chatID string) *tools.ToolResult {
 	return func(prompt, channel, chatID string) *tools.ToolResult {
-		if channel == "" || chatID == "" {
-			channel, chatID = "cli", "direct"
-		}
-
 		response, err := agentLoop.ProcessHeartbeat(context.Background(), prompt, channel, chatID)
 		if err != nil {
 			return tools.ErrorResult(fmt.Sprintf("Heartbeat error: %v", err))
@@ -0,0 +1,64 @@
+package heartbeat
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietHours is a daily time window during which proactive messages
+// (heartbeats, device notifications) are suppressed. Windows may cross
+// midnight, e.g. 22:00-07:00.
+type QuietHours struct {
+	start    int // minutes since midnight
+	end      int // minutes since midnight
+	location *time.Location
+}
+
+// NewQuietHours parses a quiet-hours window. start and end use HH:MM
+// (24-hour) format. An empty timezone uses the local time zone.
+func NewQuietHours(start, end, timezone string) (*QuietHours, error) {
+	startMin, err := parseClock(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours start %q: %w", start, err)
+	}
+	endMin, err := parseClock(end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours end %q: %w", end, err)
+	}
+	if startMin == endMin {
+		return nil, fmt.Errorf("quiet hours start and end must differ")
+	}
+
+	loc := time.Local
+	if timezone != "" {
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet hours timezone %q: %w", timezone, err)
+		}
+	}
+
+	return &QuietHours{start: startMin, end: endMin, location: loc}, nil
+}
+
+// Active reports whether t falls inside the quiet-hours window.
+// A nil QuietHours is never active.
+func (q *QuietHours) Active(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+	local := t.In(q.location)
+	now := local.Hour()*60 + local.Minute()
+	if q.start < q.end {
+		return now >= q.start && now < q.end
+	}
+	// Window wraps past midnight
+	return now >= q.start || now < q.end
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,70 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHours_Active(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		clock      string
+		want       bool
+	}{
+		{"same day inside", "13:00", "15:00", "14:00", true},
+		{"same day before", "13:00", "15:00", "12:59", false},
+		{"same day end exclusive", "13:00", "15:00", "15:00", false},
+		{"overnight late", "22:00", "07:00", "23:30", true},
+		{"overnight early", "22:00", "07:00", "03:00", true},
+		{"overnight daytime", "22:00", "07:00", "12:00", false},
+		{"overnight start inclusive", "22:00", "07:00", "22:00", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := NewQuietHours(tt.start, tt.end, "UTC")
+			if err != nil {
+				t.Fatalf("NewQuietHours() error: %v", err)
+			}
+			clock, _ := time.Parse("15:04", tt.clock)
+			now := time.Date(2026, 1, 1, clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+			if got := q.Active(now); got != tt.want {
+				t.Errorf("Active(%s) = %v, want %v", tt.clock, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHours_Timezone(t *testing.T) {
+	q, err := NewQuietHours("22:00", "07:00", "Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// 15:00 UTC is 00:00 in Tokyo
+	if !q.Active(time.Date(2026, 1, 1, 15, 0, 0, 0, time.UTC)) {
+		t.Error("expected quiet hours to be active at Tokyo midnight")
+	}
+	if q.Active(time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)) {
+		t.Error("expected quiet hours inactive at Tokyo noon")
+	}
+}
+
+func TestNewQuietHours_Invalid(t *testing.T) {
+	if _, err := NewQuietHours("25:00", "07:00", ""); err == nil {
+		t.Error("expected error for invalid start")
+	}
+	if _, err := NewQuietHours("22:00", "22:00", ""); err == nil {
+		t.Error("expected error for empty window")
+	}
+	if _, err := NewQuietHours("22:00", "07:00", "Not/AZone"); err == nil {
+		t.Error("expected error for unknown timezone")
+	}
+}
+
+func TestQuietHours_NilInactive(t *testing.T) {
+	var q *QuietHours
+	if q.Active(time.Now()) {
+		t.Error("nil QuietHours should never be active")
+	}
+}
@@ -11,11 +11,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/constants"
 	"github.com/sipeed/picoclaw/pkg/fileutil"
 	"github.com/sipeed/picoclaw/pkg/logger"
@@ -23,11 +23,6 @@ import (
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
-const (
-	minIntervalMinutes     = 5
-	defaultIntervalMinutes = 30
-)
-
 // HeartbeatHandler is the function type for handling heartbeat.
 // It returns a ToolResult that can indicate async operations.
 // channel and chatID are derived from the last active user channel.
@@ -39,26 +34,25 @@ type HeartbeatService struct {
 	bus       *bus.MessageBus
 	state     *state.Manager
 	handler   HeartbeatHandler
-	interval  time.Duration
+	schedule  config.HeartbeatConfig // interval and jitter of the timer
 	enabled   bool
+	quiet     *QuietHours
 	mu        sync.RWMutex
 	stopChan  chan struct{}
 }
 
 // NewHeartbeatService creates a new heartbeat service
 func NewHeartbeatService(workspace string, intervalMinutes int, enabled bool) *HeartbeatService {
-	// Apply minimum interval
-	if intervalMinutes < minIntervalMinutes && intervalMinutes != 0 {
-		intervalMinutes = minIntervalMinutes
-	}
-
-	if intervalMinutes == 0 {
-		intervalMinutes = defaultIntervalMinutes
+	if intervalMinutes != 0 && intervalMinutes < config.MinHeartbeatInterval {
+		logger.WarnCF("heartbeat", "Heartbeat interval below minimum, clamping", map[string]any{
+			"configured_minutes": intervalMinutes,
+			"minimum_minutes":    config.MinHeartbeatInterval,
+		})
 	}
 
 	return &HeartbeatService{
 		workspace: workspace,
-		interval:  time.Duration(intervalMinutes) * time.Minute,
+		schedule:  config.HeartbeatConfig{Interval: intervalMinutes},
 		enabled:   enabled,
 		state:     state.NewManager(workspace),
 	}
@@ -78,6 +72,22 @@ func (hs *HeartbeatService) SetHandler(handler HeartbeatHandler) {
 	hs.handler = handler
 }
 
+// SetJitter turns the ±config.HeartbeatJitter spread of heartbeat times on
+// or off. It is on by default and takes effect at the next Start.
+func (hs *HeartbeatService) SetJitter(enabled bool) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.schedule.DisableJitter = !enabled
+}
+
+// SetQuietHours sets the window during which heartbeats are skipped.
+// A nil value disables quiet hours.
+func (hs *HeartbeatService) SetQuietHours(quiet *QuietHours) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.quiet = quiet
+}
+
 // Start begins the heartbeat service
 func (hs *HeartbeatService) Start() error {
 	hs.mu.Lock()
@@ -94,10 +104,11 @@ func (hs *HeartbeatService) Start() error {
 	}
 
 	hs.stopChan = make(chan struct{})
-	go hs.runLoop(hs.stopChan)
+	go hs.runLoop(hs.stopChan, hs.schedule)
 
 	logger.InfoCF("heartbeat", "Heartbeat service started", map[string]any{
-		"interval_minutes": hs.interval.Minutes(),
+		"interval_minutes": hs.schedule.BaseInterval().Minutes(),
+		"jitter":           !hs.schedule.DisableJitter,
 	})
 
 	return nil
@@ -124,10 +135,11 @@ func (hs *HeartbeatService) IsRunning() bool {
 	return hs.stopChan != nil
 }
 
-// runLoop runs the heartbeat ticker
-func (hs *HeartbeatService) runLoop(stopChan chan struct{}) {
-	ticker := time.NewTicker(hs.interval)
-	defer ticker.Stop()
+// runLoop runs the heartbeat timer, re-arming it with a fresh
+// schedule.EffectiveInterval after every tick.
+func (hs *HeartbeatService) runLoop(stopChan chan struct{}, schedule config.HeartbeatConfig) {
+	timer := time.NewTimer(schedule.EffectiveInterval())
+	defer timer.Stop()
 
 	// Run first heartbeat after initial delay
 	time.AfterFunc(time.Second, func() {
@@ -138,8 +150,13 @@ func (hs *HeartbeatService) runLoop(stopChan chan struct{}) {
 		select {
 		case <-stopChan:
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			hs.executeHeartbeat()
+			delay := schedule.EffectiveInterval()
+			logger.DebugCF("heartbeat", "Next heartbeat scheduled", map[string]any{
+				"in_seconds": delay.Seconds(),
+			})
+			timer.Reset(delay)
 		}
 	}
 }
@@ -149,6 +166,7 @@ func (hs *HeartbeatService) executeHeartbeat() {
 	hs.mu.RLock()
 	enabled := hs.enabled
 	handler := hs.handler
+	quiet := hs.quiet
 	if !hs.enabled || hs.stopChan == nil {
 		hs.mu.RUnlock()
 		return
@@ -159,6 +177,11 @@ func (hs *HeartbeatService) executeHeartbeat() {
 		return
 	}
 
+	if quiet.Active(time.Now()) {
+		hs.logInfof("Heartbeat skipped (quiet hours)")
+		return
+	}
+
 	logger.DebugC("heartbeat", "Executing heartbeat")
 
 	prompt := hs.buildPrompt()
@@ -173,11 +196,10 @@ func (hs *HeartbeatService) executeHeartbeat() {
 	}
 
 	// Get last channel info for context
-	lastChannel := hs.state.GetLastChannel()
-	channel, chatID := hs.parseLastChannel(lastChannel)
+	channel, chatID := hs.lastTarget()
 
 	// Debug log for channel resolution
-	hs.logInfof("Resolved channel: %s, chatID: %s (from lastChannel: %s)", channel, chatID, lastChannel)
+	hs.logInfof("Resolved channel: %s, chatID: %s", channel, chatID)
 
 	result := handler(prompt, channel, chatID)
 
@@ -236,7 +258,11 @@ func (hs *HeartbeatService) buildPrompt() string {
 		return ""
 	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
+	return FormatPrompt(content, time.Now())
+}
+
+// FormatPrompt wraps a HEARTBEAT.md task list in the heartbeat instructions.
+func FormatPrompt(tasks string, now time.Time) string {
 	return fmt.Sprintf(`# Heartbeat Check
 
 Current time: %s
@@ -246,7 +272,7 @@ Review the following tasks and execute any necessary actions using available ski
 If there is nothing that requires attention, respond ONLY with: HEARTBEAT_OK
 
 %s
-`, now, content)
+`, now.Format("2006-01-02 15:04:05"), tasks)
 }
 
 // createDefaultHeartbeatTemplate creates the default HEARTBEAT.md file
@@ -295,17 +321,10 @@ func (hs *HeartbeatService) sendResponse(response string) {
 		return
 	}
 
-	// Get last channel from state
-	lastChannel := hs.state.GetLastChannel()
-	if lastChannel == "" {
-		hs.logInfof("No last channel recorded, heartbeat result not sent")
-		return
-	}
-
-	platform, userID := hs.parseLastChannel(lastChannel)
-
-	// Skip internal channels that can't receive messages
+	// Get last channel from state; internal channels can't receive messages
+	platform, userID := hs.lastTarget()
 	if platform == "" || userID == "" {
+		hs.logInfof("No deliverable last channel recorded, heartbeat result not sent")
 		return
 	}
 
@@ -320,29 +339,21 @@ func (hs *HeartbeatService) sendResponse(response string) {
 	hs.logInfof("Heartbeat result sent to %s", platform)
 }
 
-// parseLastChannel parses the last channel string into platform and userID.
-// Returns empty strings for invalid or internal channels.
-func (hs *HeartbeatService) parseLastChannel(lastChannel string) (platform, userID string) {
-	if lastChannel == "" {
-		return "", ""
-	}
-
-	// Parse channel format: "platform:user_id" (e.g., "telegram:123456")
-	parts := strings.SplitN(lastChannel, ":", 2)
-	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		hs.logErrorf("Invalid last channel format: %s", lastChannel)
+// lastTarget returns the platform and chat ID of the last active channel.
+// Both are empty when none is recorded or it is an internal channel.
+func (hs *HeartbeatService) lastTarget() (platform, chatID string) {
+	platform, chatID = hs.state.GetLastTarget()
+	if platform == "" {
 		return "", ""
 	}
 
-	platform, userID = parts[0], parts[1]
-
 	// Skip internal channels
 	if constants.IsInternalChannel(platform) {
 		hs.logInfof("Skipping internal channel: %s", platform)
 		return "", ""
 	}
 
-	return platform, userID
+	return platform, chatID
 }
 
 // logInfof logs an informational message to the heartbeat log
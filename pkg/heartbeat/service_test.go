@@ -203,3 +203,65 @@ func TestHeartbeatFilePath(t *testing.T) {
 		t.Errorf("Expected HEARTBEAT.md at %s, but it doesn't exist", expectedPath)
 	}
 }
+
+func TestNewHeartbeatService_ClampsInterval(t *testing.T) {
+	tests := []struct {
+		minutes int
+		want    time.Duration
+	}{
+		{0, 30 * time.Minute},
+		{1, 5 * time.Minute},
+		{-10, 5 * time.Minute},
+		{5, 5 * time.Minute},
+		{60, 60 * time.Minute},
+	}
+	for _, tt := range tests {
+		hs := NewHeartbeatService(t.TempDir(), tt.minutes, true)
+		if got := hs.schedule.BaseInterval(); got != tt.want {
+			t.Errorf("interval(%d) = %v, want %v", tt.minutes, got, tt.want)
+		}
+	}
+}
+
+func TestSetJitter(t *testing.T) {
+	hs := NewHeartbeatService(t.TempDir(), 30, true)
+	for range 20 {
+		if got := hs.schedule.EffectiveInterval(); got < 27*time.Minute || got >= 33*time.Minute {
+			t.Fatalf("jittered interval = %v, want within ±10%% of 30m", got)
+		}
+	}
+	hs.SetJitter(false)
+	if got := hs.schedule.EffectiveInterval(); got != 30*time.Minute {
+		t.Errorf("interval without jitter = %v, want 30m", got)
+	}
+}
+
+func TestExecuteHeartbeat_UsesLastTarget(t *testing.T) {
+	tmpDir := t.TempDir()
+	hs := NewHeartbeatService(tmpDir, 30, true)
+	hs.stopChan = make(chan struct{})
+	os.WriteFile(filepath.Join(tmpDir, "HEARTBEAT.md"), []byte("Test task"), 0o644)
+
+	var gotChannel, gotChatID string
+	hs.SetHandler(func(prompt, channel, chatID string) *tools.ToolResult {
+		gotChannel, gotChatID = channel, chatID
+		return tools.SilentResult("ok")
+	})
+
+	if err := hs.state.SetLastChannel("telegram:123"); err != nil {
+		t.Fatalf("SetLastChannel failed: %v", err)
+	}
+	hs.executeHeartbeat()
+	if gotChannel != "telegram" || gotChatID != "123" {
+		t.Errorf("handler target = %q, %q, want telegram, 123", gotChannel, gotChatID)
+	}
+
+	// Internal channels can't receive the result.
+	if err := hs.state.SetLastChannel("cli:direct"); err != nil {
+		t.Fatalf("SetLastChannel failed: %v", err)
+	}
+	hs.executeHeartbeat()
+	if gotChannel != "" || gotChatID != "" {
+		t.Errorf("handler target for internal channel = %q, %q, want empty", gotChannel, gotChatID)
+	}
+}
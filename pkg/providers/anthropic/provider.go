@@ -3,13 +3,16 @@ package anthropicprovider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
 
+	"github.com/sipeed/picoclaw/pkg/providers/common"
 	"github.com/sipeed/picoclaw/pkg/providers/protocoltypes"
 )
 
@@ -94,17 +97,40 @@ func (p *Provider) Chat(
 		return nil, err
 	}
 
+	var httpResp *http.Response
+	opts = append(opts, option.WithResponseInto(&httpResp))
+
 	// OAuth/setup-tokens require streaming; API keys use non-streaming.
 	if p.tokenSource != nil {
-		return p.chatStreaming(ctx, params, opts)
+		out, err := p.chatStreaming(ctx, params, opts)
+		if err != nil {
+			return nil, common.WithRequestID(err, requestID(httpResp, err))
+		}
+		out.RequestID = requestID(httpResp, nil)
+		return out, nil
 	}
 
 	resp, err := p.client.Messages.New(ctx, params, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("claude API call: %w", err)
+		return nil, common.WithRequestID(fmt.Errorf("claude API call: %w", err), requestID(httpResp, err))
 	}
 
-	return parseResponse(resp), nil
+	out := parseResponse(resp)
+	out.RequestID = requestID(httpResp, nil)
+	return out, nil
+}
+
+// requestID returns Anthropic's request ID from the raw response or, when
+// the call failed before a response was captured, from the API error.
+func requestID(httpResp *http.Response, err error) string {
+	if httpResp != nil {
+		return common.RequestID(httpResp.Header)
+	}
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) && apiErr.Response != nil {
+		return common.RequestID(apiErr.Response.Header)
+	}
+	return ""
 }
 
 func (p *Provider) chatStreaming(
@@ -16,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/sipeed/picoclaw/pkg/providers/common"
 	"github.com/sipeed/picoclaw/pkg/providers/protocoltypes"
 )
 
@@ -119,28 +120,40 @@ func (p *Provider) Chat(
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
 
-	// Check for HTTP errors with detailed messages
-	switch resp.StatusCode {
+	requestID := common.RequestID(resp.Header)
+	if err := statusError(resp.StatusCode, body); err != nil {
+		return nil, common.WithRequestID(err, requestID)
+	}
+
+	// Parse response
+	out, err := parseResponseBody(body)
+	if err != nil {
+		return nil, common.WithRequestID(err, requestID)
+	}
+	out.RequestID = requestID
+	return out, nil
+}
+
+// statusError returns a detailed error for a non-200 response, or nil.
+func statusError(statusCode int, body []byte) error {
+	switch statusCode {
+	case http.StatusOK:
+		return nil
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("authentication failed (401): check your API key")
+		return fmt.Errorf("authentication failed (401): check your API key")
 	case http.StatusTooManyRequests:
-		return nil, fmt.Errorf("rate limited (429): %s", string(body))
+		return fmt.Errorf("rate limited (429): %s", string(body))
 	case http.StatusBadRequest:
-		return nil, fmt.Errorf("bad request (400): %s", string(body))
+		return fmt.Errorf("bad request (400): %s", string(body))
 	case http.StatusNotFound:
-		return nil, fmt.Errorf("endpoint not found (404): %s", string(body))
+		return fmt.Errorf("endpoint not found (404): %s", string(body))
 	case http.StatusInternalServerError:
-		return nil, fmt.Errorf("internal server error (500): %s", string(body))
+		return fmt.Errorf("internal server error (500): %s", string(body))
 	case http.StatusServiceUnavailable:
-		return nil, fmt.Errorf("service unavailable (503): %s", string(body))
+		return fmt.Errorf("service unavailable (503): %s", string(body))
 	default:
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-		}
+		return fmt.Errorf("API request failed with status %d: %s", statusCode, string(body))
 	}
-
-	// Parse response
-	return parseResponseBody(body)
 }
 
 // GetDefaultModel returns the default model for this provider.
@@ -8,6 +8,8 @@ package anthropicmessages
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
@@ -620,3 +622,35 @@ func TestProviderChatErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestProviderChat_RequestID(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Request-Id", "req_123")
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn"}`))
+		} else {
+			w.Write([]byte(`{"error":"overloaded"}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := NewProvider("key", server.URL)
+	opts := map[string]any{"max_tokens": 16}
+	messages := []Message{{Role: "user", Content: "Test"}}
+
+	resp, err := provider.Chat(context.Background(), messages, nil, "test-model", opts)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.RequestID != "req_123" {
+		t.Errorf("RequestID = %q, want req_123", resp.RequestID)
+	}
+
+	status = http.StatusServiceUnavailable
+	_, err = provider.Chat(context.Background(), messages, nil, "test-model", opts)
+	if err == nil || !strings.Contains(err.Error(), "request_id: req_123") {
+		t.Errorf("expected error to carry the request ID, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrAttemptBudgetExhausted is returned when a request has used up its
+// AttemptBudget before an LLM call could be made.
+var ErrAttemptBudgetExhausted = errors.New("llm attempt budget exhausted")
+
+// AttemptBudget caps the number of LLM calls made for one request, shared by
+// every retry path (fallback candidates, timeout and context-window retries).
+// A nil budget, or one with a limit of 0, is unlimited.
+type AttemptBudget struct {
+	mu    sync.Mutex
+	limit int
+	used  int
+}
+
+// NewAttemptBudget returns a budget allowing limit calls; 0 means unlimited.
+func NewAttemptBudget(limit int) *AttemptBudget {
+	return &AttemptBudget{limit: max(limit, 0)}
+}
+
+// Take reserves one call. It returns false when the budget is used up.
+func (b *AttemptBudget) Take() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit > 0 && b.used >= b.limit {
+		return false
+	}
+	b.used++
+	return true
+}
+
+// Exhausted reports whether no calls are left.
+func (b *AttemptBudget) Exhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.limit > 0 && b.used >= b.limit
+}
+
+// Used returns the number of calls taken so far.
+func (b *AttemptBudget) Used() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Limit returns the configured limit; 0 means unlimited.
+func (b *AttemptBudget) Limit() int {
+	if b == nil {
+		return 0
+	}
+	return b.limit
+}
+
+type attemptBudgetKey struct{}
+
+// WithAttemptBudget returns a context carrying budget, so FallbackChain.Execute
+// draws its candidate calls from it.
+func WithAttemptBudget(ctx context.Context, budget *AttemptBudget) context.Context {
+	return context.WithValue(ctx, attemptBudgetKey{}, budget)
+}
+
+// attemptBudgetFrom returns the budget carried by ctx, or nil.
+func attemptBudgetFrom(ctx context.Context) *AttemptBudget {
+	b, _ := ctx.Value(attemptBudgetKey{}).(*AttemptBudget)
+	return b
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/openai/openai-go/v3"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/sipeed/picoclaw/pkg/auth"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers/common"
 )
 
 const (
@@ -95,11 +97,15 @@ func (p *CodexProvider) Chat(
 		)
 	}
 
-	// Respect tools.web.prefer_native: only inject native search when the agent
-	// loop requested it (options["native_search"]), so prefer_native: false
+	// Respect tools.web.search_mode: only inject native search when the agent
+	// loop requested it (options["native_search"]), so search_mode "local"
+	// keeps Codex on the client-side web_search tool.
 	useNativeSearch := p.enableWebSearch && (options["native_search"] == true)
 	params := buildCodexParams(messages, tools, resolvedModel, options, useNativeSearch)
 
+	var httpResp *http.Response
+	opts = append(opts, option.WithResponseInto(&httpResp))
+
 	stream := p.client.Responses.NewStreaming(ctx, params, opts...)
 	defer stream.Close()
 
@@ -124,6 +130,10 @@ func (p *CodexProvider) Chat(
 			"account_id_present": accountID != "",
 			"error":              err.Error(),
 		}
+		requestID := ""
+		if httpResp != nil {
+			requestID = common.RequestID(httpResp.Header)
+		}
 		var apiErr *openai.Error
 		if errors.As(err, &apiErr) {
 			fields["status_code"] = apiErr.StatusCode
@@ -135,11 +145,14 @@ func (p *CodexProvider) Chat(
 				fields["hint"] = "verify account id header and model compatibility for codex backend"
 			}
 			if apiErr.Response != nil {
-				fields["request_id"] = apiErr.Response.Header.Get("x-request-id")
+				requestID = common.RequestID(apiErr.Response.Header)
 			}
 		}
+		if requestID != "" {
+			fields["request_id"] = requestID
+		}
 		logger.ErrorCF("provider.codex", "Codex API call failed", fields)
-		return nil, fmt.Errorf("codex API call: %w", err)
+		return nil, common.WithRequestID(fmt.Errorf("codex API call: %w", err), requestID)
 	}
 	if resp == nil {
 		fields := map[string]any{
@@ -153,7 +166,11 @@ func (p *CodexProvider) Chat(
 		return nil, fmt.Errorf("codex API call: stream ended without completed response")
 	}
 
-	return parseCodexResponse(resp), nil
+	out := parseCodexResponse(resp)
+	if httpResp != nil {
+		out.RequestID = common.RequestID(httpResp.Header)
+	}
+	return out, nil
 }
 
 func (p *CodexProvider) GetDefaultModel() string {
@@ -309,7 +326,8 @@ func buildCodexParams(
 	}
 
 	if len(tools) > 0 || enableWebSearch {
-		params.Tools = translateToolsForCodex(tools, enableWebSearch)
+		keepClientSearch, _ := options["keep_client_search"].(bool)
+		params.Tools = translateToolsForCodex(tools, enableWebSearch, keepClientSearch)
 	}
 
 	return params
@@ -339,7 +357,10 @@ func resolveCodexToolCall(tc ToolCall) (name string, arguments string, ok bool)
 	return name, "{}", true
 }
 
-func translateToolsForCodex(tools []ToolDefinition, enableWebSearch bool) []responses.ToolUnionParam {
+// translateToolsForCodex converts tool definitions to Responses API tools.
+// When enableWebSearch is set the built-in web search tool is appended and,
+// unless keepClientSearch is set, the client-side web_search function is dropped.
+func translateToolsForCodex(tools []ToolDefinition, enableWebSearch, keepClientSearch bool) []responses.ToolUnionParam {
 	capHint := len(tools)
 	if enableWebSearch {
 		capHint++
@@ -349,7 +370,7 @@ func translateToolsForCodex(tools []ToolDefinition, enableWebSearch bool) []resp
 		if t.Type != "function" {
 			continue
 		}
-		if enableWebSearch && strings.EqualFold(t.Function.Name, "web_search") {
+		if enableWebSearch && !keepClientSearch && strings.EqualFold(t.Function.Name, "web_search") {
 			continue
 		}
 		ft := responses.FunctionToolParam{
@@ -11,6 +11,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -234,6 +235,20 @@ func DecodeToolCallArguments(raw json.RawMessage, name string) map[string]any {
 		return arguments
 	}
 
+	// Some hosts (e.g. Together AI with Llama models) encode the arguments
+	// string twice; unwrap nested strings before decoding the object.
+	for {
+		s, ok := decoded.(string)
+		if !ok {
+			break
+		}
+		var inner string
+		if json.Unmarshal([]byte(strings.TrimSpace(s)), &inner) != nil {
+			break
+		}
+		decoded = inner
+	}
+
 	switch v := decoded.(type) {
 	case string:
 		if strings.TrimSpace(v) == "" {
@@ -255,6 +270,29 @@ func DecodeToolCallArguments(raw json.RawMessage, name string) map[string]any {
 
 // --- HTTP response helpers ---
 
+// requestIDHeaders are the response headers providers use to identify a
+// request in their own logs, in order of preference.
+var requestIDHeaders = []string{"X-Request-Id", "Openai-Request-Id", "Request-Id"}
+
+// RequestID returns the provider-side request ID from response headers,
+// or "" when the provider sent none.
+func RequestID(header http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := strings.TrimSpace(header.Get(name)); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// WithRequestID annotates err with the provider-side request ID, if any.
+func WithRequestID(err error, requestID string) error {
+	if err == nil || requestID == "" {
+		return err
+	}
+	return fmt.Errorf("%w (request_id: %s)", err, requestID)
+}
+
 // HandleErrorResponse reads a non-200 response body and returns an appropriate error.
 func HandleErrorResponse(resp *http.Response, apiBase string) error {
 	contentType := resp.Header.Get("Content-Type")
@@ -265,11 +303,11 @@ func HandleErrorResponse(resp *http.Response, apiBase string) error {
 	if LooksLikeHTML(body, contentType) {
 		return WrapHTMLResponseError(resp.StatusCode, body, contentType, apiBase)
 	}
-	return fmt.Errorf(
-		"API request failed:\n  Status: %d\n  Body:   %s",
-		resp.StatusCode,
-		ResponsePreview(body, 128),
-	)
+	msg := fmt.Sprintf("API request failed:\n  Status: %d\n  Body:   %s", resp.StatusCode, ResponsePreview(body, 128))
+	if id := RequestID(resp.Header); id != "" {
+		msg += "\n  Request ID: " + id
+	}
+	return errors.New(msg)
 }
 
 // ReadAndParseResponse peeks at the response body to detect HTML errors,
@@ -286,8 +324,9 @@ func ReadAndParseResponse(resp *http.Response, apiBase string) (*LLMResponse, er
 	}
 	out, err := ParseResponse(reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+		return nil, WithRequestID(fmt.Errorf("failed to parse JSON response: %w", err), RequestID(resp.Header))
 	}
+	out.RequestID = RequestID(resp.Header)
 	return out, nil
 }
 
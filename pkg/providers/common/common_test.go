@@ -224,6 +224,14 @@ func TestDecodeToolCallArguments_StringJSON(t *testing.T) {
 	}
 }
 
+func TestDecodeToolCallArguments_DoubleEncodedStringJSON(t *testing.T) {
+	raw := json.RawMessage(`"\"{\\\"city\\\":\\\"SF\\\"}\""`)
+	args := DecodeToolCallArguments(raw, "test")
+	if args["city"] != "SF" {
+		t.Errorf("city = %v, want SF (args %v)", args["city"], args)
+	}
+}
+
 func TestDecodeToolCallArguments_EmptyInput(t *testing.T) {
 	args := DecodeToolCallArguments(nil, "test")
 	if len(args) != 0 {
@@ -279,6 +287,46 @@ func TestHandleErrorResponse_JSONError(t *testing.T) {
 	}
 }
 
+func TestHandleErrorResponse_IncludesRequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-abc")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"boom"}`))
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("http.Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	err = HandleErrorResponse(resp, server.URL)
+	if err == nil || !strings.Contains(err.Error(), "Request ID: req-abc") {
+		t.Errorf("error should contain the request ID, got %v", err)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{"none", http.Header{}, ""},
+		{"x-request-id", http.Header{"X-Request-Id": {"a"}}, "a"},
+		{"openai-request-id", http.Header{"Openai-Request-Id": {"b"}}, "b"},
+		{"anthropic request-id", http.Header{"Request-Id": {"c"}}, "c"},
+		{"prefers x-request-id", http.Header{"Request-Id": {"c"}, "X-Request-Id": {"a"}}, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequestID(tt.header); got != tt.want {
+				t.Errorf("RequestID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestHandleErrorResponse_HTMLError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
@@ -0,0 +1,26 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/sipeed/picoclaw/pkg/semaphore"
+)
+
+// LimitedChat calls p.Chat once limit has a free slot, bounding the number
+// of in-flight Chat calls across every caller that shares limit. A nil limit
+// imposes no bound. It gives up with ctx's error if ctx is done while waiting.
+func LimitedChat(
+	ctx context.Context,
+	limit *semaphore.Semaphore,
+	p LLMProvider,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	if err := limit.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer limit.Release()
+	return p.Chat(ctx, messages, tools, model, options)
+}
@@ -0,0 +1,96 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/semaphore"
+)
+
+// blockingProvider holds every Chat call until release is closed and records
+// the highest number of concurrent calls.
+type blockingProvider struct {
+	release  chan struct{}
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (p *blockingProvider) Chat(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	<-p.release
+	return &LLMResponse{Content: "ok"}, nil
+}
+
+func (p *blockingProvider) GetDefaultModel() string { return "test" }
+
+func TestLimitedChat_BoundsInFlightCalls(t *testing.T) {
+	limit := semaphore.New(2)
+	provider := &blockingProvider{release: make(chan struct{})}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := LimitedChat(context.Background(), limit, provider, nil, nil, "m", nil); err != nil {
+				t.Errorf("LimitedChat() error = %v", err)
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for provider.inFlight.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(provider.release)
+	wg.Wait()
+
+	if got := provider.peak.Load(); got != 2 {
+		t.Errorf("peak concurrent calls = %d, want 2", got)
+	}
+}
+
+func TestLimitedChat_WaitRespectsContext(t *testing.T) {
+	limit := semaphore.New(1)
+	provider := &blockingProvider{release: make(chan struct{})}
+	defer close(provider.release)
+
+	go LimitedChat(context.Background(), limit, provider, nil, nil, "m", nil)
+	for provider.inFlight.Load() == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := LimitedChat(ctx, limit, provider, nil, nil, "m", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LimitedChat() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestLimitedChat_NilIsUnlimited(t *testing.T) {
+	var limit *semaphore.Semaphore
+	provider := &blockingProvider{release: make(chan struct{})}
+	close(provider.release)
+	resp, err := LimitedChat(context.Background(), limit, provider, nil, nil, "m", nil)
+	if err != nil || resp.Content != "ok" {
+		t.Errorf("LimitedChat() = %v, %v", resp, err)
+	}
+}
@@ -8,6 +8,12 @@ import (
 
 const (
 	defaultFailureWindow = 24 * time.Hour
+
+	// DefaultBaseCooldown is the cooldown after a first standard failure.
+	DefaultBaseCooldown = time.Minute
+	// rateLimitCooldownFactor stretches the standard cooldown for rate
+	// limits: retrying a throttled key early only extends the throttle.
+	rateLimitCooldownFactor = 2
 )
 
 // CooldownTracker manages per-provider cooldown state for the fallback chain.
@@ -16,6 +22,7 @@ type CooldownTracker struct {
 	mu            sync.RWMutex
 	entries       map[string]*cooldownEntry
 	failureWindow time.Duration
+	baseCooldown  time.Duration
 	nowFunc       func() time.Time // for testing
 }
 
@@ -25,14 +32,21 @@ type cooldownEntry struct {
 	CooldownEnd    time.Time      // standard cooldown expiry
 	DisabledUntil  time.Time      // billing-specific disable expiry
 	DisabledReason FailoverReason // reason for disable (billing)
+	LastReason     FailoverReason
 	LastFailure    time.Time
 }
 
 // NewCooldownTracker creates a tracker with default 24h failure window.
-func NewCooldownTracker() *CooldownTracker {
+// baseCooldown is the cooldown after a first standard failure and scales the
+// whole backoff; <= 0 uses DefaultBaseCooldown.
+func NewCooldownTracker(baseCooldown time.Duration) *CooldownTracker {
+	if baseCooldown <= 0 {
+		baseCooldown = DefaultBaseCooldown
+	}
 	return &CooldownTracker{
 		entries:       make(map[string]*cooldownEntry),
 		failureWindow: defaultFailureWindow,
+		baseCooldown:  baseCooldown,
 		nowFunc:       time.Now,
 	}
 }
@@ -40,6 +54,21 @@ func NewCooldownTracker() *CooldownTracker {
 // MarkFailure records a failure for a provider and sets appropriate cooldown.
 // Resets error counts if last failure was more than failureWindow ago.
 func (ct *CooldownTracker) MarkFailure(provider string, reason FailoverReason) {
+	ct.MarkFailureWithCooldown(provider, reason, 0)
+}
+
+// MarkFailureWithCooldown is MarkFailure with a per-provider base cooldown
+// overriding the tracker's; <= 0 uses the tracker's. Billing disables are
+// not affected.
+func (ct *CooldownTracker) MarkFailureWithCooldown(
+	provider string,
+	reason FailoverReason,
+	baseCooldown time.Duration,
+) {
+	if baseCooldown <= 0 {
+		baseCooldown = ct.baseCooldown
+	}
+
 	ct.mu.Lock()
 	defer ct.mu.Unlock()
 
@@ -54,6 +83,7 @@ func (ct *CooldownTracker) MarkFailure(provider string, reason FailoverReason) {
 
 	entry.ErrorCount++
 	entry.FailureCounts[reason]++
+	entry.LastReason = reason
 	entry.LastFailure = now
 
 	if reason == FailoverBilling {
@@ -61,7 +91,11 @@ func (ct *CooldownTracker) MarkFailure(provider string, reason FailoverReason) {
 		entry.DisabledUntil = now.Add(calculateBillingCooldown(billingCount))
 		entry.DisabledReason = FailoverBilling
 	} else {
-		entry.CooldownEnd = now.Add(calculateStandardCooldown(entry.ErrorCount))
+		cooldown := scaleCooldown(calculateStandardCooldown(entry.ErrorCount), baseCooldown)
+		if reason == FailoverRateLimit {
+			cooldown *= rateLimitCooldownFactor
+		}
+		entry.CooldownEnd = now.Add(cooldown)
 	}
 }
 
@@ -80,6 +114,7 @@ func (ct *CooldownTracker) MarkSuccess(provider string) {
 	entry.CooldownEnd = time.Time{}
 	entry.DisabledUntil = time.Time{}
 	entry.DisabledReason = ""
+	entry.LastReason = ""
 }
 
 // IsAvailable returns true if the provider is not in cooldown or disabled.
@@ -150,6 +185,19 @@ func (ct *CooldownTracker) ErrorCount(provider string) int {
 	return entry.ErrorCount
 }
 
+// LastReason returns the reason of the provider's most recent failure, or ""
+// if it has none since its last success.
+func (ct *CooldownTracker) LastReason(provider string) FailoverReason {
+	ct.mu.RLock()
+	defer ct.mu.RUnlock()
+
+	entry := ct.entries[provider]
+	if entry == nil {
+		return ""
+	}
+	return entry.LastReason
+}
+
 // FailureCount returns the failure count for a specific reason.
 func (ct *CooldownTracker) FailureCount(provider string, reason FailoverReason) int {
 	ct.mu.RLock()
@@ -188,6 +236,15 @@ func calculateStandardCooldown(errorCount int) time.Duration {
 	return time.Duration(ms) * time.Millisecond
 }
 
+// scaleCooldown rescales a standard cooldown, computed for
+// DefaultBaseCooldown, to base. The 1h cap scales with it.
+func scaleCooldown(d, base time.Duration) time.Duration {
+	if base == DefaultBaseCooldown {
+		return d
+	}
+	return time.Duration(float64(d) * float64(base) / float64(DefaultBaseCooldown))
+}
+
 // calculateBillingCooldown computes billing-specific exponential backoff.
 // Formula from OpenClaw: min(24h, 5h * 2^min(n-1, 10))
 //
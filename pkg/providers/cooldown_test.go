@@ -8,13 +8,13 @@ import (
 
 func newTestTracker(now time.Time) (*CooldownTracker, *time.Time) {
 	current := now
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	ct.nowFunc = func() time.Time { return current }
 	return ct, &current
 }
 
 func TestCooldown_InitiallyAvailable(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	if !ct.IsAvailable("openai") {
 		t.Error("new provider should be available")
 	}
@@ -28,7 +28,7 @@ func TestCooldown_StandardEscalation(t *testing.T) {
 	ct, current := newTestTracker(now)
 
 	// 1st error → 1 min cooldown
-	ct.MarkFailure("openai", FailoverRateLimit)
+	ct.MarkFailure("openai", FailoverTimeout)
 	if ct.IsAvailable("openai") {
 		t.Error("should be in cooldown after 1st error")
 	}
@@ -40,7 +40,7 @@ func TestCooldown_StandardEscalation(t *testing.T) {
 	}
 
 	// 2nd error → 5 min cooldown
-	ct.MarkFailure("openai", FailoverRateLimit)
+	ct.MarkFailure("openai", FailoverTimeout)
 	*current = now.Add(61*time.Second + 4*time.Minute)
 	if ct.IsAvailable("openai") {
 		t.Error("should be in cooldown (5 min) after 2nd error")
@@ -110,7 +110,7 @@ func TestCooldown_BillingCap(t *testing.T) {
 }
 
 func TestCooldown_SuccessReset(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 
 	ct.MarkFailure("openai", FailoverRateLimit)
 	ct.MarkFailure("openai", FailoverBilling)
@@ -157,7 +157,7 @@ func TestCooldown_FailureWindowReset(t *testing.T) {
 }
 
 func TestCooldown_PerReasonTracking(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 
 	ct.MarkFailure("openai", FailoverRateLimit)
 	ct.MarkFailure("openai", FailoverRateLimit)
@@ -183,8 +183,8 @@ func TestCooldown_BillingTakesPrecedence(t *testing.T) {
 	ct, current := newTestTracker(now)
 
 	// Standard cooldown (1 min) + billing disable (5h)
-	ct.MarkFailure("openai", FailoverRateLimit) // 1 min cooldown
-	ct.MarkFailure("openai", FailoverBilling)   // 5h disable
+	ct.MarkFailure("openai", FailoverTimeout) // 1 min cooldown
+	ct.MarkFailure("openai", FailoverBilling) // 5h disable
 
 	// After 2 min: standard cooldown expired but billing still active
 	*current = now.Add(2 * time.Minute)
@@ -208,7 +208,7 @@ func TestCooldown_CooldownRemaining(t *testing.T) {
 		t.Error("expected 0 remaining for new provider")
 	}
 
-	ct.MarkFailure("openai", FailoverRateLimit)
+	ct.MarkFailure("openai", FailoverTimeout)
 
 	*current = now.Add(30 * time.Second)
 	remaining := ct.CooldownRemaining("openai")
@@ -217,8 +217,56 @@ func TestCooldown_CooldownRemaining(t *testing.T) {
 	}
 }
 
+func TestCooldown_RateLimitIsLonger(t *testing.T) {
+	now := time.Now()
+	ct, current := newTestTracker(now)
+
+	ct.MarkFailure("openai", FailoverRateLimit)
+	ct.MarkFailure("anthropic", FailoverTimeout)
+	if got := ct.LastReason("openai"); got != FailoverRateLimit {
+		t.Errorf("LastReason = %q, want %q", got, FailoverRateLimit)
+	}
+
+	// A 5xx/timeout waits 1 min, a rate limit twice as long.
+	*current = now.Add(61 * time.Second)
+	if !ct.IsAvailable("anthropic") {
+		t.Error("timeout cooldown should have expired after 1 min")
+	}
+	if ct.IsAvailable("openai") {
+		t.Error("rate limit cooldown should last 2 min")
+	}
+	*current = now.Add(121 * time.Second)
+	if !ct.IsAvailable("openai") {
+		t.Error("rate limit cooldown should have expired after 2 min")
+	}
+}
+
+func TestCooldown_BaseCooldown(t *testing.T) {
+	now := time.Now()
+	ct := NewCooldownTracker(10 * time.Minute)
+	ct.nowFunc = func() time.Time { return now }
+
+	ct.MarkFailure("openai", FailoverTimeout)
+	if got := ct.CooldownRemaining("openai"); got != 10*time.Minute {
+		t.Errorf("remaining = %v, want 10m", got)
+	}
+
+	// A per-provider base overrides the tracker's, and the backoff scales:
+	// the second failure waits 5x the base.
+	ct.MarkFailureWithCooldown("anthropic", FailoverTimeout, 30*time.Second)
+	ct.MarkFailureWithCooldown("anthropic", FailoverTimeout, 30*time.Second)
+	if got := ct.CooldownRemaining("anthropic"); got != 150*time.Second {
+		t.Errorf("remaining = %v, want 2m30s", got)
+	}
+
+	ct.MarkSuccess("anthropic")
+	if got := ct.LastReason("anthropic"); got != "" {
+		t.Errorf("LastReason after success = %q, want empty", got)
+	}
+}
+
 func TestCooldown_SuccessOnUnknownProvider(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	// Should not panic
 	ct.MarkSuccess("nonexistent")
 	if !ct.IsAvailable("nonexistent") {
@@ -227,7 +275,7 @@ func TestCooldown_SuccessOnUnknownProvider(t *testing.T) {
 }
 
 func TestCooldown_ConcurrentAccess(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	var wg sync.WaitGroup
 
 	for range 100 {
@@ -251,7 +299,7 @@ func TestCooldown_ConcurrentAccess(t *testing.T) {
 }
 
 func TestCooldown_MultipleProviders(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 
 	ct.MarkFailure("openai", FailoverRateLimit)
 	ct.MarkFailure("anthropic", FailoverBilling)
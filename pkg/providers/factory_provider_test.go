@@ -251,6 +251,47 @@ func TestGetDefaultAPIBase_Novita(t *testing.T) {
 	}
 }
 
+func TestCreateProviderFromConfig_TogetherAndFireworks(t *testing.T) {
+	tests := []struct {
+		model       string
+		wantModelID string
+		wantAPIBase string
+	}{
+		{
+			model:       "together/meta-llama/Llama-3.3-70B-Instruct-Turbo",
+			wantModelID: "meta-llama/Llama-3.3-70B-Instruct-Turbo",
+			wantAPIBase: "https://api.together.xyz/v1",
+		},
+		{
+			model:       "fireworks/accounts/fireworks/models/llama-v3p1-70b-instruct",
+			wantModelID: "accounts/fireworks/models/llama-v3p1-70b-instruct",
+			wantAPIBase: "https://api.fireworks.ai/inference/v1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			provider, modelID, err := CreateProviderFromConfig(&config.ModelConfig{
+				ModelName: "test",
+				Model:     tt.model,
+				APIKey:    "test-key",
+			})
+			if err != nil {
+				t.Fatalf("CreateProviderFromConfig() error = %v", err)
+			}
+			if modelID != tt.wantModelID {
+				t.Errorf("modelID = %q, want %q", modelID, tt.wantModelID)
+			}
+			if _, ok := provider.(*HTTPProvider); !ok {
+				t.Fatalf("expected *HTTPProvider, got %T", provider)
+			}
+			protocol, _ := ExtractProtocol(tt.model)
+			if got := getDefaultAPIBase(protocol); got != tt.wantAPIBase {
+				t.Errorf("getDefaultAPIBase(%q) = %q, want %q", protocol, got, tt.wantAPIBase)
+			}
+		})
+	}
+}
+
 func TestCreateProviderFromConfig_Anthropic(t *testing.T) {
 	cfg := &config.ModelConfig{
 		ModelName: "test-anthropic",
@@ -1,21 +1,36 @@
 package providers
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"strings"
 	"time"
 )
 
+// DefaultFallbackAttemptTimeout bounds a single candidate call when neither
+// the candidate nor the chain configures a timeout. It matches the default
+// HTTP request timeout so HTTP providers behave as before, while CLI or
+// misbehaving providers can no longer hang the whole chain.
+const DefaultFallbackAttemptTimeout = 120 * time.Second
+
 // FallbackChain orchestrates model fallback across multiple candidates.
 type FallbackChain struct {
-	cooldown *CooldownTracker
+	cooldown       *CooldownTracker
+	maxAttempts    int           // 0 = unlimited
+	budget         time.Duration // 0 = unlimited
+	attemptTimeout time.Duration // per-candidate default
 }
 
 // FallbackCandidate represents one model/provider to try.
 type FallbackCandidate struct {
 	Provider string
 	Model    string
+	// Timeout bounds this candidate's attempt; 0 uses the chain default.
+	Timeout time.Duration
+	// Cooldown is the base cooldown after this candidate fails; 0 uses the
+	// tracker default.
+	Cooldown time.Duration
 }
 
 // FallbackResult contains the successful response and metadata about all attempts.
@@ -24,6 +39,9 @@ type FallbackResult struct {
 	Provider string
 	Model    string
 	Attempts []FallbackAttempt
+	// BudgetExhausted is true when the chain stopped early because the
+	// attempt cap or total time budget was reached.
+	BudgetExhausted bool
 }
 
 // FallbackAttempt records one attempt in the fallback chain.
@@ -38,7 +56,24 @@ type FallbackAttempt struct {
 
 // NewFallbackChain creates a new fallback chain with the given cooldown tracker.
 func NewFallbackChain(cooldown *CooldownTracker) *FallbackChain {
-	return &FallbackChain{cooldown: cooldown}
+	return &FallbackChain{cooldown: cooldown, attemptTimeout: DefaultFallbackAttemptTimeout}
+}
+
+// SetAttemptTimeout sets the per-candidate timeout used by Execute for
+// candidates without their own Timeout. Zero restores the default.
+func (fc *FallbackChain) SetAttemptTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultFallbackAttemptTimeout
+	}
+	fc.attemptTimeout = timeout
+}
+
+// SetLimits bounds the worst-case latency of Execute. maxAttempts caps the
+// number of candidates actually called (cooldown skips do not count) and
+// budget caps the total wall time across all attempts. Zero disables a limit.
+func (fc *FallbackChain) SetLimits(maxAttempts int, budget time.Duration) {
+	fc.maxAttempts = max(maxAttempts, 0)
+	fc.budget = max(budget, 0)
 }
 
 // ResolveCandidates parses model config into a deduplicated candidate list.
@@ -98,6 +133,9 @@ func ResolveCandidatesWithLookup(
 //   - Retriable errors trigger fallback to next candidate.
 //   - Success marks provider as good (resets cooldown).
 //   - If all fail, returns aggregate error with all attempts.
+//   - If the attempt cap or time budget (SetLimits), or the request's
+//     AttemptBudget, is reached first, returns the partial result with
+//     BudgetExhausted set and an aggregate error.
 func (fc *FallbackChain) Execute(
 	ctx context.Context,
 	candidates []FallbackCandidate,
@@ -111,10 +149,28 @@ func (fc *FallbackChain) Execute(
 		Attempts: make([]FallbackAttempt, 0, len(candidates)),
 	}
 
+	// The budget context is derived from ctx, so the caller's deadline still
+	// applies; budgetCtx only adds the chain-wide limit on top.
+	budgetCtx := ctx
+	if fc.budget > 0 {
+		var cancel context.CancelFunc
+		budgetCtx, cancel = context.WithTimeout(ctx, fc.budget)
+		defer cancel()
+	}
+	called := 0
+	// A request-wide budget (WithAttemptBudget) caps the calls across this
+	// chain and any retries around it.
+	attempts := attemptBudgetFrom(ctx)
+
 	for i, candidate := range candidates {
 		// Check context before each attempt.
-		if ctx.Err() == context.Canceled {
-			return nil, context.Canceled
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if (fc.maxAttempts > 0 && called >= fc.maxAttempts) || budgetCtx.Err() != nil || attempts.Exhausted() {
+			result.BudgetExhausted = true
+			return result, &FallbackExhaustedError{Attempts: result.Attempts, BudgetExhausted: true}
 		}
 
 		// Check cooldown (per provider/model, not just provider).
@@ -126,7 +182,7 @@ func (fc *FallbackChain) Execute(
 				Provider: candidate.Provider,
 				Model:    candidate.Model,
 				Skipped:  true,
-				Reason:   FailoverRateLimit,
+				Reason:   cmp.Or(fc.cooldown.LastReason(cooldownKey), FailoverRateLimit),
 				Error: fmt.Errorf(
 					"%s in cooldown (%s remaining)",
 					cooldownKey,
@@ -136,10 +192,17 @@ func (fc *FallbackChain) Execute(
 			continue
 		}
 
-		// Execute the run function.
+		// Execute the run function under its own timeout so a hung candidate
+		// leaves the rest of the budget to the fallbacks.
+		called++
+		attempts.Take()
+		timeout := cmp.Or(candidate.Timeout, fc.attemptTimeout)
+		attemptCtx, cancelAttempt := context.WithTimeout(budgetCtx, timeout)
 		start := time.Now()
-		resp, err := run(ctx, candidate.Provider, candidate.Model)
+		resp, err := run(attemptCtx, candidate.Provider, candidate.Model)
 		elapsed := time.Since(start)
+		attemptTimedOut := attemptCtx.Err() == context.DeadlineExceeded
+		cancelAttempt()
 
 		if err == nil {
 			// Success.
@@ -151,18 +214,41 @@ func (fc *FallbackChain) Execute(
 		}
 
 		// Context cancellation: abort immediately, no fallback.
-		if ctx.Err() == context.Canceled {
+		if ctxErr := ctx.Err(); ctxErr != nil {
 			result.Attempts = append(result.Attempts, FallbackAttempt{
 				Provider: candidate.Provider,
 				Model:    candidate.Model,
 				Error:    err,
 				Duration: elapsed,
 			})
-			return nil, context.Canceled
+			return nil, ctxErr
 		}
 
-		// Classify the error.
+		// Budget ran out mid-call: the error is ours, not the provider's, so
+		// don't put the candidate into cooldown.
+		if budgetCtx.Err() != nil {
+			result.Attempts = append(result.Attempts, FallbackAttempt{
+				Provider: candidate.Provider,
+				Model:    candidate.Model,
+				Error:    err,
+				Reason:   FailoverTimeout,
+				Duration: elapsed,
+			})
+			result.BudgetExhausted = true
+			return result, &FallbackExhaustedError{Attempts: result.Attempts, BudgetExhausted: true}
+		}
+
+		// Classify the error. A per-attempt timeout is always a retriable
+		// timeout, whatever the provider wrapped the deadline error in.
 		failErr := ClassifyError(err, candidate.Provider, candidate.Model)
+		if attemptTimedOut {
+			failErr = &FailoverError{
+				Reason:   FailoverTimeout,
+				Provider: candidate.Provider,
+				Model:    candidate.Model,
+				Wrapped:  fmt.Errorf("attempt timed out after %s: %w", timeout, err),
+			}
+		}
 
 		if failErr == nil {
 			// Unclassifiable error: do not fallback, return immediately.
@@ -189,7 +275,7 @@ func (fc *FallbackChain) Execute(
 		}
 
 		// Retriable error: mark failure and continue to next candidate.
-		fc.cooldown.MarkFailure(cooldownKey, failErr.Reason)
+		fc.cooldown.MarkFailureWithCooldown(cooldownKey, failErr.Reason, candidate.Cooldown)
 		result.Attempts = append(result.Attempts, FallbackAttempt{
 			Provider: candidate.Provider,
 			Model:    candidate.Model,
@@ -284,14 +370,20 @@ func (fc *FallbackChain) ExecuteImage(
 	return nil, &FallbackExhaustedError{Attempts: result.Attempts}
 }
 
-// FallbackExhaustedError indicates all fallback candidates were tried and failed.
+// FallbackExhaustedError indicates all fallback candidates were tried and failed,
+// or that the chain's attempt cap or time budget ran out first.
 type FallbackExhaustedError struct {
-	Attempts []FallbackAttempt
+	Attempts        []FallbackAttempt
+	BudgetExhausted bool
 }
 
 func (e *FallbackExhaustedError) Error() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("fallback: all %d candidates failed:", len(e.Attempts)))
+	if e.BudgetExhausted {
+		sb.WriteString(fmt.Sprintf("fallback: budget exhausted after %d attempts:", len(e.Attempts)))
+	} else {
+		sb.WriteString(fmt.Sprintf("fallback: all %d candidates failed:", len(e.Attempts)))
+	}
 	for i, a := range e.Attempts {
 		if a.Skipped {
 			sb.WriteString(fmt.Sprintf("\n  [%d] %s/%s: skipped (cooldown)", i+1, a.Provider, a.Model))
@@ -302,3 +394,13 @@ func (e *FallbackExhaustedError) Error() string {
 	}
 	return sb.String()
 }
+
+// Unwrap returns the error of the last attempted (non-skipped) candidate.
+func (e *FallbackExhaustedError) Unwrap() error {
+	for i := len(e.Attempts) - 1; i >= 0; i-- {
+		if !e.Attempts[i].Skipped {
+			return e.Attempts[i].Error
+		}
+	}
+	return nil
+}
@@ -18,7 +18,7 @@ func successRun(content string) func(ctx context.Context, provider, model string
 }
 
 func TestFallback_SingleCandidate_Success(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{makeCandidate("openai", "gpt-4")}
@@ -35,7 +35,7 @@ func TestFallback_SingleCandidate_Success(t *testing.T) {
 }
 
 func TestFallback_SecondCandidateSuccess(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{
@@ -68,7 +68,7 @@ func TestFallback_SecondCandidateSuccess(t *testing.T) {
 }
 
 func TestFallback_AllFail(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{
@@ -95,7 +95,7 @@ func TestFallback_AllFail(t *testing.T) {
 }
 
 func TestFallback_ContextCanceled(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -122,7 +122,7 @@ func TestFallback_ContextCanceled(t *testing.T) {
 }
 
 func TestFallback_NonRetriableError(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{
@@ -191,8 +191,97 @@ func TestFallback_CooldownSkip(t *testing.T) {
 	}
 }
 
+func TestFallback_RateLimitedCandidateSkippedUntilCooldownExpires(t *testing.T) {
+	now := time.Now()
+	ct, current := newTestTracker(now)
+	fc := NewFallbackChain(ct)
+
+	candidates := []FallbackCandidate{
+		makeCandidate("openai", "gpt-4"),
+		makeCandidate("anthropic", "claude"),
+	}
+	openaiCalls := 0
+	run := func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+		if provider == "openai" {
+			openaiCalls++
+			return nil, errors.New("status: 429 too many requests")
+		}
+		return &LLMResponse{Content: "claude response", FinishReason: "stop"}, nil
+	}
+	execute := func() *FallbackResult {
+		t.Helper()
+		result, err := fc.Execute(context.Background(), candidates, run)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Provider != "anthropic" {
+			t.Fatalf("provider = %q, want anthropic", result.Provider)
+		}
+		return result
+	}
+
+	// First request: openai is rate-limited, anthropic answers.
+	execute()
+	if openaiCalls != 1 {
+		t.Fatalf("openai calls = %d, want 1", openaiCalls)
+	}
+
+	// Within the 2 min rate-limit cooldown openai is skipped, and the skip
+	// reports the recorded reason.
+	*current = now.Add(90 * time.Second)
+	result := execute()
+	if openaiCalls != 1 {
+		t.Fatalf("openai calls = %d, want 1 (in cooldown)", openaiCalls)
+	}
+	if a := result.Attempts[0]; !a.Skipped || a.Reason != FailoverRateLimit {
+		t.Errorf("attempt = %+v, want skipped with rate_limit", a)
+	}
+
+	// After it expires openai is tried again and, still rate-limited, goes
+	// into a longer cooldown (2 x 5 min).
+	*current = now.Add(121 * time.Second)
+	execute()
+	if openaiCalls != 2 {
+		t.Fatalf("openai calls = %d, want 2 (cooldown expired)", openaiCalls)
+	}
+	*current = now.Add(121*time.Second + 9*time.Minute)
+	execute()
+	if openaiCalls != 2 {
+		t.Fatalf("openai calls = %d, want 2 (second cooldown)", openaiCalls)
+	}
+	*current = now.Add(121*time.Second + 10*time.Minute + time.Second)
+	execute()
+	if openaiCalls != 3 {
+		t.Fatalf("openai calls = %d, want 3 (second cooldown expired)", openaiCalls)
+	}
+}
+
+func TestFallback_CandidateCooldown(t *testing.T) {
+	now := time.Now()
+	ct, _ := newTestTracker(now)
+	fc := NewFallbackChain(ct)
+
+	flaky := makeCandidate("openai", "gpt-4")
+	flaky.Cooldown = 10 * time.Minute
+	candidates := []FallbackCandidate{flaky, makeCandidate("anthropic", "claude")}
+
+	_, err := fc.Execute(context.Background(), candidates,
+		func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+			if provider == "openai" {
+				return nil, errors.New("status: 503 service unavailable")
+			}
+			return &LLMResponse{Content: "ok", FinishReason: "stop"}, nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ct.CooldownRemaining(ModelKey("openai", "gpt-4")); got != 10*time.Minute {
+		t.Errorf("cooldown = %v, want 10m from the candidate", got)
+	}
+}
+
 func TestFallback_AllInCooldown(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	// Put all models in cooldown (using ModelKey now)
@@ -220,7 +309,7 @@ func TestFallback_AllInCooldown(t *testing.T) {
 }
 
 func TestFallback_NoCandidates(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	_, err := fc.Execute(context.Background(), nil, successRun("ok"))
@@ -231,7 +320,7 @@ func TestFallback_NoCandidates(t *testing.T) {
 
 func TestFallback_EmptyFallbacks(t *testing.T) {
 	// Single primary, no fallbacks: should work like direct call
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{makeCandidate("openai", "gpt-4")}
@@ -245,7 +334,7 @@ func TestFallback_EmptyFallbacks(t *testing.T) {
 }
 
 func TestFallback_UnclassifiedError(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{
@@ -269,7 +358,7 @@ func TestFallback_UnclassifiedError(t *testing.T) {
 }
 
 func TestFallback_SuccessResetsCooldown(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{makeCandidate("openai", "gpt-4")}
@@ -295,8 +384,198 @@ func TestFallback_SuccessResetsCooldown(t *testing.T) {
 
 // --- Image Fallback Tests ---
 
+func TestFallback_MaxAttemptsCap(t *testing.T) {
+	ct := NewCooldownTracker(0)
+	fc := NewFallbackChain(ct)
+	fc.SetLimits(2, 0)
+
+	candidates := []FallbackCandidate{
+		makeCandidate("openai", "gpt-4"),
+		makeCandidate("anthropic", "claude"),
+		makeCandidate("groq", "llama"),
+	}
+
+	calls := 0
+	run := func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+		calls++
+		return nil, errors.New("rate limit exceeded")
+	}
+
+	result, err := fc.Execute(context.Background(), candidates, run)
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+	var exhausted *FallbackExhaustedError
+	if !errors.As(err, &exhausted) || !exhausted.BudgetExhausted {
+		t.Fatalf("expected budget-exhausted FallbackExhaustedError, got %v", err)
+	}
+	if result == nil || !result.BudgetExhausted || len(result.Attempts) != 2 {
+		t.Fatalf("expected partial result with 2 attempts and BudgetExhausted, got %+v", result)
+	}
+}
+
+func TestFallback_SharedAttemptBudget(t *testing.T) {
+	ct := NewCooldownTracker(0)
+	fc := NewFallbackChain(ct)
+
+	candidates := []FallbackCandidate{
+		makeCandidate("openai", "gpt-4"),
+		makeCandidate("anthropic", "claude"),
+		makeCandidate("groq", "llama"),
+	}
+
+	calls := 0
+	run := func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+		calls++
+		return nil, errors.New("rate limit exceeded")
+	}
+
+	// One call was already spent by an earlier retry of the same request.
+	budget := NewAttemptBudget(3)
+	budget.Take()
+
+	result, err := fc.Execute(WithAttemptBudget(context.Background(), budget), candidates, run)
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+	if budget.Used() != 3 || !budget.Exhausted() {
+		t.Errorf("budget used = %d, exhausted = %v, want 3 and true", budget.Used(), budget.Exhausted())
+	}
+	if result == nil || !result.BudgetExhausted {
+		t.Fatalf("expected BudgetExhausted result, got %+v (err %v)", result, err)
+	}
+}
+
+func TestAttemptBudget_Unlimited(t *testing.T) {
+	var nilBudget *AttemptBudget
+	if !nilBudget.Take() || nilBudget.Exhausted() {
+		t.Error("nil budget should be unlimited")
+	}
+	b := NewAttemptBudget(0)
+	for range 10 {
+		if !b.Take() {
+			t.Fatal("zero limit should be unlimited")
+		}
+	}
+	if b.Used() != 10 {
+		t.Errorf("Used() = %d, want 10", b.Used())
+	}
+}
+
+func TestFallback_TimeBudget(t *testing.T) {
+	ct := NewCooldownTracker(0)
+	fc := NewFallbackChain(ct)
+	fc.SetLimits(0, 50*time.Millisecond)
+
+	candidates := []FallbackCandidate{
+		makeCandidate("openai", "gpt-4"),
+		makeCandidate("anthropic", "claude"),
+	}
+
+	calls := 0
+	run := func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+		calls++
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	result, err := fc.Execute(context.Background(), candidates, run)
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 (budget spent by the first candidate)", calls)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to wrap the last attempt's deadline error, got %v", err)
+	}
+	if result == nil || !result.BudgetExhausted {
+		t.Fatalf("expected BudgetExhausted result, got %+v", result)
+	}
+	// Running out of our own budget must not put the provider into cooldown.
+	if !ct.IsAvailable(ModelKey("openai", "gpt-4")) {
+		t.Error("budget timeout should not mark the candidate as failed")
+	}
+}
+
+func TestFallback_PerAttemptTimeout(t *testing.T) {
+	ct := NewCooldownTracker(0)
+	fc := NewFallbackChain(ct)
+	fc.SetLimits(0, 5*time.Second)
+
+	candidates := []FallbackCandidate{
+		{Provider: "openai", Model: "gpt-4", Timeout: 30 * time.Millisecond},
+		makeCandidate("anthropic", "claude"),
+	}
+
+	run := func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+		if provider == "openai" {
+			// Simulate a hung provider that only returns once the context ends,
+			// wrapping the deadline the way HTTP clients do.
+			<-ctx.Done()
+			return nil, errors.New("Post \"https://api\": " + ctx.Err().Error())
+		}
+		return &LLMResponse{Content: "from claude", FinishReason: "stop"}, nil
+	}
+
+	start := time.Now()
+	result, err := fc.Execute(context.Background(), candidates, run)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("fallback took %v, want the hung primary to be abandoned promptly", time.Since(start))
+	}
+	if result.Provider != "anthropic" {
+		t.Errorf("provider = %q, want anthropic", result.Provider)
+	}
+	if len(result.Attempts) != 1 || result.Attempts[0].Reason != FailoverTimeout {
+		t.Fatalf("expected one timeout attempt, got %+v", result.Attempts)
+	}
+	if ct.IsAvailable(ModelKey("openai", "gpt-4")) {
+		t.Error("timed-out candidate should be put into cooldown")
+	}
+}
+
+func TestFallback_ChainAttemptTimeoutDefault(t *testing.T) {
+	fc := NewFallbackChain(NewCooldownTracker(0))
+	fc.SetAttemptTimeout(20 * time.Millisecond)
+
+	var deadline time.Time
+	run := func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+		deadline, _ = ctx.Deadline()
+		return &LLMResponse{Content: "ok"}, nil
+	}
+
+	before := time.Now()
+	if _, err := fc.Execute(context.Background(), []FallbackCandidate{makeCandidate("openai", "gpt-4")}, run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deadline.IsZero() || deadline.Sub(before) > time.Second {
+		t.Errorf("expected attempt deadline from chain default, got %v", deadline)
+	}
+}
+
+func TestFallback_ParentDeadlineStopsChain(t *testing.T) {
+	fc := NewFallbackChain(NewCooldownTracker(0))
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	calls := 0
+	run := func(ctx context.Context, provider, model string) (*LLMResponse, error) {
+		calls++
+		return nil, ctx.Err()
+	}
+
+	_, err := fc.Execute(ctx, []FallbackCandidate{makeCandidate("openai", "gpt-4")}, run)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("calls = %d, want 0", calls)
+	}
+}
+
 func TestImageFallback_Success(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{makeCandidate("openai", "gpt-4o")}
@@ -310,7 +589,7 @@ func TestImageFallback_Success(t *testing.T) {
 }
 
 func TestImageFallback_DimensionError(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{
@@ -334,7 +613,7 @@ func TestImageFallback_DimensionError(t *testing.T) {
 }
 
 func TestImageFallback_SizeError(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{
@@ -358,7 +637,7 @@ func TestImageFallback_SizeError(t *testing.T) {
 }
 
 func TestImageFallback_RetryOnOtherErrors(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	candidates := []FallbackCandidate{
@@ -385,7 +664,7 @@ func TestImageFallback_RetryOnOtherErrors(t *testing.T) {
 }
 
 func TestImageFallback_NoCandidates(t *testing.T) {
-	ct := NewCooldownTracker()
+	ct := NewCooldownTracker(0)
 	fc := NewFallbackChain(ct)
 
 	_, err := fc.ExecuteImage(context.Background(), nil, successRun("ok"))
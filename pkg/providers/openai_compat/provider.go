@@ -31,6 +31,7 @@ type Provider struct {
 	apiKey         string
 	apiBase        string
 	maxTokensField string // Field name for max tokens (e.g., "max_completion_tokens" for o1/glm models)
+	extraBody      map[string]any
 	httpClient     *http.Client
 }
 
@@ -52,6 +53,14 @@ func WithRequestTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithExtraBody merges provider-specific fields into every request body.
+// Fields the provider already sets are never overridden.
+func WithExtraBody(extraBody map[string]any) Option {
+	return func(p *Provider) {
+		p.extraBody = extraBody
+	}
+}
+
 func NewProvider(apiKey, apiBase, proxy string, opts ...Option) *Provider {
 	p := &Provider{
 		apiKey:     apiKey,
@@ -107,7 +116,8 @@ func (p *Provider) Chat(
 	nativeSearch, _ := options["native_search"].(bool)
 	nativeSearch = nativeSearch && isNativeSearchHost(p.apiBase)
 	if len(tools) > 0 || nativeSearch {
-		requestBody["tools"] = buildToolsList(tools, nativeSearch)
+		keepClientSearch, _ := options["keep_client_search"].(bool)
+		requestBody["tools"] = buildToolsList(tools, nativeSearch, keepClientSearch)
 		requestBody["tool_choice"] = "auto"
 	}
 
@@ -124,7 +134,7 @@ func (p *Provider) Chat(
 				fieldName = "max_tokens"
 			}
 		}
-		requestBody[fieldName] = maxTokens
+		requestBody[fieldName] = capMaxTokens(p.apiBase, maxTokens)
 	}
 
 	if temperature, ok := common.AsFloat(options["temperature"]); ok {
@@ -150,6 +160,12 @@ func (p *Provider) Chat(
 		}
 	}
 
+	for key, value := range p.extraBody {
+		if _, exists := requestBody[key]; !exists {
+			requestBody[key] = value
+		}
+	}
+
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -175,7 +191,12 @@ func (p *Provider) Chat(
 		return nil, common.HandleErrorResponse(resp, p.apiBase)
 	}
 
-	return common.ReadAndParseResponse(resp, p.apiBase)
+	out, err := common.ReadAndParseResponse(resp, p.apiBase)
+	if err != nil {
+		return nil, err
+	}
+	normalizeResponse(out)
+	return out, nil
 }
 
 func normalizeModel(model, apiBase string) string {
@@ -191,17 +212,20 @@ func normalizeModel(model, apiBase string) string {
 	prefix := strings.ToLower(before)
 	switch prefix {
 	case "litellm", "moonshot", "nvidia", "groq", "ollama", "deepseek", "google",
-		"openrouter", "zhipu", "mistral", "vivgrid", "minimax", "novita":
+		"openrouter", "zhipu", "mistral", "vivgrid", "minimax", "novita",
+		"together", "fireworks":
 		return after
 	default:
 		return model
 	}
 }
 
-func buildToolsList(tools []ToolDefinition, nativeSearch bool) []any {
+// buildToolsList appends web_search_preview when nativeSearch is set and,
+// unless keepClientSearch is set, drops the client-side web_search tool.
+func buildToolsList(tools []ToolDefinition, nativeSearch, keepClientSearch bool) []any {
 	result := make([]any, 0, len(tools)+1)
 	for _, t := range tools {
-		if nativeSearch && strings.EqualFold(t.Function.Name, "web_search") {
+		if nativeSearch && !keepClientSearch && strings.EqualFold(t.Function.Name, "web_search") {
 			continue
 		}
 		result = append(result, t)
@@ -61,6 +61,54 @@ func TestProviderChat_UsesMaxCompletionTokensForGLM(t *testing.T) {
 	}
 }
 
+func TestProviderChat_MergesExtraBody(t *testing.T) {
+	var requestBody map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := map[string]any{
+			"choices": []map[string]any{
+				{
+					"message":       map[string]any{"content": "ok"},
+					"finish_reason": "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	p := NewProvider("key", server.URL, "", WithExtraBody(map[string]any{
+		"reasoning_effort": "high",
+		"model":            "other-model",
+		"temperature":      0.1,
+	}))
+	_, err := p.Chat(
+		t.Context(),
+		[]Message{{Role: "user", Content: "hi"}},
+		nil,
+		"o3",
+		map[string]any{"temperature": 0.7},
+	)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if got := requestBody["reasoning_effort"]; got != "high" {
+		t.Errorf("reasoning_effort = %v, want %q", got, "high")
+	}
+	if got := requestBody["model"]; got != "o3" {
+		t.Errorf("model = %v, want extra_body not to override it", got)
+	}
+	if got := requestBody["temperature"]; got != 0.7 {
+		t.Errorf("temperature = %v, want extra_body not to override it", got)
+	}
+}
+
 func TestProviderChat_ParsesToolCalls(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := map[string]any{
@@ -499,6 +547,16 @@ func TestProviderChat_StripsGroqOllamaDeepseekVivgridNovitaPrefixes(t *testing.T
 			input:     "novita/minimax/minimax-m2.5",
 			wantModel: "minimax/minimax-m2.5",
 		},
+		{
+			name:      "strips together prefix",
+			input:     "together/meta-llama/Llama-3.3-70B-Instruct-Turbo",
+			wantModel: "meta-llama/Llama-3.3-70B-Instruct-Turbo",
+		},
+		{
+			name:      "strips fireworks prefix",
+			input:     "fireworks/accounts/fireworks/models/llama-v3p1-70b-instruct",
+			wantModel: "accounts/fireworks/models/llama-v3p1-70b-instruct",
+		},
 	}
 
 	for _, tt := range tests {
@@ -849,7 +907,7 @@ func TestBuildToolsList_NativeSearchAddsWebSearchPreview(t *testing.T) {
 	tools := []ToolDefinition{
 		{Type: "function", Function: ToolFunctionDefinition{Name: "read_file", Description: "read"}},
 	}
-	result := buildToolsList(tools, true)
+	result := buildToolsList(tools, true, false)
 	if len(result) != 2 {
 		t.Fatalf("len(result) = %d, want 2", len(result))
 	}
@@ -867,7 +925,7 @@ func TestBuildToolsList_NativeSearchFiltersClientWebSearch(t *testing.T) {
 		{Type: "function", Function: ToolFunctionDefinition{Name: "web_search", Description: "search"}},
 		{Type: "function", Function: ToolFunctionDefinition{Name: "read_file", Description: "read"}},
 	}
-	result := buildToolsList(tools, true)
+	result := buildToolsList(tools, true, false)
 	for _, entry := range result {
 		if td, ok := entry.(ToolDefinition); ok && strings.EqualFold(td.Function.Name, "web_search") {
 			t.Fatal("client-side web_search should be filtered out when native search is enabled")
@@ -883,12 +941,25 @@ func TestBuildToolsList_NoNativeSearchPassesThrough(t *testing.T) {
 		{Type: "function", Function: ToolFunctionDefinition{Name: "web_search", Description: "search"}},
 		{Type: "function", Function: ToolFunctionDefinition{Name: "read_file", Description: "read"}},
 	}
-	result := buildToolsList(tools, false)
+	result := buildToolsList(tools, false, false)
 	if len(result) != 2 {
 		t.Fatalf("len(result) = %d, want 2", len(result))
 	}
 }
 
+func TestBuildToolsList_KeepClientSearch(t *testing.T) {
+	tools := []ToolDefinition{
+		{Type: "function", Function: ToolFunctionDefinition{Name: "web_search", Description: "search"}},
+	}
+	result := buildToolsList(tools, true, true)
+	if len(result) != 2 { // web_search + web_search_preview
+		t.Fatalf("len(result) = %d, want 2 (web_search + web_search_preview)", len(result))
+	}
+	if td, ok := result[0].(ToolDefinition); !ok || td.Function.Name != "web_search" {
+		t.Fatalf("result[0] = %#v, want client-side web_search", result[0])
+	}
+}
+
 func TestIsNativeSearchHost(t *testing.T) {
 	tests := []struct {
 		apiBase string
@@ -1089,3 +1160,83 @@ func TestSerializeMessages_StripsSystemParts(t *testing.T) {
 		t.Fatal("system_parts should not appear in serialized output")
 	}
 }
+
+func TestProviderChat_NormalizesTogetherStyleResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"choices": [{
+				"message": {
+					"content": "",
+					"tool_calls": [{
+						"id": "call_1",
+						"type": "function",
+						"function": {"name": "get_weather", "arguments": "\"{\\\"city\\\":\\\"SF\\\"}\""}
+					}]
+				},
+				"finish_reason": "eos"
+			}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 3}
+		}`))
+	}))
+	defer server.Close()
+
+	p := NewProvider("key", server.URL, "")
+	out, err := p.Chat(t.Context(), []Message{{Role: "user", Content: "hi"}}, nil, "together/m", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if out.FinishReason != "tool_calls" {
+		t.Errorf("FinishReason = %q, want tool_calls", out.FinishReason)
+	}
+	if len(out.ToolCalls) != 1 || out.ToolCalls[0].Arguments["city"] != "SF" {
+		t.Errorf("ToolCalls = %+v, want get_weather with city=SF", out.ToolCalls)
+	}
+	if out.Usage == nil || out.Usage.TotalTokens != 15 {
+		t.Errorf("Usage = %+v, want TotalTokens 15", out.Usage)
+	}
+}
+
+func TestNormalizeResponse_EOSWithoutToolCalls(t *testing.T) {
+	resp := &LLMResponse{Content: "hi", FinishReason: "eos"}
+	normalizeResponse(resp)
+	if resp.FinishReason != "stop" {
+		t.Errorf("FinishReason = %q, want stop", resp.FinishReason)
+	}
+}
+
+func TestCapMaxTokens(t *testing.T) {
+	tests := []struct {
+		apiBase string
+		in      int
+		want    int
+	}{
+		{"https://api.fireworks.ai/inference/v1", 8192, 4096},
+		{"https://api.fireworks.ai/inference/v1", 2048, 2048},
+		{"https://api.together.xyz/v1", 8192, 8192},
+		{"https://api.openai.com/v1", 8192, 8192},
+	}
+	for _, tt := range tests {
+		if got := capMaxTokens(tt.apiBase, tt.in); got != tt.want {
+			t.Errorf("capMaxTokens(%q, %d) = %d, want %d", tt.apiBase, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestProviderChat_CapturesRequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req-42")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	p := NewProvider("key", server.URL, "")
+	out, err := p.Chat(t.Context(), []Message{{Role: "user", Content: "hi"}}, nil, "gpt-4o", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if out.RequestID != "req-42" {
+		t.Errorf("RequestID = %q, want req-42", out.RequestID)
+	}
+}
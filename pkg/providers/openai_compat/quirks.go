@@ -0,0 +1,54 @@
+package openai_compat
+
+import (
+	"net/url"
+	"strings"
+)
+
+// fireworksMaxTokens is the largest max_tokens Fireworks AI accepts on a
+// non-streaming request; larger values are rejected with a 400.
+const fireworksMaxTokens = 4096
+
+// hostOf returns the lower-cased host name of apiBase, or "".
+func hostOf(apiBase string) string {
+	u, err := url.Parse(apiBase)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func isFireworksHost(apiBase string) bool {
+	host := hostOf(apiBase)
+	return host == "api.fireworks.ai" || strings.HasSuffix(host, ".fireworks.ai")
+}
+
+// capMaxTokens clamps maxTokens to what the host accepts for the
+// non-streaming requests this provider makes.
+func capMaxTokens(apiBase string, maxTokens int) int {
+	if isFireworksHost(apiBase) && maxTokens > fireworksMaxTokens {
+		return fireworksMaxTokens
+	}
+	return maxTokens
+}
+
+// normalizeResponse smooths over response differences between
+// OpenAI-compatible hosts so callers see OpenAI semantics:
+//   - Together AI reports finish_reason "eos" where OpenAI uses "stop".
+//   - Some hosts omit total_tokens from usage.
+//   - Some hosts report "stop" even when the message carries tool calls.
+func normalizeResponse(resp *LLMResponse) {
+	if resp == nil {
+		return
+	}
+	switch resp.FinishReason {
+	case "eos", "eos_token":
+		resp.FinishReason = "stop"
+	}
+	if len(resp.ToolCalls) > 0 && resp.FinishReason == "stop" {
+		resp.FinishReason = "tool_calls"
+	}
+	if u := resp.Usage; u != nil && u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+}
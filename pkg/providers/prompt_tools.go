@@ -0,0 +1,345 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PromptToolProvider lets models without native function calling use tools.
+// Instead of sending tool definitions in the request, it describes them in
+// the system prompt and asks the model for ReAct-style blocks:
+//
+//	Action: read_file
+//	Args: {"path": "notes.md"}
+//
+// Those blocks are parsed back into ToolCalls, so the agent loop handles them
+// like native calls. Earlier tool calls and results in the history are
+// rewritten as plain text, since the model cannot read tool-role messages.
+//
+// The optional provider interfaces (StatefulProvider, ThinkingCapable,
+// NativeSearchCapable and StreamingProvider) are forwarded to inner.
+type PromptToolProvider struct {
+	inner LLMProvider
+}
+
+// NewPromptToolProvider wraps inner with the prompt-based tool protocol. The
+// result implements StreamingProvider exactly when inner does.
+func NewPromptToolProvider(inner LLMProvider) LLMProvider {
+	p := &PromptToolProvider{inner: inner}
+	if streamer, ok := inner.(StreamingProvider); ok {
+		return &promptToolStreamingProvider{PromptToolProvider: p, streamer: streamer}
+	}
+	return p
+}
+
+func (p *PromptToolProvider) Chat(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	if len(tools) == 0 {
+		return p.inner.Chat(ctx, messages, nil, model, options)
+	}
+
+	resp, err := p.inner.Chat(ctx, promptToolMessages(messages, tools), nil, model, options)
+	if err != nil {
+		return resp, err
+	}
+	applyPromptToolCalls(resp, tools)
+	return resp, nil
+}
+
+func (p *PromptToolProvider) GetDefaultModel() string {
+	return p.inner.GetDefaultModel()
+}
+
+// Close closes inner when it holds resources.
+func (p *PromptToolProvider) Close() {
+	if stateful, ok := p.inner.(StatefulProvider); ok {
+		stateful.Close()
+	}
+}
+
+func (p *PromptToolProvider) SupportsThinking() bool {
+	tc, ok := p.inner.(ThinkingCapable)
+	return ok && tc.SupportsThinking()
+}
+
+func (p *PromptToolProvider) SupportsNativeSearch() bool {
+	ns, ok := p.inner.(NativeSearchCapable)
+	return ok && ns.SupportsNativeSearch()
+}
+
+// promptToolStreamingProvider is a PromptToolProvider over a streaming inner
+// provider. The final response of a stream is parsed like a Chat reply.
+type promptToolStreamingProvider struct {
+	*PromptToolProvider
+	streamer StreamingProvider
+}
+
+func (p *promptToolStreamingProvider) ChatStream(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (<-chan StreamDelta, error) {
+	if len(tools) == 0 {
+		return p.streamer.ChatStream(ctx, messages, nil, model, options)
+	}
+
+	in, err := p.streamer.ChatStream(ctx, promptToolMessages(messages, tools), nil, model, options)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan StreamDelta)
+	go func() {
+		defer close(out)
+		for delta := range in {
+			if delta.Response != nil {
+				applyPromptToolCalls(delta.Response, tools)
+			}
+			select {
+			case out <- delta:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// applyPromptToolCalls moves the Action/Args blocks of resp.Content into
+// resp.ToolCalls. A response that already has tool calls is left as is.
+func applyPromptToolCalls(resp *LLMResponse, tools []ToolDefinition) {
+	if resp == nil || len(resp.ToolCalls) > 0 {
+		return
+	}
+	calls, content := parsePromptToolCalls(resp.Content, tools)
+	if len(calls) > 0 {
+		resp.ToolCalls = NormalizeToolCalls(calls)
+		if resp.FinishReason == "" || resp.FinishReason == "stop" {
+			resp.FinishReason = "tool_calls"
+		}
+	}
+	resp.Content = content
+}
+
+// buildPromptToolsSection describes the tools and the Action/Args format for
+// the system prompt.
+func buildPromptToolsSection(tools []ToolDefinition) string {
+	var sb strings.Builder
+
+	sb.WriteString("## Tools\n\n")
+	sb.WriteString("You can call the tools listed below. To call one, write exactly:\n\n")
+	sb.WriteString("Action: <tool name>\n")
+	sb.WriteString("Args: <JSON object with the tool's arguments>\n\n")
+	sb.WriteString("Then stop. The result comes back in the next message, starting with \"Observation\". ")
+	sb.WriteString("To call several tools at once, repeat the Action and Args lines for each. ")
+	sb.WriteString("Never write an Observation yourself. ")
+	sb.WriteString("When you no longer need tools, reply normally without an Action line.\n\n")
+	sb.WriteString("### Tool Definitions\n\n")
+
+	for _, tool := range tools {
+		if tool.Type != "function" {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("#### %s\n", tool.Function.Name))
+		if tool.Function.Description != "" {
+			sb.WriteString(fmt.Sprintf("Description: %s\n", tool.Function.Description))
+		}
+		if len(tool.Function.Parameters) > 0 {
+			paramsJSON, _ := json.Marshal(tool.Function.Parameters)
+			sb.WriteString(fmt.Sprintf("Parameters: %s\n", string(paramsJSON)))
+		}
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// promptToolMessages returns a copy of messages that a model without native
+// tool calling can follow: the tools section is appended to the system
+// message, assistant tool calls become Action/Args text, and tool results
+// become user "Observation" messages. Consecutive results are merged into one
+// message so user and assistant turns keep alternating.
+func promptToolMessages(messages []Message, tools []ToolDefinition) []Message {
+	section := buildPromptToolsSection(tools)
+	out := make([]Message, 0, len(messages)+1)
+	callNames := make(map[string]string)
+	hasSystem := false
+
+	for _, msg := range messages {
+		switch {
+		case msg.Role == "system" && !hasSystem:
+			hasSystem = true
+			msg.Content = strings.TrimSpace(msg.Content + "\n\n" + section)
+			if len(msg.SystemParts) > 0 {
+				parts := make([]ContentBlock, len(msg.SystemParts), len(msg.SystemParts)+1)
+				copy(parts, msg.SystemParts)
+				msg.SystemParts = append(parts, ContentBlock{Type: "text", Text: section})
+			}
+			out = append(out, msg)
+
+		case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+			var sb strings.Builder
+			sb.WriteString(strings.TrimSpace(msg.Content))
+			for _, tc := range msg.ToolCalls {
+				tc = NormalizeToolCall(tc)
+				callNames[tc.ID] = tc.Name
+				argsJSON, _ := json.Marshal(tc.Arguments)
+				if sb.Len() > 0 {
+					sb.WriteString("\n\n")
+				}
+				sb.WriteString(fmt.Sprintf("Action: %s\nArgs: %s", tc.Name, string(argsJSON)))
+			}
+			msg.Content = sb.String()
+			msg.ToolCalls = nil
+			out = append(out, msg)
+
+		case msg.Role == "tool":
+			observation := "Observation"
+			if name := callNames[msg.ToolCallID]; name != "" {
+				observation += " (" + name + ")"
+			}
+			observation += ":\n" + msg.Content
+
+			if n := len(out); n > 0 && out[n-1].Role == "user" && strings.HasPrefix(out[n-1].Content, "Observation") {
+				out[n-1].Content += "\n\n" + observation
+				out[n-1].Media = append(out[n-1].Media, msg.Media...)
+				continue
+			}
+			out = append(out, Message{Role: "user", Content: observation, Media: msg.Media})
+
+		default:
+			out = append(out, msg)
+		}
+	}
+
+	if !hasSystem {
+		out = append([]Message{{Role: "system", Content: section}}, out...)
+	}
+	return out
+}
+
+var (
+	// promptActionRe matches an "Action: name" line, tolerating markdown
+	// emphasis and backticks around the label and the name.
+	promptActionRe = regexp.MustCompile("(?im)^[ \\t>*_`]*action[ \\t*_]*:[ \\t*_`\"']*([A-Za-z0-9_.\\-]+)")
+	// promptArgsRe matches the label that introduces the arguments object.
+	promptArgsRe = regexp.MustCompile("(?i)(?:args|arguments|action[ \\t]+input|input)[ \\t*_]*:")
+	// promptObservationRe matches an observation the model wrote on its own.
+	promptObservationRe = regexp.MustCompile("(?im)^[ \\t*_]*observation[^\\n:]*:")
+	// promptFinalAnswerRe matches the ReAct "Final Answer:" label.
+	promptFinalAnswerRe = regexp.MustCompile("(?im)^[ \\t*_]*final[ \\t]+answer[ \\t*_]*:[ \\t*_]*")
+	// promptOpenFenceRe matches a code fence left open before the first action.
+	promptOpenFenceRe = regexp.MustCompile("```[A-Za-z]*$")
+)
+
+// parsePromptToolCalls extracts Action/Args blocks from a model reply. It
+// returns the calls in order and the reply text with the blocks removed.
+// Anything after the first invented "Observation" is dropped, as it was not
+// produced by a real tool. Missing or malformed arguments yield an empty
+// argument map, so the tool reports what is missing instead of the call
+// being lost. Tool names are matched to tools case-insensitively. A reply
+// without actions is returned as-is, minus a leading "Final Answer:" label.
+func parsePromptToolCalls(text string, tools []ToolDefinition) ([]ToolCall, string) {
+	actions := promptActionRe.FindAllStringSubmatchIndex(text, -1)
+	if len(actions) == 0 {
+		if loc := promptFinalAnswerRe.FindStringIndex(text); loc != nil {
+			return nil, strings.TrimSpace(text[loc[1]:])
+		}
+		return nil, text
+	}
+
+	if loc := promptObservationRe.FindStringIndex(text[actions[0][1]:]); loc != nil {
+		text = text[:actions[0][1]+loc[0]]
+		actions = promptActionRe.FindAllStringSubmatchIndex(text, -1)
+	}
+
+	calls := make([]ToolCall, 0, len(actions))
+	for i, loc := range actions {
+		name := strings.TrimRight(text[loc[2]:loc[3]], ".")
+		for _, tool := range tools {
+			if strings.EqualFold(tool.Function.Name, name) {
+				name = tool.Function.Name
+				break
+			}
+		}
+		end := len(text)
+		if i+1 < len(actions) {
+			end = actions[i+1][0]
+		}
+		calls = append(calls, ToolCall{
+			Type:      "function",
+			Name:      name,
+			Arguments: parsePromptToolArgs(text[loc[1]:end]),
+		})
+	}
+
+	content := strings.TrimSpace(text[:actions[0][0]])
+	return calls, strings.TrimSpace(promptOpenFenceRe.ReplaceAllString(content, ""))
+}
+
+// parsePromptToolArgs decodes the JSON object that follows an Action line.
+func parsePromptToolArgs(segment string) map[string]any {
+	start := 0
+	if loc := promptArgsRe.FindStringIndex(segment); loc != nil {
+		start = loc[1]
+	}
+	open := strings.IndexByte(segment[start:], '{')
+	if open == -1 {
+		return map[string]any{}
+	}
+	open += start
+
+	end := findJSONObjectEnd(segment, open)
+	if end == -1 {
+		return map[string]any{}
+	}
+
+	var args map[string]any
+	if err := json.Unmarshal([]byte(segment[open:end]), &args); err != nil || args == nil {
+		return map[string]any{}
+	}
+	return args
+}
+
+// findJSONObjectEnd returns the index just past the brace closing the object
+// that opens at start, or -1 if it is never closed. Unlike findMatchingBrace
+// it skips braces inside JSON strings.
+func findJSONObjectEnd(text string, start int) int {
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
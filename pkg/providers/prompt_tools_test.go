@@ -0,0 +1,288 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var promptTestTools = []ToolDefinition{
+	{
+		Type: "function",
+		Function: ToolFunctionDefinition{
+			Name:        "read_file",
+			Description: "Read a file",
+			Parameters: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"path": map[string]any{"type": "string"}},
+			},
+		},
+	},
+	{
+		Type:     "function",
+		Function: ToolFunctionDefinition{Name: "list_dir", Description: "List a directory"},
+	},
+}
+
+// scriptedProvider replies with the given content and records what it was sent.
+type scriptedProvider struct {
+	reply    string
+	messages []Message
+	tools    []ToolDefinition
+}
+
+func (p *scriptedProvider) Chat(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (*LLMResponse, error) {
+	p.messages = messages
+	p.tools = tools
+	return &LLMResponse{Content: p.reply, FinishReason: "stop"}, nil
+}
+
+func (p *scriptedProvider) GetDefaultModel() string { return "test" }
+
+func TestParsePromptToolCalls(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantNames   []string
+		wantArgs    []map[string]any
+		wantContent string
+	}{
+		{
+			name:        "single action",
+			text:        "Thought: I should read it.\nAction: read_file\nArgs: {\"path\": \"notes.md\"}",
+			wantNames:   []string{"read_file"},
+			wantArgs:    []map[string]any{{"path": "notes.md"}},
+			wantContent: "Thought: I should read it.",
+		},
+		{
+			name:      "multiline args in code fence",
+			text:      "Action: read_file\nArgs:\n```json\n{\n  \"path\": \"a.txt\"\n}\n```",
+			wantNames: []string{"read_file"},
+			wantArgs:  []map[string]any{{"path": "a.txt"}},
+		},
+		{
+			name:      "braces inside strings",
+			text:      "Action: read_file\nArgs: {\"path\": \"}{weird}.txt\"}",
+			wantNames: []string{"read_file"},
+			wantArgs:  []map[string]any{{"path": "}{weird}.txt"}},
+		},
+		{
+			name:      "several actions",
+			text:      "Action: list_dir\nArgs: {\"path\": \".\"}\n\nAction: read_file\nArgs: {\"path\": \"b\"}",
+			wantNames: []string{"list_dir", "read_file"},
+			wantArgs:  []map[string]any{{"path": "."}, {"path": "b"}},
+		},
+		{
+			name:      "markdown emphasis and case",
+			text:      "**Action:** `Read_File`\n**Action Input:** {\"path\": \"c\"}",
+			wantNames: []string{"read_file"},
+			wantArgs:  []map[string]any{{"path": "c"}},
+		},
+		{
+			name:      "missing args",
+			text:      "Action: list_dir",
+			wantNames: []string{"list_dir"},
+			wantArgs:  []map[string]any{{}},
+		},
+		{
+			name:      "invalid json args",
+			text:      "Action: read_file\nArgs: {path: notes.md}",
+			wantNames: []string{"read_file"},
+			wantArgs:  []map[string]any{{}},
+		},
+		{
+			name:      "invented observation is dropped",
+			text:      "Action: read_file\nArgs: {\"path\": \"d\"}\nObservation: hello\nAction: list_dir\nArgs: {}",
+			wantNames: []string{"read_file"},
+			wantArgs:  []map[string]any{{"path": "d"}},
+		},
+		{
+			name:        "plain reply",
+			text:        "The answer is 42.",
+			wantContent: "The answer is 42.",
+		},
+		{
+			name:        "final answer label",
+			text:        "Thought: done.\nFinal Answer: The answer is 42.",
+			wantContent: "The answer is 42.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, content := parsePromptToolCalls(tt.text, promptTestTools)
+			if len(calls) != len(tt.wantNames) {
+				t.Fatalf("got %d calls, want %d: %+v", len(calls), len(tt.wantNames), calls)
+			}
+			for i, call := range calls {
+				if call.Name != tt.wantNames[i] {
+					t.Errorf("call %d name = %q, want %q", i, call.Name, tt.wantNames[i])
+				}
+				if len(call.Arguments) != len(tt.wantArgs[i]) {
+					t.Errorf("call %d args = %v, want %v", i, call.Arguments, tt.wantArgs[i])
+				}
+				for k, v := range tt.wantArgs[i] {
+					if call.Arguments[k] != v {
+						t.Errorf("call %d args[%q] = %v, want %v", i, k, call.Arguments[k], v)
+					}
+				}
+			}
+			if content != tt.wantContent {
+				t.Errorf("content = %q, want %q", content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestPromptToolMessages_RewritesToolHistory(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: "What is in a and b?"},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "call_1", Name: "read_file", Arguments: map[string]any{"path": "a"}},
+			{ID: "call_2", Name: "read_file", Arguments: map[string]any{"path": "b"}},
+		}},
+		{Role: "tool", ToolCallID: "call_1", Content: "alpha"},
+		{Role: "tool", ToolCallID: "call_2", Content: "beta"},
+	}
+
+	got := promptToolMessages(messages, promptTestTools)
+
+	if len(got) != 4 {
+		t.Fatalf("got %d messages, want 4: %+v", len(got), got)
+	}
+	if !strings.HasPrefix(got[0].Content, "You are helpful.") || !strings.Contains(got[0].Content, "#### read_file") {
+		t.Errorf("system message missing tools section: %q", got[0].Content)
+	}
+	if len(got[2].ToolCalls) != 0 || !strings.Contains(got[2].Content, "Action: read_file\nArgs: {\"path\":\"b\"}") {
+		t.Errorf("assistant message not rewritten: %+v", got[2])
+	}
+	if got[3].Role != "user" ||
+		!strings.Contains(got[3].Content, "Observation (read_file):\nalpha") ||
+		!strings.Contains(got[3].Content, "beta") {
+		t.Errorf("tool results not merged into one observation: %+v", got[3])
+	}
+	if messages[0].Content != "You are helpful." || len(messages[2].ToolCalls) != 2 {
+		t.Error("input messages were modified")
+	}
+}
+
+func TestPromptToolProvider_Chat(t *testing.T) {
+	inner := &scriptedProvider{reply: "Let me look.\nAction: read_file\nArgs: {\"path\": \"notes.md\"}"}
+	p := NewPromptToolProvider(inner)
+
+	resp, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, promptTestTools, "m", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if inner.tools != nil {
+		t.Errorf("tools sent natively: %v", inner.tools)
+	}
+	if inner.messages[0].Role != "system" || !strings.Contains(inner.messages[0].Content, "Action: <tool name>") {
+		t.Errorf("tools prompt not injected: %+v", inner.messages[0])
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "read_file" || resp.ToolCalls[0].ID == "" {
+		t.Fatalf("ToolCalls = %+v", resp.ToolCalls)
+	}
+	if resp.ToolCalls[0].Function == nil || resp.ToolCalls[0].Function.Arguments != `{"path":"notes.md"}` {
+		t.Errorf("Function = %+v", resp.ToolCalls[0].Function)
+	}
+	if resp.Content != "Let me look." || resp.FinishReason != "tool_calls" {
+		t.Errorf("Content = %q, FinishReason = %q", resp.Content, resp.FinishReason)
+	}
+}
+
+func TestPromptToolProvider_NoToolsPassesThrough(t *testing.T) {
+	inner := &scriptedProvider{reply: "Action: read_file"}
+	p := NewPromptToolProvider(inner)
+
+	messages := []Message{{Role: "user", Content: "summarize"}}
+	resp, err := p.Chat(context.Background(), messages, nil, "m", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(inner.messages) != 1 || len(resp.ToolCalls) != 0 || resp.Content != "Action: read_file" {
+		t.Errorf("request without tools was altered: sent %+v, got %+v", inner.messages, resp)
+	}
+}
+
+// capableProvider is a scriptedProvider with every optional interface.
+type capableProvider struct {
+	scriptedProvider
+	closed bool
+}
+
+func (p *capableProvider) Close()                     { p.closed = true }
+func (p *capableProvider) SupportsThinking() bool     { return true }
+func (p *capableProvider) SupportsNativeSearch() bool { return true }
+
+func (p *capableProvider) ChatStream(
+	ctx context.Context,
+	messages []Message,
+	tools []ToolDefinition,
+	model string,
+	options map[string]any,
+) (<-chan StreamDelta, error) {
+	resp, _ := p.Chat(ctx, messages, tools, model, options)
+	ch := make(chan StreamDelta, 2)
+	ch <- StreamDelta{Reasoning: "thinking"}
+	ch <- StreamDelta{Response: resp}
+	close(ch)
+	return ch, nil
+}
+
+func TestPromptToolProvider_ForwardsOptionalInterfaces(t *testing.T) {
+	inner := &capableProvider{scriptedProvider: scriptedProvider{reply: "Action: read_file\nArgs: {\"path\": \"a\"}"}}
+	p := NewPromptToolProvider(inner)
+
+	if tc, ok := p.(ThinkingCapable); !ok || !tc.SupportsThinking() {
+		t.Error("thinking support not forwarded")
+	}
+	if ns, ok := p.(NativeSearchCapable); !ok || !ns.SupportsNativeSearch() {
+		t.Error("native search support not forwarded")
+	}
+	stateful, ok := p.(StatefulProvider)
+	if !ok {
+		t.Fatal("wrapper is not a StatefulProvider")
+	}
+	stateful.Close()
+	if !inner.closed {
+		t.Error("Close not forwarded")
+	}
+
+	streamer, ok := p.(StreamingProvider)
+	if !ok {
+		t.Fatal("wrapper of a streaming provider does not stream")
+	}
+	deltas, err := streamer.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}},
+		promptTestTools, "m", nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	var final *LLMResponse
+	for delta := range deltas {
+		if delta.Response != nil {
+			final = delta.Response
+		}
+	}
+	if inner.tools != nil || !strings.Contains(inner.messages[0].Content, "Action: <tool name>") {
+		t.Errorf("stream request did not use the prompt protocol: tools=%v", inner.tools)
+	}
+	if final == nil || len(final.ToolCalls) != 1 || final.ToolCalls[0].Name != "read_file" {
+		t.Errorf("streamed response tool calls = %+v", final)
+	}
+
+	plain := NewPromptToolProvider(&scriptedProvider{})
+	if _, ok := plain.(StreamingProvider); ok {
+		t.Error("wrapper of a non-streaming provider claims to stream")
+	}
+	if tc, ok := plain.(ThinkingCapable); ok && tc.SupportsThinking() {
+		t.Error("wrapper of a provider without thinking reports thinking support")
+	}
+}
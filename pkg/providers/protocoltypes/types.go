@@ -32,6 +32,9 @@ type LLMResponse struct {
 	Usage            *UsageInfo        `json:"usage,omitempty"`
 	Reasoning        string            `json:"reasoning"`
 	ReasoningDetails []ReasoningDetail `json:"reasoning_details"`
+	// RequestID is the provider's ID for the request (from headers such as
+	// x-request-id), for correlating with provider-side logs.
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type ReasoningDetail struct {
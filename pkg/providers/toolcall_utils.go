@@ -6,9 +6,12 @@
 package providers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // buildCLIToolsPrompt creates the tool definitions section for a CLI provider system prompt.
@@ -88,3 +91,40 @@ func NormalizeToolCall(tc ToolCall) ToolCall {
 
 	return normalized
 }
+
+// NormalizeToolCalls normalizes every call with NormalizeToolCall and makes
+// sure each one has a non-empty ID that is unique within the batch. Some
+// models emit empty or repeated IDs, which breaks tool_call_id matching of
+// the result messages; such calls get a synthesized ID instead. Callers must
+// use the returned IDs for both the assistant message and the tool results.
+func NormalizeToolCalls(calls []ToolCall) []ToolCall {
+	normalized := make([]ToolCall, 0, len(calls))
+	seen := make(map[string]bool, len(calls))
+	for _, tc := range calls {
+		n := NormalizeToolCall(tc)
+		n.ID = strings.TrimSpace(n.ID)
+		if n.ID == "" || seen[n.ID] {
+			n.ID = newToolCallID(seen)
+		}
+		seen[n.ID] = true
+		normalized = append(normalized, n)
+	}
+	return normalized
+}
+
+// newToolCallID returns a random "call_" ID not present in taken. The charset
+// is restricted to [a-zA-Z0-9_] so every provider accepts it.
+func newToolCallID(taken map[string]bool) string {
+	for {
+		b := make([]byte, 12)
+		var id string
+		if _, err := rand.Read(b); err != nil {
+			id = fmt.Sprintf("call_%d", time.Now().UnixNano())
+		} else {
+			id = "call_" + hex.EncodeToString(b)
+		}
+		if !taken[id] {
+			return id
+		}
+	}
+}
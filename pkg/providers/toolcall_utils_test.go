@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNormalizeToolCalls_FixesEmptyAndDuplicateIDs(t *testing.T) {
+	calls := []ToolCall{
+		{ID: "call_1", Name: "read_file", Arguments: map[string]any{"path": "a"}},
+		{ID: "", Name: "read_file", Arguments: map[string]any{"path": "b"}},
+		{ID: "call_1", Name: "list_dir", Arguments: map[string]any{"path": "."}},
+		{ID: "  ", Function: &FunctionCall{Name: "exec", Arguments: `{"command":"ls"}`}},
+	}
+
+	got := NormalizeToolCalls(calls)
+
+	if len(got) != len(calls) {
+		t.Fatalf("len = %d, want %d", len(got), len(calls))
+	}
+	if got[0].ID != "call_1" {
+		t.Errorf("first ID = %q, want the original call_1 kept", got[0].ID)
+	}
+
+	validID := regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	seen := make(map[string]bool)
+	for i, tc := range got {
+		if tc.ID == "" {
+			t.Errorf("call %d has an empty ID", i)
+		}
+		if !validID.MatchString(tc.ID) {
+			t.Errorf("call %d ID %q has unsupported characters", i, tc.ID)
+		}
+		if seen[tc.ID] {
+			t.Errorf("call %d reuses ID %q", i, tc.ID)
+		}
+		seen[tc.ID] = true
+	}
+
+	// Normalization of the individual calls still applies.
+	if got[3].Name != "exec" || got[3].Arguments["command"] != "ls" {
+		t.Errorf("call 3 not normalized: %+v", got[3])
+	}
+	if got[2].Name != "list_dir" {
+		t.Errorf("call order changed: %+v", got[2])
+	}
+}
+
+func TestNormalizeToolCalls_Empty(t *testing.T) {
+	if got := NormalizeToolCalls(nil); len(got) != 0 {
+		t.Errorf("expected no calls, got %d", len(got))
+	}
+}
@@ -53,6 +53,30 @@ type NativeSearchCapable interface {
 	SupportsNativeSearch() bool
 }
 
+// StreamDelta is one increment of a streamed LLM response. Reasoning and
+// Content hold the text generated since the previous delta. The final delta
+// carries the complete Response, or Err if the request failed.
+type StreamDelta struct {
+	Reasoning string
+	Content   string
+	Response  *LLMResponse
+	Err       error
+}
+
+// StreamingProvider is an optional interface for providers that can stream
+// a response while it is generated. The agent loop uses it to forward
+// reasoning to reasoning channels as it arrives. The returned channel is
+// closed after the final delta.
+type StreamingProvider interface {
+	ChatStream(
+		ctx context.Context,
+		messages []Message,
+		tools []ToolDefinition,
+		model string,
+		options map[string]any,
+	) (<-chan StreamDelta, error)
+}
+
 // FailoverReason classifies why an LLM request failed for fallback decisions.
 type FailoverReason string
 
@@ -23,7 +23,8 @@ type ResolvedRoute struct {
 	AccountID      string
 	SessionKey     string
 	MainSessionKey string
-	MatchedBy      string // "binding.peer", "binding.peer.parent", "binding.guild", "binding.team", "binding.account", "binding.channel", "default"
+	MatchedBy      string                   // "binding.peer", "binding.peer.parent", "binding.guild", "binding.team", "binding.account", "binding.channel", "default"
+	Model          *config.AgentModelConfig // model set on the matched binding; nil uses the agent's model
 }
 
 // RouteResolver determines which agent handles a message based on config bindings.
@@ -52,7 +53,13 @@ func (r *RouteResolver) ResolveRoute(input RouteInput) ResolvedRoute {
 
 	bindings := r.filterBindings(channel, accountID)
 
-	choose := func(agentID string, matchedBy string) ResolvedRoute {
+	choose := func(binding *config.AgentBinding, matchedBy string) ResolvedRoute {
+		agentID := r.resolveDefaultAgentID()
+		var model *config.AgentModelConfig
+		if binding != nil {
+			agentID = binding.AgentID
+			model = binding.Model
+		}
 		resolvedAgentID := r.pickAgentID(agentID)
 		sessionKey := strings.ToLower(BuildAgentPeerSessionKey(SessionKeyParams{
 			AgentID:       resolvedAgentID,
@@ -70,13 +77,14 @@ func (r *RouteResolver) ResolveRoute(input RouteInput) ResolvedRoute {
 			SessionKey:     sessionKey,
 			MainSessionKey: mainSessionKey,
 			MatchedBy:      matchedBy,
+			Model:          model,
 		}
 	}
 
 	// Priority 1: Peer binding
 	if peer != nil && strings.TrimSpace(peer.ID) != "" {
 		if match := r.findPeerMatch(bindings, peer); match != nil {
-			return choose(match.AgentID, "binding.peer")
+			return choose(match, "binding.peer")
 		}
 	}
 
@@ -84,7 +92,7 @@ func (r *RouteResolver) ResolveRoute(input RouteInput) ResolvedRoute {
 	parentPeer := input.ParentPeer
 	if parentPeer != nil && strings.TrimSpace(parentPeer.ID) != "" {
 		if match := r.findPeerMatch(bindings, parentPeer); match != nil {
-			return choose(match.AgentID, "binding.peer.parent")
+			return choose(match, "binding.peer.parent")
 		}
 	}
 
@@ -92,7 +100,7 @@ func (r *RouteResolver) ResolveRoute(input RouteInput) ResolvedRoute {
 	guildID := strings.TrimSpace(input.GuildID)
 	if guildID != "" {
 		if match := r.findGuildMatch(bindings, guildID); match != nil {
-			return choose(match.AgentID, "binding.guild")
+			return choose(match, "binding.guild")
 		}
 	}
 
@@ -100,22 +108,22 @@ func (r *RouteResolver) ResolveRoute(input RouteInput) ResolvedRoute {
 	teamID := strings.TrimSpace(input.TeamID)
 	if teamID != "" {
 		if match := r.findTeamMatch(bindings, teamID); match != nil {
-			return choose(match.AgentID, "binding.team")
+			return choose(match, "binding.team")
 		}
 	}
 
 	// Priority 5: Account binding
 	if match := r.findAccountMatch(bindings); match != nil {
-		return choose(match.AgentID, "binding.account")
+		return choose(match, "binding.account")
 	}
 
 	// Priority 6: Channel wildcard binding
 	if match := r.findChannelWildcardMatch(bindings); match != nil {
-		return choose(match.AgentID, "binding.channel")
+		return choose(match, "binding.channel")
 	}
 
 	// Priority 7: Default agent
-	return choose(r.resolveDefaultAgentID(), "default")
+	return choose(nil, "default")
 }
 
 func (r *RouteResolver) filterBindings(channel, accountID string) []config.AgentBinding {
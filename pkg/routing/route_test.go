@@ -295,3 +295,84 @@ func TestResolveRoute_NoDefaultUsesFirst(t *testing.T) {
 		t.Errorf("AgentID = %q, want 'alpha' (first in list)", route.AgentID)
 	}
 }
+
+func TestResolveRoute_BindingModel(t *testing.T) {
+	agents := []config.AgentConfig{
+		{ID: "main", Default: true, Model: &config.AgentModelConfig{Primary: "gpt-4"}},
+	}
+	bindings := []config.AgentBinding{
+		{
+			AgentID: "main",
+			Match:   config.BindingMatch{Channel: "discord", AccountID: "*"},
+			Model:   &config.AgentModelConfig{Primary: "gpt-4o-mini", Fallbacks: []string{"haiku"}},
+		},
+		{
+			AgentID: "main",
+			Match:   config.BindingMatch{Channel: "telegram", AccountID: "*"},
+		},
+	}
+	cfg := testConfig(agents, bindings)
+	r := NewRouteResolver(cfg)
+
+	route := r.ResolveRoute(RouteInput{
+		Channel: "discord",
+		Peer:    &RoutePeer{Kind: "channel", ID: "c1"},
+	})
+	if route.AgentID != "main" {
+		t.Errorf("AgentID = %q, want 'main'", route.AgentID)
+	}
+	if route.Model == nil || route.Model.Primary != "gpt-4o-mini" {
+		t.Errorf("Model = %+v, want the binding's gpt-4o-mini", route.Model)
+	}
+
+	route = r.ResolveRoute(RouteInput{
+		Channel: "telegram",
+		Peer:    &RoutePeer{Kind: "direct", ID: "user1"},
+	})
+	if route.Model != nil {
+		t.Errorf("Model = %+v, want nil for a binding without a model", route.Model)
+	}
+
+	route = r.ResolveRoute(RouteInput{
+		Channel: "slack",
+		Peer:    &RoutePeer{Kind: "direct", ID: "user1"},
+	})
+	if route.MatchedBy != "default" || route.Model != nil {
+		t.Errorf("default route = %+v, want no model", route)
+	}
+}
+
+func TestResolveRoute_IdentityScopeSharesLinkedSession(t *testing.T) {
+	cfg := testConfig(nil, nil)
+	cfg.Session = config.SessionConfig{
+		DMScope: "identity",
+		IdentityLinks: map[string][]string{
+			"alice": {"telegram:111", "discord:222"},
+		},
+	}
+	r := NewRouteResolver(cfg)
+
+	fromTelegram := r.ResolveRoute(RouteInput{
+		Channel: "telegram",
+		Peer:    &RoutePeer{Kind: "direct", ID: "111"},
+	})
+	fromDiscord := r.ResolveRoute(RouteInput{
+		Channel: "discord",
+		Peer:    &RoutePeer{Kind: "direct", ID: "222"},
+	})
+	unlinked := r.ResolveRoute(RouteInput{
+		Channel: "discord",
+		Peer:    &RoutePeer{Kind: "direct", ID: "111"},
+	})
+
+	if fromTelegram.SessionKey != "agent:main:direct:alice" {
+		t.Errorf("telegram SessionKey = %q, want %q", fromTelegram.SessionKey, "agent:main:direct:alice")
+	}
+	if fromDiscord.SessionKey != fromTelegram.SessionKey {
+		t.Errorf("linked IDs got different sessions: %q vs %q", fromDiscord.SessionKey, fromTelegram.SessionKey)
+	}
+	// Same raw ID on another platform is a different person unless linked.
+	if unlinked.SessionKey != "agent:main:direct:discord:111" {
+		t.Errorf("unlinked SessionKey = %q, want %q", unlinked.SessionKey, "agent:main:direct:discord:111")
+	}
+}
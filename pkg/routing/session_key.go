@@ -2,7 +2,10 @@ package routing
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/identity"
 )
 
 // DMScope controls DM session isolation granularity.
@@ -13,6 +16,10 @@ const (
 	DMScopePerPeer               DMScope = "per-peer"
 	DMScopePerChannelPeer        DMScope = "per-channel-peer"
 	DMScopePerAccountChannelPeer DMScope = "per-account-channel-peer"
+	// DMScopeIdentity shares one DM session per person across channels:
+	// peers listed under an identity_links entry collapse to its primary
+	// name, all others are keyed by their canonical "platform:id".
+	DMScopeIdentity DMScope = "identity"
 )
 
 // RoutePeer represents a chat peer with kind and ID.
@@ -63,14 +70,23 @@ func BuildAgentPeerSessionKey(params SessionKeyParams) string {
 		peerID := strings.TrimSpace(peer.ID)
 
 		// Resolve identity links (cross-platform collapse)
+		linked := ""
 		if dmScope != DMScopeMain && peerID != "" {
-			if linked := resolveLinkedPeerID(params.IdentityLinks, params.Channel, peerID); linked != "" {
+			linked = resolveLinkedPeerID(params.IdentityLinks, params.Channel, peerID)
+			if linked != "" {
 				peerID = linked
 			}
 		}
 		peerID = strings.ToLower(peerID)
 
 		switch dmScope {
+		case DMScopeIdentity:
+			if peerID != "" {
+				if linked == "" {
+					peerID = canonicalPeerID(params.Channel, peerID)
+				}
+				return fmt.Sprintf("agent:%s:direct:%s", agentID, peerID)
+			}
 		case DMScopePerAccountChannelPeer:
 			if peerID != "" {
 				channel := normalizeChannel(params.Channel)
@@ -158,9 +174,7 @@ func resolveLinkedPeerID(identityLinks map[string][]string, channel, peerID stri
 	if rawCandidate != "" {
 		candidates[rawCandidate] = true
 	}
-	channel = strings.ToLower(strings.TrimSpace(channel))
-	if channel != "" {
-		scopedCandidate := fmt.Sprintf("%s:%s", channel, strings.ToLower(peerID))
+	if scopedCandidate := identity.BuildCanonicalID(channel, rawCandidate); scopedCandidate != "" {
 		candidates[scopedCandidate] = true
 	}
 
@@ -176,12 +190,20 @@ func resolveLinkedPeerID(identityLinks map[string][]string, channel, peerID stri
 		return ""
 	}
 
-	for canonical, ids := range identityLinks {
+	// Visit primaries in a fixed order so an ID listed under two entries
+	// always resolves to the same session.
+	primaries := make([]string, 0, len(identityLinks))
+	for canonical := range identityLinks {
+		primaries = append(primaries, canonical)
+	}
+	sort.Strings(primaries)
+
+	for _, canonical := range primaries {
 		canonicalName := strings.TrimSpace(canonical)
 		if canonicalName == "" {
 			continue
 		}
-		for _, id := range ids {
+		for _, id := range identityLinks[canonical] {
 			normalized := strings.ToLower(strings.TrimSpace(id))
 			if normalized != "" && candidates[normalized] {
 				return canonicalName
@@ -190,3 +212,15 @@ func resolveLinkedPeerID(identityLinks map[string][]string, channel, peerID stri
 	}
 	return ""
 }
+
+// canonicalPeerID returns peerID as a canonical "platform:id", keeping IDs
+// that a channel already reports in that form.
+func canonicalPeerID(channel, peerID string) string {
+	if platform, _, ok := identity.ParseCanonicalID(peerID); ok && strings.EqualFold(platform, channel) {
+		return peerID
+	}
+	if canonical := identity.BuildCanonicalID(normalizeChannel(channel), peerID); canonical != "" {
+		return canonical
+	}
+	return peerID
+}
@@ -115,6 +115,49 @@ func TestBuildAgentPeerSessionKey_IdentityLink(t *testing.T) {
 	}
 }
 
+func TestBuildAgentPeerSessionKey_DMScopeIdentity(t *testing.T) {
+	links := map[string][]string{
+		"john": {"telegram:user123", "discord:456"},
+	}
+	tests := []struct {
+		name    string
+		channel string
+		peerID  string
+		want    string
+	}{
+		{"linked telegram", "telegram", "user123", "agent:main:direct:john"},
+		{"linked discord", "discord", "456", "agent:main:direct:john"},
+		{"unlinked", "discord", "789", "agent:main:direct:discord:789"},
+		{"already canonical", "discord", "discord:789", "agent:main:direct:discord:789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildAgentPeerSessionKey(SessionKeyParams{
+				AgentID:       "main",
+				Channel:       tt.channel,
+				Peer:          &RoutePeer{Kind: "direct", ID: tt.peerID},
+				DMScope:       DMScopeIdentity,
+				IdentityLinks: links,
+			})
+			if got != tt.want {
+				t.Errorf("DMScopeIdentity = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLinkedPeerID_DeterministicPrimary(t *testing.T) {
+	links := map[string][]string{
+		"zoe":  {"telegram:123"},
+		"anna": {"telegram:123"},
+	}
+	for range 20 {
+		if got := resolveLinkedPeerID(links, "telegram", "123"); got != "anna" {
+			t.Fatalf("resolveLinkedPeerID = %q, want %q", got, "anna")
+		}
+	}
+}
+
 func TestResolveLinkedPeerID_CanonicalPeerID(t *testing.T) {
 	// When peerID is already in canonical "platform:id" format,
 	// it should match identity_links that use the bare ID.
@@ -0,0 +1,62 @@
+// Package semaphore provides the counting semaphore shared by the LLM call
+// limit and the subagent limit.
+package semaphore
+
+import "context"
+
+// Semaphore bounds how many holders run at once across every caller that
+// shares it. A nil *Semaphore imposes no limit.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// New returns a semaphore with max slots, or nil (unlimited) when max <= 0.
+func New(max int) *Semaphore {
+	if max <= 0 {
+		return nil
+	}
+	return &Semaphore{slots: make(chan struct{}, max)}
+}
+
+// Limit returns the configured maximum, or 0 when unlimited.
+func (s *Semaphore) Limit() int {
+	if s == nil {
+		return 0
+	}
+	return cap(s.slots)
+}
+
+// Acquire blocks until a slot is free or ctx is done, in which case it
+// returns ctx's error.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryAcquire takes a slot without waiting and reports whether it succeeded.
+func (s *Semaphore) TryAcquire() bool {
+	if s == nil {
+		return true
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release frees a slot taken by Acquire or TryAcquire.
+func (s *Semaphore) Release() {
+	if s == nil {
+		return
+	}
+	<-s.slots
+}
@@ -0,0 +1,52 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSemaphore_NilIsUnlimited(t *testing.T) {
+	var s *Semaphore
+	if New(0) != nil {
+		t.Fatal("expected nil semaphore for max <= 0")
+	}
+	if s.Limit() != 0 {
+		t.Errorf("Limit() = %d, want 0", s.Limit())
+	}
+	for range 3 {
+		if !s.TryAcquire() {
+			t.Fatal("nil semaphore should always admit")
+		}
+	}
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Fatalf("nil semaphore Acquire: %v", err)
+	}
+	s.Release()
+}
+
+func TestSemaphore_BoundsConcurrency(t *testing.T) {
+	s := New(1)
+	if s.Limit() != 1 {
+		t.Errorf("Limit() = %d, want 1", s.Limit())
+	}
+	if !s.TryAcquire() {
+		t.Fatal("expected first acquire to succeed")
+	}
+	if s.TryAcquire() {
+		t.Fatal("expected second acquire to fail while the slot is held")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire() on a full semaphore error = %v, want context.DeadlineExceeded", err)
+	}
+
+	s.Release()
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire() after release error = %v", err)
+	}
+	s.Release()
+}
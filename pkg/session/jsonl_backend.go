@@ -75,6 +75,12 @@ func (b *JSONLBackend) Save(key string) error {
 	return b.store.Compact(context.Background(), key)
 }
 
+// Flush is a no-op: every write is fsynced as it happens, so there is
+// nothing left in memory to persist.
+func (b *JSONLBackend) Flush() error {
+	return nil
+}
+
 // Close releases resources held by the underlying store.
 func (b *JSONLBackend) Close() error {
 	return b.store.Close()
@@ -2,6 +2,8 @@ package session
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -235,6 +237,25 @@ func (sm *SessionManager) Save(key string) error {
 	return nil
 }
 
+// Flush saves every session to disk. It keeps going past failures and
+// returns them joined.
+func (sm *SessionManager) Flush() error {
+	sm.mu.RLock()
+	keys := make([]string, 0, len(sm.sessions))
+	for key := range sm.sessions {
+		keys = append(keys, key)
+	}
+	sm.mu.RUnlock()
+
+	var errs []error
+	for _, key := range keys {
+		if err := sm.Save(key); err != nil {
+			errs = append(errs, fmt.Errorf("save session %q: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (sm *SessionManager) loadSessions() error {
 	files, err := os.ReadDir(sm.storage)
 	if err != nil {
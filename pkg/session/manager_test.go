@@ -83,3 +83,23 @@ func TestSave_RejectsPathTraversal(t *testing.T) {
 		t.Errorf("expected foo_bar.json in storage (sanitized from foo/bar)")
 	}
 }
+
+func TestFlush_SavesAllSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewSessionManager(tmpDir)
+	sm.AddMessage("telegram:1", "user", "hello")
+	sm.AddMessage("discord:2", "user", "hi")
+	sm.SetSummary("discord:2", "greetings")
+
+	if err := sm.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	reloaded := NewSessionManager(tmpDir)
+	if got := reloaded.GetHistory("telegram:1"); len(got) != 1 || got[0].Content != "hello" {
+		t.Errorf("telegram:1 history = %+v", got)
+	}
+	if got := reloaded.GetSummary("discord:2"); got != "greetings" {
+		t.Errorf("discord:2 summary = %q, want %q", got, "greetings")
+	}
+}
@@ -27,6 +27,9 @@ type SessionStore interface {
 	TruncateHistory(key string, keepLast int)
 	// Save persists any pending state to durable storage.
 	Save(key string) error
+	// Flush persists every session, so nothing held only in memory is lost
+	// on shutdown.
+	Flush() error
 	// Close releases resources held by the store.
 	Close() error
 }
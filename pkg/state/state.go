@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,10 +24,83 @@ type State struct {
 	// LastChatID is the last chat ID used for communication
 	LastChatID string `json:"last_chat_id,omitempty"`
 
+	// SessionLanguages maps session keys to a pinned reply language
+	SessionLanguages map[string]string `json:"session_languages,omitempty"`
+
+	// SessionModes maps session keys to the active prompt mode
+	SessionModes map[string]string `json:"session_modes,omitempty"`
+
+	// SessionPins maps session keys to notes pinned with /pin
+	SessionPins map[string][]string `json:"session_pins,omitempty"`
+
+	// SessionVars maps session keys to the agent's scratchpad variables
+	SessionVars map[string]map[string]SessionVar `json:"session_vars,omitempty"`
+
+	// GroupTriggerModes maps "channel:chatID" to a group trigger override
+	GroupTriggerModes map[string]string `json:"group_trigger_modes,omitempty"`
+
+	// GroupTriggerPrefixes maps "channel:chatID" to per-chat trigger prefixes
+	GroupTriggerPrefixes map[string][]string `json:"group_trigger_prefixes,omitempty"`
+
+	// KnownContacts maps contact IDs to the time of their first message
+	KnownContacts map[string]time.Time `json:"known_contacts,omitempty"`
+
+	// ToolUsage maps user IDs to today's count of quota-limited tool calls
+	ToolUsage map[string]*DailyToolUsage `json:"tool_usage,omitempty"`
+
+	// SessionCosts maps session keys to their estimated spend in USD
+	SessionCosts map[string]float64 `json:"session_costs,omitempty"`
+
+	// UserCosts maps user IDs to their estimated spend today
+	UserCosts map[string]*DailyCost `json:"user_costs,omitempty"`
+
+	// PendingTasks maps session keys to a task that stopped at the
+	// iteration limit and can be resumed with /continue
+	PendingTasks map[string]*PendingTask `json:"pending_tasks,omitempty"`
+
+	// PendingQuestions maps session keys to a clarifying question the agent
+	// asked with ask_user and is waiting for the user to answer
+	PendingQuestions map[string]*PendingQuestion `json:"pending_questions,omitempty"`
+
 	// Timestamp is the last time this state was updated
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// SessionVar is a typed scratchpad variable the agent keeps for a session.
+// Value holds the canonical string form of the value for its Type.
+type SessionVar struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// DailyToolUsage counts one user's quota-limited tool calls for a single day.
+type DailyToolUsage struct {
+	Day    string         `json:"day"` // local date, YYYY-MM-DD
+	Counts map[string]int `json:"counts"`
+}
+
+// DailyCost is one user's estimated spend in USD for a single day.
+type DailyCost struct {
+	Day string  `json:"day"` // local date, YYYY-MM-DD
+	USD float64 `json:"usd"`
+}
+
+// PendingTask is the checkpoint of an agent turn that hit the iteration limit.
+// The tool calls made so far live in the session history; this records what
+// the user asked for so a later turn can pick the work back up.
+type PendingTask struct {
+	Task       string    `json:"task"`
+	Iterations int       `json:"iterations"`
+	StoppedAt  time.Time `json:"stopped_at"`
+}
+
+// PendingQuestion is a clarifying question the agent asked the user. The
+// user's next message in the session is treated as its answer.
+type PendingQuestion struct {
+	Question string    `json:"question"`
+	AskedAt  time.Time `json:"asked_at"`
+}
+
 // Manager manages persistent state with atomic saves.
 type Manager struct {
 	workspace string
@@ -122,6 +198,447 @@ func (sm *Manager) GetLastChatID() string {
 	return sm.state.LastChatID
 }
 
+// GetLastTarget splits the last channel, recorded as "platform:chat_id", into
+// its platform and chat ID. Both are empty when nothing was recorded or the
+// value is malformed.
+func (sm *Manager) GetLastTarget() (channel, chatID string) {
+	channel, chatID, ok := strings.Cut(sm.GetLastChannel(), ":")
+	if !ok || channel == "" || chatID == "" {
+		return "", ""
+	}
+	return channel, chatID
+}
+
+// SetSessionLanguage atomically pins the reply language for a session and
+// saves the state. An empty language removes the pin.
+func (sm *Manager) SetSessionLanguage(sessionKey, language string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if language == "" {
+		delete(sm.state.SessionLanguages, sessionKey)
+	} else {
+		if sm.state.SessionLanguages == nil {
+			sm.state.SessionLanguages = make(map[string]string)
+		}
+		sm.state.SessionLanguages[sessionKey] = language
+	}
+	sm.state.Timestamp = time.Now()
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// SetSessionMode atomically stores the active prompt mode for a session and
+// saves the state. An empty mode returns the session to default behavior.
+func (sm *Manager) SetSessionMode(sessionKey, mode string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if mode == "" {
+		delete(sm.state.SessionModes, sessionKey)
+	} else {
+		if sm.state.SessionModes == nil {
+			sm.state.SessionModes = make(map[string]string)
+		}
+		sm.state.SessionModes[sessionKey] = mode
+	}
+	sm.state.Timestamp = time.Now()
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// SetSessionPins atomically stores the pinned notes of a session and saves
+// the state. An empty slice removes them.
+func (sm *Manager) SetSessionPins(sessionKey string, pins []string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if len(pins) == 0 {
+		delete(sm.state.SessionPins, sessionKey)
+	} else {
+		if sm.state.SessionPins == nil {
+			sm.state.SessionPins = make(map[string][]string)
+		}
+		sm.state.SessionPins[sessionKey] = slices.Clone(pins)
+	}
+	sm.state.Timestamp = time.Now()
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// SetSessionVar atomically stores a scratchpad variable for a session and
+// saves the state. A nil v removes the variable.
+func (sm *Manager) SetSessionVar(sessionKey, name string, v *SessionVar) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if v == nil {
+		vars, ok := sm.state.SessionVars[sessionKey]
+		if !ok {
+			return nil
+		}
+		if _, ok := vars[name]; !ok {
+			return nil
+		}
+		delete(vars, name)
+		if len(vars) == 0 {
+			delete(sm.state.SessionVars, sessionKey)
+		}
+	} else {
+		if sm.state.SessionVars == nil {
+			sm.state.SessionVars = make(map[string]map[string]SessionVar)
+		}
+		if sm.state.SessionVars[sessionKey] == nil {
+			sm.state.SessionVars[sessionKey] = make(map[string]SessionVar)
+		}
+		sm.state.SessionVars[sessionKey][name] = *v
+	}
+	sm.state.Timestamp = time.Now()
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// ClearSessionVars atomically removes all scratchpad variables of a session
+// and saves the state.
+func (sm *Manager) ClearSessionVars(sessionKey string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if _, ok := sm.state.SessionVars[sessionKey]; !ok {
+		return nil
+	}
+	delete(sm.state.SessionVars, sessionKey)
+	sm.state.Timestamp = time.Now()
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// SetPendingTask atomically stores the resumable task for a session and
+// saves the state. A nil task removes it.
+func (sm *Manager) SetPendingTask(sessionKey string, task *PendingTask) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if task == nil {
+		if _, ok := sm.state.PendingTasks[sessionKey]; !ok {
+			return nil
+		}
+		delete(sm.state.PendingTasks, sessionKey)
+	} else {
+		if sm.state.PendingTasks == nil {
+			sm.state.PendingTasks = make(map[string]*PendingTask)
+		}
+		sm.state.PendingTasks[sessionKey] = task
+	}
+	sm.state.Timestamp = time.Now()
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingTask returns a copy of the resumable task for a session.
+func (sm *Manager) GetPendingTask(sessionKey string) (PendingTask, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	task, ok := sm.state.PendingTasks[sessionKey]
+	if !ok || task == nil {
+		return PendingTask{}, false
+	}
+	return *task, true
+}
+
+// SetPendingQuestion atomically stores the question a session is waiting on
+// and saves the state. A nil question removes it.
+func (sm *Manager) SetPendingQuestion(sessionKey string, q *PendingQuestion) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if q == nil {
+		if _, ok := sm.state.PendingQuestions[sessionKey]; !ok {
+			return nil
+		}
+		delete(sm.state.PendingQuestions, sessionKey)
+	} else {
+		if sm.state.PendingQuestions == nil {
+			sm.state.PendingQuestions = make(map[string]*PendingQuestion)
+		}
+		sm.state.PendingQuestions[sessionKey] = q
+	}
+	sm.state.Timestamp = time.Now()
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingQuestion returns a copy of the question a session is waiting on.
+func (sm *Manager) GetPendingQuestion(sessionKey string) (PendingQuestion, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	q, ok := sm.state.PendingQuestions[sessionKey]
+	if !ok || q == nil {
+		return PendingQuestion{}, false
+	}
+	return *q, true
+}
+
+// GetSessionPins returns a copy of the pinned notes of a session.
+func (sm *Manager) GetSessionPins(sessionKey string) []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return slices.Clone(sm.state.SessionPins[sessionKey])
+}
+
+// GetSessionVars returns a copy of a session's scratchpad variables.
+func (sm *Manager) GetSessionVars(sessionKey string) map[string]SessionVar {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return maps.Clone(sm.state.SessionVars[sessionKey])
+}
+
+// GetSessionLanguage returns the pinned reply language for a session,
+// or "" when the session follows the user's language.
+func (sm *Manager) GetSessionLanguage(sessionKey string) string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.state.SessionLanguages[sessionKey]
+}
+
+// GetSessionMode returns the active prompt mode for a session, or "" when
+// none is set.
+func (sm *Manager) GetSessionMode(sessionKey string) string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.state.SessionModes[sessionKey]
+}
+
+// SetGroupTriggerMode atomically stores the group trigger override for a chat
+// (keyed "channel:chatID") and saves the state. An empty mode removes it.
+func (sm *Manager) SetGroupTriggerMode(chatKey, mode string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if mode == "" {
+		delete(sm.state.GroupTriggerModes, chatKey)
+	} else {
+		if sm.state.GroupTriggerModes == nil {
+			sm.state.GroupTriggerModes = make(map[string]string)
+		}
+		sm.state.GroupTriggerModes[chatKey] = mode
+	}
+	sm.state.Timestamp = time.Now()
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// GetGroupTriggerModes returns a copy of all persisted group trigger overrides.
+func (sm *Manager) GetGroupTriggerModes() map[string]string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	modes := make(map[string]string, len(sm.state.GroupTriggerModes))
+	maps.Copy(modes, sm.state.GroupTriggerModes)
+	return modes
+}
+
+// SetGroupTriggerPrefixes atomically stores per-chat trigger prefixes (keyed
+// "channel:chatID") and saves the state. An empty slice removes them.
+func (sm *Manager) SetGroupTriggerPrefixes(chatKey string, prefixes []string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if len(prefixes) == 0 {
+		delete(sm.state.GroupTriggerPrefixes, chatKey)
+	} else {
+		if sm.state.GroupTriggerPrefixes == nil {
+			sm.state.GroupTriggerPrefixes = make(map[string][]string)
+		}
+		sm.state.GroupTriggerPrefixes[chatKey] = slices.Clone(prefixes)
+	}
+	sm.state.Timestamp = time.Now()
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// MarkContact records contactID as known and saves the state. It reports
+// whether this was the contact's first message.
+func (sm *Manager) MarkContact(contactID string) (bool, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if _, known := sm.state.KnownContacts[contactID]; known {
+		return false, nil
+	}
+	if sm.state.KnownContacts == nil {
+		sm.state.KnownContacts = make(map[string]time.Time)
+	}
+	now := time.Now()
+	sm.state.KnownContacts[contactID] = now
+	sm.state.Timestamp = now
+
+	if err := sm.saveAtomic(); err != nil {
+		return true, fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return true, nil
+}
+
+// ConsumeToolQuota records one call of tool by userID and saves the state,
+// unless the user already made limit calls today; in that case it reports
+// false and records nothing. Counters reset at local midnight, and entries
+// from earlier days are dropped as they are encountered.
+func (sm *Manager) ConsumeToolQuota(userID, tool string, limit int) (bool, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	now := time.Now()
+	today := now.Format(time.DateOnly)
+	for id, usage := range sm.state.ToolUsage {
+		if usage == nil || usage.Day != today {
+			delete(sm.state.ToolUsage, id)
+		}
+	}
+
+	usage := sm.state.ToolUsage[userID]
+	if usage == nil {
+		usage = &DailyToolUsage{Day: today, Counts: make(map[string]int)}
+	}
+	if usage.Counts[tool] >= limit {
+		return false, nil
+	}
+	usage.Counts[tool]++
+	if sm.state.ToolUsage == nil {
+		sm.state.ToolUsage = make(map[string]*DailyToolUsage)
+	}
+	sm.state.ToolUsage[userID] = usage
+	sm.state.Timestamp = now
+
+	if err := sm.saveAtomic(); err != nil {
+		return true, fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return true, nil
+}
+
+// AddCost adds usd to the spend of sessionKey and to today's spend of userID
+// and saves the state. Either key may be empty to skip it. Daily spend resets
+// at local midnight.
+func (sm *Manager) AddCost(sessionKey, userID string, usd float64) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	now := time.Now()
+	if sessionKey != "" {
+		if sm.state.SessionCosts == nil {
+			sm.state.SessionCosts = make(map[string]float64)
+		}
+		sm.state.SessionCosts[sessionKey] += usd
+	}
+	if userID != "" {
+		today := now.Format(time.DateOnly)
+		for id, cost := range sm.state.UserCosts {
+			if cost == nil || cost.Day != today {
+				delete(sm.state.UserCosts, id)
+			}
+		}
+		if sm.state.UserCosts == nil {
+			sm.state.UserCosts = make(map[string]*DailyCost)
+		}
+		cost := sm.state.UserCosts[userID]
+		if cost == nil {
+			cost = &DailyCost{Day: today}
+			sm.state.UserCosts[userID] = cost
+		}
+		cost.USD += usd
+	}
+	sm.state.Timestamp = now
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// GetSessionCost returns the estimated spend of a session in USD.
+func (sm *Manager) GetSessionCost(sessionKey string) float64 {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.state.SessionCosts[sessionKey]
+}
+
+// GetUserDailyCost returns the estimated spend of a user today in USD.
+func (sm *Manager) GetUserDailyCost(userID string) float64 {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	cost := sm.state.UserCosts[userID]
+	if cost == nil || cost.Day != time.Now().Format(time.DateOnly) {
+		return 0
+	}
+	return cost.USD
+}
+
+// ResetSessionCost clears the recorded spend of a session and saves the state.
+func (sm *Manager) ResetSessionCost(sessionKey string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if _, ok := sm.state.SessionCosts[sessionKey]; !ok {
+		return nil
+	}
+	delete(sm.state.SessionCosts, sessionKey)
+	sm.state.Timestamp = time.Now()
+
+	if err := sm.saveAtomic(); err != nil {
+		return fmt.Errorf("failed to save state atomically: %w", err)
+	}
+
+	return nil
+}
+
+// GetGroupTriggerPrefixes returns a copy of all persisted per-chat prefixes.
+func (sm *Manager) GetGroupTriggerPrefixes() map[string][]string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	prefixes := make(map[string][]string, len(sm.state.GroupTriggerPrefixes))
+	for k, v := range sm.state.GroupTriggerPrefixes {
+		prefixes[k] = slices.Clone(v)
+	}
+	return prefixes
+}
+
 // GetTimestamp returns the timestamp of the last state update.
 func (sm *Manager) GetTimestamp() time.Time {
 	sm.mu.RLock()
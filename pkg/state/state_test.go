@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestAtomicSave(t *testing.T) {
@@ -82,6 +83,119 @@ func TestSetLastChatID(t *testing.T) {
 	}
 }
 
+func TestSessionLanguage(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "state-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	sm := NewManager(tmpDir)
+
+	if got := sm.GetSessionLanguage("agent:main:main"); got != "" {
+		t.Errorf("Expected no language for new session, got '%s'", got)
+	}
+
+	if err := sm.SetSessionLanguage("agent:main:main", "fr"); err != nil {
+		t.Fatalf("SetSessionLanguage failed: %v", err)
+	}
+	if got := sm.GetSessionLanguage("agent:main:main"); got != "fr" {
+		t.Errorf("Expected language 'fr', got '%s'", got)
+	}
+
+	// Verify persistence
+	sm2 := NewManager(tmpDir)
+	if got := sm2.GetSessionLanguage("agent:main:main"); got != "fr" {
+		t.Errorf("Expected persistent language 'fr', got '%s'", got)
+	}
+
+	// Clearing removes the pin
+	if err := sm2.SetSessionLanguage("agent:main:main", ""); err != nil {
+		t.Fatalf("SetSessionLanguage failed: %v", err)
+	}
+	if got := sm2.GetSessionLanguage("agent:main:main"); got != "" {
+		t.Errorf("Expected language to be cleared, got '%s'", got)
+	}
+}
+
+func TestSessionMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewManager(tmpDir)
+
+	if got := sm.GetSessionMode("agent:main:main"); got != "" {
+		t.Errorf("Expected no mode for new session, got '%s'", got)
+	}
+
+	if err := sm.SetSessionMode("agent:main:main", "concise"); err != nil {
+		t.Fatalf("SetSessionMode failed: %v", err)
+	}
+
+	// Verify persistence
+	sm2 := NewManager(tmpDir)
+	if got := sm2.GetSessionMode("agent:main:main"); got != "concise" {
+		t.Errorf("Expected persistent mode 'concise', got '%s'", got)
+	}
+
+	if err := sm2.SetSessionMode("agent:main:main", ""); err != nil {
+		t.Fatalf("SetSessionMode failed: %v", err)
+	}
+	if got := sm2.GetSessionMode("agent:main:main"); got != "" {
+		t.Errorf("Expected mode to be cleared, got '%s'", got)
+	}
+}
+
+func TestSessionVars(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewManager(tmpDir)
+
+	if got := sm.GetSessionVars("s1"); len(got) != 0 {
+		t.Errorf("Expected no vars for new session, got %v", got)
+	}
+
+	if err := sm.SetSessionVar("s1", "choice", &SessionVar{Type: "string", Value: "B"}); err != nil {
+		t.Fatalf("SetSessionVar failed: %v", err)
+	}
+	if err := sm.SetSessionVar("s1", "step", &SessionVar{Type: "number", Value: "3"}); err != nil {
+		t.Fatalf("SetSessionVar failed: %v", err)
+	}
+	if err := sm.SetSessionVar("s2", "choice", &SessionVar{Type: "string", Value: "A"}); err != nil {
+		t.Fatalf("SetSessionVar failed: %v", err)
+	}
+
+	// Verify persistence and session scoping
+	sm2 := NewManager(tmpDir)
+	vars := sm2.GetSessionVars("s1")
+	if len(vars) != 2 || vars["choice"].Value != "B" || vars["step"].Type != "number" {
+		t.Errorf("Unexpected vars for s1: %v", vars)
+	}
+	if got := sm2.GetSessionVars("s2")["choice"].Value; got != "A" {
+		t.Errorf("Expected s2 choice 'A', got '%s'", got)
+	}
+
+	// The returned map is a copy
+	vars["choice"] = SessionVar{Type: "string", Value: "mutated"}
+	if got := sm2.GetSessionVars("s1")["choice"].Value; got != "B" {
+		t.Errorf("Expected stored value to be unaffected, got '%s'", got)
+	}
+
+	if err := sm2.SetSessionVar("s1", "choice", nil); err != nil {
+		t.Fatalf("SetSessionVar(nil) failed: %v", err)
+	}
+	if _, ok := sm2.GetSessionVars("s1")["choice"]; ok {
+		t.Error("Expected choice to be removed")
+	}
+
+	if err := sm2.ClearSessionVars("s1"); err != nil {
+		t.Fatalf("ClearSessionVars failed: %v", err)
+	}
+	if got := sm2.GetSessionVars("s1"); len(got) != 0 {
+		t.Errorf("Expected s1 vars to be cleared, got %v", got)
+	}
+	if got := sm2.GetSessionVars("s2"); len(got) != 1 {
+		t.Errorf("Expected s2 vars to be kept, got %v", got)
+	}
+}
+
 func TestAtomicity_NoCorruptionOnInterrupt(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "state-test-*")
 	if err != nil {
@@ -168,6 +282,35 @@ func TestConcurrentAccess(t *testing.T) {
 	}
 }
 
+func TestGroupTriggerPrefixes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "state-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	sm := NewManager(tmpDir)
+
+	if err := sm.SetGroupTriggerPrefixes("telegram:-100", []string{"!bot", "/ask"}); err != nil {
+		t.Fatalf("SetGroupTriggerPrefixes failed: %v", err)
+	}
+
+	// Verify persistence
+	sm2 := NewManager(tmpDir)
+	got := sm2.GetGroupTriggerPrefixes()["telegram:-100"]
+	if len(got) != 2 || got[0] != "!bot" || got[1] != "/ask" {
+		t.Errorf("Expected persisted prefixes [!bot /ask], got %v", got)
+	}
+
+	// Clearing removes the override
+	if err := sm2.SetGroupTriggerPrefixes("telegram:-100", nil); err != nil {
+		t.Fatalf("SetGroupTriggerPrefixes failed: %v", err)
+	}
+	if _, ok := sm2.GetGroupTriggerPrefixes()["telegram:-100"]; ok {
+		t.Error("Expected prefixes to be cleared")
+	}
+}
+
 func TestNewManager_ExistingState(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "state-test-*")
 	if err != nil {
@@ -193,6 +336,31 @@ func TestNewManager_ExistingState(t *testing.T) {
 	}
 }
 
+func TestGetLastTarget_PersistsAcrossRestart(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	sm := NewManager(tmpDir)
+	if channel, chatID := sm.GetLastTarget(); channel != "" || chatID != "" {
+		t.Errorf("GetLastTarget() on empty state = %q, %q", channel, chatID)
+	}
+	if err := sm.SetLastChannel("telegram:-100123:42"); err != nil {
+		t.Fatalf("SetLastChannel failed: %v", err)
+	}
+
+	sm2 := NewManager(tmpDir)
+	channel, chatID := sm2.GetLastTarget()
+	if channel != "telegram" || chatID != "-100123:42" {
+		t.Errorf("GetLastTarget() after reload = %q, %q", channel, chatID)
+	}
+
+	if err := sm2.SetLastChannel("malformed"); err != nil {
+		t.Fatalf("SetLastChannel failed: %v", err)
+	}
+	if channel, chatID := NewManager(tmpDir).GetLastTarget(); channel != "" || chatID != "" {
+		t.Errorf("GetLastTarget() for malformed value = %q, %q", channel, chatID)
+	}
+}
+
 func TestNewManager_EmptyWorkspace(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "state-test-*")
 	if err != nil {
@@ -244,3 +412,218 @@ func TestNewManager_MkdirFailureDoesNotCrash(t *testing.T) {
 		t.Fatalf("NewManager should not crash when state dir creation fails, got: %v", err)
 	}
 }
+
+func TestMarkContact(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewManager(tmpDir)
+
+	first, err := sm.MarkContact("telegram:123")
+	if err != nil {
+		t.Fatalf("MarkContact failed: %v", err)
+	}
+	if !first {
+		t.Error("Expected first contact for new ID")
+	}
+
+	first, err = sm.MarkContact("telegram:123")
+	if err != nil {
+		t.Fatalf("MarkContact failed: %v", err)
+	}
+	if first {
+		t.Error("Expected known contact on second call")
+	}
+
+	// Verify persistence
+	sm2 := NewManager(tmpDir)
+	if first, _ := sm2.MarkContact("telegram:123"); first {
+		t.Error("Expected contact to be remembered across restarts")
+	}
+	if first, _ := sm2.MarkContact("discord:456"); !first {
+		t.Error("Expected first contact for a different ID")
+	}
+}
+
+func TestConsumeToolQuota(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewManager(tmpDir)
+
+	for i := 0; i < 2; i++ {
+		allowed, err := sm.ConsumeToolQuota("telegram:123", "web_search", 2)
+		if err != nil {
+			t.Fatalf("ConsumeToolQuota failed: %v", err)
+		}
+		if !allowed {
+			t.Fatalf("Expected call %d to be allowed", i+1)
+		}
+	}
+	if allowed, _ := sm.ConsumeToolQuota("telegram:123", "web_search", 2); allowed {
+		t.Error("Expected call beyond the limit to be rejected")
+	}
+
+	// Other tools and other users have their own counters
+	if allowed, _ := sm.ConsumeToolQuota("telegram:123", "web_fetch", 2); !allowed {
+		t.Error("Expected a different tool to be allowed")
+	}
+	if allowed, _ := sm.ConsumeToolQuota("discord:456", "web_search", 2); !allowed {
+		t.Error("Expected a different user to be allowed")
+	}
+
+	// Verify persistence
+	sm2 := NewManager(tmpDir)
+	if allowed, _ := sm2.ConsumeToolQuota("telegram:123", "web_search", 2); allowed {
+		t.Error("Expected usage to be remembered across restarts")
+	}
+}
+
+func TestConsumeToolQuota_ResetsOnNewDay(t *testing.T) {
+	sm := NewManager(t.TempDir())
+	sm.state.ToolUsage = map[string]*DailyToolUsage{
+		"telegram:123": {Day: "2000-01-01", Counts: map[string]int{"web_search": 5}},
+	}
+
+	if allowed, _ := sm.ConsumeToolQuota("telegram:123", "web_search", 1); !allowed {
+		t.Error("Expected usage from a previous day to be discarded")
+	}
+}
+
+func TestAddCost(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewManager(tmpDir)
+
+	if err := sm.AddCost("session-1", "telegram:123", 0.25); err != nil {
+		t.Fatalf("AddCost failed: %v", err)
+	}
+	if err := sm.AddCost("session-2", "telegram:123", 0.5); err != nil {
+		t.Fatalf("AddCost failed: %v", err)
+	}
+	if err := sm.AddCost("session-1", "", 0.25); err != nil {
+		t.Fatalf("AddCost failed: %v", err)
+	}
+
+	// Verify persistence
+	sm2 := NewManager(tmpDir)
+	if got := sm2.GetSessionCost("session-1"); got != 0.5 {
+		t.Errorf("GetSessionCost(session-1) = %v, want 0.5", got)
+	}
+	if got := sm2.GetUserDailyCost("telegram:123"); got != 0.75 {
+		t.Errorf("GetUserDailyCost = %v, want 0.75", got)
+	}
+	if got := sm2.GetUserDailyCost("discord:456"); got != 0 {
+		t.Errorf("GetUserDailyCost for unknown user = %v, want 0", got)
+	}
+
+	if err := sm2.ResetSessionCost("session-1"); err != nil {
+		t.Fatalf("ResetSessionCost failed: %v", err)
+	}
+	if got := NewManager(tmpDir).GetSessionCost("session-1"); got != 0 {
+		t.Errorf("GetSessionCost after reset = %v, want 0", got)
+	}
+}
+
+func TestAddCost_ResetsOnNewDay(t *testing.T) {
+	sm := NewManager(t.TempDir())
+	sm.state.UserCosts = map[string]*DailyCost{
+		"telegram:123": {Day: "2000-01-01", USD: 10},
+	}
+
+	if got := sm.GetUserDailyCost("telegram:123"); got != 0 {
+		t.Errorf("GetUserDailyCost for a previous day = %v, want 0", got)
+	}
+	if err := sm.AddCost("", "telegram:123", 0.5); err != nil {
+		t.Fatalf("AddCost failed: %v", err)
+	}
+	if got := sm.GetUserDailyCost("telegram:123"); got != 0.5 {
+		t.Errorf("GetUserDailyCost = %v, want 0.5", got)
+	}
+}
+
+func TestPendingTask(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewManager(tmpDir)
+
+	if _, ok := sm.GetPendingTask("session-1"); ok {
+		t.Fatal("Expected no pending task initially")
+	}
+
+	task := &PendingTask{Task: "migrate the database", Iterations: 20, StoppedAt: time.Now()}
+	if err := sm.SetPendingTask("session-1", task); err != nil {
+		t.Fatalf("SetPendingTask failed: %v", err)
+	}
+
+	// Verify persistence
+	sm2 := NewManager(tmpDir)
+	got, ok := sm2.GetPendingTask("session-1")
+	if !ok {
+		t.Fatal("Expected pending task to survive a restart")
+	}
+	if got.Task != "migrate the database" || got.Iterations != 20 {
+		t.Errorf("Unexpected pending task: %+v", got)
+	}
+	if _, ok := sm2.GetPendingTask("session-2"); ok {
+		t.Error("Expected pending tasks to be keyed by session")
+	}
+
+	if err := sm2.SetPendingTask("session-1", nil); err != nil {
+		t.Fatalf("SetPendingTask(nil) failed: %v", err)
+	}
+	if _, ok := sm2.GetPendingTask("session-1"); ok {
+		t.Error("Expected pending task to be cleared")
+	}
+}
+
+func TestPendingQuestion(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewManager(tmpDir)
+
+	if _, ok := sm.GetPendingQuestion("session-1"); ok {
+		t.Fatal("Expected no pending question initially")
+	}
+
+	q := &PendingQuestion{Question: "Which environment, staging or prod?", AskedAt: time.Now()}
+	if err := sm.SetPendingQuestion("session-1", q); err != nil {
+		t.Fatalf("SetPendingQuestion failed: %v", err)
+	}
+
+	sm2 := NewManager(tmpDir)
+	got, ok := sm2.GetPendingQuestion("session-1")
+	if !ok || got.Question != q.Question {
+		t.Fatalf("Expected pending question to survive a restart, got %+v", got)
+	}
+
+	if err := sm2.SetPendingQuestion("session-1", nil); err != nil {
+		t.Fatalf("SetPendingQuestion(nil) failed: %v", err)
+	}
+	if _, ok := sm2.GetPendingQuestion("session-1"); ok {
+		t.Error("Expected pending question to be cleared")
+	}
+}
+
+func TestSessionPins(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewManager(tmpDir)
+
+	if pins := sm.GetSessionPins("session-1"); len(pins) != 0 {
+		t.Fatalf("Expected no pins initially, got %v", pins)
+	}
+
+	if err := sm.SetSessionPins("session-1", []string{"My name is Ana", "Budget is 500 EUR"}); err != nil {
+		t.Fatalf("SetSessionPins failed: %v", err)
+	}
+
+	sm2 := NewManager(tmpDir)
+	pins := sm2.GetSessionPins("session-1")
+	if len(pins) != 2 || pins[1] != "Budget is 500 EUR" {
+		t.Fatalf("Expected pins to survive a restart, got %v", pins)
+	}
+	pins[0] = "changed"
+	if sm2.GetSessionPins("session-1")[0] != "My name is Ana" {
+		t.Error("Expected GetSessionPins to return a copy")
+	}
+
+	if err := sm2.SetSessionPins("session-1", nil); err != nil {
+		t.Fatalf("SetSessionPins(nil) failed: %v", err)
+	}
+	if pins := sm2.GetSessionPins("session-1"); len(pins) != 0 {
+		t.Errorf("Expected pins to be cleared, got %v", pins)
+	}
+}
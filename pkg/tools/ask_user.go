@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/state"
+)
+
+// MaxQuestionLen bounds the question ask_user sends.
+const MaxQuestionLen = 1000
+
+// QuestionStore records the clarifying question a session is waiting on.
+// *state.Manager satisfies it.
+type QuestionStore interface {
+	SetPendingQuestion(sessionKey string, q *state.PendingQuestion) error
+}
+
+// AskUserTool sends the user a clarifying question and ends the turn. The
+// agent loop treats the user's next message as the answer.
+type AskUserTool struct {
+	store QuestionStore
+}
+
+func NewAskUserTool(store QuestionStore) *AskUserTool {
+	return &AskUserTool{store: store}
+}
+
+func (t *AskUserTool) Name() string { return "ask_user" }
+
+func (t *AskUserTool) Description() string {
+	return "Ask the user a clarifying question instead of guessing, when the request is ambiguous or you need " +
+		"information only they have. The question is sent and your turn ends; the user's next message is the " +
+		"answer and you continue the task from there. Ask one short, specific question."
+}
+
+func (t *AskUserTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"question": map[string]any{
+				"type":        "string",
+				"description": "The question to ask the user",
+			},
+		},
+		"required": []string{"question"},
+	}
+}
+
+func (t *AskUserTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	if t.store == nil {
+		return ErrorResult("question storage not configured")
+	}
+	sessionKey := ToolSessionKey(ctx)
+	if sessionKey == "" {
+		return ErrorResult("ask_user is only available within a conversation session")
+	}
+	question, _ := args["question"].(string)
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return ErrorResult("question is required")
+	}
+	if len(question) > MaxQuestionLen {
+		return ErrorResult(fmt.Sprintf("question must be at most %d characters", MaxQuestionLen))
+	}
+
+	err := t.store.SetPendingQuestion(sessionKey, &state.PendingQuestion{Question: question, AskedAt: time.Now()})
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to record question: %v", err))
+	}
+	return &ToolResult{
+		ForLLM:  "Question sent to the user. Their next message will be the answer.",
+		ForUser: question,
+		EndTurn: true,
+	}
+}
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/state"
+)
+
+func TestAskUserTool(t *testing.T) {
+	store := state.NewManager(t.TempDir())
+	ctx := WithToolSessionKey(context.Background(), "agent:main:telegram:direct:1")
+	tool := NewAskUserTool(store)
+
+	r := tool.Execute(ctx, map[string]any{"question": "  Staging or production? "})
+	if r.IsError {
+		t.Fatalf("ask_user error: %s", r.ForLLM)
+	}
+	if !r.EndTurn || r.ForUser != "Staging or production?" {
+		t.Errorf("result = %+v, want EndTurn with the trimmed question", r)
+	}
+
+	q, ok := store.GetPendingQuestion("agent:main:telegram:direct:1")
+	if !ok || q.Question != "Staging or production?" || q.AskedAt.IsZero() {
+		t.Errorf("pending question = %+v, %v", q, ok)
+	}
+}
+
+func TestAskUserTool_Validation(t *testing.T) {
+	store := state.NewManager(t.TempDir())
+	tool := NewAskUserTool(store)
+
+	if r := tool.Execute(context.Background(), map[string]any{"question": "Why?"}); !r.IsError {
+		t.Error("expected error without a session")
+	}
+
+	ctx := WithToolSessionKey(context.Background(), "s1")
+	if r := tool.Execute(ctx, map[string]any{"question": "  "}); !r.IsError {
+		t.Error("expected error for an empty question")
+	}
+	long := strings.Repeat("a", MaxQuestionLen+1)
+	if r := tool.Execute(ctx, map[string]any{"question": long}); !r.IsError {
+		t.Error("expected error for an overlong question")
+	}
+	if _, ok := store.GetPendingQuestion("s1"); ok {
+		t.Error("rejected questions must not be stored")
+	}
+}
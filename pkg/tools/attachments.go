@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/media"
+)
+
+// ListAttachmentsTool lists the files the user attached to the current
+// message, so the agent can pass their media refs to read_file or exec.
+type ListAttachmentsTool struct {
+	mediaStore media.MediaStore
+}
+
+func NewListAttachmentsTool(store media.MediaStore) *ListAttachmentsTool {
+	return &ListAttachmentsTool{mediaStore: store}
+}
+
+func (t *ListAttachmentsTool) Name() string { return "list_attachments" }
+
+func (t *ListAttachmentsTool) ConcurrencySafe() bool { return true }
+
+func (t *ListAttachmentsTool) Description() string {
+	return "List files attached to the user's current message. " +
+		"Returns each attachment's media ref, filename, type and local path; " +
+		"pass the ref to read_file or the path to exec to work with the file."
+}
+
+func (t *ListAttachmentsTool) Parameters() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+}
+
+func (t *ListAttachmentsTool) SetMediaStore(store media.MediaStore) {
+	t.mediaStore = store
+}
+
+func (t *ListAttachmentsTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	refs := ToolMedia(ctx)
+	if len(refs) == 0 {
+		return SilentResult("No attachments in the current message.")
+	}
+	if t.mediaStore == nil {
+		return ErrorResult("media store not configured")
+	}
+
+	var sb strings.Builder
+	count := 0
+	for _, ref := range refs {
+		if !strings.HasPrefix(ref, "media://") {
+			continue
+		}
+		localPath, meta, err := t.mediaStore.ResolveWithMeta(ref)
+		if err != nil {
+			fmt.Fprintf(&sb, "- %s (unavailable: %v)\n", ref, err)
+			continue
+		}
+
+		filename := meta.Filename
+		if filename == "" {
+			filename = filepath.Base(localPath)
+		}
+		contentType := meta.ContentType
+		if contentType == "" {
+			contentType = detectMediaType(localPath)
+		}
+		size := int64(-1)
+		if info, err := os.Stat(localPath); err == nil {
+			size = info.Size()
+		}
+
+		fmt.Fprintf(&sb, "- ref: %s\n  filename: %s\n  type: %s\n  size: %d bytes\n  path: %s\n",
+			ref, filename, contentType, size, localPath)
+		count++
+	}
+	if count == 0 && sb.Len() == 0 {
+		return SilentResult("No attachments in the current message.")
+	}
+
+	return SilentResult(fmt.Sprintf("Attachments (%d):\n%s", count, sb.String()))
+}
+
+// resolveAttachment resolves ref to a local path. Only refs attached to the
+// current message are accepted, so a guessed or remembered ref cannot reach
+// media from earlier turns or other sessions.
+func resolveAttachment(ctx context.Context, store media.MediaStore, ref string) (string, media.MediaMeta, error) {
+	if store == nil {
+		return "", media.MediaMeta{}, fmt.Errorf("media store not configured")
+	}
+	if !slices.Contains(ToolMedia(ctx), ref) {
+		return "", media.MediaMeta{}, fmt.Errorf("%s is not attached to the current message", ref)
+	}
+	return store.ResolveWithMeta(ref)
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/media"
+)
+
+func TestListAttachmentsTool_NoAttachments(t *testing.T) {
+	tool := NewListAttachmentsTool(media.NewFileMediaStore())
+
+	result := tool.Execute(context.Background(), map[string]any{})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "No attachments") {
+		t.Errorf("ForLLM = %q, want no-attachments message", result.ForLLM)
+	}
+}
+
+func TestListAttachmentsTool_ListsCurrentMessageMedia(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4 test"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := media.NewFileMediaStore()
+	ref, err := store.Store(path, media.MediaMeta{
+		Filename:    "report.pdf",
+		ContentType: "application/pdf",
+	}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tool := NewListAttachmentsTool(store)
+	ctx := WithToolMedia(context.Background(), []string{ref})
+	result := tool.Execute(ctx, map[string]any{})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	for _, want := range []string{ref, "report.pdf", "application/pdf", path} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("ForLLM missing %q:\n%s", want, result.ForLLM)
+		}
+	}
+	if !result.Silent {
+		t.Error("expected silent result")
+	}
+}
+
+func TestReadFileTool_ResolvesMediaRef(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("attached notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := media.NewFileMediaStore()
+	ref, err := store.Store(path, media.MediaMeta{Filename: "notes.txt"}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workspace := t.TempDir()
+	allow := []*regexp.Regexp{regexp.MustCompile("^" + regexp.QuoteMeta(dir))}
+	tool := NewReadFileTool(workspace, true, 0, allow)
+
+	ctx := WithToolMedia(context.Background(), []string{ref})
+
+	// Without a store the ref cannot be resolved
+	if result := tool.Execute(ctx, map[string]any{"path": ref}); !result.IsError {
+		t.Fatal("expected error without media store")
+	}
+
+	tool.SetMediaStore(store)
+	result := tool.Execute(ctx, map[string]any{"path": ref})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "attached notes") {
+		t.Errorf("ForLLM = %q, want file contents", result.ForLLM)
+	}
+}
+
+func TestReadFileTool_RejectsMediaRefOutsideCurrentMessage(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(path, []byte("from another session"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := media.NewFileMediaStore()
+	ref, err := store.Store(path, media.MediaMeta{Filename: "secret.txt"}, "other-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allow := []*regexp.Regexp{regexp.MustCompile("^" + regexp.QuoteMeta(dir))}
+	tool := NewReadFileTool(t.TempDir(), true, 0, allow)
+	tool.SetMediaStore(store)
+
+	for name, ctx := range map[string]context.Context{
+		"no attachments":    context.Background(),
+		"other attachments": WithToolMedia(context.Background(), []string{"media://current"}),
+	} {
+		result := tool.Execute(ctx, map[string]any{"path": ref})
+		if !result.IsError || !strings.Contains(result.ForLLM, "not attached to the current message") {
+			t.Errorf("%s: expected the stored ref to be rejected, got %+v", name, result)
+		}
+		if strings.Contains(result.ForLLM, "from another session") {
+			t.Errorf("%s: file contents leaked: %q", name, result.ForLLM)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package tools
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
 
 // Tool is the interface that all tools must implement.
 type Tool interface {
@@ -23,6 +28,10 @@ type toolCtxKey struct{ name string }
 var (
 	ctxKeyChannel = &toolCtxKey{"channel"}
 	ctxKeyChatID  = &toolCtxKey{"chatID"}
+	ctxKeyMedia   = &toolCtxKey{"media"}
+	ctxKeySession = &toolCtxKey{"session"}
+	ctxKeyAgent   = &toolCtxKey{"agent"}
+	ctxKeyUsage   = &toolCtxKey{"usage"}
 )
 
 // WithToolContext returns a child context carrying channel and chatID.
@@ -44,6 +53,60 @@ func ToolChatID(ctx context.Context) string {
 	return v
 }
 
+// WithToolMedia returns a child context carrying the media refs attached to
+// the inbound message that triggered the current turn.
+func WithToolMedia(ctx context.Context, refs []string) context.Context {
+	return context.WithValue(ctx, ctxKeyMedia, refs)
+}
+
+// ToolMedia extracts the current message's media refs from ctx, or nil if unset.
+func ToolMedia(ctx context.Context) []string {
+	v, _ := ctx.Value(ctxKeyMedia).([]string)
+	return v
+}
+
+// WithToolSessionKey returns a child context carrying the key of the session
+// the current turn belongs to.
+func WithToolSessionKey(ctx context.Context, sessionKey string) context.Context {
+	return context.WithValue(ctx, ctxKeySession, sessionKey)
+}
+
+// ToolSessionKey extracts the session key from ctx, or "" if unset.
+func ToolSessionKey(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKeySession).(string)
+	return v
+}
+
+// WithToolAgentID returns a child context carrying the ID of the agent
+// running the current turn.
+func WithToolAgentID(ctx context.Context, agentID string) context.Context {
+	return context.WithValue(ctx, ctxKeyAgent, agentID)
+}
+
+// ToolAgentID extracts the agent ID from ctx, or "" if unset.
+func ToolAgentID(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKeyAgent).(string)
+	return v
+}
+
+// UsageRecorder is told about each LLM response a tool pays for on behalf of
+// the current turn, such as a subagent iteration or a summary. model is the
+// model_name in model_list that served it. A non-nil error means the turn's
+// spending limit is reached and the tool should stop calling the model.
+type UsageRecorder func(model string, usage *providers.UsageInfo) error
+
+// WithToolUsageRecorder returns a child context carrying the recorder that
+// meters LLM calls made for the current turn.
+func WithToolUsageRecorder(ctx context.Context, rec UsageRecorder) context.Context {
+	return context.WithValue(ctx, ctxKeyUsage, rec)
+}
+
+// ToolUsageRecorder extracts the usage recorder from ctx, or nil if unset.
+func ToolUsageRecorder(ctx context.Context) UsageRecorder {
+	v, _ := ctx.Value(ctxKeyUsage).(UsageRecorder)
+	return v
+}
+
 // AsyncCallback is a function type that async tools use to notify completion.
 // When an async tool finishes its work, it calls this callback with the result.
 //
@@ -81,6 +144,46 @@ type AsyncExecutor interface {
 	ExecuteAsync(ctx context.Context, args map[string]any, cb AsyncCallback) *ToolResult
 }
 
+// ConcurrencySafeTool is an optional interface for tools that may run in
+// parallel with other calls from the same model response. Tools that don't
+// implement it, or return false, run one at a time in the order the model
+// requested them. Only read-only tools without shared state should opt in.
+type ConcurrencySafeTool interface {
+	Tool
+	ConcurrencySafe() bool
+}
+
+// IsConcurrencySafe reports whether tool declared itself safe to run in parallel.
+func IsConcurrencySafe(tool Tool) bool {
+	safe, ok := tool.(ConcurrencySafeTool)
+	return ok && safe.ConcurrencySafe()
+}
+
+// PureTool is an optional interface for tools whose result depends only on
+// their arguments for the length of a turn and that have no side effects,
+// such as web search. Only pure tools may have an immediately repeated call
+// answered from the previous result instead of running again.
+type PureTool interface {
+	Tool
+	Pure() bool
+}
+
+// IsPure reports whether tool declared itself pure.
+func IsPure(tool Tool) bool {
+	pure, ok := tool.(PureTool)
+	return ok && pure.Pure()
+}
+
+// TimeoutTool is an optional interface for tools that bound their own run
+// time, such as exec with its timeout_seconds. The registry's per-call
+// timeout never cuts such a tool off before Timeout has passed. A negative
+// Timeout exempts the tool from the per-call timeout, for tools that run
+// unbounded by design, such as the synchronous subagent.
+type TimeoutTool interface {
+	Tool
+	Timeout() time.Duration
+}
+
 func ToolToSchema(tool Tool) map[string]any {
 	return map[string]any{
 		"type": "function",
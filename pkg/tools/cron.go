@@ -26,10 +26,14 @@ type CronTool struct {
 	execTool     *ExecTool
 	allowCommand bool
 	execEnabled  bool
+	runTimeout   time.Duration // bounds each agent run; 0 means no timeout
+	// cfg supplies the agents' default_target for jobs without a chat.
+	cfg *config.Config
 }
 
 // NewCronTool creates a new CronTool
-// execTimeout: 0 means no timeout, >0 sets the timeout duration
+// execTimeout bounds each scheduled command and agent run: 0 means no timeout,
+// >0 sets the timeout duration
 func NewCronTool(
 	cronService *cron.CronService, executor JobExecutor, msgBus *bus.MessageBus, workspace string, restrict bool,
 	execTimeout time.Duration, config *config.Config,
@@ -60,6 +64,8 @@ func NewCronTool(
 		execTool:     execTool,
 		allowCommand: allowCommand,
 		execEnabled:  execEnabled,
+		runTimeout:   execTimeout,
+		cfg:          config,
 	}, nil
 }
 
@@ -230,8 +236,10 @@ func (t *CronTool) addJob(ctx context.Context, args map[string]any) *ToolResult
 		return ErrorResult(fmt.Sprintf("Error adding job: %v", err))
 	}
 
-	if command != "" {
+	agentID := ToolAgentID(ctx)
+	if command != "" || agentID != "" {
 		job.Payload.Command = command
+		job.Payload.Agent = agentID
 		// Need to save the updated payload
 		t.cronService.UpdateJob(job)
 	}
@@ -301,7 +309,14 @@ func (t *CronTool) ExecuteJob(ctx context.Context, job *cron.CronJob) string {
 	channel := job.Payload.Channel
 	chatID := job.Payload.To
 
-	// Default values if not set
+	// Jobs scheduled outside a chat go to their agent's default_target
+	if channel == "" || constants.IsInternalChannel(channel) {
+		if t.cfg != nil {
+			if ch, id, ok := t.cfg.AgentDefaultTarget(job.Payload.Agent); ok {
+				channel, chatID = ch, id
+			}
+		}
+	}
 	if channel == "" {
 		channel = "cli"
 	}
@@ -362,6 +377,12 @@ func (t *CronTool) ExecuteJob(ctx context.Context, job *cron.CronJob) string {
 	// For deliver=false, process through agent (for complex tasks)
 	sessionKey := fmt.Sprintf("cron-%s", job.ID)
 
+	if t.runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.runTimeout)
+		defer cancel()
+	}
+
 	// Call agent with job's message
 	response, err := t.executor.ProcessDirectWithChannel(
 		ctx,
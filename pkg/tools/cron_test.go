@@ -237,3 +237,116 @@ func TestCronTool_ExecuteJobPublishesErrorWhenExecDisabled(t *testing.T) {
 		t.Fatalf("expected exec disabled message, got: %s", msg.Content)
 	}
 }
+
+func TestCronTool_ExecuteJobUsesDefaultTarget(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.List = []config.AgentConfig{
+		{ID: "main", DefaultTarget: "telegram:123"},
+		{ID: "ops", DefaultTarget: "discord:456"},
+	}
+	tool := newTestCronToolWithConfig(t, cfg)
+
+	for _, tc := range []struct {
+		name, agent, channel, to, want string
+	}{
+		{"no target", "", "", "", "telegram:123"},
+		{"scheduled from the CLI by another agent", "ops", "cli", "direct", "discord:456"},
+		{"scheduled from a chat", "ops", "slack", "C1", "slack:C1"},
+	} {
+		job := &cron.CronJob{}
+		job.Payload.Message = "daily summary"
+		job.Payload.Deliver = true
+		job.Payload.Agent, job.Payload.Channel, job.Payload.To = tc.agent, tc.channel, tc.to
+
+		if got := tool.ExecuteJob(context.Background(), job); got != "ok" {
+			t.Fatalf("%s: ExecuteJob() = %q, want ok", tc.name, got)
+		}
+		select {
+		case msg := <-tool.msgBus.OutboundChan():
+			if got := msg.Channel + ":" + msg.ChatID; got != tc.want {
+				t.Errorf("%s: target = %s, want %s", tc.name, got, tc.want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: timeout waiting for outbound message", tc.name)
+		}
+	}
+}
+
+func TestCronTool_AddJobRecordsAgent(t *testing.T) {
+	tool := newTestCronTool(t)
+	ctx := WithToolAgentID(WithToolContext(context.Background(), "cli", "direct"), "ops")
+	result := tool.Execute(ctx, map[string]any{
+		"action":        "add",
+		"message":       "post the daily summary",
+		"every_seconds": float64(86400),
+	})
+	if result.IsError {
+		t.Fatalf("add failed: %s", result.ForLLM)
+	}
+	jobs := tool.cronService.ListJobs(true)
+	if len(jobs) != 1 || jobs[0].Payload.Agent != "ops" {
+		t.Fatalf("jobs = %+v, want one job scheduled by ops", jobs)
+	}
+}
+
+type recordingJobExecutor struct {
+	content, sessionKey, channel, chatID string
+	hasDeadline                          bool
+}
+
+func (e *recordingJobExecutor) ProcessDirectWithChannel(
+	ctx context.Context,
+	content, sessionKey, channel, chatID string,
+) (string, error) {
+	e.content, e.sessionKey, e.channel, e.chatID = content, sessionKey, channel, chatID
+	_, e.hasDeadline = ctx.Deadline()
+	return "done", nil
+}
+
+func TestCronTool_ScheduleFireAndCancel(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "cron.json")
+	executor := &recordingJobExecutor{}
+	tool, err := NewCronTool(cron.NewCronService(storePath, nil), executor, bus.NewMessageBus(),
+		t.TempDir(), true, time.Minute, config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewCronTool() error: %v", err)
+	}
+
+	ctx := WithToolContext(context.Background(), "telegram", "chat-1")
+	result := tool.Execute(ctx, map[string]any{
+		"action":     "add",
+		"message":    "check the build",
+		"at_seconds": float64(600),
+	})
+	if result.IsError {
+		t.Fatalf("add failed: %s", result.ForLLM)
+	}
+
+	// The job is persisted and survives a restart.
+	jobs := cron.NewCronService(storePath, nil).ListJobs(false)
+	if len(jobs) != 1 {
+		t.Fatalf("expected 1 persisted job, got %d", len(jobs))
+	}
+	job := jobs[0]
+
+	if got := tool.ExecuteJob(context.Background(), &job); got != "ok" {
+		t.Fatalf("ExecuteJob() = %q, want ok", got)
+	}
+	if executor.content != "check the build" || executor.channel != "telegram" || executor.chatID != "chat-1" {
+		t.Errorf("executor got %q for %s:%s", executor.content, executor.channel, executor.chatID)
+	}
+	if executor.sessionKey != "cron-"+job.ID {
+		t.Errorf("sessionKey = %q, want %q", executor.sessionKey, "cron-"+job.ID)
+	}
+	if !executor.hasDeadline {
+		t.Error("expected the agent run to be bounded by the exec timeout")
+	}
+
+	result = tool.Execute(ctx, map[string]any{"action": "remove", "job_id": job.ID})
+	if result.IsError {
+		t.Fatalf("remove failed: %s", result.ForLLM)
+	}
+	if jobs := cron.NewCronService(storePath, nil).ListJobs(true); len(jobs) != 0 {
+		t.Fatalf("expected no jobs after remove, got %d", len(jobs))
+	}
+}
@@ -0,0 +1,276 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/media"
+)
+
+const (
+	// maxDocumentFileSize caps the size of documents read_document will open.
+	maxDocumentFileSize = 20 * 1024 * 1024
+	// defaultMaxDocumentChars caps the extracted text returned to the LLM.
+	defaultMaxDocumentChars = 50000
+)
+
+var (
+	errUnsupportedDocument = errors.New("unsupported document type")
+	errEncryptedDocument   = errors.New("document is encrypted or password-protected")
+	errUnsupportedPDF      = errors.New("unsupported PDF")
+)
+
+// ReadDocumentTool extracts plain text from documents (PDF, DOCX, TXT,
+// CSV, ...) so the agent can work with files the user sends. It accepts a
+// media:// ref from list_attachments or a file path.
+type ReadDocumentTool struct {
+	workspace  string
+	restrict   bool
+	allowPaths []*regexp.Regexp
+	maxChars   int
+	mediaStore media.MediaStore
+}
+
+func NewReadDocumentTool(
+	workspace string,
+	restrict bool,
+	maxChars int,
+	allowPaths ...[]*regexp.Regexp,
+) *ReadDocumentTool {
+	if maxChars <= 0 {
+		maxChars = defaultMaxDocumentChars
+	}
+	var patterns []*regexp.Regexp
+	if len(allowPaths) > 0 {
+		patterns = allowPaths[0]
+	}
+	return &ReadDocumentTool{
+		workspace:  workspace,
+		restrict:   restrict,
+		allowPaths: patterns,
+		maxChars:   maxChars,
+	}
+}
+
+func (t *ReadDocumentTool) Name() string { return "read_document" }
+
+func (t *ReadDocumentTool) ConcurrencySafe() bool { return true }
+
+func (t *ReadDocumentTool) Description() string {
+	return "Extract the text of a document (PDF, DOCX, TXT, CSV, Markdown, JSON). " +
+		"Use for files the user attached; pass the media:// ref from list_attachments or a file path."
+}
+
+func (t *ReadDocumentTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "media:// ref of an attachment, or a path to a local file.",
+			},
+		},
+		"required": []string{"ref"},
+	}
+}
+
+func (t *ReadDocumentTool) SetMediaStore(store media.MediaStore) {
+	t.mediaStore = store
+}
+
+func (t *ReadDocumentTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	ref, _ := args["ref"].(string)
+	if strings.TrimSpace(ref) == "" {
+		return ErrorResult("ref is required")
+	}
+
+	var (
+		localPath string
+		filename  string
+		mimeType  string
+	)
+	if strings.HasPrefix(ref, "media://") {
+		path, meta, err := resolveAttachment(ctx, t.mediaStore, ref)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to resolve %s: %v", ref, err))
+		}
+		localPath, filename, mimeType = path, meta.Filename, meta.ContentType
+	} else {
+		path, err := validatePathWithAllowPaths(ref, t.workspace, t.restrict, t.allowPaths)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("invalid path: %v", err))
+		}
+		localPath = path
+	}
+	if filename == "" {
+		filename = filepath.Base(localPath)
+	}
+
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("file not found: %v", err))
+	}
+	if info.IsDir() {
+		return ErrorResult("path is a directory, expected a file")
+	}
+	if info.Size() > maxDocumentFileSize {
+		return ErrorResult(fmt.Sprintf(
+			"document too large: %d bytes (max %d bytes)", info.Size(), maxDocumentFileSize))
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read document: %v", err))
+	}
+
+	text, err := extractDocumentText(data, filename, mimeType)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("cannot extract text from %s: %v", filename, err))
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return SilentResult(fmt.Sprintf(
+			"No extractable text found in %s (it may be scanned images or use unsupported fonts).", filename))
+	}
+
+	runes := []rune(text)
+	truncated := len(runes) > t.maxChars
+	if truncated {
+		text = string(runes[:t.maxChars])
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Document: %s (%d characters)\n\n", filename, len(runes))
+	sb.WriteString(text)
+	if truncated {
+		fmt.Fprintf(&sb, "\n\n[TRUNCATED: showing first %d of %d characters]", t.maxChars, len(runes))
+	}
+	return SilentResult(sb.String())
+}
+
+// extractDocumentText picks an extractor from the file content, MIME type
+// and extension.
+func extractDocumentText(data []byte, filename, mimeType string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return extractPDFText(data)
+	case ext == ".docx" || mimeType == "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
+		return extractDOCXText(data)
+	case ext == ".csv" || mimeType == "text/csv":
+		return extractCSVText(data)
+	case isPlainTextDocument(ext, mimeType) || utf8.Valid(data) && !bytes.ContainsRune(data, 0):
+		return string(data), nil
+	default:
+		return "", errUnsupportedDocument
+	}
+}
+
+func isPlainTextDocument(ext, mimeType string) bool {
+	if strings.HasPrefix(mimeType, "text/") || mimeType == "application/json" {
+		return true
+	}
+	switch ext {
+	case ".txt", ".md", ".markdown", ".json", ".log", ".yaml", ".yml", ".xml", ".html", ".htm":
+		return true
+	}
+	return false
+}
+
+// extractCSVText renders CSV rows as tab-separated lines.
+func extractCSVText(data []byte) (string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	var sb strings.Builder
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Malformed CSV is still readable as text
+			return string(data), nil
+		}
+		sb.WriteString(strings.Join(record, "\t"))
+		sb.WriteByte('\n')
+	}
+	return sb.String(), nil
+}
+
+// extractDOCXText reads word/document.xml from a DOCX archive and returns
+// its paragraphs as lines.
+func extractDOCXText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		// Password-protected DOCX files are OLE containers, not zip archives
+		if bytes.HasPrefix(data, []byte{0xD0, 0xCF, 0x11, 0xE0}) {
+			return "", errEncryptedDocument
+		}
+		return "", fmt.Errorf("invalid docx: %w", err)
+	}
+
+	var doc *zip.File
+	for _, f := range zr.File {
+		if f.Name == "word/document.xml" {
+			doc = f
+			break
+		}
+	}
+	if doc == nil {
+		return "", fmt.Errorf("invalid docx: word/document.xml not found")
+	}
+
+	rc, err := doc.Open()
+	if err != nil {
+		return "", fmt.Errorf("invalid docx: %w", err)
+	}
+	defer rc.Close()
+
+	var sb strings.Builder
+	dec := xml.NewDecoder(io.LimitReader(rc, maxDocumentFileSize*4))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid docx: %w", err)
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteByte('\t')
+			case "br", "cr":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch el.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(el)
+			}
+		}
+	}
+	return sb.String(), nil
+}
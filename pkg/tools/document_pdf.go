@@ -0,0 +1,482 @@
+package tools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// maxPDFDecodedSize caps the total bytes inflated from all streams of one
+// PDF, so a file of many small compressed streams cannot expand without bound.
+const maxPDFDecodedSize = maxDocumentFileSize
+
+// extractPDFText is a dependency-free PDF text extractor for simple
+// documents. It inflates unfiltered and FlateDecode streams and collects the
+// strings shown by text operators (Tj, TJ, ', "), decoding them as Latin-1 or
+// UTF-16BE. It does not handle:
+//   - other stream filters (LZW, ASCII85, ...);
+//   - composite (CID) fonts, which most non-Latin and many generated PDFs use;
+//   - simple fonts with a custom encoding or ToUnicode map;
+//   - scanned pages, which hold images rather than text.
+//
+// Rather than return empty or garbled text for such files it fails with an
+// error wrapping errUnsupportedPDF that names the likely cause.
+func extractPDFText(data []byte) (string, error) {
+	if pdfIsEncrypted(data) {
+		return "", errEncryptedDocument
+	}
+	if bytes.Contains(data, []byte("/Identity-H")) || bytes.Contains(data, []byte("/Identity-V")) {
+		return "", fmt.Errorf("%w: text uses composite (CID) fonts, which cannot be decoded", errUnsupportedPDF)
+	}
+
+	var sb strings.Builder
+	streams, skipped := pdfContentStreams(data, maxPDFDecodedSize)
+	for _, content := range streams {
+		if !bytes.Contains(content, []byte("BT")) {
+			continue
+		}
+		extractPDFContentText(content, &sb)
+	}
+	text := collapsePDFWhitespace(sb.String())
+
+	switch {
+	case text == "" && skipped > 0:
+		return "", fmt.Errorf("%w: no text found; %d stream(s) use unsupported compression", errUnsupportedPDF, skipped)
+	case text == "":
+		return "", fmt.Errorf("%w: no text found; the pages may be scanned images", errUnsupportedPDF)
+	case !pdfTextLooksReadable(text):
+		return "", fmt.Errorf("%w: text uses a custom font encoding and would be garbled", errUnsupportedPDF)
+	}
+	return text, nil
+}
+
+// pdfTextLooksReadable reports whether most of text is letters, digits,
+// punctuation or spaces. Strings shown through a custom font encoding decode
+// to seemingly random Latin-1 symbols and control-like characters instead.
+func pdfTextLooksReadable(text string) bool {
+	var readable, total int
+	for _, r := range text {
+		total++
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) ||
+			r < unicode.MaxASCII && unicode.IsPunct(r) {
+			readable++
+		}
+	}
+	return readable*10 >= total*8
+}
+
+// pdfIsEncrypted reports whether the document has an /Encrypt entry in a
+// trailer dictionary or, for PDF 1.5 files, a cross-reference stream
+// dictionary. Other occurrences of the name, such as in metadata or page
+// text, do not count.
+func pdfIsEncrypted(data []byte) bool {
+	for pos := 0; ; {
+		idx := bytes.Index(data[pos:], []byte("trailer"))
+		if idx < 0 {
+			break
+		}
+		pos += idx + len("trailer")
+		if pdfDictHasKey(pdfDictAt(data, pos), "/Encrypt") {
+			return true
+		}
+	}
+
+	for pos := 0; ; {
+		idx := bytes.Index(data[pos:], []byte("/XRef"))
+		if idx < 0 {
+			break
+		}
+		idx += pos
+		pos = idx + len("/XRef")
+		objIdx := bytes.LastIndex(data[max(idx-2048, 0):idx], []byte("obj"))
+		if objIdx < 0 {
+			continue
+		}
+		if pdfDictHasKey(pdfDictAt(data, max(idx-2048, 0)+objIdx), "/Encrypt") {
+			return true
+		}
+	}
+	return false
+}
+
+// pdfDictAt returns the dictionary (<< ... >>) starting at the first "<<"
+// at or after pos, including nested dictionaries.
+func pdfDictAt(data []byte, pos int) []byte {
+	start := bytes.Index(data[pos:], []byte("<<"))
+	if start < 0 {
+		return nil
+	}
+	start += pos
+	depth := 0
+	for i := start; i+1 < len(data); i++ {
+		switch {
+		case data[i] == '<' && data[i+1] == '<':
+			depth++
+			i++
+		case data[i] == '>' && data[i+1] == '>':
+			depth--
+			i++
+			if depth == 0 {
+				return data[start : i+1]
+			}
+		}
+	}
+	return data[start:]
+}
+
+// pdfDictHasKey reports whether dict contains the name key as a whole name,
+// so "/Encrypt" does not match "/EncryptMetadata".
+func pdfDictHasKey(dict []byte, key string) bool {
+	for pos := 0; ; {
+		idx := bytes.Index(dict[pos:], []byte(key))
+		if idx < 0 {
+			return false
+		}
+		end := pos + idx + len(key)
+		if end >= len(dict) || isPDFWhitespace(dict[end]) || isPDFDelimiter(dict[end]) {
+			return true
+		}
+		pos = end
+	}
+}
+
+// pdfContentStreams returns the decoded bodies of all streams that are
+// either unfiltered or FlateDecode-compressed, and the number of non-image
+// streams it could not decode. Image streams are ignored. Decoding stops once
+// budget bytes have been produced.
+func pdfContentStreams(data []byte, budget int) (streams [][]byte, skipped int) {
+	pos := 0
+	for budget > 0 {
+		idx := bytes.Index(data[pos:], []byte("stream"))
+		if idx < 0 {
+			break
+		}
+		idx += pos
+		pos = idx + len("stream")
+
+		// Skip the "endstream" keyword
+		if idx >= 3 && string(data[idx-3:idx]) == "end" {
+			continue
+		}
+
+		start := pos
+		if start < len(data) && data[start] == '\r' {
+			start++
+		}
+		if start < len(data) && data[start] == '\n' {
+			start++
+		}
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		end += start
+		pos = end + len("endstream")
+
+		dict := pdfStreamDict(data, idx)
+		if bytes.Contains(dict, []byte("/Image")) {
+			continue
+		}
+		body := bytes.TrimRight(data[start:end], "\r\n")
+
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			decoded, err := inflatePDFStream(body, budget)
+			if err != nil {
+				skipped++
+				continue
+			}
+			budget -= len(decoded)
+			streams = append(streams, decoded)
+		case bytes.Contains(dict, []byte("/Filter")):
+			// Other filters (LZW, ASCII85, ...) are not supported
+			skipped++
+			continue
+		default:
+			if len(body) > budget {
+				body = body[:budget]
+			}
+			budget -= len(body)
+			streams = append(streams, body)
+		}
+	}
+	return streams, skipped
+}
+
+// pdfStreamDict returns the object dictionary preceding the stream keyword
+// at idx.
+func pdfStreamDict(data []byte, idx int) []byte {
+	from := max(idx-2048, 0)
+	dict := data[from:idx]
+	if objIdx := bytes.LastIndex(dict, []byte("obj")); objIdx >= 0 {
+		dict = dict[objIdx:]
+	}
+	return dict
+}
+
+// inflatePDFStream decompresses a FlateDecode stream body, keeping at most
+// limit bytes.
+func inflatePDFStream(body []byte, limit int) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, int64(limit)))
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+// extractPDFContentText interprets the text-showing operators of a content
+// stream and appends the shown strings to sb.
+func extractPDFContentText(content []byte, sb *strings.Builder) {
+	var (
+		operands []string  // strings since the last operator
+		numbers  []float64 // numbers since the last operator
+		inArray  bool
+	)
+	reset := func() {
+		operands = operands[:0]
+		numbers = numbers[:0]
+	}
+	writeOperands := func() {
+		for _, s := range operands {
+			sb.WriteString(s)
+		}
+	}
+
+	i := 0
+	for i < len(content) {
+		c := content[i]
+		switch {
+		case isPDFWhitespace(c):
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			s, next := readPDFLiteralString(content, i)
+			operands = append(operands, decodePDFString(s))
+			i = next
+		case c == '<' && i+1 < len(content) && content[i+1] == '<':
+			i += 2
+		case c == '>' && i+1 < len(content) && content[i+1] == '>':
+			i += 2
+		case c == '<':
+			s, next := readPDFHexString(content, i)
+			operands = append(operands, decodePDFString(s))
+			i = next
+		case c == '[':
+			inArray = true
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '/':
+			i++
+			for i < len(content) && !isPDFWhitespace(content[i]) && !isPDFDelimiter(content[i]) {
+				i++
+			}
+		default:
+			start := i
+			for i < len(content) && !isPDFWhitespace(content[i]) && !isPDFDelimiter(content[i]) {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			tok := string(content[start:i])
+			if n, err := strconv.ParseFloat(tok, 64); err == nil {
+				// Large negative kerning inside a TJ array marks a word gap
+				if inArray && n < -200 {
+					operands = append(operands, " ")
+				}
+				numbers = append(numbers, n)
+				continue
+			}
+
+			switch tok {
+			case "Tj", "TJ":
+				writeOperands()
+			case "'", "\"":
+				sb.WriteByte('\n')
+				writeOperands()
+			case "T*", "ET":
+				sb.WriteByte('\n')
+			case "Td", "TD":
+				if len(numbers) >= 2 && numbers[len(numbers)-1] != 0 {
+					sb.WriteByte('\n')
+				} else {
+					sb.WriteByte(' ')
+				}
+			case "Tm":
+				sb.WriteByte(' ')
+			}
+			reset()
+		}
+	}
+}
+
+func isPDFWhitespace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0:
+		return true
+	}
+	return false
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// readPDFLiteralString reads a (...) string starting at content[i] and
+// returns its unescaped bytes and the index after the closing parenthesis.
+func readPDFLiteralString(content []byte, i int) ([]byte, int) {
+	var out []byte
+	depth := 0
+	i++ // opening '('
+	for i < len(content) {
+		c := content[i]
+		switch c {
+		case '\\':
+			i++
+			if i >= len(content) {
+				return out, i
+			}
+			e := content[i]
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				// Line continuation
+				if i+1 < len(content) && content[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+				// Line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					n := 0
+					for n < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7' {
+						v = v*8 + int(content[i]-'0')
+						i++
+						n++
+					}
+					out = append(out, byte(v))
+					continue
+				}
+				out = append(out, e)
+			}
+			i++
+		case '(':
+			depth++
+			out = append(out, c)
+			i++
+		case ')':
+			if depth == 0 {
+				return out, i + 1
+			}
+			depth--
+			out = append(out, c)
+			i++
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	return out, i
+}
+
+// readPDFHexString reads a <...> string starting at content[i].
+func readPDFHexString(content []byte, i int) ([]byte, int) {
+	var digits []byte
+	i++ // opening '<'
+	for i < len(content) && content[i] != '>' {
+		if c := content[i]; !isPDFWhitespace(c) {
+			digits = append(digits, c)
+		}
+		i++
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, 0, len(digits)/2)
+	for j := 0; j+1 < len(digits); j += 2 {
+		v, err := strconv.ParseUint(string(digits[j:j+2]), 16, 8)
+		if err != nil {
+			continue
+		}
+		out = append(out, byte(v))
+	}
+	return out, i + 1
+}
+
+// decodePDFString converts PDF string bytes to text, handling UTF-16BE
+// strings with a byte-order mark and treating everything else as Latin-1.
+// Control characters are dropped.
+func decodePDFString(s []byte) string {
+	var runes []rune
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		u := make([]uint16, 0, len(s)/2)
+		for j := 2; j+1 < len(s); j += 2 {
+			u = append(u, uint16(s[j])<<8|uint16(s[j+1]))
+		}
+		runes = utf16.Decode(u)
+	} else {
+		runes = make([]rune, 0, len(s))
+		for _, b := range s {
+			runes = append(runes, rune(b))
+		}
+	}
+
+	var sb strings.Builder
+	for _, r := range runes {
+		if r == '\n' || r == '\t' || unicode.IsPrint(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// collapsePDFWhitespace trims trailing spaces and squeezes runs of blank
+// lines produced by positioning operators.
+func collapsePDFWhitespace(s string) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	blank := 0
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
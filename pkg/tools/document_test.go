@@ -0,0 +1,204 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/media"
+)
+
+// buildTestPDF assembles a minimal PDF whose single page shows lines via
+// a FlateDecode content stream.
+func buildTestPDF(t *testing.T, content string, extraTrailer string) []byte {
+	t.Helper()
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	pdf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	pdf.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n")
+	fmt.Fprintf(&pdf, "trailer\n<< /Root 1 0 R %s>>\n%%%%EOF\n", extraTrailer)
+	return pdf.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	content := "BT /F1 12 Tf 72 720 Td (Quarterly Report) Tj 0 -14 Td " +
+		"[(Revenue ) -300 (grew \\(a lot\\))] TJ T* <48656C6C6F> Tj ET"
+	data := buildTestPDF(t, content, "")
+
+	text, err := extractPDFText(data)
+	if err != nil {
+		t.Fatalf("extractPDFText() error: %v", err)
+	}
+	for _, want := range []string{"Quarterly Report", "Revenue grew (a lot)", "Hello"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("extracted text missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestExtractPDFText_Encrypted(t *testing.T) {
+	data := buildTestPDF(t, "BT (secret) Tj ET", "/Encrypt 5 0 R ")
+	if _, err := extractPDFText(data); !errors.Is(err, errEncryptedDocument) {
+		t.Fatalf("extractPDFText() error = %v, want errEncryptedDocument", err)
+	}
+}
+
+func TestExtractPDFText_Unsupported(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"scanned", buildTestPDF(t, "q 612 0 0 792 0 0 cm /Im1 Do Q", ""), "scanned images"},
+		{"cid font", buildTestPDF(t, "BT <0048> Tj ET", "/Encoding /Identity-H "), "composite (CID) fonts"},
+		{"custom encoding", buildTestPDF(t, "BT <01020304050607A4A7B6> Tj ET", ""), "custom font encoding"},
+		{
+			"lzw stream",
+			[]byte("%PDF-1.4\n1 0 obj\n<< /Length 4 /Filter /LZWDecode >>\nstream\nabcd\nendstream\nendobj\n"),
+			"unsupported compression",
+		},
+	}
+	for _, tc := range cases {
+		text, err := extractPDFText(tc.data)
+		if !errors.Is(err, errUnsupportedPDF) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: extractPDFText() = %q, %v; want an unsupported PDF error mentioning %q",
+				tc.name, text, err, tc.want)
+		}
+	}
+}
+
+func TestPDFIsEncrypted(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"trailer", "trailer\n<< /Root 1 0 R /Encrypt 5 0 R >>\n%%EOF", true},
+		{"nested trailer value", "trailer\n<< /Info << /Title (x) >> /Encrypt 5 0 R >>", true},
+		{
+			"xref stream",
+			"9 0 obj\n<< /Type /XRef /Size 10 /Encrypt 5 0 R /Filter /FlateDecode >>\nstream\nx\nendstream",
+			true,
+		},
+		{"name in page content", "4 0 obj\n<< /Title (About /Encrypt) >>\nendobj\ntrailer\n<< /Root 1 0 R >>", false},
+		{"encrypt metadata flag", "trailer\n<< /Root 1 0 R /EncryptMetadata false >>", false},
+		{"no trailer", "1 0 obj\n<< /Encrypt 5 0 R >>\nendobj", false},
+	} {
+		if got := pdfIsEncrypted([]byte(tc.data)); got != tc.want {
+			t.Errorf("%s: pdfIsEncrypted() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPDFContentStreams_Budget(t *testing.T) {
+	var pdf bytes.Buffer
+	for i := range 5 {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(bytes.Repeat([]byte("A"), 1000))
+		zw.Close()
+		fmt.Fprintf(&pdf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", i+1, compressed.Len())
+		pdf.Write(compressed.Bytes())
+		pdf.WriteString("\nendstream\nendobj\n")
+	}
+
+	streams, _ := pdfContentStreams(pdf.Bytes(), 2500)
+	total := 0
+	for _, s := range streams {
+		total += len(s)
+	}
+	if total != 2500 || len(streams) != 3 {
+		t.Errorf("decoded %d bytes in %d streams, want 2500 bytes in 3", total, len(streams))
+	}
+}
+
+func TestExtractDocumentText_DOCX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(`<?xml version="1.0"?>` +
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		`<w:p><w:r><w:t>First paragraph</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t xml:space="preserve">Second </w:t></w:r><w:r><w:t>paragraph</w:t></w:r></w:p>` +
+		`</w:body></w:document>`))
+	zw.Close()
+
+	text, err := extractDocumentText(buf.Bytes(), "notes.docx", "")
+	if err != nil {
+		t.Fatalf("extractDocumentText() error: %v", err)
+	}
+	if text != "First paragraph\nSecond paragraph\n" {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestExtractDocumentText_CSVAndUnsupported(t *testing.T) {
+	text, err := extractDocumentText([]byte("name,qty\napple,3\n"), "items.csv", "")
+	if err != nil {
+		t.Fatalf("extractDocumentText(csv) error: %v", err)
+	}
+	if text != "name\tqty\napple\t3\n" {
+		t.Errorf("csv text = %q", text)
+	}
+
+	if _, err := extractDocumentText([]byte{0x00, 0x01, 0xFF, 0xFE}, "blob.bin", ""); !errors.Is(
+		err, errUnsupportedDocument) {
+		t.Errorf("binary error = %v, want errUnsupportedDocument", err)
+	}
+}
+
+func TestReadDocumentTool_MediaRefAndTruncation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "long.txt")
+	if err := os.WriteFile(path, []byte(strings.Repeat("a", 100)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := media.NewFileMediaStore()
+	ref, err := store.Store(path, media.MediaMeta{Filename: "long.txt", ContentType: "text/plain"}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tool := NewReadDocumentTool(t.TempDir(), true, 10)
+	tool.SetMediaStore(store)
+
+	// A stored ref that is not attached to the current message is refused
+	if result := tool.Execute(context.Background(), map[string]any{"ref": ref}); !result.IsError {
+		t.Fatalf("expected an error for a ref outside the current message, got %q", result.ForLLM)
+	}
+
+	ctx := WithToolMedia(context.Background(), []string{ref})
+	result := tool.Execute(ctx, map[string]any{"ref": ref})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "long.txt") || !strings.Contains(result.ForLLM, "[TRUNCATED") {
+		t.Errorf("ForLLM = %q, want filename and truncation note", result.ForLLM)
+	}
+
+	if result := tool.Execute(context.Background(), map[string]any{}); !result.IsError {
+		t.Error("expected error for missing ref")
+	}
+}
@@ -0,0 +1,389 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFeedItems     = 10
+	maxFeedItems         = 50
+	feedSummaryMaxChars  = 500
+	feedSinceDateOnlyFmt = "2006-01-02"
+)
+
+// FetchFeedTool downloads an RSS or Atom feed through a WebFetchTool
+// (inheriting its proxy, SSRF guard, size limit and timeout) and returns the
+// most recent entries as JSON.
+type FetchFeedTool struct {
+	fetcher *WebFetchTool
+}
+
+func NewFetchFeedTool(fetcher *WebFetchTool) *FetchFeedTool {
+	return &FetchFeedTool{fetcher: fetcher}
+}
+
+func (t *FetchFeedTool) Name() string {
+	return "fetch_feed"
+}
+
+func (t *FetchFeedTool) ConcurrencySafe() bool {
+	return true
+}
+
+func (t *FetchFeedTool) Pure() bool {
+	return true
+}
+
+func (t *FetchFeedTool) Description() string {
+	return "Fetch an RSS or Atom feed and return its most recent items (title, link, date, summary), newest first. " +
+		"Use this instead of web_fetch for news feeds, blogs and release notes."
+}
+
+func (t *FetchFeedTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"description": "URL of the RSS or Atom feed",
+			},
+			"since": map[string]any{
+				"type":        "string",
+				"description": "Optional: only return items published at or after this time (RFC 3339 or YYYY-MM-DD)",
+			},
+			"max_items": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum number of items to return (default %d, max %d)", defaultFeedItems, maxFeedItems),
+				"minimum":     1.0,
+				"maximum":     float64(maxFeedItems),
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+// feedItem is one entry as returned to the model.
+type feedItem struct {
+	Title     string `json:"title"`
+	Link      string `json:"link,omitempty"`
+	Published string `json:"published,omitempty"`
+	Summary   string `json:"summary,omitempty"`
+
+	published time.Time
+}
+
+type feedResult struct {
+	Feed  string     `json:"feed"`
+	URL   string     `json:"url"`
+	Total int        `json:"total"`
+	Items []feedItem `json:"items"`
+}
+
+func (t *FetchFeedTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	urlStr, ok := args["url"].(string)
+	if !ok || urlStr == "" {
+		return ErrorResult("url is required")
+	}
+
+	var since time.Time
+	if raw, _ := args["since"].(string); raw != "" {
+		parsed, err := parseFeedSince(raw)
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		since = parsed
+	}
+
+	maxItems, err := getInt64Arg(args, "max_items", defaultFeedItems)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	if maxItems < 1 || maxItems > maxFeedItems {
+		return ErrorResult(fmt.Sprintf("max_items must be between 1 and %d", maxFeedItems))
+	}
+
+	if t.fetcher == nil {
+		return ErrorResult("fetch_feed is not configured")
+	}
+
+	resp, body, err := t.fetcher.download(ctx, urlStr)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return ErrorResult(fmt.Sprintf("failed to fetch feed %s: status %d", urlStr, resp.StatusCode))
+	}
+
+	title, items, err := parseFeed(body)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to parse feed %s: %v", urlStr, err))
+	}
+	total := len(items)
+
+	// Newest first; undated entries keep their feed order after the dated ones.
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[j].published.IsZero() {
+			return !items[i].published.IsZero()
+		}
+		return items[i].published.After(items[j].published)
+	})
+
+	if !since.IsZero() {
+		kept := items[:0]
+		for _, item := range items {
+			if !item.published.IsZero() && !item.published.Before(since) {
+				kept = append(kept, item)
+			}
+		}
+		items = kept
+	}
+	if len(items) > int(maxItems) {
+		items = items[:maxItems]
+	}
+
+	out, err := json.MarshalIndent(feedResult{
+		Feed:  title,
+		URL:   urlStr,
+		Total: total,
+		Items: items,
+	}, "", "  ")
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to encode feed items: %v", err))
+	}
+
+	return &ToolResult{
+		ForLLM:  string(out),
+		ForUser: fmt.Sprintf("Fetched %d of %d items from %s", len(items), total, urlStr),
+	}
+}
+
+func parseFeedSince(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+		return ts, nil
+	}
+	if ts, err := time.Parse(feedSinceDateOnlyFmt, raw); err == nil {
+		return ts, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since %q: use RFC 3339 (2006-01-02T15:04:05Z) or YYYY-MM-DD", raw)
+}
+
+// rssDocument covers RSS 2.0 (<rss><channel><item>) and RSS 1.0 / RDF
+// (<rdf:RDF><channel/><item>), which differ only in where items live.
+type rssDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"` // dc:date
+	Description string `xml:"description"`
+	Encoded     string `xml:"encoded"` // content:encoded
+}
+
+type atomDocument struct {
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	ID        string     `xml:"id"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// parseFeed detects the feed format from the root element and returns the
+// feed title and its entries in document order.
+func parseFeed(body []byte) (string, []feedItem, error) {
+	root, err := feedRootElement(body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch root {
+	case "rss", "RDF":
+		var doc rssDocument
+		if err := newFeedDecoder(body).Decode(&doc); err != nil {
+			return "", nil, err
+		}
+		raw := append(doc.Channel.Items, doc.Items...)
+		items := make([]feedItem, 0, len(raw))
+		for _, it := range raw {
+			link := strings.TrimSpace(it.Link)
+			if link == "" && strings.HasPrefix(strings.TrimSpace(it.GUID), "http") {
+				link = strings.TrimSpace(it.GUID)
+			}
+			items = append(items, newFeedItem(it.Title, link, firstNonEmpty(it.PubDate, it.Date),
+				firstNonEmpty(it.Description, it.Encoded)))
+		}
+		return cleanFeedText(doc.Channel.Title), items, nil
+
+	case "feed":
+		var doc atomDocument
+		if err := newFeedDecoder(body).Decode(&doc); err != nil {
+			return "", nil, err
+		}
+		items := make([]feedItem, 0, len(doc.Entries))
+		for _, e := range doc.Entries {
+			items = append(items, newFeedItem(e.Title, atomEntryLink(e.Links), firstNonEmpty(e.Published, e.Updated),
+				firstNonEmpty(e.Summary, e.Content)))
+		}
+		return cleanFeedText(doc.Title), items, nil
+
+	default:
+		return "", nil, fmt.Errorf("not an RSS or Atom feed (root element <%s>)", root)
+	}
+}
+
+func feedRootElement(body []byte) (string, error) {
+	dec := newFeedDecoder(body)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", errors.New("empty document")
+			}
+			return "", err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func newFeedDecoder(body []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	dec.CharsetReader = feedCharsetReader
+	return dec
+}
+
+// feedCharsetReader accepts the non-UTF-8 encodings still common in older
+// feeds. Latin-1 maps byte-for-byte onto the first 256 code points.
+func feedCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		return latin1Reader{bufio.NewReader(input)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported feed charset %q", charset)
+	}
+}
+
+type latin1Reader struct {
+	r io.ByteReader
+}
+
+func (l latin1Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n+1 < len(p) {
+		b, err := l.r.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], string(rune(b)))
+	}
+	return n, nil
+}
+
+func atomEntryLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	if len(links) > 0 {
+		return strings.TrimSpace(links[0].Href)
+	}
+	return ""
+}
+
+func newFeedItem(title, link, date, summary string) feedItem {
+	item := feedItem{
+		Title:   cleanFeedText(title),
+		Link:    link,
+		Summary: cleanFeedText(summary),
+	}
+	if len(item.Summary) > feedSummaryMaxChars {
+		item.Summary = strings.ToValidUTF8(item.Summary[:feedSummaryMaxChars], "") + "..."
+	}
+	if ts, ok := parseFeedDate(date); ok {
+		item.published = ts
+		item.Published = ts.UTC().Format(time.RFC3339)
+	} else {
+		item.Published = strings.TrimSpace(date)
+	}
+	return item
+}
+
+var feedDateLayouts = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parseFeedDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range feedDateLayouts {
+		if ts, err := time.Parse(layout, s); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// cleanFeedText turns the HTML fragments feeds carry in titles and summaries
+// into plain single-line text.
+func cleanFeedText(s string) string {
+	s = html.UnescapeString(stripTags(s))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,229 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+  <title>Example &amp; News</title>
+  <item>
+    <title>Older post</title>
+    <link>https://example.com/older</link>
+    <pubDate>Mon, 02 Mar 2026 09:00:00 +0000</pubDate>
+    <description>Older &lt;b&gt;news&lt;/b&gt;</description>
+  </item>
+  <item>
+    <title>Newest post</title>
+    <guid>https://example.com/newest</guid>
+    <pubDate>Fri, 13 Mar 2026 10:30:00 GMT</pubDate>
+    <description><![CDATA[<p>Release   1.2 ships</p>]]></description>
+  </item>
+  <item>
+    <title>Middle post</title>
+    <link>https://example.com/middle</link>
+    <pubDate>Sat, 7 Mar 2026 12:00:00 +0100</pubDate>
+  </item>
+  <item>
+    <title>Undated post</title>
+    <link>https://example.com/undated</link>
+  </item>
+</channel>
+</rss>`
+
+const testAtomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example Atom</title>
+  <entry>
+    <title type="html">First &amp;lt;entry&amp;gt;</title>
+    <link rel="edit" href="https://example.com/edit/1"/>
+    <link rel="alternate" href="https://example.com/1"/>
+    <id>urn:1</id>
+    <updated>2026-03-10T08:00:00Z</updated>
+    <summary>First summary</summary>
+  </entry>
+  <entry>
+    <title>Second entry</title>
+    <link href="https://example.com/2"/>
+    <id>urn:2</id>
+    <published>2026-03-12T08:00:00+02:00</published>
+    <content type="html">&lt;p&gt;Second content&lt;/p&gt;</content>
+  </entry>
+</feed>`
+
+func newFeedServer(t *testing.T, contentType, body string) *httptest.Server {
+	t.Helper()
+	withPrivateWebFetchHostsAllowed(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestFetchFeedTool(t *testing.T) *FetchFeedTool {
+	t.Helper()
+	fetcher, err := NewWebFetchTool(50000, format, testFetchLimit)
+	if err != nil {
+		t.Fatalf("Failed to create web fetch tool: %v", err)
+	}
+	return NewFetchFeedTool(fetcher)
+}
+
+func decodeFeedResult(t *testing.T, result *ToolResult) feedResult {
+	t.Helper()
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	var out feedResult
+	if err := json.Unmarshal([]byte(result.ForLLM), &out); err != nil {
+		t.Fatalf("result is not JSON: %v\n%s", err, result.ForLLM)
+	}
+	return out
+}
+
+func TestFetchFeedTool_RSS(t *testing.T) {
+	server := newFeedServer(t, "application/rss+xml", testRSSFeed)
+	tool := newTestFetchFeedTool(t)
+
+	out := decodeFeedResult(t, tool.Execute(context.Background(), map[string]any{"url": server.URL}))
+
+	if out.Feed != "Example & News" {
+		t.Errorf("feed title = %q", out.Feed)
+	}
+	if out.Total != 4 || len(out.Items) != 4 {
+		t.Fatalf("expected 4 items, got total=%d items=%d", out.Total, len(out.Items))
+	}
+
+	wantOrder := []string{"Newest post", "Middle post", "Older post", "Undated post"}
+	for i, title := range wantOrder {
+		if out.Items[i].Title != title {
+			t.Errorf("item %d = %q, want %q", i, out.Items[i].Title, title)
+		}
+	}
+
+	newest := out.Items[0]
+	if newest.Link != "https://example.com/newest" {
+		t.Errorf("expected guid to be used as link, got %q", newest.Link)
+	}
+	if newest.Published != "2026-03-13T10:30:00Z" {
+		t.Errorf("published = %q", newest.Published)
+	}
+	if newest.Summary != "Release 1.2 ships" {
+		t.Errorf("summary = %q", newest.Summary)
+	}
+	if out.Items[2].Summary != "Older news" {
+		t.Errorf("expected escaped HTML to be stripped, got %q", out.Items[2].Summary)
+	}
+	if out.Items[1].Published != "2026-03-07T11:00:00Z" {
+		t.Errorf("expected dates normalized to UTC, got %q", out.Items[1].Published)
+	}
+}
+
+func TestFetchFeedTool_Atom(t *testing.T) {
+	server := newFeedServer(t, "application/atom+xml", testAtomFeed)
+	tool := newTestFetchFeedTool(t)
+
+	out := decodeFeedResult(t, tool.Execute(context.Background(), map[string]any{"url": server.URL}))
+
+	if out.Feed != "Example Atom" || len(out.Items) != 2 {
+		t.Fatalf("unexpected feed: %+v", out)
+	}
+	second, first := out.Items[0], out.Items[1]
+	if second.Title != "Second entry" || second.Link != "https://example.com/2" ||
+		second.Published != "2026-03-12T06:00:00Z" || second.Summary != "Second content" {
+		t.Errorf("unexpected newest entry: %+v", second)
+	}
+	if first.Title != "First <entry>" || first.Link != "https://example.com/1" {
+		t.Errorf("expected alternate link and unescaped title, got %+v", first)
+	}
+	if first.Published != "2026-03-10T08:00:00Z" {
+		t.Errorf("expected updated to stand in for published, got %q", first.Published)
+	}
+}
+
+func TestFetchFeedTool_SinceAndMaxItems(t *testing.T) {
+	server := newFeedServer(t, "application/rss+xml", testRSSFeed)
+	tool := newTestFetchFeedTool(t)
+
+	out := decodeFeedResult(t, tool.Execute(context.Background(), map[string]any{
+		"url":   server.URL,
+		"since": "2026-03-05",
+	}))
+	if len(out.Items) != 2 || out.Items[0].Title != "Newest post" || out.Items[1].Title != "Middle post" {
+		t.Errorf("since filter kept wrong items: %+v", out.Items)
+	}
+	if out.Total != 4 {
+		t.Errorf("total should count every entry in the feed, got %d", out.Total)
+	}
+
+	out = decodeFeedResult(t, tool.Execute(context.Background(), map[string]any{
+		"url":       server.URL,
+		"since":     "2026-03-07T11:00:00Z",
+		"max_items": float64(1),
+	}))
+	if len(out.Items) != 1 || out.Items[0].Title != "Newest post" {
+		t.Errorf("max_items not applied: %+v", out.Items)
+	}
+}
+
+func TestFetchFeedTool_InvalidArguments(t *testing.T) {
+	tool := newTestFetchFeedTool(t)
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"missing url", map[string]any{}, "url is required"},
+		{"bad since", map[string]any{"url": "https://example.com/feed", "since": "last week"}, "invalid since"},
+		{"zero max_items", map[string]any{"url": "https://example.com/feed", "max_items": float64(0)}, "max_items"},
+		{"too many items", map[string]any{"url": "https://example.com/feed", "max_items": float64(500)}, "max_items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tool.Execute(context.Background(), tt.args)
+			if !result.IsError || !strings.Contains(result.ForLLM, tt.want) {
+				t.Errorf("expected error containing %q, got %+v", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestFetchFeedTool_NotAFeed(t *testing.T) {
+	server := newFeedServer(t, "text/html", "<html><body>hello</body></html>")
+	tool := newTestFetchFeedTool(t)
+
+	result := tool.Execute(context.Background(), map[string]any{"url": server.URL})
+	if !result.IsError || !strings.Contains(result.ForLLM, "not an RSS or Atom feed") {
+		t.Errorf("expected non-feed error, got %+v", result)
+	}
+}
+
+func TestFetchFeedTool_Latin1(t *testing.T) {
+	feed := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>" +
+		"<rss><channel><title>Caf\xe9</title><item><title>Cr\xe8me</title></item></channel></rss>"
+	server := newFeedServer(t, "application/rss+xml", feed)
+	tool := newTestFetchFeedTool(t)
+
+	out := decodeFeedResult(t, tool.Execute(context.Background(), map[string]any{"url": server.URL}))
+	if out.Feed != "Café" || len(out.Items) != 1 || out.Items[0].Title != "Crème" {
+		t.Errorf("latin-1 feed decoded incorrectly: %+v", out)
+	}
+}
+
+func TestFetchFeedTool_BlocksPrivateHosts(t *testing.T) {
+	tool := newTestFetchFeedTool(t)
+
+	result := tool.Execute(context.Background(), map[string]any{"url": "http://127.0.0.1:8080/feed.xml"})
+	if !result.IsError {
+		t.Fatal("expected private host to be rejected")
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/sipeed/picoclaw/pkg/fileutil"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/media"
 )
 
 const MaxReadFileSize = 64 * 1024 // 64KB limit to avoid context overflow
@@ -249,8 +250,9 @@ func isWithinWorkspace(candidate, workspace string) bool {
 }
 
 type ReadFileTool struct {
-	fs      fileSystem
-	maxSize int64
+	fs         fileSystem
+	maxSize    int64
+	mediaStore media.MediaStore
 }
 
 func NewReadFileTool(
@@ -279,6 +281,16 @@ func (t *ReadFileTool) Name() string {
 	return "read_file"
 }
 
+func (t *ReadFileTool) ConcurrencySafe() bool {
+	return true
+}
+
+// SetMediaStore enables reading the current message's attachments by their
+// media:// ref.
+func (t *ReadFileTool) SetMediaStore(store media.MediaStore) {
+	t.mediaStore = store
+}
+
 func (t *ReadFileTool) Description() string {
 	return "Read the contents of a file. Supports pagination via `offset` and `length`."
 }
@@ -289,7 +301,7 @@ func (t *ReadFileTool) Parameters() map[string]any {
 		"properties": map[string]any{
 			"path": map[string]any{
 				"type":        "string",
-				"description": "Path to the file to read.",
+				"description": "Path to the file to read, or a media:// ref of a user attachment.",
 			},
 			"offset": map[string]any{
 				"type":        "integer",
@@ -333,6 +345,14 @@ func (t *ReadFileTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 		length = t.maxSize
 	}
 
+	if strings.HasPrefix(path, "media://") {
+		localPath, _, err := resolveAttachment(ctx, t.mediaStore, path)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to resolve %s: %v", path, err))
+		}
+		path = localPath
+	}
+
 	file, err := t.fs.Open(path)
 	if err != nil {
 		return ErrorResult(err.Error())
@@ -550,6 +570,10 @@ func (t *ListDirTool) Name() string {
 	return "list_dir"
 }
 
+func (t *ListDirTool) ConcurrencySafe() bool {
+	return true
+}
+
 func (t *ListDirTool) Description() string {
 	return "List files and directories in a path"
 }
@@ -288,6 +288,45 @@ func TestFilesystemTool_ReadFile_RejectsSymlinkEscape(t *testing.T) {
 	}
 }
 
+func TestFilesystemTool_Restricted_RejectsPathTraversal(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, "notes.txt"), []byte("in workspace"), 0o644); err != nil {
+		t.Fatalf("failed to write workspace file: %v", err)
+	}
+
+	read := NewReadFileTool(workspace, true, MaxReadFileSize)
+	result := read.Execute(context.Background(), map[string]any{"path": "notes.txt"})
+	if result.IsError {
+		t.Fatalf("expected in-workspace read to succeed, got: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "in workspace") {
+		t.Fatalf("expected file content, got: %s", result.ForLLM)
+	}
+
+	result = read.Execute(context.Background(), map[string]any{"path": "../../etc/passwd"})
+	if !result.IsError {
+		t.Fatalf("expected traversal read to be denied, got: %s", result.ForLLM)
+	}
+
+	write := NewWriteFileTool(workspace, true)
+	result = write.Execute(context.Background(), map[string]any{
+		"path":    "../escaped.txt",
+		"content": "should not be written",
+	})
+	if !result.IsError {
+		t.Fatalf("expected traversal write to be denied")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(workspace), "escaped.txt")); !os.IsNotExist(err) {
+		t.Fatalf("traversal write created a file outside the workspace: %v", err)
+	}
+
+	list := NewListDirTool(workspace, true)
+	result = list.Execute(context.Background(), map[string]any{"path": ".."})
+	if !result.IsError {
+		t.Fatalf("expected traversal list to be denied, got: %s", result.ForLLM)
+	}
+}
+
 func TestFilesystemTool_EmptyWorkspace_AccessDenied(t *testing.T) {
 	tool := NewReadFileTool("", true, MaxReadFileSize) // restrict=true but workspace=""
 
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+)
+
+// GPIOTool provides GPIO pin access for reading inputs and driving outputs.
+type GPIOTool struct {
+	sysfsRoot string
+}
+
+func NewGPIOTool() *GPIOTool {
+	return &GPIOTool{sysfsRoot: "/sys/class/gpio"}
+}
+
+func (t *GPIOTool) Name() string {
+	return "gpio"
+}
+
+func (t *GPIOTool) Description() string {
+	return "Read and drive GPIO pins through the Linux sysfs interface. Actions: list (find GPIO chips and exported pins), read (read a pin value), write (set an output pin high or low), set_mode (configure a pin as input or output). Linux only."
+}
+
+func (t *GPIOTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"enum":        []string{"list", "read", "write", "set_mode"},
+				"description": "Action to perform: list (find GPIO chips and exported pins), read (read a pin value), write (set an output pin), set_mode (configure pin direction)",
+			},
+			"pin": map[string]any{
+				"type":        "integer",
+				"description": "Global GPIO number as used by /sys/class/gpio (e.g. 17 for gpio17). Required for read/write/set_mode.",
+			},
+			"value": map[string]any{
+				"type":        "integer",
+				"enum":        []int{0, 1},
+				"description": "Output level: 0 (low) or 1 (high). Required for write action.",
+			},
+			"mode": map[string]any{
+				"type":        "string",
+				"enum":        []string{"in", "out"},
+				"description": "Pin direction. Required for set_mode action.",
+			},
+			"confirm": map[string]any{
+				"type":        "boolean",
+				"description": "Must be true for write and set_mode operations. Safety guard to prevent accidental output changes.",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *GPIOTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	if runtime.GOOS != "linux" {
+		return ErrorResult("GPIO is only supported on Linux. This tool requires the /sys/class/gpio interface.")
+	}
+
+	action, ok := args["action"].(string)
+	if !ok {
+		return ErrorResult("action is required")
+	}
+
+	switch action {
+	case "list":
+		return t.list()
+	case "read":
+		return t.readPin(args)
+	case "write":
+		return t.writePin(args)
+	case "set_mode":
+		return t.setMode(args)
+	default:
+		return ErrorResult(fmt.Sprintf("unknown action: %s (valid: list, read, write, set_mode)", action))
+	}
+}
+
+// list finds GPIO chips and exported pins under the sysfs root
+func (t *GPIOTool) list() *ToolResult {
+	chips, err := filepath.Glob(filepath.Join(t.sysfsRoot, "gpiochip*"))
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to scan for GPIO chips: %v", err))
+	}
+	pins, err := filepath.Glob(filepath.Join(t.sysfsRoot, "gpio[0-9]*"))
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to scan for exported GPIO pins: %v", err))
+	}
+
+	if len(chips) == 0 {
+		return SilentResult(
+			"No GPIO chips found. You may need to:\n1. Enable the sysfs GPIO interface (CONFIG_GPIO_SYSFS)\n2. Configure pinmux for your board (see hardware skill)",
+		)
+	}
+
+	type gpioInfo struct {
+		Chips    []string `json:"chips"`
+		Exported []string `json:"exported"`
+	}
+
+	info := gpioInfo{Chips: make([]string, 0, len(chips)), Exported: make([]string, 0, len(pins))}
+	for _, c := range chips {
+		info.Chips = append(info.Chips, filepath.Base(c))
+	}
+	for _, p := range pins {
+		info.Exported = append(info.Exported, filepath.Base(p))
+	}
+
+	result, _ := json.MarshalIndent(info, "", "  ")
+	return SilentResult(fmt.Sprintf("Found %d GPIO chip(s):\n%s", len(info.Chips), string(result)))
+}
+
+// Helper functions for GPIO operations (used by platform-specific implementations)
+
+// parseGPIOPin extracts and validates a GPIO pin number from args
+//
+//nolint:unused // Used by gpio_linux.go
+func parseGPIOPin(args map[string]any) (int, *ToolResult) {
+	pinFloat, ok := args["pin"].(float64)
+	if !ok {
+		return 0, ErrorResult("pin is required (e.g. 17 for gpio17)")
+	}
+	pin := int(pinFloat)
+	if float64(pin) != pinFloat || pin < 0 || pin > 4095 {
+		return 0, ErrorResult("pin must be an integer between 0 and 4095")
+	}
+	return pin, nil
+}
+
+// parseGPIOMode extracts and validates a pin direction from args
+//
+//nolint:unused // Used by gpio_linux.go
+func parseGPIOMode(args map[string]any) (string, *ToolResult) {
+	mode, _ := args["mode"].(string)
+	if mode != "in" && mode != "out" {
+		return "", ErrorResult("mode is required and must be \"in\" or \"out\"")
+	}
+	return mode, nil
+}
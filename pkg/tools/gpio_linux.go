@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// pinDir returns the sysfs directory for a pin, exporting it first if needed
+func (t *GPIOTool) pinDir(pin int) (string, *ToolResult) {
+	dir := filepath.Join(t.sysfsRoot, fmt.Sprintf("gpio%d", pin))
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+
+	exportPath := filepath.Join(t.sysfsRoot, "export")
+	if err := os.WriteFile(exportPath, []byte(strconv.Itoa(pin)), 0o200); err != nil {
+		return "", ErrorResult(fmt.Sprintf("failed to export gpio%d: %v (check the pin number and permissions)", pin, err))
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return "", ErrorResult(fmt.Sprintf("gpio%d was exported but %s is not available: %v", pin, dir, err))
+	}
+	return dir, nil
+}
+
+// readPin reads the current level and direction of a pin
+func (t *GPIOTool) readPin(args map[string]any) *ToolResult {
+	pin, errResult := parseGPIOPin(args)
+	if errResult != nil {
+		return errResult
+	}
+
+	dir, errResult := t.pinDir(pin)
+	if errResult != nil {
+		return errResult
+	}
+
+	value, err := os.ReadFile(filepath.Join(dir, "value"))
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read gpio%d: %v", pin, err))
+	}
+	direction, err := os.ReadFile(filepath.Join(dir, "direction"))
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read gpio%d direction: %v", pin, err))
+	}
+
+	return SilentResult(fmt.Sprintf("gpio%d: value=%s mode=%s",
+		pin, strings.TrimSpace(string(value)), strings.TrimSpace(string(direction))))
+}
+
+// writePin drives an output pin high or low
+func (t *GPIOTool) writePin(args map[string]any) *ToolResult {
+	confirm, _ := args["confirm"].(bool)
+	if !confirm {
+		return ErrorResult(
+			"write operations require confirm: true. Please confirm with the user before driving GPIO pins, as changing outputs can affect connected hardware.",
+		)
+	}
+
+	pin, errResult := parseGPIOPin(args)
+	if errResult != nil {
+		return errResult
+	}
+	value, ok := args["value"].(float64)
+	if !ok || (value != 0 && value != 1) {
+		return ErrorResult("value is required and must be 0 or 1")
+	}
+
+	dir, errResult := t.pinDir(pin)
+	if errResult != nil {
+		return errResult
+	}
+
+	direction, err := os.ReadFile(filepath.Join(dir, "direction"))
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to read gpio%d direction: %v", pin, err))
+	}
+	if strings.TrimSpace(string(direction)) != "out" {
+		return ErrorResult(fmt.Sprintf("gpio%d is not an output. Use set_mode with mode \"out\" first.", pin))
+	}
+
+	level := strconv.Itoa(int(value))
+	if err := os.WriteFile(filepath.Join(dir, "value"), []byte(level), 0o200); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to write gpio%d: %v", pin, err))
+	}
+
+	return SilentResult(fmt.Sprintf("gpio%d set to %s", pin, level))
+}
+
+// setMode configures a pin as input or output
+func (t *GPIOTool) setMode(args map[string]any) *ToolResult {
+	confirm, _ := args["confirm"].(bool)
+	if !confirm {
+		return ErrorResult(
+			"set_mode requires confirm: true. Please confirm with the user before reconfiguring GPIO pins, as switching a pin to output can damage connected hardware.",
+		)
+	}
+
+	pin, errResult := parseGPIOPin(args)
+	if errResult != nil {
+		return errResult
+	}
+	mode, errResult := parseGPIOMode(args)
+	if errResult != nil {
+		return errResult
+	}
+
+	dir, errResult := t.pinDir(pin)
+	if errResult != nil {
+		return errResult
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "direction"), []byte(mode), 0o200); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to set gpio%d mode: %v", pin, err))
+	}
+
+	return SilentResult(fmt.Sprintf("gpio%d mode set to %s", pin, mode))
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestGPIOTool(t *testing.T, pin string) (*GPIOTool, string) {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, "gpio"+pin)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("failed to create pin dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "value"), []byte("0\n"), 0o644); err != nil {
+		t.Fatalf("failed to write value: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "direction"), []byte("in\n"), 0o644); err != nil {
+		t.Fatalf("failed to write direction: %v", err)
+	}
+	return &GPIOTool{sysfsRoot: root}, dir
+}
+
+func TestGPIOTool_ReadWriteSetMode(t *testing.T) {
+	tool, dir := newTestGPIOTool(t, "17")
+	ctx := context.Background()
+
+	result := tool.Execute(ctx, map[string]any{"action": "read", "pin": float64(17)})
+	if result.IsError || !strings.Contains(result.ForLLM, "value=0 mode=in") {
+		t.Fatalf("unexpected read result: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]any{"action": "write", "pin": float64(17), "value": float64(1), "confirm": true})
+	if !result.IsError || !strings.Contains(result.ForLLM, "not an output") {
+		t.Fatalf("expected write to an input pin to fail, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]any{"action": "set_mode", "pin": float64(17), "mode": "out"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "confirm") {
+		t.Fatalf("expected set_mode without confirm to fail, got: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]any{"action": "set_mode", "pin": float64(17), "mode": "out", "confirm": true})
+	if result.IsError {
+		t.Fatalf("set_mode failed: %s", result.ForLLM)
+	}
+
+	result = tool.Execute(ctx, map[string]any{"action": "write", "pin": float64(17), "value": float64(1), "confirm": true})
+	if result.IsError {
+		t.Fatalf("write failed: %s", result.ForLLM)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "value")); string(data) != "1" {
+		t.Errorf("value file = %q, want %q", data, "1")
+	}
+}
+
+func TestGPIOTool_InvalidArgs(t *testing.T) {
+	tool, _ := newTestGPIOTool(t, "17")
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"missing pin", map[string]any{"action": "read"}, "pin is required"},
+		{"negative pin", map[string]any{"action": "read", "pin": float64(-1)}, "between 0 and 4095"},
+		{"bad mode", map[string]any{"action": "set_mode", "pin": float64(17), "mode": "pwm", "confirm": true}, "\"in\" or \"out\""},
+		{"bad value", map[string]any{"action": "write", "pin": float64(17), "value": float64(2), "confirm": true}, "0 or 1"},
+		{"unknown action", map[string]any{"action": "toggle"}, "unknown action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tool.Execute(ctx, tt.args)
+			if !result.IsError || !strings.Contains(result.ForLLM, tt.want) {
+				t.Errorf("expected error containing %q, got: %s", tt.want, result.ForLLM)
+			}
+		})
+	}
+}
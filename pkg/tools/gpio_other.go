@@ -0,0 +1,18 @@
+//go:build !linux
+
+package tools
+
+// readPin is a stub for non-Linux platforms.
+func (t *GPIOTool) readPin(args map[string]any) *ToolResult {
+	return ErrorResult("GPIO is only supported on Linux")
+}
+
+// writePin is a stub for non-Linux platforms.
+func (t *GPIOTool) writePin(args map[string]any) *ToolResult {
+	return ErrorResult("GPIO is only supported on Linux")
+}
+
+// setMode is a stub for non-Linux platforms.
+func (t *GPIOTool) setMode(args map[string]any) *ToolResult {
+	return ErrorResult("GPIO is only supported on Linux")
+}
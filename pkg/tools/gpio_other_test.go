@@ -0,0 +1,34 @@
+//go:build !linux
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGPIOTool_NotSupported(t *testing.T) {
+	tool := NewGPIOTool()
+
+	for _, action := range []string{"list", "read", "write", "set_mode"} {
+		result := tool.Execute(context.Background(), map[string]any{"action": action, "pin": float64(17)})
+		if !result.IsError {
+			t.Fatalf("%s: expected an error on non-Linux platforms", action)
+		}
+		if !strings.Contains(result.ForLLM, "only supported on Linux") {
+			t.Errorf("%s: unexpected error: %s", action, result.ForLLM)
+		}
+	}
+
+	stubs := map[string]func(map[string]any) *ToolResult{
+		"readPin":  tool.readPin,
+		"writePin": tool.writePin,
+		"setMode":  tool.setMode,
+	}
+	for name, stub := range stubs {
+		if result := stub(nil); !result.IsError || !strings.Contains(result.ForLLM, "only supported on Linux") {
+			t.Errorf("%s: expected not supported error, got: %s", name, result.ForLLM)
+		}
+	}
+}
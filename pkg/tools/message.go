@@ -3,10 +3,20 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync/atomic"
 )
 
-type SendCallback func(channel, chatID, content string) error
+type SendCallback func(channel, chatID, content string, opts SendOptions) error
+
+// SendOptions carries optional per-message delivery hints for SendCallback.
+type SendOptions struct {
+	// DisablePreview asks the channel not to unfurl links in the message.
+	DisablePreview bool
+	// Choices are options the user can pick from. Channels with buttons render
+	// them natively; others list them as numbered text.
+	Choices []string
+}
 
 type MessageTool struct {
 	sendCallback SendCallback
@@ -41,6 +51,15 @@ func (t *MessageTool) Parameters() map[string]any {
 				"type":        "string",
 				"description": "Optional: target chat/user ID",
 			},
+			"disable_preview": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: suppress link previews/unfurling on channels that support it",
+			},
+			"choices": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional: short options for the user to pick from (e.g. [\"Yes\", \"No\"]). Shown as buttons where supported; the pick arrives as the user's next message",
+			},
 		},
 		"required": []string{"content"},
 	}
@@ -69,6 +88,15 @@ func (t *MessageTool) Execute(ctx context.Context, args map[string]any) *ToolRes
 
 	channel, _ := args["channel"].(string)
 	chatID, _ := args["chat_id"].(string)
+	disablePreview, _ := args["disable_preview"].(bool)
+	var choices []string
+	if raw, ok := args["choices"].([]any); ok {
+		for _, item := range raw {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				choices = append(choices, strings.TrimSpace(s))
+			}
+		}
+	}
 
 	if channel == "" {
 		channel = ToolChannel(ctx)
@@ -85,7 +113,10 @@ func (t *MessageTool) Execute(ctx context.Context, args map[string]any) *ToolRes
 		return &ToolResult{ForLLM: "Message sending not configured", IsError: true}
 	}
 
-	if err := t.sendCallback(channel, chatID, content); err != nil {
+	if err := t.sendCallback(channel, chatID, content, SendOptions{
+		DisablePreview: disablePreview,
+		Choices:        choices,
+	}); err != nil {
 		return &ToolResult{
 			ForLLM:  fmt.Sprintf("sending message: %v", err),
 			IsError: true,
@@ -10,7 +10,7 @@ func TestMessageTool_Execute_Success(t *testing.T) {
 	tool := NewMessageTool()
 
 	var sentChannel, sentChatID, sentContent string
-	tool.SetSendCallback(func(channel, chatID, content string) error {
+	tool.SetSendCallback(func(channel, chatID, content string, opts SendOptions) error {
 		sentChannel = channel
 		sentChatID = chatID
 		sentContent = content
@@ -61,7 +61,7 @@ func TestMessageTool_Execute_WithCustomChannel(t *testing.T) {
 	tool := NewMessageTool()
 
 	var sentChannel, sentChatID string
-	tool.SetSendCallback(func(channel, chatID, content string) error {
+	tool.SetSendCallback(func(channel, chatID, content string, opts SendOptions) error {
 		sentChannel = channel
 		sentChatID = chatID
 		return nil
@@ -96,7 +96,7 @@ func TestMessageTool_Execute_SendFailure(t *testing.T) {
 	tool := NewMessageTool()
 
 	sendErr := errors.New("network error")
-	tool.SetSendCallback(func(channel, chatID, content string) error {
+	tool.SetSendCallback(func(channel, chatID, content string, opts SendOptions) error {
 		return sendErr
 	})
 
@@ -149,7 +149,7 @@ func TestMessageTool_Execute_NoTargetChannel(t *testing.T) {
 	tool := NewMessageTool()
 	// No WithToolContext — channel/chatID are empty
 
-	tool.SetSendCallback(func(channel, chatID, content string) error {
+	tool.SetSendCallback(func(channel, chatID, content string, opts SendOptions) error {
 		return nil
 	})
 
@@ -252,3 +252,47 @@ func TestMessageTool_Parameters(t *testing.T) {
 		t.Error("Expected chat_id type to be 'string'")
 	}
 }
+
+func TestMessageTool_Execute_DisablePreview(t *testing.T) {
+	tool := NewMessageTool()
+
+	var got SendOptions
+	tool.SetSendCallback(func(channel, chatID, content string, opts SendOptions) error {
+		got = opts
+		return nil
+	})
+
+	ctx := WithToolContext(context.Background(), "telegram", "123")
+	result := tool.Execute(ctx, map[string]any{
+		"content":         "see https://example.com",
+		"disable_preview": true,
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if !got.DisablePreview {
+		t.Error("expected DisablePreview to be passed to the send callback")
+	}
+}
+
+func TestMessageTool_Execute_Choices(t *testing.T) {
+	tool := NewMessageTool()
+
+	var got SendOptions
+	tool.SetSendCallback(func(channel, chatID, content string, opts SendOptions) error {
+		got = opts
+		return nil
+	})
+
+	ctx := WithToolContext(context.Background(), "discord", "123")
+	result := tool.Execute(ctx, map[string]any{
+		"content": "Deploy now?",
+		"choices": []any{"Yes", " No ", "", 42},
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if len(got.Choices) != 2 || got.Choices[0] != "Yes" || got.Choices[1] != "No" {
+		t.Errorf("expected choices [Yes No], got %v", got.Choices)
+	}
+}
@@ -0,0 +1,23 @@
+package tools
+
+import "sync"
+
+// RunCalls runs fn for each of n tool calls. Consecutive calls for which safe
+// returns true run in parallel; any other call waits for all earlier calls to
+// finish and runs alone, so side effects happen in the order the model asked.
+func RunCalls(n int, safe func(i int) bool, fn func(i int)) {
+	var wg sync.WaitGroup
+	for i := range n {
+		if safe(i) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fn(i)
+			}()
+			continue
+		}
+		wg.Wait()
+		fn(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunCalls_SafeCallsOverlap(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(2)
+	done := make(chan struct{})
+
+	go func() {
+		RunCalls(2, func(int) bool { return true }, func(int) {
+			// Each call waits for the other to start, so this only
+			// finishes when both run at the same time.
+			started.Done()
+			started.Wait()
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("safe calls did not run in parallel")
+	}
+}
+
+func TestRunCalls_UnsafeCallsRunAloneInOrder(t *testing.T) {
+	safe := []bool{true, true, false, true, false}
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+
+	RunCalls(len(safe), func(i int) bool { return safe[i] }, func(i int) {
+		name := string(rune('a' + i))
+		record("start " + name)
+		time.Sleep(5 * time.Millisecond)
+		record("end " + name)
+	})
+
+	pos := func(event string) int { return slices.Index(events, event) }
+	// c is unsafe: both earlier calls finish before it starts, and the
+	// following call starts only after it ends.
+	if pos("start c") < pos("end a") || pos("start c") < pos("end b") {
+		t.Errorf("unsafe call c started before earlier calls finished: %v", events)
+	}
+	if pos("start d") < pos("end c") {
+		t.Errorf("call d started before unsafe call c finished: %v", events)
+	}
+	if pos("start e") < pos("end d") {
+		t.Errorf("unsafe call e started before d finished: %v", events)
+	}
+	if len(events) != 2*len(safe) {
+		t.Errorf("expected %d events, got %v", 2*len(safe), events)
+	}
+}
+
+func TestIsConcurrencySafe(t *testing.T) {
+	r := NewToolRegistry()
+	r.Register(NewReadFileTool("", false, MaxReadFileSize))
+	r.Register(NewWriteFileTool("", false))
+
+	if !r.IsConcurrencySafe("read_file") {
+		t.Error("read_file should be concurrency-safe")
+	}
+	if r.IsConcurrencySafe("write_file") {
+		t.Error("write_file should not be concurrency-safe")
+	}
+	if r.IsConcurrencySafe("missing") {
+		t.Error("unknown tools should not be concurrency-safe")
+	}
+}
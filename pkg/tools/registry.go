@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -18,10 +19,20 @@ type ToolEntry struct {
 	TTL    int
 }
 
+// DefaultCallTimeout bounds a single tool call when SetCallTimeout is given 0.
+// It is generous so slow but healthy tools (large fetches, builds) finish.
+const DefaultCallTimeout = 120 * time.Second
+
+// ownTimeoutGrace is added to the timeout of a TimeoutTool so that the tool
+// reports its own timeout, with any partial output, before the registry's.
+const ownTimeoutGrace = 5 * time.Second
+
 type ToolRegistry struct {
-	tools   map[string]*ToolEntry
-	mu      sync.RWMutex
-	version atomic.Uint64 // incremented on Register/RegisterHidden for cache invalidation
+	tools       map[string]*ToolEntry
+	disabled    map[string]bool // names that Register/RegisterHidden ignore
+	callTimeout time.Duration   // 0 = no timeout
+	mu          sync.RWMutex
+	version     atomic.Uint64 // incremented on Register/RegisterHidden for cache invalidation
 }
 
 func NewToolRegistry() *ToolRegistry {
@@ -34,6 +45,10 @@ func (r *ToolRegistry) Register(tool Tool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	name := tool.Name()
+	if r.disabled[name] {
+		logger.DebugCF("tools", "Skipped disabled tool", map[string]any{"name": name})
+		return
+	}
 	if _, exists := r.tools[name]; exists {
 		logger.WarnCF("tools", "Tool registration overwrites existing tool",
 			map[string]any{"name": name})
@@ -52,6 +67,10 @@ func (r *ToolRegistry) RegisterHidden(tool Tool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	name := tool.Name()
+	if r.disabled[name] {
+		logger.DebugCF("tools", "Skipped disabled tool", map[string]any{"name": name})
+		return
+	}
 	if _, exists := r.tools[name]; exists {
 		logger.WarnCF("tools", "Hidden tool registration overwrites existing tool",
 			map[string]any{"name": name})
@@ -65,6 +84,39 @@ func (r *ToolRegistry) RegisterHidden(tool Tool) {
 	logger.DebugCF("tools", "Registered hidden tool", map[string]any{"name": name})
 }
 
+// Disable removes the named tools and makes later registrations under those
+// names no-ops, so a tool stays out of the registry however it is added.
+func (r *ToolRegistry) Disable(names ...string) {
+	if len(names) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.disabled == nil {
+		r.disabled = make(map[string]bool, len(names))
+	}
+	for _, name := range names {
+		r.disabled[name] = true
+		if _, exists := r.tools[name]; exists {
+			delete(r.tools, name)
+			r.version.Add(1)
+		}
+	}
+}
+
+// SetCallTimeout bounds each synchronous tool call made through
+// ExecuteWithContext. 0 uses DefaultCallTimeout and a negative value disables
+// the timeout. Async tools are not bounded: their background work outlives
+// the call by design.
+func (r *ToolRegistry) SetCallTimeout(timeout time.Duration) {
+	if timeout == 0 {
+		timeout = DefaultCallTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callTimeout = max(timeout, 0)
+}
+
 // PromoteTools atomically sets the TTL for multiple non-core tools.
 // This prevents a concurrent TickTTL from decrementing between promotions.
 func (r *ToolRegistry) PromoteTools(names []string, ttl int) {
@@ -150,6 +202,13 @@ func (r *ToolRegistry) Get(name string) (Tool, bool) {
 	return entry.Tool, true
 }
 
+// IsConcurrencySafe reports whether the named tool may run in parallel with
+// other calls. Unknown tools are not.
+func (r *ToolRegistry) IsConcurrencySafe(name string) bool {
+	tool, ok := r.Get(name)
+	return ok && IsConcurrencySafe(tool)
+}
+
 func (r *ToolRegistry) Execute(ctx context.Context, name string, args map[string]any) *ToolResult {
 	return r.ExecuteWithContext(ctx, name, args, "", "", nil)
 }
@@ -186,12 +245,13 @@ func (r *ToolRegistry) ExecuteWithContext(
 
 	// If tool implements AsyncExecutor and callback is provided, use ExecuteAsync.
 	// The callback is a call parameter, not mutable state on the tool instance.
-	var result *ToolResult
+	asyncExec, isAsync := tool.(AsyncExecutor)
+	isAsync = isAsync && asyncCallback != nil
 	start := time.Now()
 
 	// Use recover to catch any panics during tool execution
 	// This prevents tool crashes from killing the entire agent
-	func() {
+	execute := func(ctx context.Context) (result *ToolResult) {
 		defer func() {
 			if re := recover(); re != nil {
 				errMsg := fmt.Sprintf("Tool '%s' crashed with panic: %v", name, re)
@@ -209,16 +269,33 @@ func (r *ToolRegistry) ExecuteWithContext(
 			}
 		}()
 
-		if asyncExec, ok := tool.(AsyncExecutor); ok && asyncCallback != nil {
+		if isAsync {
 			logger.DebugCF("tool", "Executing async tool via ExecuteAsync",
 				map[string]any{
 					"tool": name,
 				})
-			result = asyncExec.ExecuteAsync(ctx, args, asyncCallback)
+			return asyncExec.ExecuteAsync(ctx, args, asyncCallback)
+		}
+		return tool.Execute(ctx, args)
+	}
+
+	r.mu.RLock()
+	timeout := r.callTimeout
+	r.mu.RUnlock()
+	if timed, ok := tool.(TimeoutTool); ok && timeout > 0 {
+		if own := timed.Timeout(); own < 0 {
+			timeout = 0
 		} else {
-			result = tool.Execute(ctx, args)
+			timeout = max(timeout, own+ownTimeoutGrace)
 		}
-	}()
+	}
+
+	var result *ToolResult
+	if timeout > 0 && !isAsync {
+		result = executeWithTimeout(ctx, name, timeout, execute)
+	} else {
+		result = execute(ctx)
+	}
 
 	// Handle nil result (should not happen, but defensive)
 	if result == nil {
@@ -258,6 +335,49 @@ func (r *ToolRegistry) ExecuteWithContext(
 	return result
 }
 
+// executeWithTimeout runs execute under timeout. A tool that ignores ctx is
+// abandoned at the deadline rather than waited for: it finishes in the
+// background and the caller gets an error the LLM can act on. A result that
+// arrived by the deadline is always returned.
+func executeWithTimeout(
+	ctx context.Context,
+	name string,
+	timeout time.Duration,
+	execute func(context.Context) *ToolResult,
+) *ToolResult {
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan *ToolResult, 1)
+	go func() { done <- execute(callCtx) }()
+
+	var result *ToolResult
+	select {
+	case result = <-done:
+	case <-callCtx.Done():
+		// The tool may have finished just as the deadline passed.
+		select {
+		case result = <-done:
+		default:
+		}
+	}
+	if result != nil {
+		return result
+	}
+
+	// The caller's own cancellation is not a timeout; report it as is.
+	if err := ctx.Err(); err != nil {
+		return ErrorResult(fmt.Sprintf("tool %q was cancelled", name)).WithError(err)
+	}
+	logger.WarnCF("tool", "Tool execution timed out",
+		map[string]any{
+			"tool":    name,
+			"timeout": timeout.String(),
+		})
+	msg := fmt.Sprintf("tool %q timed out after %s", name, timeout)
+	return ErrorResult(msg).WithError(context.DeadlineExceeded)
+}
+
 // sortedToolNames returns tool names in sorted order for deterministic iteration.
 // This is critical for KV cache stability: non-deterministic map iteration would
 // produce different system prompts and tool definitions on each call, invalidating
@@ -346,7 +466,9 @@ func (r *ToolRegistry) Clone() *ToolRegistry {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	clone := &ToolRegistry{
-		tools: make(map[string]*ToolEntry, len(r.tools)),
+		tools:       make(map[string]*ToolEntry, len(r.tools)),
+		disabled:    maps.Clone(r.disabled),
+		callTimeout: r.callTimeout,
 	}
 	for name, entry := range r.tools {
 		clone.tools[name] = &ToolEntry{
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/sipeed/picoclaw/pkg/providers"
 )
@@ -621,3 +622,133 @@ func TestToolRegistry_Execute_PanicDoesNotAffectOtherTools(t *testing.T) {
 		t.Errorf("expected 'success', got %q", result2.ForLLM)
 	}
 }
+
+func TestToolRegistry_Disable(t *testing.T) {
+	r := NewToolRegistry()
+	r.Register(newMockTool("exec", "runs commands"))
+	r.Register(newMockTool("read_file", "reads files"))
+
+	r.Disable("exec", "spi")
+	if _, ok := r.Get("exec"); ok {
+		t.Error("expected exec to be removed when disabled")
+	}
+
+	r.Register(newMockTool("spi", "talks SPI"))
+	r.RegisterHidden(newMockTool("exec", "runs commands"))
+	if got := r.List(); len(got) != 1 || got[0] != "read_file" {
+		t.Errorf("List() = %v, want only read_file", got)
+	}
+
+	clone := r.Clone()
+	clone.Register(newMockTool("exec", "runs commands"))
+	if _, ok := clone.Get("exec"); ok {
+		t.Error("expected clone to keep the disabled tools")
+	}
+}
+
+// mockSlowTool blocks until released, ignoring its context.
+type mockSlowTool struct {
+	mockRegistryTool
+	release chan struct{}
+}
+
+func (m *mockSlowTool) Execute(_ context.Context, _ map[string]any) *ToolResult {
+	<-m.release
+	return m.result
+}
+
+func TestToolRegistry_CallTimeout(t *testing.T) {
+	r := NewToolRegistry()
+	slow := &mockSlowTool{mockRegistryTool: *newMockTool("slow", "slow tool"), release: make(chan struct{})}
+	defer close(slow.release)
+	r.Register(slow)
+	r.SetCallTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	result := r.Execute(context.Background(), "slow", nil)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Execute blocked for %v, want it to give up after the timeout", elapsed)
+	}
+	if !result.IsError {
+		t.Fatal("expected IsError=true on timeout")
+	}
+	if want := `tool "slow" timed out after 50ms`; result.ForLLM != want {
+		t.Errorf("ForLLM = %q, want %q", result.ForLLM, want)
+	}
+	if !errors.Is(result.Err, context.DeadlineExceeded) {
+		t.Errorf("Err = %v, want context.DeadlineExceeded", result.Err)
+	}
+
+	// Clones keep the timeout.
+	clone := r.Clone()
+	if result := clone.Execute(context.Background(), "slow", nil); !result.IsError {
+		t.Error("expected the cloned registry to time out too")
+	}
+}
+
+func TestToolRegistry_CallTimeout_FastToolAndAsync(t *testing.T) {
+	r := NewToolRegistry()
+	r.SetCallTimeout(0) // default
+	r.Register(newMockTool("fast", "fast tool"))
+	var asyncCtx context.Context
+	async := &mockAsyncRegistryTool{mockRegistryTool: *newMockTool("async", "async tool")}
+	async.result = AsyncResult("started")
+	r.Register(&mockCtxCapturingAsyncTool{mockAsyncRegistryTool: async, ctx: &asyncCtx})
+
+	if result := r.Execute(context.Background(), "fast", nil); result.IsError || result.ForLLM != "ok" {
+		t.Errorf("fast tool result = %+v, want ok", result)
+	}
+
+	// Async tools are not bounded, so the context they keep for background
+	// work must still be live after the call returns.
+	r.ExecuteWithContext(context.Background(), "async", nil, "cli", "direct", func(context.Context, *ToolResult) {})
+	if asyncCtx == nil {
+		t.Fatal("async tool was not executed via ExecuteAsync")
+	}
+	if err := asyncCtx.Err(); err != nil {
+		t.Errorf("async tool context err = %v, want a live context", err)
+	}
+}
+
+// mockTimedTool sleeps for delay, ignoring ctx, and declares its own timeout.
+type mockTimedTool struct {
+	mockRegistryTool
+	delay, own time.Duration
+}
+
+func (m *mockTimedTool) Execute(_ context.Context, _ map[string]any) *ToolResult {
+	time.Sleep(m.delay)
+	return m.result
+}
+
+func (m *mockTimedTool) Timeout() time.Duration { return m.own }
+
+func TestToolRegistry_CallTimeout_ToolsWithOwnTimeout(t *testing.T) {
+	r := NewToolRegistry()
+	r.SetCallTimeout(20 * time.Millisecond)
+	r.Register(&mockTimedTool{mockRegistryTool: *newMockTool("exec", "own timeout"), delay: 100 * time.Millisecond,
+		own: time.Second})
+	r.Register(&mockTimedTool{mockRegistryTool: *newMockTool("subagent", "unbounded"), delay: 100 * time.Millisecond,
+		own: -1})
+
+	for _, name := range []string{"exec", "subagent"} {
+		if result := r.Execute(context.Background(), name, nil); result.IsError || result.ForLLM != "ok" {
+			t.Errorf("%s result = %+v, want ok past the call timeout", name, result)
+		}
+	}
+}
+
+// mockCtxCapturingAsyncTool records the context ExecuteAsync receives.
+type mockCtxCapturingAsyncTool struct {
+	*mockAsyncRegistryTool
+	ctx *context.Context
+}
+
+func (m *mockCtxCapturingAsyncTool) ExecuteAsync(
+	ctx context.Context,
+	args map[string]any,
+	cb AsyncCallback,
+) *ToolResult {
+	*m.ctx = ctx
+	return m.mockAsyncRegistryTool.ExecuteAsync(ctx, args, cb)
+}
@@ -34,6 +34,15 @@ type ToolResult struct {
 	// Media contains media store refs produced by this tool.
 	// When non-empty, the agent will publish these as OutboundMediaMessage.
 	Media []string `json:"media,omitempty"`
+
+	// Metadata carries routing hints for async results. The agent loop copies
+	// it onto the inbound system message that delivers the result.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// EndTurn stops the agent loop after this tool call instead of asking
+	// the LLM for another step. ForUser becomes the turn's final reply
+	// rather than being sent separately.
+	EndTurn bool `json:"end_turn,omitempty"`
 }
 
 // NewToolResult creates a basic ToolResult with content for the LLM.
@@ -0,0 +1,298 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/state"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// Scratchpad bounds, per session.
+const (
+	MaxScratchpadVars     = 32
+	MaxScratchpadNameLen  = 64
+	MaxScratchpadValueLen = 4096
+)
+
+// scratchpadTypes lists the value types set_var accepts.
+var scratchpadTypes = []string{"string", "number", "boolean", "json"}
+
+// VarStore persists scratchpad variables per session.
+// *state.Manager satisfies it.
+type VarStore interface {
+	SetSessionVar(sessionKey, name string, v *state.SessionVar) error
+	GetSessionVars(sessionKey string) map[string]state.SessionVar
+}
+
+// sessionVars resolves the store and session key for a scratchpad call.
+func sessionVars(ctx context.Context, store VarStore) (string, *ToolResult) {
+	if store == nil {
+		return "", ErrorResult("scratchpad storage not configured")
+	}
+	sessionKey := ToolSessionKey(ctx)
+	if sessionKey == "" {
+		return "", ErrorResult("scratchpad is only available within a conversation session")
+	}
+	return sessionKey, nil
+}
+
+// varName validates the "name" argument.
+func varName(args map[string]any) (string, *ToolResult) {
+	name, _ := args["name"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrorResult("name is required")
+	}
+	if len(name) > MaxScratchpadNameLen {
+		return "", ErrorResult(fmt.Sprintf("name must be at most %d characters", MaxScratchpadNameLen))
+	}
+	return name, nil
+}
+
+// normalizeVarValue checks value against typ and returns its canonical form.
+func normalizeVarValue(typ, value string) (string, error) {
+	switch typ {
+	case "string":
+		return value, nil
+	case "number":
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a number", value)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case "boolean":
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("%q is not a boolean", value)
+		}
+		return strconv.FormatBool(b), nil
+	case "json":
+		if !json.Valid([]byte(value)) {
+			return "", fmt.Errorf("value is not valid JSON")
+		}
+		return strings.TrimSpace(value), nil
+	default:
+		return "", fmt.Errorf("unknown type %q (use one of: %s)", typ, strings.Join(scratchpadTypes, ", "))
+	}
+}
+
+// SetVarTool stores a scratchpad variable for the current session.
+type SetVarTool struct {
+	store VarStore
+}
+
+func NewSetVarTool(store VarStore) *SetVarTool {
+	return &SetVarTool{store: store}
+}
+
+func (t *SetVarTool) Name() string { return "set_var" }
+
+func (t *SetVarTool) Description() string {
+	return "Remember a value for the rest of this conversation (e.g. the option the user chose or the current " +
+		"step of a task). Variables persist across turns and restarts but are private to this conversation; " +
+		"use memory for facts that should outlive it. Setting an existing name overwrites it."
+}
+
+func (t *SetVarTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Variable name",
+			},
+			"value": map[string]any{
+				"type":        "string",
+				"description": "Value, written as text (e.g. \"B\", \"42\", \"true\", or a JSON document)",
+			},
+			"type": map[string]any{
+				"type":        "string",
+				"enum":        scratchpadTypes,
+				"description": "How to interpret value (default: string)",
+			},
+		},
+		"required": []string{"name", "value"},
+	}
+}
+
+func (t *SetVarTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	sessionKey, errResult := sessionVars(ctx, t.store)
+	if errResult != nil {
+		return errResult
+	}
+	name, errResult := varName(args)
+	if errResult != nil {
+		return errResult
+	}
+	value, ok := args["value"].(string)
+	if !ok {
+		return ErrorResult("value is required")
+	}
+	if len(value) > MaxScratchpadValueLen {
+		return ErrorResult(fmt.Sprintf("value must be at most %d characters", MaxScratchpadValueLen))
+	}
+	typ, _ := args["type"].(string)
+	if typ == "" {
+		typ = "string"
+	}
+	value, err := normalizeVarValue(typ, value)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	vars := t.store.GetSessionVars(sessionKey)
+	if _, exists := vars[name]; !exists && len(vars) >= MaxScratchpadVars {
+		return ErrorResult(fmt.Sprintf(
+			"too many variables (limit %d); delete one with delete_var first", MaxScratchpadVars))
+	}
+	if err := t.store.SetSessionVar(sessionKey, name, &state.SessionVar{Type: typ, Value: value}); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to save variable: %v", err))
+	}
+	return SilentResult(fmt.Sprintf("Set %s (%s) = %s", name, typ, value))
+}
+
+// GetVarTool reads a scratchpad variable of the current session.
+type GetVarTool struct {
+	store VarStore
+}
+
+func NewGetVarTool(store VarStore) *GetVarTool {
+	return &GetVarTool{store: store}
+}
+
+func (t *GetVarTool) Name() string { return "get_var" }
+
+func (t *GetVarTool) ConcurrencySafe() bool { return true }
+
+func (t *GetVarTool) Description() string {
+	return "Read a variable previously stored with set_var in this conversation."
+}
+
+func (t *GetVarTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Variable name",
+			},
+		},
+		"required": []string{"name"},
+	}
+}
+
+func (t *GetVarTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	sessionKey, errResult := sessionVars(ctx, t.store)
+	if errResult != nil {
+		return errResult
+	}
+	name, errResult := varName(args)
+	if errResult != nil {
+		return errResult
+	}
+	v, ok := t.store.GetSessionVars(sessionKey)[name]
+	if !ok {
+		return SilentResult(fmt.Sprintf("Variable %s is not set.", name))
+	}
+	return SilentResult(fmt.Sprintf("%s (%s) = %s", name, v.Type, v.Value))
+}
+
+// ListVarsTool lists the scratchpad variables of the current session.
+type ListVarsTool struct {
+	store VarStore
+}
+
+func NewListVarsTool(store VarStore) *ListVarsTool {
+	return &ListVarsTool{store: store}
+}
+
+func (t *ListVarsTool) Name() string { return "list_vars" }
+
+func (t *ListVarsTool) ConcurrencySafe() bool { return true }
+
+func (t *ListVarsTool) Description() string {
+	return "List the variables stored with set_var in this conversation."
+}
+
+func (t *ListVarsTool) Parameters() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+}
+
+func (t *ListVarsTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	sessionKey, errResult := sessionVars(ctx, t.store)
+	if errResult != nil {
+		return errResult
+	}
+	vars := t.store.GetSessionVars(sessionKey)
+	if len(vars) == 0 {
+		return SilentResult("No variables set.")
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Variables (%d/%d):\n", len(vars), MaxScratchpadVars)
+	for _, name := range names {
+		v := vars[name]
+		fmt.Fprintf(&sb, "- %s (%s) = %s\n", name, v.Type, utils.Truncate(v.Value, 200))
+	}
+	return SilentResult(strings.TrimRight(sb.String(), "\n"))
+}
+
+// DeleteVarTool removes a scratchpad variable of the current session.
+type DeleteVarTool struct {
+	store VarStore
+}
+
+func NewDeleteVarTool(store VarStore) *DeleteVarTool {
+	return &DeleteVarTool{store: store}
+}
+
+func (t *DeleteVarTool) Name() string { return "delete_var" }
+
+func (t *DeleteVarTool) Description() string {
+	return "Delete a variable stored with set_var in this conversation."
+}
+
+func (t *DeleteVarTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Variable name",
+			},
+		},
+		"required": []string{"name"},
+	}
+}
+
+func (t *DeleteVarTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	sessionKey, errResult := sessionVars(ctx, t.store)
+	if errResult != nil {
+		return errResult
+	}
+	name, errResult := varName(args)
+	if errResult != nil {
+		return errResult
+	}
+	if _, ok := t.store.GetSessionVars(sessionKey)[name]; !ok {
+		return SilentResult(fmt.Sprintf("Variable %s is not set.", name))
+	}
+	if err := t.store.SetSessionVar(sessionKey, name, nil); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to delete variable: %v", err))
+	}
+	return SilentResult(fmt.Sprintf("Deleted %s", name))
+}
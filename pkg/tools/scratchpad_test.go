@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/state"
+)
+
+func TestScratchpadTools(t *testing.T) {
+	store := state.NewManager(t.TempDir())
+	ctx := WithToolSessionKey(context.Background(), "agent:main:telegram:direct:1")
+
+	set := NewSetVarTool(store)
+	get := NewGetVarTool(store)
+	list := NewListVarsTool(store)
+	del := NewDeleteVarTool(store)
+
+	if r := set.Execute(ctx, map[string]any{"name": "choice", "value": "B"}); r.IsError {
+		t.Fatalf("set_var error: %s", r.ForLLM)
+	}
+	if r := set.Execute(ctx, map[string]any{"name": "step", "value": " 3.0 ", "type": "number"}); r.IsError {
+		t.Fatalf("set_var number error: %s", r.ForLLM)
+	}
+
+	if r := get.Execute(ctx, map[string]any{"name": "choice"}); r.ForLLM != "choice (string) = B" {
+		t.Errorf("get_var = %q", r.ForLLM)
+	}
+	if r := get.Execute(ctx, map[string]any{"name": "step"}); r.ForLLM != "step (number) = 3" {
+		t.Errorf("get_var number = %q, want canonical form", r.ForLLM)
+	}
+	if r := get.Execute(ctx, map[string]any{"name": "missing"}); r.IsError || !strings.Contains(r.ForLLM, "not set") {
+		t.Errorf("get_var missing = %q", r.ForLLM)
+	}
+
+	r := list.Execute(ctx, map[string]any{})
+	if !strings.Contains(r.ForLLM, "- choice (string) = B") || !strings.Contains(r.ForLLM, "- step (number) = 3") {
+		t.Errorf("list_vars = %q", r.ForLLM)
+	}
+
+	// Variables are scoped to the session.
+	other := WithToolSessionKey(context.Background(), "agent:main:telegram:direct:2")
+	if r := list.Execute(other, map[string]any{}); r.ForLLM != "No variables set." {
+		t.Errorf("list_vars in other session = %q", r.ForLLM)
+	}
+
+	if r := del.Execute(ctx, map[string]any{"name": "choice"}); r.IsError {
+		t.Fatalf("delete_var error: %s", r.ForLLM)
+	}
+	if r := get.Execute(ctx, map[string]any{"name": "choice"}); !strings.Contains(r.ForLLM, "not set") {
+		t.Errorf("get_var after delete = %q", r.ForLLM)
+	}
+}
+
+func TestSetVarTool_Validation(t *testing.T) {
+	store := state.NewManager(t.TempDir())
+	ctx := WithToolSessionKey(context.Background(), "s1")
+	set := NewSetVarTool(store)
+
+	cases := []map[string]any{
+		{"name": "", "value": "x"},
+		{"name": strings.Repeat("n", MaxScratchpadNameLen+1), "value": "x"},
+		{"name": "big", "value": strings.Repeat("v", MaxScratchpadValueLen+1)},
+		{"name": "n", "value": "abc", "type": "number"},
+		{"name": "b", "value": "maybe", "type": "boolean"},
+		{"name": "j", "value": "{oops", "type": "json"},
+		{"name": "t", "value": "x", "type": "date"},
+	}
+	for _, args := range cases {
+		if r := set.Execute(ctx, args); !r.IsError {
+			t.Errorf("set_var(%v) succeeded, want error", args)
+		}
+	}
+
+	if r := set.Execute(context.Background(), map[string]any{"name": "a", "value": "x"}); !r.IsError {
+		t.Error("set_var without a session succeeded, want error")
+	}
+}
+
+func TestSetVarTool_Limit(t *testing.T) {
+	store := state.NewManager(t.TempDir())
+	ctx := WithToolSessionKey(context.Background(), "s1")
+	set := NewSetVarTool(store)
+
+	for i := range MaxScratchpadVars {
+		if r := set.Execute(ctx, map[string]any{"name": fmt.Sprintf("v%d", i), "value": "x"}); r.IsError {
+			t.Fatalf("set_var v%d error: %s", i, r.ForLLM)
+		}
+	}
+	if r := set.Execute(ctx, map[string]any{"name": "one_too_many", "value": "x"}); !r.IsError {
+		t.Error("set_var past the limit succeeded, want error")
+	}
+	// Overwriting an existing variable is still allowed at the limit.
+	if r := set.Execute(ctx, map[string]any{"name": "v0", "value": "y"}); r.IsError {
+		t.Errorf("overwrite at limit failed: %s", r.ForLLM)
+	}
+}
@@ -466,6 +466,15 @@ func (t *ExecTool) SetTimeout(timeout time.Duration) {
 	t.timeout = timeout
 }
 
+// Timeout implements TimeoutTool: commands are bounded by timeout_seconds
+// rather than the registry's call timeout, and 0 leaves them unbounded.
+func (t *ExecTool) Timeout() time.Duration {
+	if t.timeout <= 0 {
+		return -1
+	}
+	return t.timeout
+}
+
 func (t *ExecTool) SetRestrictToWorkspace(restrict bool) {
 	t.restrictToWorkspace = restrict
 }
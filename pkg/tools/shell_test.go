@@ -586,6 +586,52 @@ func TestShellTool_CustomAllowPatterns(t *testing.T) {
 	}
 }
 
+// TestShellTool_CustomDenyPatterns verifies that configured deny patterns are
+// enforced alongside the built-in list while other commands still run.
+func TestShellTool_CustomDenyPatterns(t *testing.T) {
+	cfg := &config.Config{
+		Tools: config.ToolsConfig{
+			Exec: config.ExecConfig{
+				EnableDenyPatterns: true,
+				AllowRemote:        true,
+				CustomDenyPatterns: []string{`\bcurl\b`},
+			},
+		},
+	}
+
+	tool, err := NewExecToolWithConfig("", false, cfg)
+	if err != nil {
+		t.Fatalf("unable to configure exec tool: %s", err)
+	}
+
+	for _, cmd := range []string{"rm -rf /", "curl http://example.com"} {
+		result := tool.Execute(context.Background(), map[string]any{"command": cmd})
+		if !result.IsError || !strings.Contains(result.ForLLM, "blocked") {
+			t.Errorf("expected %q to be blocked, got: %s", cmd, result.ForLLM)
+		}
+	}
+
+	result := tool.Execute(context.Background(), map[string]any{"command": "echo hi"})
+	if result.IsError {
+		t.Fatalf("expected 'echo hi' to run, got: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "hi") {
+		t.Errorf("expected stdout in result, got: %s", result.ForLLM)
+	}
+}
+
+// TestShellTool_InvalidCustomDenyPattern verifies that a malformed pattern is
+// reported when the tool is built.
+func TestShellTool_InvalidCustomDenyPattern(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Tools.Exec.EnableDenyPatterns = true
+	cfg.Tools.Exec.CustomDenyPatterns = []string{"("}
+
+	if _, err := NewExecToolWithConfig("", false, cfg); err == nil {
+		t.Fatal("expected an error for an invalid custom deny pattern")
+	}
+}
+
 // TestShellTool_URLsNotBlocked verifies that commands containing URLs are not
 // incorrectly blocked by the workspace restriction safety guard (issue #1203).
 func TestShellTool_URLsNotBlocked(t *testing.T) {
@@ -6,6 +6,20 @@ import (
 	"strings"
 )
 
+// Spawn notify modes control how much of a background task the user sees.
+const (
+	// SpawnNotifyProgress forwards intermediate status updates and the result.
+	SpawnNotifyProgress = "progress"
+	// SpawnNotifyFinal reports only the result (default).
+	SpawnNotifyFinal = "final"
+	// SpawnNotifySilent keeps the result out of the chat; it stays available
+	// through spawn_status.
+	SpawnNotifySilent = "silent"
+
+	// SpawnNotifyMetadataKey is the ToolResult/inbound metadata key carrying the mode.
+	SpawnNotifyMetadataKey = "notify"
+)
+
 type SpawnTool struct {
 	manager        *SubagentManager
 	allowlistCheck func(targetAgentID string) bool
@@ -44,6 +58,11 @@ func (t *SpawnTool) Parameters() map[string]any {
 				"type":        "string",
 				"description": "Optional target agent ID to delegate the task to",
 			},
+			"notify": map[string]any{
+				"type":        "string",
+				"enum":        []string{SpawnNotifyProgress, SpawnNotifyFinal, SpawnNotifySilent},
+				"description": "Optional: what the user sees. progress = status updates and result, final = result only (default), silent = nothing",
+			},
 		},
 		"required": []string{"task"},
 	}
@@ -71,6 +90,14 @@ func (t *SpawnTool) execute(ctx context.Context, args map[string]any, cb AsyncCa
 
 	label, _ := args["label"].(string)
 	agentID, _ := args["agent_id"].(string)
+	notify, _ := args["notify"].(string)
+	switch notify {
+	case "":
+		notify = SpawnNotifyFinal
+	case SpawnNotifyProgress, SpawnNotifyFinal, SpawnNotifySilent:
+	default:
+		return ErrorResult(fmt.Sprintf("invalid notify mode %q: use progress, final or silent", notify))
+	}
 
 	// Check allowlist if targeting a specific agent
 	if agentID != "" && t.allowlistCheck != nil {
@@ -96,7 +123,7 @@ func (t *SpawnTool) execute(ctx context.Context, args map[string]any, cb AsyncCa
 	}
 
 	// Pass callback to manager for async completion notification
-	result, err := t.manager.Spawn(ctx, task, label, agentID, channel, chatID, cb)
+	result, err := t.manager.Spawn(ctx, task, label, agentID, channel, chatID, notify, cb)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("failed to spawn subagent: %v", err))
 	}
@@ -24,6 +24,10 @@ func (t *SpawnStatusTool) Name() string {
 	return "spawn_status"
 }
 
+func (t *SpawnStatusTool) ConcurrencySafe() bool {
+	return true
+}
+
 func (t *SpawnStatusTool) Description() string {
 	return "Get the status of spawned subagents. " +
 		"Returns a list of all subagents and their current state " +
@@ -128,7 +132,7 @@ func (t *SpawnStatusTool) Execute(ctx context.Context, args map[string]any) *Too
 
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Subagent status report (%d total):\n", len(tasks)))
-	for _, status := range []string{"running", "completed", "failed", "canceled"} {
+	for _, status := range []string{"queued", "running", "completed", "failed", "canceled"} {
 		if n := counts[status]; n > 0 {
 			label := strings.ToUpper(status[:1]) + status[1:] + ":"
 			sb.WriteString(fmt.Sprintf("  %-10s %d\n", label, n))
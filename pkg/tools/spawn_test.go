@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSpawnTool_Execute_EmptyTask(t *testing.T) {
@@ -77,3 +78,44 @@ func TestSpawnTool_Execute_NilManager(t *testing.T) {
 		t.Errorf("Error message should mention manager not configured, got: %s", result.ForLLM)
 	}
 }
+
+func TestSpawnTool_Execute_InvalidNotify(t *testing.T) {
+	manager := NewSubagentManager(&MockLLMProvider{}, "test-model", "/tmp/test")
+	tool := NewSpawnTool(manager)
+
+	result := tool.Execute(context.Background(), map[string]any{"task": "x", "notify": "loud"})
+	if !result.IsError {
+		t.Fatal("expected error for unknown notify mode")
+	}
+	if !strings.Contains(result.ForLLM, "invalid notify mode") {
+		t.Errorf("unexpected error message: %s", result.ForLLM)
+	}
+}
+
+func TestSpawnTool_NotifySilent_MarksResult(t *testing.T) {
+	manager := NewSubagentManager(&MockLLMProvider{}, "test-model", "/tmp/test")
+	tool := NewSpawnTool(manager)
+
+	done := make(chan *ToolResult, 1)
+	result := tool.ExecuteAsync(context.Background(), map[string]any{
+		"task":   "tidy up",
+		"notify": SpawnNotifySilent,
+	}, func(_ context.Context, r *ToolResult) {
+		done <- r
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+
+	select {
+	case r := <-done:
+		if !r.Silent {
+			t.Error("silent spawn result should be Silent")
+		}
+		if r.Metadata[SpawnNotifyMetadataKey] != SpawnNotifySilent {
+			t.Errorf("metadata = %v, want notify=silent", r.Metadata)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for subagent callback")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/semaphore"
 )
 
 type SubagentTask struct {
@@ -16,6 +17,7 @@ type SubagentTask struct {
 	AgentID       string
 	OriginChannel string
 	OriginChatID  string
+	Notify        string
 	Status        string
 	Result        string
 	Created       int64
@@ -33,6 +35,10 @@ type SubagentManager struct {
 	temperature    float64
 	hasMaxTokens   bool
 	hasTemperature bool
+	limiter        *semaphore.Semaphore
+	llmLimiter     *semaphore.Semaphore
+	maxResultChars int
+	noToolCalling  bool
 	nextID         int
 }
 
@@ -61,6 +67,31 @@ func (sm *SubagentManager) SetLLMOptions(maxTokens int, temperature float64) {
 	sm.hasTemperature = true
 }
 
+// SetLimiter shares a concurrency limit with other managers. Spawned tasks
+// wait in "queued" status for a free slot; synchronous subagent calls are
+// rejected when none is free.
+func (sm *SubagentManager) SetLimiter(limiter *semaphore.Semaphore) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.limiter = limiter
+}
+
+// SetLLMLimiter shares a process-wide bound on concurrent LLM calls with the
+// subagents' tool loops.
+func (sm *SubagentManager) SetLLMLimiter(limiter *semaphore.Semaphore) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.llmLimiter = limiter
+}
+
+// SetNoToolCalling marks the subagents' model as unable to call tools, so
+// their tool loops send no tool definitions.
+func (sm *SubagentManager) SetNoToolCalling(noToolCalling bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.noToolCalling = noToolCalling
+}
+
 // SetTools sets the tool registry for subagent execution.
 // If not set, subagent will have access to the provided tools.
 func (sm *SubagentManager) SetTools(tools *ToolRegistry) {
@@ -78,7 +109,7 @@ func (sm *SubagentManager) RegisterTool(tool Tool) {
 
 func (sm *SubagentManager) Spawn(
 	ctx context.Context,
-	task, label, agentID, originChannel, originChatID string,
+	task, label, agentID, originChannel, originChatID, notify string,
 	callback AsyncCallback,
 ) (string, error) {
 	sm.mu.Lock()
@@ -94,9 +125,13 @@ func (sm *SubagentManager) Spawn(
 		AgentID:       agentID,
 		OriginChannel: originChannel,
 		OriginChatID:  originChatID,
+		Notify:        notify,
 		Status:        "running",
 		Created:       time.Now().UnixMilli(),
 	}
+	if sm.limiter != nil {
+		subagentTask.Status = "queued"
+	}
 	sm.tasks[taskID] = subagentTask
 
 	// Start task in background with context cancellation support
@@ -108,6 +143,28 @@ func (sm *SubagentManager) Spawn(
 	return fmt.Sprintf("Spawned subagent for task: %s", task), nil
 }
 
+// waitForSlot blocks until the shared limiter admits task, then marks it
+// running and returns the limiter to release. It returns false if ctx ends
+// first; the task is then canceled.
+func (sm *SubagentManager) waitForSlot(ctx context.Context, task *SubagentTask) (*semaphore.Semaphore, bool) {
+	sm.mu.RLock()
+	limiter := sm.limiter
+	sm.mu.RUnlock()
+
+	if err := limiter.Acquire(ctx); err != nil {
+		sm.mu.Lock()
+		task.Status = "canceled"
+		task.Result = "Task canceled while waiting for a free subagent slot"
+		sm.mu.Unlock()
+		return nil, false
+	}
+
+	sm.mu.Lock()
+	task.Status = "running"
+	sm.mu.Unlock()
+	return limiter, true
+}
+
 func (sm *SubagentManager) runTask(ctx context.Context, task *SubagentTask, callback AsyncCallback) {
 	// Build system prompt for subagent
 	systemPrompt := `You are a subagent. Complete the given task independently and report the result.
@@ -136,6 +193,12 @@ After completing the task, provide a clear summary of what was done.`
 	default:
 	}
 
+	limiter, ok := sm.waitForSlot(ctx, task)
+	if !ok {
+		return
+	}
+	defer limiter.Release()
+
 	// Run tool loop with access to tools
 	sm.mu.RLock()
 	tools := sm.tools
@@ -144,6 +207,8 @@ After completing the task, provide a clear summary of what was done.`
 	temperature := sm.temperature
 	hasMaxTokens := sm.hasMaxTokens
 	hasTemperature := sm.hasTemperature
+	llmLimiter := sm.llmLimiter
+	noToolCalling := sm.noToolCalling
 	sm.mu.RUnlock()
 
 	var llmOptions map[string]any
@@ -157,20 +222,47 @@ After completing the task, provide a clear summary of what was done.`
 		}
 	}
 
+	var onProgress func(string)
+	if task.Notify == SpawnNotifyProgress && callback != nil {
+		// Progress results carry only ForUser: the agent loop shows them to the
+		// user without feeding them back as a system message.
+		onProgress = func(status string) {
+			callback(ctx, &ToolResult{
+				ForUser:  fmt.Sprintf("[%s] %s", subagentDisplayName(task), status),
+				Metadata: map[string]string{SpawnNotifyMetadataKey: SpawnNotifyProgress},
+			})
+		}
+	}
+
 	loopResult, err := RunToolLoop(ctx, ToolLoopConfig{
 		Provider:      sm.provider,
 		Model:         sm.defaultModel,
 		Tools:         tools,
 		MaxIterations: maxIter,
 		LLMOptions:    llmOptions,
+		Limiter:       llmLimiter,
+		OnProgress:    onProgress,
+		NoToolCalling: noToolCalling,
 	}, messages, task.OriginChannel, task.OriginChatID)
 
+	// Truncate before taking the lock; limitResult reads manager settings.
+	var content string
+	if err == nil {
+		content = sm.limitResult(task.ID, loopResult.Content)
+	}
+
 	sm.mu.Lock()
 	var result *ToolResult
 	defer func() {
 		sm.mu.Unlock()
 		// Call callback if provided and result is set
 		if callback != nil && result != nil {
+			if task.Notify != "" {
+				result.Metadata = map[string]string{SpawnNotifyMetadataKey: task.Notify}
+			}
+			if task.Notify == SpawnNotifySilent {
+				result.Silent = true
+			}
 			callback(ctx, result)
 		}
 	}()
@@ -193,15 +285,15 @@ After completing the task, provide a clear summary of what was done.`
 		}
 	} else {
 		task.Status = "completed"
-		task.Result = loopResult.Content
+		task.Result = content
 		result = &ToolResult{
 			ForLLM: fmt.Sprintf(
 				"Subagent '%s' completed (iterations: %d): %s",
 				task.Label,
 				loopResult.Iterations,
-				loopResult.Content,
+				content,
 			),
-			ForUser: loopResult.Content,
+			ForUser: content,
 			Silent:  false,
 			IsError: false,
 			Async:   false,
@@ -209,6 +301,14 @@ After completing the task, provide a clear summary of what was done.`
 	}
 }
 
+// subagentDisplayName returns the task label, or its ID when unlabeled.
+func subagentDisplayName(task *SubagentTask) string {
+	if task.Label != "" {
+		return task.Label
+	}
+	return task.ID
+}
+
 func (sm *SubagentManager) GetTask(taskID string) (*SubagentTask, bool) {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
@@ -269,6 +369,12 @@ func (t *SubagentTool) Name() string {
 	return "subagent"
 }
 
+// Timeout implements TimeoutTool. A subagent runs a whole agent loop, each
+// of its own tool calls bounded, so the call as a whole is not.
+func (t *SubagentTool) Timeout() time.Duration {
+	return -1
+}
+
 func (t *SubagentTool) Description() string {
 	return "Execute a subagent task synchronously and return the result. Use this for delegating specific tasks to an independent agent instance. Returns execution summary to user and full details to LLM."
 }
@@ -316,6 +422,19 @@ func (t *SubagentTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 
 	// Use RunToolLoop to execute with tools (same as async SpawnTool)
 	sm := t.manager
+	sm.mu.RLock()
+	limiter := sm.limiter
+	sm.mu.RUnlock()
+
+	// The caller is blocked on this tool, so don't queue behind the limit.
+	if !limiter.TryAcquire() {
+		return ErrorResult(fmt.Sprintf(
+			"Too many subagents are running (limit %d). Try again later, or use spawn to queue the task.",
+			limiter.Limit(),
+		))
+	}
+	defer limiter.Release()
+
 	sm.mu.RLock()
 	tools := sm.tools
 	maxIter := sm.maxIterations
@@ -323,6 +442,8 @@ func (t *SubagentTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 	temperature := sm.temperature
 	hasMaxTokens := sm.hasMaxTokens
 	hasTemperature := sm.hasTemperature
+	llmLimiter := sm.llmLimiter
+	noToolCalling := sm.noToolCalling
 	sm.mu.RUnlock()
 
 	var llmOptions map[string]any
@@ -353,6 +474,8 @@ func (t *SubagentTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 		Tools:         tools,
 		MaxIterations: maxIter,
 		LLMOptions:    llmOptions,
+		Limiter:       llmLimiter,
+		NoToolCalling: noToolCalling,
 	}, messages, channel, chatID)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("Subagent execution failed: %v", err)).WithError(err)
@@ -370,8 +493,9 @@ func (t *SubagentTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 	if labelStr == "" {
 		labelStr = "(unnamed)"
 	}
+	resultName := fmt.Sprintf("subagent-sync-%d", time.Now().UnixNano())
 	llmContent := fmt.Sprintf("Subagent task completed:\nLabel: %s\nIterations: %d\nResult: %s",
-		labelStr, loopResult.Iterations, loopResult.Content)
+		labelStr, loopResult.Iterations, sm.limitResult(resultName, loopResult.Content))
 
 	return &ToolResult{
 		ForLLM:  llmContent,
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// DefaultSubagentMaxResultChars bounds how much subagent output is handed
+// back to the parent conversation when no limit is configured.
+const DefaultSubagentMaxResultChars = 16000
+
+// subagentResultsDir is the workspace subdirectory holding full outputs of
+// truncated subagent results.
+const subagentResultsDir = "subagents"
+
+// SetMaxResultChars sets the largest subagent result, in characters, passed
+// back to the parent. 0 restores the default; a negative value disables the
+// limit.
+func (sm *SubagentManager) SetMaxResultChars(n int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.maxResultChars = n
+}
+
+// limitResult truncates content to the configured size. The full output is
+// saved under the workspace and the marker tells the parent how to read it.
+func (sm *SubagentManager) limitResult(name, content string) string {
+	sm.mu.RLock()
+	limit := sm.maxResultChars
+	workspace := sm.workspace
+	sm.mu.RUnlock()
+
+	if limit == 0 {
+		limit = DefaultSubagentMaxResultChars
+	}
+	runes := []rune(content)
+	if limit < 0 || len(runes) <= limit {
+		return content
+	}
+
+	marker := fmt.Sprintf("\n\n[Result truncated: showing %d of %d characters.", limit, len(runes))
+	if path, err := saveSubagentResult(workspace, name, content); err != nil {
+		logger.WarnCF("subagent", "Failed to save full subagent result", map[string]any{
+			"task":  name,
+			"error": err.Error(),
+		})
+		marker += "]"
+	} else {
+		marker += fmt.Sprintf(" Full output saved to %s; read it with read_file if needed.]", path)
+	}
+	return string(runes[:limit]) + marker
+}
+
+// saveSubagentResult writes content to <workspace>/subagents/<name>.md and
+// returns the path relative to the workspace.
+func saveSubagentResult(workspace, name, content string) (string, error) {
+	if workspace == "" {
+		return "", fmt.Errorf("no workspace configured")
+	}
+	dir := filepath.Join(workspace, subagentResultsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	rel := filepath.Join(subagentResultsDir, name+".md")
+	if err := os.WriteFile(filepath.Join(workspace, rel), []byte(content), 0o644); err != nil {
+		return "", err
+	}
+	return rel, nil
+}
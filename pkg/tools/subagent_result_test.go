@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubagentTool_TruncatesOversizedResult(t *testing.T) {
+	workspace := t.TempDir()
+	manager := NewSubagentManager(&MockLLMProvider{}, "test-model", workspace)
+	manager.SetMaxResultChars(100)
+	tool := NewSubagentTool(manager)
+
+	task := strings.Repeat("x", 500)
+	result := tool.Execute(context.Background(), map[string]any{"task": task})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+
+	// The mock echoes "Task completed: " + task, so the full output is 516 chars.
+	if !strings.Contains(result.ForLLM, "[Result truncated: showing 100 of 516 characters.") {
+		t.Fatalf("expected truncation marker, got: %s", result.ForLLM)
+	}
+	if strings.Contains(result.ForLLM, task) {
+		t.Error("expected the oversized result to be cut")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(workspace, subagentResultsDir, "*.md"))
+	if len(files) != 1 {
+		t.Fatalf("expected one saved result file, got %v", files)
+	}
+	full, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("reading saved result: %v", err)
+	}
+	if string(full) != "Task completed: "+task {
+		t.Errorf("saved result does not hold the full output (%d bytes)", len(full))
+	}
+	if !strings.Contains(result.ForLLM, filepath.Join(subagentResultsDir, filepath.Base(files[0]))) {
+		t.Errorf("expected marker to reference the saved file, got: %s", result.ForLLM)
+	}
+}
+
+func TestSubagentManager_SpawnTruncatesOversizedResult(t *testing.T) {
+	workspace := t.TempDir()
+	manager := NewSubagentManager(&MockLLMProvider{}, "test-model", workspace)
+	manager.SetMaxResultChars(50)
+
+	results := make(chan *ToolResult, 1)
+	_, err := manager.Spawn(context.Background(), strings.Repeat("y", 300), "big", "", "cli", "direct", "",
+		func(ctx context.Context, result *ToolResult) { results <- result })
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+
+	var result *ToolResult
+	select {
+	case result = <-results:
+	case <-time.After(2 * time.Second):
+		t.Fatal("spawned task did not complete")
+	}
+	if !strings.Contains(result.ForLLM, "[Result truncated: showing 50 of") {
+		t.Errorf("expected truncation marker, got: %s", result.ForLLM)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, subagentResultsDir, "subagent-1.md")); err != nil {
+		t.Errorf("expected full output saved for subagent-1: %v", err)
+	}
+}
+
+func TestSubagentManager_LimitResult(t *testing.T) {
+	manager := NewSubagentManager(&MockLLMProvider{}, "test-model", t.TempDir())
+
+	short := "small result"
+	if got := manager.limitResult("t", short); got != short {
+		t.Errorf("expected short result unchanged, got %q", got)
+	}
+
+	big := strings.Repeat("z", DefaultSubagentMaxResultChars+1)
+	if got := manager.limitResult("t", big); !strings.Contains(got, "[Result truncated") {
+		t.Error("expected the default limit to apply when none is configured")
+	}
+
+	manager.SetMaxResultChars(-1)
+	if got := manager.limitResult("t", big); got != big {
+		t.Error("expected a negative limit to disable truncation")
+	}
+}
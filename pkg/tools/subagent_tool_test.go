@@ -4,8 +4,10 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/semaphore"
 )
 
 // MockLLMProvider is a test implementation of LLMProvider
@@ -325,3 +327,51 @@ func TestSubagentTool_ForUserTruncation(t *testing.T) {
 		t.Error("ForLLM should contain reference to original task")
 	}
 }
+
+func TestSubagentTool_RejectedAtLimit(t *testing.T) {
+	limiter := semaphore.New(1)
+	manager := NewSubagentManager(&MockLLMProvider{}, "test-model", "/tmp/test")
+	manager.SetLimiter(limiter)
+	tool := NewSubagentTool(manager)
+
+	limiter.TryAcquire() // another subagent holds the only slot
+	result := tool.Execute(context.Background(), map[string]any{"task": "Do something"})
+	if !result.IsError || !strings.Contains(result.ForLLM, "limit 1") {
+		t.Fatalf("expected limit error, got: %+v", result)
+	}
+
+	limiter.Release()
+	result = tool.Execute(context.Background(), map[string]any{"task": "Do something"})
+	if result.IsError {
+		t.Fatalf("expected success once a slot is free, got: %s", result.ForLLM)
+	}
+}
+
+func TestSubagentManager_SpawnQueuesAtLimit(t *testing.T) {
+	limiter := semaphore.New(1)
+	manager := NewSubagentManager(&MockLLMProvider{}, "test-model", "/tmp/test")
+	manager.SetLimiter(limiter)
+
+	limiter.TryAcquire() // another subagent holds the only slot
+	done := make(chan struct{})
+	_, err := manager.Spawn(context.Background(), "Do something", "", "", "cli", "direct", "",
+		func(ctx context.Context, result *ToolResult) { close(done) })
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if task, _ := manager.GetTaskCopy("subagent-1"); task.Status != "queued" {
+		t.Fatalf("expected queued status while the limit is reached, got %q", task.Status)
+	}
+
+	limiter.Release()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued task did not run after a slot was released")
+	}
+	if task, _ := manager.GetTaskCopy("subagent-1"); task.Status != "completed" {
+		t.Errorf("expected completed status, got %q", task.Status)
+	}
+}
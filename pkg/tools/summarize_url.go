@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+const (
+	summarizeURLMaxTokens   = 1024
+	summarizeURLTemperature = 0.3
+)
+
+// LLMCallFunc sends messages to a model chosen by the caller, without tools.
+// The agent supplies one that picks the model, applies the concurrency limit
+// and falls back to other models on failure.
+type LLMCallFunc func(
+	ctx context.Context,
+	messages []providers.Message,
+	options map[string]any,
+) (*providers.LLMResponse, error)
+
+// SummarizeURLTool fetches a page through a WebFetchTool (inheriting its proxy,
+// SSRF guard, size limit and timeout) and returns an LLM-written summary
+// instead of the raw page text.
+type SummarizeURLTool struct {
+	fetcher *WebFetchTool
+	chat    LLMCallFunc
+}
+
+func NewSummarizeURLTool(fetcher *WebFetchTool, chat LLMCallFunc) *SummarizeURLTool {
+	return &SummarizeURLTool{
+		fetcher: fetcher,
+		chat:    chat,
+	}
+}
+
+func (t *SummarizeURLTool) Name() string {
+	return "summarize_url"
+}
+
+func (t *SummarizeURLTool) ConcurrencySafe() bool {
+	return true
+}
+
+func (t *SummarizeURLTool) Pure() bool {
+	return true
+}
+
+func (t *SummarizeURLTool) Description() string {
+	return "Fetch a URL and return a concise summary of its main content. " +
+		"Prefer this over web_fetch when you only need the gist of a page."
+}
+
+func (t *SummarizeURLTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"description": "URL to summarize",
+			},
+			"focus": map[string]any{
+				"type":        "string",
+				"description": "Optional: question or topic the summary should focus on",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+func (t *SummarizeURLTool) Execute(ctx context.Context, args map[string]any) *ToolResult {
+	urlStr, ok := args["url"].(string)
+	if !ok || urlStr == "" {
+		return ErrorResult("url is required")
+	}
+	focus, _ := args["focus"].(string)
+
+	if t.fetcher == nil || t.chat == nil {
+		return ErrorResult("summarize_url is not configured")
+	}
+
+	page, err := t.fetcher.fetch(ctx, urlStr, t.fetcher.maxChars)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	if strings.TrimSpace(page.Text) == "" {
+		return ErrorResult(fmt.Sprintf("no readable content at %s (status %d)", urlStr, page.Status))
+	}
+
+	resp, err := t.chat(
+		ctx,
+		[]providers.Message{{Role: "user", Content: buildSummarizeURLPrompt(page, focus)}},
+		map[string]any{
+			"max_tokens":  summarizeURLMaxTokens,
+			"temperature": summarizeURLTemperature,
+		},
+	)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("summarization failed: %v", err)).WithError(err)
+	}
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return ErrorResult("summarization returned an empty response")
+	}
+
+	return &ToolResult{
+		ForLLM:  fmt.Sprintf("Summary of %s:\n%s", urlStr, summary),
+		ForUser: fmt.Sprintf("Summarized %s (%d chars fetched, truncated: %v)", urlStr, len(page.Text), page.Truncated),
+	}
+}
+
+func buildSummarizeURLPrompt(page *fetchedPage, focus string) string {
+	var sb strings.Builder
+	sb.WriteString("Summarize the main content of the following web page concisely. ")
+	sb.WriteString("Ignore navigation, ads, cookie banners and other boilerplate. ")
+	sb.WriteString("Keep concrete facts, figures, names and dates.\n")
+	if focus != "" {
+		fmt.Fprintf(&sb, "Focus on: %s\n", focus)
+	}
+	if page.Truncated {
+		sb.WriteString("Note: the page was truncated; summarize only what is shown.\n")
+	}
+	fmt.Fprintf(&sb, "\nURL: %s\n\nCONTENT:\n%s\n", page.URL, page.Text)
+	return sb.String()
+}
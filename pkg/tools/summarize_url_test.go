@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+func TestSummarizeURLTool_SummarizesFetchedContent(t *testing.T) {
+	withPrivateWebFetchHostsAllowed(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><script>track()</script><p>Release 1.2 ships on Monday</p></body></html>"))
+	}))
+	defer server.Close()
+
+	fetcher, err := NewWebFetchTool(50000, format, testFetchLimit)
+	if err != nil {
+		t.Fatalf("Failed to create web fetch tool: %v", err)
+	}
+	provider := &MockLLMProvider{}
+	tool := NewSummarizeURLTool(fetcher, mockChat(provider))
+
+	result := tool.Execute(context.Background(), map[string]any{
+		"url":   server.URL,
+		"focus": "release date",
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+
+	// The mock echoes the prompt, so the page text and focus must have reached the model.
+	if !strings.Contains(result.ForLLM, "Release 1.2 ships on Monday") {
+		t.Errorf("expected page text in prompt, got: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Focus on: release date") {
+		t.Errorf("expected focus in prompt, got: %s", result.ForLLM)
+	}
+	if strings.Contains(result.ForLLM, "track()") {
+		t.Errorf("expected scripts to be stripped before summarization, got: %s", result.ForLLM)
+	}
+	if provider.lastOptions["max_tokens"] != summarizeURLMaxTokens {
+		t.Errorf("expected max_tokens %d, got %v", summarizeURLMaxTokens, provider.lastOptions["max_tokens"])
+	}
+}
+
+func TestSummarizeURLTool_BlocksPrivateHosts(t *testing.T) {
+	fetcher, err := NewWebFetchTool(50000, format, testFetchLimit)
+	if err != nil {
+		t.Fatalf("Failed to create web fetch tool: %v", err)
+	}
+	tool := NewSummarizeURLTool(fetcher, mockChat(&MockLLMProvider{}))
+
+	result := tool.Execute(context.Background(), map[string]any{"url": "http://127.0.0.1:8080/"})
+	if !result.IsError {
+		t.Fatal("expected private host to be rejected")
+	}
+	if !strings.Contains(result.ForLLM, "private or local") {
+		t.Errorf("unexpected error message: %s", result.ForLLM)
+	}
+}
+
+// mockChat sends summarization calls straight to provider.
+func mockChat(provider *MockLLMProvider) LLMCallFunc {
+	return func(ctx context.Context, messages []providers.Message, options map[string]any) (*providers.LLMResponse, error) {
+		return provider.Chat(ctx, messages, nil, "test-model", options)
+	}
+}
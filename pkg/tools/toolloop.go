@@ -10,10 +10,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
+	"strings"
 
 	"github.com/sipeed/picoclaw/pkg/logger"
 	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/semaphore"
 	"github.com/sipeed/picoclaw/pkg/utils"
 )
 
@@ -24,6 +25,15 @@ type ToolLoopConfig struct {
 	Tools         *ToolRegistry
 	MaxIterations int
 	LLMOptions    map[string]any
+	// Limiter, if set, bounds concurrent LLM calls together with every
+	// other caller sharing it (global_llm_concurrency).
+	Limiter *semaphore.Semaphore
+	// OnProgress, if set, is called with a short status line whenever the
+	// LLM requests tool calls, before they are executed.
+	OnProgress func(status string)
+	// NoToolCalling withholds the tool definitions from the model, for
+	// models configured with supports_tools false.
+	NoToolCalling bool
 }
 
 // ToolLoopResult contains the result of running the tool loop.
@@ -54,7 +64,7 @@ func RunToolLoop(
 
 		// 1. Build tool definitions
 		var providerToolDefs []providers.ToolDefinition
-		if config.Tools != nil {
+		if config.Tools != nil && !config.NoToolCalling {
 			providerToolDefs = config.Tools.ToProviderDefs()
 		}
 
@@ -64,7 +74,8 @@ func RunToolLoop(
 			llmOpts = map[string]any{}
 		}
 		// 3. Call LLM
-		response, err := config.Provider.Chat(ctx, messages, providerToolDefs, config.Model, llmOpts)
+		response, err := providers.LimitedChat(
+			ctx, config.Limiter, config.Provider, messages, providerToolDefs, config.Model, llmOpts)
 		if err != nil {
 			logger.ErrorCF("toolloop", "LLM call failed",
 				map[string]any{
@@ -73,6 +84,13 @@ func RunToolLoop(
 				})
 			return nil, fmt.Errorf("LLM call failed: %w", err)
 		}
+		// Once the spending limit is reached, keep a final answer but don't
+		// run another iteration.
+		if record := ToolUsageRecorder(ctx); record != nil {
+			if err := record(config.Model, response.Usage); err != nil && len(response.ToolCalls) > 0 {
+				return nil, err
+			}
+		}
 
 		// 4. If no tool calls, we're done
 		if len(response.ToolCalls) == 0 {
@@ -85,10 +103,7 @@ func RunToolLoop(
 			break
 		}
 
-		normalizedToolCalls := make([]providers.ToolCall, 0, len(response.ToolCalls))
-		for _, tc := range response.ToolCalls {
-			normalizedToolCalls = append(normalizedToolCalls, providers.NormalizeToolCall(tc))
-		}
+		normalizedToolCalls := providers.NormalizeToolCalls(response.ToolCalls)
 
 		// 5. Log tool calls
 		toolNames := make([]string, 0, len(normalizedToolCalls))
@@ -101,6 +116,13 @@ func RunToolLoop(
 				"count":     len(normalizedToolCalls),
 				"iteration": iteration,
 			})
+		if config.OnProgress != nil {
+			status := strings.TrimSpace(response.Content)
+			if status == "" {
+				status = "Running " + strings.Join(toolNames, ", ")
+			}
+			config.OnProgress(status)
+		}
 
 		// 6. Build assistant message with tool calls
 		assistantMsg := providers.Message{
@@ -122,22 +144,23 @@ func RunToolLoop(
 		}
 		messages = append(messages, assistantMsg)
 
-		// 7. Execute tool calls in parallel
+		// 7. Execute tool calls, in parallel where the tools allow it
 		type indexedResult struct {
 			result *ToolResult
 			tc     providers.ToolCall
 		}
 
 		results := make([]indexedResult, len(normalizedToolCalls))
-		var wg sync.WaitGroup
-
 		for i, tc := range normalizedToolCalls {
 			results[i].tc = tc
+		}
 
-			wg.Add(1)
-			go func(idx int, tc providers.ToolCall) {
-				defer wg.Done()
-
+		RunCalls(len(normalizedToolCalls),
+			func(idx int) bool {
+				return config.Tools != nil && config.Tools.IsConcurrencySafe(normalizedToolCalls[idx].Name)
+			},
+			func(idx int) {
+				tc := normalizedToolCalls[idx]
 				argsJSON, _ := json.Marshal(tc.Arguments)
 				argsPreview := utils.Truncate(string(argsJSON), 200)
 				logger.InfoCF("toolloop", fmt.Sprintf("Tool call: %s(%s)", tc.Name, argsPreview),
@@ -153,9 +176,7 @@ func RunToolLoop(
 					toolResult = ErrorResult("No tools available")
 				}
 				results[idx].result = toolResult
-			}(i, tc)
-		}
-		wg.Wait()
+			})
 
 		// Append results in original order
 		for _, r := range results {
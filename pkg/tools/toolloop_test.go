@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// loopingProvider asks for a tool call on every iteration.
+type loopingProvider struct {
+	calls int
+}
+
+func (p *loopingProvider) Chat(
+	ctx context.Context,
+	messages []providers.Message,
+	tools []providers.ToolDefinition,
+	model string,
+	options map[string]any,
+) (*providers.LLMResponse, error) {
+	p.calls++
+	return &providers.LLMResponse{
+		ToolCalls: []providers.ToolCall{{ID: "call", Name: "missing", Arguments: map[string]any{}}},
+		Usage:     &providers.UsageInfo{PromptTokens: 100, CompletionTokens: 10},
+	}, nil
+}
+
+func (p *loopingProvider) GetDefaultModel() string { return "test-model" }
+
+func TestRunToolLoop_RecordsUsageAndStopsAtLimit(t *testing.T) {
+	provider := &loopingProvider{}
+	var models []string
+	limit := errors.New("spending limit reached")
+	ctx := WithToolUsageRecorder(context.Background(), func(model string, usage *providers.UsageInfo) error {
+		models = append(models, model)
+		if len(models) == 2 {
+			return limit
+		}
+		return nil
+	})
+
+	_, err := RunToolLoop(ctx, ToolLoopConfig{
+		Provider:      provider,
+		Model:         "sub-model",
+		Tools:         NewToolRegistry(),
+		MaxIterations: 10,
+	}, []providers.Message{{Role: "user", Content: "go"}}, "cli", "direct")
+	if !errors.Is(err, limit) {
+		t.Fatalf("RunToolLoop() error = %v, want the recorder's error", err)
+	}
+	if provider.calls != 2 || len(models) != 2 || models[0] != "sub-model" {
+		t.Errorf("calls = %d, recorded models = %v", provider.calls, models)
+	}
+}
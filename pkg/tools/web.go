@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -24,7 +25,7 @@ const (
 	userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
 
 	// HTTP client timeouts for web tool providers.
-	searchTimeout     = 10 * time.Second // Brave, Tavily, DuckDuckGo
+	searchTimeout     = 10 * time.Second // Brave, Tavily, DuckDuckGo, SearXNG, GLM Search
 	perplexityTimeout = 30 * time.Second // Perplexity (LLM-based, slower)
 	fetchTimeout      = 60 * time.Second // WebFetchTool
 
@@ -87,6 +88,64 @@ type SearchProvider interface {
 	Search(ctx context.Context, query string, count int) (string, error)
 }
 
+// searchResult is one hit from a search backend. Backends that return result
+// lists normalize into it so web_search output looks the same whichever
+// backend answered.
+type searchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// formatWebSearchResults renders results as numbered entries with title, URL,
+// source site and snippet. Results without a URL or with a URL already listed
+// are dropped, and at most limit entries are kept.
+func formatWebSearchResults(query, via string, results []searchResult, limit int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Results for: %s (via %s)\n", query, via)
+
+	seen := make(map[string]bool, len(results))
+	n := 0
+	for _, r := range results {
+		if n >= limit {
+			break
+		}
+		urlStr := strings.TrimSpace(r.URL)
+		if urlStr == "" || seen[urlStr] {
+			continue
+		}
+		seen[urlStr] = true
+		n++
+
+		title := strings.TrimSpace(r.Title)
+		if title == "" {
+			title = urlStr
+		}
+		fmt.Fprintf(&b, "\n[%d] %s\n    URL: %s\n", n, title, urlStr)
+		if source := searchResultSource(urlStr); source != "" {
+			fmt.Fprintf(&b, "    Source: %s\n", source)
+		}
+		if snippet := strings.TrimSpace(r.Snippet); snippet != "" {
+			fmt.Fprintf(&b, "    Snippet: %s\n", snippet)
+		}
+	}
+
+	if n == 0 {
+		return fmt.Sprintf("No results for: %s", query)
+	}
+	b.WriteString("\nCite results by their URL.")
+	return b.String()
+}
+
+// searchResultSource returns the site name shown as a result's source.
+func searchResultSource(urlStr string) string {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
 type BraveSearchProvider struct {
 	keyPool *APIKeyPool
 	proxy   string
@@ -154,24 +213,12 @@ func (p *BraveSearchProvider) Search(ctx context.Context, query string, count in
 			return "", fmt.Errorf("failed to parse response: %w", err)
 		}
 
-		results := searchResp.Web.Results
-		if len(results) == 0 {
-			return fmt.Sprintf("No results for: %s", query), nil
-		}
-
-		var lines []string
-		lines = append(lines, fmt.Sprintf("Results for: %s", query))
-		for i, item := range results {
-			if i >= count {
-				break
-			}
-			lines = append(lines, fmt.Sprintf("%d. %s\n   %s", i+1, item.Title, item.URL))
-			if item.Description != "" {
-				lines = append(lines, fmt.Sprintf("   %s", item.Description))
-			}
+		results := make([]searchResult, 0, len(searchResp.Web.Results))
+		for _, item := range searchResp.Web.Results {
+			results = append(results, searchResult{Title: item.Title, URL: item.URL, Snippet: item.Description})
 		}
 
-		return strings.Join(lines, "\n"), nil
+		return formatWebSearchResults(query, "Brave", results, count), nil
 	}
 
 	return "", fmt.Errorf("all api keys failed, last error: %w", lastErr)
@@ -259,24 +306,12 @@ func (p *TavilySearchProvider) Search(ctx context.Context, query string, count i
 			return "", fmt.Errorf("failed to parse response: %w", err)
 		}
 
-		results := searchResp.Results
-		if len(results) == 0 {
-			return fmt.Sprintf("No results for: %s", query), nil
-		}
-
-		var lines []string
-		lines = append(lines, fmt.Sprintf("Results for: %s (via Tavily)", query))
-		for i, item := range results {
-			if i >= count {
-				break
-			}
-			lines = append(lines, fmt.Sprintf("%d. %s\n   %s", i+1, item.Title, item.URL))
-			if item.Content != "" {
-				lines = append(lines, fmt.Sprintf("   %s", item.Content))
-			}
+		results := make([]searchResult, 0, len(searchResp.Results))
+		for _, item := range searchResp.Results {
+			results = append(results, searchResult{Title: item.Title, URL: item.URL, Snippet: item.Content})
 		}
 
-		return strings.Join(lines, "\n"), nil
+		return formatWebSearchResults(query, "Tavily", results, count), nil
 	}
 
 	return "", fmt.Errorf("all api keys failed, last error: %w", lastErr)
@@ -324,9 +359,6 @@ func (p *DuckDuckGoSearchProvider) extractResults(html string, count int, query
 		return fmt.Sprintf("No results found or extraction failed. Query: %s", query), nil
 	}
 
-	var lines []string
-	lines = append(lines, fmt.Sprintf("Results for: %s (via DuckDuckGo)", query))
-
 	// Pre-compile snippet regex to run inside the loop
 	// We'll search for snippets relative to the link position or just globally if needed
 	// But simple global search for snippets might mismatch order.
@@ -337,9 +369,8 @@ func (p *DuckDuckGoSearchProvider) extractResults(html string, count int, query
 	// But for now, let's grab all snippets too
 	snippetMatches := reDDGSnippet.FindAllStringSubmatch(html, count+5)
 
-	maxItems := min(len(matches), count)
-
-	for i := range maxItems {
+	results := make([]searchResult, 0, len(matches))
+	for i := range matches {
 		urlStr := matches[i][1]
 		title := stripTags(matches[i][2])
 		title = strings.TrimSpace(title)
@@ -354,19 +385,15 @@ func (p *DuckDuckGoSearchProvider) extractResults(html string, count int, query
 			}
 		}
 
-		lines = append(lines, fmt.Sprintf("%d. %s\n   %s", i+1, title, urlStr))
-
 		// Attempt to attach snippet if available and index aligns
+		var snippet string
 		if i < len(snippetMatches) {
-			snippet := stripTags(snippetMatches[i][1])
-			snippet = strings.TrimSpace(snippet)
-			if snippet != "" {
-				lines = append(lines, fmt.Sprintf("   %s", snippet))
-			}
+			snippet = stripTags(snippetMatches[i][1])
 		}
+		results = append(results, searchResult{Title: title, URL: urlStr, Snippet: snippet})
 	}
 
-	return strings.Join(lines, "\n"), nil
+	return formatWebSearchResults(query, "DuckDuckGo", results, count), nil
 }
 
 func stripTags(content string) string {
@@ -469,6 +496,8 @@ func (p *PerplexitySearchProvider) Search(ctx context.Context, query string, cou
 
 type SearXNGSearchProvider struct {
 	baseURL string
+	proxy   string
+	client  *http.Client
 }
 
 func (p *SearXNGSearchProvider) Search(ctx context.Context, query string, count int) (string, error) {
@@ -481,8 +510,7 @@ func (p *SearXNGSearchProvider) Search(ctx context.Context, query string, count
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := p.client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
 	}
@@ -506,27 +534,12 @@ func (p *SearXNGSearchProvider) Search(ctx context.Context, query string, count
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	if len(result.Results) == 0 {
-		return fmt.Sprintf("No results for: %s", query), nil
-	}
-
-	// Limit results to requested count
-	if len(result.Results) > count {
-		result.Results = result.Results[:count]
-	}
-
-	// Format results in standard PicoClaw format
-	var b strings.Builder
-	b.WriteString(fmt.Sprintf("Results for: %s (via SearXNG)\n", query))
-	for i, r := range result.Results {
-		b.WriteString(fmt.Sprintf("%d. %s\n", i+1, r.Title))
-		b.WriteString(fmt.Sprintf("   %s\n", r.URL))
-		if r.Content != "" {
-			b.WriteString(fmt.Sprintf("   %s\n", r.Content))
-		}
+	results := make([]searchResult, 0, len(result.Results))
+	for _, r := range result.Results {
+		results = append(results, searchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
 	}
 
-	return b.String(), nil
+	return formatWebSearchResults(query, "SearXNG", results, count), nil
 }
 
 type GLMSearchProvider struct {
@@ -591,31 +604,48 @@ func (p *GLMSearchProvider) Search(ctx context.Context, query string, count int)
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	results := searchResp.SearchResult
-	if len(results) == 0 {
-		return fmt.Sprintf("No results for: %s", query), nil
+	results := make([]searchResult, 0, len(searchResp.SearchResult))
+	for _, item := range searchResp.SearchResult {
+		results = append(results, searchResult{Title: item.Title, URL: item.Link, Snippet: item.Content})
 	}
 
-	var lines []string
-	lines = append(lines, fmt.Sprintf("Results for: %s (via GLM Search)", query))
-	for i, item := range results {
-		if i >= count {
-			break
-		}
-		lines = append(lines, fmt.Sprintf("%d. %s\n   %s", i+1, item.Title, item.Link))
-		if item.Content != "" {
-			lines = append(lines, fmt.Sprintf("   %s", item.Content))
-		}
-	}
-
-	return strings.Join(lines, "\n"), nil
+	return formatWebSearchResults(query, "GLM Search", results, count), nil
 }
 
 type WebSearchTool struct {
+	backends        []searchBackend
+	maxTotalResults int
+}
+
+// searchBackend is one enabled search engine, tried in preference order.
+type searchBackend struct {
+	name       string
 	provider   SearchProvider
 	maxResults int
 }
 
+// Search engine names, as used in WebSearchToolOptions.EngineOrder and the
+// tools.web config keys.
+const (
+	SearchEnginePerplexity = "perplexity"
+	SearchEngineBrave      = "brave"
+	SearchEngineSearXNG    = "searxng"
+	SearchEngineTavily     = "tavily"
+	SearchEngineDuckDuckGo = "duckduckgo"
+	SearchEngineGLMSearch  = "glm_search"
+)
+
+// defaultSearchEngineOrder is the order engines are tried in when
+// EngineOrder does not mention them. It matches config.WebSearchEngines.
+var defaultSearchEngineOrder = []string{
+	SearchEnginePerplexity,
+	SearchEngineBrave,
+	SearchEngineSearXNG,
+	SearchEngineTavily,
+	SearchEngineDuckDuckGo,
+	SearchEngineGLMSearch,
+}
+
 type WebSearchToolOptions struct {
 	BraveAPIKeys         []string
 	BraveMaxResults      int
@@ -637,64 +667,146 @@ type WebSearchToolOptions struct {
 	GLMSearchEngine      string
 	GLMSearchMaxResults  int
 	GLMSearchEnabled     bool
+	MaxTotalResults      int      // hard cap on results returned per call, whatever the backend or requested count
+	EngineOrder          []string // engines to try first, in order; the rest follow in the default order
 	Proxy                string
 }
 
+// NewWebSearchTool builds a web_search tool over every enabled engine. Engines
+// are tried in EngineOrder, then in the default order, moving on to the next
+// when one fails. It returns nil when no engine is enabled.
 func NewWebSearchTool(opts WebSearchToolOptions) (*WebSearchTool, error) {
-	var provider SearchProvider
-	maxResults := 5
-	// Priority: Perplexity > Brave > SearXNG > Tavily > DuckDuckGo > GLM Search
-	if opts.PerplexityEnabled && len(opts.PerplexityAPIKeys) > 0 {
+	order := searchEngineOrder(opts.EngineOrder)
+
+	var backends []searchBackend
+	for _, name := range order {
+		backend, err := newSearchBackend(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		if backend != nil {
+			backends = append(backends, *backend)
+		}
+	}
+	if len(backends) == 0 {
+		return nil, nil
+	}
+
+	return &WebSearchTool{
+		backends:        backends,
+		maxTotalResults: opts.MaxTotalResults,
+	}, nil
+}
+
+// searchEngineOrder puts the preferred engines first, followed by the
+// remaining ones in the default order. Unknown names are skipped with a
+// warning; config validation reports them too.
+func searchEngineOrder(preferred []string) []string {
+	order := make([]string, 0, len(defaultSearchEngineOrder))
+	seen := make(map[string]bool, len(defaultSearchEngineOrder))
+	for _, name := range preferred {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		if !slices.Contains(defaultSearchEngineOrder, name) {
+			logger.WarnCF("tool", "Ignoring unknown search engine in engine_order", map[string]any{
+				"engine": name,
+				"known":  strings.Join(defaultSearchEngineOrder, ", "),
+			})
+			continue
+		}
+		seen[name] = true
+		order = append(order, name)
+	}
+	for _, name := range defaultSearchEngineOrder {
+		if !seen[name] {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// newSearchBackend returns the named engine, or nil when it is disabled or
+// lacks the credentials it needs.
+func newSearchBackend(name string, opts WebSearchToolOptions) (*searchBackend, error) {
+	backend := &searchBackend{name: name, maxResults: 5}
+	withMax := func(maxResults int) *searchBackend {
+		if maxResults > 0 {
+			backend.maxResults = maxResults
+		}
+		return backend
+	}
+
+	switch name {
+	case SearchEnginePerplexity:
+		if !opts.PerplexityEnabled || len(opts.PerplexityAPIKeys) == 0 {
+			return nil, nil
+		}
 		client, err := utils.CreateHTTPClient(opts.Proxy, perplexityTimeout)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create HTTP client for Perplexity: %w", err)
 		}
-		provider = &PerplexitySearchProvider{
+		backend.provider = &PerplexitySearchProvider{
 			keyPool: NewAPIKeyPool(opts.PerplexityAPIKeys),
 			proxy:   opts.Proxy,
 			client:  client,
 		}
-		if opts.PerplexityMaxResults > 0 {
-			maxResults = opts.PerplexityMaxResults
+		return withMax(opts.PerplexityMaxResults), nil
+
+	case SearchEngineBrave:
+		if !opts.BraveEnabled || len(opts.BraveAPIKeys) == 0 {
+			return nil, nil
 		}
-	} else if opts.BraveEnabled && len(opts.BraveAPIKeys) > 0 {
 		client, err := utils.CreateHTTPClient(opts.Proxy, searchTimeout)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create HTTP client for Brave: %w", err)
 		}
-		provider = &BraveSearchProvider{keyPool: NewAPIKeyPool(opts.BraveAPIKeys), proxy: opts.Proxy, client: client}
-		if opts.BraveMaxResults > 0 {
-			maxResults = opts.BraveMaxResults
+		backend.provider = &BraveSearchProvider{keyPool: NewAPIKeyPool(opts.BraveAPIKeys), proxy: opts.Proxy, client: client}
+		return withMax(opts.BraveMaxResults), nil
+
+	case SearchEngineSearXNG:
+		if !opts.SearXNGEnabled || opts.SearXNGBaseURL == "" {
+			return nil, nil
+		}
+		client, err := utils.CreateHTTPClient(opts.Proxy, searchTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP client for SearXNG: %w", err)
 		}
-	} else if opts.SearXNGEnabled && opts.SearXNGBaseURL != "" {
-		provider = &SearXNGSearchProvider{baseURL: opts.SearXNGBaseURL}
-		if opts.SearXNGMaxResults > 0 {
-			maxResults = opts.SearXNGMaxResults
+		backend.provider = &SearXNGSearchProvider{baseURL: opts.SearXNGBaseURL, proxy: opts.Proxy, client: client}
+		return withMax(opts.SearXNGMaxResults), nil
+
+	case SearchEngineTavily:
+		if !opts.TavilyEnabled || len(opts.TavilyAPIKeys) == 0 {
+			return nil, nil
 		}
-	} else if opts.TavilyEnabled && len(opts.TavilyAPIKeys) > 0 {
 		client, err := utils.CreateHTTPClient(opts.Proxy, searchTimeout)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create HTTP client for Tavily: %w", err)
 		}
-		provider = &TavilySearchProvider{
+		backend.provider = &TavilySearchProvider{
 			keyPool: NewAPIKeyPool(opts.TavilyAPIKeys),
 			baseURL: opts.TavilyBaseURL,
 			proxy:   opts.Proxy,
 			client:  client,
 		}
-		if opts.TavilyMaxResults > 0 {
-			maxResults = opts.TavilyMaxResults
+		return withMax(opts.TavilyMaxResults), nil
+
+	case SearchEngineDuckDuckGo:
+		if !opts.DuckDuckGoEnabled {
+			return nil, nil
 		}
-	} else if opts.DuckDuckGoEnabled {
 		client, err := utils.CreateHTTPClient(opts.Proxy, searchTimeout)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create HTTP client for DuckDuckGo: %w", err)
 		}
-		provider = &DuckDuckGoSearchProvider{proxy: opts.Proxy, client: client}
-		if opts.DuckDuckGoMaxResults > 0 {
-			maxResults = opts.DuckDuckGoMaxResults
+		backend.provider = &DuckDuckGoSearchProvider{proxy: opts.Proxy, client: client}
+		return withMax(opts.DuckDuckGoMaxResults), nil
+
+	case SearchEngineGLMSearch:
+		if !opts.GLMSearchEnabled || opts.GLMSearchAPIKey == "" {
+			return nil, nil
 		}
-	} else if opts.GLMSearchEnabled && opts.GLMSearchAPIKey != "" {
 		client, err := utils.CreateHTTPClient(opts.Proxy, searchTimeout)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create HTTP client for GLM Search: %w", err)
@@ -703,30 +815,30 @@ func NewWebSearchTool(opts WebSearchToolOptions) (*WebSearchTool, error) {
 		if searchEngine == "" {
 			searchEngine = "search_std"
 		}
-		provider = &GLMSearchProvider{
+		backend.provider = &GLMSearchProvider{
 			apiKey:       opts.GLMSearchAPIKey,
 			baseURL:      opts.GLMSearchBaseURL,
 			searchEngine: searchEngine,
 			proxy:        opts.Proxy,
 			client:       client,
 		}
-		if opts.GLMSearchMaxResults > 0 {
-			maxResults = opts.GLMSearchMaxResults
-		}
-	} else {
-		return nil, nil
+		return withMax(opts.GLMSearchMaxResults), nil
 	}
-
-	return &WebSearchTool{
-		provider:   provider,
-		maxResults: maxResults,
-	}, nil
+	return nil, nil
 }
 
 func (t *WebSearchTool) Name() string {
 	return "web_search"
 }
 
+func (t *WebSearchTool) ConcurrencySafe() bool {
+	return true
+}
+
+func (t *WebSearchTool) Pure() bool {
+	return true
+}
+
 func (t *WebSearchTool) Description() string {
 	return "Search the web for current information. Returns titles, URLs, and snippets from search results."
 }
@@ -756,22 +868,56 @@ func (t *WebSearchTool) Execute(ctx context.Context, args map[string]any) *ToolR
 		return ErrorResult("query is required")
 	}
 
-	count := t.maxResults
+	requested := 0
 	if c, ok := args["count"].(float64); ok {
 		if int(c) > 0 && int(c) <= 10 {
-			count = int(c)
+			requested = int(c)
 		}
 	}
 
-	result, err := t.provider.Search(ctx, query, count)
-	if err != nil {
-		return ErrorResult(fmt.Sprintf("search failed: %v", err))
+	var failures []string
+	for _, backend := range t.backends {
+		count := backend.maxResults
+		if requested > 0 {
+			count = requested
+		}
+		if t.maxTotalResults > 0 {
+			count = min(count, t.maxTotalResults)
+		}
+
+		result, err := backend.provider.Search(ctx, query, count)
+		if err == nil {
+			return &ToolResult{
+				ForLLM:  result,
+				ForUser: result,
+			}
+		}
+		if ctx.Err() != nil {
+			return ErrorResult(fmt.Sprintf("search failed: %v", err)).WithError(err)
+		}
+		logger.WarnCF("tool", "Search engine failed, trying the next one", map[string]any{
+			"engine": backend.name,
+			"error":  err.Error(),
+		})
+		failures = append(failures, fmt.Sprintf("%s: %v", backend.name, err))
 	}
 
-	return &ToolResult{
-		ForLLM:  result,
-		ForUser: result,
+	return NewToolResult(noSearchBackendMessage(failures))
+}
+
+// noSearchBackendMessage tells the model search is unavailable, so it can say
+// so instead of retrying or reporting an opaque failure.
+func noSearchBackendMessage(failures []string) string {
+	var sb strings.Builder
+	sb.WriteString("No search backend is available right now, so the web could not be searched.")
+	if len(failures) > 0 {
+		fmt.Fprintf(&sb, " Every configured engine failed (%s).", strings.Join(failures, "; "))
+	} else {
+		sb.WriteString(" No search engine is configured.")
 	}
+	sb.WriteString(" Do not retry the search in this turn. Tell the user you cannot search the web at the moment " +
+		"and answer from what you already know, noting that it may be out of date.")
+	return sb.String()
 }
 
 type WebFetchTool struct {
@@ -858,6 +1004,14 @@ func (t *WebFetchTool) Name() string {
 	return "web_fetch"
 }
 
+func (t *WebFetchTool) ConcurrencySafe() bool {
+	return true
+}
+
+func (t *WebFetchTool) Pure() bool {
+	return true
+}
+
 func (t *WebFetchTool) Description() string {
 	return "Fetch a URL and extract readable content (HTML to text). Use this to get weather info, news, articles, or any web content."
 }
@@ -886,42 +1040,82 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 		return ErrorResult("url is required")
 	}
 
+	maxChars := t.maxChars
+	if mc, ok := args["maxChars"].(float64); ok {
+		if int(mc) > 100 {
+			maxChars = int(mc)
+		}
+	}
+
+	page, err := t.fetch(ctx, urlStr, maxChars)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	result := map[string]any{
+		"url":       page.URL,
+		"status":    page.Status,
+		"extractor": page.Extractor,
+		"truncated": page.Truncated,
+		"length":    len(page.Text),
+		"text":      page.Text,
+	}
+
+	resultJSON, _ := json.MarshalIndent(result, "", "  ")
+
+	return &ToolResult{
+		ForLLM: string(resultJSON),
+		ForUser: fmt.Sprintf(
+			"Fetched %d bytes from %s (extractor: %s, truncated: %v)",
+			len(page.Text),
+			page.URL,
+			page.Extractor,
+			page.Truncated,
+		),
+	}
+}
+
+// fetchedPage is the extracted content of a URL fetched by WebFetchTool.
+type fetchedPage struct {
+	URL       string
+	Status    int
+	Extractor string
+	Truncated bool
+	Text      string
+}
+
+// download performs a GET for urlStr through the SSRF-guarded client and
+// returns the body, enforcing the configured size limit.
+func (t *WebFetchTool) download(ctx context.Context, urlStr string) (*http.Response, []byte, error) {
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
-		return ErrorResult(fmt.Sprintf("invalid URL: %v", err))
+		return nil, nil, fmt.Errorf("invalid URL: %v", err)
 	}
 
 	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return ErrorResult("only http/https URLs are allowed")
+		return nil, nil, errors.New("only http/https URLs are allowed")
 	}
 
 	if parsedURL.Host == "" {
-		return ErrorResult("missing domain in URL")
+		return nil, nil, errors.New("missing domain in URL")
 	}
 
 	// Lightweight pre-flight: block obvious localhost/literal-IP without DNS resolution.
 	// The real SSRF guard is newSafeDialContext at connect time.
 	hostname := parsedURL.Hostname()
 	if isObviousPrivateHost(hostname, t.whitelist) {
-		return ErrorResult("fetching private or local network hosts is not allowed")
-	}
-
-	maxChars := t.maxChars
-	if mc, ok := args["maxChars"].(float64); ok {
-		if int(mc) > 100 {
-			maxChars = int(mc)
-		}
+		return nil, nil, errors.New("fetching private or local network hosts is not allowed")
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
-		return ErrorResult(fmt.Sprintf("failed to create request: %v", err))
+		return nil, nil, fmt.Errorf("failed to create request: %v", err)
 	}
 
 	req.Header.Set("User-Agent", userAgent)
 	resp, err := t.client.Do(req)
 	if err != nil {
-		return ErrorResult(fmt.Sprintf("request failed: %v", err))
+		return nil, nil, fmt.Errorf("request failed: %v", err)
 	}
 
 	resp.Body = http.MaxBytesReader(nil, resp.Body, t.fetchLimitBytes)
@@ -932,9 +1126,19 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 	if err != nil {
 		var maxBytesErr *http.MaxBytesError
 		if errors.As(err, &maxBytesErr) {
-			return ErrorResult(fmt.Sprintf("failed to read response: size exceeded %d bytes limit", t.fetchLimitBytes))
+			return nil, nil, fmt.Errorf("failed to read response: size exceeded %d bytes limit", t.fetchLimitBytes)
 		}
-		return ErrorResult(fmt.Sprintf("failed to read response: %v", err))
+		return nil, nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	return resp, body, nil
+}
+
+// fetch downloads urlStr and extracts readable text capped at maxChars.
+func (t *WebFetchTool) fetch(ctx context.Context, urlStr string, maxChars int) (*fetchedPage, error) {
+	resp, body, err := t.download(ctx, urlStr)
+	if err != nil {
+		return nil, err
 	}
 
 	bodyStr := string(body)
@@ -988,7 +1192,7 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 			var err error
 			text, err = utils.HtmlToMarkdown(bodyStr)
 			if err != nil {
-				return ErrorResult(fmt.Sprintf("failed to HTML to markdown: %v", err))
+				return nil, fmt.Errorf("failed to HTML to markdown: %v", err)
 			}
 			extractor = "markdown"
 
@@ -1007,27 +1211,13 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]any) *ToolRe
 		text = text[:maxChars]
 	}
 
-	result := map[string]any{
-		"url":       urlStr,
-		"status":    resp.StatusCode,
-		"extractor": extractor,
-		"truncated": truncated,
-		"length":    len(text),
-		"text":      text,
-	}
-
-	resultJSON, _ := json.MarshalIndent(result, "", "  ")
-
-	return &ToolResult{
-		ForLLM: string(resultJSON),
-		ForUser: fmt.Sprintf(
-			"Fetched %d bytes from %s (extractor: %s, truncated: %v)",
-			len(text),
-			urlStr,
-			extractor,
-			truncated,
-		),
-	}
+	return &fetchedPage{
+		URL:       urlStr,
+		Status:    resp.StatusCode,
+		Extractor: extractor,
+		Truncated: truncated,
+		Text:      text,
+	}, nil
 }
 
 func looksLikeHTML(body string) bool {
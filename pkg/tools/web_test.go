@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/logger"
 )
 
@@ -821,9 +824,9 @@ func TestNewWebSearchTool_PropagatesProxy(t *testing.T) {
 		if err != nil {
 			t.Fatalf("NewWebSearchTool() error: %v", err)
 		}
-		p, ok := tool.provider.(*PerplexitySearchProvider)
+		p, ok := tool.backends[0].provider.(*PerplexitySearchProvider)
 		if !ok {
-			t.Fatalf("provider type = %T, want *PerplexitySearchProvider", tool.provider)
+			t.Fatalf("provider type = %T, want *PerplexitySearchProvider", tool.backends[0].provider)
 		}
 		if p.proxy != "http://127.0.0.1:7890" {
 			t.Fatalf("provider proxy = %q, want %q", p.proxy, "http://127.0.0.1:7890")
@@ -840,9 +843,9 @@ func TestNewWebSearchTool_PropagatesProxy(t *testing.T) {
 		if err != nil {
 			t.Fatalf("NewWebSearchTool() error: %v", err)
 		}
-		p, ok := tool.provider.(*BraveSearchProvider)
+		p, ok := tool.backends[0].provider.(*BraveSearchProvider)
 		if !ok {
-			t.Fatalf("provider type = %T, want *BraveSearchProvider", tool.provider)
+			t.Fatalf("provider type = %T, want *BraveSearchProvider", tool.backends[0].provider)
 		}
 		if p.proxy != "http://127.0.0.1:7890" {
 			t.Fatalf("provider proxy = %q, want %q", p.proxy, "http://127.0.0.1:7890")
@@ -858,9 +861,27 @@ func TestNewWebSearchTool_PropagatesProxy(t *testing.T) {
 		if err != nil {
 			t.Fatalf("NewWebSearchTool() error: %v", err)
 		}
-		p, ok := tool.provider.(*DuckDuckGoSearchProvider)
+		p, ok := tool.backends[0].provider.(*DuckDuckGoSearchProvider)
 		if !ok {
-			t.Fatalf("provider type = %T, want *DuckDuckGoSearchProvider", tool.provider)
+			t.Fatalf("provider type = %T, want *DuckDuckGoSearchProvider", tool.backends[0].provider)
+		}
+		if p.proxy != "http://127.0.0.1:7890" {
+			t.Fatalf("provider proxy = %q, want %q", p.proxy, "http://127.0.0.1:7890")
+		}
+	})
+
+	t.Run("searxng", func(t *testing.T) {
+		tool, err := NewWebSearchTool(WebSearchToolOptions{
+			SearXNGEnabled: true,
+			SearXNGBaseURL: "https://searx.example.com",
+			Proxy:          "http://127.0.0.1:7890",
+		})
+		if err != nil {
+			t.Fatalf("NewWebSearchTool() error: %v", err)
+		}
+		p, ok := tool.backends[0].provider.(*SearXNGSearchProvider)
+		if !ok {
+			t.Fatalf("provider type = %T, want *SearXNGSearchProvider", tool.backends[0].provider)
 		}
 		if p.proxy != "http://127.0.0.1:7890" {
 			t.Fatalf("provider proxy = %q, want %q", p.proxy, "http://127.0.0.1:7890")
@@ -997,6 +1018,67 @@ func TestAPIKeyPool(t *testing.T) {
 	}
 }
 
+func TestWebTool_WebSearch_MaxTotalResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"results": []map[string]any{
+				{"title": "Result 1", "url": "https://example.com/1", "content": "one"},
+				{"title": "Result 2", "url": "https://example.com/2", "content": "two"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	tool, err := NewWebSearchTool(WebSearchToolOptions{
+		TavilyEnabled:    true,
+		TavilyAPIKeys:    []string{"test-key"},
+		TavilyBaseURL:    server.URL,
+		TavilyMaxResults: 5,
+		MaxTotalResults:  1,
+	})
+	if err != nil {
+		t.Fatalf("NewWebSearchTool() error: %v", err)
+	}
+
+	result := tool.Execute(context.Background(), map[string]any{"query": "test", "count": 5.0})
+	if result.IsError {
+		t.Fatalf("Expected success, got: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "Result 1") || strings.Contains(result.ForLLM, "Result 2") {
+		t.Errorf("Expected only the first result, got: %s", result.ForLLM)
+	}
+}
+
+func TestFormatWebSearchResults(t *testing.T) {
+	out := formatWebSearchResults("golang", "Brave", []searchResult{
+		{Title: "Go", URL: "https://www.go.dev/doc", Snippet: "  The Go docs  "},
+		{Title: "Duplicate", URL: "https://www.go.dev/doc"},
+		{Title: "No URL"},
+		{URL: "https://pkg.go.dev/"},
+		{Title: "Over limit", URL: "https://example.com/"},
+	}, 2)
+
+	for _, want := range []string{
+		"Results for: golang (via Brave)",
+		"[1] Go\n    URL: https://www.go.dev/doc\n    Source: go.dev\n    Snippet: The Go docs\n",
+		"[2] https://pkg.go.dev/\n    URL: https://pkg.go.dev/\n    Source: pkg.go.dev\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"Duplicate", "No URL", "Over limit", "[3]"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("did not expect %q in output, got:\n%s", unwanted, out)
+		}
+	}
+
+	if got := formatWebSearchResults("nothing", "Brave", nil, 5); got != "No results for: nothing" {
+		t.Errorf("unexpected empty output: %q", got)
+	}
+}
+
 func TestWebTool_TavilySearch_Failover(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var payload map[string]any
@@ -1058,6 +1140,76 @@ func TestWebTool_TavilySearch_Failover(t *testing.T) {
 	}
 }
 
+func TestWebTool_SearXNG_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" {
+			t.Errorf("Expected path /search, got %s", r.URL.Path)
+		}
+		if q := r.URL.Query(); q.Get("q") != "test query" || q.Get("format") != "json" {
+			t.Errorf("Unexpected query string: %s", r.URL.RawQuery)
+		}
+
+		results := make([]map[string]any, 0, 5)
+		for i := 1; i <= 5; i++ {
+			results = append(results, map[string]any{
+				"title":   fmt.Sprintf("SearXNG Result %d", i),
+				"url":     fmt.Sprintf("https://example.com/%d", i),
+				"content": fmt.Sprintf("Snippet %d", i),
+				"engine":  "duckduckgo",
+				"score":   float64(6 - i),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"query":             "test query",
+			"number_of_results": 5,
+			"results":           results,
+		})
+	}))
+	defer server.Close()
+
+	tool, err := NewWebSearchTool(WebSearchToolOptions{
+		SearXNGEnabled:    true,
+		SearXNGBaseURL:    server.URL + "/",
+		SearXNGMaxResults: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewWebSearchTool() error: %v", err)
+	}
+
+	result := tool.Execute(context.Background(), map[string]any{"query": "test query"})
+	if result.IsError {
+		t.Fatalf("Expected success, got IsError=true: %s", result.ForLLM)
+	}
+	for _, want := range []string{
+		"(via SearXNG)",
+		"[1] SearXNG Result 1",
+		"URL: https://example.com/1",
+		"Snippet: Snippet 1",
+		"[3] SearXNG Result 3",
+	} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("Expected %q in output, got: %s", want, result.ForLLM)
+		}
+	}
+	if strings.Contains(result.ForLLM, "SearXNG Result 4") {
+		t.Errorf("Expected results truncated to max_results 3, got: %s", result.ForLLM)
+	}
+}
+
+func TestWebTool_SearXNG_StatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	provider := &SearXNGSearchProvider{baseURL: server.URL, client: server.Client()}
+	_, err := provider.Search(context.Background(), "test query", 5)
+	if err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("Expected status 403 error, got %v", err)
+	}
+}
+
 func TestWebTool_GLMSearch_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "POST" {
@@ -1147,11 +1299,15 @@ func TestWebTool_GLMSearch_APIError(t *testing.T) {
 		"query": "test query",
 	})
 
-	if !result.IsError {
-		t.Errorf("Expected IsError=true for 401 response")
+	// With no other engine to fall back to, the model is told search is unavailable.
+	if result.IsError {
+		t.Errorf("Expected a graceful result for 401 response, got error: %s", result.ForLLM)
+	}
+	if !strings.Contains(result.ForLLM, "No search backend is available") {
+		t.Errorf("Expected no-backend message, got: %s", result.ForLLM)
 	}
 	if !strings.Contains(result.ForLLM, "status 401") {
-		t.Errorf("Expected status 401 in error, got: %s", result.ForLLM)
+		t.Errorf("Expected status 401 in message, got: %s", result.ForLLM)
 	}
 }
 
@@ -1170,8 +1326,8 @@ func TestWebTool_GLMSearch_Priority(t *testing.T) {
 	}
 
 	// DuckDuckGo should win over GLM Search
-	if _, ok := tool.provider.(*DuckDuckGoSearchProvider); !ok {
-		t.Errorf("Expected DuckDuckGoSearchProvider when both enabled, got %T", tool.provider)
+	if _, ok := tool.backends[0].provider.(*DuckDuckGoSearchProvider); !ok {
+		t.Errorf("Expected DuckDuckGoSearchProvider when both enabled, got %T", tool.backends[0].provider)
 	}
 
 	// With DuckDuckGo disabled, GLM Search should be selected
@@ -1185,7 +1341,101 @@ func TestWebTool_GLMSearch_Priority(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewWebSearchTool() error: %v", err)
 	}
-	if _, ok := tool2.provider.(*GLMSearchProvider); !ok {
-		t.Errorf("Expected GLMSearchProvider when only GLM enabled, got %T", tool2.provider)
+	if _, ok := tool2.backends[0].provider.(*GLMSearchProvider); !ok {
+		t.Errorf("Expected GLMSearchProvider when only GLM enabled, got %T", tool2.backends[0].provider)
+	}
+}
+
+func TestNewWebSearchTool_EngineOrder(t *testing.T) {
+	opts := WebSearchToolOptions{
+		BraveEnabled:      true,
+		BraveAPIKeys:      []string{"k"},
+		DuckDuckGoEnabled: true,
+		GLMSearchEnabled:  true,
+		GLMSearchAPIKey:   "test-key",
+		EngineOrder:       []string{"GLM_Search", "duckduckgo", "glm_search"},
+	}
+	tool, err := NewWebSearchTool(opts)
+	if err != nil {
+		t.Fatalf("NewWebSearchTool() error: %v", err)
 	}
+
+	var got []string
+	for _, backend := range tool.backends {
+		got = append(got, backend.name)
+	}
+	// Preferred engines first, the rest in the default order; disabled ones are skipped.
+	want := []string{SearchEngineGLMSearch, SearchEngineDuckDuckGo, SearchEngineBrave}
+	if !slices.Equal(got, want) {
+		t.Errorf("engine order = %v, want %v", got, want)
+	}
+
+	// An unknown name is skipped rather than disabling search.
+	opts.EngineOrder = []string{"bing", "duckduckgo"}
+	tool, err = NewWebSearchTool(opts)
+	if err != nil || tool == nil {
+		t.Fatalf("NewWebSearchTool() with an unknown engine = %v, %v, want a tool", tool, err)
+	}
+	if name := tool.backends[0].name; name != SearchEngineDuckDuckGo {
+		t.Errorf("first engine = %q, want duckduckgo", name)
+	}
+
+	if !slices.Equal(defaultSearchEngineOrder, config.WebSearchEngines) {
+		t.Errorf("defaultSearchEngineOrder = %v, want config.WebSearchEngines %v",
+			defaultSearchEngineOrder, config.WebSearchEngines)
+	}
+}
+
+type stubSearchProvider struct {
+	result string
+	err    error
+	calls  int
+}
+
+func (p *stubSearchProvider) Search(ctx context.Context, query string, count int) (string, error) {
+	p.calls++
+	return p.result, p.err
+}
+
+func TestWebSearchTool_FallsBackToNextEngine(t *testing.T) {
+	failing := &stubSearchProvider{err: errors.New("connection refused")}
+	working := &stubSearchProvider{result: "Results for: picoclaw"}
+	tool := &WebSearchTool{backends: []searchBackend{
+		{name: SearchEngineBrave, provider: failing, maxResults: 5},
+		{name: SearchEngineDuckDuckGo, provider: working, maxResults: 5},
+	}}
+
+	result := tool.Execute(context.Background(), map[string]any{"query": "picoclaw"})
+	if result.IsError || result.ForLLM != "Results for: picoclaw" {
+		t.Fatalf("expected results from the second engine, got %+v", result)
+	}
+	if failing.calls != 1 || working.calls != 1 {
+		t.Errorf("calls = %d/%d, want 1/1", failing.calls, working.calls)
+	}
+}
+
+func TestWebSearchTool_NoBackendAvailable(t *testing.T) {
+	t.Run("all engines fail", func(t *testing.T) {
+		tool := &WebSearchTool{backends: []searchBackend{
+			{name: SearchEngineBrave, provider: &stubSearchProvider{err: errors.New("status 503")}, maxResults: 5},
+			{name: SearchEngineDuckDuckGo, provider: &stubSearchProvider{err: errors.New("timeout")}, maxResults: 5},
+		}}
+
+		result := tool.Execute(context.Background(), map[string]any{"query": "picoclaw"})
+		if result.IsError {
+			t.Fatalf("expected a graceful result, got error: %s", result.ForLLM)
+		}
+		for _, want := range []string{"No search backend is available", "brave: status 503", "duckduckgo: timeout"} {
+			if !strings.Contains(result.ForLLM, want) {
+				t.Errorf("ForLLM missing %q: %s", want, result.ForLLM)
+			}
+		}
+	})
+
+	t.Run("no engines", func(t *testing.T) {
+		result := (&WebSearchTool{}).Execute(context.Background(), map[string]any{"query": "picoclaw"})
+		if result.IsError || !strings.Contains(result.ForLLM, "No search engine is configured") {
+			t.Errorf("expected no-engine message, got %+v", result)
+		}
+	})
 }
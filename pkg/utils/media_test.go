@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestInferMediaType(t *testing.T) {
+	tests := []struct {
+		filename    string
+		contentType string
+		want        string
+	}{
+		{"photo.jpg", "", "image"},
+		{"blob", "image/png", "image"},
+		{"voice.ogg", "", "audio"},
+		{"blob", "application/ogg", "audio"},
+		{"clip.MOV", "", "video"},
+		{"report.pdf", "application/pdf", "file"},
+		{"", "", "file"},
+	}
+	for _, tt := range tests {
+		if got := InferMediaType(tt.filename, tt.contentType); got != tt.want {
+			t.Errorf("InferMediaType(%q, %q) = %q, want %q", tt.filename, tt.contentType, got, tt.want)
+		}
+	}
+}
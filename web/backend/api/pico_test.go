@@ -90,7 +90,8 @@ func TestEnsurePicoChannel_NoOriginWithoutCaller(t *testing.T) {
 	}
 
 	// Without a caller origin, allow_origins stays empty (CheckOrigin
-	// allows all when the list is empty, so the channel still works).
+	// allows same-origin pages when the list is empty, so the proxied
+	// channel still works).
 	if len(cfg.Channels.Pico.AllowOrigins) != 0 {
 		t.Errorf("allow_origins = %v, want empty when no caller origin", cfg.Channels.Pico.AllowOrigins)
 	}